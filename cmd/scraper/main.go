@@ -1,16 +1,22 @@
 package main
 
 import (
+	"bufio"
 	"context"
 	"flag"
+	"fmt"
 	"log"
 	"os"
 	"os/signal"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
 	"farm-search/internal/db"
+	"farm-search/internal/events"
+	"farm-search/internal/models"
 	"farm-search/internal/scraper"
 )
 
@@ -20,16 +26,34 @@ func main() {
 	maxPages := flag.Int("pages", 0, "Maximum pages to scrape (0 = all pages)")
 	workers := flag.Int("workers", 3, "Number of concurrent workers")
 	delay := flag.Duration("delay", 2*time.Second, "Delay between requests")
-	source := flag.String("source", "farmproperty", "Source to scrape: farmproperty, farmbuy, rea, domain, domain-web, or all")
+	source := flag.String("source", "farmproperty", "Source to scrape: farmproperty, farmbuy, rea, domain, domain-web, elders, raywhite-rural, nutrien, agencies (all three agency sites), gumtree, or all")
 	geocode := flag.Bool("geocode", false, "Enable geocoding for properties without coordinates")
 	useBrowser := flag.Bool("browser", false, "Use headless browser (only needed for REA)")
 	headless := flag.Bool("headless", true, "Run browser in headless mode (set false to see browser)")
+	browserSessions := flag.Int("browser-sessions", scraper.DefaultBrowserSessions, "Number of browser tabs kept warm and reused across pages")
+	browserRecycle := flag.Int("browser-recycle", scraper.DefaultBrowserRecycleAfter, "Replace a browser tab after this many pages")
 	cookieFile := flag.String("cookies", "", "Path to JSON file with cookies for REA (export from browser)")
 	userDataDir := flag.String("profile", "", "Path to Chrome user data directory (use existing browser session)")
+	cdpURL := flag.String("cdp-url", "", "Connect to a running Chrome via DevTools URL (ws://... or http://host:9222) instead of launching one")
 	scrapingBeeKey := flag.String("scrapingbee", "", "ScrapingBee API key for bypassing bot protection (REA)")
+	flareSolverURL := flag.String("flaresolverr", "", "FlareSolverr-compatible service URL for REA, used when no ScrapingBee key or browser (e.g. http://localhost:8191)")
 	domainAPIKey := flag.String("domain-api-key", "", "Domain.com.au API key for their official API")
 	domainWebURL := flag.String("domain-web-url", "", "Custom URL for domain-web scraper (with all filters applied)")
 	fullRefresh := flag.Bool("full-refresh", false, "Continue scraping all pages even if properties already exist (full refresh)")
+	searchProfile := flag.String("search-profile", "farm", "Search profile: farm (10+ HA) or lifestyle (1-4 HA)")
+	minLandHa := flag.Float64("min-land-ha", -1, "Override the search profile's minimum land size in hectares (0 = no minimum)")
+	maxLandHa := flag.Float64("max-land-ha", -1, "Override the search profile's maximum land size in hectares (0 = no maximum)")
+	minPrice := flag.Int64("min-price", -1, "Override the search profile's minimum price in dollars (0 = no minimum)")
+	maxPrice := flag.Int64("max-price", -1, "Override the search profile's maximum price in dollars (0 = no maximum)")
+	regionsFile := flag.String("regions", "", "Path to JSON file with per-source region targets (overrides defaults)")
+	listingType := flag.String("listing-type", scraper.ListingTypeBuy, "Listings to scrape: buy, or rent (REA/Domain rentals, saved to the rentals table)")
+	captureFixtures := flag.Bool("capture-fixtures", false, "Save fetched pages (contact details redacted) as parser fixtures")
+	fixturesDir := flag.String("fixtures-dir", scraper.DefaultFixtureDir, "Directory for captured fixtures (with -capture-fixtures)")
+	refreshIDs := flag.String("refresh-ids", "", "Refetch these saved properties (comma-separated IDs) from their listing pages instead of searching")
+	refreshURL := flag.String("refresh-url", "", "Refetch the saved listing with this URL instead of searching")
+	dryRun := flag.Bool("dry-run", false, "Search and parse listings but save nothing; print new/updated/unchanged per source and proxy credits used")
+	output := flag.String("output", scraper.OutputDB, "Where listings go: db, ndjson (the -o file only, for tools import-ndjson elsewhere), or both")
+	outputFile := flag.String("o", "-", "NDJSON file for -output ndjson or both (- for stdout)")
 	flag.Parse()
 
 	// Also check environment variables for API keys
@@ -39,6 +63,9 @@ func main() {
 	if *domainAPIKey == "" {
 		*domainAPIKey = os.Getenv("DOMAIN_API_KEY")
 	}
+	if *flareSolverURL == "" {
+		*flareSolverURL = os.Getenv("FLARESOLVERR_URL")
+	}
 
 	// Determine database path
 	if *dbPath == "" {
@@ -56,8 +83,46 @@ func main() {
 	}
 	defer database.Close()
 
+	// Resolve search profile and apply any overrides
+	profile, err := scraper.SearchProfileByName(*searchProfile)
+	if err != nil {
+		log.Fatalf("Invalid search profile: %v", err)
+	}
+	if *minLandHa >= 0 {
+		profile.MinLandSqm = *minLandHa * 10000
+	}
+	if *maxLandHa >= 0 {
+		profile.MaxLandSqm = *maxLandHa * 10000
+	}
+	if *minPrice >= 0 {
+		profile.MinPrice = *minPrice
+	}
+	if *maxPrice >= 0 {
+		profile.MaxPrice = *maxPrice
+	}
+	switch *listingType {
+	case scraper.ListingTypeBuy, scraper.ListingTypeRent:
+		profile.ListingType = *listingType
+	default:
+		log.Fatalf("Invalid listing type %q (expected buy or rent)", *listingType)
+	}
+
+	// Load and validate per-source region targets
+	regions := scraper.DefaultRegionTargets()
+	if *regionsFile != "" {
+		regions, err = scraper.LoadRegionTargets(*regionsFile)
+		if err != nil {
+			log.Fatalf("Failed to load region targets: %v", err)
+		}
+	}
+	if err := regions.Validate(); err != nil {
+		log.Fatalf("%v", err)
+	}
+
 	// Configure scraper
 	config := scraper.DefaultConfig()
+	config.Profile = profile
+	config.Regions = regions
 	config.MaxPages = *maxPages
 	config.Workers = *workers
 	config.DelayBetween = *delay
@@ -65,11 +130,57 @@ func main() {
 	config.SkipGeocode = !*geocode
 	config.UseBrowser = *useBrowser
 	config.Headless = *headless
+	config.BrowserTabs = *browserSessions
+	config.TabPageLimit = *browserRecycle
 	config.CookieFile = *cookieFile
 	config.ScrapingBeeKey = *scrapingBeeKey
+	config.FlareSolverURL = *flareSolverURL
 	config.DomainAPIKey = *domainAPIKey
 	config.DomainWebURL = *domainWebURL
 	config.FullRefresh = *fullRefresh
+	config.CDPURL = *cdpURL
+	if *cdpURL != "" && !*useBrowser {
+		log.Println("Warning: -cdp-url flag requires -browser flag, enabling browser mode")
+		config.UseBrowser = true
+	}
+	if *captureFixtures {
+		config.FixtureDir = *fixturesDir
+	}
+	config.DryRun = *dryRun
+	if *dryRun {
+		log.Println("Dry run: listings will be fetched and parsed but not saved")
+	}
+	config.Output = *output
+	switch *output {
+	case scraper.OutputDB:
+	case scraper.OutputNDJSON, scraper.OutputBoth:
+		ndjson := os.Stdout
+		if *outputFile != "-" {
+			ndjson, err = os.Create(*outputFile)
+			if err != nil {
+				log.Fatalf("Failed to create NDJSON file: %v", err)
+			}
+		}
+		w := bufio.NewWriter(ndjson)
+		defer func() {
+			if err := w.Flush(); err != nil {
+				log.Printf("Failed to write NDJSON file: %v", err)
+			}
+			if ndjson != os.Stdout {
+				ndjson.Close()
+			}
+		}()
+		config.NDJSON = w
+		if *output == scraper.OutputNDJSON {
+			log.Printf("Writing listings as NDJSON to %s, not the database", *outputFile)
+		}
+	default:
+		log.Fatalf("Invalid output %q (expected db, ndjson or both)", *output)
+	}
+	// Saved listings that are new or changed are announced on EVENTS_URL, if set
+	if config.Events, err = events.FromEnv(); err != nil {
+		log.Fatal(err)
+	}
 
 	// Create scraper
 	s := scraper.New(database, config)
@@ -112,6 +223,18 @@ func main() {
 		cancel()
 	}()
 
+	// Refetch specific listings instead of searching
+	if *refreshIDs != "" || *refreshURL != "" {
+		listings, err := refreshListings(database, *refreshIDs, *refreshURL)
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+		if err := s.Refresh(ctx, listings); err != nil {
+			log.Fatalf("Refresh failed: %v", err)
+		}
+		return
+	}
+
 	// Run the scraper
 	log.Println("Starting property scraper...")
 	startTime := time.Now()
@@ -126,3 +249,38 @@ func main() {
 
 	log.Printf("Scraping completed in %s", time.Since(startTime))
 }
+
+// refreshListings looks up the saved listings -refresh-ids and -refresh-url
+// name, failing if any isn't found
+func refreshListings(database *db.DB, ids, listingURL string) ([]models.Property, error) {
+	var listings []models.Property
+	if ids != "" {
+		var propertyIDs []int64
+		for _, v := range strings.Split(ids, ",") {
+			id, err := strconv.ParseInt(strings.TrimSpace(v), 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid property ID %q", v)
+			}
+			propertyIDs = append(propertyIDs, id)
+		}
+		found, err := database.GetListings(propertyIDs)
+		if err != nil {
+			return nil, err
+		}
+		if len(found) < len(propertyIDs) {
+			return nil, fmt.Errorf("only %d of %d properties found", len(found), len(propertyIDs))
+		}
+		listings = append(listings, found...)
+	}
+	if listingURL != "" {
+		listing, err := database.GetListingByURL(listingURL)
+		if err != nil {
+			return nil, err
+		}
+		if listing == nil {
+			return nil, fmt.Errorf("no saved listing with URL %s (only saved listings can be refreshed)", listingURL)
+		}
+		listings = append(listings, *listing)
+	}
+	return listings, nil
+}
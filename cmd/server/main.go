@@ -1,21 +1,28 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"log"
+	"net"
 	"net/http"
 	"os"
 	"path/filepath"
 
 	"farm-search/internal/api"
 	"farm-search/internal/db"
+	"farm-search/internal/grpcapi"
+	"farm-search/internal/jobs"
 )
 
 func main() {
 	// Parse command line flags
 	port := flag.Int("port", 8080, "Port to listen on")
 	dbPath := flag.String("db", "", "Path to SQLite database")
+	jobsFile := flag.String("jobs", "", "JSON file of tool and scraper commands to run on cron schedules (see scripts/jobs.example.json)")
+	readOnlyDB := flag.String("readonly-db", "", "Serve a read-only replica of the database (Litestream restored, or a LiteFS mount) instead of -db, refusing writes")
+	grpcPort := flag.Int("grpc-port", 0, "Port to serve property search and detail over gRPC on (0 for none)")
 	flag.Parse()
 
 	// Determine paths
@@ -44,18 +51,62 @@ func main() {
 		staticDir = filepath.Join(cwd, "web", "static")
 	}
 
-	log.Printf("Database path: %s", *dbPath)
 	log.Printf("Static files: %s", staticDir)
 
 	// Initialize database
-	database, err := db.New(*dbPath)
+	var database *db.DB
+	if *readOnlyDB != "" {
+		log.Printf("Database path: %s (read-only replica)", *readOnlyDB)
+		database, err = db.NewReadOnly(*readOnlyDB)
+	} else {
+		log.Printf("Database path: %s", *dbPath)
+		database, err = db.New(*dbPath)
+	}
 	if err != nil {
 		log.Fatalf("Failed to initialize database: %v", err)
 	}
 	defer database.Close()
 
+	// Warn about common property list filters no index serves
+	if warnings, err := database.CheckQueryPlans(); err != nil {
+		log.Printf("Warning: failed to check query plans: %v", err)
+	} else {
+		for _, w := range warnings {
+			log.Printf("Warning: %s", w)
+		}
+	}
+
+	// Start scheduled tasks
+	var runner *jobs.Runner
+	if *jobsFile != "" {
+		config, err := jobs.LoadConfig(*jobsFile)
+		if err == nil {
+			runner, err = jobs.NewRunner(config)
+		}
+		if err != nil {
+			log.Fatalf("Failed to load jobs: %v", err)
+		}
+		runner.Start(context.Background())
+		log.Printf("Scheduled %d jobs from %s", len(config.Tasks), *jobsFile)
+	}
+
 	// Create router
-	router := api.NewRouter(database, staticDir)
+	handlers := api.NewHandlers(database)
+	router := api.NewRouter(handlers, staticDir, runner)
+
+	// Serve the same property lookups over gRPC
+	if *grpcPort != 0 {
+		lis, err := net.Listen("tcp", fmt.Sprintf(":%d", *grpcPort))
+		if err != nil {
+			log.Fatalf("Failed to listen for gRPC: %v", err)
+		}
+		log.Printf("Serving gRPC on :%d", *grpcPort)
+		go func() {
+			if err := grpcapi.NewServer(handlers.Properties()).Serve(lis); err != nil {
+				log.Fatalf("gRPC server failed: %v", err)
+			}
+		}()
+	}
 
 	// Start server
 	addr := fmt.Sprintf(":%d", *port)
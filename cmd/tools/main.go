@@ -2,18 +2,31 @@ package main
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"log"
+	"net/url"
 	"os"
 	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
+	"farm-search/internal/backup"
 	"farm-search/internal/db"
+	"farm-search/internal/events"
 	"farm-search/internal/geo"
+	"farm-search/internal/gnaf"
 	"farm-search/internal/models"
+	"farm-search/internal/nswvg"
+	"farm-search/internal/publish"
 	"farm-search/internal/scraper"
+	"farm-search/internal/service"
 )
 
 // Default Valhalla URL - local instance in this container
@@ -36,20 +49,106 @@ func main() {
 		calculateDistances()
 	case "drivetimes":
 		calculateDriveTimes()
+	case "drivetimegrid":
+		calculateDriveTimeGrid()
 	case "towns":
 		calculateNearestTowns()
 	case "towndrivetimes":
 		calculateTownDriveTimes()
+	case "townwalkcycle":
+		calculateTownWalkCycleTimes()
 	case "schools":
 		calculateNearestSchools()
 	case "schooldrivetimes":
 		calculateSchoolDriveTimes()
+	case "poidrivetimes":
+		calculatePOIDriveTimes()
+	case "nearest-changed":
+		updateChangedNearest()
+	case "unroutable":
+		diagnoseUnroutable()
 	case "cadastral":
 		fetchCadastralLots()
+	case "heritage":
+		checkHeritage()
+	case "subdivision":
+		checkSubdivision()
+	case "access":
+		findAccessPoints()
+	case "imagery":
+		generateImageryLinks()
+	case "clearing":
+		detectClearing()
+	case "ndvi":
+		summariseNDVI()
+	case "enrich":
+		enrichStale()
+	case "snapshots":
+		snapshotSavedSearches()
+	case "watchlist-reports":
+		reportWatchlists()
+	case "publish":
+		publishSnapshot()
+	case "scores":
+		computeScores()
+	case "amenities":
+		importAmenities()
+	case "suburbs":
+		importSuburbs()
+	case "velocity":
+		computeVelocity()
+	case "lgas":
+		importLGAs()
+	case "postcodes":
+		importPostcodes()
+	case "boundarycheck":
+		checkBoundaries()
+	case "exclusions":
+		importExclusions()
+	case "energy":
+		importEnergyDevelopments()
+	case "noise":
+		importNoiseSources()
+	case "overlays":
+		importOverlays()
+	case "biosecurity":
+		importBiosecurity()
+	case "fires":
+		importFires()
+	case "buildings":
+		importBuildings()
+	case "dwellings":
+		estimateDwellings()
 	case "landsize":
 		backfillLandSizeFromCadastral()
 	case "readetails":
 		fetchREADetails()
+	case "auctionresults":
+		scrapeAuctionResults()
+	case "vgsales":
+		importVGSales()
+	case "vglandvalues":
+		importVGLandValues()
+	case "gnaf":
+		matchGNAF()
+	case "import":
+		importListings()
+	case "geocode":
+		geocodeProperties()
+	case "import-ndjson":
+		importNDJSON()
+	case "prune":
+		pruneDelisted()
+	case "backup":
+		backupDatabase()
+	case "restore":
+		restoreDatabase()
+	case "checkpoint":
+		checkpointDatabase()
+	case "merge-db":
+		mergeDatabases()
+	case "region-init":
+		initRegionDatabase()
 	case "seed":
 		seedSampleData()
 	default:
@@ -62,66 +161,246 @@ func printUsage() {
 	fmt.Println("Usage: tools <command> [options]")
 	fmt.Println()
 	fmt.Println("Commands:")
-	fmt.Println("  isochrones        Generate Sydney drive-time isochrones")
+	fmt.Println("  isochrones        Generate drive-time isochrones around the anchor")
 	fmt.Println("  distances         Calculate property distances to towns, schools, Sydney")
-	fmt.Println("  drivetimes        Calculate drive times to Sutherland for all properties")
+	fmt.Println("  drivetimes        Calculate drive times to the anchor for all properties")
+	fmt.Println("  drivetimegrid     Calculate drive times to the anchor over a grid for the drive time surface")
 	fmt.Println("  towns             Calculate nearest towns for all properties")
 	fmt.Println("  towndrivetimes    Calculate drive times to nearest towns for all properties")
+	fmt.Println("  townwalkcycle     Calculate walking and cycling times to the nearest town for properties on its fringe")
 	fmt.Println("  schools           Calculate nearest schools for all properties")
 	fmt.Println("  schooldrivetimes  Calculate drive times to nearest schools for all properties")
+	fmt.Println("  poidrivetimes     Calculate distances and drive times to user POIs (friends' houses, trailheads)")
+	fmt.Println("  nearest-changed   Update only the nearest towns and schools a town or school data change moved, and their drive times")
+	fmt.Println("  unroutable        Locate properties that fail routing on the road network and suggest the nearest road point")
 	fmt.Println("  cadastral         Fetch cadastral lot boundaries for properties")
+	fmt.Println("  heritage          Check properties' lots against state and local heritage listings")
+	fmt.Println("  subdivision       Look up the LEP minimum lot size over properties' lots and their subdivision ratio")
+	fmt.Println("  access            Find the driveway/gate candidate on each property's lot boundary to route drive times from")
+	fmt.Println("  imagery           Generate Street View (from the nearest road), aerial imagery and Google Earth links")
+	fmt.Println("  clearing          Compare Sentinel-2 woody cover over each property's lots with 5 years ago, flagging clearing")
+	fmt.Println("  ndvi              Summarise Sentinel-2 NDVI over each property's lots by month (pasture greenness)")
+	fmt.Println("  enrich            Recompute only the drive times, towns, schools and lots that are missing or stale")
+	fmt.Println("  snapshots         Snapshot saved search matches for the diff endpoint (run daily, after scraping)")
+	fmt.Println("  watchlist-reports Archive and publish each watchlist's weekly suburb/LGA summary (run weekly, after scraping)")
+	fmt.Println("  publish           Render a read-only HTML, GeoJSON and image snapshot of chosen properties for static hosting")
+	fmt.Println("  scores            Rescore every property with every score profile (after scraping)")
+	fmt.Println("  amenities         Import amenities of one type (e.g. hospitals) from a CSV for the nearby endpoint")
+	fmt.Println("  suburbs           Import ABS suburb boundaries (SAL GeoJSON) for the suburb stats choropleth")
+	fmt.Println("  velocity          Work out how quickly each suburb's listings go off the market, for its market heat")
+	fmt.Println("  lgas              Import ABS Local Government Area boundaries (LGA GeoJSON) for watchlists")
+	fmt.Println("  postcodes         Import ABS postcode boundaries (POA GeoJSON) for boundarycheck")
+	fmt.Println("  boundarycheck     Check each property's suburb and postcode against the boundaries its pin is in (-fix to correct)")
+	fmt.Println("  exclusions        Import an exclusion layer (e.g. highways, mines) from GeoJSON for exclude_near")
+	fmt.Println("  energy            Import wind and solar farm developments from a CSV and find each property's nearest")
+	fmt.Println("  noise             Import highways, railways and runways from OSM GeoJSON and measure each property's distance")
+	fmt.Println("  overlays          Import koala habitat and biodiversity values maps and measure each property's coverage")
+	fmt.Println("  biosecurity       Import Local Land Services regions and declared weed zones and tag each property")
+	fmt.Println("  fires             Import the NPWS fire history and find the past fires over each property's lots")
+	fmt.Println("  buildings         Import building footprints and count the buildings on each property's lots")
+	fmt.Println("  dwellings         Estimate each property's dwellings (granny flat, workers cottage, second home) from its description")
 	fmt.Println("  landsize          Backfill land size from cadastral data for properties with <10 HA")
 	fmt.Println("  readetails        Fetch full listing details for REA properties (via ScrapingBee or Bright Data)")
+	fmt.Println("  auctionresults    Scrape weekly Domain auction results and link them to properties")
+	fmt.Println("  vgsales           Import NSW Valuer General property sales (PSI bulk data) for cadastral lots")
+	fmt.Println("  vglandvalues      Import NSW Valuer General land values for cadastral lots and total them per property")
+	fmt.Println("  gnaf              Import G-NAF addresses and match each property's address to its canonical one")
+	fmt.Println("  import            Import manually collected listings from a CSV or JSON file")
+	fmt.Println("  geocode           Geocode properties missing coordinates through the configured geocoders")
+	fmt.Println("  import-ndjson     Save listings a scraper run wrote with -output ndjson")
+	fmt.Println("  prune             Delete delisted properties not seen in N months (use -dry-run first)")
+	fmt.Println("  backup            Snapshot the database online, rotate old snapshots, optionally upload to S3")
+	fmt.Println("  restore           Restore the database from a local or S3 snapshot (stop the server first)")
+	fmt.Println("  checkpoint        Copy the WAL into the database (automatic checkpoints are off with DB_REPLICATION=litestream)")
+	fmt.Println("  merge-db          Merge properties, enrichment and user data from another database (e.g. another scraper's)")
+	fmt.Println("  region-init       Start a region database's property IDs at a base, for the server's REGION_DBS")
 	fmt.Println("  seed              Seed database with sample data")
 }
 
 func generateIsochrones() {
 	outputDir := flag.String("output", "web/static/data/isochrones", "Output directory")
 	valhallaURL := flag.String("valhalla-url", defaultValhallaURL, "Valhalla server URL")
+	wait := valhallaWaitFlag()
+	anchorArg := anchorFlag()
+	anchorsArg := flag.String("anchors", "", `More anchors to generate for, separated by ";" (e.g. "Newcastle:-32.9267,151.7789;Orange:-33.28,149.1")`)
+	minutesArg := flag.String("minutes", "15,30,45,60,75,90,105,120,135,150,165,180", "Comma-separated drive times in minutes")
+	workers := flag.Int("workers", 2, "Number of isochrones to generate at once")
+	delay := flag.Duration("delay", time.Second, "Pause between each worker's Valhalla requests")
 	flag.Parse()
 
+	anchors := []geo.Anchor{mustParseAnchor(*anchorArg)}
+	for _, s := range strings.Split(*anchorsArg, ";") {
+		if s = strings.TrimSpace(s); s != "" {
+			anchors = append(anchors, mustParseAnchor(s))
+		}
+	}
+	var intervals []int
+	for _, s := range strings.Split(*minutesArg, ",") {
+		mins, err := strconv.Atoi(strings.TrimSpace(s))
+		if err != nil || mins <= 0 {
+			log.Fatalf("Invalid -minutes value %q", s)
+		}
+		intervals = append(intervals, mins)
+	}
+	if *workers < 1 {
+		*workers = 1
+	}
 	if err := os.MkdirAll(*outputDir, 0755); err != nil {
 		log.Fatalf("Failed to create output directory: %v", err)
 	}
 
 	ctx := context.Background()
 
-	log.Printf("Using Valhalla at %s", *valhallaURL)
+	mustWaitForValhalla(ctx, geo.NewRouter(*valhallaURL), *valhallaURL, *wait)
 	gen := geo.NewIsochroneGenerator(*valhallaURL)
 
-	intervals := []int{15, 30, 45, 60, 75, 90, 105, 120, 135, 150, 165, 180}
+	type job struct {
+		anchor geo.Anchor
+		mins   int
+	}
+	type result struct {
+		job      job
+		filename string
+		err      error
+	}
+	jobs := make(chan job)
+	results := make(chan result)
 
-	log.Println("Generating Sutherland isochrones...")
+	total := len(anchors) * len(intervals)
+	log.Printf("Generating %d isochrones for %d anchors with %d workers...", total, len(anchors), *workers)
 
-	for _, mins := range intervals {
-		log.Printf("Generating %d minute isochrone...", mins)
+	// Each worker pauses between its own requests, so Valhalla sees at most
+	// -workers requests per -delay
+	for w := 0; w < *workers; w++ {
+		go func() {
+			for j := range jobs {
+				filename := filepath.Join(*outputDir, fmt.Sprintf("%s_%d.geojson", j.anchor.Slug(), j.mins))
+				iso, err := gen.GenerateIsochrone(ctx, j.anchor.Lat, j.anchor.Lng, j.mins)
+				if err == nil {
+					data, _ := json.MarshalIndent(iso, "", "  ")
+					err = writeFileAtomic(filename, data)
+				}
+				results <- result{job: j, filename: filename, err: err}
+				time.Sleep(*delay)
+			}
+		}()
+	}
 
-		iso, err := gen.GenerateIsochrone(ctx, geo.Sutherland.Lat, geo.Sutherland.Lng, mins)
-		if err != nil {
-			log.Printf("Failed to generate %d min isochrone: %v", mins, err)
+	go func() {
+		for _, anchor := range anchors {
+			for _, mins := range intervals {
+				jobs <- job{anchor: anchor, mins: mins}
+			}
+		}
+		close(jobs)
+	}()
+
+	saved, failed := 0, 0
+	for i := 0; i < total; i++ {
+		r := <-results
+		if r.err != nil {
+			log.Printf("[%d/%d] Failed to generate %s %d min isochrone: %v", i+1, total, r.job.anchor.Name, r.job.mins, r.err)
+			failed++
 			continue
 		}
+		log.Printf("[%d/%d] Saved %s", i+1, total, r.filename)
+		saved++
+	}
 
-		filename := filepath.Join(*outputDir, fmt.Sprintf("sutherland_%d.geojson", mins))
-		data, _ := json.MarshalIndent(iso, "", "  ")
+	log.Printf("Done! Saved: %d, Failed: %d", saved, failed)
+}
 
-		if err := os.WriteFile(filename, data, 0644); err != nil {
-			log.Printf("Failed to write %s: %v", filename, err)
-			continue
-		}
+// writeFileAtomic writes data to path through a temp file in the same
+// directory, so the server never serves a half-written file and a failed
+// write leaves the old one
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+"-*")
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", path, err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
 
-		log.Printf("Saved %s", filename)
+// anchorFlag adds the -anchor flag shared by the tools that measure to the
+// anchor, defaulting to ANCHOR as the server does
+func anchorFlag() *string {
+	return flag.String("anchor", os.Getenv("ANCHOR"), `Anchor drive times are measured to, as "Name:lat,lng" (default ANCHOR, else Sutherland)`)
+}
 
-		// Rate limiting
-		time.Sleep(1 * time.Second)
+// mustEventsFromEnv returns the publisher EVENTS_URL configures, or nil if
+// it isn't set
+func mustEventsFromEnv() events.Publisher {
+	publisher, err := events.FromEnv()
+	if err != nil {
+		log.Fatal(err)
 	}
+	return publisher
+}
 
-	log.Println("Done!")
+// mustParseAnchor parses an -anchor value, taking an empty one as Sutherland
+func mustParseAnchor(s string) geo.Anchor {
+	if s == "" {
+		return geo.Sutherland
+	}
+	anchor, err := geo.ParseAnchor(s)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return anchor
+}
+
+// valhallaWaitFlag adds the -wait flag shared by the tools that route
+func valhallaWaitFlag() *time.Duration {
+	return flag.Duration("wait", 2*time.Minute, "How long to wait for Valhalla to come up (0 to fail at once)")
+}
+
+// logRouteCache logs how many routes a drive time job found in its cache
+// rather than asking Valhalla
+func logRouteCache(cache *geo.RouteCache) {
+	stats := cache.Stats()
+	if stats.Hits+stats.Misses == 0 {
+		return
+	}
+	log.Printf("Route cache: %d hits, %d misses (%.1f%% hit rate)", stats.Hits, stats.Misses, stats.HitRate()*100)
+}
+
+// waitForValhalla checks Valhalla is up before a tool starts routing,
+// polling for up to wait if it isn't (e.g. the container is still loading
+// tiles)
+func waitForValhalla(ctx context.Context, router *geo.Router, url string, wait time.Duration) error {
+	log.Printf("Using Valhalla at %s", url)
+	if err := router.Status(ctx); err == nil {
+		return nil
+	}
+	if wait > 0 {
+		log.Printf("Valhalla isn't up yet, waiting up to %s...", wait)
+	}
+	return router.WaitUntilReady(ctx, wait, 5*time.Second)
+}
+
+// mustWaitForValhalla is waitForValhalla for tools that can't do anything without it
+func mustWaitForValhalla(ctx context.Context, router *geo.Router, url string, wait time.Duration) {
+	if err := waitForValhalla(ctx, router, url, wait); err != nil {
+		log.Fatalf("Valhalla at %s is down: %v (start it, or point -valhalla-url at a running instance)", url, err)
+	}
 }
 
 func calculateTownDriveTimes() {
 	dbPath := flag.String("db", "data/farm-search.db", "Database path")
 	valhallaURL := flag.String("valhalla-url", defaultValhallaURL, "Valhalla server URL")
+	wait := valhallaWaitFlag()
 	all := flag.Bool("all", false, "Recalculate all properties, not just missing ones")
 	flag.Parse()
 
@@ -133,121 +412,28 @@ func calculateTownDriveTimes() {
 
 	ctx := context.Background()
 
-	// Create router
-	log.Printf("Using Valhalla at %s", *valhallaURL)
-	router := geo.NewRouter(*valhallaURL)
-
-	// Build a map of town name -> coordinates for quick lookup
-	townCoords := make(map[string]geo.Location)
-	for _, town := range geo.NSWTowns {
-		townCoords[town.Name] = town
-	}
-
-	// Get properties that need drive times calculated
-	var properties []struct {
-		ID           int64   `db:"id"`
-		Latitude     float64 `db:"latitude"`
-		Longitude    float64 `db:"longitude"`
-		Suburb       string  `db:"suburb"`
-		NearestTown1 string  `db:"nearest_town_1"`
-		NearestTown2 string  `db:"nearest_town_2"`
-	}
-
-	var query string
-	if *all {
-		query = `SELECT id, latitude, longitude, COALESCE(suburb, '') as suburb, 
-				 nearest_town_1, COALESCE(nearest_town_2, '') as nearest_town_2
-				 FROM properties 
-				 WHERE latitude IS NOT NULL AND longitude IS NOT NULL 
-				 AND nearest_town_1 IS NOT NULL`
-	} else {
-		query = `SELECT id, latitude, longitude, COALESCE(suburb, '') as suburb,
-				 nearest_town_1, COALESCE(nearest_town_2, '') as nearest_town_2
-				 FROM properties 
-				 WHERE latitude IS NOT NULL AND longitude IS NOT NULL 
-				 AND nearest_town_1 IS NOT NULL AND nearest_town_1_mins IS NULL`
-	}
+	routeCache := geo.NewRouteCache()
+	router := geo.NewRouter(*valhallaURL).WithRouteCache(routeCache)
+	mustWaitForValhalla(ctx, router, *valhallaURL, *wait)
 
-	err = database.Select(&properties, query)
+	enrichment := service.NewEnrichmentService(database, router, nil, nil)
+	stats, err := enrichment.TownDriveTimes(ctx, *all)
 	if err != nil {
-		log.Fatalf("Failed to get properties: %v", err)
+		log.Fatalf("Failed to calculate drive times: %v", err)
 	}
-
-	if len(properties) == 0 {
-		log.Println("No properties need town drive time calculation")
+	if stats.Total == 0 {
 		return
 	}
 
-	log.Printf("Calculating drive times to nearest towns for %d properties...", len(properties))
-
-	success := 0
-	failed := 0
-
-	for i, p := range properties {
-		var town1Mins, town2Mins *int
-
-		// Get drive time to nearest town 1
-		if town1, ok := townCoords[p.NearestTown1]; ok {
-			result, err := router.GetRoute(ctx, p.Latitude, p.Longitude, town1.Latitude, town1.Longitude)
-			if err != nil {
-				log.Printf("[%d/%d] Failed route to %s for property %d: %v",
-					i+1, len(properties), p.NearestTown1, p.ID, err)
-				failed++
-				continue
-			}
-			mins := int(result.DurationMins + 0.5)
-			town1Mins = &mins
-		}
-
-		// Get drive time to nearest town 2 (if exists)
-		if p.NearestTown2 != "" {
-			if town2, ok := townCoords[p.NearestTown2]; ok {
-				result, err := router.GetRoute(ctx, p.Latitude, p.Longitude, town2.Latitude, town2.Longitude)
-				if err != nil {
-					log.Printf("[%d/%d] Failed route to %s for property %d: %v",
-						i+1, len(properties), p.NearestTown2, p.ID, err)
-					// Continue anyway, we at least have town 1
-				} else {
-					mins := int(result.DurationMins + 0.5)
-					town2Mins = &mins
-				}
-			}
-		}
-
-		// Save to database
-		_, err = database.Exec(`
-			UPDATE properties 
-			SET nearest_town_1_mins = ?, nearest_town_2_mins = ?
-			WHERE id = ?`,
-			town1Mins, town2Mins, p.ID)
-
-		if err != nil {
-			log.Printf("[%d/%d] Failed to save for property %d: %v", i+1, len(properties), p.ID, err)
-			failed++
-			continue
-		}
-
-		town1Str := "N/A"
-		if town1Mins != nil {
-			town1Str = fmt.Sprintf("%d min", *town1Mins)
-		}
-		town2Str := "N/A"
-		if town2Mins != nil {
-			town2Str = fmt.Sprintf("%d min", *town2Mins)
-		}
-
-		log.Printf("[%d/%d] Property %d (%s): %s (%s), %s (%s)",
-			i+1, len(properties), p.ID, p.Suburb,
-			p.NearestTown1, town1Str, p.NearestTown2, town2Str)
-
-		success++
-	}
-
-	log.Printf("Done! Success: %d, Failed: %d", success, failed)
+	logRouteCache(routeCache)
+	log.Printf("Done! Success: %d, Failed: %d", stats.Success, stats.Failed)
 }
 
-func calculateDistances() {
+func calculateTownWalkCycleTimes() {
 	dbPath := flag.String("db", "data/farm-search.db", "Database path")
+	valhallaURL := flag.String("valhalla-url", defaultValhallaURL, "Valhalla server URL")
+	wait := valhallaWaitFlag()
+	all := flag.Bool("all", false, "Recalculate all properties on a town's fringe, not just missing ones")
 	flag.Parse()
 
 	database, err := db.New(*dbPath)
@@ -258,53 +444,28 @@ func calculateDistances() {
 
 	ctx := context.Background()
 
-	// Load schools
-	schoolData := geo.NewSchoolData()
-	if err := schoolData.LoadFromNSWData(ctx); err != nil {
-		log.Printf("Warning: Could not load school data: %v", err)
-	}
-	log.Printf("Loaded %d schools", len(schoolData.Schools))
+	routeCache := geo.NewRouteCache()
+	router := geo.NewRouter(*valhallaURL).WithRouteCache(routeCache)
+	mustWaitForValhalla(ctx, router, *valhallaURL, *wait)
 
-	// Get all properties
-	properties, err := database.ListProperties(db.PropertyFilter{Limit: 10000})
+	enrichment := service.NewEnrichmentService(database, router, nil, nil)
+	stats, err := enrichment.TownWalkCycleTimes(ctx, *all)
 	if err != nil {
-		log.Fatalf("Failed to list properties: %v", err)
+		log.Fatalf("Failed to calculate walking and cycling times: %v", err)
 	}
-
-	log.Printf("Calculating distances for %d properties...", len(properties))
-
-	for _, p := range properties {
-		// Distance to Sydney
-		distSydney := geo.DistanceToSydney(p.Latitude, p.Longitude)
-
-		// Nearest town
-		town, distTown := geo.FindNearestTown(p.Latitude, p.Longitude)
-
-		// Nearest school
-		school, distSchool := schoolData.FindNearestSchool(p.Latitude, p.Longitude)
-
-		// Save distances
-		err := database.SavePropertyDistance(p.ID, "capital", "Sydney", distSydney)
-		if err != nil {
-			log.Printf("Failed to save Sydney distance for property %d: %v", p.ID, err)
-		}
-
-		err = database.SavePropertyDistance(p.ID, "town", town.Name, distTown)
-		if err != nil {
-			log.Printf("Failed to save town distance for property %d: %v", p.ID, err)
-		}
-
-		err = database.SavePropertyDistance(p.ID, "school", school.Name, distSchool)
-		if err != nil {
-			log.Printf("Failed to save school distance for property %d: %v", p.ID, err)
-		}
+	if stats.Total == 0 {
+		return
 	}
 
-	log.Println("Distance calculations complete!")
+	logRouteCache(routeCache)
+	log.Printf("Done! Success: %d, Failed: %d", stats.Success, stats.Failed)
 }
 
-func seedSampleData() {
+func generateImageryLinks() {
 	dbPath := flag.String("db", "data/farm-search.db", "Database path")
+	valhallaURL := flag.String("valhalla-url", defaultValhallaURL, "Valhalla server URL")
+	wait := valhallaWaitFlag()
+	all := flag.Bool("all", false, "Regenerate all properties, not just missing ones")
 	flag.Parse()
 
 	database, err := db.New(*dbPath)
@@ -313,26 +474,28 @@ func seedSampleData() {
 	}
 	defer database.Close()
 
-	// Read and execute the seed SQL file
-	seedFile := "scripts/seed-sample-data.sql"
-	content, err := os.ReadFile(seedFile)
-	if err != nil {
-		log.Fatalf("Failed to read seed file: %v", err)
-	}
+	ctx := context.Background()
 
-	_, err = database.Exec(string(content))
+	router := geo.NewRouter(*valhallaURL)
+	mustWaitForValhalla(ctx, router, *valhallaURL, *wait)
+
+	enrichment := service.NewEnrichmentService(database, router, nil, nil)
+	stats, err := enrichment.ImageryLinks(ctx, *all)
 	if err != nil {
-		log.Fatalf("Failed to execute seed SQL: %v", err)
+		log.Fatalf("Failed to generate imagery links: %v", err)
+	}
+	if stats.Total == 0 {
+		return
 	}
 
-	count, _ := database.GetPropertyCount()
-	log.Printf("Database seeded successfully! Total properties: %d", count)
+	log.Printf("Done! Success: %d, Failed: %d", stats.Success, stats.Failed)
 }
 
-func calculateDriveTimes() {
+func findAccessPoints() {
 	dbPath := flag.String("db", "data/farm-search.db", "Database path")
 	valhallaURL := flag.String("valhalla-url", defaultValhallaURL, "Valhalla server URL")
-	all := flag.Bool("all", false, "Recalculate all properties, not just missing ones")
+	wait := valhallaWaitFlag()
+	all := flag.Bool("all", false, "Recheck all properties with lots, not just missing ones")
 	flag.Parse()
 
 	database, err := db.New(*dbPath)
@@ -343,149 +506,98 @@ func calculateDriveTimes() {
 
 	ctx := context.Background()
 
-	// Create router
-	log.Printf("Using Valhalla at %s", *valhallaURL)
 	router := geo.NewRouter(*valhallaURL)
+	mustWaitForValhalla(ctx, router, *valhallaURL, *wait)
 
-	// Get properties
-	var properties []struct {
-		ID        int64   `db:"id"`
-		Latitude  float64 `db:"latitude"`
-		Longitude float64 `db:"longitude"`
-		Address   string  `db:"address"`
-		Suburb    string  `db:"suburb"`
-	}
-
-	var query string
-	if *all {
-		query = `SELECT id, latitude, longitude, COALESCE(address, '') as address, COALESCE(suburb, '') as suburb 
-				 FROM properties WHERE latitude IS NOT NULL AND longitude IS NOT NULL`
-	} else {
-		query = `SELECT id, latitude, longitude, COALESCE(address, '') as address, COALESCE(suburb, '') as suburb 
-				 FROM properties WHERE latitude IS NOT NULL AND longitude IS NOT NULL AND drive_time_sydney IS NULL`
-	}
-
-	err = database.Select(&properties, query)
+	enrichment := service.NewEnrichmentService(database, router, nil, nil)
+	stats, err := enrichment.AccessPoints(ctx, *all)
 	if err != nil {
-		log.Fatalf("Failed to get properties: %v", err)
+		log.Fatalf("Failed to find access points: %v", err)
 	}
-
-	if len(properties) == 0 {
-		log.Println("No properties need drive time calculation")
+	if stats.Total == 0 {
 		return
 	}
 
-	log.Printf("Calculating drive times for %d properties to Sutherland...", len(properties))
-	log.Printf("Sutherland coordinates: %.4f, %.4f", geo.Sutherland.Lat, geo.Sutherland.Lng)
-
-	success := 0
-	failed := 0
-
-	for i, p := range properties {
-		// Get drive time
-		result, err := router.GetDriveTime(ctx, p.Latitude, p.Longitude)
-		if err != nil {
-			log.Printf("[%d/%d] Failed for property %d (%s, %s): %v",
-				i+1, len(properties), p.ID, p.Address, p.Suburb, err)
-			failed++
-			continue
-		}
+	log.Printf("Done! Success: %d, Failed: %d", stats.Success, stats.Failed)
+	log.Printf("Drive times from moved access points are marked stale; run drivetimes, towndrivetimes and schooldrivetimes (or enrich)")
+}
 
-		// Round to nearest minute
-		driveTimeMins := int(result.DurationMins + 0.5)
+func detectClearing() {
+	dbPath := flag.String("db", "data/farm-search.db", "Database path")
+	all := flag.Bool("all", false, "Recheck all properties, not just missing ones")
+	flag.Parse()
 
-		// Save to database immediately
-		err = database.UpdatePropertyDriveTime(p.ID, driveTimeMins)
-		if err != nil {
-			log.Printf("[%d/%d] Failed to save drive time for property %d: %v",
-				i+1, len(properties), p.ID, err)
-			failed++
-			continue
-		}
+	source := mustVegetationSource()
 
-		location := p.Suburb
-		if p.Address != "" {
-			location = p.Address
-		}
-		log.Printf("[%d/%d] Property %d (%s): %d mins (%.1f km)",
-			i+1, len(properties), p.ID, location, driveTimeMins, result.DistanceKm)
+	database, err := db.New(*dbPath)
+	if err != nil {
+		log.Fatalf("Failed to open database: %v", err)
+	}
+	defer database.Close()
 
-		success++
+	enrichment := service.NewEnrichmentService(database, nil, nil, nil).WithVegetation(source)
+	stats, err := enrichment.Clearing(context.Background(), *all)
+	if err != nil {
+		log.Fatalf("Failed to check clearing: %v", err)
+	}
+	if stats.Total == 0 {
+		return
 	}
 
-	log.Printf("Done! Success: %d, Failed: %d", success, failed)
+	log.Printf("Done! Success: %d, Failed: %d", stats.Success, stats.Failed)
 }
 
-func calculateNearestTowns() {
+func summariseNDVI() {
 	dbPath := flag.String("db", "data/farm-search.db", "Database path")
-	all := flag.Bool("all", false, "Recalculate all properties, not just missing ones")
+	all := flag.Bool("all", false, "Resummarise all properties, not just missing ones")
 	flag.Parse()
 
+	source := mustVegetationSource()
+
 	database, err := db.New(*dbPath)
 	if err != nil {
 		log.Fatalf("Failed to open database: %v", err)
 	}
 	defer database.Close()
 
-	// Get properties
-	var properties []struct {
-		ID        int64   `db:"id"`
-		Latitude  float64 `db:"latitude"`
-		Longitude float64 `db:"longitude"`
-		Suburb    string  `db:"suburb"`
-	}
-
-	var query string
-	if *all {
-		query = `SELECT id, latitude, longitude, COALESCE(suburb, '') as suburb 
-				 FROM properties WHERE latitude IS NOT NULL AND longitude IS NOT NULL`
-	} else {
-		query = `SELECT id, latitude, longitude, COALESCE(suburb, '') as suburb 
-				 FROM properties WHERE latitude IS NOT NULL AND longitude IS NOT NULL AND nearest_town_1 IS NULL`
-	}
-
-	err = database.Select(&properties, query)
+	enrichment := service.NewEnrichmentService(database, nil, nil, nil).WithVegetation(source)
+	stats, err := enrichment.PastureNDVI(context.Background(), *all)
 	if err != nil {
-		log.Fatalf("Failed to get properties: %v", err)
+		log.Fatalf("Failed to summarise NDVI: %v", err)
 	}
-
-	if len(properties) == 0 {
-		log.Println("No properties need nearest town calculation")
+	if stats.Total == 0 {
 		return
 	}
 
-	log.Printf("Calculating nearest towns for %d properties using %d towns...", len(properties), len(geo.NSWTowns))
-
-	for i, p := range properties {
-		// Find two nearest towns
-		town1, town2 := geo.FindTwoNearestTowns(p.Latitude, p.Longitude)
-
-		// Save to database
-		_, err = database.Exec(`
-			UPDATE properties 
-			SET nearest_town_1 = ?, nearest_town_1_km = ?, 
-			    nearest_town_2 = ?, nearest_town_2_km = ?
-			WHERE id = ?`,
-			town1.Name, town1.DistanceKm, town2.Name, town2.DistanceKm, p.ID)
-
-		if err != nil {
-			log.Printf("[%d/%d] Failed to save for property %d: %v", i+1, len(properties), p.ID, err)
-			continue
-		}
+	log.Printf("Done! Success: %d, Failed: %d", stats.Success, stats.Failed)
+}
 
-		log.Printf("[%d/%d] Property %d (%s): %s (%.1f km), %s (%.1f km)",
-			i+1, len(properties), p.ID, p.Suburb,
-			town1.Name, town1.DistanceKm, town2.Name, town2.DistanceKm)
+// mustVegetationSource returns the configured satellite vegetation backend,
+// exiting if there isn't one
+func mustVegetationSource() geo.VegetationSource {
+	source, err := geo.NewVegetationSourceFromEnv()
+	if err != nil {
+		log.Fatal(err)
 	}
-
-	log.Println("Done!")
+	if source == nil {
+		log.Fatal("No vegetation source configured: set SENTINELHUB_CLIENT_ID and SENTINELHUB_CLIENT_SECRET")
+	}
+	return source
 }
 
-func calculateNearestSchools() {
+func enrichStale() {
 	dbPath := flag.String("db", "data/farm-search.db", "Database path")
-	all := flag.Bool("all", false, "Recalculate all properties, not just missing ones")
+	valhallaURL := flag.String("valhalla-url", defaultValhallaURL, "Valhalla server URL")
+	wait := valhallaWaitFlag()
+	schools := flag.Bool("schools", true, "Load NSW school data for the school steps")
+	cadastral := flag.Bool("cadastral", true, "Fetch cadastral lots from NSW Spatial Services")
+	heritage := flag.Bool("heritage", true, "Check lots against the NSW heritage layers")
+	lotSize := flag.Bool("lot-size", true, "Check lots against the LEP minimum lot size layer")
+	anchorArg := anchorFlag()
 	flag.Parse()
 
+	anchor := mustParseAnchor(*anchorArg)
+
 	database, err := db.New(*dbPath)
 	if err != nil {
 		log.Fatalf("Failed to open database: %v", err)
@@ -494,72 +606,93 @@ func calculateNearestSchools() {
 
 	ctx := context.Background()
 
-	// Load schools
-	schoolData := geo.NewSchoolData()
-	if err := schoolData.LoadFromNSWData(ctx); err != nil {
-		log.Printf("Warning: Could not load school data: %v", err)
+	routeCache := geo.NewRouteCache()
+	router := geo.NewRouter(*valhallaURL).WithRouteCache(routeCache)
+	if err := waitForValhalla(ctx, router, *valhallaURL, *wait); err != nil {
+		log.Printf("Warning: Valhalla is down, skipping the drive time steps: %v", err)
+		router = nil
 	}
-	log.Printf("Loaded %d schools", len(schoolData.Schools))
 
-	// Get properties
-	var properties []struct {
-		ID        int64   `db:"id"`
-		Latitude  float64 `db:"latitude"`
-		Longitude float64 `db:"longitude"`
-		Suburb    string  `db:"suburb"`
+	var schoolData *geo.SchoolData
+	if *schools {
+		schoolData = geo.NewSchoolData()
+		if err := schoolData.LoadFromNSWData(ctx); err != nil {
+			log.Printf("Warning: Could not load school data: %v", err)
+		}
+		log.Printf("Loaded %d schools", len(schoolData.Schools))
 	}
 
-	var query string
-	if *all {
-		query = `SELECT id, latitude, longitude, COALESCE(suburb, '') as suburb 
-				 FROM properties WHERE latitude IS NOT NULL AND longitude IS NOT NULL`
-	} else {
-		query = `SELECT id, latitude, longitude, COALESCE(suburb, '') as suburb 
-				 FROM properties WHERE latitude IS NOT NULL AND longitude IS NOT NULL AND nearest_school_1 IS NULL`
+	var cadastralClient *geo.CadastralClient
+	if *cadastral {
+		cadastralClient = geo.NewCadastralClient()
 	}
 
-	err = database.Select(&properties, query)
+	enrichment := service.NewEnrichmentService(database, router, schoolData, cadastralClient).
+		WithAnchor(anchor).WithEvents(mustEventsFromEnv())
+	if *heritage {
+		enrichment = enrichment.WithHeritage(geo.NewHeritageClient())
+	}
+	if *lotSize {
+		enrichment = enrichment.WithLotSize(geo.NewLotSizeClient())
+	}
+	// The vegetation steps only run with a configured satellite backend
+	source, err := geo.NewVegetationSourceFromEnv()
 	if err != nil {
-		log.Fatalf("Failed to get properties: %v", err)
+		log.Fatal(err)
 	}
-
-	if len(properties) == 0 {
-		log.Println("No properties need nearest school calculation")
-		return
+	if source != nil {
+		enrichment = enrichment.WithVegetation(source)
+	}
+	saveSchools(enrichment)
+	if err := enrichment.MarkChangedInputs(); err != nil {
+		log.Fatalf("Failed to check enrichment inputs: %v", err)
 	}
 
-	log.Printf("Calculating nearest schools for %d properties...", len(properties))
+	for _, result := range enrichment.Refresh(ctx) {
+		switch {
+		case result.Skipped:
+			log.Printf("%-20s skipped", result.Step)
+		case result.Err != nil:
+			log.Printf("%-20s failed: %v", result.Step, result.Err)
+		default:
+			log.Printf("%-20s success: %d, failed: %d", result.Step, result.Stats.Success, result.Stats.Failed)
+		}
+	}
 
-	for i, p := range properties {
-		// Find two nearest schools
-		school1, school2 := schoolData.FindTwoNearestSchools(p.Latitude, p.Longitude)
-
-		// Save to database (including coordinates for routing)
-		_, err = database.Exec(`
-			UPDATE properties 
-			SET nearest_school_1 = ?, nearest_school_1_km = ?, nearest_school_1_lat = ?, nearest_school_1_lng = ?,
-			    nearest_school_2 = ?, nearest_school_2_km = ?, nearest_school_2_lat = ?, nearest_school_2_lng = ?
-			WHERE id = ?`,
-			school1.Name, school1.DistanceKm, school1.Latitude, school1.Longitude,
-			school2.Name, school2.DistanceKm, school2.Latitude, school2.Longitude, p.ID)
+	stale, err := database.CountStaleSteps()
+	if err != nil {
+		log.Fatalf("Failed to count stale steps: %v", err)
+	}
+	steps := make([]string, 0, len(stale))
+	for step := range stale {
+		steps = append(steps, string(step))
+	}
+	sort.Strings(steps)
+	for _, step := range steps {
+		log.Printf("Still stale: %s for %d properties", step, stale[db.EnrichmentStep(step)])
+	}
 
-		if err != nil {
-			log.Printf("[%d/%d] Failed to save for property %d: %v", i+1, len(properties), p.ID, err)
-			continue
-		}
+	// Drive times feed the scores
+	profiles, err := service.NewScoringService(database).ComputeAll()
+	if err != nil {
+		log.Fatalf("Failed to rescore properties: %v", err)
+	}
+	log.Printf("Rescored properties for %d score profiles", profiles)
 
-		log.Printf("[%d/%d] Property %d (%s): %s (%.1f km), %s (%.1f km)",
-			i+1, len(properties), p.ID, p.Suburb,
-			school1.Name, school1.DistanceKm, school2.Name, school2.DistanceKm)
+	refreshed, err := database.RefreshPropertySearch()
+	if err != nil {
+		log.Fatalf("Failed to refresh property search: %v", err)
 	}
+	log.Printf("Refreshed %d properties in the property list", refreshed)
 
+	logRouteCache(routeCache)
 	log.Println("Done!")
 }
 
-func calculateSchoolDriveTimes() {
+func snapshotSavedSearches() {
 	dbPath := flag.String("db", "data/farm-search.db", "Database path")
-	valhallaURL := flag.String("valhalla-url", defaultValhallaURL, "Valhalla server URL")
-	all := flag.Bool("all", false, "Recalculate all properties, not just missing ones")
+	valhallaURL := flag.String("valhalla-url", defaultValhallaURL, "Valhalla server URL, for drive time area filters")
+	keepDays := flag.Int("keep-days", 90, "Delete snapshots older than this many days (each search's latest is kept)")
 	flag.Parse()
 
 	database, err := db.New(*dbPath)
@@ -568,144 +701,173 @@ func calculateSchoolDriveTimes() {
 	}
 	defer database.Close()
 
+	searches, err := database.ListSavedSearches()
+	if err != nil {
+		log.Fatalf("Failed to list saved searches: %v", err)
+	}
+
 	ctx := context.Background()
+	isochrones := service.NewIsochroneService(database, geo.NewIsochroneGenerator(*valhallaURL))
+	snapshots := service.NewSavedSearchService(database, service.NewPropertyService(database, isochrones))
+	failed := 0
+	for i := range searches {
+		n, err := snapshots.Snapshot(ctx, &searches[i])
+		if err != nil {
+			log.Printf("%-20s failed: %v", searches[i].Name, err)
+			failed++
+			continue
+		}
+		log.Printf("%-20s %d matches", searches[i].Name, n)
+	}
 
-	// Load schools to get coordinates
-	schoolData := geo.NewSchoolData()
-	if err := schoolData.LoadFromNSWData(ctx); err != nil {
-		log.Printf("Warning: Could not load school data: %v", err)
+	if *keepDays > 0 {
+		pruned, err := database.PruneSearchSnapshots(time.Now().AddDate(0, 0, -*keepDays))
+		if err != nil {
+			log.Fatalf("Failed to prune snapshots: %v", err)
+		}
+		log.Printf("Deleted %d snapshots older than %d days", pruned, *keepDays)
 	}
-	log.Printf("Loaded %d schools", len(schoolData.Schools))
 
-	// Build a map of school name -> coordinates for quick lookup
-	schoolCoords := make(map[string]geo.School)
-	for _, school := range schoolData.Schools {
-		schoolCoords[school.Name] = school
+	if failed > 0 {
+		log.Fatalf("%d saved searches failed", failed)
 	}
+	log.Println("Done!")
+}
 
-	// Create router
-	log.Printf("Using Valhalla at %s", *valhallaURL)
-	router := geo.NewRouter(*valhallaURL)
+func reportWatchlists() {
+	dbPath := flag.String("db", "data/farm-search.db", "Database path")
+	days := flag.Int("days", 7, "Days each report covers, up to now")
+	flag.Parse()
 
-	// Get properties that need drive times calculated
-	var properties []struct {
-		ID             int64   `db:"id"`
-		Latitude       float64 `db:"latitude"`
-		Longitude      float64 `db:"longitude"`
-		Suburb         string  `db:"suburb"`
-		NearestSchool1 string  `db:"nearest_school_1"`
-		NearestSchool2 string  `db:"nearest_school_2"`
-	}
-
-	var query string
-	if *all {
-		query = `SELECT id, latitude, longitude, COALESCE(suburb, '') as suburb, 
-				 nearest_school_1, COALESCE(nearest_school_2, '') as nearest_school_2
-				 FROM properties 
-				 WHERE latitude IS NOT NULL AND longitude IS NOT NULL 
-				 AND nearest_school_1 IS NOT NULL`
-	} else {
-		query = `SELECT id, latitude, longitude, COALESCE(suburb, '') as suburb,
-				 nearest_school_1, COALESCE(nearest_school_2, '') as nearest_school_2
-				 FROM properties 
-				 WHERE latitude IS NOT NULL AND longitude IS NOT NULL 
-				 AND nearest_school_1 IS NOT NULL AND nearest_school_1_mins IS NULL`
+	if *days < 1 {
+		log.Fatal("-days must be at least 1")
 	}
 
-	err = database.Select(&properties, query)
+	database, err := db.New(*dbPath)
 	if err != nil {
-		log.Fatalf("Failed to get properties: %v", err)
+		log.Fatalf("Failed to open database: %v", err)
 	}
+	defer database.Close()
 
-	if len(properties) == 0 {
-		log.Println("No properties need school drive time calculation")
-		return
+	watchlists, err := database.ListWatchlists()
+	if err != nil {
+		log.Fatalf("Failed to list watchlists: %v", err)
 	}
 
-	log.Printf("Calculating drive times to nearest schools for %d properties...", len(properties))
-
-	success := 0
+	ctx := context.Background()
+	// Watchlists have no drive time filters, so Valhalla is never called
+	isochrones := service.NewIsochroneService(database, geo.NewIsochroneGenerator(defaultValhallaURL))
+	reports := service.NewWatchlistService(database, service.NewPropertyService(database, isochrones))
+	publisher := mustEventsFromEnv()
+	end := time.Now().UTC().Truncate(time.Second)
+	start := end.AddDate(0, 0, -*days)
+	var reported []events.Event
 	failed := 0
+	for i := range watchlists {
+		w := &watchlists[i]
+		report, err := reports.Report(ctx, w, start, end)
+		if err != nil {
+			log.Printf("%-20s failed: %v", w.Name, err)
+			failed++
+			continue
+		}
+		s := report.Summary
+		log.Printf("%-20s %d listings, %d new, %d price changes, %d delisted",
+			w.Name, s.Listings, len(s.NewListings), len(s.PriceChanges), s.Delisted)
+		reported = append(reported, events.Reported(w, report))
+	}
+	events.Send(ctx, publisher, reported)
 
-	for i, p := range properties {
-		var school1Mins, school2Mins *int
+	if failed > 0 {
+		log.Fatalf("%d watchlists failed", failed)
+	}
+	log.Println("Done!")
+}
 
-		// Get drive time to nearest school 1
-		if school1, ok := schoolCoords[p.NearestSchool1]; ok {
-			result, err := router.GetRoute(ctx, p.Latitude, p.Longitude, school1.Latitude, school1.Longitude)
-			if err != nil {
-				log.Printf("[%d/%d] Failed route to %s for property %d: %v",
-					i+1, len(properties), p.NearestSchool1, p.ID, err)
-				failed++
-				continue
-			}
-			mins := int(result.DurationMins + 0.5)
-			school1Mins = &mins
-		}
+func publishSnapshot() {
+	dbPath := flag.String("db", "data/farm-search.db", "Database path")
+	output := flag.String("output", "publish", "Output directory (its images directory is replaced)")
+	query := flag.String("query", "", `Properties to include, as an /api/properties filter query string, e.g. "tags=shortlist"`)
+	idsArg := flag.String("ids", "", "Comma-separated property IDs to include, after any -query matches")
+	title := flag.String("title", "Farm shortlist", "Page heading")
+	maxImages := flag.Int("max-images", 6, "Images to download per property (0 for none)")
+	valhallaURL := flag.String("valhalla-url", defaultValhallaURL, "Valhalla server URL, for drive time area filters")
+	anchorArg := anchorFlag()
+	flag.Parse()
 
-		// Get drive time to nearest school 2 (if exists)
-		if p.NearestSchool2 != "" {
-			if school2, ok := schoolCoords[p.NearestSchool2]; ok {
-				result, err := router.GetRoute(ctx, p.Latitude, p.Longitude, school2.Latitude, school2.Longitude)
-				if err != nil {
-					log.Printf("[%d/%d] Failed route to %s for property %d: %v",
-						i+1, len(properties), p.NearestSchool2, p.ID, err)
-					// Continue anyway, we at least have school 1
-				} else {
-					mins := int(result.DurationMins + 0.5)
-					school2Mins = &mins
-				}
-			}
-		}
+	if *query == "" && *idsArg == "" {
+		log.Fatal("-query or -ids is required")
+	}
+	anchor := mustParseAnchor(*anchorArg)
 
-		// Save to database (also save coordinates for routing if we looked them up)
-		var school1Lat, school1Lng, school2Lat, school2Lng *float64
-		if school1, ok := schoolCoords[p.NearestSchool1]; ok {
-			school1Lat = &school1.Latitude
-			school1Lng = &school1.Longitude
-		}
-		if p.NearestSchool2 != "" {
-			if school2, ok := schoolCoords[p.NearestSchool2]; ok {
-				school2Lat = &school2.Latitude
-				school2Lng = &school2.Longitude
-			}
-		}
+	database, err := db.New(*dbPath)
+	if err != nil {
+		log.Fatalf("Failed to open database: %v", err)
+	}
+	defer database.Close()
 
-		_, err = database.Exec(`
-			UPDATE properties 
-			SET nearest_school_1_mins = ?, nearest_school_1_lat = ?, nearest_school_1_lng = ?,
-			    nearest_school_2_mins = ?, nearest_school_2_lat = ?, nearest_school_2_lng = ?
-			WHERE id = ?`,
-			school1Mins, school1Lat, school1Lng, school2Mins, school2Lat, school2Lng, p.ID)
+	ctx := context.Background()
+	isochrones := service.NewIsochroneService(database, geo.NewIsochroneGenerator(*valhallaURL))
+	properties := service.NewPropertyService(database, isochrones)
 
+	var ids []int64
+	if *query != "" {
+		values, err := url.ParseQuery(strings.TrimPrefix(*query, "?"))
 		if err != nil {
-			log.Printf("[%d/%d] Failed to save for property %d: %v", i+1, len(properties), p.ID, err)
-			failed++
+			log.Fatalf("Invalid -query: %v", err)
+		}
+		matches, err := properties.List(ctx, service.ParsePropertyFilter(values))
+		if err != nil {
+			log.Fatalf("Failed to list properties: %v", err)
+		}
+		for _, m := range matches {
+			ids = append(ids, m.ID)
+		}
+	}
+	for _, s := range strings.Split(*idsArg, ",") {
+		if s = strings.TrimSpace(s); s == "" {
 			continue
 		}
+		id, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			log.Fatalf("Invalid property ID %q", s)
+		}
+		ids = append(ids, id)
+	}
 
-		school1Str := "N/A"
-		if school1Mins != nil {
-			school1Str = fmt.Sprintf("%d min", *school1Mins)
+	// Duplicate listings resolve to their canonical property, shown once
+	seen := make(map[int64]bool)
+	var selected []*models.PropertyDetail
+	for _, id := range ids {
+		p, err := properties.Get(id)
+		if err != nil {
+			log.Printf("Skipping property %d: %v", id, err)
+			continue
 		}
-		school2Str := "N/A"
-		if school2Mins != nil {
-			school2Str = fmt.Sprintf("%d min", *school2Mins)
+		if !seen[p.ID] {
+			seen[p.ID] = true
+			selected = append(selected, p)
 		}
+	}
+	if len(selected) == 0 {
+		log.Fatal("No properties to publish")
+	}
 
-		log.Printf("[%d/%d] Property %d (%s): %s (%s), %s (%s)",
-			i+1, len(properties), p.ID, p.Suburb,
-			p.NearestSchool1, school1Str, p.NearestSchool2, school2Str)
-
-		success++
+	log.Printf("Publishing %d properties to %s...", len(selected), *output)
+	stats, err := publish.NewPublisher().Write(ctx, *output, selected, publish.Options{
+		Title:      *title,
+		AnchorName: anchor.Name,
+		MaxImages:  *maxImages,
+	})
+	if err != nil {
+		log.Fatalf("Failed to publish: %v", err)
 	}
 
-	log.Printf("Done! Success: %d, Failed: %d", success, failed)
+	log.Printf("Done! %d properties, %d images (%d failed) in %s", stats.Properties, stats.Images, stats.FailedImages, *output)
 }
 
-func fetchCadastralLots() {
+func computeScores() {
 	dbPath := flag.String("db", "data/farm-search.db", "Database path")
-	all := flag.Bool("all", false, "Fetch lots for all properties, not just those without lots")
 	flag.Parse()
 
 	database, err := db.New(*dbPath)
@@ -714,126 +876,125 @@ func fetchCadastralLots() {
 	}
 	defer database.Close()
 
-	ctx := context.Background()
-
-	// Create cadastral client
-	client := geo.NewCadastralClient()
+	profiles, err := database.ListScoreProfiles()
+	if err != nil {
+		log.Fatalf("Failed to list score profiles: %v", err)
+	}
+	if len(profiles) == 0 {
+		log.Println("No score profiles. Create one with POST /api/score-profiles")
+		return
+	}
 
-	// Get properties that need cadastral lots
-	var properties []struct {
-		ID        int64   `db:"id"`
-		Latitude  float64 `db:"latitude"`
-		Longitude float64 `db:"longitude"`
-		Address   string  `db:"address"`
-		Suburb    string  `db:"suburb"`
+	scoring := service.NewScoringService(database)
+	for i := range profiles {
+		n, err := scoring.Compute(&profiles[i])
+		if err != nil {
+			log.Fatalf("Failed to score profile %q: %v", profiles[i].Name, err)
+		}
+		log.Printf("%-20s scored %d properties", profiles[i].Name, n)
 	}
 
-	var query string
-	if *all {
-		query = `SELECT id, latitude, longitude, COALESCE(address, '') as address, COALESCE(suburb, '') as suburb 
-				 FROM properties WHERE latitude IS NOT NULL AND longitude IS NOT NULL`
-	} else {
-		query = `SELECT p.id, p.latitude, p.longitude, COALESCE(p.address, '') as address, COALESCE(p.suburb, '') as suburb 
-				 FROM properties p
-				 LEFT JOIN property_lots pl ON p.id = pl.property_id
-				 WHERE p.latitude IS NOT NULL AND p.longitude IS NOT NULL 
-				 AND pl.property_id IS NULL`
+	log.Println("Done!")
+}
+
+func importAmenities() {
+	dbPath := flag.String("db", "data/farm-search.db", "Database path")
+	amenityType := flag.String("type", "", "Amenity type, e.g. hospital (required)")
+	path := flag.String("path", "", "CSV with name, latitude and longitude columns, optionally detail (required)")
+	source := flag.String("source", "import", "Source recorded for the amenities")
+	flag.Parse()
+
+	if *amenityType == "" || *path == "" {
+		log.Fatal("An amenity type and CSV are required. Use -type hospital -path hospitals.csv")
+	}
+	if *amenityType == service.AmenityTown {
+		log.Fatal("Towns are built in (internal/geo) and can't be imported")
 	}
 
-	err = database.Select(&properties, query)
+	f, err := os.Open(*path)
 	if err != nil {
-		log.Fatalf("Failed to get properties: %v", err)
+		log.Fatalf("Failed to open CSV: %v", err)
 	}
+	defer f.Close()
 
-	if len(properties) == 0 {
-		log.Println("No properties need cadastral lot lookup")
-		return
+	parsed, err := geo.ParseAmenitiesCSV(f)
+	if err != nil {
+		log.Fatalf("Failed to parse CSV: %v", err)
+	}
+	if len(parsed) == 0 {
+		log.Fatal("No amenities with coordinates found in CSV")
 	}
 
-	log.Printf("Fetching cadastral lots for %d properties...", len(properties))
-
-	success := 0
-	failed := 0
-	lotsFound := 0
+	database, err := db.New(*dbPath)
+	if err != nil {
+		log.Fatalf("Failed to open database: %v", err)
+	}
+	defer database.Close()
 
-	for i, p := range properties {
-		// Fetch lots at the property's coordinates
-		lots, err := client.FetchLotsAtPoint(ctx, p.Longitude, p.Latitude)
-		if err != nil {
-			log.Printf("[%d/%d] Failed for property %d (%s): %v",
-				i+1, len(properties), p.ID, p.Suburb, err)
-			failed++
-			time.Sleep(500 * time.Millisecond) // Rate limiting
-			continue
+	amenities := make([]models.Amenity, len(parsed))
+	for i, a := range parsed {
+		amenities[i] = models.Amenity{
+			Name:      a.Name,
+			Detail:    sql.NullString{String: a.Detail, Valid: a.Detail != ""},
+			Latitude:  a.Latitude,
+			Longitude: a.Longitude,
 		}
+	}
 
-		if len(lots) == 0 {
-			log.Printf("[%d/%d] Property %d (%s): No lots found",
-				i+1, len(properties), p.ID, p.Suburb)
-			failed++
-			time.Sleep(500 * time.Millisecond)
-			continue
-		}
+	n, err := database.ReplaceAmenities(strings.ToLower(*amenityType), *source, amenities)
+	if err != nil {
+		log.Fatalf("Failed to save amenities: %v", err)
+	}
+	log.Printf("Done! Replaced %s amenities with %d from %s", strings.ToLower(*amenityType), n, *path)
+}
 
-		// Save each lot and link to property
-		for _, lot := range lots {
-			// Calculate centroid
-			centroidLat, centroidLng, err := geo.CalculateLotCentroid(lot.Geometry)
-			if err != nil {
-				log.Printf("  Warning: Could not calculate centroid for lot %s: %v", lot.LotIDString, err)
-				centroidLat, centroidLng = p.Latitude, p.Longitude // Use property coords as fallback
-			}
+func importSuburbs() {
+	dbPath := flag.String("db", "data/farm-search.db", "Database path")
+	path := flag.String("path", "", "ABS Suburbs and Localities (SAL) GeoJSON (required)")
+	state := flag.String("state", "New South Wales", "State to import (empty for all)")
+	flag.Parse()
 
-			// Convert geometry to JSON string
-			geomJSON, err := geo.LotGeometryToJSON(lot.Geometry)
-			if err != nil {
-				log.Printf("  Warning: Could not serialize geometry for lot %s: %v", lot.LotIDString, err)
-				continue
-			}
+	if *path == "" {
+		log.Fatal("A GeoJSON file is required. Use -path SAL_2021_AUST_GDA2020.geojson")
+	}
 
-			// Save lot to database
-			lotID, err := database.SaveCadastralLot(
-				lot.LotIDString,
-				lot.LotNumber,
-				lot.PlanLabel,
-				lot.AreaSqm,
-				centroidLat,
-				centroidLng,
-				geomJSON,
-			)
-			if err != nil {
-				log.Printf("  Warning: Could not save lot %s: %v", lot.LotIDString, err)
-				continue
-			}
+	f, err := os.Open(*path)
+	if err != nil {
+		log.Fatalf("Failed to open GeoJSON: %v", err)
+	}
+	defer f.Close()
 
-			// Link property to lot
-			err = database.LinkPropertyToLot(p.ID, lotID)
-			if err != nil {
-				log.Printf("  Warning: Could not link property %d to lot %d: %v", p.ID, lotID, err)
-				continue
-			}
+	parsed, err := geo.ReadSuburbBoundaries(f, *state)
+	if err != nil {
+		log.Fatalf("Failed to read suburb boundaries: %v", err)
+	}
+	if len(parsed) == 0 {
+		log.Fatalf("No suburb boundaries found for %q", *state)
+	}
 
-			lotsFound++
-		}
+	database, err := db.New(*dbPath)
+	if err != nil {
+		log.Fatalf("Failed to open database: %v", err)
+	}
+	defer database.Close()
 
-		location := p.Suburb
-		if p.Address != "" {
-			location = p.Address
+	boundaries := make([]models.SuburbBoundary, len(parsed))
+	for i, b := range parsed {
+		geometry, err := json.Marshal(b.Geometry)
+		if err != nil {
+			log.Fatalf("Failed to encode geometry of %s: %v", b.Name, err)
 		}
-		log.Printf("[%d/%d] Property %d (%s): Found %d lots",
-			i+1, len(properties), p.ID, location, len(lots))
-
-		success++
-
-		// Rate limiting to avoid overloading NSW Spatial Services
-		time.Sleep(500 * time.Millisecond)
+		boundaries[i] = models.SuburbBoundary{Code: b.Code, Name: b.Name, State: b.State, Geometry: string(geometry)}
 	}
 
-	totalLots, _ := database.GetCadastralLotCount()
-	log.Printf("Done! Properties: %d success, %d failed. Total lots in DB: %d", success, failed, totalLots)
+	n, err := database.ReplaceSuburbBoundaries(boundaries)
+	if err != nil {
+		log.Fatalf("Failed to save suburb boundaries: %v", err)
+	}
+	log.Printf("Done! Replaced suburb boundaries with %d from %s", n, *path)
 }
 
-func backfillLandSizeFromCadastral() {
+func computeVelocity() {
 	dbPath := flag.String("db", "data/farm-search.db", "Database path")
 	flag.Parse()
 
@@ -843,77 +1004,107 @@ func backfillLandSizeFromCadastral() {
 	}
 	defer database.Close()
 
-	// 10 hectares = 100,000 sqm
-	thresholdSqm := 100000.0
-
-	// Get properties with cadastral lots where land_size_sqm is NULL or < 10 HA
-	properties, err := database.GetPropertiesWithSmallLandSize(thresholdSqm)
+	// The whole history is read with no drive time filters, so Valhalla is never called
+	isochrones := service.NewIsochroneService(database, geo.NewIsochroneGenerator(defaultValhallaURL))
+	suburbs := service.NewSuburbStatsService(database, service.NewPropertyService(database, isochrones))
+	result, err := suburbs.ComputeVelocity(context.Background(), time.Now())
 	if err != nil {
-		log.Fatalf("Failed to get properties: %v", err)
+		log.Fatalf("Failed to compute velocity: %v", err)
 	}
+	log.Printf("Done! %d spells on the market ended in the last 24 months (%d outside every suburb); saved %d suburbs",
+		result.Spells, result.Unplaced, result.Suburbs)
+}
 
-	if len(properties) == 0 {
-		log.Println("No properties need land size backfill")
-		return
-	}
+func importLGAs() {
+	dbPath := flag.String("db", "data/farm-search.db", "Database path")
+	path := flag.String("path", "", "ABS Local Government Areas (LGA) GeoJSON (required)")
+	state := flag.String("state", "New South Wales", "State to import (empty for all)")
+	flag.Parse()
 
-	log.Printf("Found %d properties with land size < 10 HA that have cadastral data", len(properties))
+	if *path == "" {
+		log.Fatal("A GeoJSON file is required. Use -path LGA_2023_AUST_GDA2020.geojson")
+	}
 
-	updated := 0
-	skipped := 0
+	f, err := os.Open(*path)
+	if err != nil {
+		log.Fatalf("Failed to open GeoJSON: %v", err)
+	}
+	defer f.Close()
 
-	for i, p := range properties {
-		// Get total cadastral area for this property
-		totalArea, err := database.GetTotalCadastralAreaForProperty(p.ID)
-		if err != nil {
-			log.Printf("[%d/%d] Property %d: Failed to get cadastral area: %v", i+1, len(properties), p.ID, err)
-			skipped++
-			continue
-		}
+	parsed, err := geo.ReadLGABoundaries(f, *state)
+	if err != nil {
+		log.Fatalf("Failed to read LGA boundaries: %v", err)
+	}
+	if len(parsed) == 0 {
+		log.Fatalf("No LGA boundaries found for %q", *state)
+	}
 
-		if totalArea <= 0 {
-			log.Printf("[%d/%d] Property %d (%s): No cadastral area found", i+1, len(properties), p.ID, p.Suburb)
-			skipped++
-			continue
-		}
+	database, err := db.New(*dbPath)
+	if err != nil {
+		log.Fatalf("Failed to open database: %v", err)
+	}
+	defer database.Close()
 
-		// Update the property's land size
-		err = database.UpdatePropertyLandSize(p.ID, totalArea)
+	boundaries := make([]models.RegionBoundary, len(parsed))
+	for i, b := range parsed {
+		geometry, err := json.Marshal(b.Geometry)
 		if err != nil {
-			log.Printf("[%d/%d] Property %d: Failed to update: %v", i+1, len(properties), p.ID, err)
-			skipped++
-			continue
-		}
-
-		oldHA := p.LandSizeSqm / 10000
-		newHA := totalArea / 10000
-
-		location := p.Suburb
-		if p.Address != "" {
-			location = p.Address
+			log.Fatalf("Failed to encode geometry of %s: %v", b.Name, err)
 		}
-		log.Printf("[%d/%d] Property %d (%s): %.1f HA -> %.1f HA", i+1, len(properties), p.ID, location, oldHA, newHA)
-		updated++
+		boundaries[i] = models.RegionBoundary{Kind: "lga", Code: b.Code, Name: b.Name, State: b.State, Geometry: string(geometry)}
 	}
 
-	log.Printf("Done! Updated %d properties, skipped %d", updated, skipped)
+	n, err := database.ReplaceLGABoundaries(boundaries)
+	if err != nil {
+		log.Fatalf("Failed to save LGA boundaries: %v", err)
+	}
+	log.Printf("Done! Replaced LGA boundaries with %d from %s", n, *path)
 }
 
-func fetchREADetails() {
+func importPostcodes() {
 	dbPath := flag.String("db", "data/farm-search.db", "Database path")
-	scrapingBeeKey := flag.String("scrapingbee", "", "ScrapingBee API key (required)")
-	limit := flag.Int("limit", 0, "Maximum number of properties to process (0 = no limit)")
-	workers := flag.Int("workers", 5, "Number of parallel workers")
-	maxRetries := flag.Int("retries", 3, "Maximum retries per property")
+	path := flag.String("path", "", "ABS Postal Areas (POA) GeoJSON (required)")
+	nswOnly := flag.Bool("nsw", true, "Only import postcodes overlapping NSW")
 	flag.Parse()
 
-	// Also check environment variable
-	if *scrapingBeeKey == "" {
-		*scrapingBeeKey = os.Getenv("SCRAPINGBEE_API_KEY")
+	if *path == "" {
+		log.Fatal("A GeoJSON file is required. Use -path POA_2021_AUST_GDA2020.geojson")
 	}
 
-	if *scrapingBeeKey == "" {
-		log.Fatal("ScrapingBee API key is required. Use -scrapingbee flag or set SCRAPINGBEE_API_KEY env var")
+	f, err := os.Open(*path)
+	if err != nil {
+		log.Fatalf("Failed to open GeoJSON: %v", err)
+	}
+	defer f.Close()
+
+	parsed, err := geo.ReadPostcodeBoundaries(f)
+	if err != nil {
+		log.Fatalf("Failed to read postcode boundaries: %v", err)
+	}
+
+	// Postal areas have no state to go by, so keep those whose bounds
+	// overlap NSW's
+	b := geo.NSWBounds
+	var boundaries []models.PostcodeBoundary
+	for _, p := range parsed {
+		if *nswOnly {
+			area, err := geo.NewArea(&geo.GeoJSONFeatureCollection{Features: []geo.GeoJSONFeature{{Geometry: p.Geometry}}})
+			if err != nil || area.Empty() {
+				continue
+			}
+			swLat, swLng, neLat, neLng := area.Bounds()
+			if neLat < b.SWLat || swLat > b.NELat || neLng < b.SWLng || swLng > b.NELng {
+				continue
+			}
+		}
+		geometry, err := json.Marshal(p.Geometry)
+		if err != nil {
+			log.Fatalf("Failed to encode geometry of %s: %v", p.Code, err)
+		}
+		boundaries = append(boundaries, models.PostcodeBoundary{Postcode: p.Code, Geometry: string(geometry)})
+	}
+	if len(boundaries) == 0 {
+		log.Fatalf("No postcode boundaries found in %s", *path)
 	}
 
 	database, err := db.New(*dbPath)
@@ -922,154 +1113,2173 @@ func fetchREADetails() {
 	}
 	defer database.Close()
 
-	ctx := context.Background()
+	n, err := database.ReplacePostcodeBoundaries(boundaries)
+	if err != nil {
+		log.Fatalf("Failed to save postcode boundaries: %v", err)
+	}
+	log.Printf("Done! Replaced postcode boundaries with %d from %s", n, *path)
+}
 
-	// Create REA scraper with ScrapingBee
-	reaScraper := scraper.NewREAScraperWithScrapingBee(*scrapingBeeKey)
+func checkBoundaries() {
+	dbPath := flag.String("db", "data/farm-search.db", "Database path")
+	fix := flag.Bool("fix", false, "Correct mismatched (or missing) suburbs and postcodes to the boundaries', keeping the listing's")
+	flag.Parse()
 
-	// Get REA properties that haven't had details scraped yet
-	properties, err := database.GetREAPropertiesWithoutDetails(*limit)
+	database, err := db.New(*dbPath)
 	if err != nil {
-		log.Fatalf("Failed to get properties: %v", err)
+		log.Fatalf("Failed to open database: %v", err)
 	}
+	defer database.Close()
 
-	if len(properties) == 0 {
-		log.Println("No REA properties need detail scraping")
-		return
+	result, err := service.NewBoundaryCheckService(database).Check(*fix)
+	if err != nil {
+		log.Fatalf("Boundary check failed: %v", err)
+	}
+	log.Printf("Checked %d properties (%d outside every suburb boundary): %d suburb and %d postcode mismatches",
+		result.Checked, result.Outside, result.SuburbMismatches, result.PostcodeMismatches)
+	if *fix {
+		log.Printf("Corrected %d suburbs and %d postcodes", result.SuburbsFixed, result.PostcodesFixed)
 	}
+}
 
-	log.Printf("Fetching details for %d REA properties with %d workers...", len(properties), *workers)
+func importEnergyDevelopments() {
+	dbPath := flag.String("db", "data/farm-search.db", "Database path")
+	path := flag.String("path", "", "CSV with name, type, status, latitude and longitude columns, optionally capacity_mw (required)")
+	source := flag.String("source", "import", "Source recorded for the developments, e.g. nsw-planning; each import replaces its source")
+	flag.Parse()
 
-	// Create channels for work distribution and results
-	type workItem struct {
-		index    int
-		property db.REAPropertyForDetails
-	}
-	type result struct {
-		index   int
-		id      int64
-		success bool
-		found   []string
-		err     error
+	if *path == "" {
+		log.Fatal("A CSV is required. Use -path data/wind-solar.csv -source nsw-planning")
 	}
 
-	workChan := make(chan workItem, len(properties))
-	resultChan := make(chan result, len(properties))
+	f, err := os.Open(*path)
+	if err != nil {
+		log.Fatalf("Failed to open CSV: %v", err)
+	}
+	defer f.Close()
 
-	// Start workers
-	for w := 0; w < *workers; w++ {
-		go func(workerID int) {
-			for work := range workChan {
-				p := work.property
-				var lastErr error
-				var details *models.Property
+	parsed, err := geo.ParseEnergyDevelopmentsCSV(f)
+	if err != nil {
+		log.Fatalf("Failed to parse CSV: %v", err)
+	}
+	if len(parsed) == 0 {
+		log.Fatal("No wind or solar farms with coordinates found in CSV")
+	}
 
-				// Retry loop
-				for attempt := 1; attempt <= *maxRetries; attempt++ {
-					fetchedDetails, fetchErr := reaScraper.FetchListingDetails(ctx, p.URL)
-					if fetchErr == nil {
-						details = fetchedDetails
-						lastErr = nil
-						break
-					}
-					lastErr = fetchErr
+	database, err := db.New(*dbPath)
+	if err != nil {
+		log.Fatalf("Failed to open database: %v", err)
+	}
+	defer database.Close()
 
-					if attempt < *maxRetries {
-						// Exponential backoff: 2s, 4s, 8s...
-						backoff := time.Duration(1<<attempt) * time.Second
-						log.Printf("[Worker %d] Property %d attempt %d/%d failed: %v, retrying in %v",
-							workerID, p.ID, attempt, *maxRetries, fetchErr, backoff)
-						time.Sleep(backoff)
-					}
-				}
+	developments := make([]models.EnergyDevelopment, len(parsed))
+	for i, d := range parsed {
+		developments[i] = models.EnergyDevelopment{
+			Name:      d.Name,
+			Kind:      d.Kind,
+			Status:    d.Status,
+			Latitude:  d.Latitude,
+			Longitude: d.Longitude,
+		}
+		if d.CapacityMW != nil {
+			developments[i].CapacityMW = sql.NullFloat64{Float64: *d.CapacityMW, Valid: true}
+		}
+	}
 
-				if lastErr != nil {
-					resultChan <- result{index: work.index, id: p.ID, success: false, err: lastErr}
-					continue
-				}
+	n, err := database.ReplaceEnergyDevelopments(*source, developments)
+	if err != nil {
+		log.Fatalf("Failed to save energy developments: %v", err)
+	}
+	log.Printf("Replaced %s energy developments with %d from %s", *source, n, *path)
 
-				// Prepare values for update
-				var description, images string
-				var landSizeSqm *float64
-				var bedrooms, bathrooms *int64
-				var priceMin, priceMax *int64
+	// Record the new developments and recompute every property against them
+	enrichment := service.NewEnrichmentService(database, nil, nil, nil)
+	if err := enrichment.MarkEnergyDevelopmentsChanged(); err != nil {
+		log.Fatalf("Failed to mark energy developments stale: %v", err)
+	}
+	stats, err := enrichment.EnergyDevelopments(false)
+	if err != nil {
+		log.Fatalf("Failed to find nearest energy developments: %v", err)
+	}
+	log.Printf("Done! Updated: %d, Failed: %d", stats.Success, stats.Failed)
+}
 
-				if details.Description.Valid && details.Description.String != "" {
-					description = details.Description.String
-				}
-				if details.Images.Valid && details.Images.String != "" && details.Images.String != "[]" {
-					images = details.Images.String
-				}
-				if details.LandSizeSqm.Valid && details.LandSizeSqm.Float64 > 0 {
-					landSizeSqm = &details.LandSizeSqm.Float64
-				}
-				if details.Bedrooms.Valid {
-					bedrooms = &details.Bedrooms.Int64
-				}
-				if details.Bathrooms.Valid {
-					bathrooms = &details.Bathrooms.Int64
-				}
-				if details.PriceMin.Valid {
-					priceMin = &details.PriceMin.Int64
-				}
-				if details.PriceMax.Valid {
-					priceMax = &details.PriceMax.Int64
-				}
+func importNoiseSources() {
+	dbPath := flag.String("db", "data/farm-search.db", "Database path")
+	path := flag.String("path", "", "OSM GeoJSON with highway=motorway/trunk, railway=rail and aeroway=runway ways (required)")
+	flag.Parse()
 
-				// Update the property with the fetched details
-				saveErr := database.UpdatePropertyFromDetails(p.ID, description, images, landSizeSqm, bedrooms, bathrooms, priceMin, priceMax)
-				if saveErr != nil {
-					resultChan <- result{index: work.index, id: p.ID, success: false, err: saveErr}
-					continue
-				}
+	if *path == "" {
+		log.Fatal("A GeoJSON file is required. Use -path data/nsw-noise.geojson")
+	}
 
-				// Build found list for logging
-				var found []string
-				if description != "" {
-					found = append(found, "description")
-				}
-				if images != "" {
-					found = append(found, "images")
-				}
-				if landSizeSqm != nil {
-					found = append(found, fmt.Sprintf("%.1f ha", *landSizeSqm/10000))
-				}
-				if bedrooms != nil {
-					found = append(found, fmt.Sprintf("%d bed", *bedrooms))
-				}
-				if bathrooms != nil {
-					found = append(found, fmt.Sprintf("%d bath", *bathrooms))
-				}
+	f, err := os.Open(*path)
+	if err != nil {
+		log.Fatalf("Failed to open GeoJSON: %v", err)
+	}
+	defer f.Close()
 
-				resultChan <- result{index: work.index, id: p.ID, success: true, found: found}
+	layers, err := geo.ReadNoiseSources(f)
+	if err != nil {
+		log.Fatalf("Failed to read noise sources: %v", err)
+	}
+	if len(layers) == 0 {
+		log.Fatal("No highways, railways or runways found in GeoJSON")
+	}
+
+	database, err := db.New(*dbPath)
+	if err != nil {
+		log.Fatalf("Failed to open database: %v", err)
+	}
+	defer database.Close()
+
+	// Layers missing from the file are left as they are, so they can be
+	// imported from separate extracts
+	for _, layer := range geo.NoiseLayers {
+		if len(layers[layer]) == 0 {
+			log.Printf("No %s in %s, keeping the existing layer", layer, *path)
+			continue
+		}
+		features := make([]models.ExclusionFeature, len(layers[layer]))
+		for i, e := range layers[layer] {
+			geometry, err := json.Marshal(e.Geometry)
+			if err != nil {
+				log.Fatalf("Failed to encode geometry of %s feature %d: %v", layer, i, err)
 			}
-		}(w)
+			features[i] = models.ExclusionFeature{Name: e.Name, Geometry: string(geometry)}
+		}
+		n, err := database.ReplaceExclusionLayer(layer, features)
+		if err != nil {
+			log.Fatalf("Failed to save %s: %v", layer, err)
+		}
+		log.Printf("Replaced %s with %d features", layer, n)
 	}
 
-	// Send work to workers
-	for i, p := range properties {
-		workChan <- workItem{index: i, property: p}
+	// Record the new layers and remeasure every property against them
+	enrichment := service.NewEnrichmentService(database, nil, nil, nil)
+	if err := enrichment.MarkNoiseSourcesChanged(); err != nil {
+		log.Fatalf("Failed to mark noise sources stale: %v", err)
 	}
-	close(workChan)
+	stats, err := enrichment.NoiseDistances(false)
+	if err != nil {
+		log.Fatalf("Failed to measure noise source distances: %v", err)
+	}
+	log.Printf("Done! Updated: %d, Failed: %d", stats.Success, stats.Failed)
+}
 
-	// Collect results
-	success := 0
-	failed := 0
+func importOverlays() {
+	dbPath := flag.String("db", "data/farm-search.db", "Database path")
+	koala := flag.String("koala", "", "GeoJSON of the koala habitat SEPP development application map")
+	biodiversity := flag.String("biodiversity", "", "GeoJSON of the biodiversity values map")
+	flag.Parse()
 
-	for i := 0; i < len(properties); i++ {
-		r := <-resultChan
-		if r.success {
-			success++
-			if len(r.found) > 0 {
-				log.Printf("[%d/%d] Property %d: found %v", r.index+1, len(properties), r.id, r.found)
-			} else {
-				log.Printf("[%d/%d] Property %d: no new details found", r.index+1, len(properties), r.id)
+	paths := map[string]string{geo.OverlayKoalaHabitat: *koala, geo.OverlayBiodiversity: *biodiversity}
+	if *koala == "" && *biodiversity == "" {
+		log.Fatal("A GeoJSON file is required. Use -koala data/koala-habitat.geojson and/or -biodiversity data/bv-map.geojson")
+	}
+
+	database, err := db.New(*dbPath)
+	if err != nil {
+		log.Fatalf("Failed to open database: %v", err)
+	}
+	defer database.Close()
+
+	// An overlay not given is left as it is
+	for _, layer := range geo.OverlayLayers {
+		if paths[layer] == "" {
+			continue
+		}
+		f, err := os.Open(paths[layer])
+		if err != nil {
+			log.Fatalf("Failed to open GeoJSON: %v", err)
+		}
+		read, err := geo.ReadExclusionFeatures(f)
+		f.Close()
+		if err != nil {
+			log.Fatalf("Failed to read %s: %v", paths[layer], err)
+		}
+
+		var features []models.ExclusionFeature
+		for i, e := range read {
+			if e.Geometry.Type != "Polygon" && e.Geometry.Type != "MultiPolygon" {
+				continue
 			}
-		} else {
-			failed++
-			log.Printf("[%d/%d] Property %d: FAILED after %d retries: %v", r.index+1, len(properties), r.id, *maxRetries, r.err)
+			geometry, err := json.Marshal(e.Geometry)
+			if err != nil {
+				log.Fatalf("Failed to encode geometry of %s feature %d: %v", layer, i, err)
+			}
+			features = append(features, models.ExclusionFeature{Name: e.Name, Geometry: string(geometry)})
+		}
+		if len(features) == 0 {
+			log.Fatalf("No polygons found in %s", paths[layer])
 		}
+
+		n, err := database.ReplaceExclusionLayer(layer, features)
+		if err != nil {
+			log.Fatalf("Failed to save %s: %v", layer, err)
+		}
+		log.Printf("Replaced %s with %d polygons", layer, n)
 	}
 
-	log.Printf("Done! Success: %d, Failed: %d", success, failed)
+	// Record the new overlays and remeasure every property's lots against them
+	enrichment := service.NewEnrichmentService(database, nil, nil, nil)
+	if err := enrichment.MarkOverlaysChanged(); err != nil {
+		log.Fatalf("Failed to mark overlays stale: %v", err)
+	}
+	stats, err := enrichment.Overlays(false)
+	if err != nil {
+		log.Fatalf("Failed to measure overlay coverage: %v", err)
+	}
+	log.Printf("Done! Updated: %d, Failed: %d", stats.Success, stats.Failed)
+}
+
+func importBiosecurity() {
+	dbPath := flag.String("db", "data/farm-search.db", "Database path")
+	regions := flag.String("regions", "", "GeoJSON of the Local Land Services regions")
+	weeds := flag.String("weeds", "", "GeoJSON of declared priority weed zones (weed and priority attributes)")
+	flag.Parse()
+
+	if *regions == "" && *weeds == "" {
+		log.Fatal("A GeoJSON file is required. Use -regions data/lls-regions.geojson and/or -weeds data/weed-zones.geojson")
+	}
+
+	database, err := db.New(*dbPath)
+	if err != nil {
+		log.Fatalf("Failed to open database: %v", err)
+	}
+	defer database.Close()
+
+	// A kind not given is left as it is
+	for _, kind := range []struct {
+		name, path string
+		read       func(io.Reader) ([]geo.BiosecurityArea, error)
+	}{
+		{geo.BiosecurityLLSRegion, *regions, geo.ReadLLSRegions},
+		{geo.BiosecurityWeedZone, *weeds, geo.ReadWeedZones},
+	} {
+		if kind.path == "" {
+			continue
+		}
+		f, err := os.Open(kind.path)
+		if err != nil {
+			log.Fatalf("Failed to open GeoJSON: %v", err)
+		}
+		read, err := kind.read(f)
+		f.Close()
+		if err != nil {
+			log.Fatalf("Failed to read %s: %v", kind.path, err)
+		}
+		if len(read) == 0 {
+			log.Fatalf("No named polygons found in %s", kind.path)
+		}
+
+		areas := make([]models.BiosecurityArea, len(read))
+		for i, a := range read {
+			geometry, err := json.Marshal(a.Geometry)
+			if err != nil {
+				log.Fatalf("Failed to encode geometry of %s: %v", a.Name, err)
+			}
+			areas[i] = models.BiosecurityArea{Name: a.Name, Category: a.Category, Geometry: string(geometry)}
+		}
+		n, err := database.ReplaceBiosecurityAreas(kind.name, areas)
+		if err != nil {
+			log.Fatalf("Failed to save %s areas: %v", kind.name, err)
+		}
+		log.Printf("Replaced %s areas with %d polygons", kind.name, n)
+	}
+
+	// Record the new areas and retag every property
+	enrichment := service.NewEnrichmentService(database, nil, nil, nil)
+	if err := enrichment.MarkBiosecurityChanged(); err != nil {
+		log.Fatalf("Failed to mark biosecurity areas stale: %v", err)
+	}
+	stats, err := enrichment.Biosecurity(false)
+	if err != nil {
+		log.Fatalf("Failed to match biosecurity areas: %v", err)
+	}
+	log.Printf("Done! Updated: %d, Failed: %d", stats.Success, stats.Failed)
+}
+
+func importFires() {
+	dbPath := flag.String("db", "data/farm-search.db", "Database path")
+	path := flag.String("path", "", "GeoJSON of past fire extents (NPWS fire history)")
+	flag.Parse()
+
+	if *path == "" {
+		log.Fatal("A GeoJSON file is required. Use -path data/fire-history.geojson")
+	}
+
+	f, err := os.Open(*path)
+	if err != nil {
+		log.Fatalf("Failed to open GeoJSON: %v", err)
+	}
+	read, err := geo.ReadFireHistory(f)
+	f.Close()
+	if err != nil {
+		log.Fatalf("Failed to read %s: %v", *path, err)
+	}
+	if len(read) == 0 {
+		log.Fatalf("No dated fire polygons found in %s", *path)
+	}
+
+	fires := make([]models.FireExtent, len(read))
+	for i, e := range read {
+		geometry, err := json.Marshal(e.Geometry)
+		if err != nil {
+			log.Fatalf("Failed to encode geometry of fire %d: %v", i, err)
+		}
+		fires[i] = models.FireExtent{Year: e.Year, FireType: e.FireType, Name: e.Name, Geometry: string(geometry)}
+	}
+
+	database, err := db.New(*dbPath)
+	if err != nil {
+		log.Fatalf("Failed to open database: %v", err)
+	}
+	defer database.Close()
+
+	n, err := database.ReplaceFireExtents(fires)
+	if err != nil {
+		log.Fatalf("Failed to save fire history: %v", err)
+	}
+	log.Printf("Replaced fire history with %d fire extents", n)
+
+	// Record the new fire history and recheck every property's lots against it
+	enrichment := service.NewEnrichmentService(database, nil, nil, nil)
+	if err := enrichment.MarkFireHistoryChanged(); err != nil {
+		log.Fatalf("Failed to mark fire history stale: %v", err)
+	}
+	stats, err := enrichment.FireHistory(false)
+	if err != nil {
+		log.Fatalf("Failed to check fire history: %v", err)
+	}
+	log.Printf("Done! Updated: %d, Failed: %d", stats.Success, stats.Failed)
+}
+
+func importBuildings() {
+	dbPath := flag.String("db", "data/farm-search.db", "Database path")
+	path := flag.String("path", "", "Building footprint GeoJSON, a FeatureCollection or one Feature per line (required)")
+	nswOnly := flag.Bool("nsw", true, "Only keep buildings inside New South Wales' bounding box")
+	flag.Parse()
+
+	if *path == "" {
+		log.Fatal("A GeoJSON file is required. Use -path data/Australia.geojsonl")
+	}
+
+	var within *geo.BBox
+	if *nswOnly {
+		b := geo.NSWBounds
+		within = &geo.BBox{MinLat: b.SWLat, MinLng: b.SWLng, MaxLat: b.NELat, MaxLng: b.NELng}
+	}
+
+	f, err := os.Open(*path)
+	if err != nil {
+		log.Fatalf("Failed to open GeoJSON: %v", err)
+	}
+	read, err := geo.ReadBuildingFootprints(f, within)
+	f.Close()
+	if err != nil {
+		log.Fatalf("Failed to read %s: %v", *path, err)
+	}
+	if len(read) == 0 {
+		log.Fatalf("No building polygons found in %s", *path)
+	}
+
+	footprints := make([]models.BuildingFootprint, len(read))
+	for i, b := range read {
+		footprints[i] = models.BuildingFootprint{Latitude: b.Lat, Longitude: b.Lng, AreaSqm: b.AreaSqm}
+	}
+
+	database, err := db.New(*dbPath)
+	if err != nil {
+		log.Fatalf("Failed to open database: %v", err)
+	}
+	defer database.Close()
+
+	n, err := database.ReplaceBuildingFootprints(footprints)
+	if err != nil {
+		log.Fatalf("Failed to save building footprints: %v", err)
+	}
+	log.Printf("Replaced building footprints with %d buildings", n)
+
+	// Record the new footprints and recount every property's buildings
+	enrichment := service.NewEnrichmentService(database, nil, nil, nil)
+	if err := enrichment.MarkBuildingsChanged(); err != nil {
+		log.Fatalf("Failed to mark building footprints stale: %v", err)
+	}
+	stats, err := enrichment.Buildings(false)
+	if err != nil {
+		log.Fatalf("Failed to count buildings: %v", err)
+	}
+	log.Printf("Done! Updated: %d, Failed: %d", stats.Success, stats.Failed)
+}
+
+func estimateDwellings() {
+	dbPath := flag.String("db", "data/farm-search.db", "Database path")
+	all := flag.Bool("all", false, "Re-estimate all properties, not just those not yet estimated or whose description changed")
+	flag.Parse()
+
+	database, err := db.New(*dbPath)
+	if err != nil {
+		log.Fatalf("Failed to open database: %v", err)
+	}
+	defer database.Close()
+
+	enrichment := service.NewEnrichmentService(database, nil, nil, nil)
+	stats, err := enrichment.Dwellings(*all)
+	if err != nil {
+		log.Fatalf("Failed to get properties: %v", err)
+	}
+	if stats.Total == 0 {
+		return
+	}
+
+	log.Printf("Done! Properties: %d success, %d failed", stats.Success, stats.Failed)
+}
+
+func importExclusions() {
+	dbPath := flag.String("db", "data/farm-search.db", "Database path")
+	layer := flag.String("layer", "", "Layer name used by exclude_near, e.g. highways (required)")
+	path := flag.String("path", "", "GeoJSON of points, lines or polygons (required)")
+	flag.Parse()
+
+	if *layer == "" || *path == "" {
+		log.Fatal("A layer name and GeoJSON file are required. Use -layer highways -path highways.geojson")
+	}
+	if strings.ContainsAny(*layer, ":,") {
+		log.Fatal("Layer names can't contain ':' or ','")
+	}
+
+	f, err := os.Open(*path)
+	if err != nil {
+		log.Fatalf("Failed to open GeoJSON: %v", err)
+	}
+	defer f.Close()
+
+	parsed, err := geo.ReadExclusionFeatures(f)
+	if err != nil {
+		log.Fatalf("Failed to read exclusion features: %v", err)
+	}
+	if len(parsed) == 0 {
+		log.Fatal("No point, line or polygon features found in GeoJSON")
+	}
+
+	database, err := db.New(*dbPath)
+	if err != nil {
+		log.Fatalf("Failed to open database: %v", err)
+	}
+	defer database.Close()
+
+	features := make([]models.ExclusionFeature, len(parsed))
+	for i, e := range parsed {
+		geometry, err := json.Marshal(e.Geometry)
+		if err != nil {
+			log.Fatalf("Failed to encode geometry of feature %d: %v", i, err)
+		}
+		features[i] = models.ExclusionFeature{Name: e.Name, Geometry: string(geometry)}
+	}
+
+	n, err := database.ReplaceExclusionLayer(*layer, features)
+	if err != nil {
+		log.Fatalf("Failed to save exclusion layer: %v", err)
+	}
+	log.Printf("Done! Replaced exclusion layer %s with %d features from %s", *layer, n, *path)
+}
+
+func calculateDistances() {
+	dbPath := flag.String("db", "data/farm-search.db", "Database path")
+	flag.Parse()
+
+	database, err := db.New(*dbPath)
+	if err != nil {
+		log.Fatalf("Failed to open database: %v", err)
+	}
+	defer database.Close()
+
+	ctx := context.Background()
+
+	// Load schools
+	schoolData := geo.NewSchoolData()
+	if err := schoolData.LoadFromNSWData(ctx); err != nil {
+		log.Printf("Warning: Could not load school data: %v", err)
+	}
+	log.Printf("Loaded %d schools", len(schoolData.Schools))
+
+	// Get all properties
+	properties, err := database.ListProperties(db.PropertyFilter{Limit: 10000})
+	if err != nil {
+		log.Fatalf("Failed to list properties: %v", err)
+	}
+
+	log.Printf("Calculating distances for %d properties...", len(properties))
+
+	for _, p := range properties {
+		// Distance to Sydney
+		distSydney := geo.DistanceToSydney(p.Latitude, p.Longitude)
+
+		// Nearest town
+		town, distTown := geo.FindNearestTown(p.Latitude, p.Longitude)
+
+		// Nearest school
+		school, distSchool := schoolData.FindNearestSchool(p.Latitude, p.Longitude)
+
+		// Save distances
+		err := database.SavePropertyDistance(p.ID, "capital", "Sydney", distSydney)
+		if err != nil {
+			log.Printf("Failed to save Sydney distance for property %d: %v", p.ID, err)
+		}
+
+		err = database.SavePropertyDistance(p.ID, "town", town.Name, distTown)
+		if err != nil {
+			log.Printf("Failed to save town distance for property %d: %v", p.ID, err)
+		}
+
+		err = database.SavePropertyDistance(p.ID, "school", school.Name, distSchool)
+		if err != nil {
+			log.Printf("Failed to save school distance for property %d: %v", p.ID, err)
+		}
+	}
+
+	log.Println("Distance calculations complete!")
+}
+
+func seedSampleData() {
+	dbPath := flag.String("db", "data/farm-search.db", "Database path")
+	flag.Parse()
+
+	database, err := db.New(*dbPath)
+	if err != nil {
+		log.Fatalf("Failed to open database: %v", err)
+	}
+	defer database.Close()
+
+	// Read and execute the seed SQL file
+	seedFile := "scripts/seed-sample-data.sql"
+	content, err := os.ReadFile(seedFile)
+	if err != nil {
+		log.Fatalf("Failed to read seed file: %v", err)
+	}
+
+	_, err = database.Exec(string(content))
+	if err != nil {
+		log.Fatalf("Failed to execute seed SQL: %v", err)
+	}
+
+	count, _ := database.GetPropertyCount()
+	log.Printf("Database seeded successfully! Total properties: %d", count)
+}
+
+func calculateDriveTimes() {
+	dbPath := flag.String("db", "data/farm-search.db", "Database path")
+	valhallaURL := flag.String("valhalla-url", defaultValhallaURL, "Valhalla server URL")
+	wait := valhallaWaitFlag()
+	all := flag.Bool("all", false, "Recalculate all properties, not just missing ones")
+	anchorArg := anchorFlag()
+	flag.Parse()
+
+	anchor := mustParseAnchor(*anchorArg)
+	database, err := db.New(*dbPath)
+	if err != nil {
+		log.Fatalf("Failed to open database: %v", err)
+	}
+	defer database.Close()
+
+	ctx := context.Background()
+
+	routeCache := geo.NewRouteCache()
+	router := geo.NewRouter(*valhallaURL).WithRouteCache(routeCache)
+	mustWaitForValhalla(ctx, router, *valhallaURL, *wait)
+
+	enrichment := service.NewEnrichmentService(database, router, nil, nil).WithAnchor(anchor)
+	stats, err := enrichment.DriveTimesToAnchor(ctx, *all)
+	if err != nil {
+		log.Fatalf("Failed to calculate drive times: %v", err)
+	}
+	if stats.Total == 0 {
+		return
+	}
+
+	logRouteCache(routeCache)
+	log.Printf("Done! Success: %d, Failed: %d", stats.Success, stats.Failed)
+}
+
+func calculateDriveTimeGrid() {
+	dbPath := flag.String("db", "data/farm-search.db", "Database path")
+	valhallaURL := flag.String("valhalla-url", defaultValhallaURL, "Valhalla server URL")
+	wait := valhallaWaitFlag()
+	spacing := flag.Float64("spacing-km", 10, "Distance between grid points in km")
+	maxKm := flag.Float64("max-km", 400, "Skip points further than this from the anchor in a straight line (0 for no limit)")
+	bounds := flag.String("bounds", "", "Grid area as sw_lat,sw_lng,ne_lat,ne_lng (default NSW)")
+	batch := flag.Int("batch", 100, "Grid points per Valhalla matrix request")
+	anchorArg := anchorFlag()
+	flag.Parse()
+
+	anchor := mustParseAnchor(*anchorArg)
+
+	if *spacing < 1 {
+		log.Fatal("-spacing-km must be at least 1")
+	}
+	b := geo.NSWBounds
+	if *bounds != "" {
+		if _, err := fmt.Sscanf(*bounds, "%f,%f,%f,%f", &b.SWLat, &b.SWLng, &b.NELat, &b.NELng); err != nil {
+			log.Fatalf("Invalid -bounds %q: expected sw_lat,sw_lng,ne_lat,ne_lng", *bounds)
+		}
+	}
+
+	database, err := db.New(*dbPath)
+	if err != nil {
+		log.Fatalf("Failed to open database: %v", err)
+	}
+	defer database.Close()
+
+	ctx := context.Background()
+
+	router := geo.NewRouter(*valhallaURL)
+	mustWaitForValhalla(ctx, router, *valhallaURL, *wait)
+
+	enrichment := service.NewEnrichmentService(database, router, nil, nil).WithAnchor(anchor)
+	stats, err := enrichment.DriveTimeGrid(ctx, service.DriveTimeGridOptions{
+		SWLat: b.SWLat, SWLng: b.SWLng, NELat: b.NELat, NELng: b.NELng,
+		SpacingKm: *spacing,
+		MaxKm:     *maxKm,
+		BatchSize: *batch,
+	})
+	if err != nil {
+		log.Fatalf("Failed to calculate drive time grid: %v", err)
+	}
+
+	log.Printf("Done! Routed: %d, Failed: %d", stats.Success, stats.Failed)
+}
+
+func calculateNearestTowns() {
+	dbPath := flag.String("db", "data/farm-search.db", "Database path")
+	all := flag.Bool("all", false, "Recalculate all properties, not just missing ones")
+	flag.Parse()
+
+	database, err := db.New(*dbPath)
+	if err != nil {
+		log.Fatalf("Failed to open database: %v", err)
+	}
+	defer database.Close()
+
+	enrichment := service.NewEnrichmentService(database, nil, nil, nil)
+	if _, err := enrichment.NearestTowns(*all); err != nil {
+		log.Fatalf("Failed to get properties: %v", err)
+	}
+
+	log.Println("Done!")
+}
+
+func calculateNearestSchools() {
+	dbPath := flag.String("db", "data/farm-search.db", "Database path")
+	all := flag.Bool("all", false, "Recalculate all properties, not just missing ones")
+	flag.Parse()
+
+	database, err := db.New(*dbPath)
+	if err != nil {
+		log.Fatalf("Failed to open database: %v", err)
+	}
+	defer database.Close()
+
+	ctx := context.Background()
+
+	// Load schools
+	schoolData := geo.NewSchoolData()
+	if err := schoolData.LoadFromNSWData(ctx); err != nil {
+		log.Printf("Warning: Could not load school data: %v", err)
+	}
+	log.Printf("Loaded %d schools", len(schoolData.Schools))
+
+	enrichment := service.NewEnrichmentService(database, nil, schoolData, nil)
+	saveSchools(enrichment)
+	if _, err := enrichment.NearestSchools(*all); err != nil {
+		log.Fatalf("Failed to get properties: %v", err)
+	}
+
+	log.Println("Done!")
+}
+
+// saveSchools stores the loaded schools as amenities for the nearby endpoint
+func saveSchools(enrichment *service.EnrichmentService) {
+	n, err := enrichment.SaveSchools()
+	if err != nil {
+		log.Printf("Warning: Could not save schools as amenities: %v", err)
+	} else if n > 0 {
+		log.Printf("Saved %d schools as amenities", n)
+	}
+}
+
+func calculateSchoolDriveTimes() {
+	dbPath := flag.String("db", "data/farm-search.db", "Database path")
+	valhallaURL := flag.String("valhalla-url", defaultValhallaURL, "Valhalla server URL")
+	wait := valhallaWaitFlag()
+	all := flag.Bool("all", false, "Recalculate all properties, not just missing ones")
+	flag.Parse()
+
+	database, err := db.New(*dbPath)
+	if err != nil {
+		log.Fatalf("Failed to open database: %v", err)
+	}
+	defer database.Close()
+
+	ctx := context.Background()
+
+	// Load schools
+	schoolData := geo.NewSchoolData()
+	if err := schoolData.LoadFromNSWData(ctx); err != nil {
+		log.Printf("Warning: Could not load school data: %v", err)
+	}
+	log.Printf("Loaded %d schools", len(schoolData.Schools))
+
+	routeCache := geo.NewRouteCache()
+	router := geo.NewRouter(*valhallaURL).WithRouteCache(routeCache)
+	mustWaitForValhalla(ctx, router, *valhallaURL, *wait)
+
+	enrichment := service.NewEnrichmentService(database, router, schoolData, nil)
+	stats, err := enrichment.SchoolDriveTimes(ctx, *all)
+	if err != nil {
+		log.Fatalf("Failed to calculate drive times: %v", err)
+	}
+	if stats.Total == 0 {
+		return
+	}
+
+	logRouteCache(routeCache)
+	log.Printf("Done! Success: %d, Failed: %d", stats.Success, stats.Failed)
+}
+
+func calculatePOIDriveTimes() {
+	dbPath := flag.String("db", "data/farm-search.db", "Database path")
+	valhallaURL := flag.String("valhalla-url", defaultValhallaURL, "Valhalla server URL")
+	wait := valhallaWaitFlag()
+	all := flag.Bool("all", false, "Recalculate all properties to all POIs, not just missing ones")
+	flag.Parse()
+
+	database, err := db.New(*dbPath)
+	if err != nil {
+		log.Fatalf("Failed to open database: %v", err)
+	}
+	defer database.Close()
+
+	ctx := context.Background()
+	routeCache := geo.NewRouteCache()
+	router := geo.NewRouter(*valhallaURL).WithRouteCache(routeCache)
+	mustWaitForValhalla(ctx, router, *valhallaURL, *wait)
+
+	enrichment := service.NewEnrichmentService(database, router, nil, nil)
+	stats, err := enrichment.POIDriveTimes(ctx, *all)
+	if err != nil {
+		log.Fatalf("Failed to calculate drive times: %v", err)
+	}
+	if stats.Total == 0 {
+		return
+	}
+
+	logRouteCache(routeCache)
+	log.Printf("Done! Success: %d, Failed: %d", stats.Success, stats.Failed)
+}
+
+// updateChangedNearest rechecks every property's nearest towns and schools
+// after the town list or school data changes, saving and rerouting only
+// those that changed instead of `towns -all` and `schools -all`
+func updateChangedNearest() {
+	dbPath := flag.String("db", "data/farm-search.db", "Database path")
+	valhallaURL := flag.String("valhalla-url", defaultValhallaURL, "Valhalla server URL")
+	wait := valhallaWaitFlag()
+	schools := flag.Bool("schools", true, "Load NSW school data and recheck nearest schools too")
+	flag.Parse()
+
+	database, err := db.New(*dbPath)
+	if err != nil {
+		log.Fatalf("Failed to open database: %v", err)
+	}
+	defer database.Close()
+
+	ctx := context.Background()
+
+	var schoolData *geo.SchoolData
+	if *schools {
+		schoolData = geo.NewSchoolData()
+		if err := schoolData.LoadFromNSWData(ctx); err != nil {
+			log.Printf("Warning: Could not load school data: %v", err)
+		}
+		log.Printf("Loaded %d schools", len(schoolData.Schools))
+	}
+
+	routeCache := geo.NewRouteCache()
+	router := geo.NewRouter(*valhallaURL).WithRouteCache(routeCache)
+	if err := waitForValhalla(ctx, router, *valhallaURL, *wait); err != nil {
+		log.Printf("Warning: Valhalla is down, leaving drive times stale for `tools enrich`: %v", err)
+		router = nil
+	}
+
+	enrichment := service.NewEnrichmentService(database, router, schoolData, nil)
+	saveSchools(enrichment)
+	towns, schoolStats, err := enrichment.RecheckNearest()
+	if err != nil {
+		log.Fatalf("Failed to recheck nearest towns and schools: %v", err)
+	}
+	log.Printf("Nearest towns changed for %d of %d properties", towns.Success, towns.Total)
+	if schoolStats.Total > 0 {
+		log.Printf("Nearest schools changed for %d of %d properties", schoolStats.Success, schoolStats.Total)
+	}
+
+	if router != nil {
+		if _, err := enrichment.TownDriveTimes(ctx, false); err != nil {
+			log.Fatalf("Failed to calculate town drive times: %v", err)
+		}
+		if schoolData != nil && len(schoolData.Schools) > 0 {
+			if _, err := enrichment.SchoolDriveTimes(ctx, false); err != nil {
+				log.Fatalf("Failed to calculate school drive times: %v", err)
+			}
+		}
+
+		// Drive times feed the scores
+		profiles, err := service.NewScoringService(database).ComputeAll()
+		if err != nil {
+			log.Fatalf("Failed to rescore properties: %v", err)
+		}
+		log.Printf("Rescored properties for %d score profiles", profiles)
+	}
+
+	logRouteCache(routeCache)
+	log.Println("Done!")
+}
+
+func diagnoseUnroutable() {
+	dbPath := flag.String("db", "data/farm-search.db", "Database path")
+	valhallaURL := flag.String("valhalla-url", defaultValhallaURL, "Valhalla server URL")
+	wait := valhallaWaitFlag()
+	searchKm := flag.Float64("search-km", 50, "How far from each property to look for a road")
+	propertyID := flag.Int64("id", 0, "Only check this property")
+	anchorArg := anchorFlag()
+	flag.Parse()
+
+	anchor := mustParseAnchor(*anchorArg)
+	database, err := db.New(*dbPath)
+	if err != nil {
+		log.Fatalf("Failed to open database: %v", err)
+	}
+	defer database.Close()
+
+	ctx := context.Background()
+
+	router := geo.NewRouter(*valhallaURL)
+	mustWaitForValhalla(ctx, router, *valhallaURL, *wait)
+
+	enrichment := service.NewEnrichmentService(database, router, nil, nil).WithAnchor(anchor)
+	if *propertyID != 0 {
+		enrichment = enrichment.ForProperty(*propertyID)
+	}
+	stats, err := enrichment.DiagnoseUnroutable(ctx, *searchKm)
+	if err != nil {
+		log.Fatalf("Failed to diagnose unroutable properties: %v", err)
+	}
+	if stats.Total == 0 {
+		return
+	}
+
+	log.Printf("Done! Located: %d, Failed: %d", stats.Success, stats.Failed)
+}
+
+func fetchCadastralLots() {
+	dbPath := flag.String("db", "data/farm-search.db", "Database path")
+	all := flag.Bool("all", false, "Fetch lots for all properties, not just those without lots")
+	flag.Parse()
+
+	database, err := db.New(*dbPath)
+	if err != nil {
+		log.Fatalf("Failed to open database: %v", err)
+	}
+	defer database.Close()
+
+	ctx := context.Background()
+
+	enrichment := service.NewEnrichmentService(database, nil, nil, geo.NewCadastralClient())
+	stats, err := enrichment.CadastralLots(ctx, *all)
+	if err != nil {
+		log.Fatalf("Failed to get properties: %v", err)
+	}
+	if stats.Total == 0 {
+		return
+	}
+
+	totalLots, _ := database.GetCadastralLotCount()
+	log.Printf("Done! Properties: %d success, %d failed. Total lots in DB: %d", stats.Success, stats.Failed, totalLots)
+}
+
+func checkHeritage() {
+	dbPath := flag.String("db", "data/farm-search.db", "Database path")
+	all := flag.Bool("all", false, "Recheck all properties and lots, not just those not yet checked")
+	flag.Parse()
+
+	database, err := db.New(*dbPath)
+	if err != nil {
+		log.Fatalf("Failed to open database: %v", err)
+	}
+	defer database.Close()
+
+	ctx := context.Background()
+
+	enrichment := service.NewEnrichmentService(database, nil, nil, nil).WithHeritage(geo.NewHeritageClient())
+	stats, err := enrichment.Heritage(ctx, *all)
+	if err != nil {
+		log.Fatalf("Failed to get properties: %v", err)
+	}
+	if stats.Total == 0 {
+		return
+	}
+
+	log.Printf("Done! Properties: %d success, %d failed", stats.Success, stats.Failed)
+}
+
+func checkSubdivision() {
+	dbPath := flag.String("db", "data/farm-search.db", "Database path")
+	all := flag.Bool("all", false, "Recheck all properties and lots, not just those not yet checked")
+	flag.Parse()
+
+	database, err := db.New(*dbPath)
+	if err != nil {
+		log.Fatalf("Failed to open database: %v", err)
+	}
+	defer database.Close()
+
+	ctx := context.Background()
+
+	enrichment := service.NewEnrichmentService(database, nil, nil, nil).WithLotSize(geo.NewLotSizeClient())
+	stats, err := enrichment.Subdivision(ctx, *all)
+	if err != nil {
+		log.Fatalf("Failed to get properties: %v", err)
+	}
+	if stats.Total == 0 {
+		return
+	}
+
+	log.Printf("Done! Properties: %d success, %d failed", stats.Success, stats.Failed)
+}
+
+func backfillLandSizeFromCadastral() {
+	dbPath := flag.String("db", "data/farm-search.db", "Database path")
+	flag.Parse()
+
+	database, err := db.New(*dbPath)
+	if err != nil {
+		log.Fatalf("Failed to open database: %v", err)
+	}
+	defer database.Close()
+
+	// 10 hectares = 100,000 sqm
+	thresholdSqm := 100000.0
+
+	// Get properties with cadastral lots where land_size_sqm is NULL or < 10 HA
+	properties, err := database.GetPropertiesWithSmallLandSize(thresholdSqm)
+	if err != nil {
+		log.Fatalf("Failed to get properties: %v", err)
+	}
+
+	if len(properties) == 0 {
+		log.Println("No properties need land size backfill")
+		return
+	}
+
+	log.Printf("Found %d properties with land size < 10 HA that have cadastral data", len(properties))
+
+	updated := 0
+	skipped := 0
+
+	for i, p := range properties {
+		// Get total cadastral area for this property
+		totalArea, err := database.GetTotalCadastralAreaForProperty(p.ID)
+		if err != nil {
+			log.Printf("[%d/%d] Property %d: Failed to get cadastral area: %v", i+1, len(properties), p.ID, err)
+			skipped++
+			continue
+		}
+
+		if totalArea <= 0 {
+			log.Printf("[%d/%d] Property %d (%s): No cadastral area found", i+1, len(properties), p.ID, p.Suburb)
+			skipped++
+			continue
+		}
+
+		// Update the property's land size
+		err = database.UpdatePropertyLandSize(p.ID, totalArea)
+		if err != nil {
+			log.Printf("[%d/%d] Property %d: Failed to update: %v", i+1, len(properties), p.ID, err)
+			skipped++
+			continue
+		}
+
+		oldHA := p.LandSizeSqm / 10000
+		newHA := totalArea / 10000
+
+		location := p.Suburb
+		if p.Address != "" {
+			location = p.Address
+		}
+		log.Printf("[%d/%d] Property %d (%s): %.1f HA -> %.1f HA", i+1, len(properties), p.ID, location, oldHA, newHA)
+		updated++
+	}
+
+	log.Printf("Done! Updated %d properties, skipped %d", updated, skipped)
+}
+
+func fetchREADetails() {
+	dbPath := flag.String("db", "data/farm-search.db", "Database path")
+	scrapingBeeKey := flag.String("scrapingbee", "", "ScrapingBee API key (or use -flaresolverr)")
+	flareSolverURL := flag.String("flaresolverr", "", "FlareSolverr-compatible service URL, used if no ScrapingBee key")
+	limit := flag.Int("limit", 0, "Maximum number of properties to process (0 = no limit)")
+	workers := flag.Int("workers", 5, "Number of parallel workers")
+	maxRetries := flag.Int("retries", 3, "Maximum retries per property")
+	flag.Parse()
+
+	// Also check environment variable
+	if *scrapingBeeKey == "" {
+		*scrapingBeeKey = os.Getenv("SCRAPINGBEE_API_KEY")
+	}
+	if *flareSolverURL == "" {
+		*flareSolverURL = os.Getenv("FLARESOLVERR_URL")
+	}
+
+	if *scrapingBeeKey == "" && *flareSolverURL == "" {
+		log.Fatal("ScrapingBee API key or FlareSolverr URL is required. Use -scrapingbee/-flaresolverr flags or set SCRAPINGBEE_API_KEY/FLARESOLVERR_URL env vars")
+	}
+
+	database, err := db.New(*dbPath)
+	if err != nil {
+		log.Fatalf("Failed to open database: %v", err)
+	}
+	defer database.Close()
+
+	ctx := context.Background()
+
+	// Create REA scraper with ScrapingBee, falling back to FlareSolverr
+	var reaScraper *scraper.REAScraper
+	if *scrapingBeeKey != "" {
+		reaScraper = scraper.NewREAScraperWithScrapingBee(*scrapingBeeKey)
+	} else {
+		reaScraper = scraper.NewREAScraperWithFlareSolverr(*flareSolverURL)
+	}
+
+	// Get REA properties that haven't had details scraped yet
+	properties, err := database.GetREAPropertiesWithoutDetails(*limit)
+	if err != nil {
+		log.Fatalf("Failed to get properties: %v", err)
+	}
+
+	if len(properties) == 0 {
+		log.Println("No REA properties need detail scraping")
+		return
+	}
+
+	log.Printf("Fetching details for %d REA properties with %d workers...", len(properties), *workers)
+
+	// Create channels for work distribution and results
+	type workItem struct {
+		index    int
+		property db.REAPropertyForDetails
+	}
+	type result struct {
+		index   int
+		id      int64
+		success bool
+		found   []string
+		err     error
+	}
+
+	workChan := make(chan workItem, len(properties))
+	resultChan := make(chan result, len(properties))
+
+	// Start workers
+	for w := 0; w < *workers; w++ {
+		go func(workerID int) {
+			for work := range workChan {
+				p := work.property
+				var lastErr error
+				var details *models.Property
+
+				// Retry loop
+				for attempt := 1; attempt <= *maxRetries; attempt++ {
+					fetchedDetails, fetchErr := reaScraper.FetchListingDetails(ctx, p.URL)
+					if fetchErr == nil {
+						details = fetchedDetails
+						lastErr = nil
+						break
+					}
+					lastErr = fetchErr
+
+					if attempt < *maxRetries {
+						// Exponential backoff: 2s, 4s, 8s...
+						backoff := time.Duration(1<<attempt) * time.Second
+						log.Printf("[Worker %d] Property %d attempt %d/%d failed: %v, retrying in %v",
+							workerID, p.ID, attempt, *maxRetries, fetchErr, backoff)
+						time.Sleep(backoff)
+					}
+				}
+
+				if lastErr != nil {
+					resultChan <- result{index: work.index, id: p.ID, success: false, err: lastErr}
+					continue
+				}
+
+				// Prepare values for update
+				var description, images string
+				var landSizeSqm *float64
+				var bedrooms, bathrooms, carspaces *int64
+				var priceMin, priceMax *int64
+
+				if details.Description.Valid && details.Description.String != "" {
+					description = details.Description.String
+				}
+				if details.Images.Valid && details.Images.String != "" && details.Images.String != "[]" {
+					images = details.Images.String
+				}
+				if details.LandSizeSqm.Valid && details.LandSizeSqm.Float64 > 0 {
+					landSizeSqm = &details.LandSizeSqm.Float64
+				}
+				if details.Bedrooms.Valid {
+					bedrooms = &details.Bedrooms.Int64
+				}
+				if details.Bathrooms.Valid {
+					bathrooms = &details.Bathrooms.Int64
+				}
+				if details.Carspaces.Valid {
+					carspaces = &details.Carspaces.Int64
+				}
+				if details.PriceMin.Valid {
+					priceMin = &details.PriceMin.Int64
+				}
+				if details.PriceMax.Valid {
+					priceMax = &details.PriceMax.Int64
+				}
+
+				// Update the property with the fetched details
+				saveErr := database.UpdatePropertyFromDetails(p.ID, description, images, landSizeSqm, bedrooms, bathrooms, carspaces, priceMin, priceMax)
+				if saveErr != nil {
+					resultChan <- result{index: work.index, id: p.ID, success: false, err: saveErr}
+					continue
+				}
+
+				// Build found list for logging
+				var found []string
+				if description != "" {
+					found = append(found, "description")
+				}
+				if images != "" {
+					found = append(found, "images")
+				}
+				if landSizeSqm != nil {
+					found = append(found, fmt.Sprintf("%.1f ha", *landSizeSqm/10000))
+				}
+				if bedrooms != nil {
+					found = append(found, fmt.Sprintf("%d bed", *bedrooms))
+				}
+				if bathrooms != nil {
+					found = append(found, fmt.Sprintf("%d bath", *bathrooms))
+				}
+				if carspaces != nil {
+					found = append(found, fmt.Sprintf("%d car", *carspaces))
+				}
+
+				resultChan <- result{index: work.index, id: p.ID, success: true, found: found}
+			}
+		}(w)
+	}
+
+	// Send work to workers
+	for i, p := range properties {
+		workChan <- workItem{index: i, property: p}
+	}
+	close(workChan)
+
+	// Collect results
+	success := 0
+	failed := 0
+
+	for i := 0; i < len(properties); i++ {
+		r := <-resultChan
+		if r.success {
+			success++
+			if len(r.found) > 0 {
+				log.Printf("[%d/%d] Property %d: found %v", r.index+1, len(properties), r.id, r.found)
+			} else {
+				log.Printf("[%d/%d] Property %d: no new details found", r.index+1, len(properties), r.id)
+			}
+		} else {
+			failed++
+			log.Printf("[%d/%d] Property %d: FAILED after %d retries: %v", r.index+1, len(properties), r.id, *maxRetries, r.err)
+		}
+	}
+
+	log.Printf("Done! Success: %d, Failed: %d", success, failed)
+}
+
+// geocodeAddress returns the address to geocode a property by, or "" if it
+// has neither a street address nor a suburb
+func geocodeAddress(p *models.Property) string {
+	var parts []string
+	for _, s := range []sql.NullString{p.Address, p.Suburb} {
+		if s.Valid && s.String != "" {
+			parts = append(parts, s.String)
+		}
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return strings.Join(append(parts, p.State, "Australia"), ", ")
+}
+
+// geocodeProperties geocodes the properties missing coordinates through the
+// chain of geocoders configured (see scraper.NewGeocoderChainFromEnv),
+// caching each address's result
+func geocodeProperties() {
+	dbPath := flag.String("db", "data/farm-search.db", "Database path")
+	limit := flag.Int("limit", 0, "Geocode at most this many properties (0 for all)")
+	retryMisses := flag.Bool("retry-misses", false, "Look up again addresses no geocoder knew last time")
+	verify := flag.Bool("verify", true, "Check NSW geocodes against the cadastre, queueing mismatches for review")
+	flag.Parse()
+
+	chain, err := scraper.NewGeocoderChainFromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	database, err := db.New(*dbPath)
+	if err != nil {
+		log.Fatalf("Failed to open database: %v", err)
+	}
+	defer database.Close()
+
+	properties, err := database.GetPropertiesMissingCoordinates(*limit)
+	if err != nil {
+		log.Fatalf("Failed to get properties: %v", err)
+	}
+	if len(properties) == 0 {
+		log.Println("No properties missing coordinates")
+		return
+	}
+	log.Printf("Geocoding %d properties with %s...", len(properties), strings.Join(chain.Providers(), " -> "))
+
+	var verifier *service.EnrichmentService
+	if *verify {
+		verifier = service.NewEnrichmentService(database, nil, nil, geo.NewCadastralClient())
+	}
+
+	ctx := context.Background()
+	byProvider := make(map[string]int)
+	var geocoded, cached, ambiguous, imprecise, noMatch, failed, noAddress int
+	var verified, unverified, flagged int
+	for i := range properties {
+		p := &properties[i]
+		addr := geocodeAddress(p)
+		if addr == "" {
+			noAddress++
+			continue
+		}
+
+		entry, err := database.GetGeocodeCache(addr)
+		if err != nil {
+			log.Fatalf("Failed to read geocode cache: %v", err)
+		}
+		if entry != nil && (entry.Latitude != nil || !*retryMisses) {
+			cached++
+		} else {
+			entry = &db.GeocodeCacheEntry{Address: addr}
+			result, err := chain.GeocodeWithConfidence(ctx, addr)
+			if err != nil && !errors.Is(err, scraper.ErrNoGeocodeMatch) {
+				// Not cached, so the next run tries again
+				log.Printf("[%d/%d] Geocoding failed for %s: %v", i+1, len(properties), addr, err)
+				failed++
+				continue
+			}
+			if err == nil {
+				entry.Provider, entry.Match = &result.Provider, &result.Match
+				entry.Latitude, entry.Longitude = &result.Lat, &result.Lng
+				entry.Confidence, entry.Ambiguous = &result.Confidence, result.Ambiguous
+			}
+			if err := database.SaveGeocodeCache(entry); err != nil {
+				log.Fatalf("Failed to cache geocode: %v", err)
+			}
+		}
+
+		if entry.Latitude == nil {
+			log.Printf("[%d/%d] No geocoder knows %s", i+1, len(properties), addr)
+			noMatch++
+			continue
+		}
+
+		if verifier != nil {
+			check, err := verifier.VerifyGeocode(ctx, p, *entry.Latitude, *entry.Longitude)
+			// NSW Spatial Services is shared, so go easy on it
+			time.Sleep(500 * time.Millisecond)
+			if err != nil {
+				log.Printf("[%d/%d] Verifying geocode failed for property %d: %v", i+1, len(properties), p.ID, err)
+				failed++
+				continue
+			}
+			switch check.Verdict {
+			case service.GeocodeVerified:
+				verified++
+			case service.GeocodeUnverified:
+				unverified++
+			default:
+				review := &models.GeocodeReview{
+					PropertyID: p.ID,
+					Address:    addr,
+					Provider:   *entry.Provider,
+					Latitude:   *entry.Latitude,
+					Longitude:  *entry.Longitude,
+					Confidence: *entry.Confidence,
+					Reason:     check.Verdict,
+				}
+				if check.LotIDString != "" {
+					review.LotIDString = &check.LotIDString
+				}
+				if check.FoundAddress != "" {
+					review.FoundAddress = &check.FoundAddress
+				}
+				status, err := database.FlagGeocodeReview(review)
+				if err != nil {
+					log.Fatalf("Failed to flag geocode: %v", err)
+				}
+				note := ""
+				if status == "rejected" {
+					note = " (rejected earlier)"
+				}
+				if check.Verdict == service.GeocodeNoLot {
+					log.Printf("[%d/%d] Property %d: %s -> %.5f, %.5f isn't on a lot, flagged for review%s",
+						i+1, len(properties), p.ID, addr, *entry.Latitude, *entry.Longitude, note)
+				} else {
+					log.Printf("[%d/%d] Property %d: %s -> %.5f, %.5f is on %s at %s, flagged for review%s",
+						i+1, len(properties), p.ID, addr, *entry.Latitude, *entry.Longitude, check.LotIDString, check.FoundAddress, note)
+				}
+				flagged++
+				continue
+			}
+		}
+
+		if err := database.SetPropertyCoordinates(p.ID, *entry.Latitude, *entry.Longitude, "geocoder", *entry.Confidence); err != nil {
+			log.Printf("[%d/%d] Failed to save coordinates for property %d: %v", i+1, len(properties), p.ID, err)
+			failed++
+			continue
+		}
+
+		geocoded++
+		byProvider[*entry.Provider]++
+		note := ""
+		switch {
+		case entry.Ambiguous:
+			ambiguous++
+			note = " (ambiguous: check the pin)"
+		case *entry.Confidence < 0.5:
+			imprecise++
+			note = " (street or suburb only)"
+		}
+		log.Printf("[%d/%d] Property %d: %s -> %.5f, %.5f via %s, %s match%s",
+			i+1, len(properties), p.ID, addr, *entry.Latitude, *entry.Longitude, *entry.Provider, *entry.Match, note)
+	}
+
+	sources := []string{fmt.Sprintf("%d from cache", cached)}
+	for name, n := range byProvider {
+		sources = append(sources, fmt.Sprintf("%s %d", name, n))
+	}
+	sort.Strings(sources[1:])
+	log.Printf("Done! Geocoded: %d (%s), ambiguous: %d, street or suburb only: %d, no match: %d, failed: %d, no address: %d",
+		geocoded, strings.Join(sources, ", "), ambiguous, imprecise, noMatch, failed, noAddress)
+	if verifier != nil {
+		log.Printf("Verified against the cadastre: %d, unverified: %d, flagged for review: %d", verified, unverified, flagged)
+		if flagged > 0 {
+			log.Println("Review flagged geocodes with GET /api/geocode-reviews")
+		}
+	}
+	log.Println("Run tools enrich to fill in drive times, nearest towns and lots for the geocoded properties")
+}
+
+func importListings() {
+	dbPath := flag.String("db", "data/farm-search.db", "Database path")
+	file := flag.String("file", "", "CSV or JSON file of listings to import (required)")
+	source := flag.String("source", scraper.ManualSource, "Source recorded for the imported listings")
+	geocode := flag.Bool("geocode", true, "Geocode listings without coordinates from their address")
+	flag.Parse()
+
+	if *file == "" {
+		log.Fatal("An import file is required. Use -file listings.csv or -file listings.json")
+	}
+
+	listings, err := scraper.LoadManualListings(*file, *source)
+	if err != nil {
+		log.Fatalf("Failed to load listings: %v", err)
+	}
+
+	if len(listings) == 0 {
+		log.Println("No listings found in import file")
+		return
+	}
+
+	database, err := db.New(*dbPath)
+	if err != nil {
+		log.Fatalf("Failed to open database: %v", err)
+	}
+	defer database.Close()
+
+	log.Printf("Importing %d listings from %s as source %q...", len(listings), *file, *source)
+
+	ctx := context.Background()
+	publisher := mustEventsFromEnv()
+	geocoder := scraper.NewGeocoder()
+	var toSave []models.Property
+	skipped := 0
+
+	for i := range listings {
+		p := &listings[i]
+
+		if (!p.Latitude.Valid || !p.Longitude.Valid) && *geocode {
+			addr := geocodeAddress(p)
+			result, err := geocoder.GeocodeWithConfidence(ctx, addr)
+			if err != nil {
+				log.Printf("[%d/%d] Geocoding failed for %s: %v", i+1, len(listings), addr, err)
+			} else {
+				p.Latitude = sql.NullFloat64{Float64: result.Lat, Valid: true}
+				p.Longitude = sql.NullFloat64{Float64: result.Lng, Valid: true}
+				p.CoordSource = sql.NullString{String: "geocoder", Valid: true}
+				p.CoordConfidence = sql.NullFloat64{Float64: result.Confidence, Valid: true}
+			}
+
+			// Nominatim allows 1 request per second
+			time.Sleep(1 * time.Second)
+		}
+
+		// Properties without coordinates can't be shown on the map
+		if !p.Latitude.Valid || !p.Longitude.Valid {
+			log.Printf("[%d/%d] Skipping %s: no coordinates", i+1, len(listings), p.ExternalID)
+			skipped++
+			continue
+		}
+
+		toSave = append(toSave, *p)
+	}
+
+	// Saved in one transaction so a failed import leaves nothing half done
+	result, err := database.SaveProperties(toSave)
+	if err != nil {
+		log.Fatalf("Failed to save listings: %v", err)
+	}
+	for _, err := range result.Errors {
+		log.Printf("Failed to save listing: %v", err)
+	}
+	events.Send(ctx, publisher, events.Saved(result))
+
+	// Link imported listings to the same property found on other sites
+	if err := database.FindDuplicateProperties(); err != nil {
+		log.Printf("Warning: failed to find duplicate properties: %v", err)
+	}
+
+	log.Printf("Done! Imported %d listings (%d new, %d updated), skipped %d",
+		result.Saved(), result.Inserted, result.Updated, skipped+result.Failed)
+}
+
+func importNDJSON() {
+	dbPath := flag.String("db", "data/farm-search.db", "Database path")
+	file := flag.String("file", "", "NDJSON file written by the scraper's -output ndjson (- for stdin; required)")
+	flag.Parse()
+
+	if *file == "" {
+		log.Fatal("An NDJSON file is required. Use -file listings.ndjson")
+	}
+
+	in := os.Stdin
+	if *file != "-" {
+		f, err := os.Open(*file)
+		if err != nil {
+			log.Fatalf("Failed to open NDJSON file: %v", err)
+		}
+		defer f.Close()
+		in = f
+	}
+	records, err := scraper.ReadNDJSON(in)
+	if err != nil {
+		log.Fatalf("Failed to read NDJSON: %v", err)
+	}
+	if len(records) == 0 {
+		log.Println("No listings found in NDJSON file")
+		return
+	}
+
+	database, err := db.New(*dbPath)
+	if err != nil {
+		log.Fatalf("Failed to open database: %v", err)
+	}
+	defer database.Close()
+
+	// Grouped by source in the order sources first appear, to save one
+	// transaction per source as the scraper does
+	var sources []string
+	seen := make(map[string]bool)
+	listings := make(map[string][]models.Property)
+	rentals := make(map[string][]models.Rental)
+	skipped := 0
+	for _, r := range records {
+		p := r.Property
+		if !seen[p.Source] {
+			seen[p.Source] = true
+			sources = append(sources, p.Source)
+		}
+		if r.ListingType == scraper.ListingTypeRent {
+			rentals[p.Source] = append(rentals[p.Source], scraper.RentalFromProperty(&p))
+			continue
+		}
+		// Properties without coordinates can't be shown on the map
+		if !p.Latitude.Valid || !p.Longitude.Valid {
+			skipped++
+			continue
+		}
+		listings[p.Source] = append(listings[p.Source], p)
+	}
+
+	log.Printf("Importing %d listings from %s...", len(records), *file)
+	publisher := mustEventsFromEnv()
+
+	var total db.SaveResult
+	saved := func(source, kind string, result db.SaveResult, err error) {
+		if err != nil {
+			log.Fatalf("Failed to save %s %s: %v", source, kind, err)
+		}
+		for _, err := range result.Errors {
+			log.Printf("Failed to save %s: %v", kind, err)
+		}
+		log.Printf("Saved %s %s: %d new, %d updated, %d failed",
+			source, kind, result.Inserted, result.Updated, result.Failed)
+		total.Add(result)
+	}
+	for _, source := range sources {
+		if len(listings[source]) > 0 {
+			result, err := database.SaveProperties(listings[source])
+			saved(source, "listings", result, err)
+			events.Send(context.Background(), publisher, events.Saved(result))
+		}
+		if len(rentals[source]) > 0 {
+			result, err := database.SaveRentals(rentals[source])
+			saved(source, "rentals", result, err)
+		}
+	}
+
+	// Link imported listings to the same property found on other sites
+	if err := database.FindDuplicateProperties(); err != nil {
+		log.Printf("Warning: failed to find duplicate properties: %v", err)
+	}
+
+	log.Printf("Done! Imported %d listings (%d new, %d updated), skipped %d without coordinates, %d failed",
+		total.Saved(), total.Inserted, total.Updated, skipped, total.Failed)
+}
+
+func scrapeAuctionResults() {
+	dbPath := flag.String("db", "data/farm-search.db", "Database path")
+	cities := flag.String("cities", "sydney,canberra", "Comma-separated Domain auction results cities ("+strings.Join(scraper.AuctionCities, ", ")+")")
+	allTypes := flag.Bool("all-types", false, "Keep every property type, not just rural, acreage and land")
+	flag.Parse()
+
+	database, err := db.New(*dbPath)
+	if err != nil {
+		log.Fatalf("Failed to open database: %v", err)
+	}
+	defer database.Close()
+
+	ctx := context.Background()
+	auctions := scraper.NewAuctionResultsScraper(*allTypes)
+	saved := 0
+
+	for _, city := range strings.Split(*cities, ",") {
+		city = strings.TrimSpace(city)
+		if city == "" {
+			continue
+		}
+
+		results, err := auctions.ScrapeResults(ctx, city)
+		if err != nil {
+			log.Printf("Failed to scrape %s auction results: %v", city, err)
+			continue
+		}
+
+		sold, reported, rate := scraper.AuctionClearance(results)
+		log.Printf("%s: %d results, %d of %d sold (%.0f%% clearance)", city, len(results), sold, reported, rate)
+
+		for i := range results {
+			if err := database.UpsertAuctionResult(&results[i]); err != nil {
+				log.Printf("Failed to save auction result for %s: %v", results[i].Address, err)
+				continue
+			}
+			saved++
+		}
+
+		// Be polite between cities
+		time.Sleep(2 * time.Second)
+	}
+
+	linked, err := database.LinkAuctionResults()
+	if err != nil {
+		log.Printf("Warning: failed to link auction results: %v", err)
+	}
+
+	log.Printf("Done! Saved %d auction results, linked %d to properties", saved, linked)
+}
+
+func importVGSales() {
+	dbPath := flag.String("db", "data/farm-search.db", "Database path")
+	path := flag.String("path", "", "PSI .DAT file, zip archive, or directory of either (required)")
+	all := flag.Bool("all", false, "Import sales of every lot, not just lots in cadastral_lots")
+	flag.Parse()
+
+	if *path == "" {
+		log.Fatal("A sales file is required. Use -path data/vg/2024.zip")
+	}
+
+	database, err := db.New(*dbPath)
+	if err != nil {
+		log.Fatalf("Failed to open database: %v", err)
+	}
+	defer database.Close()
+
+	// The statewide files hold millions of lots; by default only keep sales of
+	// lots we've already fetched boundaries for (run 'cadastral' first)
+	var known map[string]bool
+	if !*all {
+		known, err = database.GetCadastralLotIDStrings()
+		if err != nil {
+			log.Fatalf("Failed to get cadastral lots: %v", err)
+		}
+		if len(known) == 0 {
+			log.Fatal("No cadastral lots in database. Run 'tools cadastral' first or use -all")
+		}
+		log.Printf("Importing sales for %d cadastral lots from %s...", len(known), *path)
+	} else {
+		log.Printf("Importing all sales from %s...", *path)
+	}
+
+	now := time.Now()
+	read := 0
+	saved := 0
+
+	err = nswvg.ReadSales(*path, func(sale nswvg.Sale) error {
+		read++
+		if read%100000 == 0 {
+			log.Printf("Read %d sales, saved %d lot sales", read, saved)
+		}
+
+		for _, lot := range sale.Lots {
+			if known != nil && !known[lot] {
+				continue
+			}
+
+			hs := &models.HistoricalSale{
+				LotIDString:    lot,
+				DealingNumber:  sale.DealingNumber,
+				DistrictCode:   nullString(sale.DistrictCode),
+				VGPropertyID:   nullString(sale.PropertyID),
+				Address:        nullString(sale.Address),
+				Locality:       nullString(sale.Locality),
+				Postcode:       nullString(sale.Postcode),
+				ContractDate:   sale.ContractDate,
+				SettlementDate: nullString(sale.SettlementDate),
+				PurchasePrice:  sale.PurchasePrice,
+				LotCount:       len(sale.Lots),
+				Zoning:         nullString(sale.Zoning),
+				Nature:         nullString(sale.Nature),
+				PrimaryPurpose: nullString(sale.PrimaryPurpose),
+				ImportedAt:     now,
+			}
+			if sale.AreaSqm > 0 {
+				hs.AreaSqm = sql.NullFloat64{Float64: sale.AreaSqm, Valid: true}
+			}
+
+			if err := database.UpsertHistoricalSale(hs); err != nil {
+				log.Printf("Failed to save sale %s for %s: %v", sale.DealingNumber, lot, err)
+				continue
+			}
+			saved++
+		}
+		return nil
+	})
+	if err != nil {
+		log.Fatalf("Failed to read sales: %v", err)
+	}
+
+	log.Printf("Done! Read %d sales, saved %d lot sales", read, saved)
+}
+
+func importVGLandValues() {
+	dbPath := flag.String("db", "data/farm-search.db", "Database path")
+	path := flag.String("path", "", "Land value .csv file, zip archive, or directory of either (required)")
+	all := flag.Bool("all", false, "Import values of every lot, not just lots in cadastral_lots")
+	flag.Parse()
+
+	if *path == "" {
+		log.Fatal("A land value file is required. Use -path data/vg/LV_20241001.zip")
+	}
+
+	database, err := db.New(*dbPath)
+	if err != nil {
+		log.Fatalf("Failed to open database: %v", err)
+	}
+	defer database.Close()
+
+	var known map[string]bool
+	if !*all {
+		known, err = database.GetCadastralLotIDStrings()
+		if err != nil {
+			log.Fatalf("Failed to get cadastral lots: %v", err)
+		}
+		if len(known) == 0 {
+			log.Fatal("No cadastral lots in database. Run 'tools cadastral' first or use -all")
+		}
+		log.Printf("Importing land values for %d cadastral lots from %s...", len(known), *path)
+	} else {
+		log.Printf("Importing all land values from %s...", *path)
+	}
+
+	now := time.Now()
+	read := 0
+	saved := 0
+
+	err = nswvg.ReadLandValues(*path, func(lv nswvg.LandValue) error {
+		read++
+		if read%100000 == 0 {
+			log.Printf("Read %d land values, saved %d lot values", read, saved)
+		}
+
+		for _, lot := range lv.Lots {
+			if known != nil && !known[lot] {
+				continue
+			}
+
+			rec := &models.LandValueRecord{
+				LotIDString:  lot,
+				VGPropertyID: lv.PropertyID,
+				DistrictCode: nullString(lv.DistrictCode),
+				Address:      nullString(lv.Address),
+				Locality:     nullString(lv.Locality),
+				Postcode:     nullString(lv.Postcode),
+				ZoneCode:     nullString(lv.ZoneCode),
+				LandValue:    lv.LandValue,
+				BaseDate:     nullString(lv.BaseDate),
+				LotCount:     len(lv.Lots),
+				ImportedAt:   now,
+			}
+			if lv.AreaSqm > 0 {
+				rec.AreaSqm = sql.NullFloat64{Float64: lv.AreaSqm, Valid: true}
+			}
+
+			if err := database.UpsertLandValue(rec); err != nil {
+				log.Printf("Failed to save land value for %s: %v", lot, err)
+				continue
+			}
+			saved++
+		}
+		return nil
+	})
+	if err != nil {
+		log.Fatalf("Failed to read land values: %v", err)
+	}
+
+	updated, err := database.UpdatePropertyLandValues()
+	if err != nil {
+		log.Printf("Warning: failed to update property land values: %v", err)
+	}
+
+	log.Printf("Done! Read %d land values, saved %d lot values, updated %d properties", read, saved, updated)
+}
+
+func matchGNAF() {
+	dbPath := flag.String("db", "data/farm-search.db", "Database path")
+	path := flag.String("path", "", "G-NAF release zip or extracted directory (omit to re-match against the addresses already imported)")
+	state := flag.String("state", "NSW", "State whose addresses to import and properties to match")
+	all := flag.Bool("all", false, "Import every locality's addresses, not just localities with properties")
+	flag.Parse()
+
+	*state = strings.ToUpper(*state)
+
+	database, err := db.New(*dbPath)
+	if err != nil {
+		log.Fatalf("Failed to open database: %v", err)
+	}
+	defer database.Close()
+
+	if *path != "" {
+		// A state's addresses run to millions; by default only keep the
+		// localities we have listings in
+		var keep func(string) bool
+		if !*all {
+			suburbs, err := database.GetPropertySuburbs(*state)
+			if err != nil {
+				log.Fatalf("Failed to get suburbs: %v", err)
+			}
+			if len(suburbs) == 0 {
+				log.Fatalf("No %s properties with a suburb. Scrape some first or use -all", *state)
+			}
+			keep = func(locality string) bool { return suburbs[locality] }
+			log.Printf("Importing %s addresses in %d suburbs from %s...", *state, len(suburbs), *path)
+		} else {
+			log.Printf("Importing all %s addresses from %s...", *state, *path)
+		}
+
+		addresses, err := gnaf.Read(*path, *state, keep)
+		if err != nil {
+			log.Fatalf("Failed to read G-NAF: %v", err)
+		}
+		n, err := database.ReplaceGNAFAddresses(*state, addresses)
+		if err != nil {
+			log.Fatalf("Failed to save G-NAF addresses: %v", err)
+		}
+		log.Printf("Replaced %s G-NAF addresses with %d addresses", *state, n)
+	}
+
+	properties, err := database.GetPropertiesToMatchGNAF(*state)
+	if err != nil {
+		log.Fatalf("Failed to get properties: %v", err)
+	}
+	log.Printf("Matching %d %s properties to G-NAF addresses...", len(properties), *state)
+
+	var matches []db.GNAFMatch
+	byKind := make(map[string]int)
+	var locality string
+	var candidates []models.GNAFAddress
+	for _, p := range properties {
+		// Properties come by suburb, so each locality is loaded once
+		if suburb := strings.ToUpper(strings.TrimSpace(p.Suburb.String)); suburb != locality {
+			locality = suburb
+			if candidates, err = database.GetGNAFAddressesInLocality(*state, locality); err != nil {
+				log.Fatalf("Failed to get G-NAF addresses: %v", err)
+			}
+		}
+
+		listing := gnaf.Listing{
+			Address:  p.Address.String,
+			Lat:      p.Latitude.Float64,
+			Lng:      p.Longitude.Float64,
+			HasCoord: p.Latitude.Valid && p.Longitude.Valid,
+		}
+		match, kind, ok := gnaf.Match(listing, candidates)
+		if !ok {
+			continue
+		}
+		matches = append(matches, db.GNAFMatch{PropertyID: p.ID, PID: match.PID, Kind: kind})
+		byKind[kind]++
+	}
+	if err := database.SaveGNAFMatches(*state, matches); err != nil {
+		log.Fatalf("Failed to save matches: %v", err)
+	}
+	log.Printf("Matched %d of %d properties (house number: %d, lot number: %d, nearest on the street: %d)",
+		len(matches), len(properties), byKind[gnaf.MatchNumber], byKind[gnaf.MatchLot], byKind[gnaf.MatchNearest])
+
+	// Listings matched to the same address are the same property
+	if err := database.FindDuplicateProperties(); err != nil {
+		log.Printf("Warning: duplicate detection failed: %v", err)
+	}
+	log.Println("Done!")
+}
+
+// nullString returns a valid NullString for non-empty strings
+func pruneDelisted() {
+	dbPath := flag.String("db", "data/farm-search.db", "Database path")
+	months := flag.Int("months", 6, "Delete properties last scraped more than this many months ago")
+	dryRun := flag.Bool("dry-run", false, "Report what would be deleted without deleting it")
+	orphanLots := flag.Bool("lots", false, "Also delete cadastral lots no longer linked to any property")
+	vacuum := flag.Bool("vacuum", false, "Rebuild the database file afterwards to reclaim disk space")
+	flag.Parse()
+
+	if *months < 1 {
+		log.Fatal("-months must be at least 1")
+	}
+
+	database, err := db.New(*dbPath)
+	if err != nil {
+		log.Fatalf("Failed to open database: %v", err)
+	}
+	defer database.Close()
+
+	publisher := mustEventsFromEnv()
+
+	cutoff := time.Now().AddDate(0, -*months, 0)
+	log.Printf("Pruning properties last scraped before %s...", cutoff.Format("2006-01-02"))
+
+	result, err := database.PruneDelistedProperties(cutoff, *orphanLots, *dryRun)
+	if err != nil {
+		log.Fatalf("Failed to prune: %v", err)
+	}
+
+	for _, source := range result.StaleSources {
+		log.Printf("Skipped source %s: not scraped since the cutoff, so its listings may not be delisted", source)
+	}
+	sources := make([]string, 0, len(result.BySource))
+	for source := range result.BySource {
+		sources = append(sources, source)
+	}
+	sort.Strings(sources)
+	for _, source := range sources {
+		log.Printf("  %-14s %d properties", source, result.BySource[source])
+	}
+	log.Printf("Properties: %d, distances: %d, lot links: %d, duplicate links: %d, link rejections: %d, merged fields: %d, events: %d, media: %d, road snaps: %d, auction results unlinked: %d",
+		result.Properties, result.Distances, result.LotLinks, result.DuplicateLinks, result.LinkRejections, result.MergedFields, result.Events, result.Media, result.RoadSnaps, result.AuctionResults)
+	log.Printf("Kept listing history and change log of %d pruned listings", result.Archived)
+	if result.Kept > 0 {
+		log.Printf("Kept %d delisted properties with attachments", result.Kept)
+	}
+	if result.StillListed > 0 {
+		log.Printf("Kept %d delisted properties with a duplicate listing still scraped", result.StillListed)
+	}
+	if *orphanLots {
+		log.Printf("Orphaned cadastral lots: %d", result.OrphanLots)
+	}
+
+	if *dryRun {
+		log.Println("Dry run: nothing deleted")
+		return
+	}
+	events.Send(context.Background(), publisher, events.Listings(events.PropertyDelisted, result.Pruned))
+
+	if *vacuum {
+		log.Println("Vacuuming database...")
+		if err := database.Vacuum(); err != nil {
+			log.Fatalf("Failed to vacuum: %v", err)
+		}
+	}
+
+	log.Println("Done!")
+}
+
+func backupDatabase() {
+	dbPath := flag.String("db", "data/farm-search.db", "Database path")
+	dir := flag.String("dir", "data/backups", "Directory for snapshots")
+	keep := flag.Int("keep", 7, "Number of local snapshots to keep (0 keeps all)")
+	upload := flag.Bool("s3", false, "Upload the snapshot to S3-compatible storage (S3_ENDPOINT, S3_BUCKET, S3_ACCESS_KEY_ID, S3_SECRET_ACCESS_KEY)")
+	prefix := flag.String("s3-prefix", "farm-search", "Key prefix for uploaded snapshots")
+	flag.Parse()
+
+	// Check S3 settings before spending time on the snapshot
+	var s3 *backup.S3Client
+	if *upload {
+		var err error
+		if s3, err = backup.S3FromEnv(); err != nil {
+			log.Fatalf("S3 upload: %v", err)
+		}
+	}
+
+	database, err := db.New(*dbPath)
+	if err != nil {
+		log.Fatalf("Failed to open database: %v", err)
+	}
+	defer database.Close()
+
+	start := time.Now()
+	path, err := backup.Snapshot(database, *dir)
+	if err != nil {
+		log.Fatalf("Failed to snapshot database: %v", err)
+	}
+	if err := backup.Verify(path); err != nil {
+		log.Fatalf("Snapshot %s is unusable: %v", path, err)
+	}
+	info, _ := os.Stat(path)
+	log.Printf("Wrote %s (%.1f MB) in %s", path, float64(info.Size())/1e6, time.Since(start).Round(time.Millisecond))
+
+	if s3 != nil {
+		key := backup.KeyFor(*prefix, path)
+		if err := s3.Upload(context.Background(), key, path); err != nil {
+			log.Fatalf("Failed to upload snapshot: %v", err)
+		}
+		log.Printf("Uploaded to s3://%s/%s", s3.Bucket, key)
+	}
+
+	if *keep > 0 {
+		removed, err := backup.Rotate(*dir, *keep)
+		if err != nil {
+			log.Printf("Warning: failed to rotate snapshots: %v", err)
+		}
+		for _, old := range removed {
+			log.Printf("Removed old snapshot %s", old)
+		}
+	}
+
+	log.Println("Done!")
+}
+
+func restoreDatabase() {
+	dbPath := flag.String("db", "data/farm-search.db", "Database path to replace")
+	from := flag.String("from", "", "Snapshot file to restore, or \"latest\" for the newest in -dir")
+	dir := flag.String("dir", "data/backups", "Directory for snapshots")
+	s3Key := flag.String("s3-key", "", "Restore this object from S3-compatible storage instead of a local file")
+	flag.Parse()
+
+	// Replacing the file under Litestream or LiteFS leaves replicas with WAL
+	// frames for a database that's gone
+	if r := db.Replication(); r != "" {
+		log.Fatalf("DB_REPLICATION is %s: stop %s and restore with it (e.g. litestream restore), or unset DB_REPLICATION once it's stopped", r, r)
+	}
+
+	src := *from
+	switch {
+	case *s3Key != "":
+		s3, err := backup.S3FromEnv()
+		if err != nil {
+			log.Fatalf("S3 download: %v", err)
+		}
+		if err := os.MkdirAll(*dir, 0755); err != nil {
+			log.Fatalf("Failed to create backup directory: %v", err)
+		}
+		src = filepath.Join(*dir, "downloaded-"+filepath.Base(*s3Key))
+		log.Printf("Downloading s3://%s/%s...", s3.Bucket, *s3Key)
+		if err := s3.Download(context.Background(), *s3Key, src); err != nil {
+			log.Fatalf("Failed to download snapshot: %v", err)
+		}
+	case src == "latest":
+		snapshots, err := backup.List(*dir)
+		if err != nil || len(snapshots) == 0 {
+			log.Fatalf("No snapshots in %s", *dir)
+		}
+		src = snapshots[len(snapshots)-1]
+	case src == "":
+		log.Fatal("A snapshot is required. Use -from data/backups/farm-search-20260101-030000.db, -from latest, or -s3-key")
+	}
+
+	log.Printf("Restoring %s to %s...", src, *dbPath)
+	if err := backup.Restore(src, *dbPath); err != nil {
+		log.Fatalf("Failed to restore: %v", err)
+	}
+
+	// Opening runs migrations, bringing an older snapshot up to the current schema
+	database, err := db.New(*dbPath)
+	if err != nil {
+		log.Fatalf("Failed to open restored database: %v", err)
+	}
+	defer database.Close()
+	count, _ := database.GetPropertyCount()
+
+	log.Printf("Done! Restored %d properties; previous database kept as %s.pre-restore", count, *dbPath)
+}
+
+func checkpointDatabase() {
+	dbPath := flag.String("db", "data/farm-search.db", "Database path")
+	mode := flag.String("mode", "passive", "Checkpoint mode: "+strings.Join(db.CheckpointModes, ", ")+
+		" (restart and truncate wait for readers, and reset the WAL)")
+	flag.Parse()
+
+	database, err := db.New(*dbPath)
+	if err != nil {
+		log.Fatalf("Failed to open database: %v", err)
+	}
+	defer database.Close()
+
+	result, err := database.Checkpoint(*mode)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if result.Frames < 0 {
+		log.Println("The database isn't in WAL mode; nothing to checkpoint")
+		return
+	}
+	log.Printf("Checkpointed %d of %d WAL frames", result.Checkpointed, result.Frames)
+	if result.Busy {
+		log.Println("Warning: readers or writers kept it from finishing; try again, or stop them")
+	}
+}
+
+func mergeDatabases() {
+	dbPath := flag.String("db", "data/farm-search.db", "Database path to merge into")
+	from := flag.String("from", "", "Database to merge from (required; brought up to the current schema, otherwise unchanged)")
+	valhallaURL := flag.String("valhalla-url", defaultValhallaURL, "Valhalla server URL, for saved searches with drive time area filters")
+	flag.Parse()
+
+	if *from == "" {
+		log.Fatal("A database to merge from is required. Use -from other.db")
+	}
+	target, _ := filepath.Abs(*dbPath)
+	source, _ := filepath.Abs(*from)
+	if target == source {
+		log.Fatal("-from is the database being merged into")
+	}
+	if _, err := os.Stat(*from); err != nil {
+		log.Fatalf("Failed to open %s: %v", *from, err)
+	}
+
+	// Opening runs migrations, so both have the same columns
+	other, err := db.New(*from)
+	if err != nil {
+		log.Fatalf("Failed to open %s: %v", *from, err)
+	}
+	defer other.Close()
+	database, err := db.New(*dbPath)
+	if err != nil {
+		log.Fatalf("Failed to open database: %v", err)
+	}
+	defer database.Close()
+
+	log.Printf("Merging properties from %s...", *from)
+	result, err := database.MergeDatabase(*from)
+	if err != nil {
+		log.Fatalf("Failed to merge properties: %v", err)
+	}
+	log.Printf("Properties: %d new, %d listings updated, %d relocated, %d enrichment filled in; cadastral lots: %d new",
+		result.Inserted, result.Updated, result.Relocated, result.Filled, result.Lots)
+
+	// User data is matched by listing, so goes after the properties
+	ctx := context.Background()
+	newUserDataService := func(database *db.DB) *service.UserDataService {
+		isochrones := service.NewIsochroneService(database, geo.NewIsochroneGenerator(*valhallaURL))
+		properties := service.NewPropertyService(database, isochrones)
+		return service.NewUserDataService(database, properties,
+			service.NewSavedSearchService(database, properties), service.NewScoringService(database))
+	}
+	data, err := newUserDataService(other).Export()
+	if err != nil {
+		log.Fatalf("Failed to export user data from %s: %v", *from, err)
+	}
+	imported, err := newUserDataService(database).Import(ctx, data)
+	if err != nil {
+		log.Fatalf("Failed to import user data: %v", err)
+	}
+	sections := make([]string, 0, len(imported.Imported))
+	for section := range imported.Imported {
+		sections = append(sections, section)
+	}
+	sort.Strings(sections)
+	for _, section := range sections {
+		log.Printf("  %-16s %d imported, %d skipped", section, imported.Imported[section], imported.Skipped[section])
+	}
+
+	// Link the merged listings to the same property found on other sites
+	if err := database.FindDuplicateProperties(); err != nil {
+		log.Printf("Warning: failed to find duplicate properties: %v", err)
+	}
+
+	// New and updated properties need scores from every profile
+	if n, err := service.NewScoringService(database).ComputeAll(); err != nil {
+		log.Printf("Warning: failed to rescore properties: %v", err)
+	} else if n > 0 {
+		log.Printf("Rescored properties with %d score profiles", n)
+	}
+
+	log.Println("Done! Run `tools enrich` to recompute anything stale")
+}
+
+func initRegionDatabase() {
+	dbPath := flag.String("db", "", "Region database to create or prepare (required), e.g. data/vic.db")
+	idBase := flag.Int64("id-base", 0, "First property ID, clear of every other database's, e.g. 100000000 (required)")
+	flag.Parse()
+
+	if *dbPath == "" || *idBase < 1 {
+		log.Fatal("A database and ID base are required. Use -db data/vic.db -id-base 100000000")
+	}
+
+	database, err := db.New(*dbPath)
+	if err != nil {
+		log.Fatalf("Failed to open database: %v", err)
+	}
+	defer database.Close()
+
+	if err := database.SetPropertyIDBase(*idBase); err != nil {
+		log.Fatalf("Failed to set ID base: %v", err)
+	}
+
+	log.Printf("Done! New properties in %s start at ID %d; scrape into it with -db, then add it to REGION_DBS", *dbPath, *idBase)
+}
+
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
 }
@@ -1,11 +1,33 @@
 package api
 
 import (
+	"bytes"
 	"context"
+	"crypto/rand"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
+	"farm-search/internal/attachments"
 	"farm-search/internal/db"
+	"farm-search/internal/feed"
 	"farm-search/internal/geo"
+	"farm-search/internal/graphql"
+	"farm-search/internal/jobs"
+	"farm-search/internal/models"
+	"farm-search/internal/planner"
+	"farm-search/internal/service"
+	"fmt"
+	"html"
+	"io"
+	"log"
+	"math"
+	"net"
 	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -15,251 +37,2958 @@ import (
 
 // Handlers contains HTTP handlers and their dependencies
 type Handlers struct {
-	db *db.DB
+	db         *db.DB
+	properties *service.PropertyService
+	nearby     *service.NearbyService
+	isochrones *service.IsochroneService
+	scoring    *service.ScoringService
+	searches   *service.SavedSearchService
+	watchlists *service.WatchlistService
+	tags       *service.TagService
+	suburbs    *service.SuburbStatsService
+	lots       *service.LotService
+	userData   *service.UserDataService
+
+	// attachments is nil when the attachment store isn't configured
+	attachments *service.AttachmentService
+	// jobs is nil when the server runs no scheduled tasks (-jobs)
+	jobs *jobs.Runner
 }
 
 // NewHandlers creates a new Handlers instance
 func NewHandlers(database *db.DB) *Handlers {
-	return &Handlers{db: database}
+	isochrones := service.NewIsochroneService(database, geo.NewIsochroneGenerator(valhallaURL))
+	properties := service.NewPropertyService(database, isochrones).
+		WithWhat3Words(geo.NewWhat3WordsClient(what3wordsAPIKey))
+	if regions, err := openRegions(regionDBs, database.ReadOnly()); err != nil {
+		log.Printf("Warning: region databases disabled: %v", err)
+	} else if len(regions) > 0 {
+		federated, err := properties.WithRegions(regions)
+		if err != nil {
+			log.Printf("Warning: region databases disabled: %v", err)
+		} else {
+			properties = federated
+			log.Printf("Federating %d region databases", len(regions))
+		}
+	}
+	scoring := service.NewScoringService(database)
+	searches := service.NewSavedSearchService(database, properties)
+	h := &Handlers{
+		db:         database,
+		properties: properties,
+		nearby:     service.NewNearbyService(database),
+		isochrones: isochrones,
+		scoring:    scoring,
+		searches:   searches,
+		watchlists: service.NewWatchlistService(database, properties),
+		tags:       service.NewTagService(database, properties),
+		suburbs:    service.NewSuburbStatsService(database, properties),
+		lots:       service.NewLotService(database, geo.NewCadastralClient()),
+		userData:   service.NewUserDataService(database, properties, searches, scoring),
+	}
+
+	store, err := attachments.FromEnv()
+	if err != nil {
+		log.Printf("Warning: attachments disabled: %v", err)
+	} else {
+		h.attachments = service.NewAttachmentService(database, store)
+	}
+	return h
 }
 
-// parsePropertyFilter extracts filter parameters from query string
-func parsePropertyFilter(q map[string][]string) db.PropertyFilter {
-	filter := db.PropertyFilter{}
+// Properties returns the property lookups the handlers answer from, for
+// serving them over other protocols (see grpcapi)
+func (h *Handlers) Properties() *service.PropertyService {
+	return h.properties
+}
 
-	get := func(key string) string {
-		if vals, ok := q[key]; ok && len(vals) > 0 {
-			return vals[0]
-		}
-		return ""
+// ListProperties handles GET /api/properties
+// A drive time area filter (within_lat, within_lng, within_minutes) may have
+// to generate its isochrone first, failing with 503 if Valhalla is down.
+func (h *Handlers) ListProperties(w http.ResponseWriter, r *http.Request) {
+	filter := service.ParsePropertyFilter(r.URL.Query())
+
+	ctx, cancel := context.WithTimeout(r.Context(), 60*time.Second)
+	defer cancel()
+
+	properties, err := h.properties.List(ctx, filter)
+	if err != nil {
+		http.Error(w, err.Error(), routingErrorStatus(err))
+		return
+	}
+
+	setRowCount(r, len(properties))
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"properties": properties,
+		"count":      len(properties),
+	})
+}
+
+// GetProperty handles GET /api/properties/{id}
+func (h *Handlers) GetProperty(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		http.Error(w, "invalid property ID", http.StatusBadRequest)
+		return
+	}
+
+	property, err := h.properties.Detail(r.Context(), id)
+	if err != nil {
+		http.Error(w, "property not found", http.StatusNotFound)
+		return
 	}
 
-	// Parse price filters
-	if v := get("price_min"); v != "" {
-		if val, err := strconv.ParseInt(v, 10, 64); err == nil {
-			filter.PriceMin = &val
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(property)
+}
+
+// maxRecentDays caps how far back GET /api/properties/recent looks
+const maxRecentDays = 365
+
+// GetRecentProperties handles GET /api/properties/recent: the properties
+// matching the /api/properties filters that came on the market (change=new),
+// dropped their asking price (price_drop) or came back on the market
+// (back_on_market) in the last days (default 7), most recent first, for a
+// "this week in your patch" digest. change takes a comma-separated list and
+// defaults to all three.
+func (h *Handlers) GetRecentProperties(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	days := 7
+	if v := q.Get("days"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxRecentDays {
+			http.Error(w, fmt.Sprintf("days must be 1 to %d", maxRecentDays), http.StatusBadRequest)
+			return
 		}
+		days = n
 	}
-	if v := get("price_max"); v != "" {
-		if val, err := strconv.ParseInt(v, 10, 64); err == nil {
-			filter.PriceMax = &val
+	kinds := db.ChangeKinds
+	if v := q.Get("change"); v != "" {
+		kinds = strings.Split(v, ",")
+		for _, kind := range kinds {
+			if !slices.Contains(db.ChangeKinds, kind) {
+				http.Error(w, fmt.Sprintf("change must be %s", strings.Join(db.ChangeKinds, ", ")), http.StatusBadRequest)
+				return
+			}
 		}
 	}
 
-	// Parse property types
-	if v := get("type"); v != "" {
-		filter.PropertyTypes = strings.Split(v, ",")
+	ctx, cancel := context.WithTimeout(r.Context(), 60*time.Second)
+	defer cancel()
+
+	since := time.Now().AddDate(0, 0, -days)
+	changes, err := h.properties.RecentChanges(ctx, service.ParsePropertyFilter(q), since, kinds)
+	if err != nil {
+		http.Error(w, err.Error(), routingErrorStatus(err))
+		return
 	}
 
-	// Parse land size filters
-	if v := get("land_size_min"); v != "" {
-		if val, err := strconv.ParseFloat(v, 64); err == nil {
-			filter.LandSizeMin = &val
-		}
+	setRowCount(r, len(changes))
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"since":      since.UTC().Format(time.RFC3339),
+		"properties": changes,
+		"count":      len(changes),
+	})
+}
+
+// GetPropertiesAsOf handles GET /api/properties/as-of: the properties
+// matching the /api/properties filters that were on the market at date (RFC
+// 3339, or YYYY-MM-DD for the end of that day in Sydney time), with their
+// asking price, type and land size as they were then, most recently listed
+// first
+func (h *Handlers) GetPropertiesAsOf(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	v := q.Get("date")
+	if v == "" {
+		http.Error(w, "date required", http.StatusBadRequest)
+		return
 	}
-	if v := get("land_size_max"); v != "" {
-		if val, err := strconv.ParseFloat(v, 64); err == nil {
-			filter.LandSizeMax = &val
-		}
+	asOf, err := parseSince(v)
+	if err != nil {
+		http.Error(w, "date must be RFC 3339 or YYYY-MM-DD", http.StatusBadRequest)
+		return
+	}
+	if len(v) == len("2006-01-02") {
+		asOf = asOf.AddDate(0, 0, 1)
+	}
+	if now := time.Now(); asOf.After(now) {
+		asOf = now
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 60*time.Second)
+	defer cancel()
+
+	properties, err := h.properties.AsOf(ctx, service.ParsePropertyFilter(q), asOf)
+	if err != nil {
+		http.Error(w, err.Error(), routingErrorStatus(err))
+		return
+	}
+
+	setRowCount(r, len(properties))
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"as_of":      asOf.UTC().Format(time.RFC3339),
+		"properties": properties,
+		"count":      len(properties),
+	})
+}
+
+// propertyDetailsRequest is POST /api/properties/details' body
+type propertyDetailsRequest struct {
+	IDs []int64 `json:"ids"`
+}
+
+// GetPropertyDetails handles POST /api/properties/details: the details of
+// several properties in one request, e.g. {"ids":[40,41,97]}, for previewing
+// a map cluster without a request per property. Properties come back in the
+// order asked for, a duplicate listing's ID resolving to its canonical
+// property (listed once); IDs that don't exist are returned as missing.
+// Share locations aren't included (see GET /api/properties/:id).
+func (h *Handlers) GetPropertyDetails(w http.ResponseWriter, r *http.Request) {
+	var req propertyDetailsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid JSON body", http.StatusBadRequest)
+		return
+	}
+	if len(req.IDs) == 0 {
+		http.Error(w, "ids required", http.StatusBadRequest)
+		return
+	}
+	if len(req.IDs) > service.MaxListLimit {
+		http.Error(w, fmt.Sprintf("at most %d ids allowed", service.MaxListLimit), http.StatusBadRequest)
+		return
+	}
+
+	properties, missing, err := h.properties.GetList(req.IDs)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if properties == nil {
+		properties = []*models.PropertyDetail{}
+	}
+	if missing == nil {
+		missing = []int64{}
 	}
 
-	// Parse distance filters
-	if v := get("distance_sydney_max"); v != "" {
-		if val, err := strconv.ParseFloat(v, 64); err == nil {
-			filter.DistanceSydneyMax = &val
+	setRowCount(r, len(properties))
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"properties": properties,
+		"missing":    missing,
+	})
+}
+
+// maxGraphQLBytes caps the size of a GraphQL request body
+const maxGraphQLBytes = 64 << 10
+
+// graphQLRequest is a GraphQL request, as a POST body or GET parameters
+type graphQLRequest struct {
+	Query     string                 `json:"query"`
+	Variables map[string]interface{} `json:"variables"`
+}
+
+// graphQLError is an error in a GraphQL response, with the path of the
+// root field it happened in
+type graphQLError struct {
+	Message string   `json:"message"`
+	Path    []string `json:"path,omitempty"`
+}
+
+// GraphQL handles GET and POST /api/graphql, for clients that want only some
+// of the detail view's fields, e.g.
+//
+//	{ property(id: 40) { price_text prior_sales { date price } fire_history } }
+//
+// The root fields are property(id) (GET /api/properties/:id) and
+// properties(filter, limit, offset), whose filter is a GET /api/properties
+// query string; a property list selecting fields beyond the list's gets
+// each property's details. Fields are named as in the REST responses, and
+// one selected without a sub-selection is returned whole. Only queries are
+// supported (see internal/graphql).
+func (h *Handlers) GraphQL(w http.ResponseWriter, r *http.Request) {
+	var req graphQLRequest
+	if r.Method == http.MethodPost {
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxGraphQLBytes)).Decode(&req); err != nil {
+			http.Error(w, "invalid request body", http.StatusBadRequest)
+			return
 		}
+	} else {
+		req.Query = r.URL.Query().Get("query")
+		if v := r.URL.Query().Get("variables"); v != "" {
+			if err := json.Unmarshal([]byte(v), &req.Variables); err != nil {
+				http.Error(w, "invalid variables", http.StatusBadRequest)
+				return
+			}
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	fields, err := graphql.Parse(req.Query)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{"errors": []graphQLError{{Message: err.Error()}}})
+		return
 	}
-	if v := get("distance_town_max"); v != "" {
-		if val, err := strconv.ParseFloat(v, 64); err == nil {
-			filter.DistanceTownMax = &val
+
+	ctx, cancel := context.WithTimeout(r.Context(), 60*time.Second)
+	defer cancel()
+
+	data := make(graphql.Object, 0, len(fields))
+	var errs []graphQLError
+	for _, field := range fields {
+		value, err := h.resolveGraphQL(ctx, field, req.Variables)
+		if err != nil {
+			errs = append(errs, graphQLError{Message: err.Error(), Path: []string{field.Key()}})
+			value = nil
 		}
+		data = append(data, graphql.Member{Key: field.Key(), Value: value})
+	}
+
+	resp := map[string]interface{}{"data": data}
+	if len(errs) > 0 {
+		resp["errors"] = errs
 	}
-	// Parse drive time filters
-	if v := get("drive_time_sydney_max"); v != "" {
-		if val, err := strconv.Atoi(v); err == nil {
-			filter.DriveTimeSydneyMax = &val
+	json.NewEncoder(w).Encode(resp)
+}
+
+// resolveGraphQL resolves a root field of a GraphQL query
+func (h *Handlers) resolveGraphQL(ctx context.Context, field *graphql.Field, vars map[string]interface{}) (interface{}, error) {
+	args := make(map[string]interface{}, len(field.Args))
+	for name, arg := range field.Args {
+		value, err := arg.Resolve(vars)
+		if err != nil {
+			return nil, err
 		}
+		args[name] = value
 	}
-	if v := get("drive_time_town_max"); v != "" {
-		if val, err := strconv.Atoi(v); err == nil {
-			filter.DriveTimeTownMax = &val
+
+	switch field.Name {
+	case "__typename":
+		return "Query", nil
+	case "property":
+		id, err := graphQLInt(args["id"])
+		if err != nil {
+			return nil, fmt.Errorf("id: %w", err)
+		}
+		property, err := h.properties.Detail(ctx, id)
+		if err != nil {
+			return nil, errors.New("property not found")
+		}
+		return graphql.Project(property, field.Selections)
+	case "properties":
+		query, ok := args["filter"].(string)
+		if !ok && args["filter"] != nil {
+			return nil, errors.New("filter: expected a query string")
+		}
+		values, err := url.ParseQuery(query)
+		if err != nil {
+			return nil, fmt.Errorf("filter: %w", err)
+		}
+		f := service.ParsePropertyFilter(values)
+		for name, n := range map[string]*int{"limit": &f.Limit, "offset": &f.Offset} {
+			if args[name] != nil {
+				v, err := graphQLInt(args[name])
+				if err != nil {
+					return nil, fmt.Errorf("%s: %w", name, err)
+				}
+				*n = int(v)
+			}
+		}
+		// Unlike GET /api/properties, never every match
+		if f.Limit <= 0 || f.Limit > service.MaxListLimit {
+			f.Limit = service.MaxListLimit
+		}
+
+		properties, err := h.properties.List(ctx, f)
+		if err != nil {
+			return nil, err
 		}
+		if items, err := graphql.Project(properties, field.Selections); err == nil {
+			return items, nil
+		}
+		// Fields beyond the list's need each property's details
+		ids := make([]int64, len(properties))
+		for i, p := range properties {
+			ids[i] = p.ID
+		}
+		details := h.properties.GetMany(ids)
+		list := make([]*models.PropertyDetail, 0, len(properties))
+		for _, p := range properties {
+			if d, ok := details[p.ID]; ok {
+				list = append(list, d)
+			}
+		}
+		return graphql.Project(list, field.Selections)
 	}
-	if v := get("drive_time_school_max"); v != "" {
-		if val, err := strconv.Atoi(v); err == nil {
-			filter.DriveTimeSchoolMax = &val
+	return nil, fmt.Errorf("unknown field %q on Query (want property or properties)", field.Name)
+}
+
+// graphQLInt reads an integer argument, given as a literal, a JSON variable
+// (a float64) or an ID string
+func graphQLInt(v interface{}) (int64, error) {
+	switch n := v.(type) {
+	case int64:
+		return n, nil
+	case float64:
+		if n == math.Trunc(n) {
+			return int64(n), nil
 		}
+	case string:
+		if i, err := strconv.ParseInt(n, 10, 64); err == nil {
+			return i, nil
+		}
+	case nil:
+		return 0, errors.New("required")
+	}
+	return 0, fmt.Errorf("expected an integer, got %v", v)
+}
+
+// maxNearbyK caps how many amenities of each type GetPropertyNearby returns
+const maxNearbyK = 20
+
+// GetPropertyNearby handles GET /api/properties/{id}/nearby
+// Returns the k nearest amenities of each type to the property, closest
+// first, with straight-line distance and the drive time where one has
+// already been calculated.
+// Optional params: types (comma-separated, default "town,school"), k (default 5, max 20)
+func (h *Handlers) GetPropertyNearby(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "invalid property ID", http.StatusBadRequest)
+		return
 	}
 
-	// Parse map bounds (sw_lat,sw_lng,ne_lat,ne_lng)
-	if v := get("bounds"); v != "" {
-		parts := strings.Split(v, ",")
-		if len(parts) == 4 {
-			swLat, _ := strconv.ParseFloat(parts[0], 64)
-			swLng, _ := strconv.ParseFloat(parts[1], 64)
-			neLat, _ := strconv.ParseFloat(parts[2], 64)
-			neLng, _ := strconv.ParseFloat(parts[3], 64)
-			filter.SWLat = &swLat
-			filter.SWLng = &swLng
-			filter.NELat = &neLat
-			filter.NELng = &neLng
+	q := r.URL.Query()
+	k := 5
+	if v, err := strconv.Atoi(q.Get("k")); err == nil && v > 0 {
+		k = min(v, maxNearbyK)
+	}
+	types := []string{service.AmenityTown, service.AmenitySchool}
+	if v := q.Get("types"); v != "" {
+		types = nil
+		for _, t := range strings.Split(v, ",") {
+			if t = strings.ToLower(strings.TrimSpace(t)); t != "" && !slices.Contains(types, t) {
+				types = append(types, t)
+			}
 		}
 	}
 
-	// Parse pagination
-	if v := get("limit"); v != "" {
-		if val, err := strconv.Atoi(v); err == nil && val > 0 && val <= 500 {
-			filter.Limit = val
+	property, err := h.properties.Get(id)
+	if err != nil {
+		http.Error(w, "property not found", http.StatusNotFound)
+		return
+	}
+
+	nearby, err := h.nearby.Nearest(property, types, k)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"property_id": property.ID,
+		"k":           k,
+		"nearby":      nearby,
+	})
+}
+
+// maxNeighboursKm caps the radius GetPropertyNeighbours searches
+const maxNeighboursKm = 50
+
+// GetPropertyNeighbours handles GET /api/properties/{id}/neighbours
+// Returns the other current listings within a radius of the property,
+// closest first, with straight-line distance.
+// Optional params: km (default 5, max 50)
+func (h *Handlers) GetPropertyNeighbours(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "invalid property ID", http.StatusBadRequest)
+		return
+	}
+
+	km := 5.0
+	if v, err := strconv.ParseFloat(r.URL.Query().Get("km"), 64); err == nil && v > 0 {
+		km = min(v, maxNeighboursKm)
+	}
+
+	property, err := h.properties.Get(id)
+	if err != nil {
+		http.Error(w, "property not found", http.StatusNotFound)
+		return
+	}
+
+	neighbours, err := h.properties.Neighbours(property, km)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"property_id": property.ID,
+		"km":          km,
+		"neighbours":  neighbours,
+	})
+}
+
+// SetPropertyCoordinates handles POST /api/properties/{id}/coordinates
+// Body: {"lat": -34.5, "lng": 150.3}. Manually corrects a property's location
+// (a duplicate's ID corrects its canonical property), which scrapes then keep,
+// and recomputes its drive times, nearest towns, cadastral lots and their
+// heritage listings. Nearest schools are left for the schools tools.
+func (h *Handlers) SetPropertyCoordinates(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "invalid property ID", http.StatusBadRequest)
+		return
+	}
+
+	var req struct {
+		Lat *float64 `json:"lat"`
+		Lng *float64 `json:"lng"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid JSON body", http.StatusBadRequest)
+		return
+	}
+	// Roughly Australia, to catch swapped or sign-flipped coordinates
+	if req.Lat == nil || req.Lng == nil || *req.Lat < -44 || *req.Lat > -9 || *req.Lng < 112 || *req.Lng > 154 {
+		http.Error(w, "lat and lng required, within Australia", http.StatusBadRequest)
+		return
+	}
+
+	property, err := h.properties.Get(id)
+	if err != nil {
+		http.Error(w, "property not found", http.StatusNotFound)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 2*time.Minute)
+	defer cancel()
+
+	before := auditCoordinates(property)
+	enrichment := service.NewEnrichmentService(h.db, geo.NewRouter(valhallaURL), nil, geo.NewCadastralClient()).
+		WithAnchor(anchor).WithHeritage(geo.NewHeritageClient()).WithLotSize(geo.NewLotSizeClient())
+	done, pending, err := enrichment.CorrectCoordinates(ctx, property.ID, *req.Lat, *req.Lng)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	property, err = h.properties.Get(property.ID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	h.audit(r, "property.set_coordinates", "property", property.ID, before, auditCoordinates(property))
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"property":   property,
+		"recomputed": done,
+		"pending":    pending,
+	})
+}
+
+// auditCoordinates is the part of a property a coordinate correction changes
+func auditCoordinates(p *models.PropertyDetail) map[string]interface{} {
+	return map[string]interface{}{
+		"lat":              p.Latitude,
+		"lng":              p.Longitude,
+		"coord_source":     p.CoordSource,
+		"coord_confidence": p.CoordConfidence,
+	}
+}
+
+// GetPropertyRentals handles GET /api/properties/{id}/rentals
+// Returns rentals near the property with the median weekly rent and, if the
+// property has a price, the gross rental yield that rent implies.
+// Optional params: radius_km (default 20), limit (default 20)
+func (h *Handlers) GetPropertyRentals(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		http.Error(w, "invalid property ID", http.StatusBadRequest)
+		return
+	}
+
+	q := r.URL.Query()
+	radiusKm := 20.0
+	if v, err := strconv.ParseFloat(q.Get("radius_km"), 64); err == nil && v > 0 {
+		radiusKm = v
+	}
+	limit := 20
+	if v, err := strconv.Atoi(q.Get("limit")); err == nil && v > 0 {
+		limit = v
+	}
+
+	property, err := h.properties.Get(id)
+	if err != nil {
+		http.Error(w, "property not found", http.StatusNotFound)
+		return
+	}
+
+	candidates, err := h.db.GetRentalsInBox(property.Latitude, property.Longitude, radiusKm)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Refine the bounding box to the actual radius, nearest first
+	rentals := candidates[:0]
+	for _, rental := range candidates {
+		rental.DistanceKm = geo.Haversine(property.Latitude, property.Longitude, rental.Latitude, rental.Longitude)
+		if rental.DistanceKm <= radiusKm {
+			rentals = append(rentals, rental)
 		}
 	}
-	if v := get("offset"); v != "" {
-		if val, err := strconv.Atoi(v); err == nil && val >= 0 {
-			filter.Offset = val
+	sort.Slice(rentals, func(i, j int) bool {
+		return rentals[i].DistanceKm < rentals[j].DistanceKm
+	})
+
+	// Median over every rental in range, not just the ones returned
+	response := map[string]interface{}{
+		"property_id": id,
+		"radius_km":   radiusKm,
+		"count":       len(rentals),
+	}
+	if len(rentals) > 0 {
+		rents := make([]int64, len(rentals))
+		for i, rental := range rentals {
+			rents[i] = rental.WeeklyRent
+		}
+		sort.Slice(rents, func(i, j int) bool { return rents[i] < rents[j] })
+		median := float64(rents[len(rents)/2])
+		if len(rents)%2 == 0 {
+			median = float64(rents[len(rents)/2-1]+rents[len(rents)/2]) / 2
+		}
+		response["median_weekly_rent"] = median
+
+		// Gross yield = annual rent / price, using the midpoint of a price range
+		var price float64
+		if property.PriceMin != nil && property.PriceMax != nil {
+			price = float64(*property.PriceMin+*property.PriceMax) / 2
+		} else if property.PriceMin != nil {
+			price = float64(*property.PriceMin)
+		} else if property.PriceMax != nil {
+			price = float64(*property.PriceMax)
+		}
+		if price > 0 {
+			response["gross_yield_pct"] = median * 52 / price * 100
 		}
 	}
 
-	return filter
+	if len(rentals) > limit {
+		rentals = rentals[:limit]
+	}
+	response["rentals"] = rentals
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
 }
 
-// ListProperties handles GET /api/properties
-func (h *Handlers) ListProperties(w http.ResponseWriter, r *http.Request) {
-	filter := parsePropertyFilter(r.URL.Query())
+// GetAnchor handles GET /api/anchor
+// Returns the anchor primary drive times (drive_time_primary), the static
+// isochrones and the drive time grid are measured to, set by ANCHOR
+func (h *Handlers) GetAnchor(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"name":             anchor.Name,
+		"lat":              anchor.Lat,
+		"lng":              anchor.Lng,
+		"isochrone_prefix": anchor.Slug(),
+	})
+}
+
+// GetFilterOptions handles GET /api/filters/options. Its query string takes
+// the same filters as GET /api/properties, which the facet counts are for.
+func (h *Handlers) GetFilterOptions(w http.ResponseWriter, r *http.Request) {
+	options, err := h.db.GetFilterOptions()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 60*time.Second)
+	defer cancel()
+
+	facets, err := h.properties.Facets(ctx, service.ParsePropertyFilter(r.URL.Query()))
+	if err != nil {
+		http.Error(w, err.Error(), routingErrorStatus(err))
+		return
+	}
+	options["facets"] = facets
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(options)
+}
+
+// TriggerScrape handles POST /api/scrape/trigger
+// Starts the scheduled scrape tasks (see -jobs) now rather than at their next
+// run: the one named by the task param, or else every task whose name starts
+// with "scrape". Tasks already running are left to finish and listed in
+// already_running. 503 if the server has no jobs, 404 if no task matches, 409
+// if every match is already running.
+func (h *Handlers) TriggerScrape(w http.ResponseWriter, r *http.Request) {
+	if h.jobs == nil {
+		http.Error(w, "no scheduled jobs configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	var names []string
+	if name := r.URL.Query().Get("task"); name != "" {
+		names = []string{name}
+	} else {
+		for _, name := range h.jobs.Names() {
+			if strings.HasPrefix(name, "scrape") {
+				names = append(names, name)
+			}
+		}
+		if len(names) == 0 {
+			http.Error(w, "no scrape tasks scheduled", http.StatusNotFound)
+			return
+		}
+	}
+
+	queued := []string{}
+	running := []string{}
+	for _, name := range names {
+		switch err := h.jobs.RunNow(name); {
+		case err == nil:
+			queued = append(queued, name)
+		case errors.Is(err, jobs.ErrRunning):
+			running = append(running, name)
+		case errors.Is(err, jobs.ErrUnknownTask):
+			http.Error(w, "no such task: "+name, http.StatusNotFound)
+			return
+		default:
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+	}
+
+	resp := map[string]interface{}{
+		"status":          "queued",
+		"tasks":           queued,
+		"already_running": running,
+	}
+	if len(queued) == 0 {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusConflict)
+		resp["status"] = "already_running"
+		json.NewEncoder(w).Encode(resp)
+		return
+	}
+	h.audit(r, "scrape.trigger", "scrape", nil, nil, map[string]interface{}{"tasks": queued})
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// routingErrorStatus returns the HTTP status for a routing error: 503 if
+// Valhalla is down, 422 if it found no route, else 500
+func routingErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, geo.ErrValhallaUnavailable):
+		return http.StatusServiceUnavailable
+	case errors.Is(err, geo.ErrNoRoute):
+		return http.StatusUnprocessableEntity
+	}
+	return http.StatusInternalServerError
+}
+
+// audit records a change made by a request in the audit log, with the
+// changed record before and after (nil where it didn't exist). A failure is
+// only logged, since the change itself has already been made.
+func (h *Handlers) audit(r *http.Request, action, entity string, entityID, before, after interface{}) {
+	e := &models.AuditEntry{Action: action, Entity: entity}
+	if user := requestUser(r); user != "" {
+		e.User = &user
+	}
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		e.RemoteAddr = &host
+	}
+	if entityID != nil {
+		id := fmt.Sprint(entityID)
+		e.EntityID = &id
+	}
+	e.Before = auditJSON(before)
+	e.After = auditJSON(after)
+	if err := h.db.RecordAudit(e); err != nil {
+		log.Printf("Failed to audit %s: %v", action, err)
+	}
+}
+
+// auditJSON encodes a record for the audit log; nil (or a nil pointer) is
+// left empty
+func auditJSON(v interface{}) json.RawMessage {
+	if v == nil {
+		return nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil || string(b) == "null" {
+		return nil
+	}
+	return b
+}
+
+// requestUser identifies who made a request, from the header an
+// authenticating proxy sets (X-Forwarded-User, or X-Auth-Request-User from
+// oauth2-proxy) or basic auth. There are no accounts of our own, so it's
+// empty when the server is accessed directly.
+func requestUser(r *http.Request) string {
+	for _, header := range []string{"X-Forwarded-User", "X-Auth-Request-User"} {
+		if user := strings.TrimSpace(r.Header.Get(header)); user != "" {
+			return user
+		}
+	}
+	if user, _, ok := r.BasicAuth(); ok {
+		return user
+	}
+	return ""
+}
+
+// parseSince parses a since param: an RFC 3339 time, or a date in Sydney time
+func parseSince(v string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, nil
+	}
+	return time.ParseInLocation("2006-01-02", v, geo.SydneyTime)
+}
+
+// maxSlowQueries caps how many statements GetSlowQueries returns
+const maxSlowQueries = 200
+
+// GetSlowQueries handles GET /api/debug/slow
+// Lists the slowest statements run recently, for deciding what to index:
+// their run count and slowest, total, mean and last times. Optional params:
+// sort (max, the default, total or mean), limit (default 20, max 200).
+func (h *Handlers) GetSlowQueries(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	sortBy := q.Get("sort")
+	if sortBy == "" {
+		sortBy = "max"
+	}
+	if !slices.Contains(db.QueryStatSorts, sortBy) {
+		http.Error(w, "sort must be one of "+strings.Join(db.QueryStatSorts, ", "), http.StatusBadRequest)
+		return
+	}
+	limit := 20
+	if v, err := strconv.Atoi(q.Get("limit")); err == nil && v > 0 {
+		limit = min(v, maxSlowQueries)
+	}
+
+	queries := db.SlowQueries(sortBy, limit)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"queries": queries,
+		"count":   len(queries),
+	})
+}
+
+// GetJobSchedule handles GET /api/jobs/schedule
+// Lists the scheduled tasks (see -jobs) with their cron expression and
+// command, whether each is running, when it next runs, and how its last run
+// went: {"tasks": [...], "count": n}
+func (h *Handlers) GetJobSchedule(w http.ResponseWriter, r *http.Request) {
+	if h.jobs == nil {
+		http.Error(w, "no scheduled jobs configured", http.StatusServiceUnavailable)
+		return
+	}
+	tasks := h.jobs.Status()
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"tasks": tasks,
+		"count": len(tasks),
+	})
+}
+
+// maxAuditEntries caps how many entries GetAudit returns
+const maxAuditEntries = 1000
+
+// GetAudit handles GET /api/audit
+// Lists changes made through the API, newest first.
+// Optional params: entity (e.g. "property", "saved_search"), entity_id,
+// user, since (RFC 3339 or YYYY-MM-DD in Sydney time), limit (default 100,
+// max 1000)
+func (h *Handlers) GetAudit(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	f := db.AuditFilter{
+		Entity:   q.Get("entity"),
+		EntityID: q.Get("entity_id"),
+		User:     q.Get("user"),
+		Limit:    100,
+	}
+	if v, err := strconv.Atoi(q.Get("limit")); err == nil && v > 0 {
+		f.Limit = min(v, maxAuditEntries)
+	}
+	if v := q.Get("since"); v != "" {
+		since, err := parseSince(v)
+		if err != nil {
+			http.Error(w, "since must be an RFC 3339 time or YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+		f.Since = since
+	}
+
+	entries, err := h.db.ListAudit(f)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"entries": entries,
+		"count":   len(entries),
+	})
+}
+
+// GetRoute handles GET /api/route
+// Returns a driving route from a property to a destination as GeoJSON LineString
+// Supports two modes:
+// 1. By town name: from_lat, from_lng, town
+// 2. By coordinates: from_lat, from_lng, to_lat, to_lng, name (optional)
+func (h *Handlers) GetRoute(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	// Parse from coordinates (required)
+	fromLat, err1 := strconv.ParseFloat(q.Get("from_lat"), 64)
+	fromLng, err2 := strconv.ParseFloat(q.Get("from_lng"), 64)
+
+	if err1 != nil || err2 != nil {
+		http.Error(w, "from_lat and from_lng required", http.StatusBadRequest)
+		return
+	}
+
+	var toLat, toLng float64
+	var destName string
+
+	// Check if routing by coordinates or by town name
+	if q.Get("to_lat") != "" && q.Get("to_lng") != "" {
+		// Mode 2: Route by coordinates
+		var err3, err4 error
+		toLat, err3 = strconv.ParseFloat(q.Get("to_lat"), 64)
+		toLng, err4 = strconv.ParseFloat(q.Get("to_lng"), 64)
+		if err3 != nil || err4 != nil {
+			http.Error(w, "invalid to_lat or to_lng", http.StatusBadRequest)
+			return
+		}
+		destName = q.Get("name") // Optional name for the destination
+	} else if q.Get("town") != "" {
+		// Mode 1: Route by town name (legacy support)
+		townName := q.Get("town")
+		found := false
+		for _, town := range geo.NSWTowns {
+			if strings.EqualFold(town.Name, townName) {
+				toLat = town.Latitude
+				toLng = town.Longitude
+				destName = townName
+				found = true
+				break
+			}
+		}
+		if !found {
+			http.Error(w, "town not found", http.StatusNotFound)
+			return
+		}
+	} else {
+		http.Error(w, "either 'town' or 'to_lat'+'to_lng' parameters required", http.StatusBadRequest)
+		return
+	}
+
+	// Get route from Valhalla
+	router := geo.NewRouter(valhallaURL)
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+	defer cancel()
+
+	route, err := router.GetRouteWithShape(ctx, fromLat, fromLng, toLat, toLng)
+	if err != nil {
+		http.Error(w, "failed to get route: "+err.Error(), routingErrorStatus(err))
+		return
+	}
+
+	// Build GeoJSON response
+	geojson := map[string]interface{}{
+		"type": "Feature",
+		"geometry": map[string]interface{}{
+			"type":        "LineString",
+			"coordinates": route.Coordinates,
+		},
+		"properties": map[string]interface{}{
+			"duration_mins": route.DurationMins,
+			"distance_km":   route.DistanceKm,
+			"name":          destName,
+		},
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(geojson)
+}
+
+// maxMatrixProperties limits how many properties one matrix request can include
+const maxMatrixProperties = 25
+
+// routeOrigin is a configured starting point for inspection day routes
+type routeOrigin struct {
+	Name string
+	Lat  float64
+	Lng  float64
+}
+
+// valhallaURL is read from VALHALLA_URL (e.g. "http://localhost:8002"),
+// defaulting to the public OSM server
+var valhallaURL = os.Getenv("VALHALLA_URL")
+
+// what3wordsAPIKey is read from WHAT3WORDS_API_KEY; without one, property
+// details have no what3words addresses
+var what3wordsAPIKey = os.Getenv("WHAT3WORDS_API_KEY")
+
+// regionDBs is read from REGION_DBS, comma-separated paths of region
+// databases (e.g. "data/vic.db,data/qld.db") whose properties are listed
+// and looked up alongside the main database's
+var regionDBs = os.Getenv("REGION_DBS")
+
+// openRegions opens the region databases in a REGION_DBS list, each named
+// after its file (e.g. "vic"), as replicas if readOnly
+func openRegions(paths string, readOnly bool) ([]service.Region, error) {
+	var regions []service.Region
+	for _, path := range strings.Split(paths, ",") {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			continue
+		}
+		// db.New would create a missing file
+		_, err := os.Stat(path)
+		var database *db.DB
+		if err == nil && readOnly {
+			database, err = db.NewReadOnly(path)
+		} else if err == nil {
+			database, err = db.New(path)
+		}
+		if err != nil {
+			for _, r := range regions {
+				r.DB.Close()
+			}
+			return nil, fmt.Errorf("region database %s: %w", path, err)
+		}
+		name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		regions = append(regions, service.Region{Name: name, DB: database})
+	}
+	return regions, nil
+}
+
+// anchor is read from ANCHOR ("Newcastle:-32.9267,151.7789"), defaulting to
+// Sutherland
+var anchor = loadAnchor()
+
+// loadAnchor returns the configured anchor, falling back to Sutherland (with
+// a warning) if ANCHOR can't be parsed
+func loadAnchor() geo.Anchor {
+	a, err := geo.AnchorFromEnv()
+	if err != nil {
+		log.Printf("Warning: %v; using %s", err, geo.Sutherland.Name)
+		return geo.Sutherland
+	}
+	return a
+}
+
+// routeOrigins are read from ROUTE_ORIGINS ("Home:-34.03,151.06;Work:-33.87,151.21"),
+// defaulting to the anchor
+var routeOrigins = parseRouteOrigins(os.Getenv("ROUTE_ORIGINS"))
+
+// parseRouteOrigins parses "Name:lat,lng" entries separated by semicolons
+func parseRouteOrigins(s string) []routeOrigin {
+	var origins []routeOrigin
+	for _, entry := range strings.Split(s, ";") {
+		name, coords, ok := strings.Cut(strings.TrimSpace(entry), ":")
+		if !ok {
+			continue
+		}
+		parts := strings.Split(coords, ",")
+		if len(parts) != 2 {
+			continue
+		}
+		lat, err1 := strconv.ParseFloat(strings.TrimSpace(parts[0]), 64)
+		lng, err2 := strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
+		if err1 != nil || err2 != nil {
+			continue
+		}
+		origins = append(origins, routeOrigin{Name: strings.TrimSpace(name), Lat: lat, Lng: lng})
+	}
+	if len(origins) == 0 {
+		origins = []routeOrigin{{Name: anchor.Name, Lat: anchor.Lat, Lng: anchor.Lng}}
+	}
+	return origins
+}
+
+// parsePropertyIDs parses a comma-separated list of up to limit property IDs,
+// writing an error response and returning nil if they're invalid
+func parsePropertyIDs(w http.ResponseWriter, s string, limit int) []int64 {
+	var ids []int64
+	for _, part := range strings.Split(s, ",") {
+		if id, err := strconv.ParseInt(strings.TrimSpace(part), 10, 64); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		http.Error(w, "ids required", http.StatusBadRequest)
+		return nil
+	}
+	if len(ids) > limit {
+		http.Error(w, fmt.Sprintf("at most %d properties allowed", limit), http.StatusBadRequest)
+		return nil
+	}
+	return ids
+}
+
+// loadMatrixProperties loads properties for a matrix or plan request, writing
+// an error response and returning nil if any is missing
+func (h *Handlers) loadMatrixProperties(w http.ResponseWriter, ids []int64) []*models.PropertyDetail {
+	properties := make([]*models.PropertyDetail, 0, len(ids))
+	for _, id := range ids {
+		property, err := h.properties.Get(id)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("property %d not found", id), http.StatusNotFound)
+			return nil
+		}
+		properties = append(properties, property)
+	}
+	return properties
+}
+
+// matrixPoints lists the configured origins followed by the properties, the
+// row order of a drive time matrix
+func matrixPoints(properties []*models.PropertyDetail) []geo.MatrixPoint {
+	points := make([]geo.MatrixPoint, 0, len(routeOrigins)+len(properties))
+	for _, o := range routeOrigins {
+		points = append(points, geo.MatrixPoint{Lat: o.Lat, Lng: o.Lng})
+	}
+	for _, p := range properties {
+		points = append(points, geo.MatrixPoint{Lat: p.Latitude, Lng: p.Longitude})
+	}
+	return points
+}
+
+// routeOriginIndex returns the index of the origin with the given name, or 0
+func routeOriginIndex(name string) int {
+	for i, o := range routeOrigins {
+		if strings.EqualFold(o.Name, name) {
+			return i
+		}
+	}
+	return 0
+}
+
+// propertyDisplayName is a property's address and suburb
+func propertyDisplayName(p *models.PropertyDetail) string {
+	if p.Suburb == "" {
+		return p.Address
+	}
+	return strings.TrimPrefix(p.Address+", "+p.Suburb, ", ")
+}
+
+// GetIsochrone handles GET /api/isochrone
+// Returns a GeoJSON FeatureCollection of the area within the given drive time
+// of a point, generated through Valhalla and cached.
+// Required params: lat, lng, minutes (1-180)
+func (h *Handlers) GetIsochrone(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	lat, latErr := strconv.ParseFloat(q.Get("lat"), 64)
+	lng, lngErr := strconv.ParseFloat(q.Get("lng"), 64)
+	if latErr != nil || lngErr != nil || lat < -90 || lat > 90 || lng < -180 || lng > 180 {
+		http.Error(w, "valid lat and lng required", http.StatusBadRequest)
+		return
+	}
+	minutes, err := strconv.Atoi(q.Get("minutes"))
+	if err != nil || minutes < 1 || minutes > service.MaxIsochroneMinutes {
+		http.Error(w, fmt.Sprintf("minutes must be 1-%d", service.MaxIsochroneMinutes), http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 60*time.Second)
+	defer cancel()
+
+	iso, cached, err := h.isochrones.Get(ctx, lat, lng, minutes)
+	if err != nil {
+		http.Error(w, "failed to get isochrone: "+err.Error(), routingErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age=86400")
+	if cached {
+		w.Header().Set("X-Cache", "HIT")
+	} else {
+		w.Header().Set("X-Cache", "MISS")
+	}
+	json.NewEncoder(w).Encode(iso)
+}
+
+// GetDriveTimeGrid handles GET /api/drive-time-grid
+// Returns the drive time grid (from `tools drivetimegrid`) as a GeoJSON
+// FeatureCollection of square cells, each with its drive time to the anchor,
+// for a drive time surface layer.
+// Optional params: bounds (sw_lat,sw_lng,ne_lat,ne_lng, default NSW),
+// max_minutes
+func (h *Handlers) GetDriveTimeGrid(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	b := geo.NSWBounds
+	if v := q.Get("bounds"); v != "" {
+		var coords [4]float64
+		parts := strings.Split(v, ",")
+		if len(parts) != 4 {
+			http.Error(w, "bounds must be sw_lat,sw_lng,ne_lat,ne_lng", http.StatusBadRequest)
+			return
+		}
+		for i, part := range parts {
+			f, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+			if err != nil {
+				http.Error(w, "bounds must be sw_lat,sw_lng,ne_lat,ne_lng", http.StatusBadRequest)
+				return
+			}
+			coords[i] = f
+		}
+		b.SWLat, b.SWLng, b.NELat, b.NELng = coords[0], coords[1], coords[2], coords[3]
+	}
+	maxMinutes := 0
+	if v := q.Get("max_minutes"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			http.Error(w, "max_minutes must be a positive number", http.StatusBadRequest)
+			return
+		}
+		maxMinutes = n
+	}
+
+	cells, err := h.db.GetDriveTimeGrid(b.SWLat, b.SWLng, b.NELat, b.NELng, maxMinutes)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	features := make([]map[string]interface{}, 0, len(cells))
+	for _, c := range cells {
+		dLat, dLng := c.LatStep/2, c.LngStep/2
+		features = append(features, map[string]interface{}{
+			"type": "Feature",
+			"geometry": map[string]interface{}{
+				"type": "Polygon",
+				"coordinates": [][][2]float64{{
+					{c.Lng - dLng, c.Lat - dLat}, {c.Lng + dLng, c.Lat - dLat},
+					{c.Lng + dLng, c.Lat + dLat}, {c.Lng - dLng, c.Lat + dLat},
+					{c.Lng - dLng, c.Lat - dLat},
+				}},
+			},
+			"properties": map[string]interface{}{
+				"drive_time_mins": c.DriveTimeMins,
+				"distance_km":     c.DistanceKm,
+			},
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age=3600")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"type":     "FeatureCollection",
+		"features": features,
+	})
+}
+
+// GetRouteMatrix handles GET /api/route/matrix
+// Returns a drive time matrix between the configured origins and the given
+// properties (e.g. favorites), for planning an inspection day.
+// Params: ids (comma-separated property IDs, required), order=true to also
+// return a round trip visiting every property, start (origin name the trip
+// starts and ends at, default the first origin)
+func (h *Handlers) GetRouteMatrix(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	ids := parsePropertyIDs(w, q.Get("ids"), maxMatrixProperties)
+	if ids == nil {
+		return
+	}
+	properties := h.loadMatrixProperties(w, ids)
+	if properties == nil {
+		return
+	}
+
+	// Origins come first, then properties
+	locations := make([]map[string]interface{}, 0, len(routeOrigins)+len(properties))
+	for _, o := range routeOrigins {
+		locations = append(locations, map[string]interface{}{
+			"name":   o.Name,
+			"lat":    o.Lat,
+			"lng":    o.Lng,
+			"origin": true,
+		})
+	}
+	var stops []int
+	for _, p := range properties {
+		stops = append(stops, len(locations))
+		locations = append(locations, map[string]interface{}{
+			"property_id": p.ID,
+			"name":        propertyDisplayName(p),
+			"lat":         p.Latitude,
+			"lng":         p.Longitude,
+		})
+	}
+	points := matrixPoints(properties)
+
+	router := geo.NewRouter(valhallaURL)
+	ctx, cancel := context.WithTimeout(r.Context(), 60*time.Second)
+	defer cancel()
+
+	matrix, err := router.GetMatrix(ctx, points)
+	if err != nil {
+		http.Error(w, "failed to get drive time matrix: "+err.Error(), routingErrorStatus(err))
+		return
+	}
+
+	// Unreachable pairs are null
+	durations := make([][]*float64, len(matrix))
+	distances := make([][]*float64, len(matrix))
+	for i, row := range matrix {
+		durations[i] = make([]*float64, len(row))
+		distances[i] = make([]*float64, len(row))
+		for j, cell := range row {
+			if cell == nil {
+				continue
+			}
+			mins := math.Round(cell.DurationMins*10) / 10
+			km := math.Round(cell.DistanceKm*10) / 10
+			durations[i][j] = &mins
+			distances[i][j] = &km
+		}
+	}
+
+	response := map[string]interface{}{
+		"locations":      locations,
+		"durations_mins": durations,
+		"distances_km":   distances,
+	}
+
+	if q.Get("order") == "true" {
+		order, totalMins := geo.VisitOrder(matrix, routeOriginIndex(q.Get("start")), stops)
+		response["route"] = map[string]interface{}{
+			"order":      order,
+			"total_mins": math.Round(totalMins*10) / 10,
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// GetInspectionPlan handles GET /api/plan
+// Plans an inspection day around the given properties' open-for-inspection
+// times: the visiting order, arrival and departure times, and drive legs.
+// Params: ids (comma-separated property IDs, required), date (YYYY-MM-DD,
+// default the next Saturday), start (origin name, default the first origin),
+// depart (HH:MM, default 08:00), visit_mins (default 20), format (json, ics or gpx)
+func (h *Handlers) GetInspectionPlan(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	ids := parsePropertyIDs(w, q.Get("ids"), maxMatrixProperties)
+	if ids == nil {
+		return
+	}
+
+	now := time.Now().In(geo.SydneyTime)
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, geo.SydneyTime)
+	day = day.AddDate(0, 0, (int(time.Saturday)-int(day.Weekday())+7)%7)
+	if v := q.Get("date"); v != "" {
+		t, err := time.ParseInLocation("2006-01-02", v, geo.SydneyTime)
+		if err != nil {
+			http.Error(w, "invalid date (expected YYYY-MM-DD)", http.StatusBadRequest)
+			return
+		}
+		day = t
+	}
+
+	departHour, departMin := 8, 0
+	if v := q.Get("depart"); v != "" {
+		t, err := time.Parse("15:04", v)
+		if err != nil {
+			http.Error(w, "invalid depart (expected HH:MM)", http.StatusBadRequest)
+			return
+		}
+		departHour, departMin = t.Hour(), t.Minute()
+	}
+	depart := time.Date(day.Year(), day.Month(), day.Day(), departHour, departMin, 0, 0, geo.SydneyTime)
+
+	visitLength := 20 * time.Minute
+	if v, err := strconv.Atoi(q.Get("visit_mins")); err == nil && v > 0 {
+		visitLength = time.Duration(v) * time.Minute
+	}
+
+	properties := h.loadMatrixProperties(w, ids)
+	if properties == nil {
+		return
+	}
+
+	propertyIDs := make([]int64, len(properties))
+	for i, p := range properties {
+		propertyIDs[i] = p.ID
+	}
+	events, err := h.properties.Events(propertyIDs, day, day.AddDate(0, 0, 1))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	windows := make(map[int64][]planner.Window)
+	for _, e := range events {
+		if e.Kind != "inspection" {
+			continue
+		}
+		start := e.StartsAt.In(geo.SydneyTime)
+		end := start.Add(30 * time.Minute) // Typical open home if no closing time
+		if e.EndsAt.Valid {
+			end = e.EndsAt.Time.In(geo.SydneyTime)
+		}
+		windows[e.PropertyID] = append(windows[e.PropertyID], planner.Window{Start: start, End: end})
+	}
+
+	originIndex := routeOriginIndex(q.Get("start"))
+	o := routeOrigins[originIndex]
+	origin := planner.Origin{Index: originIndex, Name: o.Name, Lat: o.Lat, Lng: o.Lng}
+
+	stops := make([]planner.Stop, 0, len(properties))
+	for i, p := range properties {
+		stops = append(stops, planner.Stop{
+			Index:      len(routeOrigins) + i,
+			PropertyID: p.ID,
+			Name:       propertyDisplayName(p),
+			URL:        p.URL,
+			Lat:        p.Latitude,
+			Lng:        p.Longitude,
+			Windows:    windows[p.ID],
+		})
+	}
+
+	router := geo.NewRouter(valhallaURL)
+	ctx, cancel := context.WithTimeout(r.Context(), 60*time.Second)
+	defer cancel()
+
+	matrix, err := router.GetMatrix(ctx, matrixPoints(properties))
+	if err != nil {
+		http.Error(w, "failed to get drive time matrix: "+err.Error(), routingErrorStatus(err))
+		return
+	}
+
+	plan := planner.Schedule(matrix, origin, stops, day.Format("2006-01-02"), depart, visitLength)
+
+	switch q.Get("format") {
+	case "ics":
+		w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="inspections-%s.ics"`, plan.Date))
+		planner.WriteICS(w, "Inspections "+plan.Date, planner.PlanEvents(plan))
+	case "gpx":
+		w.Header().Set("Content-Type", "application/gpx+xml")
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="inspections-%s.gpx"`, plan.Date))
+		planner.WriteGPX(w, plan)
+	default:
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(plan)
+	}
+}
+
+// maxCalendarProperties limits how many properties a calendar feed can include
+const maxCalendarProperties = 200
+
+// GetCalendar handles GET /api/calendar.ics
+// Serves an iCalendar feed of upcoming inspections and auctions for the given
+// properties (e.g. favorites), for subscribing to from Google Calendar.
+// Params: ids (comma-separated property IDs, required), days (how far ahead, default 60)
+func (h *Handlers) GetCalendar(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	ids := parsePropertyIDs(w, q.Get("ids"), maxCalendarProperties)
+	if ids == nil {
+		return
+	}
+
+	days := 60
+	if v, err := strconv.Atoi(q.Get("days")); err == nil && v > 0 {
+		days = v
+	}
+
+	// Properties that no longer exist are skipped rather than failing the feed
+	properties := h.properties.GetMany(ids)
+	propertyIDs := make([]int64, 0, len(properties))
+	for id := range properties {
+		propertyIDs = append(propertyIDs, id)
+	}
+
+	// Include today's earlier events so they don't vanish from calendars mid-day
+	from := time.Now().Add(-24 * time.Hour)
+	events, err := h.properties.Events(propertyIDs, from, time.Now().AddDate(0, 0, days))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	var calEvents []planner.CalendarEvent
+	for _, e := range events {
+		p, ok := properties[e.PropertyID]
+		if !ok {
+			continue
+		}
+		name := propertyDisplayName(p)
+
+		// Events are replaced on each scrape, so the UID comes from what the
+		// event is rather than its row ID
+		ce := planner.CalendarEvent{
+			UID:      fmt.Sprintf("%s-%d-%s@farm-search", e.Kind, e.PropertyID, e.StartsAt.UTC().Format("20060102T1504")),
+			Location: name,
+			URL:      p.URL,
+			Start:    e.StartsAt,
+			Lat:      p.Latitude,
+			Lng:      p.Longitude,
+		}
+		switch e.Kind {
+		case "auction":
+			ce.Summary = "Auction: " + name
+			ce.End = e.StartsAt.Add(time.Hour)
+			if e.Location.Valid {
+				ce.Location = e.Location.String
+				ce.Lat, ce.Lng = 0, 0 // Venue, not the property
+			}
+		default:
+			ce.Summary = "Inspection: " + name
+			ce.End = e.StartsAt.Add(30 * time.Minute)
+		}
+		if e.EndsAt.Valid {
+			ce.End = e.EndsAt.Time
+		}
+
+		var details []string
+		if p.PriceText != "" {
+			details = append(details, p.PriceText)
+		}
+		if p.LandSizeSqm != nil {
+			details = append(details, fmt.Sprintf("%.1f ha", *p.LandSizeSqm/10000))
+		}
+		details = append(details, p.URL)
+		ce.Description = strings.Join(details, "\n")
+
+		calEvents = append(calEvents, ce)
+	}
+
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	planner.WriteICS(w, "Farm Search inspections & auctions", calEvents)
+}
+
+// CreateSavedSearch handles POST /api/saved-searches
+// Body: {"name": "...", "query": "price_max=1500000&land_size_min=40"}, where
+// query uses the same filter params as /api/properties
+func (h *Handlers) CreateSavedSearch(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Name  string `json:"name"`
+		Query string `json:"query"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid JSON body", http.StatusBadRequest)
+		return
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		http.Error(w, "name required", http.StatusBadRequest)
+		return
+	}
+	values, err := url.ParseQuery(strings.TrimPrefix(req.Query, "?"))
+	if err != nil {
+		http.Error(w, "invalid query", http.StatusBadRequest)
+		return
+	}
+
+	search := &models.SavedSearch{Name: req.Name, Query: values.Encode()}
+	if err := h.searches.Create(r.Context(), search); err != nil {
+		http.Error(w, err.Error(), routingErrorStatus(err))
+		return
+	}
+	h.audit(r, "saved_search.create", "saved_search", search.ID, nil, search)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"saved_search": search,
+		"feed_url":     fmt.Sprintf("/api/feeds/%d.rss", search.ID),
+	})
+}
+
+// ListSavedSearches handles GET /api/saved-searches
+func (h *Handlers) ListSavedSearches(w http.ResponseWriter, r *http.Request) {
+	searches, err := h.db.ListSavedSearches()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"saved_searches": searches,
+		"count":          len(searches),
+	})
+}
+
+// DeleteSavedSearch handles DELETE /api/saved-searches/{id}
+func (h *Handlers) DeleteSavedSearch(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "invalid saved search ID", http.StatusBadRequest)
+		return
+	}
+
+	before, _ := h.db.GetSavedSearch(id)
+	found, err := h.db.DeleteSavedSearch(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if !found {
+		http.Error(w, "saved search not found", http.StatusNotFound)
+		return
+	}
+	h.audit(r, "saved_search.delete", "saved_search", id, before, nil)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// maxWatchlistReports caps how many reports ListWatchlistReports returns
+const maxWatchlistReports = 52
+
+// CreateWatchlist handles POST /api/watchlists
+// Body: {"kind": "suburb" or "lga", "region": "Mudgee"}, where region is the
+// suburb's SAL code or the LGA's code, or its name. Returns 201 with the new
+// watchlist, or 200 with the existing one if the region is already watched.
+func (h *Handlers) CreateWatchlist(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Kind   string `json:"kind"`
+		Region string `json:"region"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid JSON body", http.StatusBadRequest)
+		return
+	}
+	if !slices.Contains(service.WatchlistKinds, req.Kind) {
+		http.Error(w, "kind must be one of "+strings.Join(service.WatchlistKinds, ", "), http.StatusBadRequest)
+		return
+	}
+	req.Region = strings.TrimSpace(req.Region)
+	if req.Region == "" {
+		http.Error(w, "region required", http.StatusBadRequest)
+		return
+	}
+
+	watchlist, created, err := h.watchlists.Watch(req.Kind, req.Region)
+	if errors.Is(err, service.ErrUnknownRegion) {
+		http.Error(w, fmt.Sprintf("no %s boundary %q", req.Kind, req.Region), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if created {
+		h.audit(r, "watchlist.create", "watchlist", watchlist.ID, nil, watchlist)
+		w.WriteHeader(http.StatusCreated)
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"watchlist": watchlist,
+	})
+}
+
+// ListWatchlists handles GET /api/watchlists
+func (h *Handlers) ListWatchlists(w http.ResponseWriter, r *http.Request) {
+	watchlists, err := h.db.ListWatchlists()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"watchlists": watchlists,
+		"count":      len(watchlists),
+	})
+}
+
+// DeleteWatchlist handles DELETE /api/watchlists/{id}
+// Its archived reports are deleted with it.
+func (h *Handlers) DeleteWatchlist(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "invalid watchlist ID", http.StatusBadRequest)
+		return
+	}
+
+	before, _ := h.db.GetWatchlist(id)
+	found, err := h.db.DeleteWatchlist(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if !found {
+		http.Error(w, "watchlist not found", http.StatusNotFound)
+		return
+	}
+	h.audit(r, "watchlist.delete", "watchlist", id, before, nil)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListWatchlistReports handles GET /api/watchlists/{id}/reports
+// Returns the watchlist's archived weekly summaries, newest first.
+// Optional param: limit (default and max 52)
+func (h *Handlers) ListWatchlistReports(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "invalid watchlist ID", http.StatusBadRequest)
+		return
+	}
+	limit := maxWatchlistReports
+	if v := r.URL.Query().Get("limit"); v != "" {
+		if limit, err = strconv.Atoi(v); err != nil || limit < 1 {
+			http.Error(w, "limit must be a positive integer", http.StatusBadRequest)
+			return
+		}
+		limit = min(limit, maxWatchlistReports)
+	}
+
+	watchlist, err := h.db.GetWatchlist(id)
+	if err != nil {
+		http.Error(w, "watchlist not found", http.StatusNotFound)
+		return
+	}
+	reports, err := h.db.ListWatchlistReports(id, limit)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"watchlist": watchlist,
+		"reports":   reports,
+		"count":     len(reports),
+	})
+}
+
+// GetWatchlistReport handles GET /api/watchlists/{id}/reports/{reportID}
+func (h *Handlers) GetWatchlistReport(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "invalid watchlist ID", http.StatusBadRequest)
+		return
+	}
+	reportID, err := strconv.ParseInt(chi.URLParam(r, "reportID"), 10, 64)
+	if err != nil {
+		http.Error(w, "invalid report ID", http.StatusBadRequest)
+		return
+	}
+
+	report, err := h.db.GetWatchlistReport(id, reportID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if report == nil {
+		http.Error(w, "report not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// maxShareBytes caps the filter state a share link can hold
+const maxShareBytes = 64 << 10
+
+// CreateShareLink handles POST /api/share
+// Body: the filter state to share, as a JSON object. Saves it behind a new
+// random token and returns the token and the /s/{token} link that opens it.
+func (h *Handlers) CreateShareLink(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxShareBytes))
+	if err != nil {
+		http.Error(w, "filter state too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+	var filters bytes.Buffer
+	if err := json.Compact(&filters, body); err != nil || !bytes.HasPrefix(filters.Bytes(), []byte("{")) {
+		http.Error(w, "body must be a JSON object", http.StatusBadRequest)
+		return
+	}
+
+	// 6 random bytes make an 8 character token
+	token := make([]byte, 6)
+	if _, err := rand.Read(token); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	link := &models.ShareLink{Token: base64.RawURLEncoding.EncodeToString(token), Filters: filters.Bytes()}
+	if err := h.db.CreateShareLink(link); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	h.audit(r, "share_link.create", "share_link", link.Token, nil, link)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"token": link.Token,
+		"url":   "/s/" + link.Token,
+	})
+}
+
+// GetShareLink handles GET /api/share/{token}
+// Returns the filter state saved behind a share link.
+func (h *Handlers) GetShareLink(w http.ResponseWriter, r *http.Request) {
+	link, err := h.db.GetShareLink(chi.URLParam(r, "token"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if link == nil {
+		http.Error(w, "share link not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(link)
+}
+
+// OpenShareLink handles GET /s/{token}
+// Redirects to the map with ?share={token}, which loads the shared filters.
+func (h *Handlers) OpenShareLink(w http.ResponseWriter, r *http.Request) {
+	token := chi.URLParam(r, "token")
+	link, err := h.db.GetShareLink(token)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if link == nil {
+		http.Error(w, "share link not found", http.StatusNotFound)
+		return
+	}
+	http.Redirect(w, r, "/?share="+url.QueryEscape(link.Token), http.StatusFound)
+}
+
+// ExportUserData handles GET /api/user-data/export
+// Downloads the tags, saved searches, score profiles, share links, corrected
+// coordinates and duplicate decisions as JSON, for POST /api/user-data/import.
+func (h *Handlers) ExportUserData(w http.ResponseWriter, r *http.Request) {
+	data, err := h.userData.Export()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition",
+		fmt.Sprintf(`attachment; filename="farm-search-user-data-%s.json"`, data.ExportedAt.Format("20060102")))
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(data)
+}
+
+// maxUserDataBytes caps the size of a user data import
+const maxUserDataBytes = 32 << 20
+
+// ImportUserData handles POST /api/user-data/import
+// Body: a GET /api/user-data/export download. Adds what isn't already in the
+// database, returning counts of what was imported and skipped per section.
+func (h *Handlers) ImportUserData(w http.ResponseWriter, r *http.Request) {
+	var data models.UserData
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxUserDataBytes)).Decode(&data); err != nil {
+		http.Error(w, "invalid JSON body", http.StatusBadRequest)
+		return
+	}
+
+	result, err := h.userData.Import(r.Context(), &data)
+	switch {
+	case errors.Is(err, service.ErrInvalidUserData):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	case err != nil:
+		http.Error(w, err.Error(), routingErrorStatus(err))
+		return
+	}
+	h.audit(r, "user_data.import", "user_data", nil, nil, result)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// ListScoreProfiles handles GET /api/score-profiles
+// Returns the score profiles and the criteria they can weight.
+func (h *Handlers) ListScoreProfiles(w http.ResponseWriter, r *http.Request) {
+	profiles, err := h.db.ListScoreProfiles()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"profiles": profiles,
+		"criteria": service.ScoreCriteria,
+		"count":    len(profiles),
+	})
+}
+
+// decodeScoreProfile reads a score profile's name and weights from a request
+// body, writing a 400 and returning nil if they're missing or invalid
+func decodeScoreProfile(w http.ResponseWriter, r *http.Request) *models.ScoreProfile {
+	var req struct {
+		Name    string             `json:"name"`
+		Weights map[string]float64 `json:"weights"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid JSON body", http.StatusBadRequest)
+		return nil
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		http.Error(w, "name required", http.StatusBadRequest)
+		return nil
+	}
+	if err := service.ValidateWeights(req.Weights); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return nil
+	}
+	return &models.ScoreProfile{Name: req.Name, Weights: req.Weights}
+}
+
+// CreateScoreProfile handles POST /api/score-profiles
+// Body: {"name": "...", "weights": {"drive_time_primary": 3, "price_per_ha": 2}}
+// Scores every property with the new profile before returning it.
+func (h *Handlers) CreateScoreProfile(w http.ResponseWriter, r *http.Request) {
+	profile := decodeScoreProfile(w, r)
+	if profile == nil {
+		return
+	}
+
+	if err := h.scoring.Create(profile); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	h.audit(r, "score_profile.create", "score_profile", profile.ID, nil, profile)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(profile)
+}
+
+// UpdateScoreProfile handles PUT /api/score-profiles/{id}
+// Replaces the profile's name and weights and rescores every property.
+func (h *Handlers) UpdateScoreProfile(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "invalid score profile ID", http.StatusBadRequest)
+		return
+	}
+	profile := decodeScoreProfile(w, r)
+	if profile == nil {
+		return
+	}
+	profile.ID = id
+
+	before, _ := h.db.GetScoreProfile(id)
+	found, err := h.scoring.Update(profile)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if !found {
+		http.Error(w, "score profile not found", http.StatusNotFound)
+		return
+	}
+
+	// Reload for created_at
+	profile, err = h.db.GetScoreProfile(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	h.audit(r, "score_profile.update", "score_profile", id, before, profile)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(profile)
+}
+
+// DeleteScoreProfile handles DELETE /api/score-profiles/{id}
+func (h *Handlers) DeleteScoreProfile(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "invalid score profile ID", http.StatusBadRequest)
+		return
+	}
+
+	before, _ := h.db.GetScoreProfile(id)
+	found, err := h.db.DeleteScoreProfile(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if !found {
+		http.Error(w, "score profile not found", http.StatusNotFound)
+		return
+	}
+	h.audit(r, "score_profile.delete", "score_profile", id, before, nil)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// decodeUserPOI reads a user POI's name and coordinates from a request body,
+// writing a 400 and returning nil if they're missing or invalid
+func decodeUserPOI(w http.ResponseWriter, r *http.Request) *models.UserPOI {
+	var req struct {
+		Name string   `json:"name"`
+		Lat  *float64 `json:"lat"`
+		Lng  *float64 `json:"lng"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid JSON body", http.StatusBadRequest)
+		return nil
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	// near_poi separates POIs with commas and names from minutes with colons
+	if req.Name == "" || strings.ContainsAny(req.Name, ",:") {
+		http.Error(w, "name required, without commas or colons", http.StatusBadRequest)
+		return nil
+	}
+	// Roughly Australia, to catch swapped or sign-flipped coordinates
+	if req.Lat == nil || req.Lng == nil || *req.Lat < -44 || *req.Lat > -9 || *req.Lng < 112 || *req.Lng > 154 {
+		http.Error(w, "lat and lng required, within Australia", http.StatusBadRequest)
+		return nil
+	}
+	return &models.UserPOI{Name: req.Name, Latitude: *req.Lat, Longitude: *req.Lng}
+}
+
+// CreateUserPOI handles POST /api/pois
+// Body: {"name": "Mum", "lat": -33.71, "lng": 150.31}. Properties' distances
+// and drive times to it are worked out by the poi_drive_times enrichment
+// step (tools enrich or tools poidrivetimes).
+func (h *Handlers) CreateUserPOI(w http.ResponseWriter, r *http.Request) {
+	poi := decodeUserPOI(w, r)
+	if poi == nil {
+		return
+	}
+
+	err := h.db.CreateUserPOI(poi)
+	if errors.Is(err, db.ErrPOINameTaken) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	h.audit(r, "poi.create", "poi", poi.ID, nil, poi)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(poi)
+}
+
+// ListUserPOIs handles GET /api/pois
+func (h *Handlers) ListUserPOIs(w http.ResponseWriter, r *http.Request) {
+	pois, err := h.db.ListUserPOIs()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"pois":  pois,
+		"count": len(pois),
+	})
+}
+
+// UpdateUserPOI handles PUT /api/pois/{id}
+// Same body as create. Moving a POI clears properties' times to it until
+// they're worked out again.
+func (h *Handlers) UpdateUserPOI(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "invalid POI ID", http.StatusBadRequest)
+		return
+	}
+	poi := decodeUserPOI(w, r)
+	if poi == nil {
+		return
+	}
+	poi.ID = id
+
+	before, _ := h.db.GetUserPOI(id)
+	found, err := h.db.UpdateUserPOI(poi)
+	if errors.Is(err, db.ErrPOINameTaken) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if !found {
+		http.Error(w, "POI not found", http.StatusNotFound)
+		return
+	}
+
+	// Reload for created_at
+	poi, err = h.db.GetUserPOI(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	h.audit(r, "poi.update", "poi", id, before, poi)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(poi)
+}
+
+// DeleteUserPOI handles DELETE /api/pois/{id}
+func (h *Handlers) DeleteUserPOI(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "invalid POI ID", http.StatusBadRequest)
+		return
+	}
+
+	before, _ := h.db.GetUserPOI(id)
+	found, err := h.db.DeleteUserPOI(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if !found {
+		http.Error(w, "POI not found", http.StatusNotFound)
+		return
+	}
+	h.audit(r, "poi.delete", "poi", id, before, nil)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetSavedSearchDiff handles GET /api/saved-searches/{id}/diff
+// Reports the properties that newly match the search, no longer match, or
+// changed price, land size or type since a snapshot of its matches.
+// Optional param: since (RFC 3339 time or YYYY-MM-DD in Sydney time, default
+// 7 days ago)
+func (h *Handlers) GetSavedSearchDiff(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "invalid saved search ID", http.StatusBadRequest)
+		return
+	}
+
+	since := time.Now().AddDate(0, 0, -7)
+	if v := r.URL.Query().Get("since"); v != "" {
+		if since, err = parseSince(v); err != nil {
+			http.Error(w, "since must be an RFC 3339 time or YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+	}
+
+	search, err := h.db.GetSavedSearch(id)
+	if err != nil {
+		http.Error(w, "saved search not found", http.StatusNotFound)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 60*time.Second)
+	defer cancel()
+
+	diff, err := h.searches.Diff(ctx, search, since)
+	if errors.Is(err, service.ErrNoSnapshot) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), routingErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(diff)
+}
+
+// ListTags handles GET /api/tags
+// Returns every tag in use with how many properties have it.
+func (h *Handlers) ListTags(w http.ResponseWriter, r *http.Request) {
+	tags, err := h.db.ListTags()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"tags":  tags,
+		"count": len(tags),
+	})
+}
+
+// ListExclusionLayers handles GET /api/exclusion-layers
+// Returns the imported exclusion layers exclude_near can name, with how many
+// features each has.
+func (h *Handlers) ListExclusionLayers(w http.ResponseWriter, r *http.Request) {
+	layers, err := h.db.ListExclusionLayers()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"layers": layers,
+		"count":  len(layers),
+	})
+}
+
+// AddTag handles POST /api/tags/{tag}/add
+// Body: {"ids": [1, 2]} or {"query": "price_max=1500000&land_size_min=400000"}
+// Tags the listed properties, or every property matching the filters.
+func (h *Handlers) AddTag(w http.ResponseWriter, r *http.Request) {
+	h.changeTag(w, r, true)
+}
+
+// RemoveTag handles POST /api/tags/{tag}/remove
+// Same body as AddTag; untags the selected properties.
+func (h *Handlers) RemoveTag(w http.ResponseWriter, r *http.Request) {
+	h.changeTag(w, r, false)
+}
+
+// changeTag applies a bulk tag or untag request
+func (h *Handlers) changeTag(w http.ResponseWriter, r *http.Request, add bool) {
+	tag, err := service.NormalizeTag(chi.URLParam(r, "tag"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var sel service.TagSelection
+	if err := json.NewDecoder(r.Body).Decode(&sel); err != nil {
+		http.Error(w, "invalid JSON body", http.StatusBadRequest)
+		return
+	}
+	if (sel.Query == nil) == (len(sel.IDs) == 0) {
+		http.Error(w, "either ids or query required", http.StatusBadRequest)
+		return
+	}
+	if sel.Query != nil {
+		if _, err := url.ParseQuery(strings.TrimPrefix(*sel.Query, "?")); err != nil {
+			http.Error(w, "invalid query", http.StatusBadRequest)
+			return
+		}
+	}
+	if len(sel.IDs) > service.MaxListLimit {
+		http.Error(w, fmt.Sprintf("at most %d ids; use query for larger selections", service.MaxListLimit), http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 60*time.Second)
+	defer cancel()
+
+	var selected int
+	var changed int64
+	action, key := "add", "tagged"
+	if add {
+		selected, changed, err = h.tags.Tag(ctx, tag, sel)
+	} else {
+		selected, changed, err = h.tags.Untag(ctx, tag, sel)
+		action, key = "remove", "untagged"
+	}
+	if err != nil {
+		http.Error(w, err.Error(), routingErrorStatus(err))
+		return
+	}
+	h.audit(r, "tag."+action, "tag", tag, nil, map[string]interface{}{
+		"ids":      sel.IDs,
+		"query":    sel.Query,
+		"selected": selected,
+		key:        changed,
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"tag":      tag,
+		"selected": selected,
+		key:        changed,
+	})
+}
+
+// maxAttachmentBytes caps the size of an uploaded attachment
+const maxAttachmentBytes = 25 << 20
+
+// UploadAttachment handles POST /api/properties/{id}/attachments
+// Multipart form: file (required), kind (contract, section-10-7, soil-test,
+// survey, report or other; default other), note (optional).
+func (h *Handlers) UploadAttachment(w http.ResponseWriter, r *http.Request) {
+	if h.attachments == nil {
+		http.Error(w, "attachment store not configured", http.StatusServiceUnavailable)
+		return
+	}
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "invalid property ID", http.StatusBadRequest)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxAttachmentBytes+1<<20)
+	if err := r.ParseMultipartForm(8 << 20); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			http.Error(w, fmt.Sprintf("file too large (max %d MB)", maxAttachmentBytes>>20), http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, "invalid multipart form", http.StatusBadRequest)
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		http.Error(w, "file required", http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+	if header.Size > maxAttachmentBytes {
+		http.Error(w, fmt.Sprintf("file too large (max %d MB)", maxAttachmentBytes>>20), http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	contentType := header.Header.Get("Content-Type")
+	if contentType == "" || contentType == "application/octet-stream" {
+		sniff := make([]byte, 512)
+		n, _ := io.ReadFull(file, sniff)
+		contentType = http.DetectContentType(sniff[:n])
+		if _, err := file.Seek(0, io.SeekStart); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	a := &models.Attachment{
+		Kind:        r.FormValue("kind"),
+		Filename:    header.Filename,
+		ContentType: contentType,
+	}
+	if note := strings.TrimSpace(r.FormValue("note")); note != "" {
+		a.Note = &note
+	}
+	if err := h.attachments.Add(r.Context(), id, a, file); err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidAttachment):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, service.ErrPropertyNotFound):
+			http.Error(w, "property not found", http.StatusNotFound)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+	h.audit(r, "attachment.upload", "attachment", a.ID, nil, a)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(a)
+}
+
+// ListAttachments handles GET /api/properties/{id}/attachments
+func (h *Handlers) ListAttachments(w http.ResponseWriter, r *http.Request) {
+	if h.attachments == nil {
+		http.Error(w, "attachment store not configured", http.StatusServiceUnavailable)
+		return
+	}
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "invalid property ID", http.StatusBadRequest)
+		return
+	}
+
+	list, err := h.attachments.List(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"attachments": list,
+		"count":       len(list),
+	})
+}
+
+// DownloadAttachment handles GET /api/attachments/{id}
+// Serves the file as a download under its uploaded filename.
+func (h *Handlers) DownloadAttachment(w http.ResponseWriter, r *http.Request) {
+	if h.attachments == nil {
+		http.Error(w, "attachment store not configured", http.StatusServiceUnavailable)
+		return
+	}
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "invalid attachment ID", http.StatusBadRequest)
+		return
+	}
+
+	a, err := h.attachments.Get(id)
+	if err != nil {
+		http.Error(w, "attachment not found", http.StatusNotFound)
+		return
+	}
+	body, err := h.attachments.Open(r.Context(), a)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, attachments.ErrNotFound) {
+			status = http.StatusNotFound
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+	defer body.Close()
+
+	w.Header().Set("Content-Type", a.ContentType)
+	w.Header().Set("Content-Length", strconv.FormatInt(a.SizeBytes, 10))
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", a.Filename))
+	io.Copy(w, body)
+}
+
+// DeleteAttachment handles DELETE /api/attachments/{id}
+func (h *Handlers) DeleteAttachment(w http.ResponseWriter, r *http.Request) {
+	if h.attachments == nil {
+		http.Error(w, "attachment store not configured", http.StatusServiceUnavailable)
+		return
+	}
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "invalid attachment ID", http.StatusBadRequest)
+		return
+	}
+
+	before, _ := h.attachments.Get(id)
+	found, err := h.attachments.Delete(r.Context(), id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if !found {
+		http.Error(w, "attachment not found", http.StatusNotFound)
+		return
+	}
+	h.audit(r, "attachment.delete", "attachment", id, before, nil)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// maxFeedItems limits a saved search feed to its newest matches
+const maxFeedItems = 50
+
+// GetSavedSearchFeed handles GET /api/feeds/{id}.rss
+// Serves an RSS feed of the newest properties matching a saved search, newest
+// first by when they were first scraped, with the first photo as an enclosure.
+func (h *Handlers) GetSavedSearchFeed(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "invalid saved search ID", http.StatusBadRequest)
+		return
+	}
+
+	search, err := h.db.GetSavedSearch(id)
+	if err != nil {
+		http.Error(w, "saved search not found", http.StatusNotFound)
+		return
+	}
+
+	// The query was validated when saved
+	values, _ := url.ParseQuery(search.Query)
+	matches, err := h.properties.Newest(r.Context(), service.ParsePropertyFilter(values), maxFeedItems)
+	if err != nil {
+		http.Error(w, err.Error(), routingErrorStatus(err))
+		return
+	}
+
+	var items []feed.Item
+	for _, m := range matches {
+		p, err := h.properties.Get(m.ID)
+		if err != nil {
+			continue
+		}
+		item := feed.Item{
+			GUID:        fmt.Sprintf("farm-search-property-%d", p.ID),
+			Title:       propertyDisplayName(p),
+			Link:        p.URL,
+			Description: feedDescription(p),
+		}
+		if p.FirstSeenAt != nil {
+			item.Published, _ = time.Parse(time.RFC3339Nano, *p.FirstSeenAt)
+		}
+		if len(p.Images) > 0 {
+			item.ImageURL = p.Images[0]
+		}
+		items = append(items, item)
+	}
+
+	w.Header().Set("Content-Type", "application/rss+xml; charset=utf-8")
+	feed.WriteRSS(w, "Farm Search: "+search.Name, requestBaseURL(r)+"/",
+		"New properties matching "+search.Name, items)
+}
+
+// feedDescription summarises a property's key stats as HTML for a feed item
+func feedDescription(p *models.PropertyDetail) string {
+	var stats []string
+	if p.PriceText != "" {
+		stats = append(stats, p.PriceText)
+	}
+	if p.LandSizeSqm != nil {
+		stats = append(stats, fmt.Sprintf("%.1f ha", *p.LandSizeSqm/10000))
+	}
+	if p.PropertyType != "" {
+		stats = append(stats, p.PropertyType)
+	}
+	if p.Bedrooms != nil {
+		stats = append(stats, fmt.Sprintf("%d bed", *p.Bedrooms))
+	}
+	if p.DriveTimePrimary != nil {
+		stats = append(stats, fmt.Sprintf("%d min to %s", *p.DriveTimePrimary, anchor.Name))
+	}
+	if p.NearestTown1 != nil && p.NearestTown1Km != nil {
+		stats = append(stats, fmt.Sprintf("%.0f km to %s", *p.NearestTown1Km, *p.NearestTown1))
+	}
+	for i, s := range stats {
+		stats[i] = html.EscapeString(s)
+	}
+
+	desc := "<p>" + strings.Join(stats, " &middot; ") + "</p>"
+	if len(p.Images) > 0 {
+		desc = fmt.Sprintf(`<p><img src="%s" alt=""></p>`, html.EscapeString(p.Images[0])) + desc
+	}
+	return desc
+}
+
+// requestBaseURL returns the scheme and host the request was made to, for
+// absolute links in feeds
+func requestBaseURL(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host
+}
+
+// maxPropertyLinks limits how many links or audit entries one request returns
+const maxPropertyLinks = 500
+
+// ListPropertyLinks handles GET /api/property-links
+// Lists duplicate links for review, newest first.
+// Optional params: property_id (links involving that listing), unconfirmed=true
+func (h *Handlers) ListPropertyLinks(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	propertyID, _ := strconv.ParseInt(q.Get("property_id"), 10, 64)
+
+	links, err := h.db.ListPropertyLinks(propertyID, q.Get("unconfirmed") == "true", maxPropertyLinks)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"links": links,
+		"count": len(links),
+	})
+}
+
+// propertyLinkRequest is the body of the property link endpoints; only note
+// applies to confirm and reject
+type propertyLinkRequest struct {
+	CanonicalID int64  `json:"canonical_id"`
+	DuplicateID int64  `json:"duplicate_id"`
+	Note        string `json:"note"`
+}
+
+// decodePropertyLinkRequest reads an optional JSON body, writing an error
+// response and returning false if it's invalid
+func decodePropertyLinkRequest(w http.ResponseWriter, r *http.Request) (propertyLinkRequest, bool) {
+	var req propertyLinkRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		http.Error(w, "invalid JSON body", http.StatusBadRequest)
+		return req, false
+	}
+	req.Note = strings.TrimSpace(req.Note)
+	return req, true
+}
+
+// CreatePropertyLink handles POST /api/property-links
+// Body: {"canonical_id": 12, "duplicate_id": 40, "note": "..."}. Manually
+// marks a listing as a duplicate of another, replacing any automatic link.
+func (h *Handlers) CreatePropertyLink(w http.ResponseWriter, r *http.Request) {
+	req, ok := decodePropertyLinkRequest(w, r)
+	if !ok {
+		return
+	}
+	if req.CanonicalID == 0 || req.DuplicateID == 0 {
+		http.Error(w, "canonical_id and duplicate_id required", http.StatusBadRequest)
+		return
+	}
+
+	before, _ := h.db.GetPropertyLink(req.DuplicateID)
+	err := h.properties.LinkDuplicate(req.CanonicalID, req.DuplicateID, req.Note)
+	switch {
+	case errors.Is(err, service.ErrPropertyNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	case errors.Is(err, service.ErrSelfLink):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	link, err := h.db.GetPropertyLink(req.DuplicateID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	h.audit(r, "property_link.create", "property_link", req.DuplicateID, before, link)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(link)
+}
+
+// ConfirmPropertyLink handles POST /api/property-links/{duplicate_id}/confirm
+// Marks an automatic link as checked. Optional body: {"note": "..."}
+func (h *Handlers) ConfirmPropertyLink(w http.ResponseWriter, r *http.Request) {
+	duplicateID, err := strconv.ParseInt(chi.URLParam(r, "duplicate_id"), 10, 64)
+	if err != nil {
+		http.Error(w, "invalid property ID", http.StatusBadRequest)
+		return
+	}
+	req, ok := decodePropertyLinkRequest(w, r)
+	if !ok {
+		return
+	}
+
+	before, _ := h.db.GetPropertyLink(duplicateID)
+	found, err := h.db.ConfirmPropertyLink(duplicateID, req.Note)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if !found {
+		http.Error(w, "property link not found", http.StatusNotFound)
+		return
+	}
+
+	link, err := h.db.GetPropertyLink(duplicateID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	h.audit(r, "property_link.confirm", "property_link", duplicateID, before, link)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(link)
+}
+
+// RejectPropertyLink handles POST /api/property-links/{duplicate_id}/reject
+// Unlinks a listing wrongly matched as a duplicate (e.g. a neighbouring farm)
+// and stops duplicate detection linking the pair again. Optional body: {"note": "..."}
+func (h *Handlers) RejectPropertyLink(w http.ResponseWriter, r *http.Request) {
+	duplicateID, err := strconv.ParseInt(chi.URLParam(r, "duplicate_id"), 10, 64)
+	if err != nil {
+		http.Error(w, "invalid property ID", http.StatusBadRequest)
+		return
+	}
+	req, ok := decodePropertyLinkRequest(w, r)
+	if !ok {
+		return
+	}
+
+	before, _ := h.db.GetPropertyLink(duplicateID)
+	found, err := h.properties.RejectDuplicate(duplicateID, req.Note)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if !found {
+		http.Error(w, "property link not found", http.StatusNotFound)
+		return
+	}
+	h.audit(r, "property_link.reject", "property_link", duplicateID, before, nil)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// maxSuspectKm caps the distance GET /api/property-links/suspects looks
+// for duplicates over
+const maxSuspectKm = 2.0
+
+// ListDuplicateSuspects handles GET /api/property-links/suspects
+// Lists unlinked listings from different sources within km (default 0.3) of
+// each other with similar land sizes, closest first, for approving (POST
+// /api/property-links/suspects/approve, as POST /api/property-links) or
+// rejecting.
+func (h *Handlers) ListDuplicateSuspects(w http.ResponseWriter, r *http.Request) {
+	km := 0.3
+	if v := r.URL.Query().Get("km"); v != "" {
+		parsed, err := strconv.ParseFloat(v, 64)
+		if err != nil || parsed <= 0 || parsed > maxSuspectKm {
+			http.Error(w, fmt.Sprintf("km must be over 0 and at most %g", maxSuspectKm), http.StatusBadRequest)
+			return
+		}
+		km = parsed
+	}
+
+	suspects, err := h.db.GetDuplicateSuspects(km, maxPropertyLinks)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"suspects": suspects,
+		"count":    len(suspects),
+	})
+}
+
+// RejectDuplicateSuspect handles POST /api/property-links/suspects/reject
+// Body: {"canonical_id": 12, "duplicate_id": 40, "note": "..."}. Records that
+// the pair aren't the same property, so they aren't suspected or linked again.
+func (h *Handlers) RejectDuplicateSuspect(w http.ResponseWriter, r *http.Request) {
+	req, ok := decodePropertyLinkRequest(w, r)
+	if !ok {
+		return
+	}
+	if req.CanonicalID == 0 || req.DuplicateID == 0 {
+		http.Error(w, "canonical_id and duplicate_id required", http.StatusBadRequest)
+		return
+	}
+
+	err := h.properties.RejectSuspect(req.CanonicalID, req.DuplicateID, req.Note)
+	switch {
+	case errors.Is(err, service.ErrPropertyNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	case errors.Is(err, service.ErrSelfLink):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	case errors.Is(err, service.ErrLinked):
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	h.audit(r, "property_link.reject_suspect", "property_link", req.DuplicateID, nil, map[string]interface{}{
+		"canonical_id": req.CanonicalID,
+		"duplicate_id": req.DuplicateID,
+		"note":         req.Note,
+	})
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetPropertyLinkAudit handles GET /api/property-links/audit
+// Lists manual link creates, confirms and rejects, newest first.
+// Optional params: property_id (changes involving that listing)
+func (h *Handlers) GetPropertyLinkAudit(w http.ResponseWriter, r *http.Request) {
+	propertyID, _ := strconv.ParseInt(r.URL.Query().Get("property_id"), 10, 64)
+
+	entries, err := h.db.GetPropertyLinkAudit(propertyID, maxPropertyLinks)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"audit": entries,
+		"count": len(entries),
+	})
+}
+
+// maxRouteReviews caps how many route reviews ListRouteReviews returns
+const maxRouteReviews = 500
+
+// ListRouteReviews handles GET /api/route-reviews
+// Lists routes held back from the drive time columns because the road
+// distance looked implausible, most suspicious first.
+// Optional params: status ('pending' (default), 'accepted', 'rejected' or 'all')
+func (h *Handlers) ListRouteReviews(w http.ResponseWriter, r *http.Request) {
+	status := r.URL.Query().Get("status")
+	switch status {
+	case "":
+		status = "pending"
+	case "all":
+		status = ""
+	case "pending", "accepted", "rejected":
+	default:
+		http.Error(w, "status must be pending, accepted, rejected or all", http.StatusBadRequest)
+		return
+	}
+
+	reviews, err := h.db.ListRouteReviews(status, maxRouteReviews)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"reviews": reviews,
+		"count":   len(reviews),
+	})
+}
+
+// AcceptRouteReview handles POST /api/route-reviews/{id}/accept
+// Marks a held route as genuine and saves its drive time to the property.
+func (h *Handlers) AcceptRouteReview(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "invalid route review ID", http.StatusBadRequest)
+		return
+	}
+
+	before, _ := h.db.GetRouteReview(id)
+	found, err := h.db.AcceptRouteReview(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if !found {
+		http.Error(w, "route review not found", http.StatusNotFound)
+		return
+	}
 
-	properties, err := h.db.ListProperties(filter)
+	review, err := h.db.GetRouteReview(id)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	h.audit(r, "route_review.accept", "route_review", id, before, review)
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"properties": properties,
-		"count":      len(properties),
-	})
+	json.NewEncoder(w).Encode(review)
 }
 
-// GetProperty handles GET /api/properties/{id}
-func (h *Handlers) GetProperty(w http.ResponseWriter, r *http.Request) {
-	idStr := chi.URLParam(r, "id")
-	id, err := strconv.ParseInt(idStr, 10, 64)
+// RejectRouteReview handles POST /api/route-reviews/{id}/reject
+// Marks a held route as bogus; the drive time stays unset.
+func (h *Handlers) RejectRouteReview(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
 	if err != nil {
-		http.Error(w, "invalid property ID", http.StatusBadRequest)
+		http.Error(w, "invalid route review ID", http.StatusBadRequest)
 		return
 	}
 
-	property, err := h.db.GetProperty(id)
+	before, _ := h.db.GetRouteReview(id)
+	found, err := h.db.RejectRouteReview(id)
 	if err != nil {
-		http.Error(w, "property not found", http.StatusNotFound)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
-
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(property)
+	if !found {
+		http.Error(w, "route review not found", http.StatusNotFound)
+		return
+	}
+	after, _ := h.db.GetRouteReview(id)
+	h.audit(r, "route_review.reject", "route_review", id, before, after)
+	w.WriteHeader(http.StatusNoContent)
 }
 
-// GetFilterOptions handles GET /api/filters/options
-func (h *Handlers) GetFilterOptions(w http.ResponseWriter, r *http.Request) {
-	options, err := h.db.GetFilterOptions()
+// maxGeocodeReviews caps how many geocode reviews ListGeocodeReviews returns
+const maxGeocodeReviews = 500
+
+// ListGeocodeReviews handles GET /api/geocode-reviews
+// Lists geocoded points held back from properties because they weren't on a
+// lot, or the lot's address didn't match the listing's, most recent first.
+// Optional params: status ('pending' (default), 'accepted', 'rejected' or 'all')
+func (h *Handlers) ListGeocodeReviews(w http.ResponseWriter, r *http.Request) {
+	status := r.URL.Query().Get("status")
+	switch status {
+	case "":
+		status = "pending"
+	case "all":
+		status = ""
+	case "pending", "accepted", "rejected":
+	default:
+		http.Error(w, "status must be pending, accepted, rejected or all", http.StatusBadRequest)
+		return
+	}
+
+	reviews, err := h.db.ListGeocodeReviews(status, maxGeocodeReviews)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(options)
-}
-
-// TriggerScrape handles POST /api/scrape/trigger
-func (h *Handlers) TriggerScrape(w http.ResponseWriter, r *http.Request) {
-	// TODO: Implement scraper trigger
-	// For now, return a placeholder response
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]string{
-		"status":  "queued",
-		"message": "Scrape job has been queued",
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"reviews": reviews,
+		"count":   len(reviews),
 	})
 }
 
-// GetRoute handles GET /api/route
-// Returns a driving route from a property to a destination as GeoJSON LineString
-// Supports two modes:
-// 1. By town name: from_lat, from_lng, town
-// 2. By coordinates: from_lat, from_lng, to_lat, to_lng, name (optional)
-func (h *Handlers) GetRoute(w http.ResponseWriter, r *http.Request) {
-	q := r.URL.Query()
-
-	// Parse from coordinates (required)
-	fromLat, err1 := strconv.ParseFloat(q.Get("from_lat"), 64)
-	fromLng, err2 := strconv.ParseFloat(q.Get("from_lng"), 64)
-
-	if err1 != nil || err2 != nil {
-		http.Error(w, "from_lat and from_lng required", http.StatusBadRequest)
+// AcceptGeocodeReview handles POST /api/geocode-reviews/{id}/accept
+// Marks a held geocode as right and saves it as the property's coordinates;
+// tools enrich fills in the rest.
+func (h *Handlers) AcceptGeocodeReview(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "invalid geocode review ID", http.StatusBadRequest)
 		return
 	}
 
-	var toLat, toLng float64
-	var destName string
+	before, _ := h.db.GetGeocodeReview(id)
+	found, err := h.db.AcceptGeocodeReview(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if !found {
+		http.Error(w, "geocode review not found", http.StatusNotFound)
+		return
+	}
 
-	// Check if routing by coordinates or by town name
-	if q.Get("to_lat") != "" && q.Get("to_lng") != "" {
-		// Mode 2: Route by coordinates
-		var err3, err4 error
-		toLat, err3 = strconv.ParseFloat(q.Get("to_lat"), 64)
-		toLng, err4 = strconv.ParseFloat(q.Get("to_lng"), 64)
-		if err3 != nil || err4 != nil {
-			http.Error(w, "invalid to_lat or to_lng", http.StatusBadRequest)
-			return
-		}
-		destName = q.Get("name") // Optional name for the destination
-	} else if q.Get("town") != "" {
-		// Mode 1: Route by town name (legacy support)
-		townName := q.Get("town")
-		found := false
-		for _, town := range geo.NSWTowns {
-			if strings.EqualFold(town.Name, townName) {
-				toLat = town.Latitude
-				toLng = town.Longitude
-				destName = townName
-				found = true
-				break
-			}
-		}
-		if !found {
-			http.Error(w, "town not found", http.StatusNotFound)
-			return
-		}
-	} else {
-		http.Error(w, "either 'town' or 'to_lat'+'to_lng' parameters required", http.StatusBadRequest)
+	review, err := h.db.GetGeocodeReview(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	h.audit(r, "geocode_review.accept", "geocode_review", id, before, review)
 
-	// Get route from Valhalla
-	router := geo.NewRouter("")
-	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
-	defer cancel()
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(review)
+}
 
-	route, err := router.GetRouteWithShape(ctx, fromLat, fromLng, toLat, toLng)
+// RejectGeocodeReview handles POST /api/geocode-reviews/{id}/reject
+// Marks a held geocode as wrong; the property stays without coordinates.
+func (h *Handlers) RejectGeocodeReview(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
 	if err != nil {
-		http.Error(w, "failed to get route: "+err.Error(), http.StatusInternalServerError)
+		http.Error(w, "invalid geocode review ID", http.StatusBadRequest)
 		return
 	}
 
-	// Build GeoJSON response
-	geojson := map[string]interface{}{
-		"type": "Feature",
-		"geometry": map[string]interface{}{
-			"type":        "LineString",
-			"coordinates": route.Coordinates,
-		},
-		"properties": map[string]interface{}{
-			"duration_mins": route.DurationMins,
-			"distance_km":   route.DistanceKm,
-			"name":          destName,
-		},
+	before, _ := h.db.GetGeocodeReview(id)
+	found, err := h.db.RejectGeocodeReview(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
 	}
-
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(geojson)
+	if !found {
+		http.Error(w, "geocode review not found", http.StatusNotFound)
+		return
+	}
+	after, _ := h.db.GetGeocodeReview(id)
+	h.audit(r, "geocode_review.reject", "geocode_review", id, before, after)
+	w.WriteHeader(http.StatusNoContent)
 }
 
 // GetBoundaries handles GET /api/boundaries
@@ -275,7 +3004,7 @@ func (h *Handlers) GetBoundaries(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Parse all filters (same as properties endpoint)
-	filter := parsePropertyFilter(q)
+	filter := service.ParsePropertyFilter(q)
 
 	// Parse zoom level and add buffer at high zoom
 	zoom := 0.0
@@ -309,6 +3038,26 @@ func (h *Handlers) GetBoundaries(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Drive time area, drawn search area and exclusion filters, by lot
+	// centroid as lots don't carry their property's point (the isochrone is
+	// cached by the properties request)
+	ctx, cancel := context.WithTimeout(r.Context(), 60*time.Second)
+	defer cancel()
+	spatial, err := h.properties.Spatial(ctx, filter)
+	if err != nil {
+		http.Error(w, err.Error(), routingErrorStatus(err))
+		return
+	}
+	if !spatial.Empty() {
+		inside := lots[:0]
+		for _, lot := range lots {
+			if spatial.Matches(lot.CentroidLat, lot.CentroidLng) {
+				inside = append(inside, lot)
+			}
+		}
+		lots = inside
+	}
+
 	// Build GeoJSON FeatureCollection
 	features := make([]map[string]interface{}, 0, len(lots))
 	for _, lot := range lots {
@@ -318,15 +3067,20 @@ func (h *Handlers) GetBoundaries(w http.ResponseWriter, r *http.Request) {
 			continue // Skip lots with invalid geometry
 		}
 
+		props := map[string]interface{}{
+			"lot_id":     lot.LotIDString,
+			"lot_number": lot.LotNumber,
+			"plan_label": lot.PlanLabel,
+			"area_sqm":   lot.AreaSqm,
+		}
+		if lot.LandValue != nil {
+			props["land_value"] = *lot.LandValue
+		}
+
 		feature := map[string]interface{}{
-			"type":     "Feature",
-			"geometry": geometry,
-			"properties": map[string]interface{}{
-				"lot_id":     lot.LotIDString,
-				"lot_number": lot.LotNumber,
-				"plan_label": lot.PlanLabel,
-				"area_sqm":   lot.AreaSqm,
-			},
+			"type":       "Feature",
+			"geometry":   geometry,
+			"properties": props,
 		}
 		features = append(features, feature)
 	}
@@ -336,6 +3090,322 @@ func (h *Handlers) GetBoundaries(w http.ResponseWriter, r *http.Request) {
 		"features": features,
 	}
 
+	setRowCount(r, len(features))
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(geojson)
 }
+
+// GetLot handles GET /api/lots/{lotIDString}
+// Returns a stored cadastral lot as a GeoJSON Feature, with its land value,
+// zoning, minimum lot size, hazards (heritage items, planning overlays and
+// past fires) and the properties on it. The lot ID is as stored, e.g.
+// 2//DP1051800; its slashes may be escaped.
+func (h *Handlers) GetLot(w http.ResponseWriter, r *http.Request) {
+	lotID, err := url.PathUnescape(chi.URLParam(r, "*"))
+	if err != nil || strings.TrimSpace(lotID) == "" {
+		http.Error(w, "invalid lot ID", http.StatusBadRequest)
+		return
+	}
+
+	lot, err := h.db.GetLot(strings.ToUpper(strings.TrimSpace(lotID)))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if lot == nil {
+		http.Error(w, "lot not found", http.StatusNotFound)
+		return
+	}
+
+	feature, err := lotFeature(lot, false)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(feature)
+}
+
+// SearchLots handles GET /api/lots?q=Lot 12 DP1234567
+// Looks up a lot by a Lot/DP reference as written on contracts, fetching it
+// from NSW Spatial Services if it isn't stored, and returns it as GetLot
+// does, with "fetched" set in its properties if it was just fetched.
+func (h *Handlers) SearchLots(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+	defer cancel()
+
+	lot, fetched, err := h.lots.Search(ctx, r.URL.Query().Get("q"))
+	if errors.Is(err, service.ErrInvalidLotReference) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	if lot == nil {
+		http.Error(w, "lot not found", http.StatusNotFound)
+		return
+	}
+
+	feature, err := lotFeature(lot, fetched)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(feature)
+}
+
+// lotFeature returns a lot as a GeoJSON Feature, with what's recorded about
+// it in its properties (and "fetched" if it was just fetched)
+func lotFeature(lot *models.LotDetail, fetched bool) (map[string]interface{}, error) {
+	var geometry interface{}
+	if err := json.Unmarshal([]byte(lot.Geometry), &geometry); err != nil {
+		return nil, fmt.Errorf("invalid geometry for lot %s: %w", lot.LotIDString, err)
+	}
+
+	props := map[string]interface{}{
+		"lot_id":       lot.LotIDString,
+		"lot_number":   lot.LotNumber,
+		"plan_label":   lot.PlanLabel,
+		"area_sqm":     lot.AreaSqm,
+		"centroid_lat": lot.CentroidLat,
+		"centroid_lng": lot.CentroidLng,
+		"fetched_at":   lot.FetchedAt,
+		"heritage":     lot.HeritageItems,
+		"overlays":     lot.Overlays,
+		"fires":        lot.Fires,
+		"properties":   lot.Properties,
+	}
+	if lot.LandValue != nil {
+		props["land_value"] = *lot.LandValue
+		props["land_value_base_date"] = lot.LandValueBaseDate
+	}
+	if lot.ZoneCode != nil && *lot.ZoneCode != "" {
+		props["zone_code"] = *lot.ZoneCode
+	}
+	if lot.MinLotSizeSqm != nil {
+		props["min_lot_size_sqm"] = *lot.MinLotSizeSqm
+	}
+	if fetched {
+		props["fetched"] = true
+	}
+
+	return map[string]interface{}{
+		"type":       "Feature",
+		"geometry":   geometry,
+		"properties": props,
+	}, nil
+}
+
+// GetSuburbStats handles GET /api/stats/suburbs.geojson
+// Returns the suburb boundaries (from `tools suburbs`) containing properties
+// matching the same filters as /api/properties, each with its listing count,
+// median asking price and median drive time to the anchor, for choropleths,
+// and once `tools velocity` has run, how quickly its listings go off the
+// market and its market heat (above 1 is faster than the typical suburb).
+func (h *Handlers) GetSuburbStats(w http.ResponseWriter, r *http.Request) {
+	filter := service.ParsePropertyFilter(r.URL.Query())
+
+	ctx, cancel := context.WithTimeout(r.Context(), 60*time.Second)
+	defer cancel()
+
+	stats, err := h.suburbs.Stats(ctx, filter)
+	if err != nil {
+		http.Error(w, err.Error(), routingErrorStatus(err))
+		return
+	}
+
+	features := make([]map[string]interface{}, 0, len(stats))
+	for _, s := range stats {
+		props := map[string]interface{}{
+			"sal_code": s.Boundary.Code,
+			"name":     s.Boundary.Name,
+			"listings": s.Listings,
+			"priced":   s.Priced,
+		}
+		if s.MedianPrice != nil {
+			props["median_price"] = *s.MedianPrice
+		}
+		if s.MedianDriveTimePrimary != nil {
+			props["median_drive_time_primary"] = *s.MedianDriveTimePrimary
+		}
+		if v := s.Velocity; v != nil {
+			props["off_market"] = v.OffMarket
+			if v.MedianDays != nil {
+				props["median_days_on_market"] = *v.MedianDays
+			}
+			if v.EMADays != nil {
+				props["ema_days_on_market"] = *v.EMADays
+			}
+		}
+		if s.MarketHeat != nil {
+			props["market_heat"] = *s.MarketHeat
+		}
+		features = append(features, map[string]interface{}{
+			"type":       "Feature",
+			"geometry":   json.RawMessage(s.Boundary.Geometry),
+			"properties": props,
+		})
+	}
+
+	setRowCount(r, len(features))
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"type":     "FeatureCollection",
+		"features": features,
+	})
+}
+
+// GetDistribution handles GET /api/stats/distribution: a histogram of
+// field (land_size_sqm or price) over the properties matching the
+// /api/properties filters, split into buckets (default 20) of equal width,
+// or of equal ratio with scale=log, for range sliders' density previews.
+func (h *Handlers) GetDistribution(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	field := q.Get("field")
+	if !slices.Contains(service.DistributionFields, field) {
+		http.Error(w, fmt.Sprintf("field must be one of %s", strings.Join(service.DistributionFields, ", ")), http.StatusBadRequest)
+		return
+	}
+	buckets := 20
+	if v := q.Get("buckets"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > service.MaxDistributionBuckets {
+			http.Error(w, fmt.Sprintf("buckets must be 1 to %d", service.MaxDistributionBuckets), http.StatusBadRequest)
+			return
+		}
+		buckets = n
+	}
+	scale := q.Get("scale")
+	if scale != "" && scale != "linear" && scale != "log" {
+		http.Error(w, "scale must be linear or log", http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 60*time.Second)
+	defer cancel()
+
+	distribution, err := h.properties.Distribution(ctx, service.ParsePropertyFilter(q), field, buckets, scale == "log")
+	if err != nil {
+		http.Error(w, err.Error(), routingErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(distribution)
+}
+
+// parseHistoryDates parses the from, to (YYYY-MM-DD in Sydney time, default
+// today) and interval (default defaultInterval) params of a series over the
+// listing history, writing a 400 if they're invalid. from defaults to
+// defaultFrom(to).
+func parseHistoryDates(w http.ResponseWriter, q url.Values, defaultInterval string, defaultFrom func(to time.Time) time.Time) ([]time.Time, string, bool) {
+	today := time.Now().In(geo.SydneyTime)
+	to := time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, geo.SydneyTime)
+	if v := q.Get("to"); v != "" {
+		t, err := time.ParseInLocation("2006-01-02", v, geo.SydneyTime)
+		if err != nil {
+			http.Error(w, "to must be YYYY-MM-DD", http.StatusBadRequest)
+			return nil, "", false
+		}
+		to = t
+	}
+	from := defaultFrom(to)
+	if v := q.Get("from"); v != "" {
+		t, err := time.ParseInLocation("2006-01-02", v, geo.SydneyTime)
+		if err != nil {
+			http.Error(w, "from must be YYYY-MM-DD", http.StatusBadRequest)
+			return nil, "", false
+		}
+		from = t
+	}
+	if from.After(to) {
+		http.Error(w, "from must not be after to", http.StatusBadRequest)
+		return nil, "", false
+	}
+	interval := q.Get("interval")
+	if interval == "" {
+		interval = defaultInterval
+	}
+	if !slices.Contains(service.HistoryIntervals, interval) {
+		http.Error(w, fmt.Sprintf("interval must be one of %s", strings.Join(service.HistoryIntervals, ", ")), http.StatusBadRequest)
+		return nil, "", false
+	}
+	dates, ok := service.HistoryDates(from, to, interval)
+	if !ok {
+		http.Error(w, fmt.Sprintf("at most %d dates; use a longer interval", service.MaxHistoryDates), http.StatusBadRequest)
+		return nil, "", false
+	}
+	return dates, interval, true
+}
+
+// GetActiveListings handles GET /api/stats/active-listings
+// Counts the properties matching the /api/properties filters on the market
+// at the end of each date from from to to (YYYY-MM-DD in Sydney time,
+// default the last 90 days), a day, week or month apart (interval, default
+// day), for charting the market over time.
+func (h *Handlers) GetActiveListings(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	dates, interval, ok := parseHistoryDates(w, q, "day", func(to time.Time) time.Time { return to.AddDate(0, 0, -90) })
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 60*time.Second)
+	defer cancel()
+
+	counts, err := h.properties.ActiveListings(ctx, service.ParsePropertyFilter(q), dates)
+	if err != nil {
+		http.Error(w, err.Error(), routingErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"interval": interval,
+		"counts":   counts,
+	})
+}
+
+// GetTimeSeries handles GET /api/stats/timeseries
+// Charts a market metric (median_price, new_listings or delistings) per
+// day, week or month (interval, default month) from from to to (YYYY-MM-DD
+// in Sydney time, default the last year) over the properties matching the
+// /api/properties filters, optionally in one region (a suburb's SAL code or
+// name).
+func (h *Handlers) GetTimeSeries(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	metric := q.Get("metric")
+	if !slices.Contains(service.TrendMetrics, metric) {
+		http.Error(w, fmt.Sprintf("metric must be one of %s", strings.Join(service.TrendMetrics, ", ")), http.StatusBadRequest)
+		return
+	}
+	dates, interval, ok := parseHistoryDates(w, q, "month", func(to time.Time) time.Time { return to.AddDate(-1, 0, 0) })
+	if !ok {
+		return
+	}
+	region := q.Get("region")
+
+	ctx, cancel := context.WithTimeout(r.Context(), 60*time.Second)
+	defer cancel()
+
+	points, err := h.suburbs.TimeSeries(ctx, service.ParsePropertyFilter(q), region, metric, dates, interval)
+	if errors.Is(err, service.ErrUnknownRegion) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), routingErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"metric":   metric,
+		"interval": interval,
+		"region":   region,
+		"points":   points,
+	})
+}
@@ -1,25 +1,82 @@
 package api
 
 import (
+	"context"
+	"fmt"
+	"hash/fnv"
 	"log"
+	"maps"
 	"net/http"
+	"net/url"
+	"slices"
+	"strings"
 	"time"
+
+	"farm-search/internal/db"
 )
 
-// Logger logs HTTP requests
+// Logger logs HTTP requests. API requests also log a hash of their query
+// parameters (without sort and pagination), so requests for the same filter
+// can be grouped, and how many rows the handler returned, if it says (see
+// setRowCount).
 func Logger(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
-		
+
 		// Wrap response writer to capture status code
 		wrapped := &responseWriter{ResponseWriter: w, status: http.StatusOK}
-		
+		stats := &requestStats{rows: -1}
+		r = r.WithContext(context.WithValue(r.Context(), requestStatsKey{}, stats))
+
 		next.ServeHTTP(wrapped, r)
-		
-		log.Printf("%s %s %d %s", r.Method, r.URL.Path, wrapped.status, time.Since(start))
+
+		line := fmt.Sprintf("%s %s %d %s", r.Method, r.URL.Path, wrapped.status, time.Since(start))
+		if strings.HasPrefix(r.URL.Path, "/api/") {
+			if hash := filterHash(r.URL.Query()); hash != "" {
+				line += " filter=" + hash
+			}
+			if stats.rows >= 0 {
+				line += fmt.Sprintf(" rows=%d", stats.rows)
+			}
+		}
+		log.Print(line)
 	})
 }
 
+// requestStats is what a handler reports about its request for the log
+type requestStats struct {
+	rows int // -1 if not reported
+}
+
+type requestStatsKey struct{}
+
+// setRowCount records how many rows (properties, lots, suburbs) a handler
+// returned, for the request log
+func setRowCount(r *http.Request, n int) {
+	if stats, ok := r.Context().Value(requestStatsKey{}).(*requestStats); ok {
+		stats.rows = n
+	}
+}
+
+// unhashedParams are the query parameters left out of filterHash, as they
+// don't change which properties match
+var unhashedParams = []string{"sort", "limit", "offset"}
+
+// filterHash returns a short hash of the query parameters, the same whatever
+// their order, or "" if there are none
+func filterHash(q url.Values) string {
+	q = maps.Clone(q)
+	for _, name := range unhashedParams {
+		q.Del(name)
+	}
+	if len(q) == 0 {
+		return ""
+	}
+	h := fnv.New32a()
+	h.Write([]byte(q.Encode()))
+	return fmt.Sprintf("%08x", h.Sum32())
+}
+
 type responseWriter struct {
 	http.ResponseWriter
 	status int
@@ -30,6 +87,51 @@ func (rw *responseWriter) WriteHeader(code int) {
 	rw.ResponseWriter.WriteHeader(code)
 }
 
+// readOnlyPosts are the POST endpoints that only read, so a read-only replica
+// serves them
+var readOnlyPosts = []string{"/api/properties/details", "/api/graphql"}
+
+// readsOnly reports whether r can't write: GET, HEAD, OPTIONS and
+// readOnlyPosts
+func readsOnly(r *http.Request) bool {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	case http.MethodPost:
+		return slices.Contains(readOnlyPosts, r.URL.Path)
+	}
+	return false
+}
+
+// ReadOnly refuses API requests that could write, for a server on a read-only
+// replica (-readonly-db)
+func ReadOnly(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !readsOnly(r) {
+			http.Error(w, "This server is a read-only replica", http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// RefreshSearch refreshes property_search after each request that could
+// write, before its response completes, so a list requested after a change
+// (a coordinate correction, a duplicate link) shows it. Lists only read.
+func RefreshSearch(database *db.DB) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r)
+			if readsOnly(r) {
+				return
+			}
+			if _, err := database.RefreshPropertySearch(); err != nil {
+				log.Printf("Warning: %v", err)
+			}
+		})
+	}
+}
+
 // CORS adds CORS headers for development
 func CORS(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -1,8 +1,11 @@
 package api
 
 import (
-	"farm-search/internal/db"
+	"context"
+	"farm-search/internal/grpcapi"
+	"farm-search/internal/jobs"
 	"html/template"
+	"log"
 	"net/http"
 	"os"
 	"path/filepath"
@@ -18,27 +21,110 @@ var cacheBuster = strconv.FormatInt(time.Now().Unix(), 10)
 // Mapbox token from environment
 var mapboxToken = os.Getenv("MAPBOX_TOKEN")
 
-// NewRouter creates and configures the Chi router
-func NewRouter(database *db.DB, staticDir string) http.Handler {
+// NewRouter creates and configures the Chi router for h (see NewHandlers).
+// runner is the server's scheduled tasks, nil if it has none.
+func NewRouter(h *Handlers, staticDir string, runner *jobs.Runner) http.Handler {
 	r := chi.NewRouter()
 
 	// Middleware
 	r.Use(Logger)
 	r.Use(CORS)
+	if h.db.ReadOnly() {
+		r.Use(ReadOnly)
+	} else {
+		r.Use(RefreshSearch(h.db))
+	}
 
-	// Create handlers
-	h := NewHandlers(database)
+	h.jobs = runner
 
 	// API routes
 	r.Route("/api", func(r chi.Router) {
 		r.Get("/properties", h.ListProperties)
 		r.Get("/properties/{id}", h.GetProperty)
+		r.Post("/properties/details", h.GetPropertyDetails)
+		r.Get("/properties/recent", h.GetRecentProperties)
+		r.Get("/properties/as-of", h.GetPropertiesAsOf)
+		r.Get("/graphql", h.GraphQL)
+		r.Post("/graphql", h.GraphQL)
+		r.Get("/properties/{id}/rentals", h.GetPropertyRentals)
+		r.Get("/properties/{id}/nearby", h.GetPropertyNearby)
+		r.Get("/properties/{id}/neighbours", h.GetPropertyNeighbours)
+		r.Post("/properties/{id}/coordinates", h.SetPropertyCoordinates)
+		r.Get("/properties/{id}/attachments", h.ListAttachments)
+		r.Post("/properties/{id}/attachments", h.UploadAttachment)
+		r.Get("/attachments/{id}", h.DownloadAttachment)
+		r.Delete("/attachments/{id}", h.DeleteAttachment)
 		r.Get("/filters/options", h.GetFilterOptions)
+		r.Get("/anchor", h.GetAnchor)
 		r.Get("/boundaries", h.GetBoundaries)
+		r.Get("/lots", h.SearchLots)
+		r.Get("/lots/*", h.GetLot)
+		r.Get("/stats/suburbs.geojson", h.GetSuburbStats)
+		r.Get("/stats/distribution", h.GetDistribution)
+		r.Get("/stats/active-listings", h.GetActiveListings)
+		r.Get("/stats/timeseries", h.GetTimeSeries)
 		r.Get("/route", h.GetRoute)
+		r.Get("/route/matrix", h.GetRouteMatrix)
+		r.Get("/isochrone", h.GetIsochrone)
+		r.Get("/drive-time-grid", h.GetDriveTimeGrid)
+		r.Get("/plan", h.GetInspectionPlan)
+		r.Get("/calendar.ics", h.GetCalendar)
+		r.Get("/saved-searches", h.ListSavedSearches)
+		r.Post("/saved-searches", h.CreateSavedSearch)
+		r.Delete("/saved-searches/{id}", h.DeleteSavedSearch)
+		r.Get("/saved-searches/{id}/diff", h.GetSavedSearchDiff)
+		r.Get("/watchlists", h.ListWatchlists)
+		r.Post("/watchlists", h.CreateWatchlist)
+		r.Delete("/watchlists/{id}", h.DeleteWatchlist)
+		r.Get("/watchlists/{id}/reports", h.ListWatchlistReports)
+		r.Get("/watchlists/{id}/reports/{reportID}", h.GetWatchlistReport)
+		r.Post("/share", h.CreateShareLink)
+		r.Get("/share/{token}", h.GetShareLink)
+		r.Get("/user-data/export", h.ExportUserData)
+		r.Post("/user-data/import", h.ImportUserData)
+		r.Get("/tags", h.ListTags)
+		r.Post("/tags/{tag}/add", h.AddTag)
+		r.Post("/tags/{tag}/remove", h.RemoveTag)
+		r.Get("/exclusion-layers", h.ListExclusionLayers)
+		r.Get("/feeds/{id}.rss", h.GetSavedSearchFeed)
+		r.Get("/score-profiles", h.ListScoreProfiles)
+		r.Post("/score-profiles", h.CreateScoreProfile)
+		r.Put("/score-profiles/{id}", h.UpdateScoreProfile)
+		r.Delete("/score-profiles/{id}", h.DeleteScoreProfile)
+		r.Get("/pois", h.ListUserPOIs)
+		r.Post("/pois", h.CreateUserPOI)
+		r.Put("/pois/{id}", h.UpdateUserPOI)
+		r.Delete("/pois/{id}", h.DeleteUserPOI)
+		r.Get("/property-links", h.ListPropertyLinks)
+		r.Post("/property-links", h.CreatePropertyLink)
+		r.Get("/property-links/audit", h.GetPropertyLinkAudit)
+		r.Get("/property-links/suspects", h.ListDuplicateSuspects)
+		r.Post("/property-links/suspects/approve", h.CreatePropertyLink)
+		r.Post("/property-links/suspects/reject", h.RejectDuplicateSuspect)
+		r.Post("/property-links/{duplicate_id}/confirm", h.ConfirmPropertyLink)
+		r.Post("/property-links/{duplicate_id}/reject", h.RejectPropertyLink)
+		r.Get("/route-reviews", h.ListRouteReviews)
+		r.Post("/route-reviews/{id}/accept", h.AcceptRouteReview)
+		r.Post("/route-reviews/{id}/reject", h.RejectRouteReview)
+		r.Get("/geocode-reviews", h.ListGeocodeReviews)
+		r.Post("/geocode-reviews/{id}/accept", h.AcceptGeocodeReview)
+		r.Post("/geocode-reviews/{id}/reject", h.RejectGeocodeReview)
 		r.Post("/scrape/trigger", h.TriggerScrape)
+		r.Get("/audit", h.GetAudit)
+		r.Get("/debug/slow", h.GetSlowQueries)
+		r.Get("/jobs/schedule", h.GetJobSchedule)
 	})
 
+	// Share links open the map with their filters
+	r.Get("/s/{token}", h.OpenShareLink)
+
+	// The gRPC property search service's grpc-gateway routes
+	if gateway, err := grpcapi.NewServer(h.properties).Gateway(context.Background()); err != nil {
+		log.Printf("Warning: /v1 routes disabled: %v", err)
+	} else {
+		r.Handle("/v1/*", gateway)
+	}
+
 	// Serve static files
 	fileServer := http.FileServer(http.Dir(staticDir))
 	r.Handle("/static/*", http.StripPrefix("/static/", fileServer))
@@ -59,6 +145,8 @@ func NewRouter(database *db.DB, staticDir string) http.Handler {
 		tmpl.Execute(w, map[string]string{
 			"V":           cacheBuster,
 			"MapboxToken": mapboxToken,
+			"AnchorName":  anchor.Name,
+			"AnchorSlug":  anchor.Slug(),
 		})
 	})
 
@@ -0,0 +1,137 @@
+// Package attachments stores the files attached to properties (contracts,
+// section 10.7 certificates, soil tests) on local disk or S3-compatible
+// storage. Their metadata lives in the property_attachments table.
+package attachments
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"farm-search/internal/backup"
+)
+
+// ErrNotFound is returned when a stored file is missing
+var ErrNotFound = errors.New("attachment file not found")
+
+// Store saves and serves attachment files by key, e.g.
+// "properties/40/3f9a...-contract.pdf"
+type Store interface {
+	Put(ctx context.Context, key string, body io.ReadSeeker) error
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// FromEnv configures the store from ATTACHMENTS_STORE: "s3" uses the S3
+// settings the backups use (see backup.S3FromEnv) under ATTACHMENTS_S3_PREFIX
+// (default "farm-search/attachments"); anything else stores files under
+// ATTACHMENTS_DIR (default data/attachments).
+func FromEnv() (Store, error) {
+	if os.Getenv("ATTACHMENTS_STORE") == "s3" {
+		s3, err := backup.S3FromEnv()
+		if err != nil {
+			return nil, err
+		}
+		prefix := os.Getenv("ATTACHMENTS_S3_PREFIX")
+		if prefix == "" {
+			prefix = "farm-search/attachments"
+		}
+		return &S3Store{client: s3, prefix: strings.TrimSuffix(prefix, "/") + "/"}, nil
+	}
+
+	dir := os.Getenv("ATTACHMENTS_DIR")
+	if dir == "" {
+		dir = filepath.Join("data", "attachments")
+	}
+	return &DiskStore{Dir: dir}, nil
+}
+
+// DiskStore keeps files in a local directory
+type DiskStore struct {
+	Dir string
+}
+
+// path maps a key into the directory, refusing keys that would escape it
+func (s *DiskStore) path(key string) (string, error) {
+	path := filepath.Join(s.Dir, filepath.FromSlash(key))
+	if !strings.HasPrefix(path, filepath.Clean(s.Dir)+string(filepath.Separator)) {
+		return "", fmt.Errorf("invalid attachment key %q", key)
+	}
+	return path, nil
+}
+
+// Put writes body to key, through a temp file so a failed upload leaves nothing
+func (s *DiskStore) Put(ctx context.Context, key string, body io.ReadSeeker) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create attachment directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return fmt.Errorf("failed to create attachment file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, body); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write attachment: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write attachment: %w", err)
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Open opens the file at key
+func (s *DiskStore) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, key)
+	}
+	return f, err
+}
+
+// Delete removes the file at key; a missing file isn't an error
+func (s *DiskStore) Delete(ctx context.Context, key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to delete attachment: %w", err)
+	}
+	return nil
+}
+
+// S3Store keeps files in S3-compatible storage under a key prefix
+type S3Store struct {
+	client *backup.S3Client
+	prefix string
+}
+
+// Put uploads body to key
+func (s *S3Store) Put(ctx context.Context, key string, body io.ReadSeeker) error {
+	return s.client.Put(ctx, s.prefix+key, body)
+}
+
+// Open downloads the object at key
+func (s *S3Store) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	return s.client.Get(ctx, s.prefix+key)
+}
+
+// Delete removes the object at key
+func (s *S3Store) Delete(ctx context.Context, key string) error {
+	return s.client.Delete(ctx, s.prefix+key)
+}
@@ -0,0 +1,155 @@
+// Package backup snapshots, rotates, uploads and restores the SQLite database.
+package backup
+
+import (
+	"database/sql"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	_ "modernc.org/sqlite"
+
+	"farm-search/internal/db"
+)
+
+// filePrefix starts every snapshot's file name; rotation only touches these files
+const filePrefix = "farm-search-"
+
+// Snapshot writes an online backup of database into dir, named by the current
+// time, and returns its path
+func Snapshot(database *db.DB, dir string) (string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create backup directory: %w", err)
+	}
+	path := filepath.Join(dir, filePrefix+time.Now().UTC().Format("20060102-150405")+".db")
+	if err := database.BackupTo(path); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// Rotate deletes all but the newest keep snapshots in dir, returning the
+// paths it removed
+func Rotate(dir string, keep int) ([]string, error) {
+	snapshots, err := List(dir)
+	if err != nil {
+		return nil, err
+	}
+	if len(snapshots) <= keep {
+		return nil, nil
+	}
+
+	var removed []string
+	for _, path := range snapshots[:len(snapshots)-keep] {
+		if err := os.Remove(path); err != nil {
+			return removed, fmt.Errorf("failed to remove old backup: %w", err)
+		}
+		removed = append(removed, path)
+	}
+	return removed, nil
+}
+
+// List returns the snapshots in dir, oldest first
+func List(dir string) ([]string, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, filePrefix+"*.db"))
+	if err != nil {
+		return nil, err
+	}
+	// Names hold a sortable UTC timestamp
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// Verify checks that the file at path is an intact SQLite database with a
+// properties table
+func Verify(path string) error {
+	if _, err := os.Stat(path); err != nil {
+		return err
+	}
+	conn, err := sql.Open("sqlite", path+"?mode=ro")
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	var result string
+	if err := conn.QueryRow("PRAGMA integrity_check").Scan(&result); err != nil {
+		return fmt.Errorf("failed to check backup: %w", err)
+	}
+	if result != "ok" {
+		return fmt.Errorf("backup failed integrity check: %s", result)
+	}
+	var count int
+	if err := conn.QueryRow("SELECT COUNT(*) FROM properties").Scan(&count); err != nil {
+		return fmt.Errorf("backup has no properties table: %w", err)
+	}
+	return nil
+}
+
+// Restore replaces the database at dbPath with the backup at src, after
+// verifying the backup. The current database is kept as dbPath.pre-restore.
+// Nothing may have the database open while it runs (stop the server first).
+func Restore(src, dbPath string) error {
+	if err := Verify(src); err != nil {
+		return err
+	}
+
+	// Copy next to the target first so the final swap is a rename
+	tmp := dbPath + ".restoring"
+	if err := copyFile(src, tmp); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to copy backup: %w", err)
+	}
+
+	// Journal files move with the current database; left behind, they would
+	// be replayed onto the restored one
+	for _, suffix := range []string{"", "-journal", "-wal", "-shm"} {
+		if _, err := os.Stat(dbPath + suffix); err != nil {
+			continue
+		}
+		if err := os.Rename(dbPath+suffix, dbPath+".pre-restore"+suffix); err != nil {
+			os.Remove(tmp)
+			return fmt.Errorf("failed to keep current database: %w", err)
+		}
+	}
+
+	if err := os.Rename(tmp, dbPath); err != nil {
+		return fmt.Errorf("failed to move backup into place: %w", err)
+	}
+	return nil
+}
+
+// KeyFor returns the object key for a snapshot uploaded under prefix
+func KeyFor(prefix, path string) string {
+	prefix = strings.Trim(prefix, "/")
+	if prefix == "" {
+		return filepath.Base(path)
+	}
+	return prefix + "/" + filepath.Base(path)
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Sync(); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,208 @@
+package backup
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// S3Client uploads and downloads objects from S3-compatible storage (AWS S3,
+// Cloudflare R2, Backblaze B2, MinIO) using path-style URLs and SigV4 signing
+type S3Client struct {
+	Endpoint  string // e.g. https://s3.ap-southeast-2.amazonaws.com
+	Bucket    string
+	Region    string
+	AccessKey string
+	SecretKey string
+	client    *http.Client
+}
+
+// S3FromEnv configures an S3Client from S3_ENDPOINT, S3_BUCKET, S3_REGION
+// (default us-east-1), S3_ACCESS_KEY_ID and S3_SECRET_ACCESS_KEY
+func S3FromEnv() (*S3Client, error) {
+	c := &S3Client{
+		Endpoint:  strings.TrimRight(os.Getenv("S3_ENDPOINT"), "/"),
+		Bucket:    os.Getenv("S3_BUCKET"),
+		Region:    os.Getenv("S3_REGION"),
+		AccessKey: os.Getenv("S3_ACCESS_KEY_ID"),
+		SecretKey: os.Getenv("S3_SECRET_ACCESS_KEY"),
+		client:    &http.Client{Timeout: 30 * time.Minute},
+	}
+	if c.Region == "" {
+		c.Region = "us-east-1"
+	}
+	if c.Endpoint == "" || c.Bucket == "" || c.AccessKey == "" || c.SecretKey == "" {
+		return nil, fmt.Errorf("S3_ENDPOINT, S3_BUCKET, S3_ACCESS_KEY_ID and S3_SECRET_ACCESS_KEY must be set")
+	}
+	return c, nil
+}
+
+// Upload puts the file at path to key
+func (c *S3Client) Upload(ctx context.Context, key, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return c.Put(ctx, key, f)
+}
+
+// Put uploads body to key
+func (c *S3Client) Put(ctx context.Context, key string, body io.ReadSeeker) error {
+	// SigV4 signs the payload hash, so read the body once to hash it
+	h := sha256.New()
+	size, err := io.Copy(h, body)
+	if err != nil {
+		return err
+	}
+	if _, err := body.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	req, err := c.newRequest(ctx, http.MethodPut, key, body, hex.EncodeToString(h.Sum(nil)))
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to upload %s: %w", key, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("upload failed: %s: %s", resp.Status, body)
+	}
+	return nil
+}
+
+// Download saves the object at key to path
+func (c *S3Client) Download(ctx context.Context, key, path string) error {
+	body, err := c.Get(ctx, key)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	out, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, body); err != nil {
+		out.Close()
+		return fmt.Errorf("failed to download %s: %w", key, err)
+	}
+	return out.Close()
+}
+
+// Get opens the object at key; the caller closes it
+func (c *S3Client) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	emptyHash := sha256.Sum256(nil)
+	req, err := c.newRequest(ctx, http.MethodGet, key, nil, hex.EncodeToString(emptyHash[:]))
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", key, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("download failed: %s: %s", resp.Status, body)
+	}
+	return resp.Body, nil
+}
+
+// Delete removes the object at key
+func (c *S3Client) Delete(ctx context.Context, key string) error {
+	emptyHash := sha256.Sum256(nil)
+	req, err := c.newRequest(ctx, http.MethodDelete, key, nil, hex.EncodeToString(emptyHash[:]))
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to delete %s: %w", key, err)
+	}
+	defer resp.Body.Close()
+	// S3 answers 204 whether or not the object existed
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("delete failed: %s: %s", resp.Status, body)
+	}
+	return nil
+}
+
+// newRequest builds a request for key signed with AWS Signature Version 4
+func (c *S3Client) newRequest(ctx context.Context, method, key string, body io.Reader, payloadHash string) (*http.Request, error) {
+	path := "/" + uriEncode(c.Bucket) + "/" + uriEncode(key)
+	req, err := http.NewRequestWithContext(ctx, method, c.Endpoint+path, body)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	req.Header.Set("x-amz-date", amzDate)
+	req.Header.Set("x-amz-content-sha256", payloadHash)
+
+	signedHeaders := "host;x-amz-content-sha256;x-amz-date"
+	canonicalRequest := strings.Join([]string{
+		method,
+		path,
+		"", // No query string
+		"host:" + req.URL.Host,
+		"x-amz-content-sha256:" + payloadHash,
+		"x-amz-date:" + amzDate,
+		"",
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + c.Region + "/s3/aws4_request"
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+	key4 := hmacSHA256([]byte("AWS4"+c.SecretKey), date)
+	key4 = hmacSHA256(key4, c.Region)
+	key4 = hmacSHA256(key4, "s3")
+	key4 = hmacSHA256(key4, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key4, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		c.AccessKey, scope, signedHeaders, signature))
+	return req, nil
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// uriEncode percent-encodes everything but unreserved characters and '/', as SigV4 requires
+func uriEncode(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		ch := s[i]
+		if (ch >= 'A' && ch <= 'Z') || (ch >= 'a' && ch <= 'z') || (ch >= '0' && ch <= '9') ||
+			ch == '-' || ch == '_' || ch == '.' || ch == '~' || ch == '/' {
+			b.WriteByte(ch)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", ch)
+		}
+	}
+	return b.String()
+}
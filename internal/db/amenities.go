@@ -0,0 +1,91 @@
+package db
+
+import (
+	"fmt"
+	"time"
+
+	"farm-search/internal/models"
+)
+
+// ReplaceAmenities replaces every amenity of a type with a fresh import, in
+// one transaction. Returns the number saved.
+func (db *DB) ReplaceAmenities(amenityType, source string, amenities []models.Amenity) (int, error) {
+	tx, err := db.Beginx()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM amenities WHERE type = ?", amenityType); err != nil {
+		return 0, fmt.Errorf("failed to clear amenities: %w", err)
+	}
+
+	stmt, err := tx.Prepare(`
+		INSERT INTO amenities (type, name, detail, latitude, longitude, source, imported_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prepare amenity insert: %w", err)
+	}
+	defer stmt.Close()
+
+	now := time.Now().UTC()
+	for _, a := range amenities {
+		if _, err := stmt.Exec(amenityType, a.Name, a.Detail, a.Latitude, a.Longitude, source, now); err != nil {
+			return 0, fmt.Errorf("failed to save amenity %s: %w", a.Name, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit amenities: %w", err)
+	}
+	return len(amenities), nil
+}
+
+// GetAmenities returns every amenity of a type
+func (db *DB) GetAmenities(amenityType string) ([]models.Amenity, error) {
+	var amenities []models.Amenity
+	if err := db.Select(&amenities, "SELECT * FROM amenities WHERE type = ? ORDER BY id", amenityType); err != nil {
+		return nil, fmt.Errorf("failed to get amenities: %w", err)
+	}
+	return amenities, nil
+}
+
+// GetAmenityVersion returns a string that changes whenever amenities of a
+// type are reimported, for caching indexes built from them
+func (db *DB) GetAmenityVersion(amenityType string) (string, error) {
+	var version string
+	err := db.Get(&version, `
+		SELECT COUNT(*) || '|' || COALESCE(MAX(id), 0) FROM amenities WHERE type = ?
+	`, amenityType)
+	if err != nil {
+		return "", fmt.Errorf("failed to get amenity version: %w", err)
+	}
+	return version, nil
+}
+
+// GetPropertyDriveTimes returns the drive times saved in property_distances
+// for a property, by target type and name
+func (db *DB) GetPropertyDriveTimes(propertyID int64) (map[string]map[string]int, error) {
+	var rows []struct {
+		TargetType string `db:"target_type"`
+		TargetName string `db:"target_name"`
+		Mins       int    `db:"drive_time_mins"`
+	}
+	err := db.Select(&rows, `
+		SELECT target_type, target_name, drive_time_mins FROM property_distances
+		WHERE property_id = ? AND drive_time_mins IS NOT NULL
+	`, propertyID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get property drive times: %w", err)
+	}
+
+	times := make(map[string]map[string]int)
+	for _, r := range rows {
+		if times[r.TargetType] == nil {
+			times[r.TargetType] = make(map[string]int)
+		}
+		times[r.TargetType][r.TargetName] = r.Mins
+	}
+	return times, nil
+}
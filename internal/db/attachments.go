@@ -0,0 +1,55 @@
+package db
+
+import (
+	"fmt"
+	"time"
+
+	"farm-search/internal/models"
+)
+
+const attachmentColumns = `id, property_id, kind, filename, content_type, size_bytes, sha256,
+	storage_key, note, uploaded_at`
+
+// CreateAttachment records an attached file and sets its ID and upload time
+func (db *DB) CreateAttachment(a *models.Attachment) error {
+	a.UploadedAt = time.Now().UTC().Truncate(time.Second)
+	result, err := db.Exec(`
+		INSERT INTO property_attachments (property_id, kind, filename, content_type, size_bytes, sha256,
+			storage_key, note, uploaded_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, a.PropertyID, a.Kind, a.Filename, a.ContentType, a.SizeBytes, a.SHA256, a.StorageKey, a.Note, a.UploadedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create attachment: %w", err)
+	}
+	a.ID, err = result.LastInsertId()
+	return err
+}
+
+// ListAttachments returns a property's attachments, newest first
+func (db *DB) ListAttachments(propertyID int64) ([]models.Attachment, error) {
+	attachments := []models.Attachment{}
+	if err := db.Select(&attachments, "SELECT "+attachmentColumns+` FROM property_attachments
+		WHERE property_id = ? ORDER BY uploaded_at DESC, id DESC`, propertyID); err != nil {
+		return nil, fmt.Errorf("failed to list attachments: %w", err)
+	}
+	return attachments, nil
+}
+
+// GetAttachment returns an attachment by ID
+func (db *DB) GetAttachment(id int64) (*models.Attachment, error) {
+	var a models.Attachment
+	if err := db.Get(&a, "SELECT "+attachmentColumns+" FROM property_attachments WHERE id = ?", id); err != nil {
+		return nil, fmt.Errorf("failed to get attachment: %w", err)
+	}
+	return &a, nil
+}
+
+// DeleteAttachment removes an attachment's record, reporting whether it existed
+func (db *DB) DeleteAttachment(id int64) (bool, error) {
+	result, err := db.Exec("DELETE FROM property_attachments WHERE id = ?", id)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete attachment: %w", err)
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
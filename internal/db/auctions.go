@@ -0,0 +1,124 @@
+package db
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"farm-search/internal/models"
+)
+
+// UpsertAuctionResult inserts or updates an auction outcome based on source, date and address
+func (db *DB) UpsertAuctionResult(a *models.AuctionResult) error {
+	query := `
+		INSERT INTO auction_results (
+			source, city, auction_date, address, suburb, state, postcode,
+			property_type, bedrooms, result, sold_price, agency, url, scraped_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(source, auction_date, address, suburb) DO UPDATE SET
+			result = excluded.result,
+			sold_price = COALESCE(excluded.sold_price, auction_results.sold_price),
+			property_type = COALESCE(excluded.property_type, auction_results.property_type),
+			bedrooms = COALESCE(excluded.bedrooms, auction_results.bedrooms),
+			agency = COALESCE(excluded.agency, auction_results.agency),
+			url = COALESCE(excluded.url, auction_results.url),
+			scraped_at = excluded.scraped_at
+	`
+
+	_, err := db.Exec(query,
+		a.Source, a.City, a.AuctionDate, a.Address, a.Suburb, a.State, a.Postcode,
+		a.PropertyType, a.Bedrooms, a.Result, a.SoldPrice, a.Agency, a.URL, a.ScrapedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to upsert auction result: %w", err)
+	}
+	return nil
+}
+
+// streetTypeAbbreviations shortens street types so "12 Smith Road" matches "12 Smith Rd"
+var streetTypeAbbreviations = map[string]string{
+	"street": "st", "road": "rd", "lane": "ln", "avenue": "ave", "drive": "dr",
+	"court": "ct", "place": "pl", "crescent": "cres", "highway": "hwy",
+	"parade": "pde", "close": "cl", "terrace": "tce", "way": "wy",
+}
+
+var addressPunctuation = regexp.MustCompile(`[^a-z0-9/ ]+`)
+
+// normalizeAddress reduces a street address to lowercase words with abbreviated
+// street types, dropping anything after the first comma (usually the suburb)
+func normalizeAddress(address string) string {
+	address = strings.ToLower(address)
+	if idx := strings.Index(address, ","); idx >= 0 {
+		address = address[:idx]
+	}
+	words := strings.Fields(addressPunctuation.ReplaceAllString(address, " "))
+	for i, w := range words {
+		if abbr, ok := streetTypeAbbreviations[w]; ok {
+			words[i] = abbr
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+// LinkAuctionResults links unlinked auction results to properties in the same
+// suburb with the same street address. Returns the number of results linked.
+func (db *DB) LinkAuctionResults() (int, error) {
+	var results []struct {
+		ID      int64  `db:"id"`
+		Address string `db:"address"`
+		Suburb  string `db:"suburb"`
+	}
+	err := db.Select(&results, `
+		SELECT id, address, suburb FROM auction_results WHERE property_id IS NULL
+	`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get unlinked auction results: %w", err)
+	}
+
+	linked := 0
+	for _, r := range results {
+		target := normalizeAddress(r.Address)
+		if target == "" {
+			continue
+		}
+
+		var candidates []struct {
+			ID      int64  `db:"id"`
+			Address string `db:"address"`
+		}
+		err := db.Select(&candidates, `
+			SELECT id, address FROM properties
+			WHERE address IS NOT NULL AND LOWER(suburb) = LOWER(?)
+		`, r.Suburb)
+		if err != nil {
+			return linked, fmt.Errorf("failed to get properties in %s: %w", r.Suburb, err)
+		}
+
+		for _, c := range candidates {
+			if normalizeAddress(c.Address) == target {
+				if _, err := db.Exec("UPDATE auction_results SET property_id = ? WHERE id = ?", c.ID, r.ID); err != nil {
+					return linked, fmt.Errorf("failed to link auction result: %w", err)
+				}
+				linked++
+				break
+			}
+		}
+	}
+
+	return linked, nil
+}
+
+// GetPropertyAuctionResults returns a property's auction outcomes, most recent first
+func (db *DB) GetPropertyAuctionResults(propertyID int64) ([]models.AuctionSummary, error) {
+	var results []models.AuctionSummary
+	err := db.Select(&results, `
+		SELECT auction_date, result, sold_price, COALESCE(agency, '') as agency, source
+		FROM auction_results
+		WHERE property_id = ?
+		ORDER BY auction_date DESC
+	`, propertyID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get auction results: %w", err)
+	}
+	return results, nil
+}
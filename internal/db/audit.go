@@ -0,0 +1,92 @@
+package db
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"farm-search/internal/models"
+)
+
+// AuditFilter narrows ListAudit; zero fields don't filter
+type AuditFilter struct {
+	Entity   string
+	EntityID string
+	User     string
+	Since    time.Time
+	Limit    int
+}
+
+// auditRow is an audit_log row, with before and after still nullable text
+type auditRow struct {
+	models.AuditEntry
+	BeforeJSON *string `db:"before_json"`
+	AfterJSON  *string `db:"after_json"`
+}
+
+// RecordAudit appends an entry to the audit log, setting its ID and time
+func (db *DB) RecordAudit(e *models.AuditEntry) error {
+	e.CreatedAt = time.Now().UTC().Truncate(time.Second)
+	result, err := db.Exec(`
+		INSERT INTO audit_log (created_at, user, remote_addr, action, entity, entity_id, before_json, after_json)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`, e.CreatedAt, e.User, e.RemoteAddr, e.Action, e.Entity, e.EntityID, nullJSON(e.Before), nullJSON(e.After))
+	if err != nil {
+		return fmt.Errorf("failed to record audit entry: %w", err)
+	}
+	e.ID, err = result.LastInsertId()
+	return err
+}
+
+// ListAudit returns audit entries matching f, newest first
+func (db *DB) ListAudit(f AuditFilter) ([]models.AuditEntry, error) {
+	query := `SELECT id, created_at, user, remote_addr, action, entity, entity_id, before_json, after_json
+		FROM audit_log WHERE 1=1`
+	var args []interface{}
+	if f.Entity != "" {
+		query += " AND entity = ?"
+		args = append(args, f.Entity)
+	}
+	if f.EntityID != "" {
+		query += " AND entity_id = ?"
+		args = append(args, f.EntityID)
+	}
+	if f.User != "" {
+		query += " AND user = ?"
+		args = append(args, f.User)
+	}
+	if !f.Since.IsZero() {
+		query += " AND created_at >= ?"
+		args = append(args, f.Since.UTC())
+	}
+	query += " ORDER BY id DESC"
+	if f.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, f.Limit)
+	}
+
+	var rows []auditRow
+	if err := db.Select(&rows, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to list audit entries: %w", err)
+	}
+
+	entries := make([]models.AuditEntry, len(rows))
+	for i, r := range rows {
+		entries[i] = r.AuditEntry
+		if r.BeforeJSON != nil {
+			entries[i].Before = json.RawMessage(*r.BeforeJSON)
+		}
+		if r.AfterJSON != nil {
+			entries[i].After = json.RawMessage(*r.AfterJSON)
+		}
+	}
+	return entries, nil
+}
+
+// nullJSON stores an empty JSON value as NULL
+func nullJSON(v []byte) interface{} {
+	if len(v) == 0 {
+		return nil
+	}
+	return string(v)
+}
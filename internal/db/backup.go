@@ -0,0 +1,14 @@
+package db
+
+import (
+	"fmt"
+)
+
+// BackupTo writes a consistent copy of the database to path while it stays
+// online, using VACUUM INTO. path must not already exist.
+func (db *DB) BackupTo(path string) error {
+	if _, err := db.Exec("VACUUM INTO ?", path); err != nil {
+		return fmt.Errorf("failed to back up database: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,344 @@
+package db
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"farm-search/internal/models"
+	"farm-search/internal/sanitize"
+)
+
+// SaveResult counts what a batch save did. Errors has one entry per listing
+// (or listing's events or media) that couldn't be saved; the rest of the batch is kept.
+type SaveResult struct {
+	Inserted int
+	Updated  int
+	Failed   int
+	Errors   []error
+
+	// The listings inserted, and the existing listings the save changed a
+	// stored field of (Updated also counts those it only marked as scraped).
+	// Only SaveProperties fills these in.
+	New     []ListingRef
+	Changed []ListingRef
+}
+
+// ListingRef identifies a saved or pruned listing
+type ListingRef struct {
+	ID         int64  `db:"id"`
+	Source     string `db:"source"`
+	ExternalID string `db:"external_id"`
+	URL        string `db:"url"`
+}
+
+// Saved returns how many listings were inserted or updated
+func (r SaveResult) Saved() int {
+	return r.Inserted + r.Updated
+}
+
+// Add accumulates another batch's counts into r
+func (r *SaveResult) Add(other SaveResult) {
+	r.Inserted += other.Inserted
+	r.Updated += other.Updated
+	r.Failed += other.Failed
+	r.Errors = append(r.Errors, other.Errors...)
+	r.New = append(r.New, other.New...)
+	r.Changed = append(r.Changed, other.Changed...)
+}
+
+// batchStatements prepares statements on a transaction, remembering the
+// first error so callers can check once after preparing them all
+type batchStatements struct {
+	tx    *sqlx.Tx
+	stmts []*sqlx.Stmt
+	err   error
+}
+
+func (b *batchStatements) prepare(query string) *sqlx.Stmt {
+	if b.err != nil {
+		return nil
+	}
+	stmt, err := b.tx.Preparex(query)
+	if err != nil {
+		b.err = fmt.Errorf("failed to prepare statement: %w", err)
+		return nil
+	}
+	b.stmts = append(b.stmts, stmt)
+	return stmt
+}
+
+func (b *batchStatements) close() {
+	for _, stmt := range b.stmts {
+		stmt.Close()
+	}
+}
+
+// SaveProperties upserts one source's listings in a single transaction with
+// prepared statements, so an interrupted scrape saves none of the batch rather
+// than part of it. Descriptions are sanitized to plain text. Changes are logged to property_changes and versioned in
+// property_history. Listings with Media have their videos and floorplans from
+// that source replaced, and listings with Events their upcoming events from
+// that source (past events are kept; times are stored in UTC so they compare
+// correctly as text).
+func (db *DB) SaveProperties(listings []models.Property) (SaveResult, error) {
+	var result SaveResult
+
+	tx, err := db.Beginx()
+	if err != nil {
+		return result, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	b := &batchStatements{tx: tx}
+	defer b.close()
+	findStmt := b.prepare("SELECT id, scraped_at, substr(scraped_at, 1, 10) AS scraped_day, " + storedListingColumns +
+		" FROM properties WHERE external_id = ? AND source = ?")
+	upsertStmt := b.prepare(upsertPropertyQuery)
+	insertChangeStmt := b.prepare(`
+		INSERT INTO property_changes (property_id, kind, changed_at, price_before, price_after)
+		VALUES (?, ?, ?, ?, ?)
+	`)
+	history := prepareHistory(b)
+	clearEventsStmt := b.prepare("DELETE FROM property_events WHERE property_id = ? AND source = ? AND starts_at >= ?")
+	insertEventStmt := b.prepare(`
+		INSERT INTO property_events (property_id, kind, starts_at, ends_at, location, source)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`)
+	clearMediaStmt := b.prepare("DELETE FROM property_images WHERE property_id = ? AND source = ?")
+	insertMediaStmt := b.prepare(`
+		INSERT INTO property_images (property_id, kind, url, position, source)
+		VALUES (?, ?, ?, ?, ?)
+	`)
+	if b.err != nil {
+		return result, b.err
+	}
+
+	now := time.Now().UTC().Truncate(time.Second)
+	offMarketBefore, err := offMarketCutoff(tx, listings)
+	if err != nil {
+		return result, err
+	}
+	for i := range listings {
+		p := &listings[i]
+		sanitizeDescription(&p.Description)
+
+		var stored storedListing
+		err := findStmt.Get(&stored, p.ExternalID, p.Source)
+		if err != nil && err != sql.ErrNoRows {
+			result.Failed++
+			result.Errors = append(result.Errors, fmt.Errorf("listing %s: %w", p.ExternalID, err))
+			continue
+		}
+		exists := err == nil
+
+		res, err := upsertStmt.Exec(upsertPropertyArgs(p)...)
+		if err != nil {
+			result.Failed++
+			result.Errors = append(result.Errors, fmt.Errorf("listing %s: %w", p.ExternalID, err))
+			continue
+		}
+		propertyID := stored.ID
+		ref := ListingRef{Source: p.Source, ExternalID: p.ExternalID, URL: p.URL}
+		if exists {
+			result.Updated++
+			if listingChanges(p, &stored.Property) {
+				ref.ID = propertyID
+				result.Changed = append(result.Changed, ref)
+			}
+		} else {
+			propertyID, _ = res.LastInsertId()
+			result.Inserted++
+			ref.ID = propertyID
+			result.New = append(result.New, ref)
+		}
+		var offMarketSince time.Time
+		for _, c := range logChanges(p, stored, exists, offMarketBefore) {
+			if _, err := insertChangeStmt.Exec(propertyID, c.Kind, now, c.PriceBefore, c.PriceAfter); err != nil {
+				result.Errors = append(result.Errors, fmt.Errorf("listing %s changes: %w", p.ExternalID, err))
+			}
+			if c.Kind == "back_on_market" {
+				offMarketSince = stored.ScrapedAt
+			}
+		}
+		if err := history.record(propertyID, now, offMarketSince); err != nil {
+			result.Errors = append(result.Errors, fmt.Errorf("listing %s history: %w", p.ExternalID, err))
+		}
+
+		if p.Media != nil {
+			if _, err := clearMediaStmt.Exec(propertyID, p.Source); err != nil {
+				result.Errors = append(result.Errors, fmt.Errorf("listing %s media: %w", p.ExternalID, err))
+			} else {
+				for i, m := range p.Media {
+					if _, err := insertMediaStmt.Exec(propertyID, m.Kind, m.URL, i, p.Source); err != nil {
+						result.Errors = append(result.Errors, fmt.Errorf("listing %s media: %w", p.ExternalID, err))
+						break
+					}
+				}
+			}
+		}
+
+		if p.Events == nil {
+			continue
+		}
+		if _, err := clearEventsStmt.Exec(propertyID, p.Source, now); err != nil {
+			result.Errors = append(result.Errors, fmt.Errorf("listing %s events: %w", p.ExternalID, err))
+			continue
+		}
+		for _, e := range p.Events {
+			if e.StartsAt.Before(now) {
+				continue
+			}
+			if _, err := insertEventStmt.Exec(propertyID, e.Kind, e.StartsAt.UTC(), nullTimeUTC(e.EndsAt), e.Location, p.Source); err != nil {
+				result.Errors = append(result.Errors, fmt.Errorf("listing %s events: %w", p.ExternalID, err))
+				break
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return SaveResult{}, fmt.Errorf("failed to commit listings: %w", err)
+	}
+	return result, nil
+}
+
+// PreviewResult counts what SaveProperties would do with a batch
+type PreviewResult struct {
+	New       int
+	Updated   int // Existing listings the save would change
+	Unchanged int // Existing listings the save would only mark as scraped
+}
+
+// PreviewProperties works out what SaveProperties would do with listings
+// without writing anything, for a scrape dry run. A listing counts as updated
+// if saving it would change a stored field (see listingChanges).
+func (db *DB) PreviewProperties(listings []models.Property) (PreviewResult, error) {
+	var result PreviewResult
+
+	stmt, err := db.Preparex("SELECT " + storedListingColumns + " FROM properties WHERE external_id = ? AND source = ?")
+	if err != nil {
+		return result, fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	for i := range listings {
+		p := &listings[i]
+		sanitizeDescription(&p.Description)
+		var stored models.Property
+		err := stmt.Get(&stored, p.ExternalID, p.Source)
+		if err == sql.ErrNoRows {
+			result.New++
+			continue
+		}
+		if err != nil {
+			return result, fmt.Errorf("listing %s: %w", p.ExternalID, err)
+		}
+
+		if listingChanges(p, &stored) {
+			result.Updated++
+		} else {
+			result.Unchanged++
+		}
+	}
+	return result, nil
+}
+
+// storedListingColumns are the stored fields listingChanges compares a
+// listing against. A suburb or postcode corrected by its boundaries is
+// compared as the listing gave it.
+const storedListingColumns = `url, address, COALESCE(listed_suburb, suburb) AS suburb,
+	COALESCE(listed_postcode, postcode) AS postcode, latitude, longitude, coord_source,
+	price_min, price_max, price_text, COALESCE(property_type_raw, property_type) AS property_type, bedrooms, bathrooms,
+	carspaces, land_size_sqm, description, images`
+
+// listingChanges reports whether saving p over the stored listing would
+// change a stored field, following the upsert's rules: missing values keep
+// what's stored and manual coordinates are never replaced
+func listingChanges(p, stored *models.Property) bool {
+	changed := p.URL != stored.URL
+	for _, f := range [][2]driver.Valuer{
+		{p.Address, stored.Address}, {p.Suburb, stored.Suburb}, {p.Postcode, stored.Postcode},
+		{p.PriceMin, stored.PriceMin}, {p.PriceMax, stored.PriceMax}, {p.PriceText, stored.PriceText},
+		{p.PropertyType, stored.PropertyType}, {p.Bedrooms, stored.Bedrooms}, {p.Bathrooms, stored.Bathrooms},
+		{p.Carspaces, stored.Carspaces}, {p.LandSizeSqm, stored.LandSizeSqm}, {p.Description, stored.Description}, {p.Images, stored.Images},
+	} {
+		changed = changed || replaces(f[0], f[1])
+	}
+	if stored.CoordSource.String != "manual" {
+		changed = changed || replaces(p.Latitude, stored.Latitude) || replaces(p.Longitude, stored.Longitude)
+	}
+	return changed
+}
+
+// sanitizeDescription converts a description to plain text before it's
+// stored or compared, dropping it if nothing is left
+func sanitizeDescription(d *sql.NullString) {
+	if d.Valid {
+		d.String = sanitize.Description(d.String)
+		d.Valid = d.String != ""
+	}
+}
+
+// replaces reports whether saving value over stored would change it: values
+// that are missing keep what's stored
+func replaces(value, stored driver.Valuer) bool {
+	v, _ := value.Value()
+	if v == nil {
+		return false
+	}
+	s, _ := stored.Value()
+	return v != s
+}
+
+// SaveRentals upserts one source's rentals in a single transaction with
+// prepared statements, sanitizing their descriptions as SaveProperties does
+func (db *DB) SaveRentals(rentals []models.Rental) (SaveResult, error) {
+	var result SaveResult
+
+	tx, err := db.Beginx()
+	if err != nil {
+		return result, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	b := &batchStatements{tx: tx}
+	defer b.close()
+	findStmt := b.prepare("SELECT 1 FROM rentals WHERE external_id = ? AND source = ?")
+	upsertStmt := b.prepare(upsertRentalQuery)
+	if b.err != nil {
+		return result, b.err
+	}
+
+	for i := range rentals {
+		r := &rentals[i]
+		sanitizeDescription(&r.Description)
+
+		var found int
+		err := findStmt.Get(&found, r.ExternalID, r.Source)
+		if err != nil && err != sql.ErrNoRows {
+			result.Failed++
+			result.Errors = append(result.Errors, fmt.Errorf("rental %s: %w", r.ExternalID, err))
+			continue
+		}
+		exists := err == nil
+
+		if _, err := upsertStmt.Exec(upsertRentalArgs(r)...); err != nil {
+			result.Failed++
+			result.Errors = append(result.Errors, fmt.Errorf("rental %s: %w", r.ExternalID, err))
+			continue
+		}
+		if exists {
+			result.Updated++
+		} else {
+			result.Inserted++
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return SaveResult{}, fmt.Errorf("failed to commit rentals: %w", err)
+	}
+	return result, nil
+}
@@ -0,0 +1,73 @@
+package db
+
+import (
+	"fmt"
+	"time"
+
+	"farm-search/internal/models"
+)
+
+// ReplaceBiosecurityAreas replaces every biosecurity area of a kind with a
+// fresh import, in one transaction. Returns the number saved.
+func (db *DB) ReplaceBiosecurityAreas(kind string, areas []models.BiosecurityArea) (int, error) {
+	tx, err := db.Beginx()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM biosecurity_areas WHERE kind = ?", kind); err != nil {
+		return 0, fmt.Errorf("failed to clear biosecurity areas: %w", err)
+	}
+
+	stmt, err := tx.Prepare(`
+		INSERT INTO biosecurity_areas (kind, name, category, geometry, imported_at)
+		VALUES (?, ?, ?, ?, ?)
+	`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prepare biosecurity area insert: %w", err)
+	}
+	defer stmt.Close()
+
+	now := time.Now().UTC()
+	for _, a := range areas {
+		if _, err := stmt.Exec(kind, a.Name, a.Category, a.Geometry, now); err != nil {
+			return 0, fmt.Errorf("failed to save biosecurity area %s: %w", a.Name, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit biosecurity areas: %w", err)
+	}
+	return len(areas), nil
+}
+
+// GetBiosecurityAreas returns every biosecurity area, of every kind
+func (db *DB) GetBiosecurityAreas() ([]models.BiosecurityArea, error) {
+	var areas []models.BiosecurityArea
+	if err := db.Select(&areas, "SELECT * FROM biosecurity_areas ORDER BY kind, name, category"); err != nil {
+		return nil, fmt.Errorf("failed to get biosecurity areas: %w", err)
+	}
+	return areas, nil
+}
+
+// GetBiosecurityAreaVersion returns a string that changes whenever either
+// kind of biosecurity area is reimported
+func (db *DB) GetBiosecurityAreaVersion() (string, error) {
+	var version string
+	err := db.Get(&version, `
+		SELECT COUNT(*) || '|' || COALESCE(MAX(imported_at), '') FROM biosecurity_areas
+	`)
+	if err != nil {
+		return "", fmt.Errorf("failed to get biosecurity area version: %w", err)
+	}
+	return version, nil
+}
+
+// UpdatePropertyBiosecurity saves a property's Local Land Services region
+// (nil if it's in none) and the weed zones it's in, as a JSON array
+func (db *DB) UpdatePropertyBiosecurity(propertyID int64, region *string, weedZones string) error {
+	_, err := db.Exec("UPDATE properties SET lls_region = ?, weed_zones = ? WHERE id = ?",
+		region, weedZones, propertyID)
+	return err
+}
@@ -0,0 +1,114 @@
+package db
+
+import (
+	"fmt"
+
+	"farm-search/internal/models"
+)
+
+// BoundaryCheck is what the suburb and postcode boundaries say about a
+// property's listed suburb and postcode
+type BoundaryCheck struct {
+	PropertyID int64
+	// The suburb and postcode whose boundaries the pin is in, "" if it's
+	// outside them all (or they weren't imported)
+	Suburb   string
+	Postcode string
+	// Whether the listing's differ, and whether to correct them to the
+	// boundaries', keeping the listing's in listed_suburb and listed_postcode
+	SuburbMismatch   bool
+	PostcodeMismatch bool
+	FixSuburb        bool
+	FixPostcode      bool
+}
+
+// GetPropertiesToCheckBoundaries returns the properties with coordinates,
+// with their suburb and postcode and how far their pin can be trusted
+func (db *DB) GetPropertiesToCheckBoundaries() ([]models.Property, error) {
+	var properties []models.Property
+	err := db.Select(&properties, `
+		SELECT id, suburb, postcode, latitude, longitude, coord_confidence FROM properties
+		WHERE latitude IS NOT NULL AND longitude IS NOT NULL
+		ORDER BY id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get properties to check: %w", err)
+	}
+	return properties, nil
+}
+
+// SaveBoundaryChecks replaces the properties' boundary checks, clearing
+// those of properties not in checks, and makes the corrections they ask
+// for, in one transaction. Returns the number of suburbs and postcodes
+// corrected.
+func (db *DB) SaveBoundaryChecks(checks []BoundaryCheck) (suburbs, postcodes int, err error) {
+	tx, err := db.Beginx()
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`
+		UPDATE properties SET boundary_suburb = NULL, boundary_postcode = NULL,
+			suburb_mismatch = NULL, postcode_mismatch = NULL
+	`); err != nil {
+		return 0, 0, fmt.Errorf("failed to clear boundary checks: %w", err)
+	}
+	stmt, err := tx.Prepare(`
+		UPDATE properties SET boundary_suburb = ?, boundary_postcode = ?,
+			suburb_mismatch = ?, postcode_mismatch = ?
+		WHERE id = ?
+	`)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to prepare boundary check update: %w", err)
+	}
+	defer stmt.Close()
+	// The listing's value is only kept the first time, so it survives
+	// checking again after boundaries are reimported
+	fixSuburb, err := tx.Prepare(`
+		UPDATE properties SET listed_suburb = COALESCE(listed_suburb, suburb), suburb = ?, suburb_mismatch = 0
+		WHERE id = ?
+	`)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to prepare suburb correction: %w", err)
+	}
+	defer fixSuburb.Close()
+	fixPostcode, err := tx.Prepare(`
+		UPDATE properties SET listed_postcode = COALESCE(listed_postcode, postcode), postcode = ?, postcode_mismatch = 0
+		WHERE id = ?
+	`)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to prepare postcode correction: %w", err)
+	}
+	defer fixPostcode.Close()
+
+	for _, c := range checks {
+		var suburbMismatch, postcodeMismatch interface{}
+		if c.Suburb != "" {
+			suburbMismatch = c.SuburbMismatch
+		}
+		if c.Postcode != "" {
+			postcodeMismatch = c.PostcodeMismatch
+		}
+		if _, err := stmt.Exec(nullString(c.Suburb), nullString(c.Postcode), suburbMismatch, postcodeMismatch, c.PropertyID); err != nil {
+			return 0, 0, fmt.Errorf("failed to save boundary check for property %d: %w", c.PropertyID, err)
+		}
+		if c.FixSuburb && c.Suburb != "" {
+			if _, err := fixSuburb.Exec(c.Suburb, c.PropertyID); err != nil {
+				return 0, 0, fmt.Errorf("failed to correct suburb of property %d: %w", c.PropertyID, err)
+			}
+			suburbs++
+		}
+		if c.FixPostcode && c.Postcode != "" {
+			if _, err := fixPostcode.Exec(c.Postcode, c.PropertyID); err != nil {
+				return 0, 0, fmt.Errorf("failed to correct postcode of property %d: %w", c.PropertyID, err)
+			}
+			postcodes++
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, 0, fmt.Errorf("failed to commit boundary checks: %w", err)
+	}
+	return suburbs, postcodes, nil
+}
@@ -0,0 +1,81 @@
+package db
+
+import (
+	"fmt"
+	"time"
+
+	"farm-search/internal/models"
+)
+
+// ReplaceBuildingFootprints replaces the building footprints with a fresh
+// import, in one transaction. Returns the number saved.
+func (db *DB) ReplaceBuildingFootprints(footprints []models.BuildingFootprint) (int, error) {
+	tx, err := db.Beginx()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM building_footprints"); err != nil {
+		return 0, fmt.Errorf("failed to clear building footprints: %w", err)
+	}
+
+	stmt, err := tx.Prepare(`
+		INSERT INTO building_footprints (latitude, longitude, area_sqm, imported_at)
+		VALUES (?, ?, ?, ?)
+	`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prepare building footprint insert: %w", err)
+	}
+	defer stmt.Close()
+
+	now := time.Now().UTC()
+	for _, b := range footprints {
+		if _, err := stmt.Exec(b.Latitude, b.Longitude, b.AreaSqm, now); err != nil {
+			return 0, fmt.Errorf("failed to save building at %.6f, %.6f: %w", b.Latitude, b.Longitude, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit building footprints: %w", err)
+	}
+	return len(footprints), nil
+}
+
+// GetBuildingFootprintsWithin returns the building footprints with their
+// centroid in a bounding box
+func (db *DB) GetBuildingFootprintsWithin(swLat, swLng, neLat, neLng float64) ([]models.BuildingFootprint, error) {
+	var footprints []models.BuildingFootprint
+	err := db.Select(&footprints, `
+		SELECT * FROM building_footprints
+		WHERE latitude BETWEEN ? AND ? AND longitude BETWEEN ? AND ?
+	`, swLat, neLat, swLng, neLng)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get building footprints: %w", err)
+	}
+	return footprints, nil
+}
+
+// GetBuildingFootprintVersion returns a string that changes whenever the
+// building footprints are reimported
+func (db *DB) GetBuildingFootprintVersion() (string, error) {
+	var version string
+	err := db.Get(&version, `
+		SELECT COUNT(*) || '|' || COALESCE(MAX(imported_at), '') FROM building_footprints
+	`)
+	if err != nil {
+		return "", fmt.Errorf("failed to get building footprint version: %w", err)
+	}
+	return version, nil
+}
+
+// UpdatePropertyBuildings saves the number of buildings on a property's lots,
+// their total and largest plan area and whether one is a dwelling
+func (db *DB) UpdatePropertyBuildings(propertyID int64, count int, totalSqm, largestSqm float64, hasDwelling bool) error {
+	_, err := db.Exec(`
+		UPDATE properties
+		SET building_count = ?, building_area_sqm = ?, largest_building_sqm = ?, has_dwelling = ?
+		WHERE id = ?`,
+		count, totalSqm, largestSqm, hasDwelling, propertyID)
+	return err
+}
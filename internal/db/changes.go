@@ -0,0 +1,105 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"farm-search/internal/models"
+)
+
+// ChangeKinds are the kinds of entry in the listing change log
+var ChangeKinds = []string{"new", "price_drop", "back_on_market"}
+
+// offMarketDays is how long a listing has to have been missing from its
+// source's scrapes to count as back on the market when it reappears
+const offMarketDays = 30
+
+// storedListing is a listing as SaveProperties finds it stored, with the day
+// it was last scraped
+type storedListing struct {
+	models.Property
+	ScrapedDay string `db:"scraped_day"`
+}
+
+// offMarketCutoff returns the day before which a listing in the batch was
+// last scraped if it has been off the market: offMarketDays before its
+// source was last scraped, so a source that wasn't scraped for a while
+// doesn't bring all its listings back. "" if the source hasn't been scraped.
+func offMarketCutoff(tx *sqlx.Tx, listings []models.Property) (string, error) {
+	if len(listings) == 0 {
+		return "", nil
+	}
+	var last sql.NullString
+	if err := tx.Get(&last, "SELECT MAX(substr(scraped_at, 1, 10)) FROM properties WHERE source = ?", listings[0].Source); err != nil {
+		return "", fmt.Errorf("failed to get last scrape: %w", err)
+	}
+	day, err := time.Parse("2006-01-02", last.String)
+	if err != nil {
+		return "", nil
+	}
+	return day.AddDate(0, 0, -offMarketDays).Format("2006-01-02"), nil
+}
+
+// logChanges returns the change log entries saving p makes: new if it
+// wasn't stored, back on the market if it was last scraped before
+// offMarketBefore, and a price drop if its asking price (the top of its
+// range) is lower than the stored one
+func logChanges(p *models.Property, stored storedListing, exists bool, offMarketBefore string) []models.PropertyChange {
+	if !exists {
+		return []models.PropertyChange{{Kind: "new"}}
+	}
+	var changes []models.PropertyChange
+	if stored.ScrapedDay < offMarketBefore {
+		changes = append(changes, models.PropertyChange{Kind: "back_on_market"})
+	}
+	before, ok := askingPrice(&stored.Property)
+	after, ok2 := askingPrice(p)
+	if ok && ok2 && after < before {
+		changes = append(changes, models.PropertyChange{Kind: "price_drop", PriceBefore: &before, PriceAfter: &after})
+	}
+	return changes
+}
+
+// askingPrice returns the top of a listing's price range, if it has one
+func askingPrice(p *models.Property) (int64, bool) {
+	if p.PriceMax.Valid {
+		return p.PriceMax.Int64, true
+	}
+	return p.PriceMin.Int64, p.PriceMin.Valid
+}
+
+// GetPropertyChanges returns the change log entries of the given kinds
+// since a time, oldest first, attributed to canonical properties. A new
+// duplicate listing only counts as new if its canonical property was first
+// seen since then too.
+func (db *DB) GetPropertyChanges(since time.Time, kinds []string) ([]models.PropertyChange, error) {
+	changes := []models.PropertyChange{}
+	if len(kinds) == 0 {
+		return changes, nil
+	}
+	placeholders := make([]string, len(kinds))
+	args := []interface{}{since.UTC(), since.UTC().Format("2006-01-02")}
+	for i, kind := range kinds {
+		placeholders[i] = "?"
+		args = append(args, kind)
+	}
+
+	err := db.Select(&changes, fmt.Sprintf(`
+		SELECT COALESCE(pl.canonical_id, c.property_id) AS property_id, c.kind, c.changed_at, c.price_before, c.price_after
+		FROM property_changes c
+		LEFT JOIN property_links pl ON pl.duplicate_id = c.property_id
+		JOIN properties p ON p.id = COALESCE(pl.canonical_id, c.property_id)
+		WHERE c.changed_at >= ?
+			AND (c.kind != 'new' OR substr(p.first_seen_at, 1, 10) >= ?)
+			AND c.kind IN (%s)
+		ORDER BY c.changed_at, c.id
+	`, strings.Join(placeholders, ",")), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get property changes: %w", err)
+	}
+	return changes, nil
+}
@@ -3,8 +3,10 @@ package db
 import (
 	"embed"
 	"fmt"
+	"log"
 	"os"
 	"path/filepath"
+	"sync"
 
 	"github.com/jmoiron/sqlx"
 	_ "modernc.org/sqlite"
@@ -16,6 +18,10 @@ var schemaFS embed.FS
 // DB wraps sqlx.DB with application-specific methods
 type DB struct {
 	*sqlx.DB
+
+	// searchRefresh is held while RefreshPropertySearch runs
+	searchRefresh sync.Mutex
+	readOnly      bool
 }
 
 // New creates a new database connection and runs migrations
@@ -26,7 +32,11 @@ func New(dbPath string) (*DB, error) {
 		return nil, fmt.Errorf("failed to create database directory: %w", err)
 	}
 
-	db, err := sqlx.Connect("sqlite", dbPath+"?_foreign_keys=on")
+	dsn, err := writerDSN(dbPath)
+	if err != nil {
+		return nil, err
+	}
+	db, err := sqlx.Connect("sqlite", dsn)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
@@ -37,7 +47,12 @@ func New(dbPath string) (*DB, error) {
 		return nil, fmt.Errorf("failed to run migrations: %w", err)
 	}
 
-	return &DB{db}, nil
+	// Catch up on rows a migration or an earlier process left dirty
+	database := &DB{DB: db}
+	if _, err := database.RefreshPropertySearch(); err != nil {
+		log.Printf("Warning: %v", err)
+	}
+	return database, nil
 }
 
 func migrate(db *sqlx.DB) error {
@@ -54,13 +69,21 @@ func migrate(db *sqlx.DB) error {
 	// Run additional migrations for existing databases
 	runMigrations(db)
 
+	if err := canonicalizePropertyTypes(db); err != nil {
+		return err
+	}
+
 	return nil
 }
 
 // runMigrations handles schema changes for existing databases
 func runMigrations(db *sqlx.DB) {
-	// Add drive_time_sydney column if it doesn't exist
-	db.Exec("ALTER TABLE properties ADD COLUMN drive_time_sydney INTEGER")
+	// drive_time_sydney became drive_time_primary when the anchor became configurable
+	if _, err := db.Exec("ALTER TABLE properties RENAME COLUMN drive_time_sydney TO drive_time_primary"); err == nil {
+		renameSydneyDriveTime(db)
+	}
+	// Add drive_time_primary column if it doesn't exist
+	db.Exec("ALTER TABLE properties ADD COLUMN drive_time_primary INTEGER")
 	// Add nearest town columns
 	db.Exec("ALTER TABLE properties ADD COLUMN nearest_town_1 TEXT")
 	db.Exec("ALTER TABLE properties ADD COLUMN nearest_town_1_km REAL")
@@ -69,6 +92,9 @@ func runMigrations(db *sqlx.DB) {
 	// Add nearest town drive time columns
 	db.Exec("ALTER TABLE properties ADD COLUMN nearest_town_1_mins INTEGER")
 	db.Exec("ALTER TABLE properties ADD COLUMN nearest_town_2_mins INTEGER")
+	// Add walking and cycling time to nearest town columns
+	db.Exec("ALTER TABLE properties ADD COLUMN nearest_town_1_walk_mins INTEGER")
+	db.Exec("ALTER TABLE properties ADD COLUMN nearest_town_1_cycle_mins INTEGER")
 	// Add nearest school columns
 	db.Exec("ALTER TABLE properties ADD COLUMN nearest_school_1 TEXT")
 	db.Exec("ALTER TABLE properties ADD COLUMN nearest_school_1_km REAL")
@@ -83,4 +109,140 @@ func runMigrations(db *sqlx.DB) {
 	db.Exec("ALTER TABLE properties ADD COLUMN nearest_school_2_lng REAL")
 	// Add details_scraped_at column to track when full listing details were fetched
 	db.Exec("ALTER TABLE properties ADD COLUMN details_scraped_at DATETIME")
+	// Add NSW VG land value columns (total over the property's lots)
+	db.Exec("ALTER TABLE properties ADD COLUMN land_value INTEGER")
+	db.Exec("ALTER TABLE properties ADD COLUMN land_value_base_date TEXT")
+	// Add first_seen_at so feeds can tell new listings from re-scraped ones
+	db.Exec("ALTER TABLE properties ADD COLUMN first_seen_at DATETIME")
+	db.Exec("UPDATE properties SET first_seen_at = scraped_at WHERE first_seen_at IS NULL")
+	// Add confirmed_at so manually reviewed duplicate links can be told apart
+	db.Exec("ALTER TABLE property_links ADD COLUMN confirmed_at DATETIME")
+	// Add coordinate provenance; existing coordinates came from listings or the geocoder
+	// (indistinguishable now), so they're left unscored
+	db.Exec("ALTER TABLE properties ADD COLUMN coord_source TEXT")
+	db.Exec("ALTER TABLE properties ADD COLUMN coord_confidence REAL")
+	db.Exec("ALTER TABLE properties ADD COLUMN coord_updated_at DATETIME")
+	// Keep the source's property type when property_type is canonicalized
+	db.Exec("ALTER TABLE properties ADD COLUMN property_type_raw TEXT")
+	// Add nearest wind and solar farm columns
+	db.Exec("ALTER TABLE properties ADD COLUMN nearest_wind_farm TEXT")
+	db.Exec("ALTER TABLE properties ADD COLUMN nearest_wind_farm_status TEXT")
+	db.Exec("ALTER TABLE properties ADD COLUMN nearest_wind_farm_km REAL")
+	db.Exec("ALTER TABLE properties ADD COLUMN nearest_solar_farm TEXT")
+	db.Exec("ALTER TABLE properties ADD COLUMN nearest_solar_farm_status TEXT")
+	db.Exec("ALTER TABLE properties ADD COLUMN nearest_solar_farm_km REAL")
+	// Add noise proxy columns (distances to highways, railways and runways)
+	db.Exec("ALTER TABLE properties ADD COLUMN highway_km REAL")
+	db.Exec("ALTER TABLE properties ADD COLUMN railway_km REAL")
+	db.Exec("ALTER TABLE properties ADD COLUMN runway_km REAL")
+	// Add heritage listing columns (per property, and when each lot was checked)
+	db.Exec("ALTER TABLE properties ADD COLUMN heritage_listing TEXT")
+	db.Exec("ALTER TABLE cadastral_lots ADD COLUMN heritage_checked_at DATETIME")
+	// Add planning overlay columns (koala habitat and biodiversity values coverage)
+	db.Exec("ALTER TABLE properties ADD COLUMN koala_habitat_pct REAL")
+	db.Exec("ALTER TABLE properties ADD COLUMN biodiversity_pct REAL")
+	// Add Local Land Services region and declared weed zone columns
+	db.Exec("ALTER TABLE properties ADD COLUMN lls_region TEXT")
+	db.Exec("ALTER TABLE properties ADD COLUMN weed_zones TEXT")
+	// Add fire history columns (most recent burn and burns in the last 30 years)
+	db.Exec("ALTER TABLE properties ADD COLUMN last_burn_year INTEGER")
+	db.Exec("ALTER TABLE properties ADD COLUMN burn_count_30y INTEGER")
+	// Add imagery link columns (Street View, aerial imagery, Google Earth)
+	db.Exec("ALTER TABLE properties ADD COLUMN street_view_url TEXT")
+	db.Exec("ALTER TABLE properties ADD COLUMN aerial_url TEXT")
+	db.Exec("ALTER TABLE properties ADD COLUMN google_earth_url TEXT")
+	// Add vegetation change columns (woody cover and NDVI since a baseline, clearing flag)
+	db.Exec("ALTER TABLE properties ADD COLUMN woody_cover_change REAL")
+	db.Exec("ALTER TABLE properties ADD COLUMN ndvi_change REAL")
+	db.Exec("ALTER TABLE properties ADD COLUMN clearing_baseline_year INTEGER")
+	db.Exec("ALTER TABLE properties ADD COLUMN clearing_flagged INTEGER")
+	// Add NDVI summary columns (pasture greenness and its seasonal range)
+	db.Exec("ALTER TABLE properties ADD COLUMN ndvi_mean REAL")
+	db.Exec("ALTER TABLE properties ADD COLUMN ndvi_seasonal_range REAL")
+	db.Exec("ALTER TABLE properties ADD COLUMN ndvi_monthly TEXT")
+	// Add building footprint columns (structures on the lots, dwelling flag)
+	db.Exec("ALTER TABLE properties ADD COLUMN building_count INTEGER")
+	db.Exec("ALTER TABLE properties ADD COLUMN building_area_sqm REAL")
+	db.Exec("ALTER TABLE properties ADD COLUMN largest_building_sqm REAL")
+	db.Exec("ALTER TABLE properties ADD COLUMN has_dwelling INTEGER")
+	// Add minimum lot size columns (per lot, and per property with its subdivision ratio)
+	db.Exec("ALTER TABLE cadastral_lots ADD COLUMN min_lot_size_sqm REAL")
+	db.Exec("ALTER TABLE properties ADD COLUMN min_lot_size_sqm REAL")
+	db.Exec("ALTER TABLE properties ADD COLUMN subdivision_ratio REAL")
+	// Add access point columns (the routing origin for drive times)
+	db.Exec("ALTER TABLE properties ADD COLUMN access_lat REAL")
+	db.Exec("ALTER TABLE properties ADD COLUMN access_lng REAL")
+	db.Exec("ALTER TABLE properties ADD COLUMN access_source TEXT")
+	// Add the matched G-NAF address
+	db.Exec("ALTER TABLE properties ADD COLUMN gnaf_pid TEXT")
+	db.Exec("ALTER TABLE properties ADD COLUMN gnaf_match TEXT")
+	db.Exec("CREATE INDEX IF NOT EXISTS idx_properties_gnaf ON properties(gnaf_pid)")
+	// Add suburb and postcode boundary check columns (and the listing's
+	// values when corrected)
+	db.Exec("ALTER TABLE properties ADD COLUMN boundary_suburb TEXT")
+	db.Exec("ALTER TABLE properties ADD COLUMN boundary_postcode TEXT")
+	db.Exec("ALTER TABLE properties ADD COLUMN suburb_mismatch INTEGER")
+	db.Exec("ALTER TABLE properties ADD COLUMN postcode_mismatch INTEGER")
+	db.Exec("ALTER TABLE properties ADD COLUMN listed_suburb TEXT")
+	db.Exec("ALTER TABLE properties ADD COLUMN listed_postcode TEXT")
+	// Add car spaces (garage, carport and parking), parsed by every scraper
+	db.Exec("ALTER TABLE properties ADD COLUMN carspaces INTEGER")
+	// Add the number of dwellings estimated from the description
+	db.Exec("ALTER TABLE properties ADD COLUMN dwelling_count INTEGER")
+	// Index the columns the property filters use most. ListProperties reads
+	// property_search, indexed the same in schema.sql; the boundaries layer
+	// still filters properties.
+	db.Exec("CREATE INDEX IF NOT EXISTS idx_properties_price_max ON properties(price_max)")
+	db.Exec("CREATE INDEX IF NOT EXISTS idx_properties_land_size ON properties(land_size_sqm)")
+	db.Exec("CREATE INDEX IF NOT EXISTS idx_properties_type_land_size ON properties(property_type, land_size_sqm)")
+	db.Exec("CREATE INDEX IF NOT EXISTS idx_properties_drive_time ON properties(drive_time_primary)")
+	db.Exec("CREATE INDEX IF NOT EXISTS idx_properties_town_km ON properties(nearest_town_1_km)")
+	db.Exec("CREATE INDEX IF NOT EXISTS idx_properties_town_mins ON properties(nearest_town_1_mins)")
+	db.Exec("CREATE INDEX IF NOT EXISTS idx_properties_school_mins ON properties(nearest_school_1_mins)")
+	// Start the history of listings saved before it was kept (or copied in
+	// without it) from their current values
+	db.Exec(seedHistoryQuery)
+	// Mark enrichment steps stale when their inputs change. Created here rather
+	// than in schema.sql as they reference columns added above. They're
+	// recreated each time so they cover steps added since.
+	db.Exec("DROP TRIGGER IF EXISTS properties_coordinates_stale")
+	db.Exec("DROP TRIGGER IF EXISTS properties_access_stale")
+	db.Exec("DROP TRIGGER IF EXISTS property_lots_insert_stale")
+	db.Exec("DROP TRIGGER IF EXISTS property_lots_delete_stale")
+	db.Exec("DROP TRIGGER IF EXISTS properties_nearest_towns_stale")
+	db.Exec("DROP TRIGGER IF EXISTS properties_nearest_schools_stale")
+	db.Exec("DROP TRIGGER IF EXISTS properties_listing_text_stale")
+	for _, trigger := range staleTriggers {
+		db.Exec(trigger)
+	}
+	// Copy room counts to property_search for the bedroom, bathroom and car
+	// space filters, emptying it so the seed below copies them for every row
+	if _, err := db.Exec("ALTER TABLE property_search ADD COLUMN bedrooms INTEGER"); err == nil {
+		db.Exec("ALTER TABLE property_search ADD COLUMN bathrooms INTEGER")
+		db.Exec("ALTER TABLE property_search ADD COLUMN carspaces INTEGER")
+		db.Exec("DELETE FROM property_search")
+	}
+	// And the dwelling count for its filter
+	if _, err := db.Exec("ALTER TABLE property_search ADD COLUMN dwelling_count INTEGER"); err == nil {
+		db.Exec("DELETE FROM property_search")
+	}
+	// Keep property_search up to date. Recreated each time so they cover
+	// columns copied since.
+	for _, name := range searchTriggerNames {
+		db.Exec("DROP TRIGGER IF EXISTS " + name)
+	}
+	for _, trigger := range searchTriggers {
+		db.Exec(trigger)
+	}
+	db.Exec(seedPropertySearchQuery)
+}
+
+// renameSydneyDriveTime moves what referred to drive_time_sydney by name (the
+// stale step, score profile weights, saved search queries and route reviews)
+// over to drive_time_primary
+func renameSydneyDriveTime(db *sqlx.DB) {
+	db.Exec("UPDATE property_stale_steps SET step = 'drive_time_primary' WHERE step = 'drive_time_sydney'")
+	db.Exec(`UPDATE score_profiles SET weights = REPLACE(weights, '"drive_time_sydney"', '"drive_time_primary"')`)
+	db.Exec("UPDATE saved_searches SET query = REPLACE(query, 'drive_time_sydney_max=', 'drive_time_primary_max=')")
+	db.Exec("UPDATE route_reviews SET target_type = 'anchor' WHERE target_type = 'sutherland'")
 }
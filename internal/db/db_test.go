@@ -0,0 +1,52 @@
+package db
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"farm-search/internal/models"
+)
+
+// newTestDB opens a migrated database in a temporary directory
+func newTestDB(t *testing.T) *DB {
+	t.Helper()
+	database, err := New(filepath.Join(t.TempDir(), "farm-search.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { database.Close() })
+	return database
+}
+
+// testListing returns a scraped listing at lat, lng
+func testListing(externalID string, lat, lng float64) models.Property {
+	now := time.Now().UTC().Truncate(time.Second)
+	return models.Property{
+		ExternalID: externalID,
+		Source:     "domain",
+		URL:        "https://www.domain.com.au/" + externalID,
+		Address:    sql.NullString{String: "1204 Sofala Road", Valid: true},
+		Suburb:     sql.NullString{String: "Wattle Flat", Valid: true},
+		Latitude:   sql.NullFloat64{Float64: lat, Valid: true},
+		Longitude:  sql.NullFloat64{Float64: lng, Valid: true},
+		PriceMax:   sql.NullInt64{Int64: 1250000, Valid: true},
+		ScrapedAt:  now,
+		UpdatedAt:  now,
+	}
+}
+
+// saveListings saves listings as a scrape does, failing the test if any
+// listing fails
+func saveListings(t *testing.T, database *DB, listings ...models.Property) SaveResult {
+	t.Helper()
+	result, err := database.SaveProperties(listings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Failed > 0 {
+		t.Fatalf("%d listings failed to save: %v", result.Failed, result.Errors)
+	}
+	return result
+}
@@ -0,0 +1,47 @@
+package db
+
+import (
+	"fmt"
+	"time"
+)
+
+// ParseStat is the number of pages and listings a scraper extracted via one
+// extraction path (e.g. REA "argonaut_map") during a run
+type ParseStat struct {
+	RunAt    time.Time `db:"run_at" json:"run_at"`
+	Source   string    `db:"source" json:"source"`
+	Path     string    `db:"path" json:"path"`
+	Pages    int       `db:"pages" json:"pages"`
+	Listings int       `db:"listings" json:"listings"`
+}
+
+// SaveParseStats records the extraction path counts for a scrape run
+func (db *DB) SaveParseStats(runAt time.Time, stats []ParseStat) error {
+	for _, st := range stats {
+		_, err := db.Exec(`
+			INSERT INTO parse_stats (run_at, source, path, pages, listings)
+			VALUES (?, ?, ?, ?, ?)
+		`, runAt, st.Source, st.Path, st.Pages, st.Listings)
+		if err != nil {
+			return fmt.Errorf("failed to save parse stats: %w", err)
+		}
+	}
+	return nil
+}
+
+// GetPreviousParseStats returns the path counts from the most recent run of a
+// source before the given time
+func (db *DB) GetPreviousParseStats(source string, before time.Time) ([]ParseStat, error) {
+	var stats []ParseStat
+	err := db.Select(&stats, `
+		SELECT run_at, source, path, pages, listings
+		FROM parse_stats
+		WHERE source = ?
+		  AND run_at = (SELECT MAX(run_at) FROM parse_stats WHERE source = ? AND run_at < ?)
+		ORDER BY path
+	`, source, source, before)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get parse stats: %w", err)
+	}
+	return stats, nil
+}
@@ -0,0 +1,61 @@
+package db
+
+import (
+	"fmt"
+	"time"
+
+	"farm-search/internal/models"
+)
+
+// ReplaceDriveTimeGrid replaces the drive time grid with freshly computed
+// cells, in one transaction. Returns the number saved.
+func (db *DB) ReplaceDriveTimeGrid(cells []models.DriveTimeCell) (int, error) {
+	tx, err := db.Beginx()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM drive_time_grid"); err != nil {
+		return 0, fmt.Errorf("failed to clear drive time grid: %w", err)
+	}
+
+	stmt, err := tx.Prepare(`
+		INSERT OR REPLACE INTO drive_time_grid (lat, lng, lat_step, lng_step, drive_time_mins, distance_km, computed_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prepare drive time grid insert: %w", err)
+	}
+	defer stmt.Close()
+
+	now := time.Now().UTC()
+	for _, c := range cells {
+		if _, err := stmt.Exec(c.Lat, c.Lng, c.LatStep, c.LngStep, c.DriveTimeMins, c.DistanceKm, now); err != nil {
+			return 0, fmt.Errorf("failed to save drive time grid cell %.4f,%.4f: %w", c.Lat, c.Lng, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit drive time grid: %w", err)
+	}
+	return len(cells), nil
+}
+
+// GetDriveTimeGrid returns the grid cells whose centres are within a
+// bounding box and at most maxMins from the anchor (0 for any)
+func (db *DB) GetDriveTimeGrid(swLat, swLng, neLat, neLng float64, maxMins int) ([]models.DriveTimeCell, error) {
+	query := "SELECT * FROM drive_time_grid WHERE lat BETWEEN ? AND ? AND lng BETWEEN ? AND ?"
+	args := []interface{}{swLat, neLat, swLng, neLng}
+	if maxMins > 0 {
+		query += " AND drive_time_mins <= ?"
+		args = append(args, maxMins)
+	}
+	query += " ORDER BY lat, lng"
+
+	cells := []models.DriveTimeCell{}
+	if err := db.Select(&cells, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to get drive time grid: %w", err)
+	}
+	return cells, nil
+}
@@ -0,0 +1,27 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// GetPropertyListingText returns a property's description ("" if it has
+// none) and bedroom count, which its dwelling count is estimated from
+func (db *DB) GetPropertyListingText(propertyID int64) (string, sql.NullInt64, error) {
+	var row struct {
+		Description string        `db:"description"`
+		Bedrooms    sql.NullInt64 `db:"bedrooms"`
+	}
+	err := db.Get(&row, "SELECT COALESCE(description, '') AS description, bedrooms FROM properties WHERE id = ?", propertyID)
+	if err != nil {
+		return "", sql.NullInt64{}, fmt.Errorf("failed to get description: %w", err)
+	}
+	return row.Description, row.Bedrooms, nil
+}
+
+// UpdatePropertyDwellingCount saves the number of dwellings a property's
+// listing describes (0 if it describes none), marking it checked
+func (db *DB) UpdatePropertyDwellingCount(propertyID int64, count int) error {
+	_, err := db.Exec("UPDATE properties SET dwelling_count = ? WHERE id = ?", count, propertyID)
+	return err
+}
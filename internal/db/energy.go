@@ -0,0 +1,73 @@
+package db
+
+import (
+	"fmt"
+	"time"
+
+	"farm-search/internal/models"
+)
+
+// ReplaceEnergyDevelopments replaces every energy development from a source
+// with a fresh import, in one transaction. Returns the number saved.
+func (db *DB) ReplaceEnergyDevelopments(source string, developments []models.EnergyDevelopment) (int, error) {
+	tx, err := db.Beginx()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM energy_developments WHERE source = ?", source); err != nil {
+		return 0, fmt.Errorf("failed to clear energy developments: %w", err)
+	}
+
+	stmt, err := tx.Prepare(`
+		INSERT INTO energy_developments (name, kind, status, capacity_mw, latitude, longitude, source, imported_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prepare energy development insert: %w", err)
+	}
+	defer stmt.Close()
+
+	now := time.Now().UTC()
+	for _, d := range developments {
+		if _, err := stmt.Exec(d.Name, d.Kind, d.Status, d.CapacityMW, d.Latitude, d.Longitude, source, now); err != nil {
+			return 0, fmt.Errorf("failed to save energy development %s: %w", d.Name, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit energy developments: %w", err)
+	}
+	return len(developments), nil
+}
+
+// GetEnergyDevelopments returns every energy development, of every source
+func (db *DB) GetEnergyDevelopments() ([]models.EnergyDevelopment, error) {
+	var developments []models.EnergyDevelopment
+	if err := db.Select(&developments, "SELECT * FROM energy_developments ORDER BY id"); err != nil {
+		return nil, fmt.Errorf("failed to get energy developments: %w", err)
+	}
+	return developments, nil
+}
+
+// UpdatePropertyEnergyDevelopments saves a property's nearest wind and solar
+// farms (nil leaves them NULL)
+func (db *DB) UpdatePropertyEnergyDevelopments(propertyID int64, wind, solar *models.NearbyDevelopment) error {
+	var windName, windStatus, solarName, solarStatus *string
+	var windKm, solarKm *float64
+	if wind != nil {
+		windName, windStatus, windKm = &wind.Name, &wind.Status, &wind.DistanceKm
+	}
+	if solar != nil {
+		solarName, solarStatus, solarKm = &solar.Name, &solar.Status, &solar.DistanceKm
+	}
+
+	_, err := db.Exec(`
+		UPDATE properties
+		SET nearest_wind_farm = ?, nearest_wind_farm_status = ?, nearest_wind_farm_km = ?,
+		    nearest_solar_farm = ?, nearest_solar_farm_status = ?, nearest_solar_farm_km = ?
+		WHERE id = ?`,
+		windName, windStatus, windKm, solarName, solarStatus, solarKm, propertyID)
+	return err
+}
@@ -0,0 +1,352 @@
+package db
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"farm-search/internal/models"
+)
+
+// EnrichmentStep is a derived property column set filled in by an enrichment tool
+type EnrichmentStep string
+
+const (
+	StepDriveTimePrimary   EnrichmentStep = "drive_time_primary"
+	StepNearestTowns       EnrichmentStep = "nearest_towns"
+	StepTownDriveTimes     EnrichmentStep = "town_drive_times"
+	StepNearestSchools     EnrichmentStep = "nearest_schools"
+	StepSchoolDriveTimes   EnrichmentStep = "school_drive_times"
+	StepCadastralLots      EnrichmentStep = "cadastral_lots"
+	StepEnergyDevelopments EnrichmentStep = "energy_developments"
+	StepNoiseSources       EnrichmentStep = "noise_sources"
+	StepHeritage           EnrichmentStep = "heritage"
+	StepOverlays           EnrichmentStep = "overlays"
+	StepBiosecurity        EnrichmentStep = "biosecurity"
+	StepFireHistory        EnrichmentStep = "fire_history"
+	StepImageryLinks       EnrichmentStep = "imagery_links"
+	StepClearing           EnrichmentStep = "clearing"
+	StepNDVI               EnrichmentStep = "ndvi"
+	StepBuildings          EnrichmentStep = "buildings"
+	StepSubdivision        EnrichmentStep = "subdivision"
+	StepAccessPoint        EnrichmentStep = "access_point"
+	StepTownWalkCycleTimes EnrichmentStep = "town_walk_cycle_times"
+	StepPOIDriveTimes      EnrichmentStep = "poi_drive_times"
+	StepDwellings          EnrichmentStep = "dwellings"
+)
+
+// TownFringeKm is how close to its nearest town a property has to be for
+// walking and cycling times to it to be worked out
+const TownFringeKm = 10.0
+
+// enrichmentSteps maps each step to the properties it applies to, and those
+// of them still missing it
+var enrichmentSteps = map[EnrichmentStep]struct{ applies, missing string }{
+	StepDriveTimePrimary: {"1", "p.drive_time_primary IS NULL"},
+	StepNearestTowns:     {"1", "p.nearest_town_1 IS NULL"},
+	StepTownDriveTimes:   {"p.nearest_town_1 IS NOT NULL", "p.nearest_town_1_mins IS NULL"},
+	StepTownWalkCycleTimes: {fmt.Sprintf("p.nearest_town_1_km <= %g", TownFringeKm),
+		"p.nearest_town_1_walk_mins IS NULL AND p.nearest_town_1_cycle_mins IS NULL"},
+	StepNearestSchools:   {"1", "p.nearest_school_1 IS NULL"},
+	StepSchoolDriveTimes: {"p.nearest_school_1 IS NOT NULL", "p.nearest_school_1_mins IS NULL"},
+	StepCadastralLots:    {"1", "NOT EXISTS (SELECT 1 FROM property_lots pl WHERE pl.property_id = p.id)"},
+	// Only once developments are imported, else every property would stay missing
+	StepEnergyDevelopments: {"EXISTS (SELECT 1 FROM energy_developments)", "p.nearest_wind_farm_km IS NULL AND p.nearest_solar_farm_km IS NULL"},
+	StepNoiseSources: {"EXISTS (SELECT 1 FROM exclusion_features WHERE layer IN ('highways', 'railways', 'runways'))",
+		"p.highway_km IS NULL AND p.railway_km IS NULL AND p.runway_km IS NULL"},
+	// Checked per lot, so only once the property has some
+	StepHeritage: {"EXISTS (SELECT 1 FROM property_lots pl WHERE pl.property_id = p.id)", "p.heritage_listing IS NULL"},
+	StepOverlays: {`EXISTS (SELECT 1 FROM property_lots pl WHERE pl.property_id = p.id)
+		AND EXISTS (SELECT 1 FROM exclusion_features WHERE layer IN ('koala-habitat', 'biodiversity-values'))`,
+		"p.koala_habitat_pct IS NULL AND p.biodiversity_pct IS NULL"},
+	// weed_zones is '[]' once checked, even outside every zone
+	StepBiosecurity: {"EXISTS (SELECT 1 FROM biosecurity_areas)", "p.weed_zones IS NULL"},
+	// burn_count_30y is 0 once checked, even if never burnt
+	StepFireHistory: {`EXISTS (SELECT 1 FROM property_lots pl WHERE pl.property_id = p.id)
+		AND EXISTS (SELECT 1 FROM fire_extents)`, "p.burn_count_30y IS NULL"},
+	StepImageryLinks: {"1", "p.street_view_url IS NULL"},
+	// Measured over the lots' bounding box
+	StepClearing: {"EXISTS (SELECT 1 FROM property_lots pl WHERE pl.property_id = p.id)", "p.woody_cover_change IS NULL"},
+	StepNDVI:     {"EXISTS (SELECT 1 FROM property_lots pl WHERE pl.property_id = p.id)", "p.ndvi_mean IS NULL"},
+	// building_count is 0 once checked, even with no buildings
+	StepBuildings: {`EXISTS (SELECT 1 FROM property_lots pl WHERE pl.property_id = p.id)
+		AND EXISTS (SELECT 1 FROM building_footprints)`, "p.building_count IS NULL"},
+	// min_lot_size_sqm is 0 once checked, even where none is mapped
+	StepSubdivision: {"EXISTS (SELECT 1 FROM property_lots pl WHERE pl.property_id = p.id)", "p.min_lot_size_sqm IS NULL"},
+	// access_source is 'none' once checked, even with no road near
+	StepAccessPoint: {"EXISTS (SELECT 1 FROM property_lots pl WHERE pl.property_id = p.id)", "p.access_source IS NULL"},
+	// dwelling_count is 0 once checked, even if the listing describes none
+	StepDwellings: {"p.description IS NOT NULL", "p.dwelling_count IS NULL"},
+	// Missing while any user POI has no time from the property
+	StepPOIDriveTimes: {"EXISTS (SELECT 1 FROM user_pois)", `EXISTS (SELECT 1 FROM user_pois u WHERE NOT EXISTS (
+		SELECT 1 FROM property_poi_times t WHERE t.property_id = p.id AND t.poi_id = u.id))`},
+}
+
+// GetPropertiesToEnrich returns properties with coordinates that step applies
+// to: all of them if all is set, otherwise only those still missing it or
+// with it marked stale. A non-zero propertyID limits it to that property.
+func (db *DB) GetPropertiesToEnrich(step EnrichmentStep, all bool, propertyID int64) ([]models.EnrichmentTarget, error) {
+	cond, ok := enrichmentSteps[step]
+	if !ok {
+		return nil, fmt.Errorf("unknown enrichment step %q", step)
+	}
+
+	query := `
+		SELECT p.id, p.latitude, p.longitude,
+			COALESCE(p.access_lat, p.latitude) as origin_lat,
+			COALESCE(p.access_lng, p.longitude) as origin_lng,
+			COALESCE(p.address, '') as address,
+			COALESCE(p.suburb, '') as suburb,
+			COALESCE(p.nearest_town_1, '') as nearest_town_1,
+			COALESCE(p.nearest_town_2, '') as nearest_town_2,
+			COALESCE(p.nearest_school_1, '') as nearest_school_1,
+			COALESCE(p.nearest_school_2, '') as nearest_school_2
+		FROM properties p
+		WHERE p.latitude IS NOT NULL AND p.longitude IS NOT NULL
+			AND ` + cond.applies
+	var args []interface{}
+	if !all {
+		query += " AND (" + cond.missing + ` OR EXISTS (
+			SELECT 1 FROM property_stale_steps s WHERE s.property_id = p.id AND s.step = ?))`
+		args = append(args, step)
+	}
+	if propertyID != 0 {
+		query += " AND p.id = ?"
+		args = append(args, propertyID)
+	}
+
+	var targets []models.EnrichmentTarget
+	if err := db.Select(&targets, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to get properties to enrich: %w", err)
+	}
+	return targets, nil
+}
+
+// GetSavedNearestTowns returns the properties with nearest towns saved, and them
+func (db *DB) GetSavedNearestTowns() ([]models.SavedNearest, error) {
+	return db.getSavedNearest("nearest_town")
+}
+
+// GetSavedNearestSchools returns the properties with nearest schools saved,
+// and them
+func (db *DB) GetSavedNearestSchools() ([]models.SavedNearest, error) {
+	return db.getSavedNearest("nearest_school")
+}
+
+func (db *DB) getSavedNearest(prefix string) ([]models.SavedNearest, error) {
+	var saved []models.SavedNearest
+	err := db.Select(&saved, `
+		SELECT id, latitude, longitude,
+			`+prefix+`_1 AS name_1, `+prefix+`_1_km AS km_1,
+			COALESCE(`+prefix+`_2, '') AS name_2, `+prefix+`_2_km AS km_2
+		FROM properties
+		WHERE latitude IS NOT NULL AND longitude IS NOT NULL AND `+prefix+`_1 IS NOT NULL
+		ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get saved %ss: %w", prefix, err)
+	}
+	return saved, nil
+}
+
+// UpdatePropertyNearestTowns saves a property's two nearest towns and their distances
+func (db *DB) UpdatePropertyNearestTowns(propertyID int64, town1, town2 models.NearbyPlace) error {
+	// Walking and cycling times are only kept while they're to the same town
+	_, err := db.Exec(`
+		UPDATE properties
+		SET nearest_town_1 = ?, nearest_town_1_km = ?,
+		    nearest_town_2 = ?, nearest_town_2_km = ?,
+		    nearest_town_1_walk_mins = CASE WHEN nearest_town_1 IS ? THEN nearest_town_1_walk_mins END,
+		    nearest_town_1_cycle_mins = CASE WHEN nearest_town_1 IS ? THEN nearest_town_1_cycle_mins END
+		WHERE id = ?`,
+		town1.Name, town1.DistanceKm, town2.Name, town2.DistanceKm, town1.Name, town1.Name, propertyID)
+	return err
+}
+
+// UpdatePropertyTownWalkCycleTimes saves walking and cycling times to a
+// property's nearest town
+func (db *DB) UpdatePropertyTownWalkCycleTimes(propertyID int64, walkMins, cycleMins *int) error {
+	_, err := db.Exec(`
+		UPDATE properties
+		SET nearest_town_1_walk_mins = ?, nearest_town_1_cycle_mins = ?
+		WHERE id = ?`,
+		walkMins, cycleMins, propertyID)
+	return err
+}
+
+// UpdatePropertyTownDriveTimes saves drive times to a property's nearest towns
+func (db *DB) UpdatePropertyTownDriveTimes(propertyID int64, town1Mins, town2Mins *int) error {
+	_, err := db.Exec(`
+		UPDATE properties
+		SET nearest_town_1_mins = ?, nearest_town_2_mins = ?
+		WHERE id = ?`,
+		town1Mins, town2Mins, propertyID)
+	return err
+}
+
+// UpdatePropertyNearestSchools saves a property's two nearest schools,
+// including their coordinates for routing
+func (db *DB) UpdatePropertyNearestSchools(propertyID int64, school1, school2 models.NearbyPlace) error {
+	_, err := db.Exec(`
+		UPDATE properties
+		SET nearest_school_1 = ?, nearest_school_1_km = ?, nearest_school_1_lat = ?, nearest_school_1_lng = ?,
+		    nearest_school_2 = ?, nearest_school_2_km = ?, nearest_school_2_lat = ?, nearest_school_2_lng = ?
+		WHERE id = ?`,
+		school1.Name, school1.DistanceKm, school1.Latitude, school1.Longitude,
+		school2.Name, school2.DistanceKm, school2.Latitude, school2.Longitude, propertyID)
+	return err
+}
+
+// UpdatePropertySchoolDriveTimes saves drive times to a property's nearest
+// schools, and their coordinates where known (nil leaves them NULL)
+func (db *DB) UpdatePropertySchoolDriveTimes(propertyID int64, school1Mins, school2Mins *int, school1, school2 *models.NearbyPlace) error {
+	var school1Lat, school1Lng, school2Lat, school2Lng *float64
+	if school1 != nil {
+		school1Lat, school1Lng = &school1.Latitude, &school1.Longitude
+	}
+	if school2 != nil {
+		school2Lat, school2Lng = &school2.Latitude, &school2.Longitude
+	}
+
+	_, err := db.Exec(`
+		UPDATE properties
+		SET nearest_school_1_mins = ?, nearest_school_1_lat = ?, nearest_school_1_lng = ?,
+		    nearest_school_2_mins = ?, nearest_school_2_lat = ?, nearest_school_2_lng = ?
+		WHERE id = ?`,
+		school1Mins, school1Lat, school1Lng, school2Mins, school2Lat, school2Lng, propertyID)
+	return err
+}
+
+// UpdatePropertyAccessPoint saves a property's access point and where it was
+// found ('lot', 'point', or 'none' with nil coordinates)
+func (db *DB) UpdatePropertyAccessPoint(propertyID int64, lat, lng *float64, source string) error {
+	_, err := db.Exec(`
+		UPDATE properties
+		SET access_lat = ?, access_lng = ?, access_source = ?
+		WHERE id = ?`,
+		lat, lng, source, propertyID)
+	return err
+}
+
+// UpdatePropertyImageryLinks saves a property's Street View, aerial imagery
+// and Google Earth links
+func (db *DB) UpdatePropertyImageryLinks(propertyID int64, streetView, aerial, googleEarth string) error {
+	_, err := db.Exec(`
+		UPDATE properties
+		SET street_view_url = ?, aerial_url = ?, google_earth_url = ?
+		WHERE id = ?`,
+		streetView, aerial, googleEarth, propertyID)
+	return err
+}
+
+// UpdatePropertyClearing saves the change in a property's woody cover
+// (percentage points) and mean NDVI since the baseline year, and whether it's
+// flagged for clearing
+func (db *DB) UpdatePropertyClearing(propertyID int64, woodyCoverChange, ndviChange float64, baselineYear int, flagged bool) error {
+	_, err := db.Exec(`
+		UPDATE properties
+		SET woody_cover_change = ?, ndvi_change = ?, clearing_baseline_year = ?, clearing_flagged = ?
+		WHERE id = ?`,
+		woodyCoverChange, ndviChange, baselineYear, flagged, propertyID)
+	return err
+}
+
+// UpdatePropertyNDVI saves a property's mean NDVI, its seasonal range and the
+// monthly means (nil for months never observed) they're taken from
+func (db *DB) UpdatePropertyNDVI(propertyID int64, mean, seasonalRange float64, monthly [12]*float64) error {
+	monthlyJSON, err := json.Marshal(monthly)
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec(`
+		UPDATE properties
+		SET ndvi_mean = ?, ndvi_seasonal_range = ?, ndvi_monthly = ?
+		WHERE id = ?`,
+		mean, seasonalRange, string(monthlyJSON), propertyID)
+	return err
+}
+
+// UpdatePropertyNoiseDistances saves a property's distances to the nearest
+// highway, railway and runway (nil leaves them NULL)
+func (db *DB) UpdatePropertyNoiseDistances(propertyID int64, highwayKm, railwayKm, runwayKm *float64) error {
+	_, err := db.Exec(`
+		UPDATE properties
+		SET highway_km = ?, railway_km = ?, runway_km = ?
+		WHERE id = ?`,
+		highwayKm, railwayKm, runwayKm, propertyID)
+	return err
+}
+
+// SetPropertyCoordinates saves a property's coordinates with where they came
+// from, in one transaction clearing everything derived from the old ones:
+// drive times, nearest towns, schools and energy developments, distances
+// (including to noise sources), Local Land Services region and weed zones,
+// imagery links, cadastral lot links and the land value, heritage listing,
+// overlay coverage, fire history, vegetation change, NDVI, buildings,
+// minimum lot size and access point taken from those lots.
+// The enrichment steps then see the property as missing them. A pending
+// geocode review for the property is dropped, as it's been placed.
+func (db *DB) SetPropertyCoordinates(propertyID int64, lat, lng float64, source string, confidence float64) error {
+	tx, err := db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := setPropertyCoordinates(tx, propertyID, lat, lng, source, confidence); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit coordinates: %w", err)
+	}
+	return nil
+}
+
+// setPropertyCoordinates is SetPropertyCoordinates within tx
+func setPropertyCoordinates(tx *sqlx.Tx, propertyID int64, lat, lng float64, source string, confidence float64) error {
+	result, err := tx.Exec(`
+		UPDATE properties SET
+			latitude = ?, longitude = ?,
+			coord_source = ?, coord_confidence = ?, coord_updated_at = ?,
+			drive_time_primary = NULL,
+			nearest_town_1 = NULL, nearest_town_1_km = NULL, nearest_town_1_mins = NULL,
+			nearest_town_1_walk_mins = NULL, nearest_town_1_cycle_mins = NULL,
+			nearest_town_2 = NULL, nearest_town_2_km = NULL, nearest_town_2_mins = NULL,
+			nearest_school_1 = NULL, nearest_school_1_km = NULL, nearest_school_1_mins = NULL,
+			nearest_school_1_lat = NULL, nearest_school_1_lng = NULL,
+			nearest_school_2 = NULL, nearest_school_2_km = NULL, nearest_school_2_mins = NULL,
+			nearest_school_2_lat = NULL, nearest_school_2_lng = NULL,
+			nearest_wind_farm = NULL, nearest_wind_farm_status = NULL, nearest_wind_farm_km = NULL,
+			nearest_solar_farm = NULL, nearest_solar_farm_status = NULL, nearest_solar_farm_km = NULL,
+			highway_km = NULL, railway_km = NULL, runway_km = NULL,
+			lls_region = NULL, weed_zones = NULL,
+			land_value = NULL, land_value_base_date = NULL,
+			heritage_listing = NULL, koala_habitat_pct = NULL, biodiversity_pct = NULL,
+			last_burn_year = NULL, burn_count_30y = NULL,
+			street_view_url = NULL, aerial_url = NULL, google_earth_url = NULL,
+			woody_cover_change = NULL, ndvi_change = NULL, clearing_baseline_year = NULL, clearing_flagged = NULL,
+			ndvi_mean = NULL, ndvi_seasonal_range = NULL, ndvi_monthly = NULL,
+			building_count = NULL, building_area_sqm = NULL, largest_building_sqm = NULL, has_dwelling = NULL,
+			min_lot_size_sqm = NULL, subdivision_ratio = NULL,
+			access_lat = NULL, access_lng = NULL, access_source = NULL
+		WHERE id = ?
+	`, lat, lng, source, confidence, time.Now().UTC(), propertyID)
+	if err != nil {
+		return fmt.Errorf("failed to update coordinates: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("property %d not found", propertyID)
+	}
+
+	for _, query := range []string{
+		"DELETE FROM property_distances WHERE property_id = ?",
+		"DELETE FROM property_lots WHERE property_id = ?",
+		"DELETE FROM geocode_reviews WHERE property_id = ? AND status = 'pending'",
+	} {
+		if _, err := tx.Exec(query, propertyID); err != nil {
+			return fmt.Errorf("failed to clear derived data: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"farm-search/internal/models"
+)
+
+// GetPropertyEvents returns events for the given properties starting between
+// from and to, in start order
+func (db *DB) GetPropertyEvents(propertyIDs []int64, from, to time.Time) ([]models.PropertyEvent, error) {
+	if len(propertyIDs) == 0 {
+		return nil, nil
+	}
+
+	placeholders := make([]string, len(propertyIDs))
+	args := make([]interface{}, 0, len(propertyIDs)+2)
+	for i, id := range propertyIDs {
+		placeholders[i] = "?"
+		args = append(args, id)
+	}
+	args = append(args, from.UTC(), to.UTC())
+
+	var events []models.PropertyEvent
+	err := db.Select(&events, fmt.Sprintf(`
+		SELECT id, property_id, kind, starts_at, ends_at, location, source
+		FROM property_events
+		WHERE property_id IN (%s) AND starts_at >= ? AND starts_at < ?
+		ORDER BY starts_at
+	`, strings.Join(placeholders, ",")), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get property events: %w", err)
+	}
+	return events, nil
+}
+
+func nullTimeUTC(t sql.NullTime) sql.NullTime {
+	if t.Valid {
+		t.Time = t.Time.UTC()
+	}
+	return t
+}
@@ -0,0 +1,79 @@
+package db
+
+import (
+	"fmt"
+	"time"
+
+	"farm-search/internal/models"
+)
+
+// ReplaceExclusionLayer replaces an exclusion layer's features with a fresh
+// import, in one transaction. Returns the number saved.
+func (db *DB) ReplaceExclusionLayer(layer string, features []models.ExclusionFeature) (int, error) {
+	tx, err := db.Beginx()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM exclusion_features WHERE layer = ?", layer); err != nil {
+		return 0, fmt.Errorf("failed to clear exclusion layer: %w", err)
+	}
+
+	stmt, err := tx.Prepare(`
+		INSERT INTO exclusion_features (layer, name, geometry, imported_at)
+		VALUES (?, ?, ?, ?)
+	`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prepare exclusion feature insert: %w", err)
+	}
+	defer stmt.Close()
+
+	now := time.Now().UTC()
+	for _, f := range features {
+		if _, err := stmt.Exec(layer, f.Name, f.Geometry, now); err != nil {
+			return 0, fmt.Errorf("failed to save exclusion feature %s: %w", f.Name, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit exclusion layer: %w", err)
+	}
+	return len(features), nil
+}
+
+// ListExclusionLayers returns each imported exclusion layer with its number
+// of features, by name
+func (db *DB) ListExclusionLayers() ([]models.ExclusionLayer, error) {
+	layers := []models.ExclusionLayer{}
+	err := db.Select(&layers, `
+		SELECT layer, COUNT(*) AS features, imported_at
+		FROM exclusion_features GROUP BY layer ORDER BY layer
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list exclusion layers: %w", err)
+	}
+	return layers, nil
+}
+
+// GetExclusionFeatures returns an exclusion layer's features
+func (db *DB) GetExclusionFeatures(layer string) ([]models.ExclusionFeature, error) {
+	var features []models.ExclusionFeature
+	if err := db.Select(&features, "SELECT * FROM exclusion_features WHERE layer = ? ORDER BY id", layer); err != nil {
+		return nil, fmt.Errorf("failed to get exclusion features: %w", err)
+	}
+	return features, nil
+}
+
+// GetExclusionLayerVersion returns a string that changes whenever an
+// exclusion layer is reimported, for caching the geometries parsed from it
+func (db *DB) GetExclusionLayerVersion(layer string) (string, error) {
+	var version string
+	err := db.Get(&version, `
+		SELECT COUNT(*) || '|' || COALESCE(MAX(imported_at), '') FROM exclusion_features WHERE layer = ?
+	`, layer)
+	if err != nil {
+		return "", fmt.Errorf("failed to get exclusion layer version: %w", err)
+	}
+	return version, nil
+}
@@ -0,0 +1,120 @@
+package db
+
+import (
+	"fmt"
+	"time"
+
+	"farm-search/internal/models"
+)
+
+// ReplaceFireExtents replaces the fire history with a fresh import, in one
+// transaction, clearing the fires recorded on lots with it. Returns the
+// number saved.
+func (db *DB) ReplaceFireExtents(fires []models.FireExtent) (int, error) {
+	tx, err := db.Beginx()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, query := range []string{"DELETE FROM lot_fires", "DELETE FROM fire_extents"} {
+		if _, err := tx.Exec(query); err != nil {
+			return 0, fmt.Errorf("failed to clear fire history: %w", err)
+		}
+	}
+
+	stmt, err := tx.Prepare(`
+		INSERT INTO fire_extents (year, fire_type, name, geometry, imported_at)
+		VALUES (?, ?, ?, ?, ?)
+	`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prepare fire extent insert: %w", err)
+	}
+	defer stmt.Close()
+
+	now := time.Now().UTC()
+	for _, f := range fires {
+		if _, err := stmt.Exec(f.Year, f.FireType, f.Name, f.Geometry, now); err != nil {
+			return 0, fmt.Errorf("failed to save %d fire %s: %w", f.Year, f.Name, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit fire history: %w", err)
+	}
+	return len(fires), nil
+}
+
+// GetFireExtents returns every imported fire extent
+func (db *DB) GetFireExtents() ([]models.FireExtent, error) {
+	var fires []models.FireExtent
+	if err := db.Select(&fires, "SELECT * FROM fire_extents ORDER BY id"); err != nil {
+		return nil, fmt.Errorf("failed to get fire extents: %w", err)
+	}
+	return fires, nil
+}
+
+// GetFireExtentVersion returns a string that changes whenever the fire
+// history is reimported
+func (db *DB) GetFireExtentVersion() (string, error) {
+	var version string
+	err := db.Get(&version, `
+		SELECT COUNT(*) || '|' || COALESCE(MAX(imported_at), '') FROM fire_extents
+	`)
+	if err != nil {
+		return "", fmt.Errorf("failed to get fire extent version: %w", err)
+	}
+	return version, nil
+}
+
+// ReplaceLotFires replaces the fires recorded on a lot, from the percentage
+// of it each fire (by ID) burnt
+func (db *DB) ReplaceLotFires(lotID int64, burntPct map[int64]float64) error {
+	tx, err := db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM lot_fires WHERE lot_id = ?", lotID); err != nil {
+		return fmt.Errorf("failed to clear lot fires: %w", err)
+	}
+	for fireID, pct := range burntPct {
+		if _, err := tx.Exec("INSERT INTO lot_fires (lot_id, fire_id, burnt_pct) VALUES (?, ?, ?)",
+			lotID, fireID, pct); err != nil {
+			return fmt.Errorf("failed to save fire %d: %w", fireID, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit lot fires: %w", err)
+	}
+	return nil
+}
+
+// GetPropertyLotFires returns the past fires over a property's lots, most
+// recent first
+func (db *DB) GetPropertyLotFires(propertyID int64) ([]models.LotFire, error) {
+	var fires []models.LotFire
+	err := db.Select(&fires, `
+		SELECT cl.lot_id_string, f.year, f.fire_type, f.name, lf.burnt_pct
+		FROM lot_fires lf
+		JOIN fire_extents f ON f.id = lf.fire_id
+		JOIN cadastral_lots cl ON cl.id = lf.lot_id
+		JOIN property_lots pl ON pl.lot_id = cl.id
+		WHERE pl.property_id = ?
+		ORDER BY f.year DESC, cl.lot_id_string
+	`, propertyID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get lot fires: %w", err)
+	}
+	return fires, nil
+}
+
+// UpdatePropertyFireHistory saves a property's most recent burn year (nil if
+// never burnt) and the number of years in the last 30 it burnt
+func (db *DB) UpdatePropertyFireHistory(propertyID int64, lastBurnYear *int, burnCount int) error {
+	_, err := db.Exec("UPDATE properties SET last_burn_year = ?, burn_count_30y = ? WHERE id = ?",
+		lastBurnYear, burnCount, propertyID)
+	return err
+}
@@ -0,0 +1,78 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"farm-search/internal/models"
+)
+
+// GeocodeCacheEntry is an address tools geocode has looked up; Latitude and
+// Longitude are nil if no provider knew it
+type GeocodeCacheEntry struct {
+	Address    string    `db:"address"`
+	Provider   *string   `db:"provider"`
+	Latitude   *float64  `db:"latitude"`
+	Longitude  *float64  `db:"longitude"`
+	Confidence *float64  `db:"confidence"`
+	Match      *string   `db:"match"`
+	Ambiguous  bool      `db:"ambiguous"`
+	GeocodedAt time.Time `db:"geocoded_at"`
+}
+
+// geocodeCacheKey normalises an address so spacing and case don't matter
+func geocodeCacheKey(address string) string {
+	return strings.ToLower(strings.Join(strings.Fields(address), " "))
+}
+
+// GetPropertiesMissingCoordinates returns the properties without
+// coordinates, oldest first, up to limit (0 for all)
+func (db *DB) GetPropertiesMissingCoordinates(limit int) ([]models.Property, error) {
+	query := `
+		SELECT id, address, suburb, COALESCE(state, 'NSW') as state, postcode FROM properties
+		WHERE latitude IS NULL OR longitude IS NULL
+		ORDER BY id`
+	var args []interface{}
+	if limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, limit)
+	}
+	var properties []models.Property
+	if err := db.Select(&properties, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to get properties missing coordinates: %w", err)
+	}
+	return properties, nil
+}
+
+// GetGeocodeCache returns the cached lookup of an address, or nil if it
+// hasn't been looked up
+func (db *DB) GetGeocodeCache(address string) (*GeocodeCacheEntry, error) {
+	var e GeocodeCacheEntry
+	err := db.Get(&e, `
+		SELECT address, provider, latitude, longitude, confidence, match, ambiguous, geocoded_at
+		FROM geocode_cache WHERE address = ?
+	`, geocodeCacheKey(address))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cached geocode: %w", err)
+	}
+	return &e, nil
+}
+
+// SaveGeocodeCache caches an address's lookup, replacing any earlier one
+func (db *DB) SaveGeocodeCache(e *GeocodeCacheEntry) error {
+	e.Address = geocodeCacheKey(e.Address)
+	e.GeocodedAt = time.Now().UTC().Truncate(time.Second)
+	_, err := db.Exec(`
+		INSERT OR REPLACE INTO geocode_cache (address, provider, latitude, longitude, confidence, match, ambiguous, geocoded_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`, e.Address, e.Provider, e.Latitude, e.Longitude, e.Confidence, e.Match, e.Ambiguous, e.GeocodedAt)
+	if err != nil {
+		return fmt.Errorf("failed to cache geocode: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"farm-search/internal/models"
+)
+
+// Geocode review reasons
+const (
+	GeocodeNoLot           = "no_lot"
+	GeocodeAddressMismatch = "address_mismatch"
+)
+
+// sameGeocodeDegrees is how close a new geocoded point must be to a reviewed
+// one for the review to still apply (about 50 m)
+const sameGeocodeDegrees = 0.0005
+
+// geocodeReviewColumns selects a geocode_reviews row
+const geocodeReviewColumns = `
+	id, property_id, address, provider, latitude, longitude, confidence, reason,
+	lot_id_string, found_address, status, flagged_at, resolved_at
+`
+
+// FlagGeocodeReview queues a geocoded point for review instead of saving it,
+// replacing the property's earlier one, and returns the review's status. A
+// rejection stands if the point is the same as the one rejected; otherwise
+// it's pending again.
+func (db *DB) FlagGeocodeReview(r *models.GeocodeReview) (string, error) {
+	var status string
+	err := db.Get(&status, `
+		INSERT INTO geocode_reviews
+			(property_id, address, provider, latitude, longitude, confidence, reason, lot_id_string, found_address, status, flagged_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, 'pending', ?)
+		ON CONFLICT(property_id) DO UPDATE SET
+			status = CASE
+				WHEN status = 'rejected' AND ABS(latitude - excluded.latitude) < ? AND ABS(longitude - excluded.longitude) < ? THEN 'rejected'
+				ELSE 'pending' END,
+			resolved_at = CASE
+				WHEN status = 'rejected' AND ABS(latitude - excluded.latitude) < ? AND ABS(longitude - excluded.longitude) < ? THEN resolved_at
+				ELSE NULL END,
+			address = excluded.address,
+			provider = excluded.provider,
+			latitude = excluded.latitude,
+			longitude = excluded.longitude,
+			confidence = excluded.confidence,
+			reason = excluded.reason,
+			lot_id_string = excluded.lot_id_string,
+			found_address = excluded.found_address,
+			flagged_at = excluded.flagged_at
+		RETURNING status
+	`, r.PropertyID, r.Address, r.Provider, r.Latitude, r.Longitude, r.Confidence, r.Reason, r.LotIDString, r.FoundAddress,
+		time.Now().UTC(), sameGeocodeDegrees, sameGeocodeDegrees, sameGeocodeDegrees, sameGeocodeDegrees)
+	if err != nil {
+		return "", fmt.Errorf("failed to flag geocode: %w", err)
+	}
+	return status, nil
+}
+
+// ListGeocodeReviews returns geocode reviews, most recently flagged first.
+// An empty status returns all of them.
+func (db *DB) ListGeocodeReviews(status string, limit int) ([]models.GeocodeReview, error) {
+	query := `SELECT ` + geocodeReviewColumns + ` FROM geocode_reviews WHERE 1 = 1`
+	var args []interface{}
+	if status != "" {
+		query += " AND status = ?"
+		args = append(args, status)
+	}
+	query += " ORDER BY flagged_at DESC, id"
+	if limit > 0 {
+		query += fmt.Sprintf(" LIMIT %d", limit)
+	}
+
+	reviews := []models.GeocodeReview{}
+	if err := db.Select(&reviews, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to list geocode reviews: %w", err)
+	}
+	return reviews, nil
+}
+
+// GetGeocodeReview returns a geocode review, or nil if there's none with
+// that ID
+func (db *DB) GetGeocodeReview(id int64) (*models.GeocodeReview, error) {
+	var review models.GeocodeReview
+	err := db.Get(&review, `SELECT `+geocodeReviewColumns+` FROM geocode_reviews WHERE id = ?`, id)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get geocode review: %w", err)
+	}
+	return &review, nil
+}
+
+// AcceptGeocodeReview marks a geocoded point as right and saves it as the
+// property's coordinates (see SetPropertyCoordinates), in one transaction.
+// Returns false if there's no review with that ID.
+func (db *DB) AcceptGeocodeReview(id int64) (bool, error) {
+	tx, err := db.Beginx()
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var r models.GeocodeReview
+	err = tx.Get(&r, "SELECT id, property_id, latitude, longitude, confidence FROM geocode_reviews WHERE id = ?", id)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to get geocode review: %w", err)
+	}
+
+	if _, err := tx.Exec("UPDATE geocode_reviews SET status = 'accepted', resolved_at = ? WHERE id = ?",
+		time.Now().UTC(), id); err != nil {
+		return false, fmt.Errorf("failed to accept geocode: %w", err)
+	}
+	if err := setPropertyCoordinates(tx, r.PropertyID, r.Latitude, r.Longitude, "geocoder", r.Confidence); err != nil {
+		return false, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit geocode review: %w", err)
+	}
+	return true, nil
+}
+
+// RejectGeocodeReview marks a geocoded point as wrong, leaving the property
+// without coordinates until they're set by hand. Returns false if there's no
+// review with that ID.
+func (db *DB) RejectGeocodeReview(id int64) (bool, error) {
+	result, err := db.Exec("UPDATE geocode_reviews SET status = 'rejected', resolved_at = ? WHERE id = ?",
+		time.Now().UTC(), id)
+	if err != nil {
+		return false, fmt.Errorf("failed to reject geocode: %w", err)
+	}
+	n, _ := result.RowsAffected()
+	return n > 0, nil
+}
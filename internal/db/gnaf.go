@@ -0,0 +1,132 @@
+package db
+
+import (
+	"fmt"
+	"time"
+
+	"farm-search/internal/models"
+)
+
+// GNAFMatch is the G-NAF address a property was matched to
+type GNAFMatch struct {
+	PropertyID int64
+	PID        string
+	Kind       string // 'number', 'lot' or 'nearest' (see gnaf.Match)
+}
+
+// ReplaceGNAFAddresses replaces a state's G-NAF addresses with a fresh
+// import, in one transaction. Returns the number saved.
+func (db *DB) ReplaceGNAFAddresses(state string, addresses []models.GNAFAddress) (int, error) {
+	tx, err := db.Beginx()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM gnaf_addresses WHERE state = ?", state); err != nil {
+		return 0, fmt.Errorf("failed to clear G-NAF addresses: %w", err)
+	}
+
+	stmt, err := tx.Prepare(`
+		INSERT OR REPLACE INTO gnaf_addresses (
+			address_detail_pid, address, number_first, number_last, lot_number, street_name, street_type,
+			locality, state, postcode, legal_parcel_id, latitude, longitude, imported_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prepare G-NAF address insert: %w", err)
+	}
+	defer stmt.Close()
+
+	now := time.Now().UTC()
+	for _, a := range addresses {
+		if _, err := stmt.Exec(a.PID, a.Address, a.NumberFirst, a.NumberLast, a.LotNumber, a.StreetName, a.StreetType,
+			a.Locality, a.State, a.Postcode, a.LegalParcelID, a.Latitude, a.Longitude, now); err != nil {
+			return 0, fmt.Errorf("failed to save G-NAF address %s: %w", a.PID, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit G-NAF addresses: %w", err)
+	}
+	return len(addresses), nil
+}
+
+// GetPropertySuburbs returns the suburbs of a state's properties, upper case
+// as G-NAF has its localities
+func (db *DB) GetPropertySuburbs(state string) (map[string]bool, error) {
+	var suburbs []string
+	err := db.Select(&suburbs, `
+		SELECT DISTINCT UPPER(TRIM(suburb)) FROM properties
+		WHERE COALESCE(state, 'NSW') = ? AND TRIM(COALESCE(suburb, '')) != ''
+	`, state)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get property suburbs: %w", err)
+	}
+
+	result := make(map[string]bool, len(suburbs))
+	for _, s := range suburbs {
+		result[s] = true
+	}
+	return result, nil
+}
+
+// GetGNAFAddressesInLocality returns the G-NAF addresses in a state's
+// locality (upper case)
+func (db *DB) GetGNAFAddressesInLocality(state, locality string) ([]models.GNAFAddress, error) {
+	var addresses []models.GNAFAddress
+	err := db.Select(&addresses, `
+		SELECT address_detail_pid, address, number_first, number_last, lot_number, street_name, street_type,
+			locality, state, postcode, legal_parcel_id, latitude, longitude
+		FROM gnaf_addresses WHERE state = ? AND locality = ?
+		ORDER BY address_detail_pid
+	`, state, locality)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get G-NAF addresses: %w", err)
+	}
+	return addresses, nil
+}
+
+// GetPropertiesToMatchGNAF returns a state's properties with a street
+// address, by suburb
+func (db *DB) GetPropertiesToMatchGNAF(state string) ([]models.Property, error) {
+	var properties []models.Property
+	err := db.Select(&properties, `
+		SELECT id, address, suburb, COALESCE(state, 'NSW') as state, latitude, longitude FROM properties
+		WHERE COALESCE(state, 'NSW') = ? AND TRIM(COALESCE(address, '')) != '' AND TRIM(COALESCE(suburb, '')) != ''
+		ORDER BY UPPER(TRIM(suburb)), id
+	`, state)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get properties to match: %w", err)
+	}
+	return properties, nil
+}
+
+// SaveGNAFMatches replaces a state's properties' G-NAF matches, clearing
+// those of properties not in matches, in one transaction
+func (db *DB) SaveGNAFMatches(state string, matches []GNAFMatch) error {
+	tx, err := db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("UPDATE properties SET gnaf_pid = NULL, gnaf_match = NULL WHERE COALESCE(state, 'NSW') = ?", state); err != nil {
+		return fmt.Errorf("failed to clear G-NAF matches: %w", err)
+	}
+	stmt, err := tx.Prepare("UPDATE properties SET gnaf_pid = ?, gnaf_match = ? WHERE id = ?")
+	if err != nil {
+		return fmt.Errorf("failed to prepare G-NAF match update: %w", err)
+	}
+	defer stmt.Close()
+	for _, m := range matches {
+		if _, err := stmt.Exec(m.PID, m.Kind, m.PropertyID); err != nil {
+			return fmt.Errorf("failed to save G-NAF match for property %d: %w", m.PropertyID, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit G-NAF matches: %w", err)
+	}
+	return nil
+}
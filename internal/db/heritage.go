@@ -0,0 +1,71 @@
+package db
+
+import (
+	"fmt"
+	"time"
+
+	"farm-search/internal/models"
+)
+
+// ReplaceLotHeritageItems replaces the heritage items recorded on a lot and
+// marks it checked, in one transaction
+func (db *DB) ReplaceLotHeritageItems(lotID int64, items []models.HeritageItem) error {
+	tx, err := db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM lot_heritage_items WHERE lot_id = ?", lotID); err != nil {
+		return fmt.Errorf("failed to clear heritage items: %w", err)
+	}
+
+	stmt, err := tx.Prepare(`
+		INSERT OR IGNORE INTO lot_heritage_items (lot_id, listing, item_id, name, class)
+		VALUES (?, ?, ?, ?, ?)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare heritage item insert: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, item := range items {
+		if _, err := stmt.Exec(lotID, item.Listing, item.ItemID, item.Name, item.Class); err != nil {
+			return fmt.Errorf("failed to save heritage item %s: %w", item.ItemID, err)
+		}
+	}
+
+	if _, err := tx.Exec("UPDATE cadastral_lots SET heritage_checked_at = ? WHERE id = ?", time.Now().UTC(), lotID); err != nil {
+		return fmt.Errorf("failed to mark lot checked: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit heritage items: %w", err)
+	}
+	return nil
+}
+
+// GetPropertyHeritageItems returns the heritage items on a property's lots,
+// state listings first
+func (db *DB) GetPropertyHeritageItems(propertyID int64) ([]models.HeritageItem, error) {
+	var items []models.HeritageItem
+	err := db.Select(&items, `
+		SELECT cl.lot_id_string, h.listing, h.item_id,
+			COALESCE(h.name, '') as name, COALESCE(h.class, '') as class
+		FROM lot_heritage_items h
+		JOIN cadastral_lots cl ON cl.id = h.lot_id
+		JOIN property_lots pl ON pl.lot_id = cl.id
+		WHERE pl.property_id = ?
+		ORDER BY h.listing = 'state' DESC, h.name, cl.lot_id_string
+	`, propertyID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get heritage items: %w", err)
+	}
+	return items, nil
+}
+
+// UpdatePropertyHeritage saves a property's most restrictive heritage listing
+func (db *DB) UpdatePropertyHeritage(propertyID int64, listing string) error {
+	_, err := db.Exec("UPDATE properties SET heritage_listing = ? WHERE id = ?", listing, propertyID)
+	return err
+}
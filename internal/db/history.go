@@ -0,0 +1,113 @@
+package db
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"farm-search/internal/models"
+)
+
+// historyColumns are the listing fields property_history keeps versions of
+const historyColumns = "price_min, price_max, price_text, property_type, land_size_sqm"
+
+// seedHistoryQuery starts the history of listings that have none from their
+// current values, as of when they were first seen
+const seedHistoryQuery = `
+	INSERT INTO property_history (property_id, valid_from, ` + historyColumns + `)
+	SELECT id, COALESCE(first_seen_at, scraped_at), ` + historyColumns + `
+	FROM properties p
+	WHERE NOT EXISTS (SELECT 1 FROM property_history h WHERE h.property_id = p.id)`
+
+// historyStatements keep listing history as SaveProperties saves listings
+type historyStatements struct {
+	end        *sqlx.Stmt // Ends the latest version
+	endChanged *sqlx.Stmt // Ends the latest version if the listing no longer matches it
+	start      *sqlx.Stmt // Starts a version from the listing if it has none open
+}
+
+func prepareHistory(b *batchStatements) historyStatements {
+	return historyStatements{
+		end: b.prepare("UPDATE property_history SET valid_to = ? WHERE property_id = ? AND valid_to IS NULL"),
+		endChanged: b.prepare(`
+			UPDATE property_history SET valid_to = ?
+			FROM properties p
+			WHERE property_history.property_id = ? AND property_history.valid_to IS NULL
+				AND p.id = property_history.property_id
+				AND (p.price_min IS NOT property_history.price_min
+					OR p.price_max IS NOT property_history.price_max
+					OR p.price_text IS NOT property_history.price_text
+					OR p.property_type IS NOT property_history.property_type
+					OR p.land_size_sqm IS NOT property_history.land_size_sqm)
+		`),
+		start: b.prepare(`
+			INSERT INTO property_history (property_id, valid_from, ` + historyColumns + `)
+			SELECT id, ?, ` + historyColumns + ` FROM properties p
+			WHERE id = ? AND NOT EXISTS (
+				SELECT 1 FROM property_history h WHERE h.property_id = p.id AND h.valid_to IS NULL
+			)
+		`),
+	}
+}
+
+// record versions a saved listing: its latest version ends when it was last
+// scraped if it's back on the market (offMarketSince, zero if it isn't), or
+// now if the save changed it, and a version from now starts in its place
+func (h historyStatements) record(propertyID int64, now, offMarketSince time.Time) error {
+	if !offMarketSince.IsZero() {
+		if _, err := h.end.Exec(offMarketSince.UTC(), propertyID); err != nil {
+			return err
+		}
+	}
+	if _, err := h.endChanged.Exec(now, propertyID); err != nil {
+		return err
+	}
+	_, err := h.start.Exec(now, propertyID)
+	return err
+}
+
+// GetListingHistory returns every listing's versions, oldest first per
+// listing, attributed to canonical properties. A latest version runs on
+// (ValidTo nil) while its listing is in its source's scrapes; one whose
+// listing has been missing from them for offMarketDays ends when it was
+// last scraped. Pruned listings keep their history, attributed as when they
+// were pruned, and are off the market.
+func (db *DB) GetListingHistory() ([]models.ListingVersion, error) {
+	var rows []struct {
+		models.ListingVersion
+		ScrapedAt       *time.Time `db:"scraped_at"`
+		PrunedScrapedAt *time.Time `db:"pruned_scraped_at"`
+		OffMarket       bool       `db:"off_market"`
+	}
+	err := db.Select(&rows, fmt.Sprintf(`
+		SELECT COALESCE(pl.canonical_id, pr.canonical_id, h.property_id) AS property_id, h.property_id AS listing_id,
+			COALESCE(p.source, pr.source) AS source,
+			h.valid_from, h.valid_to, h.price_min, h.price_max, h.price_text, h.property_type, h.land_size_sqm,
+			p.scraped_at, pr.scraped_at AS pruned_scraped_at,
+			COALESCE(substr(p.scraped_at, 1, 10) < date(s.last_scraped, '-%d days'), 0) AS off_market
+		FROM property_history h
+		LEFT JOIN properties p ON p.id = h.property_id
+		LEFT JOIN pruned_listings pr ON pr.property_id = h.property_id AND p.id IS NULL
+		LEFT JOIN property_links pl ON pl.duplicate_id = h.property_id
+		LEFT JOIN (
+			SELECT source, MAX(substr(scraped_at, 1, 10)) AS last_scraped FROM properties GROUP BY source
+		) s ON s.source = p.source
+		WHERE p.id IS NOT NULL OR pr.property_id IS NOT NULL
+		ORDER BY h.property_id, h.valid_from, h.id
+	`, offMarketDays))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get listing history: %w", err)
+	}
+
+	versions := make([]models.ListingVersion, len(rows))
+	for i, r := range rows {
+		versions[i] = r.ListingVersion
+		if r.ValidTo == nil && r.OffMarket {
+			versions[i].ValidTo = r.ScrapedAt
+		} else if r.ValidTo == nil && r.PrunedScrapedAt != nil {
+			versions[i].ValidTo = r.PrunedScrapedAt
+		}
+	}
+	return versions, nil
+}
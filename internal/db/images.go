@@ -0,0 +1,37 @@
+package db
+
+import (
+	"fmt"
+
+	"farm-search/internal/models"
+)
+
+// GetPropertyMedia returns the video and floorplan URLs of a property and the
+// listings linked to it as duplicates, its own first, each URL once
+func (db *DB) GetPropertyMedia(propertyID int64) (videos, floorplans []string, err error) {
+	var media []models.PropertyMedia
+	err = db.Select(&media, `
+		SELECT kind, url FROM property_images
+		WHERE property_id = ?
+			OR property_id IN (SELECT duplicate_id FROM property_links WHERE canonical_id = ?)
+		ORDER BY property_id != ?, property_id, position
+	`, propertyID, propertyID, propertyID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get property media: %w", err)
+	}
+
+	seen := make(map[string]bool)
+	for _, m := range media {
+		if seen[m.URL] {
+			continue
+		}
+		seen[m.URL] = true
+		switch m.Kind {
+		case "video":
+			videos = append(videos, m.URL)
+		case "floorplan":
+			floorplans = append(floorplans, m.URL)
+		}
+	}
+	return videos, floorplans, nil
+}
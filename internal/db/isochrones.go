@@ -0,0 +1,36 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// GetCachedIsochrone returns the GeoJSON of an isochrone generated since
+// notBefore, or "" if there's none
+func (db *DB) GetCachedIsochrone(lat, lng float64, minutes int, notBefore time.Time) (string, error) {
+	var geojson string
+	err := db.Get(&geojson, `
+		SELECT geojson FROM isochrones
+		WHERE lat = ? AND lng = ? AND minutes = ? AND created_at >= ?
+	`, lat, lng, minutes, notBefore.UTC())
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get cached isochrone: %w", err)
+	}
+	return geojson, nil
+}
+
+// SaveIsochrone caches an isochrone's GeoJSON, replacing any older one
+func (db *DB) SaveIsochrone(lat, lng float64, minutes int, geojson string) error {
+	_, err := db.Exec(`
+		INSERT INTO isochrones (lat, lng, minutes, geojson, created_at) VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(lat, lng, minutes) DO UPDATE SET geojson = excluded.geojson, created_at = excluded.created_at
+	`, lat, lng, minutes, geojson, time.Now().UTC())
+	if err != nil {
+		return fmt.Errorf("failed to cache isochrone: %w", err)
+	}
+	return nil
+}
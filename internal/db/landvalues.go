@@ -0,0 +1,124 @@
+package db
+
+import (
+	"fmt"
+
+	"farm-search/internal/models"
+)
+
+// askingVsLandValueExpr is a property's asking price (midpoint of its price
+// range) divided by its land value, NULL if either is missing
+const askingVsLandValueExpr = `CASE WHEN p.land_value > 0 AND p.price_min IS NOT NULL
+	THEN (p.price_min + COALESCE(p.price_max, p.price_min)) / 2.0 / p.land_value END`
+
+// UpsertLandValue inserts or updates a lot's land value, keeping the value with
+// the latest base date
+func (db *DB) UpsertLandValue(lv *models.LandValueRecord) error {
+	query := `
+		INSERT INTO land_values (
+			lot_id_string, vg_property_id, district_code, address, locality,
+			postcode, zone_code, area_sqm, land_value, base_date, lot_count, imported_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(lot_id_string) DO UPDATE SET
+			vg_property_id = excluded.vg_property_id,
+			district_code = excluded.district_code,
+			address = excluded.address,
+			locality = excluded.locality,
+			postcode = excluded.postcode,
+			zone_code = excluded.zone_code,
+			area_sqm = excluded.area_sqm,
+			land_value = excluded.land_value,
+			base_date = excluded.base_date,
+			lot_count = excluded.lot_count,
+			imported_at = excluded.imported_at
+		WHERE COALESCE(excluded.base_date, '') >= COALESCE(land_values.base_date, '')
+	`
+
+	_, err := db.Exec(query,
+		lv.LotIDString, lv.VGPropertyID, lv.DistrictCode, lv.Address, lv.Locality,
+		lv.Postcode, lv.ZoneCode, lv.AreaSqm, lv.LandValue, lv.BaseDate, lv.LotCount, lv.ImportedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to upsert land value: %w", err)
+	}
+	return nil
+}
+
+// UpdatePropertyLandValues totals the land values of each property's lots into
+// properties.land_value. A VG property covering several of the lots is only
+// counted once. Returns the number of properties updated.
+func (db *DB) UpdatePropertyLandValues() (int, error) {
+	var rows []struct {
+		PropertyID   int64   `db:"property_id"`
+		VGPropertyID string  `db:"vg_property_id"`
+		LandValue    int64   `db:"land_value"`
+		BaseDate     *string `db:"base_date"`
+	}
+	err := db.Select(&rows, `
+		SELECT DISTINCT pl.property_id, lv.vg_property_id, lv.land_value, lv.base_date
+		FROM property_lots pl
+		JOIN cadastral_lots cl ON cl.id = pl.lot_id
+		JOIN land_values lv ON lv.lot_id_string = cl.lot_id_string
+		ORDER BY pl.property_id
+	`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get lot land values: %w", err)
+	}
+
+	type total struct {
+		value    int64
+		baseDate string
+	}
+	totals := make(map[int64]*total)
+	var order []int64
+	seen := make(map[string]bool)
+	for _, r := range rows {
+		key := fmt.Sprintf("%d|%s", r.PropertyID, r.VGPropertyID)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		t, ok := totals[r.PropertyID]
+		if !ok {
+			t = &total{}
+			totals[r.PropertyID] = t
+			order = append(order, r.PropertyID)
+		}
+		t.value += r.LandValue
+		if r.BaseDate != nil && *r.BaseDate > t.baseDate {
+			t.baseDate = *r.BaseDate
+		}
+	}
+
+	for _, id := range order {
+		t := totals[id]
+		var baseDate interface{}
+		if t.baseDate != "" {
+			baseDate = t.baseDate
+		}
+		if _, err := db.Exec("UPDATE properties SET land_value = ?, land_value_base_date = ? WHERE id = ?",
+			t.value, baseDate, id); err != nil {
+			return 0, fmt.Errorf("failed to update property land value: %w", err)
+		}
+	}
+
+	return len(order), nil
+}
+
+// GetPropertyLotLandValues returns the land value of each of a property's lots
+func (db *DB) GetPropertyLotLandValues(propertyID int64) ([]models.LotLandValue, error) {
+	var values []models.LotLandValue
+	err := db.Select(&values, `
+		SELECT lv.lot_id_string, lv.land_value, COALESCE(lv.base_date, '') as base_date, lv.lot_count
+		FROM land_values lv
+		JOIN cadastral_lots cl ON cl.lot_id_string = lv.lot_id_string
+		JOIN property_lots pl ON pl.lot_id = cl.id
+		WHERE pl.property_id = ?
+		ORDER BY lv.lot_id_string
+	`, propertyID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get lot land values: %w", err)
+	}
+	return values, nil
+}
@@ -0,0 +1,427 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"time"
+
+	"farm-search/internal/geo"
+	"farm-search/internal/models"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// propertyLinkColumns selects a property_links row (as pl) with both
+// listings' source and address, joined as c (canonical) and d (duplicate)
+const propertyLinkColumns = `
+	pl.canonical_id, pl.duplicate_id, pl.match_type, pl.created_at, pl.confirmed_at,
+	c.source AS canonical_source,
+	TRIM(COALESCE(c.address, '') || ', ' || COALESCE(c.suburb, ''), ', ') AS canonical_address,
+	d.source AS duplicate_source,
+	TRIM(COALESCE(d.address, '') || ', ' || COALESCE(d.suburb, ''), ', ') AS duplicate_address
+`
+
+// ListPropertyLinks returns duplicate links, newest first. With propertyID,
+// only links where it is the canonical or duplicate listing; with
+// unconfirmedOnly, only links nobody has confirmed yet.
+func (db *DB) ListPropertyLinks(propertyID int64, unconfirmedOnly bool, limit int) ([]models.PropertyLink, error) {
+	query := `SELECT ` + propertyLinkColumns + ` FROM property_links pl
+		JOIN properties c ON c.id = pl.canonical_id
+		JOIN properties d ON d.id = pl.duplicate_id
+		WHERE 1 = 1`
+	var args []interface{}
+	if propertyID > 0 {
+		query += " AND (pl.canonical_id = ? OR pl.duplicate_id = ?)"
+		args = append(args, propertyID, propertyID)
+	}
+	if unconfirmedOnly {
+		query += " AND pl.confirmed_at IS NULL"
+	}
+	query += " ORDER BY pl.created_at DESC, pl.duplicate_id DESC"
+	if limit > 0 {
+		query += fmt.Sprintf(" LIMIT %d", limit)
+	}
+
+	links := []models.PropertyLink{}
+	if err := db.Select(&links, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to list property links: %w", err)
+	}
+	return links, nil
+}
+
+// GetPropertyLink returns the link making duplicateID a duplicate, or nil if
+// it isn't one
+func (db *DB) GetPropertyLink(duplicateID int64) (*models.PropertyLink, error) {
+	var link models.PropertyLink
+	err := db.Get(&link, `SELECT `+propertyLinkColumns+` FROM property_links pl
+		JOIN properties c ON c.id = pl.canonical_id
+		JOIN properties d ON d.id = pl.duplicate_id
+		WHERE pl.duplicate_id = ?`, duplicateID)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get property link: %w", err)
+	}
+	return &link, nil
+}
+
+// CreatePropertyLink manually links duplicateID to canonicalID as a confirmed
+// 'manual' link, in one transaction. canonicalID must not itself be a
+// duplicate, except of duplicateID, in which case the two swap roles. A link
+// duplicateID already has to another canonical property is replaced, and
+// listings linked to duplicateID move to canonicalID. Any earlier rejection of
+// the pair is cleared.
+//
+// Fields merged from a listing whose link changes are put back first, so run
+// MergeDuplicateProperties afterwards to merge onto the new canonical property.
+func (db *DB) CreatePropertyLink(canonicalID, duplicateID int64, note string) error {
+	if canonicalID == duplicateID {
+		return fmt.Errorf("a property can't be a duplicate of itself")
+	}
+
+	tx, err := db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Swapping roles: the new canonical stops being duplicateID's duplicate
+	if _, err := unlinkDuplicate(tx, duplicateID, canonicalID); err != nil {
+		return err
+	}
+	var canonicalOf int64
+	err = tx.Get(&canonicalOf, "SELECT canonical_id FROM property_links WHERE duplicate_id = ?", canonicalID)
+	if err == nil {
+		return fmt.Errorf("property %d is a duplicate of %d", canonicalID, canonicalOf)
+	}
+	if err != sql.ErrNoRows {
+		return fmt.Errorf("failed to get existing link: %w", err)
+	}
+
+	// Listings linked to duplicateID now belong to canonicalID
+	var moved []int64
+	if err := tx.Select(&moved, "SELECT duplicate_id FROM property_links WHERE canonical_id = ?", duplicateID); err != nil {
+		return fmt.Errorf("failed to get duplicates of %d: %w", duplicateID, err)
+	}
+	for _, id := range moved {
+		if err := unmergeDuplicate(tx, duplicateID, id); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.Exec("UPDATE property_links SET canonical_id = ? WHERE canonical_id = ?",
+		canonicalID, duplicateID); err != nil {
+		return fmt.Errorf("failed to move duplicates of %d: %w", duplicateID, err)
+	}
+
+	var previous sql.NullInt64
+	err = tx.Get(&previous, "SELECT canonical_id FROM property_links WHERE duplicate_id = ?", duplicateID)
+	if err != nil && err != sql.ErrNoRows {
+		return fmt.Errorf("failed to get existing link: %w", err)
+	}
+	if previous.Valid {
+		if err := unmergeDuplicate(tx, previous.Int64, duplicateID); err != nil {
+			return err
+		}
+	}
+
+	now := time.Now().UTC()
+	if _, err := tx.Exec(`
+		INSERT INTO property_links (canonical_id, duplicate_id, match_type, created_at, confirmed_at)
+		VALUES (?, ?, 'manual', ?, ?)
+		ON CONFLICT(duplicate_id) DO UPDATE SET
+			canonical_id = excluded.canonical_id,
+			match_type = excluded.match_type,
+			created_at = excluded.created_at,
+			confirmed_at = excluded.confirmed_at
+	`, canonicalID, duplicateID, now, now); err != nil {
+		return fmt.Errorf("failed to create property link: %w", err)
+	}
+
+	a, b := linkPair(canonicalID, duplicateID)
+	if _, err := tx.Exec("DELETE FROM property_link_rejections WHERE property_id_a = ? AND property_id_b = ?", a, b); err != nil {
+		return fmt.Errorf("failed to clear link rejection: %w", err)
+	}
+
+	if err := auditPropertyLink(tx, "create", canonicalID, duplicateID, previous, "manual", note, now); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit property link: %w", err)
+	}
+	return nil
+}
+
+// ConfirmPropertyLink marks duplicateID's link as checked by a person,
+// reporting whether it had one
+func (db *DB) ConfirmPropertyLink(duplicateID int64, note string) (bool, error) {
+	tx, err := db.Beginx()
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var link struct {
+		CanonicalID int64  `db:"canonical_id"`
+		MatchType   string `db:"match_type"`
+	}
+	err = tx.Get(&link, "SELECT canonical_id, match_type FROM property_links WHERE duplicate_id = ?", duplicateID)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to get property link: %w", err)
+	}
+
+	now := time.Now().UTC()
+	if _, err := tx.Exec("UPDATE property_links SET confirmed_at = ? WHERE duplicate_id = ?", now, duplicateID); err != nil {
+		return false, fmt.Errorf("failed to confirm property link: %w", err)
+	}
+	if err := auditPropertyLink(tx, "confirm", link.CanonicalID, duplicateID, sql.NullInt64{}, link.MatchType, note, now); err != nil {
+		return false, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit property link: %w", err)
+	}
+	return true, nil
+}
+
+// RejectPropertyLink removes duplicateID's link, puts back the fields merged
+// from it onto its canonical property, and records the pair as rejected so
+// duplicate detection doesn't link them again. Reports whether it had a link.
+func (db *DB) RejectPropertyLink(duplicateID int64, note string) (bool, error) {
+	tx, err := db.Beginx()
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var link struct {
+		CanonicalID int64  `db:"canonical_id"`
+		MatchType   string `db:"match_type"`
+	}
+	err = tx.Get(&link, "SELECT canonical_id, match_type FROM property_links WHERE duplicate_id = ?", duplicateID)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to get property link: %w", err)
+	}
+
+	if _, err := unlinkDuplicate(tx, link.CanonicalID, duplicateID); err != nil {
+		return false, err
+	}
+
+	now := time.Now().UTC()
+	a, b := linkPair(link.CanonicalID, duplicateID)
+	if _, err := tx.Exec(`
+		INSERT INTO property_link_rejections (property_id_a, property_id_b, note, rejected_at)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(property_id_a, property_id_b) DO UPDATE SET
+			note = excluded.note,
+			rejected_at = excluded.rejected_at
+	`, a, b, nullString(note), now); err != nil {
+		return false, fmt.Errorf("failed to record link rejection: %w", err)
+	}
+	if err := auditPropertyLink(tx, "reject", link.CanonicalID, duplicateID, sql.NullInt64{}, link.MatchType, note, now); err != nil {
+		return false, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit property link: %w", err)
+	}
+	return true, nil
+}
+
+// DuplicateLandSizeRatio is how many times larger one listing's land size
+// can be than the other's for them to be the same property
+const DuplicateLandSizeRatio = 1.25
+
+// similarLandSize is the condition that properties p1 and p2 have similar
+// land sizes, or either is unknown
+var similarLandSize = fmt.Sprintf(`(p1.land_size_sqm IS NULL OR p2.land_size_sqm IS NULL
+		OR MAX(p1.land_size_sqm, p2.land_size_sqm) <= %g * MIN(p1.land_size_sqm, p2.land_size_sqm))`, DuplicateLandSizeRatio)
+
+// GetDuplicateSuspects returns pairs of listings from different sources
+// within maxKm of each other with similar land sizes (see
+// DuplicateLandSizeRatio) that duplicate detection hasn't linked: neither is
+// a duplicate, and the pair hasn't been rejected. Closest first, at most
+// limit (0 for all).
+func (db *DB) GetDuplicateSuspects(maxKm float64, limit int) ([]models.DuplicateSuspect, error) {
+	// A bounding box to narrow the join; a degree of longitude is at least
+	// 2/3 of a degree of latitude up to 48°
+	latDeg := maxKm / 111.0
+	lngDeg := latDeg * 1.5
+
+	var candidates []models.DuplicateSuspect
+	err := db.Select(&candidates, `
+		SELECT p1.id AS canonical_id, p2.id AS duplicate_id,
+			p1.source AS canonical_source,
+			TRIM(COALESCE(p1.address, '') || ', ' || COALESCE(p1.suburb, ''), ', ') AS canonical_address,
+			p1.land_size_sqm AS canonical_land_size_sqm,
+			p1.latitude AS canonical_lat, p1.longitude AS canonical_lng,
+			p2.source AS duplicate_source,
+			TRIM(COALESCE(p2.address, '') || ', ' || COALESCE(p2.suburb, ''), ', ') AS duplicate_address,
+			p2.land_size_sqm AS duplicate_land_size_sqm,
+			p2.latitude AS duplicate_lat, p2.longitude AS duplicate_lng
+		FROM properties p1
+		JOIN properties p2 ON p1.id < p2.id
+			AND p1.source != p2.source
+			AND p2.latitude BETWEEN p1.latitude - ? AND p1.latitude + ?
+			AND p2.longitude BETWEEN p1.longitude - ? AND p1.longitude + ?
+		WHERE p1.latitude IS NOT NULL AND p1.longitude IS NOT NULL
+			AND p2.latitude IS NOT NULL AND p2.longitude IS NOT NULL
+			AND `+similarLandSize+`
+			AND NOT EXISTS (SELECT 1 FROM property_links pl WHERE pl.duplicate_id IN (p1.id, p2.id))
+			AND NOT EXISTS (SELECT 1 FROM property_link_rejections r
+				WHERE r.property_id_a = p1.id AND r.property_id_b = p2.id)
+	`, latDeg, latDeg, lngDeg, lngDeg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find duplicate suspects: %w", err)
+	}
+
+	suspects := []models.DuplicateSuspect{}
+	for _, s := range candidates {
+		km := geo.Haversine(s.CanonicalLat, s.CanonicalLng, s.DuplicateLat, s.DuplicateLng)
+		if km <= maxKm {
+			s.DistanceM = km * 1000
+			suspects = append(suspects, s)
+		}
+	}
+	sort.SliceStable(suspects, func(i, j int) bool { return suspects[i].DistanceM < suspects[j].DistanceM })
+	if limit > 0 && len(suspects) > limit {
+		suspects = suspects[:limit]
+	}
+	return suspects, nil
+}
+
+// RejectDuplicateSuspect records two unlinked listings as not the same
+// property, so duplicate detection and the suspect queue leave the pair
+// alone. A link between them isn't removed; see RejectPropertyLink.
+func (db *DB) RejectDuplicateSuspect(canonicalID, duplicateID int64, note string) error {
+	tx, err := db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now().UTC()
+	a, b := linkPair(canonicalID, duplicateID)
+	if _, err := tx.Exec(`
+		INSERT INTO property_link_rejections (property_id_a, property_id_b, note, rejected_at)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(property_id_a, property_id_b) DO UPDATE SET
+			note = excluded.note,
+			rejected_at = excluded.rejected_at
+	`, a, b, nullString(note), now); err != nil {
+		return fmt.Errorf("failed to record link rejection: %w", err)
+	}
+	if err := auditPropertyLink(tx, "reject", canonicalID, duplicateID, sql.NullInt64{}, "suspect", note, now); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit link rejection: %w", err)
+	}
+	return nil
+}
+
+// GetPropertyLinkAudit returns manual link changes, newest first. With
+// propertyID, only changes involving it.
+func (db *DB) GetPropertyLinkAudit(propertyID int64, limit int) ([]models.PropertyLinkAudit, error) {
+	query := `
+		SELECT id, action, canonical_id, duplicate_id, previous_canonical_id,
+			COALESCE(match_type, '') AS match_type, COALESCE(note, '') AS note, created_at
+		FROM property_link_audit`
+	var args []interface{}
+	if propertyID > 0 {
+		query += " WHERE canonical_id = ? OR duplicate_id = ? OR previous_canonical_id = ?"
+		args = append(args, propertyID, propertyID, propertyID)
+	}
+	query += " ORDER BY id DESC"
+	if limit > 0 {
+		query += fmt.Sprintf(" LIMIT %d", limit)
+	}
+
+	entries := []models.PropertyLinkAudit{}
+	if err := db.Select(&entries, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to get property link audit: %w", err)
+	}
+	return entries, nil
+}
+
+// unlinkDuplicate removes the link making duplicateID a duplicate of
+// canonicalID, putting back the fields merged from it, and reports whether
+// there was one
+func unlinkDuplicate(tx *sqlx.Tx, canonicalID, duplicateID int64) (bool, error) {
+	if err := unmergeDuplicate(tx, canonicalID, duplicateID); err != nil {
+		return false, err
+	}
+	result, err := tx.Exec("DELETE FROM property_links WHERE canonical_id = ? AND duplicate_id = ?", canonicalID, duplicateID)
+	if err != nil {
+		return false, fmt.Errorf("failed to remove property link: %w", err)
+	}
+	n, _ := result.RowsAffected()
+	return n > 0, nil
+}
+
+// unmergeDuplicate restores the canonical property's own values for fields
+// merged onto it from duplicateID and drops their provenance. A field the
+// canonical listing's scraper has set since keeps its new value.
+func unmergeDuplicate(tx *sqlx.Tx, canonicalID, duplicateID int64) error {
+	var merged []struct {
+		Field         string      `db:"field"`
+		PreviousValue interface{} `db:"previous_value"`
+	}
+	if err := tx.Select(&merged, `
+		SELECT field, previous_value FROM property_field_sources
+		WHERE property_id = ? AND source_property_id = ?
+	`, canonicalID, duplicateID); err != nil {
+		return fmt.Errorf("failed to get merged fields: %w", err)
+	}
+
+	for _, m := range merged {
+		if !isMergeField(m.Field) {
+			continue
+		}
+		if b, ok := m.PreviousValue.([]byte); ok {
+			m.PreviousValue = string(b)
+		}
+		query := fmt.Sprintf(`UPDATE properties SET %[1]s = ? WHERE id = ?
+			AND %[1]s IS (SELECT %[1]s FROM properties WHERE id = ?)`, m.Field)
+		if _, err := tx.Exec(query, m.PreviousValue, canonicalID, duplicateID); err != nil {
+			return fmt.Errorf("failed to restore %s on %d: %w", m.Field, canonicalID, err)
+		}
+	}
+
+	if _, err := tx.Exec("DELETE FROM property_field_sources WHERE property_id = ? AND source_property_id = ?",
+		canonicalID, duplicateID); err != nil {
+		return fmt.Errorf("failed to clear merge provenance: %w", err)
+	}
+	return nil
+}
+
+func auditPropertyLink(tx *sqlx.Tx, action string, canonicalID, duplicateID int64, previousCanonicalID sql.NullInt64, matchType, note string, at time.Time) error {
+	if _, err := tx.Exec(`
+		INSERT INTO property_link_audit (action, canonical_id, duplicate_id, previous_canonical_id, match_type, note, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, action, canonicalID, duplicateID, previousCanonicalID, matchType, nullString(note), at); err != nil {
+		return fmt.Errorf("failed to record property link audit: %w", err)
+	}
+	return nil
+}
+
+// linkPair orders two property IDs as stored in property_link_rejections
+func linkPair(id1, id2 int64) (int64, int64) {
+	if id1 < id2 {
+		return id1, id2
+	}
+	return id2, id1
+}
+
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
@@ -0,0 +1,86 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+
+	"farm-search/internal/models"
+)
+
+// GetLot returns a cadastral lot by its lot_id_string with its land value,
+// zoning, heritage items, overlays, past fires and the canonical properties
+// linked to it. Nil if the lot hasn't been fetched.
+func (db *DB) GetLot(lotIDString string) (*models.LotDetail, error) {
+	var lot models.LotDetail
+	err := db.Get(&lot, `
+		SELECT cl.*, lv.land_value, lv.zone_code, lv.base_date as land_value_base_date
+		FROM cadastral_lots cl
+		LEFT JOIN land_values lv ON lv.lot_id_string = cl.lot_id_string
+		WHERE cl.lot_id_string = ?
+	`, lotIDString)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get lot: %w", err)
+	}
+
+	// Empty rather than nil, so they encode as []
+	lot.HeritageItems = []models.HeritageItem{}
+	lot.Overlays = []models.LotOverlay{}
+	lot.Fires = []models.LotFire{}
+	lot.Properties = []models.PropertyListItem{}
+
+	err = db.Select(&lot.HeritageItems, `
+		SELECT ? as lot_id_string, listing, item_id, COALESCE(name, '') as name, COALESCE(class, '') as class
+		FROM lot_heritage_items
+		WHERE lot_id = ?
+		ORDER BY listing = 'state' DESC, name
+	`, lot.LotIDString, lot.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get lot heritage items: %w", err)
+	}
+
+	err = db.Select(&lot.Overlays, `
+		SELECT ? as lot_id_string, overlay, affected_pct
+		FROM lot_overlays
+		WHERE lot_id = ?
+		ORDER BY overlay
+	`, lot.LotIDString, lot.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get lot overlays: %w", err)
+	}
+
+	err = db.Select(&lot.Fires, `
+		SELECT ? as lot_id_string, f.year, f.fire_type, f.name, lf.burnt_pct
+		FROM lot_fires lf
+		JOIN fire_extents f ON f.id = lf.fire_id
+		WHERE lf.lot_id = ?
+		ORDER BY f.year DESC
+	`, lot.LotIDString, lot.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get lot fires: %w", err)
+	}
+
+	err = db.Select(&lot.Properties, `
+		SELECT
+			p.id, p.latitude, p.longitude,
+			COALESCE(p.price_text, '') as price_text,
+			COALESCE(p.property_type, '') as property_type,
+			COALESCE(p.address, '') as address,
+			COALESCE(p.suburb, '') as suburb,
+			p.source,
+			p.drive_time_primary,
+			`+askingVsLandValueExpr+` as asking_vs_land_value_ratio
+		FROM property_lots pl
+		JOIN properties p ON p.id = pl.property_id
+		WHERE pl.lot_id = ?
+			AND p.latitude IS NOT NULL AND p.longitude IS NOT NULL
+			AND NOT EXISTS (SELECT 1 FROM property_links l WHERE l.duplicate_id = p.id)
+		ORDER BY p.first_seen_at DESC
+	`, lot.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get lot properties: %w", err)
+	}
+	return &lot, nil
+}
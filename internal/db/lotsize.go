@@ -0,0 +1,19 @@
+package db
+
+// UpdateLotMinLotSize saves the LEP minimum lot size over a lot (0 if none is
+// mapped), marking it checked
+func (db *DB) UpdateLotMinLotSize(lotID int64, minLotSizeSqm float64) error {
+	_, err := db.Exec("UPDATE cadastral_lots SET min_lot_size_sqm = ? WHERE id = ?", minLotSizeSqm, lotID)
+	return err
+}
+
+// UpdatePropertySubdivision saves the minimum lot size over a property's lots
+// and its subdivision ratio (nil where no minimum is mapped)
+func (db *DB) UpdatePropertySubdivision(propertyID int64, minLotSizeSqm float64, ratio *float64) error {
+	_, err := db.Exec(`
+		UPDATE properties
+		SET min_lot_size_sqm = ?, subdivision_ratio = ?
+		WHERE id = ?`,
+		minLotSizeSqm, ratio, propertyID)
+	return err
+}
@@ -0,0 +1,264 @@
+package db
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// mergeField is a listing column a canonical property can take from one of
+// its duplicates, with the rule deciding whether the duplicate's value is
+// better than the one it has
+type mergeField struct {
+	column string
+	better func(current, candidate interface{}) bool
+}
+
+var mergeFields = []mergeField{
+	{"address", fillMissing},
+	{"suburb", fillMissing},
+	{"postcode", fillMissing},
+	{"price_min", fillMissing},
+	{"price_max", fillMissing},
+	{"price_text", fillMissing},
+	{"property_type", fillMissing},
+	{"property_type_raw", fillMissing},
+	{"bedrooms", fillMissing},
+	{"bathrooms", fillMissing},
+	{"carspaces", fillMissing},
+	{"land_size_sqm", fillMissing},
+	{"description", longerText},
+	{"images", moreImages},
+}
+
+func isMergeField(column string) bool {
+	for _, f := range mergeFields {
+		if f.column == column {
+			return true
+		}
+	}
+	return false
+}
+
+// fillMissing takes the candidate only when the current value is empty
+func fillMissing(current, candidate interface{}) bool {
+	return isEmptyValue(current) && !isEmptyValue(candidate)
+}
+
+// longerText takes the candidate when it has more text, since sources often
+// truncate descriptions differently
+func longerText(current, candidate interface{}) bool {
+	c, _ := candidate.(string)
+	cur, _ := current.(string)
+	return len(strings.TrimSpace(c)) > len(strings.TrimSpace(cur))
+}
+
+// moreImages takes the candidate JSON image array when it has more photos
+func moreImages(current, candidate interface{}) bool {
+	return imageCount(candidate) > imageCount(current)
+}
+
+func imageCount(v interface{}) int {
+	s, _ := v.(string)
+	var images []string
+	json.Unmarshal([]byte(s), &images)
+	return len(images)
+}
+
+func isEmptyValue(v interface{}) bool {
+	switch v := v.(type) {
+	case nil:
+		return true
+	case string:
+		return strings.TrimSpace(v) == ""
+	}
+	return false
+}
+
+// scanMergeRow reads a properties row selected by mergeColumns, converting
+// TEXT returned as bytes to strings so values can be compared
+func scanMergeRow(row interface {
+	MapScan(map[string]interface{}) error
+}) (map[string]interface{}, error) {
+	m := make(map[string]interface{})
+	if err := row.MapScan(m); err != nil {
+		return nil, err
+	}
+	for k, v := range m {
+		if b, ok := v.([]byte); ok {
+			m[k] = string(b)
+		}
+	}
+	return m, nil
+}
+
+func mergeColumns() string {
+	cols := make([]string, len(mergeFields))
+	for i, f := range mergeFields {
+		cols[i] = "p." + f.column
+	}
+	return "p.id, p.source, " + strings.Join(cols, ", ")
+}
+
+// MergeDuplicateProperties consolidates the best available fields from linked
+// duplicates onto each canonical property, in one transaction. Each value
+// taken from a duplicate is recorded in property_field_sources along with the
+// value it replaced. Returns the number of fields merged.
+//
+// A canonical listing's own scraper overwrites merged values on its next
+// run, so this is re-run after every duplicate detection pass; provenance
+// that no longer matches the duplicate's value is dropped and re-merged.
+func (db *DB) MergeDuplicateProperties() (int, error) {
+	tx, err := db.Beginx()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Provenance for links that no longer exist is meaningless
+	if _, err := tx.Exec(`
+		DELETE FROM property_field_sources WHERE NOT EXISTS (
+			SELECT 1 FROM property_links pl
+			WHERE pl.canonical_id = property_field_sources.property_id
+				AND pl.duplicate_id = property_field_sources.source_property_id
+		)
+	`); err != nil {
+		return 0, fmt.Errorf("failed to clear stale merge provenance: %w", err)
+	}
+
+	var canonicalIDs []int64
+	if err := tx.Select(&canonicalIDs, "SELECT DISTINCT canonical_id FROM property_links ORDER BY canonical_id"); err != nil {
+		return 0, fmt.Errorf("failed to get canonical properties: %w", err)
+	}
+
+	merged := 0
+	now := time.Now()
+	for _, id := range canonicalIDs {
+		n, err := mergeCanonicalProperty(tx, id, now)
+		if err != nil {
+			return 0, err
+		}
+		merged += n
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit merge: %w", err)
+	}
+	return merged, nil
+}
+
+// mergeCanonicalProperty merges one canonical property's duplicates onto it
+func mergeCanonicalProperty(tx *sqlx.Tx, canonicalID int64, now time.Time) (int, error) {
+	canonical, err := scanMergeRow(tx.QueryRowx(
+		"SELECT "+mergeColumns()+" FROM properties p WHERE p.id = ?", canonicalID))
+	if err != nil {
+		return 0, fmt.Errorf("failed to get canonical property %d: %w", canonicalID, err)
+	}
+
+	rows, err := tx.Queryx(`
+		SELECT `+mergeColumns()+` FROM properties p
+		JOIN property_links pl ON pl.duplicate_id = p.id
+		WHERE pl.canonical_id = ?
+		ORDER BY p.id
+	`, canonicalID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get duplicates of %d: %w", canonicalID, err)
+	}
+	var duplicates []map[string]interface{}
+	for rows.Next() {
+		dup, err := scanMergeRow(rows)
+		if err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan duplicate: %w", err)
+		}
+		duplicates = append(duplicates, dup)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("failed to get duplicates of %d: %w", canonicalID, err)
+	}
+
+	var provenance []struct {
+		Field            string `db:"field"`
+		SourcePropertyID int64  `db:"source_property_id"`
+	}
+	if err := tx.Select(&provenance,
+		"SELECT field, source_property_id FROM property_field_sources WHERE property_id = ?", canonicalID); err != nil {
+		return 0, fmt.Errorf("failed to get merge provenance: %w", err)
+	}
+	for _, pv := range provenance {
+		var dup map[string]interface{}
+		for _, d := range duplicates {
+			if d["id"] == pv.SourcePropertyID {
+				dup = d
+			}
+		}
+		if dup != nil && dup[pv.Field] == canonical[pv.Field] {
+			continue
+		}
+		// The canonical listing's own source has since set this field
+		if _, err := tx.Exec("DELETE FROM property_field_sources WHERE property_id = ? AND field = ?",
+			canonicalID, pv.Field); err != nil {
+			return 0, fmt.Errorf("failed to clear merge provenance: %w", err)
+		}
+	}
+
+	merged := 0
+	for _, f := range mergeFields {
+		best := canonical[f.column]
+		var from map[string]interface{}
+		for _, dup := range duplicates {
+			if f.better(best, dup[f.column]) {
+				best = dup[f.column]
+				from = dup
+			}
+		}
+		if from == nil {
+			continue
+		}
+
+		if _, err := tx.Exec(fmt.Sprintf("UPDATE properties SET %s = ? WHERE id = ?", f.column),
+			best, canonicalID); err != nil {
+			return 0, fmt.Errorf("failed to merge %s onto %d: %w", f.column, canonicalID, err)
+		}
+		// Keep the original value when a better duplicate replaces an earlier merge
+		if _, err := tx.Exec(`
+			INSERT INTO property_field_sources (property_id, field, source_property_id, source, previous_value, merged_at)
+			VALUES (?, ?, ?, ?, ?, ?)
+			ON CONFLICT(property_id, field) DO UPDATE SET
+				source_property_id = excluded.source_property_id,
+				source = excluded.source,
+				merged_at = excluded.merged_at
+		`, canonicalID, f.column, from["id"], from["source"], canonical[f.column], now); err != nil {
+			return 0, fmt.Errorf("failed to record merge provenance: %w", err)
+		}
+		canonical[f.column] = best
+		merged++
+	}
+
+	return merged, nil
+}
+
+// GetMergedFields returns which source each merged field of a canonical
+// property came from, keyed by column name
+func (db *DB) GetMergedFields(propertyID int64) (map[string]string, error) {
+	var rows []struct {
+		Field  string `db:"field"`
+		Source string `db:"source"`
+	}
+	if err := db.Select(&rows,
+		"SELECT field, source FROM property_field_sources WHERE property_id = ? ORDER BY field", propertyID); err != nil {
+		return nil, fmt.Errorf("failed to get merged fields: %w", err)
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+	fields := make(map[string]string, len(rows))
+	for _, r := range rows {
+		fields[r.Field] = r.Source
+	}
+	return fields, nil
+}
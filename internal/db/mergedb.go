@@ -0,0 +1,424 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// propertyListingColumns are the properties columns a scrape sets, taken
+// together from whichever database saw the listing updated most recently
+var propertyListingColumns = []string{
+	"url", "address", "suburb", "state", "postcode", "price_min", "price_max", "price_text",
+	"property_type", "property_type_raw", "bedrooms", "bathrooms", "carspaces", "land_size_sqm",
+	"description", "images", "listed_at", "scraped_at", "updated_at", "details_scraped_at",
+	"listed_suburb", "listed_postcode",
+}
+
+// propertyLocationColumns are the properties columns holding its coordinates.
+// Every other column (besides the ID and listing key) is enrichment computed
+// from them, so goes with them.
+var propertyLocationColumns = []string{
+	"latitude", "longitude", "coord_source", "coord_confidence", "coord_updated_at",
+}
+
+// MergeDatabaseResult counts what MergeDatabase took from the other database
+type MergeDatabaseResult struct {
+	Inserted  int // Properties new to this database, with their enrichment
+	Updated   int // Properties whose listing was updated more recently there
+	Relocated int // Properties whose coordinates (and enrichment) were corrected by hand or updated more recently there
+	Filled    int // Properties at the same location, filled in with enrichment only done there
+	Lots      int // Cadastral lots new to this database
+}
+
+// mergeKey is what deciding how to merge a property needs from each database
+type mergeKey struct {
+	ID             int64           `db:"id"`
+	Source         string          `db:"source"`
+	ExternalID     string          `db:"external_id"`
+	UpdatedAt      time.Time       `db:"updated_at"`
+	CoordUpdatedAt sql.NullTime    `db:"coord_updated_at"`
+	CoordSource    sql.NullString  `db:"coord_source"`
+	Latitude       sql.NullFloat64 `db:"latitude"`
+	Longitude      sql.NullFloat64 `db:"longitude"`
+}
+
+// locationTime is when the coordinates were last set
+func (k mergeKey) locationTime() time.Time {
+	if k.CoordUpdatedAt.Valid {
+		return k.CoordUpdatedAt.Time
+	}
+	return k.UpdatedAt
+}
+
+// sameLocation reports whether both have the same coordinates
+func (k mergeKey) sameLocation(other mergeKey) bool {
+	return k.Latitude == other.Latitude && k.Longitude == other.Longitude
+}
+
+// takesLocation reports whether other's coordinates replace k's: coordinates
+// corrected by hand win, then the most recently set
+func (k mergeKey) takesLocation(other mergeKey) bool {
+	if !other.Latitude.Valid || !other.Longitude.Valid || k.sameLocation(other) {
+		return false
+	}
+	if manual, otherManual := k.CoordSource.String == "manual", other.CoordSource.String == "manual"; manual != otherManual {
+		return otherManual
+	}
+	if !k.Latitude.Valid || !k.Longitude.Valid {
+		return true
+	}
+	return other.locationTime().After(k.locationTime())
+}
+
+// MergeDatabase merges the properties at path (another farm-search database,
+// e.g. from a scraper on another machine) into this one, in one transaction,
+// matching them by listing source and external ID:
+//
+//   - Properties not here are copied with their enrichment (distances, lots,
+//     route reviews, stale steps), upcoming events, media and history.
+//   - Change log entries (new listings, price drops and so on) not here are
+//     copied, for every property.
+//   - A listing updated more recently there replaces the one here, events,
+//     media and history included. It's copied as scraped: fields merged onto it from duplicates
+//     there are restored, for FindDuplicateProperties to merge here.
+//   - Coordinates corrected by hand there, or set more recently, replace the
+//     ones here along with everything computed from them. At the same
+//     coordinates, enrichment only done there fills in what's missing here.
+//
+// Cadastral lots are matched by lot ID. User data (tags, saved searches and
+// so on) and duplicate links aren't merged; see service.UserDataService and
+// FindDuplicateProperties. path must be at the current schema (opened with
+// New), and isn't changed.
+func (db *DB) MergeDatabase(path string) (*MergeDatabaseResult, error) {
+	ctx := context.Background()
+	conn, err := db.Connx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, "ATTACH DATABASE ? AS other", path); err != nil {
+		return nil, fmt.Errorf("failed to attach %s: %w", path, err)
+	}
+	defer conn.ExecContext(ctx, "DETACH DATABASE other")
+
+	result, err := mergeAttached(ctx, conn)
+	conn.ExecContext(ctx, "DROP TABLE IF EXISTS temp.merge_lots")
+	conn.ExecContext(ctx, "DROP TABLE IF EXISTS temp.merge_properties")
+	return result, err
+}
+
+// mergeAttached merges the database attached as other into main
+func mergeAttached(ctx context.Context, conn *sqlx.Conn) (*MergeDatabaseResult, error) {
+	result := &MergeDatabaseResult{}
+	tx, err := conn.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if result.Lots, err = mergeLots(tx); err != nil {
+		return nil, err
+	}
+	if err := planPropertyMerge(tx, result); err != nil {
+		return nil, err
+	}
+
+	columns, err := tableColumns(tx, "properties")
+	if err != nil {
+		return nil, err
+	}
+	var enrichmentColumns []string
+	for _, c := range columns {
+		if c != "id" && c != "source" && c != "external_id" &&
+			!containsString(propertyListingColumns, c) && !containsString(propertyLocationColumns, c) {
+			enrichmentColumns = append(enrichmentColumns, c)
+		}
+	}
+
+	// New properties, then the merge plan's IDs for them
+	insertColumns := strings.Join(columns[1:], ", ")
+	if _, err := tx.Exec(`
+		INSERT INTO main.properties (` + insertColumns + `)
+		SELECT ` + prefixColumns("o", columns[1:]) + `
+		FROM other.properties o JOIN temp.merge_properties mp ON mp.other_id = o.id
+		WHERE mp.is_new`); err != nil {
+		return nil, fmt.Errorf("failed to insert properties: %w", err)
+	}
+	if _, err := tx.Exec(`
+		UPDATE temp.merge_properties SET id = (
+			SELECT p.id FROM main.properties p JOIN other.properties o
+				ON o.source = p.source AND o.external_id = p.external_id
+			WHERE o.id = merge_properties.other_id
+		) WHERE is_new`); err != nil {
+		return nil, fmt.Errorf("failed to map new properties: %w", err)
+	}
+
+	if err := updateFromOther(tx, propertyListingColumns, "mp.take_listing AND NOT mp.is_new"); err != nil {
+		return nil, err
+	}
+	if err := unmergeListings(tx); err != nil {
+		return nil, err
+	}
+	if err := updateFromOther(tx, append(append([]string{}, propertyLocationColumns...), enrichmentColumns...),
+		"mp.take_location AND NOT mp.is_new"); err != nil {
+		return nil, err
+	}
+
+	// Fill in enrichment at the same location (lots added here mark their steps stale)
+	fill := make([]string, len(enrichmentColumns))
+	missing := make([]string, len(enrichmentColumns))
+	for i, c := range enrichmentColumns {
+		fill[i] = fmt.Sprintf("COALESCE(properties.%s, o.%s)", c, c)
+		missing[i] = fmt.Sprintf("(properties.%s IS NULL AND o.%s IS NOT NULL)", c, c)
+	}
+	res, err := tx.Exec(`
+		UPDATE main.properties SET (` + strings.Join(enrichmentColumns, ", ") + `) = (` + strings.Join(fill, ", ") + `)
+		FROM temp.merge_properties mp JOIN other.properties o ON o.id = mp.other_id
+		WHERE mp.id = properties.id AND mp.same_location AND NOT mp.is_new
+			AND (` + strings.Join(missing, " OR ") + `)`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fill in enrichment: %w", err)
+	}
+	filled, _ := res.RowsAffected()
+	result.Filled = int(filled)
+
+	for _, step := range []struct {
+		query string
+		what  string
+	}{
+		// Enrichment moves with the coordinates
+		{"DELETE FROM main.property_distances WHERE property_id IN (SELECT id FROM temp.merge_properties WHERE take_location AND NOT is_new)", "clear distances"},
+		{"DELETE FROM main.property_lots WHERE property_id IN (SELECT id FROM temp.merge_properties WHERE take_location AND NOT is_new)", "clear lots"},
+		{"DELETE FROM main.route_reviews WHERE property_id IN (SELECT id FROM temp.merge_properties WHERE take_location AND NOT is_new)", "clear route reviews"},
+		{"DELETE FROM main.geocode_reviews WHERE status = 'pending' AND property_id IN (SELECT id FROM temp.merge_properties WHERE take_location AND NOT is_new)", "clear geocode reviews"},
+		{`INSERT OR IGNORE INTO main.property_distances (property_id, target_type, target_name, distance_km, drive_time_mins)
+			SELECT mp.id, o.target_type, o.target_name, o.distance_km, o.drive_time_mins
+			FROM other.property_distances o JOIN temp.merge_properties mp ON mp.other_id = o.property_id
+			WHERE mp.is_new OR mp.take_location OR mp.same_location`, "copy distances"},
+		{`INSERT OR IGNORE INTO main.property_lots (property_id, lot_id)
+			SELECT mp.id, ml.id
+			FROM other.property_lots o
+			JOIN temp.merge_properties mp ON mp.other_id = o.property_id
+			JOIN temp.merge_lots ml ON ml.other_id = o.lot_id
+			WHERE mp.is_new OR mp.take_location OR mp.same_location`, "copy lots"},
+		{`INSERT OR IGNORE INTO main.route_reviews (property_id, target_type, target_name, straight_km, road_km, ratio,
+				drive_time_mins, status, flagged_at, resolved_at)
+			SELECT mp.id, o.target_type, o.target_name, o.straight_km, o.road_km, o.ratio,
+				o.drive_time_mins, o.status, o.flagged_at, o.resolved_at
+			FROM other.route_reviews o JOIN temp.merge_properties mp ON mp.other_id = o.property_id
+			WHERE mp.is_new OR mp.take_location`, "copy route reviews"},
+		// What's stale there is stale here, replacing what the triggers marked
+		{"DELETE FROM main.property_stale_steps WHERE property_id IN (SELECT id FROM temp.merge_properties WHERE is_new OR take_location)", "clear stale steps"},
+		{`INSERT INTO main.property_stale_steps (property_id, step, reason, marked_at)
+			SELECT mp.id, o.step, o.reason, o.marked_at
+			FROM other.property_stale_steps o JOIN temp.merge_properties mp ON mp.other_id = o.property_id
+			WHERE mp.is_new OR mp.take_location`, "copy stale steps"},
+		// Events move with the listing
+		{"DELETE FROM main.property_events WHERE property_id IN (SELECT id FROM temp.merge_properties WHERE take_listing AND NOT is_new)", "clear events"},
+		{`INSERT INTO main.property_events (property_id, kind, starts_at, ends_at, location, source)
+			SELECT mp.id, o.kind, o.starts_at, o.ends_at, o.location, o.source
+			FROM other.property_events o JOIN temp.merge_properties mp ON mp.other_id = o.property_id
+			WHERE mp.take_listing`, "copy events"},
+		// And so do videos and floorplans
+		{"DELETE FROM main.property_images WHERE property_id IN (SELECT id FROM temp.merge_properties WHERE take_listing AND NOT is_new)", "clear media"},
+		{`INSERT INTO main.property_images (property_id, kind, url, position, source)
+			SELECT mp.id, o.kind, o.url, o.position, o.source
+			FROM other.property_images o JOIN temp.merge_properties mp ON mp.other_id = o.property_id
+			WHERE mp.take_listing`, "copy media"},
+		// The change log is kept from both, so the recent changes there show here
+		{`INSERT INTO main.property_changes (property_id, kind, changed_at, price_before, price_after)
+			SELECT mp.id, o.kind, o.changed_at, o.price_before, o.price_after
+			FROM other.property_changes o JOIN temp.merge_properties mp ON mp.other_id = o.property_id
+			WHERE NOT EXISTS (SELECT 1 FROM main.property_changes c
+				WHERE c.property_id = mp.id AND c.kind = o.kind AND c.changed_at = o.changed_at)`, "copy changes"},
+		// History moves with the listing
+		{"DELETE FROM main.property_history WHERE property_id IN (SELECT id FROM temp.merge_properties WHERE take_listing AND NOT is_new)", "clear history"},
+		{`INSERT INTO main.property_history (property_id, valid_from, valid_to, ` + historyColumns + `)
+			SELECT mp.id, o.valid_from, o.valid_to, ` + prefixColumns("o", strings.Split(historyColumns, ", ")) + `
+			FROM other.property_history o JOIN temp.merge_properties mp ON mp.other_id = o.property_id
+			WHERE mp.is_new OR mp.take_listing`, "copy history"},
+	} {
+		if _, err := tx.Exec(step.query); err != nil {
+			return nil, fmt.Errorf("failed to %s: %w", step.what, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit merge: %w", err)
+	}
+	return result, nil
+}
+
+// mergeLots copies the cadastral lots not here, with their heritage items and
+// overlays, and fills in checks only done there. It leaves temp.merge_lots
+// mapping the other database's lot IDs to these. Returns the lots copied.
+func mergeLots(tx *sqlx.Tx) (int, error) {
+	columns, err := tableColumns(tx, "cadastral_lots")
+	if err != nil {
+		return 0, err
+	}
+	res, err := tx.Exec(`
+		INSERT OR IGNORE INTO main.cadastral_lots (` + strings.Join(columns[1:], ", ") + `)
+		SELECT ` + prefixColumns("o", columns[1:]) + ` FROM other.cadastral_lots o`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to copy lots: %w", err)
+	}
+	inserted, _ := res.RowsAffected()
+
+	for _, step := range []struct {
+		query string
+		what  string
+	}{
+		{`CREATE TEMP TABLE merge_lots AS
+			SELECT o.id AS other_id, l.id AS id
+			FROM other.cadastral_lots o JOIN main.cadastral_lots l ON l.lot_id_string = o.lot_id_string`, "map lots"},
+		{`INSERT OR IGNORE INTO main.lot_heritage_items (lot_id, listing, item_id, name, class)
+			SELECT ml.id, o.listing, o.item_id, o.name, o.class
+			FROM other.lot_heritage_items o JOIN temp.merge_lots ml ON ml.other_id = o.lot_id`, "copy heritage items"},
+		{`INSERT OR IGNORE INTO main.lot_overlays (lot_id, overlay, affected_pct)
+			SELECT ml.id, o.overlay, o.affected_pct
+			FROM other.lot_overlays o JOIN temp.merge_lots ml ON ml.other_id = o.lot_id`, "copy overlays"},
+		{`UPDATE main.cadastral_lots SET
+				heritage_checked_at = COALESCE(cadastral_lots.heritage_checked_at, o.heritage_checked_at),
+				min_lot_size_sqm = COALESCE(cadastral_lots.min_lot_size_sqm, o.min_lot_size_sqm)
+			FROM temp.merge_lots ml JOIN other.cadastral_lots o ON o.id = ml.other_id
+			WHERE ml.id = cadastral_lots.id`, "fill in lot checks"},
+	} {
+		if _, err := tx.Exec(step.query); err != nil {
+			return 0, fmt.Errorf("failed to %s: %w", step.what, err)
+		}
+	}
+	return int(inserted), nil
+}
+
+// planPropertyMerge decides what to take of each property in the other
+// database, into temp.merge_properties. Timestamps are compared once parsed,
+// since they aren't all stored in the same format or time zone.
+func planPropertyMerge(tx *sqlx.Tx, result *MergeDatabaseResult) error {
+	const keyColumns = "id, source, external_id, updated_at, coord_updated_at, coord_source, latitude, longitude"
+	var local []mergeKey
+	if err := tx.Select(&local, "SELECT "+keyColumns+" FROM main.properties"); err != nil {
+		return fmt.Errorf("failed to get properties: %w", err)
+	}
+	var others []mergeKey
+	if err := tx.Select(&others, "SELECT "+keyColumns+" FROM other.properties"); err != nil {
+		return fmt.Errorf("failed to get other properties: %w", err)
+	}
+	byKey := make(map[[2]string]mergeKey, len(local))
+	for _, k := range local {
+		byKey[[2]string{k.Source, k.ExternalID}] = k
+	}
+
+	if _, err := tx.Exec(`
+		CREATE TEMP TABLE merge_properties (
+			other_id INTEGER PRIMARY KEY,
+			id INTEGER,
+			is_new INTEGER NOT NULL,
+			take_listing INTEGER NOT NULL,
+			take_location INTEGER NOT NULL,
+			same_location INTEGER NOT NULL
+		)`); err != nil {
+		return fmt.Errorf("failed to plan merge: %w", err)
+	}
+	stmt, err := tx.Preparex(`
+		INSERT INTO temp.merge_properties (other_id, id, is_new, take_listing, take_location, same_location)
+		VALUES (?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, o := range others {
+		k, ok := byKey[[2]string{o.Source, o.ExternalID}]
+		var id sql.NullInt64
+		takeListing, takeLocation, sameLocation := true, true, false
+		if ok {
+			id = sql.NullInt64{Int64: k.ID, Valid: true}
+			takeListing = o.UpdatedAt.After(k.UpdatedAt)
+			takeLocation = k.takesLocation(o)
+			sameLocation = k.sameLocation(o) && o.Latitude.Valid
+		}
+		if !takeListing && !takeLocation && !sameLocation {
+			continue
+		}
+		if _, err := stmt.Exec(o.ID, id, !ok, takeListing, takeLocation, sameLocation); err != nil {
+			return fmt.Errorf("failed to plan merge: %w", err)
+		}
+
+		switch {
+		case !ok:
+			result.Inserted++
+			continue
+		case takeListing:
+			result.Updated++
+		}
+		if takeLocation {
+			result.Relocated++
+		}
+	}
+	return nil
+}
+
+// updateFromOther sets columns of the merged properties matching where (on
+// temp.merge_properties mp) to their values in the other database
+func updateFromOther(tx *sqlx.Tx, columns []string, where string) error {
+	_, err := tx.Exec(`
+		UPDATE main.properties SET (` + strings.Join(columns, ", ") + `) = (` + prefixColumns("o", columns) + `)
+		FROM temp.merge_properties mp JOIN other.properties o ON o.id = mp.other_id
+		WHERE mp.id = properties.id AND ` + where)
+	if err != nil {
+		return fmt.Errorf("failed to update properties: %w", err)
+	}
+	return nil
+}
+
+// unmergeListings restores the fields merged onto listings taken from the
+// other database from their duplicates there
+func unmergeListings(tx *sqlx.Tx) error {
+	for _, f := range mergeFields {
+		_, err := tx.Exec(`
+			UPDATE main.properties SET `+f.column+` = o.previous_value
+			FROM temp.merge_properties mp JOIN other.property_field_sources o ON o.property_id = mp.other_id
+			WHERE mp.id = properties.id AND mp.take_listing AND o.field = ?`, f.column)
+		if err != nil {
+			return fmt.Errorf("failed to unmerge %s: %w", f.column, err)
+		}
+	}
+	return nil
+}
+
+// tableColumns returns a main database table's columns in order
+func tableColumns(tx *sqlx.Tx, table string) ([]string, error) {
+	var columns []string
+	if err := tx.Select(&columns, "SELECT name FROM pragma_table_info(?, 'main') ORDER BY cid", table); err != nil {
+		return nil, fmt.Errorf("failed to get %s columns: %w", table, err)
+	}
+	if len(columns) == 0 || columns[0] != "id" {
+		return nil, fmt.Errorf("unexpected %s columns: %v", table, columns)
+	}
+	return columns, nil
+}
+
+// prefixColumns qualifies columns with a table alias, comma-separated
+func prefixColumns(alias string, columns []string) string {
+	qualified := make([]string, len(columns))
+	for i, c := range columns {
+		qualified[i] = alias + "." + c
+	}
+	return strings.Join(qualified, ", ")
+}
+
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
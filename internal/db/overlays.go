@@ -0,0 +1,63 @@
+package db
+
+import (
+	"fmt"
+
+	"farm-search/internal/models"
+)
+
+// ReplaceLotOverlays replaces the overlay coverage recorded on a lot, from
+// the percentage of it each overlay covers. Overlays covering none of it
+// aren't kept.
+func (db *DB) ReplaceLotOverlays(lotID int64, affectedPct map[string]float64) error {
+	tx, err := db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM lot_overlays WHERE lot_id = ?", lotID); err != nil {
+		return fmt.Errorf("failed to clear lot overlays: %w", err)
+	}
+	for overlay, pct := range affectedPct {
+		if pct <= 0 {
+			continue
+		}
+		if _, err := tx.Exec("INSERT INTO lot_overlays (lot_id, overlay, affected_pct) VALUES (?, ?, ?)",
+			lotID, overlay, pct); err != nil {
+			return fmt.Errorf("failed to save %s overlay: %w", overlay, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit lot overlays: %w", err)
+	}
+	return nil
+}
+
+// GetPropertyLotOverlays returns the overlays covering part of a property's
+// lots, by lot
+func (db *DB) GetPropertyLotOverlays(propertyID int64) ([]models.LotOverlay, error) {
+	var overlays []models.LotOverlay
+	err := db.Select(&overlays, `
+		SELECT cl.lot_id_string, o.overlay, o.affected_pct
+		FROM lot_overlays o
+		JOIN cadastral_lots cl ON cl.id = o.lot_id
+		JOIN property_lots pl ON pl.lot_id = cl.id
+		WHERE pl.property_id = ?
+		ORDER BY cl.lot_id_string, o.overlay
+	`, propertyID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get lot overlays: %w", err)
+	}
+	return overlays, nil
+}
+
+// UpdatePropertyOverlays saves the percentage of a property's lots covered by
+// koala habitat and the biodiversity values map (nil leaves them NULL, for a
+// layer that hasn't been imported)
+func (db *DB) UpdatePropertyOverlays(propertyID int64, koalaHabitatPct, biodiversityPct *float64) error {
+	_, err := db.Exec("UPDATE properties SET koala_habitat_pct = ?, biodiversity_pct = ? WHERE id = ?",
+		koalaHabitatPct, biodiversityPct, propertyID)
+	return err
+}
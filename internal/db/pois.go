@@ -0,0 +1,178 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"farm-search/internal/models"
+)
+
+// ErrPOINameTaken is returned for a user POI named like another (ignoring case)
+var ErrPOINameTaken = errors.New("a POI with that name already exists")
+
+// poiNameTaken reports whether a user POI other than id has name
+func (db *DB) poiNameTaken(name string, id int64) (bool, error) {
+	var n int
+	if err := db.Get(&n, "SELECT COUNT(*) FROM user_pois WHERE name = ? AND id != ?", name, id); err != nil {
+		return false, fmt.Errorf("failed to check POI name: %w", err)
+	}
+	return n > 0, nil
+}
+
+// CreateUserPOI saves a user POI and sets its ID. Properties' times to it are
+// left for the poi_drive_times step.
+func (db *DB) CreateUserPOI(p *models.UserPOI) error {
+	if taken, err := db.poiNameTaken(p.Name, 0); err != nil {
+		return err
+	} else if taken {
+		return ErrPOINameTaken
+	}
+	p.CreatedAt = time.Now().UTC().Truncate(time.Second)
+	result, err := db.Exec("INSERT INTO user_pois (name, latitude, longitude, created_at) VALUES (?, ?, ?, ?)",
+		p.Name, p.Latitude, p.Longitude, p.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create POI: %w", err)
+	}
+	p.ID, err = result.LastInsertId()
+	return err
+}
+
+// ListUserPOIs returns all user POIs, by name
+func (db *DB) ListUserPOIs() ([]models.UserPOI, error) {
+	pois := []models.UserPOI{}
+	if err := db.Select(&pois, "SELECT id, name, latitude, longitude, created_at FROM user_pois ORDER BY name"); err != nil {
+		return nil, fmt.Errorf("failed to list POIs: %w", err)
+	}
+	return pois, nil
+}
+
+// GetUserPOI returns a user POI by ID
+func (db *DB) GetUserPOI(id int64) (*models.UserPOI, error) {
+	var p models.UserPOI
+	if err := db.Get(&p, "SELECT id, name, latitude, longitude, created_at FROM user_pois WHERE id = ?", id); err != nil {
+		return nil, fmt.Errorf("failed to get POI: %w", err)
+	}
+	return &p, nil
+}
+
+// UpdateUserPOI renames or moves a user POI, reporting whether it exists.
+// Moving it clears properties' times to it for the poi_drive_times step to
+// recompute.
+func (db *DB) UpdateUserPOI(p *models.UserPOI) (bool, error) {
+	if taken, err := db.poiNameTaken(p.Name, p.ID); err != nil {
+		return false, err
+	} else if taken {
+		return false, ErrPOINameTaken
+	}
+
+	tx, err := db.Beginx()
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`
+		DELETE FROM property_poi_times WHERE poi_id = ? AND EXISTS (
+			SELECT 1 FROM user_pois WHERE id = ? AND (latitude != ? OR longitude != ?)
+		)`, p.ID, p.ID, p.Latitude, p.Longitude); err != nil {
+		return false, fmt.Errorf("failed to clear POI times: %w", err)
+	}
+	result, err := tx.Exec("UPDATE user_pois SET name = ?, latitude = ?, longitude = ? WHERE id = ?",
+		p.Name, p.Latitude, p.Longitude, p.ID)
+	if err != nil {
+		return false, fmt.Errorf("failed to update POI: %w", err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, tx.Commit()
+}
+
+// DeleteUserPOI removes a user POI and properties' times to it, reporting
+// whether it existed
+func (db *DB) DeleteUserPOI(id int64) (bool, error) {
+	tx, err := db.Beginx()
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM property_poi_times WHERE poi_id = ?", id); err != nil {
+		return false, fmt.Errorf("failed to delete POI times: %w", err)
+	}
+	result, err := tx.Exec("DELETE FROM user_pois WHERE id = ?", id)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete POI: %w", err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, tx.Commit()
+}
+
+// GetPOIsToRoute returns the user POIs the poi_drive_times step has to
+// measure a property against: those it has no time to yet, or all of them if
+// all is set or the step is stale for it
+func (db *DB) GetPOIsToRoute(propertyID int64, all bool) ([]models.UserPOI, error) {
+	var pois []models.UserPOI
+	err := db.Select(&pois, `
+		SELECT id, name, latitude, longitude, created_at FROM user_pois u
+		WHERE ? OR NOT EXISTS (SELECT 1 FROM property_poi_times t WHERE t.property_id = ? AND t.poi_id = u.id)
+			OR EXISTS (SELECT 1 FROM property_stale_steps s WHERE s.property_id = ? AND s.step = ?)
+		ORDER BY id
+	`, all, propertyID, propertyID, StepPOIDriveTimes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get POIs to route: %w", err)
+	}
+	return pois, nil
+}
+
+// SavePropertyPOITime saves a property's distance and drive time (nil if it
+// couldn't be routed) to a user POI
+func (db *DB) SavePropertyPOITime(propertyID, poiID int64, distanceKm float64, driveTimeMins *int) error {
+	_, err := db.Exec(`
+		INSERT OR REPLACE INTO property_poi_times (property_id, poi_id, distance_km, drive_time_mins, computed_at)
+		VALUES (?, ?, ?, ?, ?)
+	`, propertyID, poiID, distanceKm, driveTimeMins, time.Now().UTC())
+	if err != nil {
+		return fmt.Errorf("failed to save POI time: %w", err)
+	}
+	return nil
+}
+
+// GetPropertyPOITimes returns a property's distances and drive times to user
+// POIs, nearest by drive time first (unrouted ones last, by distance)
+func (db *DB) GetPropertyPOITimes(propertyID int64) ([]models.PropertyPOITime, error) {
+	var times []models.PropertyPOITime
+	err := db.Select(&times, `
+		SELECT t.poi_id, u.name, t.distance_km, t.drive_time_mins
+		FROM property_poi_times t
+		JOIN user_pois u ON u.id = t.poi_id
+		WHERE t.property_id = ?
+		ORDER BY t.drive_time_mins IS NULL, t.drive_time_mins, t.distance_km
+	`, propertyID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get POI times: %w", err)
+	}
+	return times, nil
+}
+
+// poiConditions returns the WHERE conditions for a filter's user POI drive
+// time filters on properties aliased p
+func poiConditions(f PropertyFilter) (string, []interface{}) {
+	var conditions string
+	var args []interface{}
+	if f.POIDriveTimeMax != nil {
+		conditions += " AND EXISTS (SELECT 1 FROM property_poi_times t WHERE t.property_id = p.id AND t.drive_time_mins <= ?)"
+		args = append(args, *f.POIDriveTimeMax)
+	}
+	for _, near := range f.NearPOIs {
+		conditions += ` AND EXISTS (SELECT 1 FROM property_poi_times t JOIN user_pois u ON u.id = t.poi_id
+			WHERE t.property_id = p.id AND u.name = ? AND t.drive_time_mins <= ?)`
+		args = append(args, near.Name, near.Mins)
+	}
+	return conditions, args
+}
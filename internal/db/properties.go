@@ -1,39 +1,240 @@
 package db
 
 import (
+	"database/sql"
 	"encoding/json"
+	"farm-search/internal/geo"
 	"farm-search/internal/models"
+	"farm-search/internal/sanitize"
 	"fmt"
+	"slices"
 	"strings"
 )
 
 // PropertyFilter contains all filter parameters for property queries
 type PropertyFilter struct {
-	PriceMin           *int64
-	PriceMax           *int64
-	PropertyTypes      []string
-	LandSizeMin        *float64
-	LandSizeMax        *float64
-	DistanceSydneyMax  *float64
-	DistanceTownMax    *float64
-	DriveTimeSydneyMax *int
-	DriveTimeTownMax   *int // Drive time to nearest town in minutes
-	DriveTimeSchoolMax *int // Drive time to nearest school in minutes
+	PriceMin            *int64
+	PriceMax            *int64
+	PropertyTypes       []string
+	LandSizeMin         *float64
+	LandSizeMax         *float64
+	DistanceSydneyMax   *float64
+	DistanceTownMax     *float64
+	DriveTimePrimaryMax *int
+	DriveTimeTownMax    *int // Drive time to nearest town in minutes
+	DriveTimeSchoolMax  *int // Drive time to nearest school in minutes
+	// Walking and cycling time to the nearest town, for properties on its
+	// fringe; properties further out or not yet routed are excluded
+	WalkTimeTownMax  *int
+	CycleTimeTownMax *int
+	// Drive time to the nearest user POI, and to particular ones ("within 45
+	// min of Mum"); properties not yet routed to them are excluded
+	POIDriveTimeMax *int
+	NearPOIs        []POIDriveTime
+	// Minimum distance to the nearest wind or solar farm, operating or
+	// planned. Properties not yet checked aren't excluded.
+	WindFarmMinKm  *float64
+	SolarFarmMinKm *float64
+	// Distance ranges to noise sources, for quiet (min) or access (max)
+	HighwayMinKm *float64
+	HighwayMaxKm *float64
+	RailwayMinKm *float64
+	RailwayMaxKm *float64
+	RunwayMinKm  *float64
+	RunwayMaxKm  *float64
+	// Maximum percentage of a property's lots under koala habitat or on the
+	// biodiversity values map. Properties not yet checked aren't excluded.
+	KoalaHabitatMaxPct *float64
+	BiodiversityMaxPct *float64
+	// Vegetation: leave out properties flagged for recent clearing, and the
+	// minimum mean NDVI and maximum seasonal range (pasture greenness and its
+	// reliability). Properties not yet measured aren't excluded.
+	ExcludeClearing bool
+	NDVIMin         *float64
+	NDVIRangeMax    *float64
+	// Whether a house-sized building is on the lots. Unlike the limits
+	// above, properties not yet checked are excluded.
+	HasDwelling *bool
+	// Minimum dwellings estimated from the description (2 for a granny
+	// flat, workers cottage or second home). Properties not yet estimated
+	// are excluded.
+	MinDwellings *int
+	// Listings whose suburb or postcode isn't the one their pin is in, by
+	// the last boundary check; properties not checked are excluded
+	BoundaryMismatch bool
+	// Minimum subdivision ratio (lots' area over the minimum lot size).
+	// Properties not yet checked or with no minimum mapped are excluded.
+	SubdivisionRatioMin *float64
+	// Minimum bedrooms, bathrooms and car spaces. Listings that don't give
+	// the count are excluded.
+	MinBedrooms  *int
+	MinBathrooms *int
+	MinCarspaces *int
+	// Drive time area: within WithinMinutes of (WithinLat, WithinLng) by its
+	// isochrone. Applied by service.PropertyService, not ListProperties.
+	WithinLat     *float64
+	WithinLng     *float64
+	WithinMinutes *int
+	// Drawn search area: only properties inside its polygons. Applied by
+	// service.PropertyService, like the drive time area.
+	SearchArea *geo.Area
+	// Exclusions: properties inside ExcludeArea, or near an exclusion layer's
+	// features, are left out. Applied by service.PropertyService too.
+	ExcludeArea *geo.Area
+	ExcludeNear []ExclusionRadius
+	// Score profile whose scores are returned, and sorted on by "score"
+	ScoreProfileID *int64
+	// Tags: properties must have all of Tags and none of ExcludeTags
+	Tags        []string
+	ExcludeTags []string
 	// Map bounds
 	SWLat *float64
 	SWLng *float64
 	NELat *float64
 	NELng *float64
+	// Also list pruned properties, as they were when pruned, for queries
+	// over listing history
+	IncludePruned bool
+	// Sorting: a propertySorts key, prefixed with "-" for descending
+	Sort string
 	// Pagination
 	Limit  int
 	Offset int
 }
 
+// POIDriveTime limits the drive time to the user POI with Name (ignoring
+// case) to Mins
+type POIDriveTime struct {
+	Name string
+	Mins int
+}
+
+// ExclusionRadius excludes properties within Km of an exclusion layer's
+// features (inside counts, for polygons)
+type ExclusionRadius struct {
+	Layer string
+	Km    float64
+}
+
+// noiseConditions returns the WHERE conditions for f's noise source distance
+// ranges. Properties not yet measured pass a minimum but not a maximum.
+func noiseConditions(f PropertyFilter) (string, []interface{}) {
+	var query string
+	var args []interface{}
+	for _, r := range []struct {
+		column   string
+		min, max *float64
+	}{
+		{"p.highway_km", f.HighwayMinKm, f.HighwayMaxKm},
+		{"p.railway_km", f.RailwayMinKm, f.RailwayMaxKm},
+		{"p.runway_km", f.RunwayMinKm, f.RunwayMaxKm},
+	} {
+		if r.min != nil {
+			query += fmt.Sprintf(" AND (%s IS NULL OR %s >= ?)", r.column, r.column)
+			args = append(args, *r.min)
+		}
+		if r.max != nil {
+			query += fmt.Sprintf(" AND %s <= ?", r.column)
+			args = append(args, *r.max)
+		}
+	}
+	return query, args
+}
+
+// overlayConditions returns the WHERE conditions for f's planning overlay
+// maximums
+func overlayConditions(f PropertyFilter) (string, []interface{}) {
+	var query string
+	var args []interface{}
+	if f.KoalaHabitatMaxPct != nil {
+		query += " AND (p.koala_habitat_pct IS NULL OR p.koala_habitat_pct <= ?)"
+		args = append(args, *f.KoalaHabitatMaxPct)
+	}
+	if f.BiodiversityMaxPct != nil {
+		query += " AND (p.biodiversity_pct IS NULL OR p.biodiversity_pct <= ?)"
+		args = append(args, *f.BiodiversityMaxPct)
+	}
+	return query, args
+}
+
+// vegetationConditions returns the WHERE conditions for f's clearing flag and
+// NDVI limits
+func vegetationConditions(f PropertyFilter) (string, []interface{}) {
+	var query string
+	var args []interface{}
+	if f.ExcludeClearing {
+		query += " AND COALESCE(p.clearing_flagged, 0) = 0"
+	}
+	if f.NDVIMin != nil {
+		query += " AND (p.ndvi_mean IS NULL OR p.ndvi_mean >= ?)"
+		args = append(args, *f.NDVIMin)
+	}
+	if f.NDVIRangeMax != nil {
+		query += " AND (p.ndvi_seasonal_range IS NULL OR p.ndvi_seasonal_range <= ?)"
+		args = append(args, *f.NDVIRangeMax)
+	}
+	return query, args
+}
+
+// roomConditions returns the WHERE conditions for f's minimum bedrooms,
+// bathrooms and car spaces
+func roomConditions(f PropertyFilter) (string, []interface{}) {
+	var query string
+	var args []interface{}
+	if f.MinBedrooms != nil {
+		query += " AND p.bedrooms >= ?"
+		args = append(args, *f.MinBedrooms)
+	}
+	if f.MinBathrooms != nil {
+		query += " AND p.bathrooms >= ?"
+		args = append(args, *f.MinBathrooms)
+	}
+	if f.MinCarspaces != nil {
+		query += " AND p.carspaces >= ?"
+		args = append(args, *f.MinCarspaces)
+	}
+	return query, args
+}
+
+// propertySorts maps sort keys to the list query's ORDER BY expression
+var propertySorts = map[string]string{
+	"asking_vs_land_value_ratio": "asking_vs_land_value_ratio",
+	"first_seen_at":              "p.first_seen_at",
+	"ndvi_mean":                  "p.ndvi_mean",
+	"score":                      "score",
+	"subdivision_ratio":          "p.subdivision_ratio",
+}
+
 // ListProperties returns properties matching the given filters
 // Excludes duplicate properties (only shows canonical ones)
 func (db *DB) ListProperties(f PropertyFilter) ([]models.PropertyListItem, error) {
+	query, args := listPropertiesQuery(f)
+	var properties []models.PropertyListItem
+	err := db.Select(&properties, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list properties: %w", err)
+	}
+
+	return properties, nil
+}
+
+// listPropertiesQuery builds ListProperties' query for f, with its arguments.
+// It reads property_search (and property_search_pruned with
+// f.IncludePruned), which only has canonical properties with coordinates, so
+// conditions on p name columns copied there.
+func listPropertiesQuery(f PropertyFilter) (string, []interface{}) {
+	// A sort on a column not otherwise listed returns its value too, so lists
+	// from several databases can be merged in order
+	sortValue := "NULL"
+	if expr := propertySorts[strings.TrimPrefix(f.Sort, "-")]; strings.HasPrefix(expr, "p.") {
+		sortValue = expr
+	}
+	from := "property_search"
+	if f.IncludePruned {
+		from = searchWithPruned
+	}
 	query := `
-		SELECT DISTINCT
+		SELECT
 			p.id,
 			p.latitude,
 			p.longitude,
@@ -42,16 +243,21 @@ func (db *DB) ListProperties(f PropertyFilter) ([]models.PropertyListItem, error
 			COALESCE(p.address, '') as address,
 			COALESCE(p.suburb, '') as suburb,
 			p.source,
-			p.drive_time_sydney
-		FROM properties p
-		LEFT JOIN property_distances pd_sydney ON p.id = pd_sydney.property_id 
-			AND pd_sydney.target_type = 'capital' AND pd_sydney.target_name = 'Sydney'
-		LEFT JOIN property_links pl ON p.id = pl.duplicate_id
-		WHERE p.latitude IS NOT NULL AND p.longitude IS NOT NULL
-			AND pl.duplicate_id IS NULL  -- Exclude properties that are duplicates
+			p.drive_time_primary,
+			` + askingVsLandValueExpr + ` as asking_vs_land_value_ratio,
+			ps.score,
+			` + sortValue + ` as sort_value
+		FROM ` + from + ` p
+		LEFT JOIN property_scores ps ON p.id = ps.property_id AND ps.profile_id = ?
+		WHERE 1 = 1
 	`
 
-	args := make([]interface{}, 0)
+	// Without a profile the join matches nothing, leaving scores NULL
+	var profileID int64
+	if f.ScoreProfileID != nil {
+		profileID = *f.ScoreProfileID
+	}
+	args := []interface{}{profileID}
 	argIndex := 1
 
 	// Price filters
@@ -88,18 +294,18 @@ func (db *DB) ListProperties(f PropertyFilter) ([]models.PropertyListItem, error
 
 	// Distance filters
 	if f.DistanceSydneyMax != nil {
-		query += " AND pd_sydney.distance_km <= ?"
+		query += " AND p.distance_sydney_km <= ?"
 		args = append(args, *f.DistanceSydneyMax)
 	}
 	if f.DistanceTownMax != nil {
-		// Use pre-computed nearest_town_1_km on properties table (much faster)
+		// Use pre-computed nearest_town_1_km (much faster)
 		query += " AND p.nearest_town_1_km <= ?"
 		args = append(args, *f.DistanceTownMax)
 	}
-	// Drive time filters (use pre-computed columns on properties table)
-	if f.DriveTimeSydneyMax != nil {
-		query += " AND p.drive_time_sydney <= ?"
-		args = append(args, *f.DriveTimeSydneyMax)
+	// Drive time filters (use pre-computed columns)
+	if f.DriveTimePrimaryMax != nil {
+		query += " AND p.drive_time_primary <= ?"
+		args = append(args, *f.DriveTimePrimaryMax)
 	}
 	if f.DriveTimeTownMax != nil {
 		query += " AND p.nearest_town_1_mins <= ?"
@@ -109,6 +315,60 @@ func (db *DB) ListProperties(f PropertyFilter) ([]models.PropertyListItem, error
 		query += " AND p.nearest_school_1_mins <= ?"
 		args = append(args, *f.DriveTimeSchoolMax)
 	}
+	if f.WalkTimeTownMax != nil {
+		query += " AND p.nearest_town_1_walk_mins <= ?"
+		args = append(args, *f.WalkTimeTownMax)
+	}
+	if f.CycleTimeTownMax != nil {
+		query += " AND p.nearest_town_1_cycle_mins <= ?"
+		args = append(args, *f.CycleTimeTownMax)
+	}
+	// Energy development filters
+	if f.WindFarmMinKm != nil {
+		query += " AND (p.nearest_wind_farm_km IS NULL OR p.nearest_wind_farm_km >= ?)"
+		args = append(args, *f.WindFarmMinKm)
+	}
+	if f.SolarFarmMinKm != nil {
+		query += " AND (p.nearest_solar_farm_km IS NULL OR p.nearest_solar_farm_km >= ?)"
+		args = append(args, *f.SolarFarmMinKm)
+	}
+	conditions, noiseArgs := noiseConditions(f)
+	query += conditions
+	args = append(args, noiseArgs...)
+	conditions, overlayArgs := overlayConditions(f)
+	query += conditions
+	args = append(args, overlayArgs...)
+	conditions, vegetationArgs := vegetationConditions(f)
+	query += conditions
+	args = append(args, vegetationArgs...)
+
+	if f.HasDwelling != nil {
+		query += " AND p.has_dwelling = ?"
+		args = append(args, *f.HasDwelling)
+	}
+	if f.MinDwellings != nil {
+		query += " AND p.dwelling_count >= ?"
+		args = append(args, *f.MinDwellings)
+	}
+	if f.BoundaryMismatch {
+		query += " AND (p.suburb_mismatch = 1 OR p.postcode_mismatch = 1)"
+	}
+	if f.SubdivisionRatioMin != nil {
+		query += " AND p.subdivision_ratio >= ?"
+		args = append(args, *f.SubdivisionRatioMin)
+	}
+	conditions, roomArgs := roomConditions(f)
+	query += conditions
+	args = append(args, roomArgs...)
+
+	conditions, poiArgs := poiConditions(f)
+	query += conditions
+	args = append(args, poiArgs...)
+
+	// Tag filters
+	conditions, tagArgs := tagConditions(f)
+	query += conditions
+	args = append(args, tagArgs...)
 
 	// Map bounds filter
 	if f.SWLat != nil && f.SWLng != nil && f.NELat != nil && f.NELng != nil {
@@ -116,6 +376,15 @@ func (db *DB) ListProperties(f PropertyFilter) ([]models.PropertyListItem, error
 		args = append(args, *f.SWLat, *f.NELat, *f.SWLng, *f.NELng)
 	}
 
+	// Sort, with properties missing the value last
+	if expr, ok := propertySorts[strings.TrimPrefix(f.Sort, "-")]; ok {
+		direction := "ASC"
+		if strings.HasPrefix(f.Sort, "-") {
+			direction = "DESC"
+		}
+		query += fmt.Sprintf(" ORDER BY %s IS NULL, %s %s", expr, expr, direction)
+	}
+
 	// Apply limit only if specified
 	if f.Limit > 0 {
 		query += fmt.Sprintf(" LIMIT %d", f.Limit)
@@ -130,14 +399,7 @@ func (db *DB) ListProperties(f PropertyFilter) ([]models.PropertyListItem, error
 	query = strings.ReplaceAll(query, "?2", "?")
 	query = strings.ReplaceAll(query, "?3", "?")
 	query = strings.ReplaceAll(query, "?4", "?")
-
-	var properties []models.PropertyListItem
-	err := db.Select(&properties, query, args...)
-	if err != nil {
-		return nil, fmt.Errorf("failed to list properties: %w", err)
-	}
-
-	return properties, nil
+	return query, args
 }
 
 // GetProperty returns a single property by ID with full details
@@ -150,19 +412,38 @@ func (db *DB) GetProperty(id int64) (*models.PropertyDetail, error) {
 			state,
 			COALESCE(postcode, '') as postcode,
 			latitude, longitude,
+			COALESCE(coord_source, '') as coord_source, coord_confidence,
 			price_min, price_max,
 			COALESCE(price_text, '') as price_text,
 			COALESCE(property_type, '') as property_type,
-			bedrooms, bathrooms, land_size_sqm,
+			COALESCE(property_type_raw, '') as property_type_raw,
+			bedrooms, bathrooms, carspaces, dwelling_count, land_size_sqm,
 			COALESCE(description, '') as description,
 			COALESCE(images, '[]') as images,
-			listed_at,
-			drive_time_sydney,
+			listed_at, first_seen_at,
+			drive_time_primary,
 			nearest_town_1, nearest_town_1_km, nearest_town_1_mins,
 			nearest_town_2, nearest_town_2_km, nearest_town_2_mins,
+			nearest_town_1_walk_mins, nearest_town_1_cycle_mins,
 			nearest_school_1, nearest_school_1_km, nearest_school_1_mins, nearest_school_1_lat, nearest_school_1_lng,
-			nearest_school_2, nearest_school_2_km, nearest_school_2_mins, nearest_school_2_lat, nearest_school_2_lng
-		FROM properties WHERE id = ?
+			nearest_school_2, nearest_school_2_km, nearest_school_2_mins, nearest_school_2_lat, nearest_school_2_lng,
+			nearest_wind_farm, nearest_wind_farm_status, nearest_wind_farm_km,
+			nearest_solar_farm, nearest_solar_farm_status, nearest_solar_farm_km,
+			highway_km, railway_km, runway_km,
+			heritage_listing, koala_habitat_pct, biodiversity_pct,
+			lls_region, weed_zones, last_burn_year, burn_count_30y,
+			street_view_url, aerial_url, google_earth_url,
+			woody_cover_change, ndvi_change, clearing_baseline_year, clearing_flagged,
+			ndvi_mean, ndvi_seasonal_range, ndvi_monthly,
+			building_count, building_area_sqm, largest_building_sqm, has_dwelling,
+			min_lot_size_sqm, subdivision_ratio,
+			access_lat, access_lng, access_source,
+			gnaf_pid, gnaf_match,
+			(SELECT g.address FROM gnaf_addresses g WHERE g.address_detail_pid = p.gnaf_pid) as gnaf_address,
+			boundary_suburb, boundary_postcode, suburb_mismatch, postcode_mismatch, listed_suburb, listed_postcode,
+			land_value, land_value_base_date,
+			` + askingVsLandValueExpr + ` as asking_vs_land_value_ratio
+		FROM properties p WHERE id = ?
 	`
 
 	var p struct {
@@ -176,17 +457,23 @@ func (db *DB) GetProperty(id int64) (*models.PropertyDetail, error) {
 		Postcode           string   `db:"postcode"`
 		Latitude           float64  `db:"latitude"`
 		Longitude          float64  `db:"longitude"`
+		CoordSource        string   `db:"coord_source"`
+		CoordConfidence    *float64 `db:"coord_confidence"`
 		PriceMin           *int64   `db:"price_min"`
 		PriceMax           *int64   `db:"price_max"`
 		PriceText          string   `db:"price_text"`
 		PropertyType       string   `db:"property_type"`
+		PropertyTypeRaw    string   `db:"property_type_raw"`
 		Bedrooms           *int64   `db:"bedrooms"`
 		Bathrooms          *int64   `db:"bathrooms"`
+		Carspaces          *int64   `db:"carspaces"`
+		DwellingCount      *int     `db:"dwelling_count"`
 		LandSizeSqm        *float64 `db:"land_size_sqm"`
 		Description        string   `db:"description"`
 		Images             string   `db:"images"`
 		ListedAt           *string  `db:"listed_at"`
-		DriveTimeSydney    *int     `db:"drive_time_sydney"`
+		FirstSeenAt        *string  `db:"first_seen_at"`
+		DriveTimePrimary   *int     `db:"drive_time_primary"`
 		NearestTown1       *string  `db:"nearest_town_1"`
 		NearestTown1Km     *float64 `db:"nearest_town_1_km"`
 		NearestTown1Mins   *int     `db:"nearest_town_1_mins"`
@@ -203,6 +490,57 @@ func (db *DB) GetProperty(id int64) (*models.PropertyDetail, error) {
 		NearestSchool2Mins *int     `db:"nearest_school_2_mins"`
 		NearestSchool2Lat  *float64 `db:"nearest_school_2_lat"`
 		NearestSchool2Lng  *float64 `db:"nearest_school_2_lng"`
+		LandValue          *int64   `db:"land_value"`
+		LandValueBaseDate  *string  `db:"land_value_base_date"`
+		AskingVsLandValue  *float64 `db:"asking_vs_land_value_ratio"`
+
+		// Nearest wind and solar farms
+		NearestWindFarm        *string  `db:"nearest_wind_farm"`
+		NearestWindFarmStatus  *string  `db:"nearest_wind_farm_status"`
+		NearestWindFarmKm      *float64 `db:"nearest_wind_farm_km"`
+		NearestSolarFarm       *string  `db:"nearest_solar_farm"`
+		NearestSolarFarmStatus *string  `db:"nearest_solar_farm_status"`
+		NearestSolarFarmKm     *float64 `db:"nearest_solar_farm_km"`
+		HighwayKm              *float64 `db:"highway_km"`
+		RailwayKm              *float64 `db:"railway_km"`
+		RunwayKm               *float64 `db:"runway_km"`
+		HeritageListing        *string  `db:"heritage_listing"`
+		KoalaHabitatPct        *float64 `db:"koala_habitat_pct"`
+		BiodiversityPct        *float64 `db:"biodiversity_pct"`
+		LLSRegion              *string  `db:"lls_region"`
+		WeedZones              *string  `db:"weed_zones"`
+		LastBurnYear           *int     `db:"last_burn_year"`
+		BurnCount30y           *int     `db:"burn_count_30y"`
+		StreetViewURL          *string  `db:"street_view_url"`
+		AerialURL              *string  `db:"aerial_url"`
+		GoogleEarthURL         *string  `db:"google_earth_url"`
+		WoodyCoverChange       *float64 `db:"woody_cover_change"`
+		NDVIChange             *float64 `db:"ndvi_change"`
+		ClearingBaselineYear   *int     `db:"clearing_baseline_year"`
+		ClearingFlagged        *bool    `db:"clearing_flagged"`
+		NDVIMean               *float64 `db:"ndvi_mean"`
+		NDVISeasonalRange      *float64 `db:"ndvi_seasonal_range"`
+		NDVIMonthly            *string  `db:"ndvi_monthly"`
+		BuildingCount          *int     `db:"building_count"`
+		BuildingAreaSqm        *float64 `db:"building_area_sqm"`
+		LargestBuildingSqm     *float64 `db:"largest_building_sqm"`
+		HasDwelling            *bool    `db:"has_dwelling"`
+		MinLotSizeSqm          *float64 `db:"min_lot_size_sqm"`
+		SubdivisionRatio       *float64 `db:"subdivision_ratio"`
+		NearestTown1WalkMins   *int     `db:"nearest_town_1_walk_mins"`
+		NearestTown1CycleMins  *int     `db:"nearest_town_1_cycle_mins"`
+		AccessLat              *float64 `db:"access_lat"`
+		AccessLng              *float64 `db:"access_lng"`
+		AccessSource           *string  `db:"access_source"`
+		GNAFPID                *string  `db:"gnaf_pid"`
+		GNAFMatch              *string  `db:"gnaf_match"`
+		GNAFAddress            *string  `db:"gnaf_address"`
+		BoundarySuburb         *string  `db:"boundary_suburb"`
+		BoundaryPostcode       *string  `db:"boundary_postcode"`
+		SuburbMismatch         *bool    `db:"suburb_mismatch"`
+		PostcodeMismatch       *bool    `db:"postcode_mismatch"`
+		ListedSuburb           *string  `db:"listed_suburb"`
+		ListedPostcode         *string  `db:"listed_postcode"`
 	}
 
 	err := db.Get(&p, query, id)
@@ -212,9 +550,28 @@ func (db *DB) GetProperty(id int64) (*models.PropertyDetail, error) {
 
 	var images []string
 	json.Unmarshal([]byte(p.Images), &images)
+	var weedZones []models.WeedZone
+	if p.WeedZones != nil {
+		json.Unmarshal([]byte(*p.WeedZones), &weedZones)
+	}
+	var ndviMonthly []*float64
+	if p.NDVIMonthly != nil {
+		json.Unmarshal([]byte(*p.NDVIMonthly), &ndviMonthly)
+	}
 
 	// Get all sources for this property
 	sources, _ := db.GetPropertySources(id)
+	auctions, _ := db.GetPropertyAuctionResults(id)
+	priorSales, _ := db.GetPropertyPriorSales(id)
+	lotLandValues, _ := db.GetPropertyLotLandValues(id)
+	mergedFields, _ := db.GetMergedFields(id)
+	tags, _ := db.GetPropertyTags(id)
+	attachments, _ := db.ListAttachments(id)
+	heritageItems, _ := db.GetPropertyHeritageItems(id)
+	overlayLots, _ := db.GetPropertyLotOverlays(id)
+	lotFires, _ := db.GetPropertyLotFires(id)
+	poiTimes, _ := db.GetPropertyPOITimes(id)
+	videos, floorplans, _ := db.GetPropertyMedia(id)
 
 	return &models.PropertyDetail{
 		ID:                 p.ID,
@@ -222,23 +579,42 @@ func (db *DB) GetProperty(id int64) (*models.PropertyDetail, error) {
 		Source:             p.Source,
 		URL:                p.URL,
 		Sources:            sources,
+		Tags:               tags,
+		HeritageListing:    p.HeritageListing,
+		HeritageItems:      heritageItems,
+		Attachments:        attachments,
+		MergedFields:       mergedFields,
+		AuctionResults:     auctions,
+		PriorSales:         priorSales,
+		LandValue:          p.LandValue,
+		LandValueBaseDate:  p.LandValueBaseDate,
+		LotLandValues:      lotLandValues,
+		AskingVsLandValue:  p.AskingVsLandValue,
 		Address:            p.Address,
 		Suburb:             p.Suburb,
 		State:              p.State,
 		Postcode:           p.Postcode,
 		Latitude:           p.Latitude,
 		Longitude:          p.Longitude,
+		CoordSource:        p.CoordSource,
+		CoordConfidence:    p.CoordConfidence,
 		PriceMin:           p.PriceMin,
 		PriceMax:           p.PriceMax,
 		PriceText:          p.PriceText,
 		PropertyType:       p.PropertyType,
+		PropertyTypeRaw:    p.PropertyTypeRaw,
 		Bedrooms:           p.Bedrooms,
 		Bathrooms:          p.Bathrooms,
+		Carspaces:          p.Carspaces,
+		DwellingCount:      p.DwellingCount,
 		LandSizeSqm:        p.LandSizeSqm,
 		Description:        p.Description,
 		Images:             images,
+		Videos:             videos,
+		Floorplans:         floorplans,
 		ListedAt:           p.ListedAt,
-		DriveTimeSydney:    p.DriveTimeSydney,
+		FirstSeenAt:        p.FirstSeenAt,
+		DriveTimePrimary:   p.DriveTimePrimary,
 		NearestTown1:       p.NearestTown1,
 		NearestTown1Km:     p.NearestTown1Km,
 		NearestTown1Mins:   p.NearestTown1Mins,
@@ -255,6 +631,55 @@ func (db *DB) GetProperty(id int64) (*models.PropertyDetail, error) {
 		NearestSchool2Mins: p.NearestSchool2Mins,
 		NearestSchool2Lat:  p.NearestSchool2Lat,
 		NearestSchool2Lng:  p.NearestSchool2Lng,
+
+		NearestWindFarm:        p.NearestWindFarm,
+		NearestWindFarmStatus:  p.NearestWindFarmStatus,
+		NearestWindFarmKm:      p.NearestWindFarmKm,
+		NearestSolarFarm:       p.NearestSolarFarm,
+		NearestSolarFarmStatus: p.NearestSolarFarmStatus,
+		NearestSolarFarmKm:     p.NearestSolarFarmKm,
+		HighwayKm:              p.HighwayKm,
+		RailwayKm:              p.RailwayKm,
+		RunwayKm:               p.RunwayKm,
+		KoalaHabitatPct:        p.KoalaHabitatPct,
+		BiodiversityPct:        p.BiodiversityPct,
+		OverlayLots:            overlayLots,
+		LLSRegion:              p.LLSRegion,
+		WeedZones:              weedZones,
+		LastBurnYear:           p.LastBurnYear,
+		BurnCount30y:           p.BurnCount30y,
+		LotFires:               lotFires,
+		StreetViewURL:          p.StreetViewURL,
+		AerialURL:              p.AerialURL,
+		GoogleEarthURL:         p.GoogleEarthURL,
+		WoodyCoverChange:       p.WoodyCoverChange,
+		NDVIChange:             p.NDVIChange,
+		ClearingBaselineYear:   p.ClearingBaselineYear,
+		ClearingFlagged:        p.ClearingFlagged,
+		NDVIMean:               p.NDVIMean,
+		NDVISeasonalRange:      p.NDVISeasonalRange,
+		NDVIMonthly:            ndviMonthly,
+		BuildingCount:          p.BuildingCount,
+		BuildingAreaSqm:        p.BuildingAreaSqm,
+		LargestBuildingSqm:     p.LargestBuildingSqm,
+		HasDwelling:            p.HasDwelling,
+		MinLotSizeSqm:          p.MinLotSizeSqm,
+		SubdivisionRatio:       p.SubdivisionRatio,
+		NearestTown1WalkMins:   p.NearestTown1WalkMins,
+		NearestTown1CycleMins:  p.NearestTown1CycleMins,
+		AccessLat:              p.AccessLat,
+		AccessLng:              p.AccessLng,
+		AccessSource:           p.AccessSource,
+		GNAFPID:                p.GNAFPID,
+		GNAFAddress:            p.GNAFAddress,
+		GNAFMatch:              p.GNAFMatch,
+		BoundarySuburb:         p.BoundarySuburb,
+		BoundaryPostcode:       p.BoundaryPostcode,
+		SuburbMismatch:         p.SuburbMismatch,
+		PostcodeMismatch:       p.PostcodeMismatch,
+		ListedSuburb:           p.ListedSuburb,
+		ListedPostcode:         p.ListedPostcode,
+		POITimes:               poiTimes,
 	}, nil
 }
 
@@ -262,12 +687,18 @@ func (db *DB) GetProperty(id int64) (*models.PropertyDetail, error) {
 func (db *DB) GetFilterOptions() (map[string]interface{}, error) {
 	options := make(map[string]interface{})
 
-	// Get distinct property types
-	var types []string
-	err := db.Select(&types, "SELECT DISTINCT property_type FROM properties WHERE property_type IS NOT NULL ORDER BY property_type")
+	// Canonical property types in use, in taxonomy order
+	var inUse []string
+	err := db.Select(&inUse, "SELECT DISTINCT property_type FROM properties WHERE property_type IS NOT NULL")
 	if err != nil {
 		return nil, err
 	}
+	types := []string{}
+	for _, t := range PropertyTypes {
+		if slices.Contains(inUse, t) {
+			types = append(types, t)
+		}
+	}
 	options["property_types"] = types
 
 	// Get price range
@@ -294,53 +725,110 @@ func (db *DB) GetFilterOptions() (map[string]interface{}, error) {
 	options["land_size_min"] = landRange.Min
 	options["land_size_max"] = landRange.Max
 
+	// Tags in use, for the tag filter
+	tags, err := db.ListTags()
+	if err != nil {
+		return nil, err
+	}
+	options["tags"] = tags
+
 	return options, nil
 }
 
-// UpsertProperty inserts or updates a property based on external_id
-func (db *DB) UpsertProperty(p *models.Property) error {
-	query := `
-		INSERT INTO properties (
-			external_id, source, url, address, suburb, state, postcode,
-			latitude, longitude, price_min, price_max, price_text,
-			property_type, bedrooms, bathrooms, land_size_sqm,
-			description, images, listed_at, scraped_at, updated_at
-		) VALUES (
-			?, ?, ?, ?, ?, ?, ?,
-			?, ?, ?, ?, ?,
-			?, ?, ?, ?,
-			?, ?, ?, ?, ?
-		)
-		ON CONFLICT(external_id, source) DO UPDATE SET
-			url = excluded.url,
-			address = COALESCE(excluded.address, properties.address),
-			suburb = COALESCE(excluded.suburb, properties.suburb),
-			postcode = COALESCE(excluded.postcode, properties.postcode),
-			latitude = COALESCE(excluded.latitude, properties.latitude),
-			longitude = COALESCE(excluded.longitude, properties.longitude),
-			price_min = COALESCE(excluded.price_min, properties.price_min),
-			price_max = COALESCE(excluded.price_max, properties.price_max),
-			price_text = COALESCE(excluded.price_text, properties.price_text),
-			property_type = COALESCE(excluded.property_type, properties.property_type),
-			bedrooms = COALESCE(excluded.bedrooms, properties.bedrooms),
-			bathrooms = COALESCE(excluded.bathrooms, properties.bathrooms),
-			land_size_sqm = COALESCE(excluded.land_size_sqm, properties.land_size_sqm),
-			description = COALESCE(excluded.description, properties.description),
-			images = COALESCE(excluded.images, properties.images),
-			scraped_at = excluded.scraped_at,
-			updated_at = excluded.updated_at
-	`
+// upsertPropertyQuery inserts a listing or updates it by (external_id, source),
+// keeping existing values where the new scrape has none. first_seen_at is only
+// set on insert. Manually corrected coordinates are never overwritten, nor
+// is a suburb or postcode corrected by its boundaries while the listing
+// still gives the one it was corrected from.
+const upsertPropertyQuery = `
+	INSERT INTO properties (
+		external_id, source, url, address, suburb, state, postcode,
+		latitude, longitude, coord_source, coord_confidence, coord_updated_at,
+		price_min, price_max, price_text,
+		property_type, property_type_raw, bedrooms, bathrooms, carspaces, land_size_sqm,
+		description, images, listed_at, scraped_at, updated_at, first_seen_at
+	) VALUES (
+		?, ?, ?, ?, ?, ?, ?,
+		?, ?, ?, ?, ?,
+		?, ?, ?,
+		?, ?, ?, ?, ?, ?,
+		?, ?, ?, ?, ?, ?
+	)
+	ON CONFLICT(external_id, source) DO UPDATE SET
+		url = excluded.url,
+		address = COALESCE(excluded.address, properties.address),
+		suburb = CASE WHEN excluded.suburb = properties.listed_suburb THEN properties.suburb
+			ELSE COALESCE(excluded.suburb, properties.suburb) END,
+		postcode = CASE WHEN excluded.postcode = properties.listed_postcode THEN properties.postcode
+			ELSE COALESCE(excluded.postcode, properties.postcode) END,
+		listed_suburb = CASE WHEN excluded.suburb IS NULL OR excluded.suburb = properties.listed_suburb
+			THEN properties.listed_suburb END,
+		listed_postcode = CASE WHEN excluded.postcode IS NULL OR excluded.postcode = properties.listed_postcode
+			THEN properties.listed_postcode END,
+		latitude = CASE WHEN properties.coord_source = 'manual' THEN properties.latitude
+			ELSE COALESCE(excluded.latitude, properties.latitude) END,
+		longitude = CASE WHEN properties.coord_source = 'manual' THEN properties.longitude
+			ELSE COALESCE(excluded.longitude, properties.longitude) END,
+		coord_updated_at = CASE WHEN properties.coord_source = 'manual' OR excluded.latitude IS NULL
+			OR (excluded.latitude IS properties.latitude AND excluded.longitude IS properties.longitude
+				AND excluded.coord_source IS properties.coord_source)
+			THEN properties.coord_updated_at ELSE excluded.coord_updated_at END,
+		coord_source = CASE WHEN properties.coord_source = 'manual' OR excluded.latitude IS NULL
+			THEN properties.coord_source ELSE excluded.coord_source END,
+		coord_confidence = CASE WHEN properties.coord_source = 'manual' OR excluded.latitude IS NULL
+			THEN properties.coord_confidence ELSE excluded.coord_confidence END,
+		price_min = COALESCE(excluded.price_min, properties.price_min),
+		price_max = COALESCE(excluded.price_max, properties.price_max),
+		price_text = COALESCE(excluded.price_text, properties.price_text),
+		property_type = COALESCE(excluded.property_type, properties.property_type),
+		property_type_raw = COALESCE(excluded.property_type_raw, properties.property_type_raw),
+		bedrooms = COALESCE(excluded.bedrooms, properties.bedrooms),
+		bathrooms = COALESCE(excluded.bathrooms, properties.bathrooms),
+		carspaces = COALESCE(excluded.carspaces, properties.carspaces),
+		land_size_sqm = COALESCE(excluded.land_size_sqm, properties.land_size_sqm),
+		description = COALESCE(excluded.description, properties.description),
+		images = COALESCE(excluded.images, properties.images),
+		scraped_at = excluded.scraped_at,
+		updated_at = excluded.updated_at
+`
+
+// listingCoordConfidence is the confidence given to coordinates a listing
+// source supplied itself; agents usually pin the house or gate, but some
+// sources only have the suburb
+const listingCoordConfidence = 0.8
+
+// upsertPropertyArgs returns the arguments for upsertPropertyQuery. The
+// listing's property type is saved as-is to property_type_raw and filed
+// under its canonical type.
+func upsertPropertyArgs(p *models.Property) []interface{} {
+	coordSource, coordConfidence := p.CoordSource, p.CoordConfidence
+	var coordUpdatedAt interface{}
+	if p.Latitude.Valid && p.Longitude.Valid {
+		if !coordSource.Valid {
+			coordSource = sql.NullString{String: "listing", Valid: true}
+			coordConfidence = sql.NullFloat64{Float64: listingCoordConfidence, Valid: true}
+		}
+		coordUpdatedAt = p.ScrapedAt
+	} else {
+		coordSource, coordConfidence = sql.NullString{}, sql.NullFloat64{}
+	}
 
-	_, err := db.Exec(query,
+	return []interface{}{
 		p.ExternalID, p.Source, p.URL,
 		p.Address, p.Suburb, p.State, p.Postcode,
-		p.Latitude, p.Longitude,
+		p.Latitude, p.Longitude, coordSource, coordConfidence, coordUpdatedAt,
 		p.PriceMin, p.PriceMax, p.PriceText,
-		p.PropertyType, p.Bedrooms, p.Bathrooms, p.LandSizeSqm,
+		nullString(CanonicalPropertyType(p.PropertyType.String)), p.PropertyType,
+		p.Bedrooms, p.Bathrooms, p.Carspaces, p.LandSizeSqm,
 		p.Description, p.Images, p.ListedAt,
-		p.ScrapedAt, p.UpdatedAt,
-	)
+		p.ScrapedAt, p.UpdatedAt, p.ScrapedAt,
+	}
+}
 
+// UpsertProperty inserts or updates a property based on external_id
+func (db *DB) UpsertProperty(p *models.Property) error {
+	sanitizeDescription(&p.Description)
+	_, err := db.Exec(upsertPropertyQuery, upsertPropertyArgs(p)...)
 	return err
 }
 
@@ -364,9 +852,14 @@ func (db *DB) SavePropertyDistance(propertyID int64, targetType, targetName stri
 }
 
 // FindDuplicateProperties finds properties that appear to be the same based on coordinates
-// Properties within ~100m of each other are considered potential duplicates
+// Properties within ~100m of each other with similar land sizes (or either
+// unknown) are considered potential duplicates, as are properties matched to
+// the same G-NAF address by number, unless the pair has been rejected
+// through the link management API
 func (db *DB) FindDuplicateProperties() error {
-	// Find properties with nearly identical coordinates (within ~0.001 degrees ≈ 100m)
+	// Find properties with nearly identical coordinates (within ~0.001 degrees
+	// ≈ 100m) and similar land sizes; neighbouring farms listed at a shared
+	// gate differ in size. Pairs further apart are left to the suspect queue.
 	query := `
 		INSERT OR IGNORE INTO property_links (canonical_id, duplicate_id, match_type)
 		SELECT 
@@ -380,10 +873,15 @@ func (db *DB) FindDuplicateProperties() error {
 			AND ABS(p1.longitude - p2.longitude) < 0.001
 			AND p1.latitude IS NOT NULL
 			AND p2.latitude IS NOT NULL
-		WHERE NOT EXISTS (
+		WHERE ` + similarLandSize + `
+		AND NOT EXISTS (
 			SELECT 1 FROM property_links pl 
 			WHERE pl.duplicate_id = p2.id
 		)
+		AND NOT EXISTS (
+			SELECT 1 FROM property_link_rejections r
+			WHERE r.property_id_a = p1.id AND r.property_id_b = p2.id
+		)
 	`
 	result, err := db.Exec(query)
 	if err != nil {
@@ -391,10 +889,44 @@ func (db *DB) FindDuplicateProperties() error {
 	}
 
 	rows, _ := result.RowsAffected()
+
+	// The same G-NAF address, however far apart the pins (a listing geocoded
+	// to the suburb, say). A match to the nearest address on the street is a
+	// guess, so doesn't count.
+	result, err = db.Exec(`
+		INSERT OR IGNORE INTO property_links (canonical_id, duplicate_id, match_type)
+		SELECT p1.id, p2.id, 'gnaf'
+		FROM properties p1
+		JOIN properties p2 ON p1.id < p2.id
+			AND p1.source != p2.source
+			AND p1.gnaf_pid = p2.gnaf_pid
+			AND p1.gnaf_match IN ('number', 'lot')
+			AND p2.gnaf_match IN ('number', 'lot')
+		WHERE ` + similarLandSize + `
+		AND NOT EXISTS (SELECT 1 FROM property_links pl WHERE pl.duplicate_id IN (p1.id, p2.id))
+		AND NOT EXISTS (
+			SELECT 1 FROM property_link_rejections r
+			WHERE r.property_id_a = p1.id AND r.property_id_b = p2.id
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to find duplicates by G-NAF address: %w", err)
+	}
+	gnafRows, _ := result.RowsAffected()
+	rows += gnafRows
+
 	if rows > 0 {
 		fmt.Printf("Linked %d duplicate properties\n", rows)
 	}
 
+	merged, err := db.MergeDuplicateProperties()
+	if err != nil {
+		return err
+	}
+	if merged > 0 {
+		fmt.Printf("Merged %d fields from duplicate listings\n", merged)
+	}
+
 	return nil
 }
 
@@ -446,6 +978,50 @@ func (db *DB) GetPropertySources(propertyID int64) ([]models.PropertySource, err
 	return sources, nil
 }
 
+// GetCanonicalPropertyID returns the canonical property a duplicate listing is
+// linked to, or id itself if it isn't a duplicate
+func (db *DB) GetCanonicalPropertyID(id int64) (int64, error) {
+	var canonicalID int64
+	err := db.Get(&canonicalID, "SELECT canonical_id FROM property_links WHERE duplicate_id = ?", id)
+	if err == sql.ErrNoRows {
+		return id, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to get canonical property: %w", err)
+	}
+	return canonicalID, nil
+}
+
+// GetDuplicatePropertyIDs returns the duplicate listings linked to any of
+// canonicalIDs, mapped to their canonical property ID
+func (db *DB) GetDuplicatePropertyIDs(canonicalIDs []int64) (map[int64]int64, error) {
+	duplicates := make(map[int64]int64)
+	if len(canonicalIDs) == 0 {
+		return duplicates, nil
+	}
+
+	placeholders := make([]string, len(canonicalIDs))
+	args := make([]interface{}, len(canonicalIDs))
+	for i, id := range canonicalIDs {
+		placeholders[i] = "?"
+		args[i] = id
+	}
+
+	var links []struct {
+		DuplicateID int64 `db:"duplicate_id"`
+		CanonicalID int64 `db:"canonical_id"`
+	}
+	query := fmt.Sprintf("SELECT duplicate_id, canonical_id FROM property_links WHERE canonical_id IN (%s)",
+		strings.Join(placeholders, ","))
+	if err := db.Select(&links, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to get duplicate properties: %w", err)
+	}
+	for _, l := range links {
+		duplicates[l.DuplicateID] = l.CanonicalID
+	}
+	return duplicates, nil
+}
+
 // GetPropertyDistances returns all distance calculations for a property
 func (db *DB) GetPropertyDistances(propertyID int64) ([]models.PropertyDistance, error) {
 	query := `SELECT property_id, target_type, target_name, distance_km, drive_time_mins 
@@ -457,7 +1033,7 @@ func (db *DB) GetPropertyDistances(propertyID int64) ([]models.PropertyDistance,
 
 // UpdatePropertyDriveTime updates the drive time to Sydney for a property
 func (db *DB) UpdatePropertyDriveTime(propertyID int64, driveTimeMins int) error {
-	_, err := db.Exec("UPDATE properties SET drive_time_sydney = ? WHERE id = ?", driveTimeMins, propertyID)
+	_, err := db.Exec("UPDATE properties SET drive_time_primary = ? WHERE id = ?", driveTimeMins, propertyID)
 	return err
 }
 
@@ -476,7 +1052,7 @@ func (db *DB) GetPropertiesWithoutDriveTime() ([]models.PropertyListItem, error)
 		FROM properties 
 		WHERE latitude IS NOT NULL 
 			AND longitude IS NOT NULL 
-			AND drive_time_sydney IS NULL
+			AND drive_time_primary IS NULL
 	`
 	var properties []models.PropertyListItem
 	err := db.Select(&properties, query)
@@ -495,7 +1071,12 @@ func (db *DB) SaveCadastralLot(lotIDString, lotNumber, planLabel string, areaSqm
 			geometry = excluded.geometry,
 			centroid_lat = excluded.centroid_lat,
 			centroid_lng = excluded.centroid_lng,
-			fetched_at = excluded.fetched_at
+			fetched_at = excluded.fetched_at,
+			-- A redrawn lot needs checking against the heritage and lot size layers again
+			heritage_checked_at = CASE WHEN cadastral_lots.geometry IS excluded.geometry
+				THEN cadastral_lots.heritage_checked_at END,
+			min_lot_size_sqm = CASE WHEN cadastral_lots.geometry IS excluded.geometry
+				THEN cadastral_lots.min_lot_size_sqm END
 		RETURNING id
 	`
 
@@ -526,11 +1107,19 @@ func (db *DB) LinkPropertyToLot(propertyID, lotID int64) error {
 	return err
 }
 
+// UnlinkPropertyLots removes all of a property's cadastral lot links, before
+// relinking it to the lots at new coordinates
+func (db *DB) UnlinkPropertyLots(propertyID int64) error {
+	_, err := db.Exec("DELETE FROM property_lots WHERE property_id = ?", propertyID)
+	return err
+}
+
 // GetPropertyLots returns all cadastral lots linked to a property
 func (db *DB) GetPropertyLots(propertyID int64) ([]models.CadastralLot, error) {
 	query := `
-		SELECT cl.* FROM cadastral_lots cl
+		SELECT cl.*, lv.land_value FROM cadastral_lots cl
 		JOIN property_lots pl ON cl.id = pl.lot_id
+		LEFT JOIN land_values lv ON lv.lot_id_string = cl.lot_id_string
 		WHERE pl.property_id = ?
 	`
 	var lots []models.CadastralLot
@@ -613,6 +1202,54 @@ func (db *DB) PropertiesExist(externalIDs []string, source string) (map[string]b
 	return result, nil
 }
 
+// listingColumns are the properties columns a scraper fills in, for reading
+// listings back as models.Property (timestamps are left out: their format
+// has varied over time). The property type is the source's, as scraped.
+const listingColumns = `id, external_id, source, url, address, suburb, COALESCE(state, 'NSW') AS state, postcode,
+	latitude, longitude, coord_source, coord_confidence, price_min, price_max, price_text,
+	COALESCE(property_type_raw, property_type) AS property_type, bedrooms, bathrooms, carspaces, land_size_sqm, description, images`
+
+// GetListings returns the stored listings with the given IDs, skipping IDs
+// that don't exist
+func (db *DB) GetListings(ids []int64) ([]models.Property, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	placeholders := make([]string, len(ids))
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		placeholders[i] = "?"
+		args[i] = id
+	}
+	query := fmt.Sprintf("SELECT %s FROM properties WHERE id IN (%s) ORDER BY id",
+		listingColumns, strings.Join(placeholders, ","))
+
+	var listings []models.Property
+	if err := db.Select(&listings, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to get listings: %w", err)
+	}
+	return listings, nil
+}
+
+// GetListingByURL returns the stored listing with a URL, ignoring any query
+// string or trailing slash. Returns nil if there isn't one.
+func (db *DB) GetListingByURL(listingURL string) (*models.Property, error) {
+	base := strings.TrimSuffix(strings.SplitN(listingURL, "?", 2)[0], "/")
+	var listing models.Property
+	err := db.Get(&listing, "SELECT "+listingColumns+` FROM properties
+		WHERE url IN (?, ?)
+			OR (INSTR(url, '?') > 0 AND SUBSTR(url, 1, INSTR(url, '?') - 1) IN (?, ?))
+		ORDER BY id LIMIT 1`,
+		base, base+"/", base, base+"/")
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get listing: %w", err)
+	}
+	return &listing, nil
+}
+
 // GetBoundariesInBounds returns cadastral lot boundaries for properties matching the filter
 // Returns a list of lots with their geometry (GeoJSON) and associated property IDs
 // Applies the same filters as ListProperties to ensure boundaries match visible properties
@@ -620,9 +1257,11 @@ func (db *DB) GetBoundariesInBounds(f PropertyFilter) ([]models.CadastralLot, er
 	// Build base query with same joins as ListProperties for filtering
 	query := `
 		SELECT DISTINCT cl.id, cl.lot_id_string, cl.lot_number, cl.plan_label, 
-			   cl.area_sqm, cl.geometry, cl.centroid_lat, cl.centroid_lng, cl.fetched_at
+			   cl.area_sqm, cl.geometry, cl.centroid_lat, cl.centroid_lng, cl.fetched_at,
+			   lv.land_value
 		FROM cadastral_lots cl
 		JOIN property_lots pl ON cl.id = pl.lot_id
+		LEFT JOIN land_values lv ON lv.lot_id_string = cl.lot_id_string
 		JOIN properties p ON pl.property_id = p.id
 		LEFT JOIN property_distances pd_sydney ON p.id = pd_sydney.property_id 
 			AND pd_sydney.target_type = 'capital' AND pd_sydney.target_name = 'Sydney'
@@ -673,9 +1312,9 @@ func (db *DB) GetBoundariesInBounds(f PropertyFilter) ([]models.CadastralLot, er
 		args = append(args, *f.DistanceTownMax)
 	}
 	// Drive time filters
-	if f.DriveTimeSydneyMax != nil {
-		query += " AND p.drive_time_sydney <= ?"
-		args = append(args, *f.DriveTimeSydneyMax)
+	if f.DriveTimePrimaryMax != nil {
+		query += " AND p.drive_time_primary <= ?"
+		args = append(args, *f.DriveTimePrimaryMax)
 	}
 	if f.DriveTimeTownMax != nil {
 		query += " AND p.nearest_town_1_mins <= ?"
@@ -685,6 +1324,60 @@ func (db *DB) GetBoundariesInBounds(f PropertyFilter) ([]models.CadastralLot, er
 		query += " AND p.nearest_school_1_mins <= ?"
 		args = append(args, *f.DriveTimeSchoolMax)
 	}
+	if f.WalkTimeTownMax != nil {
+		query += " AND p.nearest_town_1_walk_mins <= ?"
+		args = append(args, *f.WalkTimeTownMax)
+	}
+	if f.CycleTimeTownMax != nil {
+		query += " AND p.nearest_town_1_cycle_mins <= ?"
+		args = append(args, *f.CycleTimeTownMax)
+	}
+	// Energy development filters
+	if f.WindFarmMinKm != nil {
+		query += " AND (p.nearest_wind_farm_km IS NULL OR p.nearest_wind_farm_km >= ?)"
+		args = append(args, *f.WindFarmMinKm)
+	}
+	if f.SolarFarmMinKm != nil {
+		query += " AND (p.nearest_solar_farm_km IS NULL OR p.nearest_solar_farm_km >= ?)"
+		args = append(args, *f.SolarFarmMinKm)
+	}
+	conditions, noiseArgs := noiseConditions(f)
+	query += conditions
+	args = append(args, noiseArgs...)
+	conditions, overlayArgs := overlayConditions(f)
+	query += conditions
+	args = append(args, overlayArgs...)
+	conditions, vegetationArgs := vegetationConditions(f)
+	query += conditions
+	args = append(args, vegetationArgs...)
+
+	if f.HasDwelling != nil {
+		query += " AND p.has_dwelling = ?"
+		args = append(args, *f.HasDwelling)
+	}
+	if f.MinDwellings != nil {
+		query += " AND p.dwelling_count >= ?"
+		args = append(args, *f.MinDwellings)
+	}
+	if f.BoundaryMismatch {
+		query += " AND (p.suburb_mismatch = 1 OR p.postcode_mismatch = 1)"
+	}
+	if f.SubdivisionRatioMin != nil {
+		query += " AND p.subdivision_ratio >= ?"
+		args = append(args, *f.SubdivisionRatioMin)
+	}
+	conditions, roomArgs := roomConditions(f)
+	query += conditions
+	args = append(args, roomArgs...)
+
+	conditions, poiArgs := poiConditions(f)
+	query += conditions
+	args = append(args, poiArgs...)
+
+	// Tag filters
+	conditions, tagArgs := tagConditions(f)
+	query += conditions
+	args = append(args, tagArgs...)
 
 	// Map bounds filter - check both property coords and lot centroid
 	if f.SWLat != nil && f.SWLng != nil && f.NELat != nil && f.NELng != nil {
@@ -736,8 +1429,10 @@ func (db *DB) MarkPropertyDetailsScraped(id int64) error {
 	return err
 }
 
-// UpdatePropertyFromDetails updates a property with details fetched from the listing page
-func (db *DB) UpdatePropertyFromDetails(id int64, description, images string, landSizeSqm *float64, bedrooms, bathrooms *int64, priceMin, priceMax *int64) error {
+// UpdatePropertyFromDetails updates a property with details fetched from the
+// listing page, sanitizing the description to plain text
+func (db *DB) UpdatePropertyFromDetails(id int64, description, images string, landSizeSqm *float64, bedrooms, bathrooms, carspaces *int64, priceMin, priceMax *int64) error {
+	description = sanitize.Description(description)
 	_, err := db.Exec(`
 		UPDATE properties SET
 			description = COALESCE(?, description),
@@ -745,12 +1440,13 @@ func (db *DB) UpdatePropertyFromDetails(id int64, description, images string, la
 			land_size_sqm = COALESCE(?, land_size_sqm),
 			bedrooms = COALESCE(?, bedrooms),
 			bathrooms = COALESCE(?, bathrooms),
+			carspaces = COALESCE(?, carspaces),
 			price_min = COALESCE(?, price_min),
 			price_max = COALESCE(?, price_max),
 			details_scraped_at = CURRENT_TIMESTAMP,
 			updated_at = CURRENT_TIMESTAMP
 		WHERE id = ?
-	`, description, images, landSizeSqm, bedrooms, bathrooms, priceMin, priceMax, id)
+	`, description, images, landSizeSqm, bedrooms, bathrooms, carspaces, priceMin, priceMax, id)
 	return err
 }
 
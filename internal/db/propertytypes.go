@@ -0,0 +1,117 @@
+package db
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// PropertyTypes are the canonical property types listings are filed under,
+// from most to least farm-like. The source's own wording is kept in
+// property_type_raw.
+var PropertyTypes = []string{
+	"farm",
+	"rural",
+	"lifestyle",
+	"acreage-semi-rural",
+	"land",
+	"house",
+	"other",
+}
+
+// propertyTypeMap maps source property types, normalized by
+// propertyTypeKey, to canonical types. Canonical types map to themselves so
+// they can be given as filters.
+var propertyTypeMap = map[string]string{
+	// Farms: REA and FarmBuy file these by enterprise
+	"farm":           "farm",
+	"specialistfarm": "farm",
+	"cropping":       "farm",
+	"livestock":      "farm",
+	"mixedfarming":   "farm",
+	"dairy":          "farm",
+	"horticulture":   "farm",
+	"viticulture":    "farm",
+
+	"rural":      "rural",
+	"otherrural": "rural",
+
+	"lifestyle":          "lifestyle",
+	"rurallifestyle":     "lifestyle",
+	"farmlet":            "lifestyle",
+	"hobbyfarmsfarmlets": "lifestyle",
+
+	"acreage":          "acreage-semi-rural",
+	"acreagesemirural": "acreage-semi-rural",
+
+	"land":             "land",
+	"vacantland":       "land",
+	"residentialland":  "land",
+	"residentialblock": "land",
+
+	"house": "house",
+
+	"other":        "other",
+	"waterlicense": "other",
+}
+
+// propertyTypeKey normalizes a source property type for propertyTypeMap,
+// so "AcreageSemiRural" and "acreage/semi-rural" match
+func propertyTypeKey(raw string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(raw) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// CanonicalPropertyType returns the canonical type for a source property
+// type, "other" for types the taxonomy doesn't know, or "" for an empty one
+func CanonicalPropertyType(raw string) string {
+	key := propertyTypeKey(raw)
+	if key == "" {
+		return ""
+	}
+	if canonical, ok := propertyTypeMap[key]; ok {
+		return canonical
+	}
+	return "other"
+}
+
+// canonicalizePropertyTypes files properties saved before the taxonomy
+// under their canonical type, moving the source's wording to
+// property_type_raw, and rewrites snapshotted types so saved search diffs
+// don't report every property as changed
+func canonicalizePropertyTypes(db *sqlx.DB) error {
+	var raws []string
+	if err := db.Select(&raws, `SELECT DISTINCT property_type FROM properties
+		WHERE property_type IS NOT NULL AND property_type_raw IS NULL`); err != nil {
+		return fmt.Errorf("failed to get property types: %w", err)
+	}
+	for _, raw := range raws {
+		if _, err := db.Exec(`UPDATE properties SET property_type_raw = property_type, property_type = ?
+			WHERE property_type = ? AND property_type_raw IS NULL`, nullString(CanonicalPropertyType(raw)), raw); err != nil {
+			return fmt.Errorf("failed to canonicalize property type %q: %w", raw, err)
+		}
+	}
+
+	raws = nil
+	if err := db.Select(&raws, "SELECT DISTINCT property_type FROM saved_search_snapshot_items WHERE property_type IS NOT NULL"); err != nil {
+		return fmt.Errorf("failed to get snapshot property types: %w", err)
+	}
+	for _, raw := range raws {
+		canonical := CanonicalPropertyType(raw)
+		if canonical == raw {
+			continue
+		}
+		if _, err := db.Exec("UPDATE saved_search_snapshot_items SET property_type = ? WHERE property_type = ?",
+			nullString(canonical), raw); err != nil {
+			return fmt.Errorf("failed to canonicalize snapshot property type %q: %w", raw, err)
+		}
+	}
+	return nil
+}
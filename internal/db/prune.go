@@ -0,0 +1,185 @@
+package db
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// PruneResult counts the rows a prune removed (or would remove, on a dry run)
+type PruneResult struct {
+	Properties     int64
+	BySource       map[string]int64
+	Distances      int64
+	LotLinks       int64
+	DuplicateLinks int64
+	LinkRejections int64 // Pairs rejected as duplicates with a pruned property
+	MergedFields   int64 // Provenance of fields merged from or onto a pruned property
+	Events         int64
+	Media          int64 // Videos and floorplans
+	Archived       int64 // Recorded in pruned_listings, for their history and change log
+	AuctionResults int64 // Unlinked from the property, not deleted
+	StaleSteps     int64 // Pending re-enrichment of a pruned property
+	RouteReviews   int64
+	GeocodeReviews int64
+	RoadSnaps      int64 // Nearest-road diagnoses from tools unroutable
+	Scores         int64
+	Tags           int64
+	POITimes       int64 // Distances and drive times to user POIs
+	Kept           int64 // Delisted but kept because documents are attached
+	StillListed    int64 // Delisted but kept because a duplicate listing of it is still scraped
+	OrphanLots     int64
+	StaleSources   []string // Not scraped since the cutoff, so left alone
+
+	Pruned []ListingRef // The properties removed
+}
+
+// PruneDelistedProperties deletes properties last scraped before cutoff, with
+// their distances, lot links, duplicate links, events and media, in one transaction.
+// A listing only counts as delisted if its source has been scraped since the
+// cutoff, so a source whose scraper stopped working isn't wiped out. With
+// orphanLots, cadastral lots no longer linked to any property are deleted
+// too. Properties with attachments are kept, since the documents were added
+// by hand and the files would be orphaned, as are canonical properties with a
+// duplicate listing that isn't delisted. A dry run does the same work and rolls it back.
+//
+// Listing history and the change log are kept. Pruned listings are recorded
+// in pruned_listings, and pruned canonical properties' property_search rows
+// copied to property_search_pruned, so history and as-of queries answer as
+// before (but for filters on scores, tags and POI times, which are deleted).
+//
+// Foreign keys aren't enforced on our connections, so dependent rows are
+// removed explicitly rather than relying on ON DELETE CASCADE.
+func (db *DB) PruneDelistedProperties(cutoff time.Time, orphanLots, dryRun bool) (*PruneResult, error) {
+	// scraped_at values differ in format over time but all start with the
+	// date, so compare at day precision
+	cutoffDate := cutoff.Format("2006-01-02")
+	result := &PruneResult{BySource: make(map[string]int64)}
+
+	// The rows copied for listing history must be current
+	if _, err := db.RefreshPropertySearch(); err != nil {
+		return nil, err
+	}
+
+	tx, err := db.Beginx()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := tx.Select(&result.StaleSources, `
+		SELECT source FROM properties GROUP BY source HAVING MAX(scraped_at) < ? ORDER BY source
+	`, cutoffDate); err != nil {
+		return nil, fmt.Errorf("failed to check sources: %w", err)
+	}
+
+	if _, err := tx.Exec(`
+		CREATE TEMP TABLE prune_ids AS
+		SELECT p.id, p.source FROM properties p
+		JOIN (SELECT source, MAX(scraped_at) AS last_scraped FROM properties GROUP BY source) s
+			ON s.source = p.source
+		WHERE p.scraped_at < ? AND s.last_scraped >= ?
+	`, cutoffDate, cutoffDate); err != nil {
+		return nil, fmt.Errorf("failed to find delisted properties: %w", err)
+	}
+	res, err := tx.Exec("DELETE FROM prune_ids WHERE id IN (SELECT property_id FROM property_attachments)")
+	if err != nil {
+		return nil, fmt.Errorf("failed to keep properties with attachments: %w", err)
+	}
+	result.Kept, _ = res.RowsAffected()
+	res, err = tx.Exec(`
+		DELETE FROM prune_ids WHERE id IN (
+			SELECT canonical_id FROM property_links WHERE duplicate_id NOT IN (SELECT id FROM prune_ids)
+		)
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to keep properties still listed: %w", err)
+	}
+	result.StillListed, _ = res.RowsAffected()
+
+	var bySource []struct {
+		Source string `db:"source"`
+		Count  int64  `db:"count"`
+	}
+	if err := tx.Select(&bySource, "SELECT source, COUNT(*) AS count FROM prune_ids GROUP BY source ORDER BY source"); err != nil {
+		return nil, fmt.Errorf("failed to count delisted properties: %w", err)
+	}
+	for _, s := range bySource {
+		result.BySource[s.Source] = s.Count
+	}
+	if err := tx.Select(&result.Pruned, `
+		SELECT p.id, p.source, p.external_id, p.url FROM properties p
+		WHERE p.id IN (SELECT id FROM prune_ids) ORDER BY p.id
+	`); err != nil {
+		return nil, fmt.Errorf("failed to list delisted properties: %w", err)
+	}
+
+	type pruneStep struct {
+		count *int64
+		query string
+	}
+	steps := []pruneStep{
+		// Before the duplicate links and property_search rows they copy
+		{&result.Archived, `INSERT INTO pruned_listings (property_id, canonical_id, source, scraped_at)
+			SELECT p.id, pl.canonical_id, p.source, p.scraped_at FROM properties p
+			LEFT JOIN property_links pl ON pl.duplicate_id = p.id
+			WHERE p.id IN (SELECT id FROM prune_ids)`},
+		{nil, `INSERT INTO property_search_pruned (` + strings.Join(propertySearchColumns, ", ") + `, distance_sydney_km)
+			SELECT ` + strings.Join(propertySearchColumns, ", ") + `, distance_sydney_km FROM property_search
+			WHERE id IN (SELECT id FROM prune_ids)`},
+		{&result.Distances, "DELETE FROM property_distances WHERE property_id IN (SELECT id FROM prune_ids)"},
+		{&result.LotLinks, "DELETE FROM property_lots WHERE property_id IN (SELECT id FROM prune_ids)"},
+		{&result.DuplicateLinks, `DELETE FROM property_links
+			WHERE canonical_id IN (SELECT id FROM prune_ids) OR duplicate_id IN (SELECT id FROM prune_ids)`},
+		{&result.LinkRejections, `DELETE FROM property_link_rejections
+			WHERE property_id_a IN (SELECT id FROM prune_ids) OR property_id_b IN (SELECT id FROM prune_ids)`},
+		{&result.MergedFields, `DELETE FROM property_field_sources
+			WHERE property_id IN (SELECT id FROM prune_ids) OR source_property_id IN (SELECT id FROM prune_ids)`},
+		{&result.Events, "DELETE FROM property_events WHERE property_id IN (SELECT id FROM prune_ids)"},
+		{&result.Media, "DELETE FROM property_images WHERE property_id IN (SELECT id FROM prune_ids)"},
+		// After the lot links, whose deletion marks the land value stale
+		{&result.StaleSteps, "DELETE FROM property_stale_steps WHERE property_id IN (SELECT id FROM prune_ids)"},
+		{&result.RouteReviews, "DELETE FROM route_reviews WHERE property_id IN (SELECT id FROM prune_ids)"},
+		{&result.GeocodeReviews, "DELETE FROM geocode_reviews WHERE property_id IN (SELECT id FROM prune_ids)"},
+		{&result.RoadSnaps, "DELETE FROM property_road_snaps WHERE property_id IN (SELECT id FROM prune_ids)"},
+		{&result.Scores, "DELETE FROM property_scores WHERE property_id IN (SELECT id FROM prune_ids)"},
+		{&result.Tags, "DELETE FROM property_tags WHERE property_id IN (SELECT id FROM prune_ids)"},
+		{&result.POITimes, "DELETE FROM property_poi_times WHERE property_id IN (SELECT id FROM prune_ids)"},
+		{&result.AuctionResults, "UPDATE auction_results SET property_id = NULL WHERE property_id IN (SELECT id FROM prune_ids)"},
+		{&result.Properties, "DELETE FROM properties WHERE id IN (SELECT id FROM prune_ids)"},
+	}
+	if orphanLots {
+		steps = append(steps, pruneStep{&result.OrphanLots,
+			"DELETE FROM cadastral_lots WHERE id NOT IN (SELECT lot_id FROM property_lots)"})
+	}
+
+	for _, step := range steps {
+		res, err := tx.Exec(step.query)
+		if err != nil {
+			return nil, fmt.Errorf("failed to prune: %w", err)
+		}
+		if step.count != nil {
+			*step.count, _ = res.RowsAffected()
+		}
+	}
+
+	// The temp table would otherwise outlive the transaction on this connection
+	if _, err := tx.Exec("DROP TABLE temp.prune_ids"); err != nil {
+		return nil, fmt.Errorf("failed to prune: %w", err)
+	}
+	if dryRun {
+		return result, nil
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit prune: %w", err)
+	}
+	return result, nil
+}
+
+// Vacuum rebuilds the database file to return space freed by deletes
+func (db *DB) Vacuum() error {
+	if _, err := db.Exec("VACUUM"); err != nil {
+		return fmt.Errorf("failed to vacuum database: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,157 @@
+package db
+
+import (
+	"database/sql"
+	"log"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// slowQueryThreshold is how long a query takes before it's logged as it
+	// finishes
+	slowQueryThreshold = 250 * time.Millisecond
+	// maxQueryStats caps how many distinct statements are timed; past it the
+	// least recently run is dropped
+	maxQueryStats = 500
+	// maxLoggedQueryLen is how much of a slow statement is logged
+	maxLoggedQueryLen = 300
+)
+
+// QueryStat is how a statement has performed since the process started (or
+// it was last dropped for not being run). Statements are told apart by their
+// text without comments and with whitespace collapsed, so each combination of filters built into
+// a query is its own statement.
+type QueryStat struct {
+	Query     string    `json:"query"`
+	Count     int       `json:"count"`
+	TotalMs   float64   `json:"total_ms"`
+	MeanMs    float64   `json:"mean_ms"`
+	MaxMs     float64   `json:"max_ms"`
+	LastMs    float64   `json:"last_ms"`
+	MaxRows   int64     `json:"max_rows"` // Rows returned (Select, Get) or affected (Exec)
+	LastRunAt time.Time `json:"last_run_at"`
+}
+
+// queryLog times the statements run through DB's Select, Get and Exec, in
+// every database the process has open (region databases too). Statements
+// run in transactions aren't timed.
+var queryLog = struct {
+	sync.Mutex
+	stats map[string]*QueryStat
+}{stats: make(map[string]*QueryStat)}
+
+// recordQuery adds a run of query to its stats, logging it if it was slow
+func recordQuery(query string, elapsed time.Duration, rows int64) {
+	query = normalizeQuery(query)
+	ms := float64(elapsed.Microseconds()) / 1000
+	if elapsed >= slowQueryThreshold {
+		logged := query
+		if len(logged) > maxLoggedQueryLen {
+			logged = logged[:maxLoggedQueryLen] + "..."
+		}
+		log.Printf("Slow query (%.0fms, %d rows): %s", ms, rows, logged)
+	}
+
+	queryLog.Lock()
+	defer queryLog.Unlock()
+	s, ok := queryLog.stats[query]
+	if !ok {
+		if len(queryLog.stats) >= maxQueryStats {
+			var oldest *QueryStat
+			for _, other := range queryLog.stats {
+				if oldest == nil || other.LastRunAt.Before(oldest.LastRunAt) {
+					oldest = other
+				}
+			}
+			delete(queryLog.stats, oldest.Query)
+		}
+		s = &QueryStat{Query: query}
+		queryLog.stats[query] = s
+	}
+	s.Count++
+	s.TotalMs += ms
+	s.MeanMs = s.TotalMs / float64(s.Count)
+	s.LastMs = ms
+	s.MaxMs = max(s.MaxMs, ms)
+	s.MaxRows = max(s.MaxRows, rows)
+	s.LastRunAt = time.Now().UTC()
+}
+
+// normalizeQuery puts a statement on one line, without its comments
+func normalizeQuery(query string) string {
+	lines := strings.Split(query, "\n")
+	for i, line := range lines {
+		if j := strings.Index(line, "--"); j >= 0 {
+			lines[i] = line[:j]
+		}
+	}
+	return strings.Join(strings.Fields(strings.Join(lines, " ")), " ")
+}
+
+// QueryStatSorts are the orders SlowQueries can list statements in: by
+// slowest run, total time, or mean time
+var QueryStatSorts = []string{"max", "total", "mean"}
+
+// SlowQueries returns the limit slowest statements run recently, by sort
+// (one of QueryStatSorts)
+func SlowQueries(sortBy string, limit int) []QueryStat {
+	queryLog.Lock()
+	stats := make([]QueryStat, 0, len(queryLog.stats))
+	for _, s := range queryLog.stats {
+		stats = append(stats, *s)
+	}
+	queryLog.Unlock()
+
+	key := func(s QueryStat) float64 { return s.MaxMs }
+	switch sortBy {
+	case "total":
+		key = func(s QueryStat) float64 { return s.TotalMs }
+	case "mean":
+		key = func(s QueryStat) float64 { return s.MeanMs }
+	}
+	sort.Slice(stats, func(i, j int) bool { return key(stats[i]) > key(stats[j]) })
+	if len(stats) > limit {
+		stats = stats[:limit]
+	}
+	return stats
+}
+
+// Select runs a query into dest as sqlx does, timing it
+func (db *DB) Select(dest interface{}, query string, args ...interface{}) error {
+	start := time.Now()
+	err := db.DB.Select(dest, query, args...)
+	var rows int64
+	if v := reflect.ValueOf(dest); v.Kind() == reflect.Pointer && v.Elem().Kind() == reflect.Slice {
+		rows = int64(v.Elem().Len())
+	}
+	recordQuery(query, time.Since(start), rows)
+	return err
+}
+
+// Get runs a query for one row into dest as sqlx does, timing it
+func (db *DB) Get(dest interface{}, query string, args ...interface{}) error {
+	start := time.Now()
+	err := db.DB.Get(dest, query, args...)
+	var rows int64
+	if err == nil {
+		rows = 1
+	}
+	recordQuery(query, time.Since(start), rows)
+	return err
+}
+
+// Exec runs a statement as database/sql does, timing it
+func (db *DB) Exec(query string, args ...interface{}) (sql.Result, error) {
+	start := time.Now()
+	result, err := db.DB.Exec(query, args...)
+	var rows int64
+	if err == nil {
+		rows, _ = result.RowsAffected()
+	}
+	recordQuery(query, time.Since(start), rows)
+	return result, err
+}
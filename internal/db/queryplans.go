@@ -0,0 +1,63 @@
+package db
+
+import (
+	"fmt"
+	"strings"
+)
+
+// listPropertiesShape is a common ListProperties filter and the columns an
+// index should cover to answer it
+type listPropertiesShape struct {
+	name    string
+	columns string
+	filter  PropertyFilter
+}
+
+// listPropertiesShapes are the filters the map and sidebar send most. The
+// values don't matter, only which conditions the query gets.
+var listPropertiesShapes = func() []listPropertiesShape {
+	lat, lng, size, price, mins, km := -34.0, 149.0, 400000.0, int64(900000), 60, 20.0
+	neLat, neLng := lat+1, lng+1
+	return []listPropertiesShape{
+		{"map bounds", "latitude, longitude", PropertyFilter{SWLat: &lat, SWLng: &lng, NELat: &neLat, NELng: &neLng}},
+		{"minimum price", "price_max", PropertyFilter{PriceMin: &price}},
+		{"maximum price", "price_min", PropertyFilter{PriceMax: &price}},
+		{"land size", "land_size_sqm", PropertyFilter{LandSizeMin: &size}},
+		{"type and land size", "property_type, land_size_sqm", PropertyFilter{PropertyTypes: []string{"Rural"}, LandSizeMin: &size}},
+		{"drive time", "drive_time_primary", PropertyFilter{DriveTimePrimaryMax: &mins}},
+		{"town distance", "nearest_town_1_km", PropertyFilter{DistanceTownMax: &km}},
+		{"town drive time", "nearest_town_1_mins", PropertyFilter{DriveTimeTownMax: &mins}},
+		{"school drive time", "nearest_school_1_mins", PropertyFilter{DriveTimeSchoolMax: &mins}},
+	}
+}()
+
+// queryPlanStep is a row of EXPLAIN QUERY PLAN
+type queryPlanStep struct {
+	ID      int    `db:"id"`
+	Parent  int    `db:"parent"`
+	NotUsed int    `db:"notused"`
+	Detail  string `db:"detail"`
+}
+
+// CheckQueryPlans explains ListProperties' query for each of its common
+// filters and returns a warning for each that would scan every property
+// rather than search an index, naming the property_search columns to index
+func (db *DB) CheckQueryPlans() ([]string, error) {
+	var warnings []string
+	for _, shape := range listPropertiesShapes {
+		query, args := listPropertiesQuery(shape.filter)
+		var steps []queryPlanStep
+		if err := db.DB.Select(&steps, "EXPLAIN QUERY PLAN "+query, args...); err != nil {
+			return nil, fmt.Errorf("failed to explain property list by %s: %w", shape.name, err)
+		}
+		for _, step := range steps {
+			// "SCAN p", or "SCAN TABLE property_search AS p" before SQLite 3.36
+			if strings.HasPrefix(step.Detail, "SCAN p") || strings.HasPrefix(step.Detail, "SCAN TABLE property_search") {
+				warnings = append(warnings, fmt.Sprintf("property list by %s scans every property (%s); index property_search(%s)",
+					shape.name, step.Detail, shape.columns))
+				break
+			}
+		}
+	}
+	return warnings, nil
+}
@@ -0,0 +1,47 @@
+package db
+
+import (
+	"fmt"
+)
+
+// PropertyIDRange returns the lowest and highest property IDs, both 0 if
+// there are no properties
+func (db *DB) PropertyIDRange() (int64, int64, error) {
+	var r struct {
+		Min int64 `db:"min_id"`
+		Max int64 `db:"max_id"`
+	}
+	if err := db.Get(&r, "SELECT COALESCE(MIN(id), 0) AS min_id, COALESCE(MAX(id), 0) AS max_id FROM properties"); err != nil {
+		return 0, 0, fmt.Errorf("failed to get property IDs: %w", err)
+	}
+	return r.Min, r.Max, nil
+}
+
+// SetPropertyIDBase makes new properties' IDs start at base, so a region
+// database's IDs stay clear of every other database's when the server
+// federates them. Only a database without properties can be given one.
+func (db *DB) SetPropertyIDBase(base int64) error {
+	if base < 1 {
+		return fmt.Errorf("ID base must be positive")
+	}
+	var count int
+	if err := db.Get(&count, "SELECT COUNT(*) FROM properties"); err != nil {
+		return fmt.Errorf("failed to count properties: %w", err)
+	}
+	if count > 0 {
+		return fmt.Errorf("database already has %d properties", count)
+	}
+
+	tx, err := db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec("DELETE FROM sqlite_sequence WHERE name = 'properties'"); err != nil {
+		return fmt.Errorf("failed to clear ID sequence: %w", err)
+	}
+	if _, err := tx.Exec("INSERT INTO sqlite_sequence (name, seq) VALUES ('properties', ?)", base-1); err != nil {
+		return fmt.Errorf("failed to set ID sequence: %w", err)
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,129 @@
+package db
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"farm-search/internal/models"
+)
+
+// upsertRentalQuery inserts a rental or updates it by (external_id, source),
+// keeping existing values where the new scrape has none
+const upsertRentalQuery = `
+	INSERT INTO rentals (
+		external_id, source, url, address, suburb, state, postcode,
+		latitude, longitude, weekly_rent, rent_text,
+		property_type, bedrooms, bathrooms, land_size_sqm,
+		description, images, scraped_at, updated_at
+	) VALUES (
+		?, ?, ?, ?, ?, ?, ?,
+		?, ?, ?, ?,
+		?, ?, ?, ?,
+		?, ?, ?, ?
+	)
+	ON CONFLICT(external_id, source) DO UPDATE SET
+		url = excluded.url,
+		address = COALESCE(excluded.address, rentals.address),
+		suburb = COALESCE(excluded.suburb, rentals.suburb),
+		postcode = COALESCE(excluded.postcode, rentals.postcode),
+		latitude = COALESCE(excluded.latitude, rentals.latitude),
+		longitude = COALESCE(excluded.longitude, rentals.longitude),
+		weekly_rent = COALESCE(excluded.weekly_rent, rentals.weekly_rent),
+		rent_text = COALESCE(excluded.rent_text, rentals.rent_text),
+		property_type = COALESCE(excluded.property_type, rentals.property_type),
+		bedrooms = COALESCE(excluded.bedrooms, rentals.bedrooms),
+		bathrooms = COALESCE(excluded.bathrooms, rentals.bathrooms),
+		land_size_sqm = COALESCE(excluded.land_size_sqm, rentals.land_size_sqm),
+		description = COALESCE(excluded.description, rentals.description),
+		images = COALESCE(excluded.images, rentals.images),
+		scraped_at = excluded.scraped_at,
+		updated_at = excluded.updated_at
+`
+
+// upsertRentalArgs returns the arguments for upsertRentalQuery
+func upsertRentalArgs(r *models.Rental) []interface{} {
+	return []interface{}{
+		r.ExternalID, r.Source, r.URL,
+		r.Address, r.Suburb, r.State, r.Postcode,
+		r.Latitude, r.Longitude,
+		r.WeeklyRent, r.RentText,
+		r.PropertyType, r.Bedrooms, r.Bathrooms, r.LandSizeSqm,
+		r.Description, r.Images,
+		r.ScrapedAt, r.UpdatedAt,
+	}
+}
+
+// UpsertRental inserts or updates a rental listing based on external_id and source
+func (db *DB) UpsertRental(r *models.Rental) error {
+	if _, err := db.Exec(upsertRentalQuery, upsertRentalArgs(r)...); err != nil {
+		return fmt.Errorf("failed to upsert rental: %w", err)
+	}
+	return nil
+}
+
+// RentalsExist checks if rentals with the given external_ids and source already exist
+// Returns a map of external_id -> exists
+func (db *DB) RentalsExist(externalIDs []string, source string) (map[string]bool, error) {
+	if len(externalIDs) == 0 {
+		return make(map[string]bool), nil
+	}
+
+	placeholders := make([]string, len(externalIDs))
+	args := make([]interface{}, len(externalIDs)+1)
+	args[0] = source
+	for i, id := range externalIDs {
+		placeholders[i] = "?"
+		args[i+1] = id
+	}
+
+	query := fmt.Sprintf(
+		"SELECT external_id FROM rentals WHERE source = ? AND external_id IN (%s)",
+		strings.Join(placeholders, ","),
+	)
+
+	var existingIDs []string
+	if err := db.Select(&existingIDs, query, args...); err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]bool)
+	for _, id := range existingIDs {
+		result[id] = true
+	}
+	return result, nil
+}
+
+// GetRentalsInBox returns rentals with a known weekly rent within radiusKm
+// (as a bounding box) of a point. Callers refine by exact distance.
+func (db *DB) GetRentalsInBox(lat, lng, radiusKm float64) ([]models.RentalListItem, error) {
+	// 1 degree of latitude is ~111km; longitude degrees shrink with latitude
+	dLat := radiusKm / 111.0
+	dLng := radiusKm / (111.0 * math.Cos(lat*math.Pi/180))
+
+	var rentals []models.RentalListItem
+	err := db.Select(&rentals, `
+		SELECT id, source, url,
+			COALESCE(address, '') as address,
+			COALESCE(suburb, '') as suburb,
+			latitude, longitude, weekly_rent,
+			COALESCE(rent_text, '') as rent_text,
+			COALESCE(property_type, '') as property_type,
+			bedrooms, land_size_sqm
+		FROM rentals
+		WHERE weekly_rent IS NOT NULL AND weekly_rent > 0
+		  AND latitude BETWEEN ? AND ?
+		  AND longitude BETWEEN ? AND ?
+	`, lat-dLat, lat+dLat, lng-dLng, lng+dLng)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rentals: %w", err)
+	}
+	return rentals, nil
+}
+
+// GetRentalCount returns total number of rental listings
+func (db *DB) GetRentalCount() (int, error) {
+	var count int
+	err := db.Get(&count, "SELECT COUNT(*) FROM rentals")
+	return count, err
+}
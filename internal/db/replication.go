@@ -0,0 +1,102 @@
+package db
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// replication is read from DB_REPLICATION, naming what replicates the
+// database so every process writing it opens it the way that expects:
+// "litestream" (WAL, with automatic checkpoints left to Litestream) or
+// "litefs" (WAL, and only on the primary node). Empty leaves SQLite's
+// defaults.
+var replication = os.Getenv("DB_REPLICATION")
+
+// busyTimeoutPragma makes connections wait for a lock (a checkpoint, another
+// writer, LiteFS halting writes) rather than fail at once
+const busyTimeoutPragma = "&_pragma=busy_timeout(5000)"
+
+// replicationPragmas are the connection flags each DB_REPLICATION value sets.
+// Litestream copies WAL frames before checkpointing them, so SQLite mustn't
+// checkpoint on its own; synchronous NORMAL is safe in WAL mode and what both
+// recommend.
+var replicationPragmas = map[string]string{
+	"":           "",
+	"litestream": busyTimeoutPragma + "&_pragma=journal_mode(WAL)&_pragma=synchronous(NORMAL)&_pragma=wal_autocheckpoint(0)",
+	"litefs":     busyTimeoutPragma + "&_pragma=journal_mode(WAL)&_pragma=synchronous(NORMAL)",
+}
+
+// Replication returns the configured DB_REPLICATION, or "" if the database
+// isn't replicated
+func Replication() string {
+	return replication
+}
+
+// writerDSN returns the connection string a writer opens dbPath with
+func writerDSN(dbPath string) (string, error) {
+	pragmas, ok := replicationPragmas[replication]
+	if !ok {
+		return "", fmt.Errorf("unknown DB_REPLICATION %q (want litestream or litefs)", replication)
+	}
+	if replication == "litefs" {
+		// LiteFS marks replicas with a .primary file naming the primary;
+		// writes there fail mid-transaction
+		if primary, err := os.ReadFile(filepath.Join(filepath.Dir(dbPath), ".primary")); err == nil {
+			return "", fmt.Errorf("this node is a LiteFS replica (the primary is %s); write there, or serve this one with -readonly-db",
+				strings.TrimSpace(string(primary)))
+		}
+	}
+	return dbPath + "?_foreign_keys=on" + pragmas, nil
+}
+
+// NewReadOnly opens a replica of the database (restored by Litestream, or a
+// LiteFS replica's mount) without writing to it: migrations aren't run, as the
+// writer's already have been, and RefreshPropertySearch leaves the property
+// list as replicated.
+func NewReadOnly(dbPath string) (*DB, error) {
+	if _, err := os.Stat(dbPath); err != nil {
+		return nil, fmt.Errorf("failed to open replica: %w", err)
+	}
+	db, err := sqlx.Connect("sqlite", "file:"+dbPath+"?mode=ro"+busyTimeoutPragma)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to replica: %w", err)
+	}
+	return &DB{DB: db, readOnly: true}, nil
+}
+
+// ReadOnly reports whether the database was opened with NewReadOnly
+func (db *DB) ReadOnly() bool {
+	return db.readOnly
+}
+
+// CheckpointModes are the wal_checkpoint modes Checkpoint takes, from least
+// to most disruptive
+var CheckpointModes = []string{"passive", "full", "restart", "truncate"}
+
+// CheckpointResult is what a WAL checkpoint did
+type CheckpointResult struct {
+	Busy         bool `db:"busy"`         // It couldn't finish for readers or writers
+	Frames       int  `db:"log"`          // Frames in the WAL
+	Checkpointed int  `db:"checkpointed"` // Frames copied into the database
+}
+
+// Checkpoint copies the WAL into the database with the given mode (one of
+// CheckpointModes). With automatic checkpoints off for Litestream the WAL
+// only shrinks when Litestream checkpoints it; this is for when it's stopped,
+// or after a bulk import. Litestream holds a read lock on what it hasn't
+// copied yet, so checkpoints don't lose frames it needs.
+func (db *DB) Checkpoint(mode string) (CheckpointResult, error) {
+	var result CheckpointResult
+	if !slices.Contains(CheckpointModes, mode) {
+		return result, fmt.Errorf("unknown checkpoint mode %q (want %s)", mode, strings.Join(CheckpointModes, ", "))
+	}
+	if err := db.DB.Get(&result, "PRAGMA wal_checkpoint("+strings.ToUpper(mode)+")"); err != nil {
+		return result, fmt.Errorf("failed to checkpoint: %w", err)
+	}
+	return result, nil
+}
@@ -0,0 +1,66 @@
+package db
+
+import (
+	"fmt"
+	"time"
+
+	"farm-search/internal/models"
+)
+
+// GetUnroutableProperties returns the properties with coordinates but no
+// drive time to the anchor that weren't held for review, i.e. whose route
+// failed outright. A non-zero propertyID limits it to that property.
+func (db *DB) GetUnroutableProperties(propertyID int64) ([]models.EnrichmentTarget, error) {
+	query := `
+		SELECT p.id, p.latitude, p.longitude,
+			COALESCE(p.access_lat, p.latitude) as origin_lat,
+			COALESCE(p.access_lng, p.longitude) as origin_lng,
+			COALESCE(p.address, '') as address,
+			COALESCE(p.suburb, '') as suburb,
+			COALESCE(p.nearest_town_1, '') as nearest_town_1,
+			COALESCE(p.nearest_town_2, '') as nearest_town_2,
+			COALESCE(p.nearest_school_1, '') as nearest_school_1,
+			COALESCE(p.nearest_school_2, '') as nearest_school_2
+		FROM properties p
+		WHERE p.latitude IS NOT NULL AND p.longitude IS NOT NULL
+			AND p.drive_time_primary IS NULL
+			AND NOT EXISTS (SELECT 1 FROM route_reviews rr WHERE rr.property_id = p.id AND rr.target_type = ?)`
+	args := []interface{}{RouteTargetAnchor}
+	if propertyID != 0 {
+		query += " AND p.id = ?"
+		args = append(args, propertyID)
+	}
+	query += " ORDER BY p.id"
+
+	var targets []models.EnrichmentTarget
+	if err := db.Select(&targets, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to get unroutable properties: %w", err)
+	}
+	return targets, nil
+}
+
+// SaveRoadSnap records where a property snaps to the road network,
+// replacing any earlier check
+func (db *DB) SaveRoadSnap(s *models.RoadSnap) error {
+	_, err := db.Exec(`
+		INSERT OR REPLACE INTO property_road_snaps
+			(property_id, snap_lat, snap_lng, snap_km, way_id, road_name, road_class, road_use, routable, checked_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		s.PropertyID, s.Lat, s.Lng, s.DistanceKm, s.WayID, s.RoadName, s.RoadClass, s.RoadUse, s.Routable, time.Now().UTC())
+	if err != nil {
+		return fmt.Errorf("failed to save road snap: %w", err)
+	}
+	return nil
+}
+
+// ClearRoutedRoadSnaps deletes the road snaps of properties that now have a
+// drive time to the anchor, returning how many
+func (db *DB) ClearRoutedRoadSnaps() (int64, error) {
+	result, err := db.Exec(`
+		DELETE FROM property_road_snaps
+		WHERE property_id IN (SELECT id FROM properties WHERE drive_time_primary IS NOT NULL)`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to clear road snaps: %w", err)
+	}
+	return result.RowsAffected()
+}
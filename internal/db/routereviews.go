@@ -0,0 +1,181 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"farm-search/internal/models"
+)
+
+// Route review target types
+const (
+	RouteTargetAnchor = "anchor"
+	RouteTargetTown   = "town"
+	RouteTargetSchool = "school"
+	RouteTargetPOI    = "poi"
+)
+
+// sameRouteKm is how close a new route's road distance must be to a reviewed
+// one for the review to still apply
+const sameRouteKm = 0.5
+
+// routeReviewColumns selects a route_reviews row (as rr) with its property's
+// address, joined as p
+const routeReviewColumns = `
+	rr.id, rr.property_id, rr.target_type, rr.target_name, rr.straight_km, rr.road_km,
+	rr.ratio, rr.drive_time_mins, rr.status, rr.flagged_at, rr.resolved_at,
+	TRIM(COALESCE(p.address, '') || ', ' || COALESCE(p.suburb, ''), ', ') AS address
+`
+
+// FlagRouteReview queues a suspicious route for review, replacing any earlier
+// one to the same target. A rejection stands if the route is the same as
+// the one rejected; otherwise it's pending again.
+func (db *DB) FlagRouteReview(r *models.RouteReview) error {
+	_, err := db.Exec(`
+		INSERT INTO route_reviews
+			(property_id, target_type, target_name, straight_km, road_km, ratio, drive_time_mins, status, flagged_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, 'pending', ?)
+		ON CONFLICT(property_id, target_type, target_name) DO UPDATE SET
+			status = CASE
+				WHEN status = 'rejected' AND ABS(road_km - excluded.road_km) < ? THEN 'rejected'
+				ELSE 'pending' END,
+			resolved_at = CASE
+				WHEN status = 'rejected' AND ABS(road_km - excluded.road_km) < ? THEN resolved_at
+				ELSE NULL END,
+			straight_km = excluded.straight_km,
+			road_km = excluded.road_km,
+			ratio = excluded.ratio,
+			drive_time_mins = excluded.drive_time_mins,
+			flagged_at = excluded.flagged_at
+	`, r.PropertyID, r.TargetType, r.TargetName, r.StraightKm, r.RoadKm, r.Ratio, r.DriveTimeMins,
+		time.Now().UTC(), sameRouteKm, sameRouteKm)
+	if err != nil {
+		return fmt.Errorf("failed to flag route: %w", err)
+	}
+	return nil
+}
+
+// IsRouteAccepted reports whether a person accepted a route to a target with
+// about this road distance, so it can be saved without flagging it again
+func (db *DB) IsRouteAccepted(propertyID int64, targetType, targetName string, roadKm float64) (bool, error) {
+	var n int
+	err := db.Get(&n, `
+		SELECT COUNT(*) FROM route_reviews
+		WHERE property_id = ? AND target_type = ? AND target_name = ?
+			AND status = 'accepted' AND ABS(road_km - ?) < ?
+	`, propertyID, targetType, targetName, roadKm, sameRouteKm)
+	if err != nil {
+		return false, fmt.Errorf("failed to check route review: %w", err)
+	}
+	return n > 0, nil
+}
+
+// ListRouteReviews returns route reviews, most suspicious first. An empty
+// status returns all of them.
+func (db *DB) ListRouteReviews(status string, limit int) ([]models.RouteReview, error) {
+	query := `SELECT ` + routeReviewColumns + ` FROM route_reviews rr
+		JOIN properties p ON p.id = rr.property_id
+		WHERE 1 = 1`
+	var args []interface{}
+	if status != "" {
+		query += " AND rr.status = ?"
+		args = append(args, status)
+	}
+	query += " ORDER BY rr.ratio DESC, rr.id"
+	if limit > 0 {
+		query += fmt.Sprintf(" LIMIT %d", limit)
+	}
+
+	reviews := []models.RouteReview{}
+	if err := db.Select(&reviews, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to list route reviews: %w", err)
+	}
+	return reviews, nil
+}
+
+// GetRouteReview returns a route review, or nil if there's none with that ID
+func (db *DB) GetRouteReview(id int64) (*models.RouteReview, error) {
+	var review models.RouteReview
+	err := db.Get(&review, `SELECT `+routeReviewColumns+` FROM route_reviews rr
+		JOIN properties p ON p.id = rr.property_id
+		WHERE rr.id = ?`, id)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get route review: %w", err)
+	}
+	return &review, nil
+}
+
+// AcceptRouteReview marks a route as genuine and saves its drive time to the
+// property, in one transaction. A town or school drive time is only saved
+// if it's still one of the property's nearest two. Returns false if there's
+// no review with that ID.
+func (db *DB) AcceptRouteReview(id int64) (bool, error) {
+	tx, err := db.Beginx()
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var r models.RouteReview
+	err = tx.Get(&r, "SELECT id, property_id, target_type, target_name, drive_time_mins FROM route_reviews WHERE id = ?", id)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to get route review: %w", err)
+	}
+
+	if _, err := tx.Exec("UPDATE route_reviews SET status = 'accepted', resolved_at = ? WHERE id = ?",
+		time.Now().UTC(), id); err != nil {
+		return false, fmt.Errorf("failed to accept route: %w", err)
+	}
+
+	var queries []string
+	switch r.TargetType {
+	case RouteTargetAnchor:
+		queries = []string{"UPDATE properties SET drive_time_primary = ? WHERE id = ?"}
+	case RouteTargetTown:
+		queries = []string{
+			"UPDATE properties SET nearest_town_1_mins = ? WHERE id = ? AND nearest_town_1 = ?",
+			"UPDATE properties SET nearest_town_2_mins = ? WHERE id = ? AND nearest_town_2 = ?",
+		}
+	case RouteTargetSchool:
+		queries = []string{
+			"UPDATE properties SET nearest_school_1_mins = ? WHERE id = ? AND nearest_school_1 = ?",
+			"UPDATE properties SET nearest_school_2_mins = ? WHERE id = ? AND nearest_school_2 = ?",
+		}
+	case RouteTargetPOI:
+		queries = []string{`UPDATE property_poi_times SET drive_time_mins = ?
+			WHERE property_id = ? AND poi_id = (SELECT id FROM user_pois WHERE name = ?)`}
+	}
+	for _, query := range queries {
+		args := []interface{}{r.DriveTimeMins, r.PropertyID}
+		if r.TargetType != RouteTargetAnchor {
+			args = append(args, r.TargetName)
+		}
+		if _, err := tx.Exec(query, args...); err != nil {
+			return false, fmt.Errorf("failed to save accepted drive time: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit route review: %w", err)
+	}
+	return true, nil
+}
+
+// RejectRouteReview marks a route as bogus, leaving the drive time unset.
+// Returns false if there's no review with that ID.
+func (db *DB) RejectRouteReview(id int64) (bool, error) {
+	result, err := db.Exec("UPDATE route_reviews SET status = 'rejected', resolved_at = ? WHERE id = ?",
+		time.Now().UTC(), id)
+	if err != nil {
+		return false, fmt.Errorf("failed to reject route: %w", err)
+	}
+	n, _ := result.RowsAffected()
+	return n > 0, nil
+}
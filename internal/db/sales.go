@@ -0,0 +1,93 @@
+package db
+
+import (
+	"fmt"
+
+	"farm-search/internal/models"
+)
+
+// UpsertHistoricalSale inserts or updates one lot of a Valuer General sale,
+// keyed on dealing number and lot
+func (db *DB) UpsertHistoricalSale(s *models.HistoricalSale) error {
+	query := `
+		INSERT INTO historical_sales (
+			lot_id_string, dealing_number, district_code, vg_property_id, address,
+			locality, postcode, area_sqm, contract_date, settlement_date,
+			purchase_price, lot_count, zoning, nature, primary_purpose, imported_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(dealing_number, lot_id_string) DO UPDATE SET
+			contract_date = excluded.contract_date,
+			settlement_date = COALESCE(excluded.settlement_date, historical_sales.settlement_date),
+			purchase_price = excluded.purchase_price,
+			lot_count = excluded.lot_count,
+			area_sqm = COALESCE(excluded.area_sqm, historical_sales.area_sqm),
+			imported_at = excluded.imported_at
+	`
+
+	_, err := db.Exec(query,
+		s.LotIDString, s.DealingNumber, s.DistrictCode, s.VGPropertyID, s.Address,
+		s.Locality, s.Postcode, s.AreaSqm, s.ContractDate, s.SettlementDate,
+		s.PurchasePrice, s.LotCount, s.Zoning, s.Nature, s.PrimaryPurpose, s.ImportedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to upsert historical sale: %w", err)
+	}
+	return nil
+}
+
+// GetCadastralLotIDStrings returns the lot ID strings of every cached cadastral lot
+func (db *DB) GetCadastralLotIDStrings() (map[string]bool, error) {
+	var ids []string
+	if err := db.Select(&ids, "SELECT lot_id_string FROM cadastral_lots"); err != nil {
+		return nil, fmt.Errorf("failed to get cadastral lot IDs: %w", err)
+	}
+
+	result := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		result[id] = true
+	}
+	return result, nil
+}
+
+// GetPropertyPriorSales returns recorded sales of a property's lots, most recent
+// first. Lots sold together in one dealing are grouped into a single sale.
+func (db *DB) GetPropertyPriorSales(propertyID int64) ([]models.PriorSale, error) {
+	var rows []struct {
+		LotIDString   string   `db:"lot_id_string"`
+		DealingNumber string   `db:"dealing_number"`
+		ContractDate  string   `db:"contract_date"`
+		PurchasePrice int64    `db:"purchase_price"`
+		AreaSqm       *float64 `db:"area_sqm"`
+		LotCount      int      `db:"lot_count"`
+	}
+	err := db.Select(&rows, `
+		SELECT hs.lot_id_string, hs.dealing_number, hs.contract_date,
+			hs.purchase_price, hs.area_sqm, hs.lot_count
+		FROM historical_sales hs
+		JOIN cadastral_lots cl ON cl.lot_id_string = hs.lot_id_string
+		JOIN property_lots pl ON pl.lot_id = cl.id
+		WHERE pl.property_id = ?
+		ORDER BY hs.contract_date DESC, hs.dealing_number, hs.lot_id_string
+	`, propertyID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get prior sales: %w", err)
+	}
+
+	var sales []models.PriorSale
+	index := make(map[string]int)
+	for _, r := range rows {
+		if i, ok := index[r.DealingNumber]; ok {
+			sales[i].Lots = append(sales[i].Lots, r.LotIDString)
+			continue
+		}
+		index[r.DealingNumber] = len(sales)
+		sales = append(sales, models.PriorSale{
+			ContractDate:  r.ContractDate,
+			PurchasePrice: r.PurchasePrice,
+			AreaSqm:       r.AreaSqm,
+			LotCount:      r.LotCount,
+			Lots:          []string{r.LotIDString},
+		})
+	}
+	return sales, nil
+}
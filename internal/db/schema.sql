@@ -10,10 +10,14 @@ CREATE TABLE IF NOT EXISTS properties (
     postcode TEXT,
     latitude REAL,
     longitude REAL,
+    coord_source TEXT,          -- Where the coordinates came from: 'listing', 'geocoder' or 'manual'
+    coord_confidence REAL,      -- 0-1, how likely the coordinates are to be on the property
+    coord_updated_at DATETIME,  -- When the coordinates last changed source or were corrected
     price_min INTEGER,
     price_max INTEGER,
     price_text TEXT,
-    property_type TEXT,
+    property_type TEXT,         -- Canonical type (see db.PropertyTypes)
+    property_type_raw TEXT,     -- Type as the source listed it, e.g. 'AcreageSemiRural'
     bedrooms INTEGER,
     bathrooms INTEGER,
     land_size_sqm REAL,
@@ -23,13 +27,15 @@ CREATE TABLE IF NOT EXISTS properties (
     scraped_at DATETIME NOT NULL,
     updated_at DATETIME NOT NULL,
     details_scraped_at DATETIME,  -- When full listing details were successfully fetched
-    drive_time_sydney INTEGER,  -- Drive time to Sutherland in minutes (via Valhalla routing)
+    drive_time_primary INTEGER,  -- Drive time to the anchor (ANCHOR, default Sutherland) in minutes (via Valhalla routing)
     nearest_town_1 TEXT,        -- Name of nearest town
     nearest_town_1_km REAL,     -- Distance to nearest town in km
     nearest_town_1_mins INTEGER,-- Drive time to nearest town in minutes
     nearest_town_2 TEXT,        -- Name of second nearest town  
     nearest_town_2_km REAL,     -- Distance to second nearest town in km
     nearest_town_2_mins INTEGER,-- Drive time to second nearest town in minutes
+    nearest_town_1_walk_mins INTEGER,  -- Walking time to nearest town's centre, within 10 km of it (NULL further out)
+    nearest_town_1_cycle_mins INTEGER, -- Cycling time to the same
     nearest_school_1 TEXT,      -- Name of nearest public school
     nearest_school_1_km REAL,   -- Distance to nearest school in km
     nearest_school_1_mins INTEGER, -- Drive time to nearest school in minutes
@@ -39,7 +45,50 @@ CREATE TABLE IF NOT EXISTS properties (
     nearest_school_2_km REAL,   -- Distance to second nearest school in km
     nearest_school_2_mins INTEGER, -- Drive time to second nearest school in minutes
     nearest_school_2_lat REAL,  -- Latitude of second nearest school
-    nearest_school_2_lng REAL   -- Longitude of second nearest school
+    nearest_school_2_lng REAL,  -- Longitude of second nearest school
+    nearest_wind_farm TEXT,     -- Name of nearest wind farm (operating or planned)
+    nearest_wind_farm_status TEXT, -- Its status: 'operating', 'construction', 'approved' or 'proposed'
+    nearest_wind_farm_km REAL,  -- Distance to it in km
+    nearest_solar_farm TEXT,    -- Name of nearest solar farm (operating or planned)
+    nearest_solar_farm_status TEXT, -- Its status
+    nearest_solar_farm_km REAL, -- Distance to it in km
+    highway_km REAL,            -- Distance to the nearest motorway or trunk road (OSM) in km
+    railway_km REAL,            -- Distance to the nearest active railway line in km
+    runway_km REAL,             -- Distance to the nearest airport runway in km
+    heritage_listing TEXT,      -- Most restrictive heritage listing on its lots: 'state', 'local' or 'none'
+    koala_habitat_pct REAL,     -- Percentage of its lots' area in mapped koala habitat (SEPP)
+    biodiversity_pct REAL,      -- Percentage of its lots' area on the biodiversity values map
+    lls_region TEXT,            -- Local Land Services region it's in
+    weed_zones TEXT,            -- JSON array of declared weed zones it's in: [{"weed": ..., "category": ...}]
+    last_burn_year INTEGER,     -- Year of the most recent fire over any of its lots (NPWS fire history)
+    burn_count_30y INTEGER,     -- Number of years in the last 30 with a fire over its lots
+    street_view_url TEXT,       -- Google Street View from the nearest road, facing the property
+    aerial_url TEXT,            -- NSW SIX Maps aerial imagery
+    google_earth_url TEXT,      -- Google Earth 3D view
+    woody_cover_change REAL,    -- Change in percentage points of its lots' woody cover (Sentinel-2 NDVI) since the baseline
+    ndvi_change REAL,           -- Change in mean NDVI over its lots since the baseline
+    clearing_baseline_year INTEGER, -- Year of the baseline the changes are measured from
+    clearing_flagged INTEGER,   -- 1 if the woody cover loss suggests recent large-scale clearing
+    ndvi_mean REAL,             -- Mean NDVI over its lots (average of the monthly means, last 3 years)
+    ndvi_seasonal_range REAL,   -- Greenest month's mean NDVI less the brownest's
+    ndvi_monthly TEXT,          -- JSON array of the 12 monthly mean NDVIs, January first (null if never observed)
+    building_count INTEGER,     -- Building footprints with their centroid on its lots
+    building_area_sqm REAL,     -- Total plan area of those buildings
+    largest_building_sqm REAL,  -- Plan area of the largest
+    has_dwelling INTEGER,       -- 1 if one of them is house-sized, 0 if none is (likely vacant land)
+    min_lot_size_sqm REAL,      -- Largest LEP minimum lot size over its lots (0 if none is mapped)
+    subdivision_ratio REAL,     -- Total area of its lots over min_lot_size_sqm (NULL if none is mapped)
+    access_lat REAL,            -- Driveway/gate candidate: the road point nearest its lots' boundary, routed from
+    access_lng REAL,
+    access_source TEXT,         -- 'lot' (nearest a boundary point), 'point' (nearest the listing point) or 'none' (no road near)
+    gnaf_pid TEXT,              -- G-NAF address it's matched to (gnaf_addresses), the canonical address
+    gnaf_match TEXT,            -- How: 'number' (street and house number), 'lot' (street and lot number) or 'nearest' (street, nearest to the pin)
+    boundary_suburb TEXT,       -- Suburb and postcode whose boundaries the pin is in (tools boundarycheck)
+    boundary_postcode TEXT,
+    suburb_mismatch INTEGER,    -- 1 if the suburb isn't boundary_suburb, 0 if it is, NULL if not checked or outside the boundaries
+    postcode_mismatch INTEGER,  -- Likewise for the postcode
+    listed_suburb TEXT,         -- The listing's suburb and postcode, if corrected to the boundary's (boundarycheck -fix)
+    listed_postcode TEXT
 );
 
 -- Pre-computed distances for filtering
@@ -73,6 +122,115 @@ CREATE TABLE IF NOT EXISTS schools (
     longitude REAL NOT NULL
 );
 
+-- Amenities near properties by type ('school', 'hospital', ...), for the
+-- nearby endpoint. Towns aren't stored; they're embedded in internal/geo.
+CREATE TABLE IF NOT EXISTS amenities (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    type TEXT NOT NULL,                   -- e.g. 'school', 'hospital'
+    name TEXT NOT NULL,
+    detail TEXT,                          -- e.g. school level and suburb
+    latitude REAL NOT NULL,
+    longitude REAL NOT NULL,
+    source TEXT NOT NULL,                 -- 'nsw-education' or the import file's source
+    imported_at DATETIME NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_amenities_type ON amenities(type);
+
+-- Drive times to the anchor over a regular grid, from `tools drivetimegrid`,
+-- for a drive time surface that doesn't depend on where listings are.
+-- Each run replaces the grid; cells with no route aren't stored.
+CREATE TABLE IF NOT EXISTS drive_time_grid (
+    lat REAL NOT NULL,                    -- Cell centre
+    lng REAL NOT NULL,
+    lat_step REAL NOT NULL,               -- Cell size in degrees
+    lng_step REAL NOT NULL,
+    drive_time_mins INTEGER NOT NULL,     -- From the cell centre to the anchor
+    distance_km REAL NOT NULL,            -- Road distance
+    computed_at DATETIME NOT NULL,
+    PRIMARY KEY (lat, lng)
+);
+
+-- Wind and solar farms, operating or planned, imported from DA registers or
+-- EPBC listings with `tools energy`. Each import replaces its source.
+CREATE TABLE IF NOT EXISTS energy_developments (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL,
+    kind TEXT NOT NULL,                   -- 'wind' or 'solar'
+    status TEXT NOT NULL,                 -- 'operating', 'construction', 'approved' or 'proposed'
+    capacity_mw REAL,
+    latitude REAL NOT NULL,
+    longitude REAL NOT NULL,
+    source TEXT NOT NULL,                 -- e.g. 'nsw-planning', 'epbc'
+    imported_at DATETIME NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_energy_developments_kind ON energy_developments(kind);
+
+-- Exclusion layers (highways, mines, wind farms), imported with `tools exclusions`
+-- for "not within N km of" filters. Each import replaces its layer.
+CREATE TABLE IF NOT EXISTS exclusion_features (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    layer TEXT NOT NULL,                  -- e.g. 'highways', 'wind-farms'
+    name TEXT NOT NULL DEFAULT '',        -- From the file's name attribute, if any
+    geometry TEXT NOT NULL,               -- GeoJSON point, line or polygon geometry
+    imported_at DATETIME NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_exclusion_features_layer ON exclusion_features(layer);
+
+-- Local Land Services regions and declared weed zones, imported with
+-- `tools biosecurity`. Each import replaces its kind.
+CREATE TABLE IF NOT EXISTS biosecurity_areas (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    kind TEXT NOT NULL,                   -- 'lls_region' or 'weed_zone'
+    name TEXT NOT NULL,                   -- Region name, or the weed's common name
+    category TEXT NOT NULL DEFAULT '',    -- Weed zone priority, e.g. 'Containment'; '' for regions
+    geometry TEXT NOT NULL,               -- GeoJSON Polygon or MultiPolygon
+    imported_at DATETIME NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_biosecurity_areas_kind ON biosecurity_areas(kind);
+
+-- ABS Suburbs and Localities (SAL) boundaries, imported with `tools suburbs`
+-- for the suburb stats choropleth. Properties are assigned by point in polygon.
+CREATE TABLE IF NOT EXISTS suburb_boundaries (
+    sal_code TEXT PRIMARY KEY,            -- e.g. '10012'
+    name TEXT NOT NULL,                   -- e.g. 'Berry', 'Richmond (NSW)'
+    state TEXT NOT NULL,                  -- e.g. 'New South Wales'
+    geometry TEXT NOT NULL,               -- GeoJSON Polygon or MultiPolygon
+    imported_at DATETIME NOT NULL
+);
+
+-- How quickly listings in each suburb go off the market, from the listing
+-- history, for the suburb stats market heat. Replaced by `tools velocity`.
+CREATE TABLE IF NOT EXISTS suburb_velocity (
+    sal_code TEXT PRIMARY KEY,            -- suburb_boundaries.sal_code
+    off_market INTEGER NOT NULL,          -- Listings that went off the market in the last 12 months
+    median_days REAL,                     -- Their median days on the market
+    ema_days REAL,                        -- Exponential moving average of monthly medians over 24 months
+    months INTEGER NOT NULL,              -- Months with listings going off the market in the average
+    computed_at DATETIME NOT NULL
+);
+
+-- ABS Local Government Area (LGA) boundaries, imported with `tools lgas`
+-- for watchlists
+CREATE TABLE IF NOT EXISTS lga_boundaries (
+    lga_code TEXT PRIMARY KEY,            -- e.g. '15650'
+    name TEXT NOT NULL,                   -- e.g. 'Mid-Western Regional'
+    state TEXT NOT NULL,
+    geometry TEXT NOT NULL,               -- GeoJSON Polygon or MultiPolygon
+    imported_at DATETIME NOT NULL
+);
+
+-- ABS Postal Areas (POA) boundaries, imported with `tools postcodes` for
+-- checking listings' postcodes against their pins
+CREATE TABLE IF NOT EXISTS postcode_boundaries (
+    postcode TEXT PRIMARY KEY,            -- e.g. '2795'
+    geometry TEXT NOT NULL,               -- GeoJSON Polygon or MultiPolygon
+    imported_at DATETIME NOT NULL
+);
+
 -- Unique constraint on external_id + source (same property ID can exist on different sites)
 CREATE UNIQUE INDEX IF NOT EXISTS idx_properties_external_source ON properties(external_id, source);
 
@@ -81,14 +239,152 @@ CREATE UNIQUE INDEX IF NOT EXISTS idx_properties_external_source ON properties(e
 CREATE TABLE IF NOT EXISTS property_links (
     canonical_id INTEGER NOT NULL REFERENCES properties(id) ON DELETE CASCADE,
     duplicate_id INTEGER NOT NULL REFERENCES properties(id) ON DELETE CASCADE,
-    match_type TEXT NOT NULL,  -- 'coords' (same lat/lng), 'address' (similar address)
+    match_type TEXT NOT NULL,  -- 'coords' (same lat/lng), 'address' (similar address), 'gnaf' (same G-NAF address), 'manual'
     created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    confirmed_at DATETIME,     -- When a person confirmed the link (set on creation for manual links)
     PRIMARY KEY (duplicate_id),  -- Each property can only be a duplicate of one canonical
     CHECK (canonical_id != duplicate_id)
 );
 
 CREATE INDEX IF NOT EXISTS idx_property_links_canonical ON property_links(canonical_id);
 
+-- Pairs of properties a person has said are not the same, so detection never links them
+CREATE TABLE IF NOT EXISTS property_link_rejections (
+    property_id_a INTEGER NOT NULL REFERENCES properties(id) ON DELETE CASCADE,  -- Lower ID of the pair
+    property_id_b INTEGER NOT NULL REFERENCES properties(id) ON DELETE CASCADE,  -- Higher ID of the pair
+    note TEXT,
+    rejected_at DATETIME NOT NULL,
+    PRIMARY KEY (property_id_a, property_id_b),
+    CHECK (property_id_a < property_id_b)
+);
+
+-- Audit trail of manual changes to property_links
+CREATE TABLE IF NOT EXISTS property_link_audit (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    action TEXT NOT NULL,                 -- 'create', 'confirm' or 'reject'
+    canonical_id INTEGER NOT NULL,
+    duplicate_id INTEGER NOT NULL,
+    previous_canonical_id INTEGER,        -- Canonical the duplicate was linked to before a 'create'
+    match_type TEXT,                      -- Match type of the link acted on
+    note TEXT,
+    created_at DATETIME NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_property_link_audit_canonical ON property_link_audit(canonical_id);
+CREATE INDEX IF NOT EXISTS idx_property_link_audit_duplicate ON property_link_audit(duplicate_id);
+
+-- Enrichment steps whose inputs changed since they were computed (coordinates,
+-- lots, nearest town/school, or a target list); kept current by triggers
+-- created in runMigrations and cleared as each step is recomputed
+CREATE TABLE IF NOT EXISTS property_stale_steps (
+    property_id INTEGER NOT NULL REFERENCES properties(id) ON DELETE CASCADE,
+    step TEXT NOT NULL,                   -- e.g. 'drive_time_primary', 'land_value'
+    reason TEXT NOT NULL,                 -- 'coordinates', 'lots', 'nearest_towns', 'nearest_schools' or an input name
+    marked_at DATETIME NOT NULL,
+    PRIMARY KEY (property_id, step)
+);
+
+CREATE INDEX IF NOT EXISTS idx_property_stale_steps_step ON property_stale_steps(step);
+
+-- Routes held back from the drive time columns because the road distance is
+-- implausible next to the straight-line distance (e.g. snapped to the wrong
+-- road), for a person to accept or reject
+CREATE TABLE IF NOT EXISTS route_reviews (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    property_id INTEGER NOT NULL REFERENCES properties(id) ON DELETE CASCADE,
+    target_type TEXT NOT NULL,            -- 'anchor', 'town', 'school' or 'poi'
+    target_name TEXT NOT NULL,
+    straight_km REAL NOT NULL,
+    road_km REAL NOT NULL,
+    ratio REAL NOT NULL,                  -- road_km / straight_km
+    drive_time_mins INTEGER NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending', -- 'pending', 'accepted' or 'rejected'
+    flagged_at DATETIME NOT NULL,
+    resolved_at DATETIME,
+    UNIQUE(property_id, target_type, target_name)
+);
+
+CREATE INDEX IF NOT EXISTS idx_route_reviews_status ON route_reviews(status);
+
+-- Geocoded points held back from properties' coordinates because the
+-- cadastre doesn't back them up: no lot near the point, or none of the
+-- addresses on its lot on the listing's road (tools geocode)
+CREATE TABLE IF NOT EXISTS geocode_reviews (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    property_id INTEGER NOT NULL UNIQUE REFERENCES properties(id) ON DELETE CASCADE,
+    address TEXT NOT NULL,                -- Address geocoded
+    provider TEXT NOT NULL,
+    latitude REAL NOT NULL,
+    longitude REAL NOT NULL,
+    confidence REAL NOT NULL,
+    reason TEXT NOT NULL,                 -- 'no_lot' or 'address_mismatch'
+    lot_id_string TEXT,                   -- Lot the point is on or nearest
+    found_address TEXT,                   -- Address on or near that lot nearest the point
+    status TEXT NOT NULL DEFAULT 'pending', -- 'pending', 'accepted' or 'rejected'
+    flagged_at DATETIME NOT NULL,
+    resolved_at DATETIME
+);
+
+CREATE INDEX IF NOT EXISTS idx_geocode_reviews_status ON geocode_reviews(status);
+
+-- Where Valhalla snaps properties that couldn't be routed to the anchor
+-- (tools unroutable), as a suggested point on the nearest mapped road
+CREATE TABLE IF NOT EXISTS property_road_snaps (
+    property_id INTEGER PRIMARY KEY REFERENCES properties(id) ON DELETE CASCADE,
+    snap_lat REAL,                        -- NULL if no road within the search distance
+    snap_lng REAL,
+    snap_km REAL,                         -- Straight-line distance from the property
+    way_id INTEGER,                       -- OpenStreetMap way of the road
+    road_name TEXT,
+    road_class TEXT,                      -- Valhalla road class, e.g. 'tertiary' or 'service_other'
+    road_use TEXT,                        -- Valhalla edge use, e.g. 'road' or 'track'
+    routable INTEGER,                     -- 1 if the snap point routes to the anchor, 0 if not
+    checked_at DATETIME NOT NULL
+);
+
+-- Isochrones generated on demand for /api/isochrone, keyed by origin
+-- (rounded to 3 decimal places, ~100 m) and drive time
+CREATE TABLE IF NOT EXISTS isochrones (
+    lat REAL NOT NULL,
+    lng REAL NOT NULL,
+    minutes INTEGER NOT NULL,
+    geojson TEXT NOT NULL,                -- GeoJSON FeatureCollection from Valhalla
+    created_at DATETIME NOT NULL,
+    PRIMARY KEY (lat, lng, minutes)
+);
+
+-- Addresses tools geocode has looked up, so reruns don't ask again. Misses
+-- (no provider knew the address) are kept with NULL coordinates.
+CREATE TABLE IF NOT EXISTS geocode_cache (
+    address TEXT PRIMARY KEY,             -- Lowercased, spaces collapsed
+    provider TEXT,                        -- Geocoder that matched, e.g. 'locationiq'
+    latitude REAL,
+    longitude REAL,
+    confidence REAL,
+    match TEXT,                           -- Provider's match type, e.g. 'house' or 'street'
+    ambiguous INTEGER NOT NULL DEFAULT 0, -- Another equally precise match was far away
+    geocoded_at DATETIME NOT NULL
+);
+
+-- Fingerprints of the targets enrichment last ran against (drive time origin,
+-- town list, school list), so a change marks every property's steps stale
+CREATE TABLE IF NOT EXISTS enrichment_inputs (
+    name TEXT PRIMARY KEY,                -- 'drive_time_origin' (the anchor), 'towns' or 'schools'
+    fingerprint TEXT NOT NULL,
+    updated_at DATETIME NOT NULL
+);
+
+-- Provenance of fields merged onto a canonical property from its duplicates
+CREATE TABLE IF NOT EXISTS property_field_sources (
+    property_id INTEGER NOT NULL REFERENCES properties(id) ON DELETE CASCADE,  -- Canonical property
+    field TEXT NOT NULL,                -- Column name, e.g. 'land_size_sqm'
+    source_property_id INTEGER NOT NULL REFERENCES properties(id) ON DELETE CASCADE,  -- Duplicate the value came from
+    source TEXT NOT NULL,               -- Source of that duplicate, e.g. 'rea'
+    previous_value,                     -- Canonical value before the merge (untyped, any column)
+    merged_at DATETIME NOT NULL,
+    PRIMARY KEY (property_id, field)
+);
+
 -- Cadastral lots table (NSW DCDB lot boundaries)
 CREATE TABLE IF NOT EXISTS cadastral_lots (
     id INTEGER PRIMARY KEY AUTOINCREMENT,
@@ -99,7 +395,9 @@ CREATE TABLE IF NOT EXISTS cadastral_lots (
     geometry TEXT NOT NULL,               -- GeoJSON geometry (Polygon)
     centroid_lat REAL,                    -- Centroid latitude
     centroid_lng REAL,                    -- Centroid longitude
-    fetched_at DATETIME NOT NULL
+    fetched_at DATETIME NOT NULL,
+    heritage_checked_at DATETIME,         -- When checked against the heritage layers (NULL: not yet)
+    min_lot_size_sqm REAL                 -- LEP minimum lot size over it (0 if none is mapped, NULL: not yet checked)
 );
 
 -- Link properties to cadastral lots (a property may span multiple lots)
@@ -109,6 +407,483 @@ CREATE TABLE IF NOT EXISTS property_lots (
     PRIMARY KEY (property_id, lot_id)
 );
 
+-- State Heritage Register and LEP heritage items overlapping each lot
+CREATE TABLE IF NOT EXISTS lot_heritage_items (
+    lot_id INTEGER NOT NULL REFERENCES cadastral_lots(id) ON DELETE CASCADE,
+    listing TEXT NOT NULL,                -- 'state' or 'local'
+    item_id TEXT NOT NULL,                -- SHR number or LEP item number
+    name TEXT,
+    class TEXT,                           -- e.g. 'Item - General', 'Conservation Area - General'
+    PRIMARY KEY (lot_id, listing, item_id)
+);
+
+-- Planning overlays (koala habitat, biodiversity values) covering part of each lot
+CREATE TABLE IF NOT EXISTS lot_overlays (
+    lot_id INTEGER NOT NULL REFERENCES cadastral_lots(id) ON DELETE CASCADE,
+    overlay TEXT NOT NULL,                -- Overlay layer: 'koala-habitat' or 'biodiversity-values'
+    affected_pct REAL NOT NULL,           -- Percentage of the lot covered (only rows over 0 are kept)
+    PRIMARY KEY (lot_id, overlay)
+);
+
+-- Mapped extents of past wildfires and prescribed burns (NPWS fire history),
+-- imported with `tools fires`. Each import replaces them all.
+CREATE TABLE IF NOT EXISTS fire_extents (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    year INTEGER NOT NULL,                -- Year the fire started
+    fire_type TEXT NOT NULL DEFAULT '',   -- 'Wildfire' or 'Prescribed burn'
+    name TEXT NOT NULL DEFAULT '',        -- Fire name, if any
+    geometry TEXT NOT NULL,               -- GeoJSON Polygon or MultiPolygon
+    imported_at DATETIME NOT NULL
+);
+
+-- Past fires that burnt part of each lot
+CREATE TABLE IF NOT EXISTS lot_fires (
+    lot_id INTEGER NOT NULL REFERENCES cadastral_lots(id) ON DELETE CASCADE,
+    fire_id INTEGER NOT NULL REFERENCES fire_extents(id) ON DELETE CASCADE,
+    burnt_pct REAL NOT NULL,              -- Percentage of the lot burnt
+    PRIMARY KEY (lot_id, fire_id)
+);
+
+-- Building footprints (Microsoft or Geoscape outlines) reduced to centroid and
+-- plan area, imported with `tools buildings`. Each import replaces them all.
+CREATE TABLE IF NOT EXISTS building_footprints (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    latitude REAL NOT NULL,               -- Centroid of the outline
+    longitude REAL NOT NULL,
+    area_sqm REAL NOT NULL,               -- Plan area of the outline
+    imported_at DATETIME NOT NULL
+);
+
+-- Rental listings (scraped with -listing-type rent), used to estimate rental yield
+CREATE TABLE IF NOT EXISTS rentals (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    external_id TEXT NOT NULL,
+    source TEXT NOT NULL,                 -- 'rea', 'domain', 'domain-web'
+    url TEXT NOT NULL,
+    address TEXT,
+    suburb TEXT,
+    state TEXT DEFAULT 'NSW',
+    postcode TEXT,
+    latitude REAL,
+    longitude REAL,
+    weekly_rent INTEGER,                  -- Rent in dollars per week
+    rent_text TEXT,                       -- Original rent display text
+    property_type TEXT,
+    bedrooms INTEGER,
+    bathrooms INTEGER,
+    land_size_sqm REAL,
+    description TEXT,
+    images TEXT,                          -- JSON array of image URLs
+    scraped_at DATETIME NOT NULL,
+    updated_at DATETIME NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_rentals_external_source ON rentals(external_id, source);
+
+-- Weekly auction results (clearance outcomes and sold prices), linked to properties by address
+CREATE TABLE IF NOT EXISTS auction_results (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    property_id INTEGER REFERENCES properties(id) ON DELETE SET NULL,
+    source TEXT NOT NULL,                 -- 'domain'
+    city TEXT NOT NULL,                   -- Results page the outcome was listed on, e.g. 'sydney'
+    auction_date TEXT NOT NULL,           -- YYYY-MM-DD (Saturday the results were published for)
+    address TEXT NOT NULL,
+    suburb TEXT NOT NULL,
+    state TEXT DEFAULT 'NSW',
+    postcode TEXT,
+    property_type TEXT,
+    bedrooms INTEGER,
+    result TEXT NOT NULL,                 -- 'sold', 'sold_prior', 'sold_after', 'passed_in', 'withdrawn'
+    sold_price INTEGER,                   -- NULL if undisclosed or not sold
+    agency TEXT,
+    url TEXT,
+    scraped_at DATETIME NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_auction_results_unique ON auction_results(source, auction_date, address, suburb);
+
+-- NSW Valuer General property sales (PSI bulk data), one row per lot in each sale
+CREATE TABLE IF NOT EXISTS historical_sales (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    lot_id_string TEXT NOT NULL,          -- Cadastral lot ID, e.g. "699//DP752033"
+    dealing_number TEXT NOT NULL,         -- Land Registry dealing; shared by every lot in the sale
+    district_code TEXT,
+    vg_property_id TEXT,
+    address TEXT,
+    locality TEXT,
+    postcode TEXT,
+    area_sqm REAL,                        -- Area of the whole sale
+    contract_date TEXT NOT NULL,          -- YYYY-MM-DD
+    settlement_date TEXT,                 -- YYYY-MM-DD
+    purchase_price INTEGER NOT NULL,      -- Price of the whole sale, not this lot
+    lot_count INTEGER NOT NULL DEFAULT 1, -- Lots in the sale
+    zoning TEXT,
+    nature TEXT,                          -- 'V' vacant, 'R' residence, '3' other
+    primary_purpose TEXT,
+    imported_at DATETIME NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_historical_sales_unique ON historical_sales(dealing_number, lot_id_string);
+
+-- NSW Valuer General unimproved land values, latest value per lot. A VG property
+-- covering several lots has the same value on each, for all of them together.
+CREATE TABLE IF NOT EXISTS land_values (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    lot_id_string TEXT NOT NULL UNIQUE,   -- Cadastral lot ID, e.g. "699//DP752033"
+    vg_property_id TEXT NOT NULL,
+    district_code TEXT,
+    address TEXT,
+    locality TEXT,
+    postcode TEXT,
+    zone_code TEXT,
+    area_sqm REAL,                        -- Area of the whole VG property
+    land_value INTEGER NOT NULL,          -- Value of the whole VG property
+    base_date TEXT,                       -- YYYY-MM-DD
+    lot_count INTEGER NOT NULL DEFAULT 1, -- Lots in the VG property
+    imported_at DATETIME NOT NULL
+);
+
+-- G-NAF addresses (tools gnaf): a state's current, principal addresses, or
+-- those in localities with listings
+CREATE TABLE IF NOT EXISTS gnaf_addresses (
+    address_detail_pid TEXT PRIMARY KEY,  -- e.g. 'GANSW710280564'
+    address TEXT NOT NULL,                -- Formatted, e.g. '289 SCOTTS LANE, CURRABUBULA NSW 2342'
+    number_first TEXT,                    -- House number, with any prefix and suffix
+    number_last TEXT,                     -- Last of a range, e.g. 14 in 12-14
+    lot_number TEXT,
+    street_name TEXT,                     -- Upper case, e.g. 'OLD BATHURST'
+    street_type TEXT,                     -- In full, e.g. 'ROAD'
+    locality TEXT NOT NULL,               -- Upper case suburb or locality
+    state TEXT NOT NULL,
+    postcode TEXT,
+    legal_parcel_id TEXT,                 -- Lot it's on, e.g. '12/DP753790'
+    latitude REAL,                        -- Default geocode
+    longitude REAL,
+    imported_at DATETIME NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_gnaf_addresses_locality ON gnaf_addresses(state, locality);
+
+-- Upcoming open-for-inspection times and auctions reported by listing sources
+CREATE TABLE IF NOT EXISTS property_events (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    property_id INTEGER NOT NULL REFERENCES properties(id) ON DELETE CASCADE,
+    kind TEXT NOT NULL,                   -- 'inspection' or 'auction'
+    starts_at DATETIME NOT NULL,
+    ends_at DATETIME,
+    location TEXT,                        -- Auction venue if not on site
+    source TEXT NOT NULL                  -- Source that reported it, e.g. 'domain'
+);
+
+-- Listing videos and floorplans reported by listing sources (photos are
+-- properties.images)
+CREATE TABLE IF NOT EXISTS property_images (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    property_id INTEGER NOT NULL REFERENCES properties(id) ON DELETE CASCADE,
+    kind TEXT NOT NULL,                   -- 'video' or 'floorplan'
+    url TEXT NOT NULL,
+    position INTEGER NOT NULL,            -- Order the source lists it in
+    source TEXT NOT NULL                  -- Source that reported it, e.g. 'domain'
+);
+
+-- Listing change log, written as scrapes save listings: new listings, asking
+-- price drops, and listings back on the market after dropping out of their
+-- source's scrapes
+CREATE TABLE IF NOT EXISTS property_changes (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    property_id INTEGER NOT NULL REFERENCES properties(id) ON DELETE CASCADE,
+    kind TEXT NOT NULL,                   -- 'new', 'price_drop' or 'back_on_market'
+    changed_at DATETIME NOT NULL,
+    price_before INTEGER,                 -- Asking price (top of the range) before a drop
+    price_after INTEGER                   -- and after it
+);
+
+-- Listing history: the asking price, type and land size each listing had
+-- over time, a row per version, so the market can be queried as it was on a
+-- date. Written as scrapes save listings; the latest version (valid_to NULL)
+-- runs until the listing was last scraped, or on while it's still listed.
+CREATE TABLE IF NOT EXISTS property_history (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    property_id INTEGER NOT NULL REFERENCES properties(id) ON DELETE CASCADE,
+    valid_from DATETIME NOT NULL,
+    valid_to DATETIME,                    -- When a scrape changed it, or when last scraped before going off the market
+    price_min INTEGER,
+    price_max INTEGER,
+    price_text TEXT,
+    property_type TEXT,                   -- Canonical type
+    land_size_sqm REAL
+);
+
+-- Saved searches (filters stored as an /api/properties query string)
+CREATE TABLE IF NOT EXISTS saved_searches (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL,
+    query TEXT NOT NULL,                  -- e.g. 'price_max=1500000&land_size_min=40'
+    created_at DATETIME NOT NULL
+);
+
+-- Share links: a snapshot of the sidebar's filter state behind a short token
+-- (/s/{token}), so someone else can open exactly the same view
+CREATE TABLE IF NOT EXISTS share_links (
+    token TEXT PRIMARY KEY,               -- Random, URL-safe, e.g. 'q3Xr9vKa'
+    filters TEXT NOT NULL,                -- JSON filter state as the frontend keeps it
+    created_at DATETIME NOT NULL
+);
+
+-- User-defined tags on properties, e.g. 'needs-water-check' or 'shortlist-round-2'
+CREATE TABLE IF NOT EXISTS property_tags (
+    property_id INTEGER NOT NULL,
+    tag TEXT NOT NULL,                    -- Lowercase, e.g. 'shortlist-round-2'
+    tagged_at DATETIME NOT NULL,
+    PRIMARY KEY (property_id, tag)
+);
+
+CREATE INDEX IF NOT EXISTS idx_property_tags_tag ON property_tags(tag);
+
+-- Every change made through the API, for reviewing who changed what. Rows are
+-- never updated or deleted (enforced by the triggers below).
+CREATE TABLE IF NOT EXISTS audit_log (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    created_at DATETIME NOT NULL,
+    user TEXT,                            -- From the auth proxy or basic auth, if any
+    remote_addr TEXT,
+    action TEXT NOT NULL,                 -- e.g. 'property.set_coordinates', 'saved_search.delete'
+    entity TEXT NOT NULL,                 -- 'property', 'attachment', 'saved_search', 'tag', ...
+    entity_id TEXT,                       -- ID (or tag name) of what changed
+    before_json TEXT,                     -- State before the change, if it existed
+    after_json TEXT                       -- State after the change, unless deleted
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_log_created ON audit_log(created_at);
+CREATE INDEX IF NOT EXISTS idx_audit_log_entity ON audit_log(entity, entity_id);
+
+CREATE TRIGGER IF NOT EXISTS audit_log_no_update BEFORE UPDATE ON audit_log
+BEGIN
+    SELECT RAISE(ABORT, 'audit_log is append-only');
+END;
+
+CREATE TRIGGER IF NOT EXISTS audit_log_no_delete BEFORE DELETE ON audit_log
+BEGIN
+    SELECT RAISE(ABORT, 'audit_log is append-only');
+END;
+
+-- Files attached to properties (contracts, 10.7 certificates, soil tests); the
+-- files themselves are in the attachment store (disk or S3)
+CREATE TABLE IF NOT EXISTS property_attachments (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    property_id INTEGER NOT NULL,
+    kind TEXT NOT NULL,                   -- 'contract', 'section-10-7', 'soil-test', 'survey', 'report' or 'other'
+    filename TEXT NOT NULL,               -- As uploaded
+    content_type TEXT NOT NULL,
+    size_bytes INTEGER NOT NULL,
+    sha256 TEXT NOT NULL,
+    storage_key TEXT NOT NULL,            -- Key in the attachment store
+    note TEXT,
+    uploaded_at DATETIME NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_property_attachments_property ON property_attachments(property_id);
+
+-- Snapshots of what each saved search matched, for diffs over time
+CREATE TABLE IF NOT EXISTS saved_search_snapshots (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    search_id INTEGER NOT NULL,
+    taken_at DATETIME NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_saved_search_snapshots_search ON saved_search_snapshots(search_id, taken_at);
+
+-- The matching properties in each snapshot, with the fields a diff compares
+CREATE TABLE IF NOT EXISTS saved_search_snapshot_items (
+    snapshot_id INTEGER NOT NULL,
+    property_id INTEGER NOT NULL,
+    address TEXT,
+    price_text TEXT,
+    price_min INTEGER,
+    price_max INTEGER,
+    land_size_sqm REAL,
+    property_type TEXT,
+    PRIMARY KEY (snapshot_id, property_id)
+);
+
+-- Suburbs and LGAs subscribed to for weekly summaries
+CREATE TABLE IF NOT EXISTS watchlists (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    kind TEXT NOT NULL,                   -- 'suburb' or 'lga'
+    code TEXT NOT NULL,                   -- SAL or LGA code
+    name TEXT NOT NULL,
+    created_at DATETIME NOT NULL,
+    UNIQUE (kind, code)
+);
+
+-- Summaries of each watchlist's market over a period (tools watchlist-reports)
+CREATE TABLE IF NOT EXISTS watchlist_reports (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    watchlist_id INTEGER NOT NULL,
+    period_start DATETIME NOT NULL,
+    period_end DATETIME NOT NULL,
+    summary TEXT NOT NULL,                -- JSON models.WatchlistSummary
+    created_at DATETIME NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_watchlist_reports_watchlist ON watchlist_reports(watchlist_id, period_end);
+
+-- People's own places (a friend's house, a favourite trailhead) properties
+-- are measured against, for filters like "within 45 min of Mum"
+CREATE TABLE IF NOT EXISTS user_pois (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL UNIQUE COLLATE NOCASE, -- e.g. 'Mum'
+    latitude REAL NOT NULL,
+    longitude REAL NOT NULL,
+    created_at DATETIME NOT NULL
+);
+
+-- Distance and drive time from each property to each user POI (the
+-- poi_drive_times enrichment step)
+CREATE TABLE IF NOT EXISTS property_poi_times (
+    property_id INTEGER NOT NULL,
+    poi_id INTEGER NOT NULL,
+    distance_km REAL NOT NULL,            -- Straight line
+    drive_time_mins INTEGER,              -- NULL if unroutable or held for review
+    computed_at DATETIME NOT NULL,
+    PRIMARY KEY (property_id, poi_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_property_poi_times_poi ON property_poi_times(poi_id, drive_time_mins);
+
+-- Scoring weight profiles (one per person, e.g. 'Dave' or 'Sam')
+CREATE TABLE IF NOT EXISTS score_profiles (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL,
+    weights TEXT NOT NULL,                -- JSON criterion -> weight, e.g. '{"drive_time_primary":3,"price_per_ha":2}'
+    created_at DATETIME NOT NULL,
+    updated_at DATETIME NOT NULL
+);
+
+-- Property scores per profile (0-100), recomputed when the profile or the data changes
+CREATE TABLE IF NOT EXISTS property_scores (
+    property_id INTEGER NOT NULL,
+    profile_id INTEGER NOT NULL,
+    score REAL NOT NULL,
+    computed_at DATETIME NOT NULL,
+    PRIMARY KEY (property_id, profile_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_property_scores_profile ON property_scores(profile_id, score);
+
+-- What the property list reads: one row per canonical property with
+-- coordinates, copied from properties with its distance to Sydney. Kept up to
+-- date by triggers marking properties dirty and RefreshPropertySearch.
+CREATE TABLE IF NOT EXISTS property_search (
+    id INTEGER PRIMARY KEY,     -- properties.id
+    latitude REAL NOT NULL,
+    longitude REAL NOT NULL,
+    price_text TEXT,
+    property_type TEXT,
+    address TEXT,
+    suburb TEXT,
+    source TEXT NOT NULL,
+    price_min INTEGER,
+    price_max INTEGER,
+    land_size_sqm REAL,
+    land_value INTEGER,
+    drive_time_primary INTEGER,
+    first_seen_at DATETIME,
+    nearest_town_1_km REAL,
+    nearest_town_1_mins INTEGER,
+    nearest_town_1_walk_mins INTEGER,
+    nearest_town_1_cycle_mins INTEGER,
+    nearest_school_1_mins INTEGER,
+    nearest_wind_farm_km REAL,
+    nearest_solar_farm_km REAL,
+    highway_km REAL,
+    railway_km REAL,
+    runway_km REAL,
+    koala_habitat_pct REAL,
+    biodiversity_pct REAL,
+    clearing_flagged INTEGER,
+    ndvi_mean REAL,
+    ndvi_seasonal_range REAL,
+    has_dwelling INTEGER,
+    suburb_mismatch INTEGER,
+    postcode_mismatch INTEGER,
+    subdivision_ratio REAL,
+    bedrooms INTEGER,
+    bathrooms INTEGER,
+    carspaces INTEGER,
+    dwelling_count INTEGER,
+    distance_sydney_km REAL     -- property_distances' capital distance to Sydney
+);
+
+-- Properties whose property_search row is out of date
+CREATE TABLE IF NOT EXISTS property_search_dirty (
+    property_id INTEGER PRIMARY KEY
+);
+
+-- Listings deleted by tools prune, which keeps their listing history and
+-- change log: who they were attributed to and when they were last scraped,
+-- so history and as-of queries answer as they did before the prune
+CREATE TABLE IF NOT EXISTS pruned_listings (
+    property_id INTEGER PRIMARY KEY,    -- The pruned properties.id
+    canonical_id INTEGER,               -- Its canonical property when pruned, if a duplicate
+    source TEXT NOT NULL,
+    scraped_at DATETIME NOT NULL,       -- When last scraped
+    pruned_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- property_search rows of pruned canonical properties, as they were when
+-- pruned, which the list query includes for listing history
+CREATE TABLE IF NOT EXISTS property_search_pruned (
+    id INTEGER PRIMARY KEY,     -- The pruned properties.id
+    latitude REAL NOT NULL,
+    longitude REAL NOT NULL,
+    price_text TEXT,
+    property_type TEXT,
+    address TEXT,
+    suburb TEXT,
+    source TEXT NOT NULL,
+    price_min INTEGER,
+    price_max INTEGER,
+    land_size_sqm REAL,
+    land_value INTEGER,
+    drive_time_primary INTEGER,
+    first_seen_at DATETIME,
+    nearest_town_1_km REAL,
+    nearest_town_1_mins INTEGER,
+    nearest_town_1_walk_mins INTEGER,
+    nearest_town_1_cycle_mins INTEGER,
+    nearest_school_1_mins INTEGER,
+    nearest_wind_farm_km REAL,
+    nearest_solar_farm_km REAL,
+    highway_km REAL,
+    railway_km REAL,
+    runway_km REAL,
+    koala_habitat_pct REAL,
+    biodiversity_pct REAL,
+    clearing_flagged INTEGER,
+    ndvi_mean REAL,
+    ndvi_seasonal_range REAL,
+    has_dwelling INTEGER,
+    suburb_mismatch INTEGER,
+    postcode_mismatch INTEGER,
+    subdivision_ratio REAL,
+    bedrooms INTEGER,
+    bathrooms INTEGER,
+    carspaces INTEGER,
+    dwelling_count INTEGER,
+    distance_sydney_km REAL     -- property_distances' capital distance to Sydney
+);
+
+-- Parse diagnostics (listings extracted per scraper extraction path, per run)
+CREATE TABLE IF NOT EXISTS parse_stats (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    run_at DATETIME NOT NULL,
+    source TEXT NOT NULL,                 -- 'rea', 'domain-web', 'farmbuy', etc.
+    path TEXT NOT NULL,                   -- Extraction path, e.g. 'argonaut_map', 'next_data', 'none'
+    pages INTEGER NOT NULL DEFAULT 0,
+    listings INTEGER NOT NULL DEFAULT 0
+);
+
 -- Indexes for performance
 CREATE INDEX IF NOT EXISTS idx_properties_coords ON properties(latitude, longitude);
 CREATE INDEX IF NOT EXISTS idx_properties_price ON properties(price_min, price_max);
@@ -119,3 +894,22 @@ CREATE INDEX IF NOT EXISTS idx_towns_coords ON towns(latitude, longitude);
 CREATE INDEX IF NOT EXISTS idx_schools_coords ON schools(latitude, longitude);
 CREATE INDEX IF NOT EXISTS idx_cadastral_lots_coords ON cadastral_lots(centroid_lat, centroid_lng);
 CREATE INDEX IF NOT EXISTS idx_property_lots_lot ON property_lots(lot_id);
+CREATE INDEX IF NOT EXISTS idx_parse_stats_source ON parse_stats(source, run_at);
+CREATE INDEX IF NOT EXISTS idx_rentals_coords ON rentals(latitude, longitude);
+CREATE INDEX IF NOT EXISTS idx_building_footprints_coords ON building_footprints(latitude, longitude);
+CREATE INDEX IF NOT EXISTS idx_auction_results_property ON auction_results(property_id);
+CREATE INDEX IF NOT EXISTS idx_historical_sales_lot ON historical_sales(lot_id_string);
+CREATE INDEX IF NOT EXISTS idx_property_events_property ON property_events(property_id, starts_at);
+CREATE INDEX IF NOT EXISTS idx_property_images_property ON property_images(property_id, position);
+CREATE INDEX IF NOT EXISTS idx_property_changes_time ON property_changes(changed_at);
+CREATE INDEX IF NOT EXISTS idx_property_history_property ON property_history(property_id, valid_to);
+CREATE INDEX IF NOT EXISTS idx_property_search_coords ON property_search(latitude, longitude);
+CREATE INDEX IF NOT EXISTS idx_property_search_price ON property_search(price_min, price_max);
+CREATE INDEX IF NOT EXISTS idx_property_search_price_max ON property_search(price_max);
+CREATE INDEX IF NOT EXISTS idx_property_search_land_size ON property_search(land_size_sqm);
+CREATE INDEX IF NOT EXISTS idx_property_search_type_land_size ON property_search(property_type, land_size_sqm);
+CREATE INDEX IF NOT EXISTS idx_property_search_drive_time ON property_search(drive_time_primary);
+CREATE INDEX IF NOT EXISTS idx_property_search_town_km ON property_search(nearest_town_1_km);
+CREATE INDEX IF NOT EXISTS idx_property_search_town_mins ON property_search(nearest_town_1_mins);
+CREATE INDEX IF NOT EXISTS idx_property_search_school_mins ON property_search(nearest_school_1_mins);
+CREATE INDEX IF NOT EXISTS idx_property_search_sydney ON property_search(distance_sydney_km);
@@ -0,0 +1,166 @@
+package db
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"farm-search/internal/models"
+)
+
+// scoreProfileRow is a score_profiles row, with weights still JSON
+type scoreProfileRow struct {
+	ID        int64     `db:"id"`
+	Name      string    `db:"name"`
+	Weights   string    `db:"weights"`
+	CreatedAt time.Time `db:"created_at"`
+	UpdatedAt time.Time `db:"updated_at"`
+}
+
+func (r scoreProfileRow) profile() (models.ScoreProfile, error) {
+	p := models.ScoreProfile{ID: r.ID, Name: r.Name, CreatedAt: r.CreatedAt, UpdatedAt: r.UpdatedAt}
+	if err := json.Unmarshal([]byte(r.Weights), &p.Weights); err != nil {
+		return p, fmt.Errorf("failed to decode weights of score profile %d: %w", r.ID, err)
+	}
+	return p, nil
+}
+
+// CreateScoreProfile saves a score profile and sets its ID
+func (db *DB) CreateScoreProfile(p *models.ScoreProfile) error {
+	weights, err := json.Marshal(p.Weights)
+	if err != nil {
+		return fmt.Errorf("failed to encode weights: %w", err)
+	}
+	p.CreatedAt = time.Now().UTC().Truncate(time.Second)
+	p.UpdatedAt = p.CreatedAt
+	result, err := db.Exec("INSERT INTO score_profiles (name, weights, created_at, updated_at) VALUES (?, ?, ?, ?)",
+		p.Name, string(weights), p.CreatedAt, p.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create score profile: %w", err)
+	}
+	p.ID, err = result.LastInsertId()
+	return err
+}
+
+// UpdateScoreProfile saves a profile's name and weights, reporting whether it
+// exists. Its scores are left for the caller to recompute.
+func (db *DB) UpdateScoreProfile(p *models.ScoreProfile) (bool, error) {
+	weights, err := json.Marshal(p.Weights)
+	if err != nil {
+		return false, fmt.Errorf("failed to encode weights: %w", err)
+	}
+	p.UpdatedAt = time.Now().UTC().Truncate(time.Second)
+	result, err := db.Exec("UPDATE score_profiles SET name = ?, weights = ?, updated_at = ? WHERE id = ?",
+		p.Name, string(weights), p.UpdatedAt, p.ID)
+	if err != nil {
+		return false, fmt.Errorf("failed to update score profile: %w", err)
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+// ListScoreProfiles returns all score profiles, oldest first
+func (db *DB) ListScoreProfiles() ([]models.ScoreProfile, error) {
+	var rows []scoreProfileRow
+	if err := db.Select(&rows, "SELECT id, name, weights, created_at, updated_at FROM score_profiles ORDER BY id"); err != nil {
+		return nil, fmt.Errorf("failed to list score profiles: %w", err)
+	}
+
+	profiles := make([]models.ScoreProfile, 0, len(rows))
+	for _, r := range rows {
+		p, err := r.profile()
+		if err != nil {
+			return nil, err
+		}
+		profiles = append(profiles, p)
+	}
+	return profiles, nil
+}
+
+// GetScoreProfile returns a score profile by ID
+func (db *DB) GetScoreProfile(id int64) (*models.ScoreProfile, error) {
+	var r scoreProfileRow
+	if err := db.Get(&r, "SELECT id, name, weights, created_at, updated_at FROM score_profiles WHERE id = ?", id); err != nil {
+		return nil, fmt.Errorf("failed to get score profile: %w", err)
+	}
+	p, err := r.profile()
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// DeleteScoreProfile removes a score profile and its scores, reporting
+// whether it existed
+func (db *DB) DeleteScoreProfile(id int64) (bool, error) {
+	tx, err := db.Beginx()
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM property_scores WHERE profile_id = ?", id); err != nil {
+		return false, fmt.Errorf("failed to delete scores: %w", err)
+	}
+	result, err := tx.Exec("DELETE FROM score_profiles WHERE id = ?", id)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete score profile: %w", err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, tx.Commit()
+}
+
+// GetScoringInputs returns what each canonical property with coordinates is
+// scored on
+func (db *DB) GetScoringInputs() ([]models.ScoringInput, error) {
+	var inputs []models.ScoringInput
+	err := db.Select(&inputs, `
+		SELECT p.id, p.drive_time_primary, p.nearest_town_1_mins, p.nearest_school_1_mins,
+			CASE WHEN p.price_min IS NOT NULL THEN (p.price_min + COALESCE(p.price_max, p.price_min)) / 2.0 END AS price_mid,
+			p.land_size_sqm,
+			`+askingVsLandValueExpr+` AS asking_vs_land_value_ratio
+		FROM properties p
+		LEFT JOIN property_links pl ON p.id = pl.duplicate_id
+		WHERE p.latitude IS NOT NULL AND p.longitude IS NOT NULL
+			AND pl.duplicate_id IS NULL
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get scoring inputs: %w", err)
+	}
+	return inputs, nil
+}
+
+// ReplacePropertyScores replaces a profile's scores with scores, keyed by
+// property ID
+func (db *DB) ReplacePropertyScores(profileID int64, scores map[int64]float64) error {
+	tx, err := db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM property_scores WHERE profile_id = ?", profileID); err != nil {
+		return fmt.Errorf("failed to clear scores: %w", err)
+	}
+
+	stmt, err := tx.Prepare("INSERT INTO property_scores (property_id, profile_id, score, computed_at) VALUES (?, ?, ?, ?)")
+	if err != nil {
+		return fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	now := time.Now().UTC()
+	for propertyID, score := range scores {
+		if _, err := stmt.Exec(propertyID, profileID, score, now); err != nil {
+			return fmt.Errorf("failed to save score for property %d: %w", propertyID, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit scores: %w", err)
+	}
+	return nil
+}
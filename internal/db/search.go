@@ -0,0 +1,168 @@
+package db
+
+import (
+	"fmt"
+	"log"
+	"strings"
+)
+
+// propertySearchColumns are the properties columns copied to property_search:
+// those the property list returns, filters or sorts on. property_search_pruned
+// has the same columns.
+var propertySearchColumns = []string{
+	"id", "latitude", "longitude", "price_text", "property_type", "address", "suburb", "source",
+	"price_min", "price_max", "land_size_sqm", "land_value", "drive_time_primary", "first_seen_at",
+	"nearest_town_1_km", "nearest_town_1_mins", "nearest_town_1_walk_mins", "nearest_town_1_cycle_mins",
+	"nearest_school_1_mins", "nearest_wind_farm_km", "nearest_solar_farm_km", "highway_km", "railway_km", "runway_km",
+	"koala_habitat_pct", "biodiversity_pct", "clearing_flagged", "ndvi_mean", "ndvi_seasonal_range", "has_dwelling",
+	"suburb_mismatch", "postcode_mismatch", "subdivision_ratio", "bedrooms", "bathrooms", "carspaces",
+	"dwelling_count",
+}
+
+// searchWithPruned is property_search with the rows of pruned properties, as
+// they were when pruned, for listing history
+var searchWithPruned = `(
+	SELECT ` + strings.Join(propertySearchColumns, ", ") + `, distance_sydney_km FROM property_search
+	UNION ALL
+	SELECT ` + strings.Join(propertySearchColumns, ", ") + `, distance_sydney_km FROM property_search_pruned
+)`
+
+// searchTriggers mark a property's property_search row dirty when what it's
+// copied from changes: the property (its copied columns), its duplicate link,
+// or its distance to Sydney. Created in runMigrations as the copied columns
+// include some added there. They use ON CONFLICT DO NOTHING rather than
+// INSERT OR IGNORE: a trigger fired by an upsert (as saving a listing is)
+// takes the upsert's conflict handling, so OR IGNORE would fail the save.
+var searchTriggers = []string{
+	`CREATE TRIGGER IF NOT EXISTS properties_search_insert
+	AFTER INSERT ON properties
+	BEGIN
+		INSERT INTO property_search_dirty (property_id) VALUES (NEW.id) ON CONFLICT DO NOTHING;
+	END`,
+	`CREATE TRIGGER IF NOT EXISTS properties_search_update
+	AFTER UPDATE OF ` + strings.Join(propertySearchColumns, ", ") + ` ON properties
+	BEGIN
+		INSERT INTO property_search_dirty (property_id) VALUES (NEW.id), (OLD.id) ON CONFLICT DO NOTHING;
+	END`,
+	`CREATE TRIGGER IF NOT EXISTS properties_search_delete
+	AFTER DELETE ON properties
+	BEGIN
+		INSERT INTO property_search_dirty (property_id) VALUES (OLD.id) ON CONFLICT DO NOTHING;
+	END`,
+	`CREATE TRIGGER IF NOT EXISTS property_links_search_insert
+	AFTER INSERT ON property_links
+	BEGIN
+		INSERT INTO property_search_dirty (property_id) VALUES (NEW.duplicate_id) ON CONFLICT DO NOTHING;
+	END`,
+	`CREATE TRIGGER IF NOT EXISTS property_links_search_update
+	AFTER UPDATE OF duplicate_id ON property_links
+	BEGIN
+		INSERT INTO property_search_dirty (property_id) VALUES (NEW.duplicate_id), (OLD.duplicate_id) ON CONFLICT DO NOTHING;
+	END`,
+	`CREATE TRIGGER IF NOT EXISTS property_links_search_delete
+	AFTER DELETE ON property_links
+	BEGIN
+		INSERT INTO property_search_dirty (property_id) VALUES (OLD.duplicate_id) ON CONFLICT DO NOTHING;
+	END`,
+	`CREATE TRIGGER IF NOT EXISTS property_distances_search_insert
+	AFTER INSERT ON property_distances
+	WHEN NEW.target_type = 'capital' AND NEW.target_name = 'Sydney'
+	BEGIN
+		INSERT INTO property_search_dirty (property_id) VALUES (NEW.property_id) ON CONFLICT DO NOTHING;
+	END`,
+	`CREATE TRIGGER IF NOT EXISTS property_distances_search_update
+	AFTER UPDATE ON property_distances
+	WHEN (NEW.target_type = 'capital' AND NEW.target_name = 'Sydney')
+		OR (OLD.target_type = 'capital' AND OLD.target_name = 'Sydney')
+	BEGIN
+		INSERT INTO property_search_dirty (property_id) VALUES (NEW.property_id), (OLD.property_id) ON CONFLICT DO NOTHING;
+	END`,
+	`CREATE TRIGGER IF NOT EXISTS property_distances_search_delete
+	AFTER DELETE ON property_distances
+	WHEN OLD.target_type = 'capital' AND OLD.target_name = 'Sydney'
+	BEGIN
+		INSERT INTO property_search_dirty (property_id) VALUES (OLD.property_id) ON CONFLICT DO NOTHING;
+	END`,
+}
+
+// searchTriggerNames are searchTriggers' names, to drop them before they're
+// recreated
+var searchTriggerNames = []string{
+	"properties_search_insert", "properties_search_update", "properties_search_delete",
+	"property_links_search_insert", "property_links_search_update", "property_links_search_delete",
+	"property_distances_search_insert", "property_distances_search_update", "property_distances_search_delete",
+}
+
+// seedPropertySearchQuery marks every property dirty while property_search is
+// empty, so a database from before it (or one whose copy was dropped) gets
+// its rows on the next refresh
+const seedPropertySearchQuery = `
+	INSERT OR IGNORE INTO property_search_dirty (property_id)
+	SELECT id FROM properties WHERE NOT EXISTS (SELECT 1 FROM property_search)
+`
+
+// RefreshPropertySearch brings the property_search rows of dirty properties
+// up to date from properties, returning how many were refreshed. Writers call
+// it once they've saved (scrapes, enrichment, the API after each request that
+// could write, and New and Close for anything left dirty), so ListProperties
+// only reads. While another goroutine is refreshing, it waits for that
+// refresh and then refreshes what was marked dirty since. A read-only replica
+// is left as the writer last refreshed it.
+func (db *DB) RefreshPropertySearch() (int, error) {
+	if db.readOnly {
+		return 0, nil
+	}
+	db.searchRefresh.Lock()
+	defer db.searchRefresh.Unlock()
+
+	var dirty bool
+	if err := db.DB.Get(&dirty, "SELECT EXISTS (SELECT 1 FROM property_search_dirty)"); err != nil {
+		return 0, fmt.Errorf("failed to check property search: %w", err)
+	}
+	if !dirty {
+		return 0, nil
+	}
+
+	tx, err := db.Beginx()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM property_search WHERE id IN (SELECT property_id FROM property_search_dirty)"); err != nil {
+		return 0, fmt.Errorf("failed to clear property search rows: %w", err)
+	}
+	_, err = tx.Exec(`
+		INSERT INTO property_search (` + strings.Join(propertySearchColumns, ", ") + `, distance_sydney_km)
+		SELECT p.` + strings.Join(propertySearchColumns, ", p.") + `, pd_sydney.distance_km
+		FROM property_search_dirty d
+		JOIN properties p ON p.id = d.property_id
+		LEFT JOIN property_distances pd_sydney ON p.id = pd_sydney.property_id
+			AND pd_sydney.target_type = 'capital' AND pd_sydney.target_name = 'Sydney'
+		LEFT JOIN property_links pl ON p.id = pl.duplicate_id
+		WHERE p.latitude IS NOT NULL AND p.longitude IS NOT NULL
+			AND pl.duplicate_id IS NULL  -- Duplicates aren't listed
+	`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to copy property search rows: %w", err)
+	}
+	res, err := tx.Exec("DELETE FROM property_search_dirty")
+	if err != nil {
+		return 0, fmt.Errorf("failed to clear dirty properties: %w", err)
+	}
+	refreshed, _ := res.RowsAffected()
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit property search: %w", err)
+	}
+	return int(refreshed), nil
+}
+
+// Close refreshes property_search with what this process left dirty, so a
+// read-only replica (which can't refresh) lists it, then closes the database
+func (db *DB) Close() error {
+	if _, err := db.RefreshPropertySearch(); err != nil {
+		log.Printf("Warning: %v", err)
+	}
+	return db.DB.Close()
+}
@@ -0,0 +1,198 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"farm-search/internal/models"
+)
+
+// CreateSavedSearch saves a named property filter query string and sets its ID
+func (db *DB) CreateSavedSearch(s *models.SavedSearch) error {
+	s.CreatedAt = time.Now().UTC().Truncate(time.Second)
+	result, err := db.Exec("INSERT INTO saved_searches (name, query, created_at) VALUES (?, ?, ?)",
+		s.Name, s.Query, s.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create saved search: %w", err)
+	}
+	s.ID, err = result.LastInsertId()
+	return err
+}
+
+// ListSavedSearches returns all saved searches, oldest first
+func (db *DB) ListSavedSearches() ([]models.SavedSearch, error) {
+	searches := []models.SavedSearch{}
+	if err := db.Select(&searches, "SELECT id, name, query, created_at FROM saved_searches ORDER BY id"); err != nil {
+		return nil, fmt.Errorf("failed to list saved searches: %w", err)
+	}
+	return searches, nil
+}
+
+// GetSavedSearch returns a saved search by ID
+func (db *DB) GetSavedSearch(id int64) (*models.SavedSearch, error) {
+	var s models.SavedSearch
+	if err := db.Get(&s, "SELECT id, name, query, created_at FROM saved_searches WHERE id = ?", id); err != nil {
+		return nil, fmt.Errorf("failed to get saved search: %w", err)
+	}
+	return &s, nil
+}
+
+// DeleteSavedSearch removes a saved search and its snapshots, reporting
+// whether it existed
+func (db *DB) DeleteSavedSearch(id int64) (bool, error) {
+	tx, err := db.Beginx()
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM saved_search_snapshot_items
+		WHERE snapshot_id IN (SELECT id FROM saved_search_snapshots WHERE search_id = ?)`, id); err != nil {
+		return false, fmt.Errorf("failed to delete snapshot items: %w", err)
+	}
+	if _, err := tx.Exec("DELETE FROM saved_search_snapshots WHERE search_id = ?", id); err != nil {
+		return false, fmt.Errorf("failed to delete snapshots: %w", err)
+	}
+	result, err := tx.Exec("DELETE FROM saved_searches WHERE id = ?", id)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete saved search: %w", err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, tx.Commit()
+}
+
+// searchMatchColumns are the property columns a saved search snapshot keeps
+const searchMatchColumns = "address, price_text, price_min, price_max, land_size_sqm, property_type"
+
+// SaveSearchSnapshot records the properties a saved search matches now,
+// returning when the snapshot was taken
+func (db *DB) SaveSearchSnapshot(searchID int64, propertyIDs []int64) (time.Time, error) {
+	tx, err := db.Beginx()
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	takenAt := time.Now().UTC().Truncate(time.Second)
+	result, err := tx.Exec("INSERT INTO saved_search_snapshots (search_id, taken_at) VALUES (?, ?)", searchID, takenAt)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to create snapshot: %w", err)
+	}
+	snapshotID, err := result.LastInsertId()
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	stmt, err := tx.Prepare(`
+		INSERT INTO saved_search_snapshot_items (snapshot_id, property_id, ` + searchMatchColumns + `)
+		SELECT ?, id, ` + searchMatchColumns + ` FROM properties WHERE id = ?
+	`)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, id := range propertyIDs {
+		if _, err := stmt.Exec(snapshotID, id); err != nil {
+			return time.Time{}, fmt.Errorf("failed to save snapshot item %d: %w", id, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return time.Time{}, fmt.Errorf("failed to commit snapshot: %w", err)
+	}
+	return takenAt, nil
+}
+
+// GetSearchSnapshotAt returns the latest snapshot of a saved search taken at
+// or before t, or failing that its earliest, with its matches. found is
+// false if the search has no snapshots.
+func (db *DB) GetSearchSnapshotAt(searchID int64, t time.Time) (takenAt time.Time, matches []models.SearchMatch, found bool, err error) {
+	var snapshot struct {
+		ID      int64     `db:"id"`
+		TakenAt time.Time `db:"taken_at"`
+	}
+	err = db.Get(&snapshot, `
+		SELECT id, taken_at FROM saved_search_snapshots WHERE search_id = ?
+		ORDER BY taken_at <= ? DESC, CASE WHEN taken_at <= ? THEN taken_at END DESC, taken_at
+		LIMIT 1
+	`, searchID, t.UTC(), t.UTC())
+	if err == sql.ErrNoRows {
+		return time.Time{}, nil, false, nil
+	}
+	if err != nil {
+		return time.Time{}, nil, false, fmt.Errorf("failed to get snapshot: %w", err)
+	}
+
+	if err := db.Select(&matches, `
+		SELECT property_id, `+searchMatchColumns+` FROM saved_search_snapshot_items WHERE snapshot_id = ?
+	`, snapshot.ID); err != nil {
+		return time.Time{}, nil, false, fmt.Errorf("failed to get snapshot items: %w", err)
+	}
+	return snapshot.TakenAt, matches, true, nil
+}
+
+// GetSearchMatches returns the current snapshot fields of properties
+func (db *DB) GetSearchMatches(propertyIDs []int64) ([]models.SearchMatch, error) {
+	matches := []models.SearchMatch{}
+	// Chunked to stay under SQLite's variable limit
+	for start := 0; start < len(propertyIDs); start += 500 {
+		chunk := propertyIDs[start:min(start+500, len(propertyIDs))]
+		placeholders := make([]string, len(chunk))
+		args := make([]interface{}, len(chunk))
+		for i, id := range chunk {
+			placeholders[i] = "?"
+			args[i] = id
+		}
+
+		var rows []models.SearchMatch
+		query := fmt.Sprintf("SELECT id AS property_id, %s FROM properties WHERE id IN (%s)",
+			searchMatchColumns, strings.Join(placeholders, ","))
+		if err := db.Select(&rows, query, args...); err != nil {
+			return nil, fmt.Errorf("failed to get search matches: %w", err)
+		}
+		matches = append(matches, rows...)
+	}
+	return matches, nil
+}
+
+// PruneSearchSnapshots deletes snapshots taken before cutoff, except each
+// search's latest, returning the number deleted
+func (db *DB) PruneSearchSnapshots(cutoff time.Time) (int64, error) {
+	tx, err := db.Beginx()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`
+		CREATE TEMP TABLE old_snapshots AS
+		SELECT id FROM saved_search_snapshots s
+		WHERE taken_at < ?
+			AND taken_at < (SELECT MAX(taken_at) FROM saved_search_snapshots WHERE search_id = s.search_id)
+	`, cutoff.UTC()); err != nil {
+		return 0, fmt.Errorf("failed to find old snapshots: %w", err)
+	}
+
+	if _, err := tx.Exec("DELETE FROM saved_search_snapshot_items WHERE snapshot_id IN (SELECT id FROM old_snapshots)"); err != nil {
+		return 0, fmt.Errorf("failed to delete snapshot items: %w", err)
+	}
+	result, err := tx.Exec("DELETE FROM saved_search_snapshots WHERE id IN (SELECT id FROM old_snapshots)")
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete snapshots: %w", err)
+	}
+	n, _ := result.RowsAffected()
+
+	if _, err := tx.Exec("DROP TABLE temp.old_snapshots"); err != nil {
+		return 0, fmt.Errorf("failed to drop temp table: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit: %w", err)
+	}
+	return n, nil
+}
@@ -0,0 +1,39 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"farm-search/internal/models"
+)
+
+// shareLinkRow is a share_links row, with filters still text
+type shareLinkRow struct {
+	Token     string    `db:"token"`
+	Filters   string    `db:"filters"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+// CreateShareLink saves a share link's filter state under its token
+func (db *DB) CreateShareLink(s *models.ShareLink) error {
+	s.CreatedAt = time.Now().UTC().Truncate(time.Second)
+	if _, err := db.Exec("INSERT INTO share_links (token, filters, created_at) VALUES (?, ?, ?)",
+		s.Token, string(s.Filters), s.CreatedAt); err != nil {
+		return fmt.Errorf("failed to create share link: %w", err)
+	}
+	return nil
+}
+
+// GetShareLink returns a share link by token, or nil if there's none
+func (db *DB) GetShareLink(token string) (*models.ShareLink, error) {
+	var r shareLinkRow
+	err := db.Get(&r, "SELECT token, filters, created_at FROM share_links WHERE token = ?", token)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get share link: %w", err)
+	}
+	return &models.ShareLink{Token: r.Token, Filters: []byte(r.Filters), CreatedAt: r.CreatedAt}, nil
+}
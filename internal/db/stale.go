@@ -0,0 +1,242 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// StepLandValue is the land value totalled from a property's lots. It isn't
+// an EnrichmentStep the tools fetch, but is marked stale when the lots change.
+const StepLandValue EnrichmentStep = "land_value"
+
+// staleTriggers mark a property's steps stale when what they were computed
+// from changes: its coordinates (everything), its lots (land value, heritage,
+// overlays, fire history and access point), its access point (the drive,
+// walking and cycling times routed from it, including to user POIs), its
+// nearest towns or schools (the drive times to them), or its description or
+// bedrooms (its dwelling count). Steps are only marked once there's something
+// to recompute from, so nulling columns doesn't. A step already marked is
+// remarked with ON CONFLICT DO UPDATE rather than INSERT OR REPLACE, which a
+// trigger fired by an upsert (as saving a listing is) would turn into a failed
+// save.
+var staleTriggers = []string{
+	`CREATE TRIGGER IF NOT EXISTS properties_coordinates_stale
+	AFTER UPDATE OF latitude, longitude ON properties
+	WHEN NEW.latitude IS NOT NULL AND NEW.longitude IS NOT NULL
+		AND (OLD.latitude IS NOT NEW.latitude OR OLD.longitude IS NOT NEW.longitude)
+	BEGIN
+		INSERT INTO property_stale_steps (property_id, step, reason, marked_at) VALUES
+			(NEW.id, 'drive_time_primary', 'coordinates', CURRENT_TIMESTAMP),
+			(NEW.id, 'nearest_towns', 'coordinates', CURRENT_TIMESTAMP),
+			(NEW.id, 'town_drive_times', 'coordinates', CURRENT_TIMESTAMP),
+			(NEW.id, 'town_walk_cycle_times', 'coordinates', CURRENT_TIMESTAMP),
+			(NEW.id, 'nearest_schools', 'coordinates', CURRENT_TIMESTAMP),
+			(NEW.id, 'school_drive_times', 'coordinates', CURRENT_TIMESTAMP),
+			(NEW.id, 'poi_drive_times', 'coordinates', CURRENT_TIMESTAMP),
+			(NEW.id, 'cadastral_lots', 'coordinates', CURRENT_TIMESTAMP),
+			(NEW.id, 'energy_developments', 'coordinates', CURRENT_TIMESTAMP),
+			(NEW.id, 'noise_sources', 'coordinates', CURRENT_TIMESTAMP),
+			(NEW.id, 'biosecurity', 'coordinates', CURRENT_TIMESTAMP),
+			(NEW.id, 'imagery_links', 'coordinates', CURRENT_TIMESTAMP),
+			(NEW.id, 'access_point', 'coordinates', CURRENT_TIMESTAMP)
+		ON CONFLICT DO UPDATE SET reason = excluded.reason, marked_at = excluded.marked_at;
+	END`,
+	`CREATE TRIGGER IF NOT EXISTS property_lots_insert_stale
+	AFTER INSERT ON property_lots
+	BEGIN
+		INSERT INTO property_stale_steps (property_id, step, reason, marked_at) VALUES
+			(NEW.property_id, 'land_value', 'lots', CURRENT_TIMESTAMP),
+			(NEW.property_id, 'heritage', 'lots', CURRENT_TIMESTAMP),
+			(NEW.property_id, 'overlays', 'lots', CURRENT_TIMESTAMP),
+			(NEW.property_id, 'fire_history', 'lots', CURRENT_TIMESTAMP),
+			(NEW.property_id, 'clearing', 'lots', CURRENT_TIMESTAMP),
+			(NEW.property_id, 'ndvi', 'lots', CURRENT_TIMESTAMP),
+			(NEW.property_id, 'buildings', 'lots', CURRENT_TIMESTAMP),
+			(NEW.property_id, 'subdivision', 'lots', CURRENT_TIMESTAMP),
+			(NEW.property_id, 'access_point', 'lots', CURRENT_TIMESTAMP)
+		ON CONFLICT DO UPDATE SET reason = excluded.reason, marked_at = excluded.marked_at;
+	END`,
+	`CREATE TRIGGER IF NOT EXISTS property_lots_delete_stale
+	AFTER DELETE ON property_lots
+	BEGIN
+		INSERT INTO property_stale_steps (property_id, step, reason, marked_at) VALUES
+			(OLD.property_id, 'land_value', 'lots', CURRENT_TIMESTAMP),
+			(OLD.property_id, 'heritage', 'lots', CURRENT_TIMESTAMP),
+			(OLD.property_id, 'overlays', 'lots', CURRENT_TIMESTAMP),
+			(OLD.property_id, 'fire_history', 'lots', CURRENT_TIMESTAMP),
+			(OLD.property_id, 'clearing', 'lots', CURRENT_TIMESTAMP),
+			(OLD.property_id, 'ndvi', 'lots', CURRENT_TIMESTAMP),
+			(OLD.property_id, 'buildings', 'lots', CURRENT_TIMESTAMP),
+			(OLD.property_id, 'subdivision', 'lots', CURRENT_TIMESTAMP),
+			(OLD.property_id, 'access_point', 'lots', CURRENT_TIMESTAMP)
+		ON CONFLICT DO UPDATE SET reason = excluded.reason, marked_at = excluded.marked_at;
+	END`,
+	`CREATE TRIGGER IF NOT EXISTS properties_access_stale
+	AFTER UPDATE OF access_lat, access_lng ON properties
+	WHEN NEW.access_source IS NOT NULL
+		AND (OLD.access_lat IS NOT NEW.access_lat OR OLD.access_lng IS NOT NEW.access_lng)
+	BEGIN
+		INSERT INTO property_stale_steps (property_id, step, reason, marked_at) VALUES
+			(NEW.id, 'drive_time_primary', 'access_point', CURRENT_TIMESTAMP),
+			(NEW.id, 'town_drive_times', 'access_point', CURRENT_TIMESTAMP),
+			(NEW.id, 'town_walk_cycle_times', 'access_point', CURRENT_TIMESTAMP),
+			(NEW.id, 'school_drive_times', 'access_point', CURRENT_TIMESTAMP),
+			(NEW.id, 'poi_drive_times', 'access_point', CURRENT_TIMESTAMP)
+		ON CONFLICT DO UPDATE SET reason = excluded.reason, marked_at = excluded.marked_at;
+	END`,
+	`CREATE TRIGGER IF NOT EXISTS properties_nearest_towns_stale
+	AFTER UPDATE OF nearest_town_1, nearest_town_2 ON properties
+	WHEN NEW.nearest_town_1 IS NOT NULL
+		AND (OLD.nearest_town_1 IS NOT NEW.nearest_town_1 OR OLD.nearest_town_2 IS NOT NEW.nearest_town_2)
+	BEGIN
+		INSERT INTO property_stale_steps (property_id, step, reason, marked_at)
+		VALUES (NEW.id, 'town_drive_times', 'nearest_towns', CURRENT_TIMESTAMP)
+		ON CONFLICT DO UPDATE SET reason = excluded.reason, marked_at = excluded.marked_at;
+	END`,
+	`CREATE TRIGGER IF NOT EXISTS properties_nearest_schools_stale
+	AFTER UPDATE OF nearest_school_1, nearest_school_2 ON properties
+	WHEN NEW.nearest_school_1 IS NOT NULL
+		AND (OLD.nearest_school_1 IS NOT NEW.nearest_school_1 OR OLD.nearest_school_2 IS NOT NEW.nearest_school_2)
+	BEGIN
+		INSERT INTO property_stale_steps (property_id, step, reason, marked_at)
+		VALUES (NEW.id, 'school_drive_times', 'nearest_schools', CURRENT_TIMESTAMP)
+		ON CONFLICT DO UPDATE SET reason = excluded.reason, marked_at = excluded.marked_at;
+	END`,
+	`CREATE TRIGGER IF NOT EXISTS properties_listing_text_stale
+	AFTER UPDATE OF description, bedrooms ON properties
+	WHEN NEW.description IS NOT NULL
+		AND (OLD.description IS NOT NEW.description OR OLD.bedrooms IS NOT NEW.bedrooms)
+	BEGIN
+		INSERT INTO property_stale_steps (property_id, step, reason, marked_at)
+		VALUES (NEW.id, 'dwellings', 'description', CURRENT_TIMESTAMP)
+		ON CONFLICT DO UPDATE SET reason = excluded.reason, marked_at = excluded.marked_at;
+	END`,
+}
+
+// MarkStepsStale marks steps stale for every property with coordinates, e.g.
+// when a target they were computed against changes. Returns the number of
+// properties marked.
+func (db *DB) MarkStepsStale(reason string, steps ...EnrichmentStep) (int64, error) {
+	tx, err := db.Beginx()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var marked int64
+	now := time.Now().UTC()
+	for _, step := range steps {
+		result, err := tx.Exec(`
+			INSERT OR REPLACE INTO property_stale_steps (property_id, step, reason, marked_at)
+			SELECT id, ?, ?, ? FROM properties
+			WHERE latitude IS NOT NULL AND longitude IS NOT NULL
+		`, step, reason, now)
+		if err != nil {
+			return 0, fmt.Errorf("failed to mark %s stale: %w", step, err)
+		}
+		marked, _ = result.RowsAffected()
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit stale steps: %w", err)
+	}
+	return marked, nil
+}
+
+// MarkPropertyStepsStale marks steps stale for one property
+func (db *DB) MarkPropertyStepsStale(propertyID int64, reason string, steps ...EnrichmentStep) error {
+	now := time.Now().UTC()
+	for _, step := range steps {
+		_, err := db.Exec(`
+			INSERT OR REPLACE INTO property_stale_steps (property_id, step, reason, marked_at)
+			VALUES (?, ?, ?, ?)`, propertyID, step, reason, now)
+		if err != nil {
+			return fmt.Errorf("failed to mark %s stale: %w", step, err)
+		}
+	}
+	return nil
+}
+
+// ClearStaleStep records that a property's step has been recomputed
+func (db *DB) ClearStaleStep(propertyID int64, step EnrichmentStep) error {
+	_, err := db.Exec("DELETE FROM property_stale_steps WHERE property_id = ? AND step = ?", propertyID, step)
+	return err
+}
+
+// CountStaleSteps returns the number of properties each step is stale for
+func (db *DB) CountStaleSteps() (map[EnrichmentStep]int, error) {
+	var rows []struct {
+		Step  EnrichmentStep `db:"step"`
+		Count int            `db:"count"`
+	}
+	if err := db.Select(&rows, "SELECT step, COUNT(*) AS count FROM property_stale_steps GROUP BY step"); err != nil {
+		return nil, fmt.Errorf("failed to count stale steps: %w", err)
+	}
+
+	counts := make(map[EnrichmentStep]int, len(rows))
+	for _, r := range rows {
+		counts[r.Step] = r.Count
+	}
+	return counts, nil
+}
+
+// GetEnrichmentInput returns the fingerprint recorded for an enrichment
+// input, or "" if none has been yet
+func (db *DB) GetEnrichmentInput(name string) (string, error) {
+	var fingerprint string
+	err := db.Get(&fingerprint, "SELECT fingerprint FROM enrichment_inputs WHERE name = ?", name)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get enrichment input: %w", err)
+	}
+	return fingerprint, nil
+}
+
+// SetEnrichmentInput records the fingerprint of an enrichment input
+func (db *DB) SetEnrichmentInput(name, fingerprint string) error {
+	_, err := db.Exec(`
+		INSERT INTO enrichment_inputs (name, fingerprint, updated_at) VALUES (?, ?, ?)
+		ON CONFLICT(name) DO UPDATE SET fingerprint = excluded.fingerprint, updated_at = excluded.updated_at
+	`, name, fingerprint, time.Now().UTC())
+	if err != nil {
+		return fmt.Errorf("failed to set enrichment input: %w", err)
+	}
+	return nil
+}
+
+// RefreshStaleLandValues retotals the land value of properties whose lots
+// changed, clearing it first in case none of the new lots have one. A
+// non-zero propertyID limits it to that property. Returns the number of
+// properties refreshed.
+func (db *DB) RefreshStaleLandValues(propertyID int64) (int, error) {
+	filter, args := "", []interface{}{StepLandValue}
+	if propertyID != 0 {
+		filter = " AND property_id = ?"
+		args = append(args, propertyID)
+	}
+
+	var ids []int64
+	if err := db.Select(&ids, "SELECT property_id FROM property_stale_steps WHERE step = ?"+filter, args...); err != nil {
+		return 0, fmt.Errorf("failed to get stale land values: %w", err)
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	if _, err := db.Exec(`
+		UPDATE properties SET land_value = NULL, land_value_base_date = NULL
+		WHERE id IN (SELECT property_id FROM property_stale_steps WHERE step = ?`+filter+`)
+	`, args...); err != nil {
+		return 0, fmt.Errorf("failed to clear stale land values: %w", err)
+	}
+	if _, err := db.UpdatePropertyLandValues(); err != nil {
+		return 0, err
+	}
+	if _, err := db.Exec("DELETE FROM property_stale_steps WHERE step = ?"+filter, args...); err != nil {
+		return 0, fmt.Errorf("failed to clear stale land values: %w", err)
+	}
+	return len(ids), nil
+}
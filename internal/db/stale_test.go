@@ -0,0 +1,79 @@
+package db
+
+import "testing"
+
+// staleSteps returns the steps marked stale for a listing, with their reasons
+func staleSteps(t *testing.T, database *DB, externalID string) map[string]string {
+	t.Helper()
+	var rows []struct {
+		Step   string `db:"step"`
+		Reason string `db:"reason"`
+	}
+	err := database.Select(&rows, `
+		SELECT s.step, s.reason FROM property_stale_steps s
+		JOIN properties p ON p.id = s.property_id
+		WHERE p.external_id = ?
+	`, externalID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	steps := make(map[string]string)
+	for _, row := range rows {
+		steps[row.Step] = row.Reason
+	}
+	return steps
+}
+
+// Re-saving a listing whose steps are already stale remarks them. The
+// triggers fire inside the save's upsert, so remarking with INSERT OR
+// REPLACE failed the save.
+func TestSavePropertiesRemarksMovedCoordinates(t *testing.T) {
+	database := newTestDB(t)
+
+	saveListings(t, database, testListing("2019000001", -33.1342, 149.6931))
+	if steps := staleSteps(t, database, "2019000001"); len(steps) != 0 {
+		t.Fatalf("new listing has stale steps %v, want none", steps)
+	}
+
+	// Each move marks (then remarks) every coordinate step
+	for _, lat := range []float64{-33.1350, -33.1361} {
+		result := saveListings(t, database, testListing("2019000001", lat, 149.6931))
+		if result.Updated != 1 {
+			t.Fatalf("moving to %v updated %d listings, want 1", lat, result.Updated)
+		}
+		steps := staleSteps(t, database, "2019000001")
+		for _, step := range []string{"drive_time_primary", "nearest_towns", "cadastral_lots", "access_point"} {
+			if steps[step] != "coordinates" {
+				t.Errorf("after moving to %v, %s stale for %q, want coordinates", lat, step, steps[step])
+			}
+		}
+	}
+}
+
+// As for coordinates, a changed description remarks the dwelling count on
+// every re-save, not just the first
+func TestSavePropertiesRemarksChangedDescription(t *testing.T) {
+	database := newTestDB(t)
+
+	descriptions := []string{
+		"Three bedroom homestead on 40 acres.",
+		"Three bedroom homestead and a granny flat on 40 acres.",
+		"Three bedroom homestead, granny flat and workers cottage on 40 acres.",
+	}
+	for i, description := range descriptions {
+		listing := testListing("2019000002", -33.1342, 149.6931)
+		listing.Description.String, listing.Description.Valid = description, true
+		saveListings(t, database, listing)
+
+		steps := staleSteps(t, database, "2019000002")
+		if i == 0 {
+			if _, ok := steps["dwellings"]; ok {
+				t.Fatalf("new listing has dwellings stale, want it left to the step")
+			}
+			continue
+		}
+		if steps["dwellings"] != "description" {
+			t.Errorf("after description %d, dwellings stale for %q, want description", i+1, steps["dwellings"])
+		}
+	}
+}
@@ -0,0 +1,216 @@
+package db
+
+import (
+	"fmt"
+	"time"
+
+	"farm-search/internal/models"
+)
+
+// ReplaceSuburbBoundaries replaces every suburb boundary with a fresh
+// import, in one transaction. Returns the number saved.
+func (db *DB) ReplaceSuburbBoundaries(boundaries []models.SuburbBoundary) (int, error) {
+	tx, err := db.Beginx()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM suburb_boundaries"); err != nil {
+		return 0, fmt.Errorf("failed to clear suburb boundaries: %w", err)
+	}
+
+	stmt, err := tx.Prepare(`
+		INSERT OR REPLACE INTO suburb_boundaries (sal_code, name, state, geometry, imported_at)
+		VALUES (?, ?, ?, ?, ?)
+	`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prepare suburb boundary insert: %w", err)
+	}
+	defer stmt.Close()
+
+	now := time.Now().UTC()
+	for _, b := range boundaries {
+		if _, err := stmt.Exec(b.Code, b.Name, b.State, b.Geometry, now); err != nil {
+			return 0, fmt.Errorf("failed to save suburb boundary %s: %w", b.Name, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit suburb boundaries: %w", err)
+	}
+	return len(boundaries), nil
+}
+
+// GetSuburbBoundaries returns every suburb boundary, by name
+func (db *DB) GetSuburbBoundaries() ([]models.SuburbBoundary, error) {
+	var boundaries []models.SuburbBoundary
+	if err := db.Select(&boundaries, "SELECT * FROM suburb_boundaries ORDER BY name"); err != nil {
+		return nil, fmt.Errorf("failed to get suburb boundaries: %w", err)
+	}
+	return boundaries, nil
+}
+
+// GetSuburbBoundaryVersion returns a string that changes whenever suburb
+// boundaries are reimported, for caching the polygons parsed from them
+func (db *DB) GetSuburbBoundaryVersion() (string, error) {
+	var version string
+	err := db.Get(&version, `
+		SELECT COUNT(*) || '|' || COALESCE(MAX(imported_at), '') FROM suburb_boundaries
+	`)
+	if err != nil {
+		return "", fmt.Errorf("failed to get suburb boundary version: %w", err)
+	}
+	return version, nil
+}
+
+// ReplacePostcodeBoundaries replaces every postcode boundary with a fresh
+// import, in one transaction. Returns the number saved.
+func (db *DB) ReplacePostcodeBoundaries(boundaries []models.PostcodeBoundary) (int, error) {
+	tx, err := db.Beginx()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM postcode_boundaries"); err != nil {
+		return 0, fmt.Errorf("failed to clear postcode boundaries: %w", err)
+	}
+
+	stmt, err := tx.Prepare(`
+		INSERT OR REPLACE INTO postcode_boundaries (postcode, geometry, imported_at)
+		VALUES (?, ?, ?)
+	`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prepare postcode boundary insert: %w", err)
+	}
+	defer stmt.Close()
+
+	now := time.Now().UTC()
+	for _, b := range boundaries {
+		if _, err := stmt.Exec(b.Postcode, b.Geometry, now); err != nil {
+			return 0, fmt.Errorf("failed to save postcode boundary %s: %w", b.Postcode, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit postcode boundaries: %w", err)
+	}
+	return len(boundaries), nil
+}
+
+// GetPostcodeBoundaries returns every postcode boundary, by postcode
+func (db *DB) GetPostcodeBoundaries() ([]models.PostcodeBoundary, error) {
+	var boundaries []models.PostcodeBoundary
+	if err := db.Select(&boundaries, "SELECT * FROM postcode_boundaries ORDER BY postcode"); err != nil {
+		return nil, fmt.Errorf("failed to get postcode boundaries: %w", err)
+	}
+	return boundaries, nil
+}
+
+// ReplaceLGABoundaries replaces every local government area boundary with a
+// fresh import, in one transaction. Returns the number saved.
+func (db *DB) ReplaceLGABoundaries(boundaries []models.RegionBoundary) (int, error) {
+	tx, err := db.Beginx()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM lga_boundaries"); err != nil {
+		return 0, fmt.Errorf("failed to clear LGA boundaries: %w", err)
+	}
+
+	stmt, err := tx.Prepare(`
+		INSERT OR REPLACE INTO lga_boundaries (lga_code, name, state, geometry, imported_at)
+		VALUES (?, ?, ?, ?, ?)
+	`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prepare LGA boundary insert: %w", err)
+	}
+	defer stmt.Close()
+
+	now := time.Now().UTC()
+	for _, b := range boundaries {
+		if _, err := stmt.Exec(b.Code, b.Name, b.State, b.Geometry, now); err != nil {
+			return 0, fmt.Errorf("failed to save LGA boundary %s: %w", b.Name, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit LGA boundaries: %w", err)
+	}
+	return len(boundaries), nil
+}
+
+// FindRegionBoundary returns the suburb (kind "suburb") or LGA ("lga")
+// boundary with region as its code or name (ignoring case), or nil if there
+// isn't one
+func (db *DB) FindRegionBoundary(kind, region string) (*models.RegionBoundary, error) {
+	var query string
+	switch kind {
+	case "suburb":
+		query = "SELECT 'suburb' AS kind, sal_code AS code, name, state, geometry FROM suburb_boundaries WHERE sal_code = ? OR name = ? COLLATE NOCASE"
+	case "lga":
+		query = "SELECT 'lga' AS kind, lga_code AS code, name, state, geometry FROM lga_boundaries WHERE lga_code = ? OR name = ? COLLATE NOCASE"
+	default:
+		return nil, fmt.Errorf("unknown region kind %q", kind)
+	}
+
+	var boundaries []models.RegionBoundary
+	if err := db.Select(&boundaries, query+" ORDER BY code LIMIT 1", region, region); err != nil {
+		return nil, fmt.Errorf("failed to find %s boundary: %w", kind, err)
+	}
+	if len(boundaries) == 0 {
+		return nil, nil
+	}
+	return &boundaries[0], nil
+}
+
+// ReplaceSuburbVelocity replaces every suburb's listing velocity, in one
+// transaction. Returns the number saved.
+func (db *DB) ReplaceSuburbVelocity(velocities []models.SuburbVelocity) (int, error) {
+	tx, err := db.Beginx()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM suburb_velocity"); err != nil {
+		return 0, fmt.Errorf("failed to clear suburb velocity: %w", err)
+	}
+
+	stmt, err := tx.Prepare(`
+		INSERT INTO suburb_velocity (sal_code, off_market, median_days, ema_days, months, computed_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prepare suburb velocity insert: %w", err)
+	}
+	defer stmt.Close()
+
+	now := time.Now().UTC()
+	for _, v := range velocities {
+		if _, err := stmt.Exec(v.Code, v.OffMarket, v.MedianDays, v.EMADays, v.Months, now); err != nil {
+			return 0, fmt.Errorf("failed to save velocity of suburb %s: %w", v.Code, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit suburb velocity: %w", err)
+	}
+	return len(velocities), nil
+}
+
+// GetSuburbVelocity returns every suburb's listing velocity, by SAL code
+func (db *DB) GetSuburbVelocity() (map[string]models.SuburbVelocity, error) {
+	var velocities []models.SuburbVelocity
+	if err := db.Select(&velocities, "SELECT * FROM suburb_velocity"); err != nil {
+		return nil, fmt.Errorf("failed to get suburb velocity: %w", err)
+	}
+	byCode := make(map[string]models.SuburbVelocity, len(velocities))
+	for _, v := range velocities {
+		byCode[v.Code] = v
+	}
+	return byCode, nil
+}
@@ -0,0 +1,136 @@
+package db
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"farm-search/internal/models"
+)
+
+// TagProperties adds a tag to properties, returning how many didn't already
+// have it
+func (db *DB) TagProperties(tag string, propertyIDs []int64) (int64, error) {
+	tx, err := db.Beginx()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare("INSERT OR IGNORE INTO property_tags (property_id, tag, tagged_at) VALUES (?, ?, ?)")
+	if err != nil {
+		return 0, fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	var tagged int64
+	now := time.Now().UTC()
+	for _, id := range propertyIDs {
+		result, err := stmt.Exec(id, tag, now)
+		if err != nil {
+			return 0, fmt.Errorf("failed to tag property %d: %w", id, err)
+		}
+		n, _ := result.RowsAffected()
+		tagged += n
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit tags: %w", err)
+	}
+	return tagged, nil
+}
+
+// UntagProperties removes a tag from properties, returning how many had it
+func (db *DB) UntagProperties(tag string, propertyIDs []int64) (int64, error) {
+	tx, err := db.Beginx()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare("DELETE FROM property_tags WHERE property_id = ? AND tag = ?")
+	if err != nil {
+		return 0, fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	var untagged int64
+	for _, id := range propertyIDs {
+		result, err := stmt.Exec(id, tag)
+		if err != nil {
+			return 0, fmt.Errorf("failed to untag property %d: %w", id, err)
+		}
+		n, _ := result.RowsAffected()
+		untagged += n
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit tags: %w", err)
+	}
+	return untagged, nil
+}
+
+// ListTags returns every tag in use with how many properties have it, by name
+func (db *DB) ListTags() ([]models.TagCount, error) {
+	tags := []models.TagCount{}
+	if err := db.Select(&tags, "SELECT tag, COUNT(*) AS count FROM property_tags GROUP BY tag ORDER BY tag"); err != nil {
+		return nil, fmt.Errorf("failed to list tags: %w", err)
+	}
+	return tags, nil
+}
+
+// CountPropertyTags returns how many of propertyIDs have each tag
+func (db *DB) CountPropertyTags(propertyIDs []int64) (map[string]int, error) {
+	counts := make(map[string]int)
+	// Chunked to stay under SQLite's variable limit
+	for start := 0; start < len(propertyIDs); start += 500 {
+		chunk := propertyIDs[start:min(start+500, len(propertyIDs))]
+		placeholders := make([]string, len(chunk))
+		args := make([]interface{}, len(chunk))
+		for i, id := range chunk {
+			placeholders[i] = "?"
+			args[i] = id
+		}
+
+		var rows []models.TagCount
+		query := fmt.Sprintf("SELECT tag, COUNT(*) AS count FROM property_tags WHERE property_id IN (%s) GROUP BY tag",
+			strings.Join(placeholders, ","))
+		if err := db.Select(&rows, query, args...); err != nil {
+			return nil, fmt.Errorf("failed to count property tags: %w", err)
+		}
+		for _, row := range rows {
+			counts[row.Tag] += row.Count
+		}
+	}
+	return counts, nil
+}
+
+// GetPropertyTags returns a property's tags, by name
+func (db *DB) GetPropertyTags(propertyID int64) ([]string, error) {
+	var tags []string
+	if err := db.Select(&tags, "SELECT tag FROM property_tags WHERE property_id = ? ORDER BY tag", propertyID); err != nil {
+		return nil, fmt.Errorf("failed to get property tags: %w", err)
+	}
+	return tags, nil
+}
+
+// tagConditions returns the WHERE conditions for a filter's tag filters on
+// properties aliased p
+func tagConditions(f PropertyFilter) (string, []interface{}) {
+	var conditions string
+	var args []interface{}
+	for _, tag := range f.Tags {
+		conditions += " AND EXISTS (SELECT 1 FROM property_tags pt WHERE pt.property_id = p.id AND pt.tag = ?)"
+		args = append(args, tag)
+	}
+	if len(f.ExcludeTags) > 0 {
+		placeholders := make([]string, len(f.ExcludeTags))
+		for i, tag := range f.ExcludeTags {
+			placeholders[i] = "?"
+			args = append(args, tag)
+		}
+		conditions += fmt.Sprintf(" AND NOT EXISTS (SELECT 1 FROM property_tags pt WHERE pt.property_id = p.id AND pt.tag IN (%s))",
+			strings.Join(placeholders, ","))
+	}
+	return conditions, args
+}
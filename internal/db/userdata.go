@@ -0,0 +1,139 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"farm-search/internal/models"
+)
+
+// FindPropertyID returns the ID of the property listed by source as
+// externalID, or 0 if there's none
+func (db *DB) FindPropertyID(source, externalID string) (int64, error) {
+	var id int64
+	err := db.Get(&id, "SELECT id FROM properties WHERE external_id = ? AND source = ?", externalID, source)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to find property: %w", err)
+	}
+	return id, nil
+}
+
+// ExportUserTags returns every property tag, by tag
+func (db *DB) ExportUserTags() ([]models.UserTag, error) {
+	tags := []models.UserTag{}
+	err := db.Select(&tags, `
+		SELECT p.source, p.external_id, t.tag, t.tagged_at
+		FROM property_tags t
+		JOIN properties p ON p.id = t.property_id
+		ORDER BY t.tag, p.id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to export tags: %w", err)
+	}
+	return tags, nil
+}
+
+// ImportTag tags a property as of taggedAt, reporting whether it didn't
+// already have the tag
+func (db *DB) ImportTag(propertyID int64, tag string, taggedAt time.Time) (bool, error) {
+	result, err := db.Exec("INSERT OR IGNORE INTO property_tags (property_id, tag, tagged_at) VALUES (?, ?, ?)",
+		propertyID, tag, taggedAt.UTC())
+	if err != nil {
+		return false, fmt.Errorf("failed to import tag: %w", err)
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+// ExportManualCoordinates returns the properties whose coordinates were
+// corrected by hand
+func (db *DB) ExportManualCoordinates() ([]models.UserCoordinates, error) {
+	coords := []models.UserCoordinates{}
+	err := db.Select(&coords, `
+		SELECT source, external_id, latitude, longitude
+		FROM properties
+		WHERE coord_source = 'manual' AND latitude IS NOT NULL AND longitude IS NOT NULL
+		ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to export coordinates: %w", err)
+	}
+	return coords, nil
+}
+
+// ExportManualPropertyLinks returns the duplicate links made by hand
+func (db *DB) ExportManualPropertyLinks() ([]models.UserPropertyLink, error) {
+	links := []models.UserPropertyLink{}
+	err := db.Select(&links, `
+		SELECT c.source AS canonical_source, c.external_id AS canonical_external_id,
+			d.source AS duplicate_source, d.external_id AS duplicate_external_id
+		FROM property_links l
+		JOIN properties c ON c.id = l.canonical_id
+		JOIN properties d ON d.id = l.duplicate_id
+		WHERE l.match_type = 'manual'
+		ORDER BY l.created_at, l.duplicate_id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to export property links: %w", err)
+	}
+	return links, nil
+}
+
+// ExportLinkRejections returns the pairs of properties marked as not the same
+func (db *DB) ExportLinkRejections() ([]models.UserLinkRejection, error) {
+	rejections := []models.UserLinkRejection{}
+	err := db.Select(&rejections, `
+		SELECT a.source AS source_a, a.external_id AS external_id_a,
+			b.source AS source_b, b.external_id AS external_id_b,
+			r.note, r.rejected_at
+		FROM property_link_rejections r
+		JOIN properties a ON a.id = r.property_id_a
+		JOIN properties b ON b.id = r.property_id_b
+		ORDER BY r.rejected_at`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to export link rejections: %w", err)
+	}
+	return rejections, nil
+}
+
+// ImportLinkRejection records a pair of unlinked properties as not the same,
+// reporting whether it wasn't already. A linked pair is unlinked with
+// RejectPropertyLink instead.
+func (db *DB) ImportLinkRejection(id1, id2 int64, note *string, rejectedAt time.Time) (bool, error) {
+	a, b := linkPair(id1, id2)
+	result, err := db.Exec(`
+		INSERT OR IGNORE INTO property_link_rejections (property_id_a, property_id_b, note, rejected_at)
+		VALUES (?, ?, ?, ?)`,
+		a, b, note, rejectedAt.UTC())
+	if err != nil {
+		return false, fmt.Errorf("failed to import link rejection: %w", err)
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+// ListShareLinks returns every share link, oldest first
+func (db *DB) ListShareLinks() ([]models.ShareLink, error) {
+	var rows []shareLinkRow
+	if err := db.Select(&rows, "SELECT token, filters, created_at FROM share_links ORDER BY created_at, token"); err != nil {
+		return nil, fmt.Errorf("failed to list share links: %w", err)
+	}
+	links := make([]models.ShareLink, len(rows))
+	for i, r := range rows {
+		links[i] = models.ShareLink{Token: r.Token, Filters: []byte(r.Filters), CreatedAt: r.CreatedAt}
+	}
+	return links, nil
+}
+
+// ImportShareLink saves a share link as it was, reporting whether its token
+// wasn't already taken
+func (db *DB) ImportShareLink(s models.ShareLink) (bool, error) {
+	result, err := db.Exec("INSERT OR IGNORE INTO share_links (token, filters, created_at) VALUES (?, ?, ?)",
+		s.Token, string(s.Filters), s.CreatedAt.UTC())
+	if err != nil {
+		return false, fmt.Errorf("failed to import share link: %w", err)
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
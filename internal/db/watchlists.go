@@ -0,0 +1,154 @@
+package db
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"farm-search/internal/models"
+)
+
+// CreateWatchlist subscribes to a suburb or LGA and sets w's ID, reporting
+// false (and loading the existing one into w) if it's already watched
+func (db *DB) CreateWatchlist(w *models.Watchlist) (bool, error) {
+	w.CreatedAt = time.Now().UTC().Truncate(time.Second)
+	result, err := db.Exec(`
+		INSERT INTO watchlists (kind, code, name, created_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT (kind, code) DO NOTHING
+	`, w.Kind, w.Code, w.Name, w.CreatedAt)
+	if err != nil {
+		return false, fmt.Errorf("failed to create watchlist: %w", err)
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return false, err
+	} else if n == 0 {
+		if err := db.Get(w, "SELECT id, kind, code, name, created_at FROM watchlists WHERE kind = ? AND code = ?", w.Kind, w.Code); err != nil {
+			return false, fmt.Errorf("failed to get watchlist: %w", err)
+		}
+		return false, nil
+	}
+	w.ID, err = result.LastInsertId()
+	return true, err
+}
+
+// ListWatchlists returns all watchlists, oldest first
+func (db *DB) ListWatchlists() ([]models.Watchlist, error) {
+	watchlists := []models.Watchlist{}
+	if err := db.Select(&watchlists, "SELECT id, kind, code, name, created_at FROM watchlists ORDER BY id"); err != nil {
+		return nil, fmt.Errorf("failed to list watchlists: %w", err)
+	}
+	return watchlists, nil
+}
+
+// GetWatchlist returns a watchlist by ID
+func (db *DB) GetWatchlist(id int64) (*models.Watchlist, error) {
+	var w models.Watchlist
+	if err := db.Get(&w, "SELECT id, kind, code, name, created_at FROM watchlists WHERE id = ?", id); err != nil {
+		return nil, fmt.Errorf("failed to get watchlist: %w", err)
+	}
+	return &w, nil
+}
+
+// DeleteWatchlist removes a watchlist and its reports, reporting whether it
+// existed
+func (db *DB) DeleteWatchlist(id int64) (bool, error) {
+	tx, err := db.Beginx()
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM watchlist_reports WHERE watchlist_id = ?", id); err != nil {
+		return false, fmt.Errorf("failed to delete watchlist reports: %w", err)
+	}
+	result, err := tx.Exec("DELETE FROM watchlists WHERE id = ?", id)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete watchlist: %w", err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, tx.Commit()
+}
+
+// watchlistReportRow is a watchlist_reports row, with the summary still JSON
+type watchlistReportRow struct {
+	ID          int64     `db:"id"`
+	WatchlistID int64     `db:"watchlist_id"`
+	PeriodStart time.Time `db:"period_start"`
+	PeriodEnd   time.Time `db:"period_end"`
+	Summary     string    `db:"summary"`
+	CreatedAt   time.Time `db:"created_at"`
+}
+
+func (r watchlistReportRow) report() (models.WatchlistReport, error) {
+	report := models.WatchlistReport{ID: r.ID, WatchlistID: r.WatchlistID,
+		PeriodStart: r.PeriodStart, PeriodEnd: r.PeriodEnd, CreatedAt: r.CreatedAt}
+	if err := json.Unmarshal([]byte(r.Summary), &report.Summary); err != nil {
+		return report, fmt.Errorf("failed to decode summary of watchlist report %d: %w", r.ID, err)
+	}
+	return report, nil
+}
+
+// SaveWatchlistReport archives a watchlist report and sets its ID
+func (db *DB) SaveWatchlistReport(r *models.WatchlistReport) error {
+	summary, err := json.Marshal(r.Summary)
+	if err != nil {
+		return fmt.Errorf("failed to encode summary: %w", err)
+	}
+	r.CreatedAt = time.Now().UTC().Truncate(time.Second)
+	result, err := db.Exec(`
+		INSERT INTO watchlist_reports (watchlist_id, period_start, period_end, summary, created_at)
+		VALUES (?, ?, ?, ?, ?)
+	`, r.WatchlistID, r.PeriodStart.UTC(), r.PeriodEnd.UTC(), string(summary), r.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save watchlist report: %w", err)
+	}
+	r.ID, err = result.LastInsertId()
+	return err
+}
+
+// ListWatchlistReports returns a watchlist's latest limit reports, newest
+// first
+func (db *DB) ListWatchlistReports(watchlistID int64, limit int) ([]models.WatchlistReport, error) {
+	var rows []watchlistReportRow
+	if err := db.Select(&rows, `
+		SELECT id, watchlist_id, period_start, period_end, summary, created_at FROM watchlist_reports
+		WHERE watchlist_id = ? ORDER BY period_end DESC, id DESC LIMIT ?
+	`, watchlistID, limit); err != nil {
+		return nil, fmt.Errorf("failed to list watchlist reports: %w", err)
+	}
+
+	reports := make([]models.WatchlistReport, 0, len(rows))
+	for _, r := range rows {
+		report, err := r.report()
+		if err != nil {
+			return nil, err
+		}
+		reports = append(reports, report)
+	}
+	return reports, nil
+}
+
+// GetWatchlistReport returns one of a watchlist's reports, or nil if it has
+// no report with that ID
+func (db *DB) GetWatchlistReport(watchlistID, id int64) (*models.WatchlistReport, error) {
+	var r watchlistReportRow
+	err := db.Get(&r, `
+		SELECT id, watchlist_id, period_start, period_end, summary, created_at FROM watchlist_reports
+		WHERE id = ? AND watchlist_id = ?
+	`, id, watchlistID)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get watchlist report: %w", err)
+	}
+	report, err := r.report()
+	if err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
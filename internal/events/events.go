@@ -0,0 +1,109 @@
+// Package events announces changes to properties on a message bus or
+// webhook, so external systems (a dashboard, n8n flows) can react to new
+// listings, price changes and delistings without polling the API. Watchlist
+// reports are delivered the same way, for forwarding as email or chat.
+package events
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"farm-search/internal/db"
+	"farm-search/internal/models"
+)
+
+// Event types
+const (
+	PropertyCreated     = "property.created"     // A listing was saved for the first time
+	PropertyUpdated     = "property.updated"     // A rescrape changed a stored field of a listing
+	PropertyDelisted    = "property.delisted"    // A listing was pruned as no longer advertised
+	EnrichmentCompleted = "enrichment.completed" // Enrichment steps were recomputed for a property
+	WatchlistReported   = "watchlist.report"     // A watchlist's weekly summary was archived
+)
+
+// Event is one change to a property, or a watchlist report, published as JSON
+type Event struct {
+	Type       string                  `json:"type"`
+	Time       time.Time               `json:"time"`
+	PropertyID int64                   `json:"property_id,omitempty"`
+	Source     string                  `json:"source,omitempty"`
+	ExternalID string                  `json:"external_id,omitempty"`
+	URL        string                  `json:"url,omitempty"`
+	Steps      []string                `json:"steps,omitempty"` // The steps an enrichment.completed recomputed
+	Watchlist  *models.Watchlist       `json:"watchlist,omitempty"`
+	Report     *models.WatchlistReport `json:"report,omitempty"`
+}
+
+// Publisher sends events to a message bus or webhook
+type Publisher interface {
+	// Publish sends a batch of events, in order
+	Publish(ctx context.Context, events []Event) error
+}
+
+// FromEnv configures the publisher EVENTS_URL names: nats://[user:pass@]host:port[/prefix]
+// publishes each event to the NATS subject <prefix>.<type> (prefix default
+// "farmsearch"), kafka+http(s)://host:port/topics/<topic> produces them to a
+// Kafka topic through a Confluent REST proxy, and http(s):// URLs get each
+// batch POSTed as a JSON array (e.g. an n8n webhook). Returns nil without an
+// error if EVENTS_URL isn't set.
+func FromEnv() (Publisher, error) {
+	raw := os.Getenv("EVENTS_URL")
+	if raw == "" {
+		return nil, nil
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid EVENTS_URL: %w", err)
+	}
+	switch u.Scheme {
+	case "nats":
+		return newNATSPublisher(u), nil
+	case "kafka+http", "kafka+https":
+		u.Scheme = strings.TrimPrefix(u.Scheme, "kafka+")
+		return &KafkaRESTPublisher{url: u.String(), client: newHTTPClient()}, nil
+	case "http", "https":
+		return &WebhookPublisher{url: u.String(), client: newHTTPClient()}, nil
+	}
+	return nil, fmt.Errorf("unknown EVENTS_URL scheme %q (want nats, kafka+http(s) or http(s))", u.Scheme)
+}
+
+// Send publishes events through p, if there is a publisher and anything to
+// send. Failures are logged rather than returned: the changes are already
+// saved, and a bus being down shouldn't fail a scrape.
+func Send(ctx context.Context, p Publisher, events []Event) {
+	if p == nil || len(events) == 0 {
+		return
+	}
+	if err := p.Publish(ctx, events); err != nil {
+		log.Printf("Failed to publish %d events: %v", len(events), err)
+		return
+	}
+	log.Printf("Published %d events", len(events))
+}
+
+// Listings returns an event of type eventType for each listing
+func Listings(eventType string, listings []db.ListingRef) []Event {
+	now := time.Now().UTC().Truncate(time.Second)
+	events := make([]Event, 0, len(listings))
+	for _, l := range listings {
+		events = append(events, Event{Type: eventType, Time: now, PropertyID: l.ID,
+			Source: l.Source, ExternalID: l.ExternalID, URL: l.URL})
+	}
+	return events
+}
+
+// Saved returns the property.created and property.updated events for a save
+func Saved(result db.SaveResult) []Event {
+	return append(Listings(PropertyCreated, result.New), Listings(PropertyUpdated, result.Changed)...)
+}
+
+// Reported returns the watchlist.report event for a report on w
+func Reported(w *models.Watchlist, report *models.WatchlistReport) Event {
+	return Event{Type: WatchlistReported, Time: report.CreatedAt, Watchlist: w, Report: report,
+		URL: fmt.Sprintf("/api/watchlists/%d/reports/%d", w.ID, report.ID)}
+}
@@ -0,0 +1,78 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+func newHTTPClient() *http.Client {
+	return &http.Client{Timeout: 30 * time.Second}
+}
+
+// WebhookPublisher POSTs each batch of events to a URL as a JSON array
+type WebhookPublisher struct {
+	url    string
+	client *http.Client
+}
+
+// Publish POSTs events as one JSON array
+func (p *WebhookPublisher) Publish(ctx context.Context, events []Event) error {
+	body, err := json.Marshal(events)
+	if err != nil {
+		return err
+	}
+	return post(ctx, p.client, p.url, "application/json", body)
+}
+
+// KafkaRESTPublisher produces events to a Kafka topic through a Confluent
+// REST proxy (v2 API), keyed by property ID so a property's events stay in
+// order on one partition
+type KafkaRESTPublisher struct {
+	url    string // The topic's URL, e.g. http://localhost:8082/topics/farm-search
+	client *http.Client
+}
+
+type kafkaRecord struct {
+	Key   string `json:"key"`
+	Value Event  `json:"value"`
+}
+
+// Publish produces events as one batch of records
+func (p *KafkaRESTPublisher) Publish(ctx context.Context, events []Event) error {
+	records := make([]kafkaRecord, len(events))
+	for i, e := range events {
+		records[i] = kafkaRecord{Key: strconv.FormatInt(e.PropertyID, 10), Value: e}
+	}
+	body, err := json.Marshal(struct {
+		Records []kafkaRecord `json:"records"`
+	}{records})
+	if err != nil {
+		return err
+	}
+	return post(ctx, p.client, p.url, "application/vnd.kafka.json.v2+json", body)
+}
+
+func post(ctx context.Context, client *http.Client, url, contentType string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to post events: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("posting events returned %s: %s", resp.Status, bytes.TrimSpace(msg))
+	}
+	return nil
+}
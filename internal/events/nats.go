@@ -0,0 +1,126 @@
+package events
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// natsTimeout bounds connecting to NATS and publishing a batch
+const natsTimeout = 30 * time.Second
+
+// NATSPublisher publishes events to NATS core subjects over the plain text
+// protocol, one connection per batch: the tools publish a batch at the end of
+// a run, so there's no connection worth keeping open. TLS isn't supported.
+type NATSPublisher struct {
+	addr   string // host:port
+	prefix string // Subjects are prefix.type, e.g. farmsearch.property.created
+	user   string
+	pass   string // With no password, user is sent as a token
+}
+
+func newNATSPublisher(u *url.URL) *NATSPublisher {
+	p := &NATSPublisher{addr: u.Host, prefix: strings.Trim(u.Path, "/")}
+	if u.Port() == "" {
+		p.addr = net.JoinHostPort(u.Hostname(), "4222")
+	}
+	if p.prefix == "" {
+		p.prefix = "farmsearch"
+	}
+	if u.User != nil {
+		p.user = u.User.Username()
+		p.pass, _ = u.User.Password()
+	}
+	return p
+}
+
+// natsInfo is the part of the server's INFO message we need
+type natsInfo struct {
+	TLSRequired bool `json:"tls_required"`
+}
+
+// Publish sends each event to its subject, then waits for the server to
+// answer a PING so errors (e.g. a permissions violation) aren't missed
+func (p *NATSPublisher) Publish(ctx context.Context, events []Event) error {
+	ctx, cancel := context.WithTimeout(ctx, natsTimeout)
+	defer cancel()
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", p.addr)
+	if err != nil {
+		return fmt.Errorf("failed to connect to NATS: %w", err)
+	}
+	defer conn.Close()
+	deadline, _ := ctx.Deadline()
+	conn.SetDeadline(deadline)
+
+	r := bufio.NewReader(conn)
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return fmt.Errorf("failed to read NATS INFO: %w", err)
+	}
+	infoJSON, ok := strings.CutPrefix(strings.TrimSpace(line), "INFO ")
+	if !ok {
+		return fmt.Errorf("unexpected NATS greeting %q", strings.TrimSpace(line))
+	}
+	var info natsInfo
+	if err := json.Unmarshal([]byte(infoJSON), &info); err != nil {
+		return fmt.Errorf("invalid NATS INFO: %w", err)
+	}
+	if info.TLSRequired {
+		return errors.New("NATS server requires TLS, which isn't supported")
+	}
+
+	connect := map[string]interface{}{"verbose": false, "pedantic": false, "name": "farm-search", "lang": "go"}
+	switch {
+	case p.user != "" && p.pass != "":
+		connect["user"], connect["pass"] = p.user, p.pass
+	case p.user != "":
+		connect["auth_token"] = p.user
+	}
+	connectJSON, err := json.Marshal(connect)
+	if err != nil {
+		return err
+	}
+
+	w := bufio.NewWriter(conn)
+	fmt.Fprintf(w, "CONNECT %s\r\n", connectJSON)
+	for _, e := range events {
+		payload, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "PUB %s.%s %d\r\n", p.prefix, e.Type, len(payload))
+		w.Write(payload)
+		w.WriteString("\r\n")
+	}
+	w.WriteString("PING\r\n")
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("failed to publish to NATS: %w", err)
+	}
+
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return fmt.Errorf("failed to publish to NATS: %w", err)
+		}
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "PONG":
+			return nil
+		case strings.HasPrefix(line, "-ERR"):
+			return fmt.Errorf("NATS error: %s", strings.TrimSpace(strings.TrimPrefix(line, "-ERR")))
+		case line == "PING":
+			if _, err := conn.Write([]byte("PONG\r\n")); err != nil {
+				return fmt.Errorf("failed to publish to NATS: %w", err)
+			}
+		}
+		// Anything else (+OK, a later INFO) doesn't matter here
+	}
+}
@@ -0,0 +1,91 @@
+// Package feed writes RSS feeds of property listings for feed readers.
+package feed
+
+import (
+	"encoding/xml"
+	"io"
+	"time"
+)
+
+// Item is an entry in an RSS feed
+type Item struct {
+	GUID        string // Stable across feed refreshes so readers don't show it twice
+	Title       string
+	Link        string
+	Description string // HTML
+	Published   time.Time
+	ImageURL    string // Optional; written as an image enclosure
+}
+
+type rss struct {
+	XMLName xml.Name `xml:"rss"`
+	Version string   `xml:"version,attr"`
+	Channel channel  `xml:"channel"`
+}
+
+type channel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	LastBuildDate string    `xml:"lastBuildDate"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string     `xml:"title"`
+	Link        string     `xml:"link"`
+	GUID        guid       `xml:"guid"`
+	PubDate     string     `xml:"pubDate,omitempty"`
+	Description string     `xml:"description"`
+	Enclosure   *enclosure `xml:"enclosure"`
+}
+
+type guid struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+type enclosure struct {
+	URL    string `xml:"url,attr"`
+	Length int    `xml:"length,attr"` // Unknown, so 0 as the spec suggests
+	Type   string `xml:"type,attr"`
+}
+
+// WriteRSS writes items as an RSS 2.0 feed
+func WriteRSS(w io.Writer, title, link, description string, items []Item) error {
+	doc := rss{
+		Version: "2.0",
+		Channel: channel{
+			Title:         title,
+			Link:          link,
+			Description:   description,
+			LastBuildDate: time.Now().UTC().Format(time.RFC1123Z),
+		},
+	}
+	for _, it := range items {
+		ri := rssItem{
+			Title:       it.Title,
+			Link:        it.Link,
+			GUID:        guid{Value: it.GUID},
+			Description: it.Description,
+		}
+		if !it.Published.IsZero() {
+			ri.PubDate = it.Published.UTC().Format(time.RFC1123Z)
+		}
+		if it.ImageURL != "" {
+			ri.Enclosure = &enclosure{URL: it.ImageURL, Type: "image/jpeg"}
+		}
+		doc.Channel.Items = append(doc.Channel.Items, ri)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
@@ -0,0 +1,231 @@
+package geo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// nswAddressPointURL is NSW Spatial Services' address point layer, one
+// point per addressed property (usually at the gate or the house)
+const nswAddressPointURL = "https://portal.spatial.nsw.gov.au/server/rest/services/NSW_Geocoded_Addressing_Theme/MapServer/1/query"
+
+// AddressPoint is an address from the NSW address point layer
+type AddressPoint struct {
+	Address string // e.g. "289 SCOTTS LANE CURRABUBULA"
+	Lat     float64
+	Lng     float64
+}
+
+// FetchAddressPointsInBounds fetches the NSW address points within the given
+// bounding box
+func (c *CadastralClient) FetchAddressPointsInBounds(ctx context.Context, minLng, minLat, maxLng, maxLat float64) ([]AddressPoint, error) {
+	params := url.Values{}
+	params.Set("where", "1=1")
+	params.Set("outFields", "*")
+	params.Set("geometry", fmt.Sprintf("%f,%f,%f,%f", minLng, minLat, maxLng, maxLat))
+	params.Set("geometryType", "esriGeometryEnvelope")
+	params.Set("inSR", "4326")
+	params.Set("outSR", "4326")
+	params.Set("spatialRel", "esriSpatialRelIntersects")
+	params.Set("f", "geojson")
+	params.Set("resultRecordCount", "2000")
+
+	req, err := http.NewRequestWithContext(ctx, "GET", c.addressURL+"?"+params.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetching address points: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("API returned %d: %s", resp.StatusCode, string(body))
+	}
+
+	var fc struct {
+		Features []struct {
+			Geometry struct {
+				Coordinates []float64 `json:"coordinates"`
+			} `json:"geometry"`
+			Properties map[string]interface{} `json:"properties"`
+		} `json:"features"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&fc); err != nil {
+		return nil, fmt.Errorf("decoding response: %w", err)
+	}
+
+	points := make([]AddressPoint, 0, len(fc.Features))
+	for _, f := range fc.Features {
+		if len(f.Geometry.Coordinates) < 2 {
+			continue
+		}
+		// The full address where the layer has one, else built from its parts
+		field := func(names ...string) string {
+			for _, name := range names {
+				if v, ok := f.Properties[name].(string); ok && strings.TrimSpace(v) != "" {
+					return strings.TrimSpace(v)
+				}
+			}
+			return ""
+		}
+		address := field("address", "fulladdress")
+		if address == "" {
+			var parts []string
+			for _, names := range [][]string{{"housenumber"}, {"roadname"}, {"roadtype"}, {"suburbname", "locality"}} {
+				if v := field(names...); v != "" {
+					parts = append(parts, v)
+				}
+			}
+			address = strings.Join(parts, " ")
+		}
+		if address == "" {
+			continue
+		}
+		points = append(points, AddressPoint{Address: address, Lat: f.Geometry.Coordinates[1], Lng: f.Geometry.Coordinates[0]})
+	}
+	return points, nil
+}
+
+// roadTypes maps the road types addresses use, abbreviated or not, to the
+// full word (as G-NAF's street type codes have them)
+var roadTypes = map[string]string{
+	"ROAD": "ROAD", "RD": "ROAD", "STREET": "STREET", "ST": "STREET", "LANE": "LANE", "LN": "LANE",
+	"AVENUE": "AVENUE", "AVE": "AVENUE", "AV": "AVENUE", "DRIVE": "DRIVE", "DR": "DRIVE",
+	"HIGHWAY": "HIGHWAY", "HWY": "HIGHWAY", "WAY": "WAY", "TRAIL": "TRAIL", "TRL": "TRAIL",
+	"TRACK": "TRACK", "TRK": "TRACK", "CLOSE": "CLOSE", "CL": "CLOSE", "COURT": "COURT", "CT": "COURT",
+	"CRESCENT": "CRESCENT", "CRES": "CRESCENT", "PLACE": "PLACE", "PL": "PLACE", "PARADE": "PARADE", "PDE": "PARADE",
+	"TERRACE": "TERRACE", "TCE": "TERRACE", "CIRCUIT": "CIRCUIT", "CCT": "CIRCUIT", "GROVE": "GROVE", "GR": "GROVE",
+	"BOULEVARD": "BOULEVARD", "BVD": "BOULEVARD", "BLVD": "BOULEVARD",
+}
+
+// addressWords uppercases an address and splits it into words, dropping
+// punctuation
+func addressWords(address string) []string {
+	return strings.FieldsFunc(strings.ToUpper(address), func(r rune) bool {
+		return !(r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '\'')
+	})
+}
+
+// roadNames returns the names of the roads in an address, without their
+// type: "Warragundi 289 Scotts Lane, Currabubula" gives ["SCOTTS"]. Only the
+// word before the type is kept, so "Old Bathurst Rd" gives "BATHURST".
+func roadNames(address string) []string {
+	words := addressWords(address)
+	var names []string
+	for i := 1; i < len(words); i++ {
+		if roadTypes[words[i]] == "" {
+			continue
+		}
+		name := strings.ReplaceAll(words[i-1], "'", "")
+		if name == "" || isNumber(name) {
+			continue // "12 Rd", not a road name
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+// isNumber reports whether an address word is a house or lot number, such as
+// "289" or "12A"
+func isNumber(word string) bool {
+	return word != "" && word[0] >= '0' && word[0] <= '9'
+}
+
+// maxStreetNameWords caps how many words before a road type ParseStreet
+// keeps, since a homestead's name often comes before the street's
+const maxStreetNameWords = 4
+
+// StreetAddress is a listing's street address taken apart
+type StreetAddress struct {
+	Number     string   // First house number, e.g. "289" or "12A"; empty if none
+	NumberLast string   // Last house number of a range such as "12-14"
+	Lot        string   // Lot number, for "Lot 2 Old Bathurst Road"
+	Words      []string // Words before the road type, e.g. ["WARRAGUNDI", "SCOTTS"]; the street's name ends them
+	Type       string   // Road type, in full, e.g. "LANE"
+}
+
+// ParseStreet takes apart the first street in an address: the house (or
+// lot) number, the name and the road type. ok is false if the address names
+// no road. The name may start with other words, such as a homestead's name,
+// so match it with NameMatches.
+func ParseStreet(address string) (s StreetAddress, ok bool) {
+	words := addressWords(address)
+	for i := 1; i < len(words); i++ {
+		if roadTypes[words[i]] == "" || isNumber(words[i-1]) {
+			continue
+		}
+		s.Type = roadTypes[words[i]]
+
+		start := i - 1
+		for start > 0 && i-start < maxStreetNameWords && !isNumber(words[start-1]) && words[start-1] != "LOT" {
+			start--
+		}
+		for _, w := range words[start:i] {
+			s.Words = append(s.Words, strings.ReplaceAll(w, "'", ""))
+		}
+
+		// The number (or range) just before the name
+		end := start
+		for start > 0 && isNumber(words[start-1]) {
+			start--
+		}
+		numbers := words[start:end]
+		if start > 0 && words[start-1] == "LOT" && len(numbers) > 0 {
+			s.Lot = numbers[0]
+		} else if len(numbers) > 0 {
+			s.Number = numbers[0]
+			if len(numbers) > 1 {
+				s.NumberLast = numbers[len(numbers)-1]
+			}
+		}
+		return s, true
+	}
+	return s, false
+}
+
+// NameMatches reports whether a street's name (as G-NAF has it, e.g. "OLD
+// BATHURST") is the end of the words before the address's road type
+func (s StreetAddress) NameMatches(name string) bool {
+	want := addressWords(name)
+	if len(want) == 0 || len(want) > len(s.Words) {
+		return false
+	}
+	offset := len(s.Words) - len(want)
+	for i, w := range want {
+		if strings.ReplaceAll(w, "'", "") != s.Words[offset+i] {
+			return false
+		}
+	}
+	return true
+}
+
+// AddressMatches reports whether found (an address point's address) roughly
+// matches a listing's street address and suburb: on the same road (by name,
+// since listings mix up road types), or in the same suburb if the listing
+// names no road. ok is false if the listing has neither to go by.
+func AddressMatches(street, suburb, found string) (match, ok bool) {
+	if roads := roadNames(street); len(roads) > 0 {
+		for _, road := range roads {
+			for _, other := range roadNames(found) {
+				if road == other {
+					return true, true
+				}
+			}
+		}
+		return false, true
+	}
+
+	want := strings.Join(addressWords(suburb), " ")
+	if want == "" {
+		return false, false
+	}
+	return strings.Contains(" "+strings.Join(addressWords(found), " ")+" ", " "+want+" "), true
+}
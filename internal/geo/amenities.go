@@ -0,0 +1,91 @@
+package geo
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Amenity is a point of interest read from an amenities CSV
+type Amenity struct {
+	Name      string
+	Detail    string
+	Latitude  float64
+	Longitude float64
+}
+
+// ParseAmenitiesCSV reads amenities from a CSV with a header row naming at
+// least name, latitude (or lat) and longitude (or lng/lon) columns, and
+// optionally detail (or suburb/town). Rows without usable coordinates inside
+// Australia are skipped.
+func ParseAmenitiesCSV(r io.Reader) ([]Amenity, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read header: %w", err)
+	}
+
+	nameIdx, detailIdx, latIdx, lngIdx := -1, -1, -1, -1
+	for i, col := range header {
+		switch strings.ToLower(strings.TrimSpace(col)) {
+		case "name":
+			nameIdx = i
+		case "detail", "suburb", "town":
+			if detailIdx == -1 {
+				detailIdx = i
+			}
+		case "latitude", "lat":
+			latIdx = i
+		case "longitude", "lng", "lon":
+			lngIdx = i
+		}
+	}
+	if nameIdx == -1 || latIdx == -1 || lngIdx == -1 {
+		return nil, fmt.Errorf("required columns not found in CSV (need name, latitude, longitude)")
+	}
+
+	var amenities []Amenity
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read row: %w", err)
+		}
+		if nameIdx >= len(record) || latIdx >= len(record) || lngIdx >= len(record) {
+			continue
+		}
+
+		lat, err := strconv.ParseFloat(strings.TrimSpace(record[latIdx]), 64)
+		if err != nil {
+			continue
+		}
+		lng, err := strconv.ParseFloat(strings.TrimSpace(record[lngIdx]), 64)
+		if err != nil {
+			continue
+		}
+		// Roughly Australia, to skip swapped or missing coordinates
+		if lat < -44 || lat > -9 || lng < 112 || lng > 154 {
+			continue
+		}
+
+		amenity := Amenity{
+			Name:      strings.TrimSpace(record[nameIdx]),
+			Latitude:  lat,
+			Longitude: lng,
+		}
+		if amenity.Name == "" {
+			continue
+		}
+		if detailIdx >= 0 && detailIdx < len(record) {
+			amenity.Detail = strings.TrimSpace(record[detailIdx])
+		}
+		amenities = append(amenities, amenity)
+	}
+	return amenities, nil
+}
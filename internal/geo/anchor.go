@@ -0,0 +1,57 @@
+package geo
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Anchor is the primary location drive times, isochrones and the drive time
+// grid are measured to. Sutherland unless ANCHOR configures another, so the
+// same setup works for someone based in Newcastle or Canberra.
+type Anchor struct {
+	Name string
+	Lat  float64
+	Lng  float64
+}
+
+// Sutherland, NSW is the default anchor
+var Sutherland = Anchor{
+	Name: "Sutherland",
+	Lat:  -34.0309,
+	Lng:  151.0579,
+}
+
+// Slug returns the anchor's name in lowercase with dashes for spaces, as used
+// in isochrone file names ("sutherland", "wagga-wagga")
+func (a Anchor) Slug() string {
+	return strings.Join(strings.Fields(strings.ToLower(a.Name)), "-")
+}
+
+// ParseAnchor parses an anchor written "Name:lat,lng", e.g.
+// "Newcastle:-32.9267,151.7789"
+func ParseAnchor(s string) (Anchor, error) {
+	name, coords, ok := strings.Cut(strings.TrimSpace(s), ":")
+	name = strings.TrimSpace(name)
+	parts := strings.Split(coords, ",")
+	if !ok || name == "" || len(parts) != 2 {
+		return Anchor{}, fmt.Errorf("invalid anchor %q: expected Name:lat,lng", s)
+	}
+	lat, err1 := strconv.ParseFloat(strings.TrimSpace(parts[0]), 64)
+	lng, err2 := strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
+	if err1 != nil || err2 != nil || lat < -90 || lat > 90 || lng < -180 || lng > 180 {
+		return Anchor{}, fmt.Errorf("invalid anchor %q: expected Name:lat,lng", s)
+	}
+	return Anchor{Name: name, Lat: lat, Lng: lng}, nil
+}
+
+// AnchorFromEnv returns the anchor configured in ANCHOR ("Name:lat,lng"), or
+// Sutherland if it's unset
+func AnchorFromEnv() (Anchor, error) {
+	s := os.Getenv("ANCHOR")
+	if s == "" {
+		return Sutherland, nil
+	}
+	return ParseAnchor(s)
+}
@@ -0,0 +1,206 @@
+package geo
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+)
+
+// Area is the polygons of a GeoJSON feature collection, such as an
+// isochrone, for testing whether points lie inside it
+type Area struct {
+	polygons       [][]ring // Each polygon's outer ring, then its holes
+	minLat, minLng float64
+	maxLat, maxLng float64
+}
+
+// ring is a closed polygon ring of [lng, lat] vertices, as in GeoJSON
+type ring [][2]float64
+
+// NewArea reads the Polygon and MultiPolygon features of a feature
+// collection. Other geometry types (e.g. isochrone LineStrings) are ignored,
+// so an area can be empty.
+func NewArea(fc *GeoJSONFeatureCollection) (*Area, error) {
+	a := newArea()
+	for _, f := range fc.Features {
+		switch f.Geometry.Type {
+		case "Polygon":
+			var polygon []ring
+			if err := json.Unmarshal(f.Geometry.Coordinates, &polygon); err != nil {
+				return nil, fmt.Errorf("failed to parse polygon: %w", err)
+			}
+			a.add(polygon)
+		case "MultiPolygon":
+			var polygons [][]ring
+			if err := json.Unmarshal(f.Geometry.Coordinates, &polygons); err != nil {
+				return nil, fmt.Errorf("failed to parse multipolygon: %w", err)
+			}
+			for _, polygon := range polygons {
+				a.add(polygon)
+			}
+		}
+	}
+	return a, nil
+}
+
+// newArea returns an empty area, with bounds that any polygon added replaces
+func newArea() *Area {
+	return &Area{minLat: math.MaxFloat64, minLng: math.MaxFloat64, maxLat: -math.MaxFloat64, maxLng: -math.MaxFloat64}
+}
+
+// add adds a polygon, growing the bounds to its outer ring
+func (a *Area) add(polygon []ring) {
+	if len(polygon) == 0 || len(polygon[0]) < 3 {
+		return
+	}
+	a.polygons = append(a.polygons, polygon)
+	for _, v := range polygon[0] {
+		a.minLng = math.Min(a.minLng, v[0])
+		a.maxLng = math.Max(a.maxLng, v[0])
+		a.minLat = math.Min(a.minLat, v[1])
+		a.maxLat = math.Max(a.maxLat, v[1])
+	}
+}
+
+// Empty reports whether the area has no polygons
+func (a *Area) Empty() bool {
+	return len(a.polygons) == 0
+}
+
+// BoundaryPoints returns n points spaced evenly along the outer rings of the
+// area's polygons, e.g. to find where a lot meets a road. Empty if the area
+// is.
+func (a *Area) BoundaryPoints(n int) []MatrixPoint {
+	var perimeter float64
+	for _, polygon := range a.polygons {
+		perimeter += polygon[0].length()
+	}
+	if n <= 0 || perimeter == 0 {
+		return nil
+	}
+
+	step := perimeter / float64(n)
+	next := step / 2 // Distance along the boundary of the next point
+	var walked float64
+	points := make([]MatrixPoint, 0, n)
+	for _, polygon := range a.polygons {
+		outer := polygon[0]
+		for i := 1; i < len(outer) && len(points) < n; i++ {
+			from, to := outer[i-1], outer[i]
+			km := Haversine(from[1], from[0], to[1], to[0])
+			for next <= walked+km && len(points) < n {
+				t := (next - walked) / km
+				points = append(points, MatrixPoint{
+					Lat: from[1] + t*(to[1]-from[1]),
+					Lng: from[0] + t*(to[0]-from[0]),
+				})
+				next += step
+			}
+			walked += km
+		}
+	}
+	return points
+}
+
+// length returns a ring's perimeter in km
+func (r ring) length() float64 {
+	var km float64
+	for i := 1; i < len(r); i++ {
+		km += Haversine(r[i-1][1], r[i-1][0], r[i][1], r[i][0])
+	}
+	return km
+}
+
+// Bounds returns the area's bounding box; meaningless if it's Empty
+func (a *Area) Bounds() (swLat, swLng, neLat, neLng float64) {
+	return a.minLat, a.minLng, a.maxLat, a.maxLng
+}
+
+// Contains reports whether a point lies inside one of the area's polygons
+// and outside that polygon's holes
+func (a *Area) Contains(lat, lng float64) bool {
+	if a.Empty() || lat < a.minLat || lat > a.maxLat || lng < a.minLng || lng > a.maxLng {
+		return false
+	}
+	for _, polygon := range a.polygons {
+		if polygonContains(polygon, lat, lng) {
+			return true
+		}
+	}
+	return false
+}
+
+// Centroid returns the centroid of the areas' polygons taken together, with
+// holes taken out, treating degrees as planar. ok is false if they have no
+// area.
+func Centroid(areas ...*Area) (lat, lng float64, ok bool) {
+	var sum, sumLat, sumLng float64
+	for _, a := range areas {
+		for _, polygon := range a.polygons {
+			for i, r := range polygon {
+				area, rLat, rLng := r.centroid()
+				if i > 0 {
+					area = -area
+				}
+				sum += area
+				sumLat += area * rLat
+				sumLng += area * rLng
+			}
+		}
+	}
+	if sum <= 0 {
+		return 0, 0, false
+	}
+	return sumLat / sum, sumLng / sum, true
+}
+
+// centroid returns a ring's unsigned area and its centroid, by the shoelace
+// formula relative to its first vertex (which keeps small lots precise)
+func (r ring) centroid() (area, lat, lng float64) {
+	if len(r) < 3 {
+		return 0, 0, 0
+	}
+	originLng, originLat := r[0][0], r[0][1]
+	var twiceArea, cLng, cLat float64
+	for i, j := 0, len(r)-1; i < len(r); j, i = i, i+1 {
+		xj, yj := r[j][0]-originLng, r[j][1]-originLat
+		xi, yi := r[i][0]-originLng, r[i][1]-originLat
+		cross := xj*yi - xi*yj
+		twiceArea += cross
+		cLng += (xj + xi) * cross
+		cLat += (yj + yi) * cross
+	}
+	if twiceArea == 0 {
+		return 0, 0, 0
+	}
+	return math.Abs(twiceArea) / 2, originLat + cLat/(3*twiceArea), originLng + cLng/(3*twiceArea)
+}
+
+// polygonContains reports whether a point lies inside a polygon's outer ring
+// and outside its holes
+func polygonContains(polygon []ring, lat, lng float64) bool {
+	if !polygon[0].contains(lat, lng) {
+		return false
+	}
+	for _, hole := range polygon[1:] {
+		if hole.contains(lat, lng) {
+			return false
+		}
+	}
+	return true
+}
+
+// contains is the even-odd ray casting test: a point is inside if a ray from
+// it crosses the ring's edges an odd number of times. Treating degrees as
+// planar is fine at the scale of a drive time area.
+func (r ring) contains(lat, lng float64) bool {
+	inside := false
+	for i, j := 0, len(r)-1; i < len(r); j, i = i, i+1 {
+		xi, yi := r[i][0], r[i][1]
+		xj, yj := r[j][0], r[j][1]
+		if (yi > lat) != (yj > lat) && lng < (xj-xi)*(lat-yi)/(yj-yi)+xi {
+			inside = !inside
+		}
+	}
+	return inside
+}
@@ -0,0 +1,63 @@
+package geo
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// Biosecurity area kinds
+const (
+	BiosecurityLLSRegion = "lls_region" // Local Land Services region
+	BiosecurityWeedZone  = "weed_zone"  // Declared priority weed zone
+)
+
+// BiosecurityArea is a Local Land Services region or a declared weed zone
+// polygon read from GeoJSON
+type BiosecurityArea struct {
+	Name     string // Region name, or the weed's common name
+	Category string // Weed zone's priority or outcome (e.g. "Containment"); empty for regions
+	Geometry GeoJSONGeometry
+}
+
+// ReadLLSRegions reads the polygons of a Local Land Services regions
+// GeoJSON, named by their region_name (or region, lls_region or name)
+// attribute
+func ReadLLSRegions(r io.Reader) ([]BiosecurityArea, error) {
+	return readBiosecurityAreas(r, []string{"region_name", "lls_region", "region", "name"}, nil)
+}
+
+// ReadWeedZones reads the polygons of a declared weed zones GeoJSON (from
+// regional strategic weed management plans), one per weed and zone, with the
+// weed's name from a weed (or common_name, species or name) attribute and the
+// zone's priority from a priority (or category, outcome or zone) attribute,
+// e.g. "Prevention", "Eradication", "Containment" or "Asset protection"
+func ReadWeedZones(r io.Reader) ([]BiosecurityArea, error) {
+	return readBiosecurityAreas(r, []string{"weed", "common_name", "species", "name"},
+		[]string{"priority", "category", "outcome", "zone"})
+}
+
+// readBiosecurityAreas reads the named Polygon and MultiPolygon features of
+// a GeoJSON file, with attributes matched by prefix
+func readBiosecurityAreas(r io.Reader, nameAttrs, categoryAttrs []string) ([]BiosecurityArea, error) {
+	var fc GeoJSONFeatureCollection
+	if err := json.NewDecoder(r).Decode(&fc); err != nil {
+		return nil, fmt.Errorf("failed to parse GeoJSON: %w", err)
+	}
+
+	var areas []BiosecurityArea
+	for _, f := range fc.Features {
+		if f.Geometry.Type != "Polygon" && f.Geometry.Type != "MultiPolygon" {
+			continue
+		}
+		a := BiosecurityArea{Name: attribute(f.Properties, nameAttrs...), Geometry: f.Geometry}
+		if categoryAttrs != nil {
+			a.Category = attribute(f.Properties, categoryAttrs...)
+		}
+		if a.Name == "" {
+			continue
+		}
+		areas = append(areas, a)
+	}
+	return areas, nil
+}
@@ -0,0 +1,132 @@
+package geo
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+)
+
+// BuildingFootprint is a building outline reduced to where it is and how big
+type BuildingFootprint struct {
+	Lat, Lng float64 // Centroid of the outline
+	AreaSqm  float64 // Plan area of the outline, less any courtyards
+}
+
+// ReadBuildingFootprints reads the Polygon and MultiPolygon outlines of a
+// building footprint GeoJSON, either a FeatureCollection (e.g. a Geoscape or
+// OSM export) or one Feature per line (Microsoft's Global ML Building
+// Footprints). The file is streamed, as a state's worth of buildings is
+// millions of features. Only buildings with their centroid in within are
+// kept, unless it's nil.
+func ReadBuildingFootprints(r io.Reader, within *BBox) ([]BuildingFootprint, error) {
+	var footprints []BuildingFootprint
+	add := func(g GeoJSONGeometry) error {
+		var polygons [][]ring
+		switch g.Type {
+		case "Polygon":
+			var polygon []ring
+			if err := json.Unmarshal(g.Coordinates, &polygon); err != nil {
+				return fmt.Errorf("failed to parse polygon: %w", err)
+			}
+			polygons = append(polygons, polygon)
+		case "MultiPolygon":
+			if err := json.Unmarshal(g.Coordinates, &polygons); err != nil {
+				return fmt.Errorf("failed to parse multipolygon: %w", err)
+			}
+		default:
+			return nil
+		}
+
+		a := newArea()
+		for _, polygon := range polygons {
+			a.add(polygon)
+		}
+		lat, lng, ok := Centroid(a)
+		if !ok || (within != nil && (lat < within.MinLat || lat > within.MaxLat || lng < within.MinLng || lng > within.MaxLng)) {
+			return nil
+		}
+		footprints = append(footprints, BuildingFootprint{Lat: lat, Lng: lng, AreaSqm: a.areaSqm()})
+		return nil
+	}
+
+	// Top-level values are a FeatureCollection or, line by line, Features
+	dec := json.NewDecoder(r)
+	for dec.More() {
+		if err := expectDelim(dec, '{'); err != nil {
+			return nil, err
+		}
+		for dec.More() {
+			key, err := dec.Token()
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse GeoJSON: %w", err)
+			}
+			switch key {
+			case "features":
+				if err := expectDelim(dec, '['); err != nil {
+					return nil, err
+				}
+				for dec.More() {
+					var f GeoJSONFeature
+					if err := dec.Decode(&f); err != nil {
+						return nil, fmt.Errorf("failed to parse feature %d: %w", len(footprints), err)
+					}
+					if err := add(f.Geometry); err != nil {
+						return nil, err
+					}
+				}
+				if err := expectDelim(dec, ']'); err != nil {
+					return nil, err
+				}
+			case "geometry":
+				var g GeoJSONGeometry
+				if err := dec.Decode(&g); err != nil {
+					return nil, fmt.Errorf("failed to parse geometry: %w", err)
+				}
+				if err := add(g); err != nil {
+					return nil, err
+				}
+			default:
+				var skip json.RawMessage
+				if err := dec.Decode(&skip); err != nil {
+					return nil, fmt.Errorf("failed to parse GeoJSON: %w", err)
+				}
+			}
+		}
+		if err := expectDelim(dec, '}'); err != nil {
+			return nil, err
+		}
+	}
+	return footprints, nil
+}
+
+// expectDelim reads the next token, which must be delim
+func expectDelim(dec *json.Decoder, delim json.Delim) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return fmt.Errorf("failed to parse GeoJSON: %w", err)
+	}
+	if tok != delim {
+		return fmt.Errorf("failed to parse GeoJSON: expected %v, got %v", delim, tok)
+	}
+	return nil
+}
+
+// areaSqm returns the area's polygons' area in square metres, with holes
+// taken out, scaling degrees at the area's latitude (fine for a building or
+// a lot)
+func (a *Area) areaSqm() float64 {
+	var degrees float64
+	for _, polygon := range a.polygons {
+		for i, r := range polygon {
+			area, _, _ := r.centroid()
+			if i > 0 {
+				area = -area
+			}
+			degrees += area
+		}
+	}
+	midLat := (a.minLat + a.maxLat) / 2
+	metresPerDegree := kmPerDegreeLat * 1000
+	return degrees * metresPerDegree * metresPerDegree * math.Cos(midLat*math.Pi/180)
+}
@@ -7,6 +7,8 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"regexp"
+	"strings"
 	"time"
 )
 
@@ -19,6 +21,7 @@ const (
 type CadastralClient struct {
 	httpClient *http.Client
 	baseURL    string
+	addressURL string
 }
 
 // NewCadastralClient creates a new cadastral API client
@@ -26,6 +29,7 @@ func NewCadastralClient() *CadastralClient {
 	return &CadastralClient{
 		httpClient: &http.Client{Timeout: 30 * time.Second},
 		baseURL:    nswSpatialBaseURL,
+		addressURL: nswAddressPointURL,
 	}
 }
 
@@ -131,6 +135,23 @@ func (c *CadastralClient) FetchLotsAtPoint(ctx context.Context, lng, lat float64
 	return c.fetchLotsWithGeometry(ctx, fmt.Sprintf("%f,%f", lng, lat), "esriGeometryPoint", 10)
 }
 
+// FetchLot fetches a cadastral lot by its lot ID string (e.g. "12//DP1234567",
+// see ParseLotReference). Nil if there's no such lot.
+func (c *CadastralClient) FetchLot(ctx context.Context, lotIDString string) (*LotFeature, error) {
+	params := url.Values{}
+	params.Set("where", fmt.Sprintf("lotidstring = '%s'", strings.ReplaceAll(lotIDString, "'", "''")))
+	params.Set("outFields", "lotnumber,planlabel,lotidstring,shape_Area")
+	params.Set("outSR", "4326")
+	params.Set("f", "geojson")
+	params.Set("resultRecordCount", "1")
+
+	lots, err := c.fetchLots(ctx, params)
+	if err != nil || len(lots) == 0 {
+		return nil, err
+	}
+	return &lots[0], nil
+}
+
 // fetchLotsWithGeometry performs the actual API query with the given geometry
 func (c *CadastralClient) fetchLotsWithGeometry(ctx context.Context, geometry, geometryType string, maxResults int) ([]LotFeature, error) {
 	params := url.Values{}
@@ -143,7 +164,11 @@ func (c *CadastralClient) fetchLotsWithGeometry(ctx context.Context, geometry, g
 	params.Set("spatialRel", "esriSpatialRelIntersects")
 	params.Set("f", "geojson")
 	params.Set("resultRecordCount", fmt.Sprintf("%d", maxResults))
+	return c.fetchLots(ctx, params)
+}
 
+// fetchLots runs a lot query with the given parameters
+func (c *CadastralClient) fetchLots(ctx context.Context, params url.Values) ([]LotFeature, error) {
 	reqURL := fmt.Sprintf("%s?%s", c.baseURL, params.Encode())
 
 	req, err := http.NewRequestWithContext(ctx, "GET", reqURL, nil)
@@ -198,6 +223,26 @@ func (c *CadastralClient) fetchLotsWithGeometry(ctx context.Context, geometry, g
 	return lots, nil
 }
 
+// lotReferencePattern matches a Lot/DP reference as written on contracts and
+// title searches: "Lot 12 DP1234567", "Lot 7 Sec 3 DP758000", "Lot 12 in DP
+// 1234567", or the lot ID string forms "12//DP1234567" and "7/3/DP758000".
+// Groups are the lot, the section (if any) and the plan.
+var lotReferencePattern = regexp.MustCompile(`^(?:LOT\s*)?([0-9]+[A-Z]?|[A-Z]{1,2})(?:\s*/\s*|\s*,?\s+)` +
+	`(?:(?:SEC(?:TION)?\.?\s*)?([0-9]+[A-Z]?)?(?:\s*/\s*|\s*,?\s+))?` +
+	`(?:(?:IN|ON|OF)\s+)?((?:DP|SP|CP|PP)\s*[0-9]+)$`)
+
+// ParseLotReference parses a Lot/DP reference (see lotReferencePattern) into
+// the lot ID string NSW Spatial Services and cadastral_lots key lots by, e.g.
+// "12//DP1234567" or "7/3/DP758000". Reports false if it isn't one.
+func ParseLotReference(ref string) (string, bool) {
+	m := lotReferencePattern.FindStringSubmatch(strings.ToUpper(strings.TrimSpace(ref)))
+	if m == nil {
+		return "", false
+	}
+	plan := strings.Join(strings.Fields(m[3]), "")
+	return m[1] + "/" + m[2] + "/" + plan, true
+}
+
 // CalculateLotCentroid calculates the centroid of a lot polygon or multipolygon geometry
 func CalculateLotCentroid(geom *LotGeometry) (lat, lng float64, err error) {
 	if geom == nil {
@@ -2,6 +2,7 @@ package geo
 
 import (
 	"math"
+	"sync"
 )
 
 const (
@@ -264,20 +265,28 @@ var NSWTowns = []Location{
 	{Name: "Mogo", Latitude: -35.7833, Longitude: 150.1333},
 }
 
+var (
+	townIndexOnce sync.Once
+	townIndex     *PointIndex
+)
+
+// NSWTownIndex returns the index over NSWTowns, building it on first use
+func NSWTownIndex() *PointIndex {
+	townIndexOnce.Do(func() {
+		townIndex = NewPointIndex(len(NSWTowns), func(i int) (float64, float64) {
+			return NSWTowns[i].Latitude, NSWTowns[i].Longitude
+		})
+	})
+	return townIndex
+}
+
 // FindNearestTown finds the nearest town to a given location
 func FindNearestTown(lat, lng float64) (Location, float64) {
-	var nearest Location
-	minDist := math.MaxFloat64
-
-	for _, town := range NSWTowns {
-		dist := Haversine(lat, lng, town.Latitude, town.Longitude)
-		if dist < minDist {
-			minDist = dist
-			nearest = town
-		}
+	nearest := NSWTownIndex().Nearest(lat, lng, 1)
+	if len(nearest) == 0 {
+		return Location{}, math.MaxFloat64
 	}
-
-	return nearest, minDist
+	return NSWTowns[nearest[0].Index], nearest[0].DistanceKm
 }
 
 // NearestTownResult contains info about a nearby town
@@ -286,24 +295,28 @@ type NearestTownResult struct {
 	DistanceKm float64
 }
 
+// FindNearestTowns finds the k nearest towns to a given location, closest first
+func FindNearestTowns(lat, lng float64, k int) []NearestTownResult {
+	nearest := NSWTownIndex().Nearest(lat, lng, k)
+	results := make([]NearestTownResult, len(nearest))
+	for i, n := range nearest {
+		results[i] = NearestTownResult{Name: NSWTowns[n.Index].Name, DistanceKm: n.DistanceKm}
+	}
+	return results
+}
+
 // FindTwoNearestTowns finds the two nearest towns to a given location
 func FindTwoNearestTowns(lat, lng float64) (NearestTownResult, NearestTownResult) {
-	var first, second NearestTownResult
-	first.DistanceKm = math.MaxFloat64
-	second.DistanceKm = math.MaxFloat64
+	first := NearestTownResult{DistanceKm: math.MaxFloat64}
+	second := NearestTownResult{DistanceKm: math.MaxFloat64}
 
-	for _, town := range NSWTowns {
-		dist := Haversine(lat, lng, town.Latitude, town.Longitude)
-		if dist < first.DistanceKm {
-			// Current first becomes second
-			second = first
-			// New first
-			first = NearestTownResult{Name: town.Name, DistanceKm: dist}
-		} else if dist < second.DistanceKm {
-			second = NearestTownResult{Name: town.Name, DistanceKm: dist}
-		}
+	nearest := FindNearestTowns(lat, lng, 2)
+	if len(nearest) > 0 {
+		first = nearest[0]
+	}
+	if len(nearest) > 1 {
+		second = nearest[1]
 	}
-
 	return first, second
 }
 
@@ -0,0 +1,157 @@
+package geo
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Energy development kinds
+const (
+	EnergyWind  = "wind"
+	EnergySolar = "solar"
+)
+
+// Energy development statuses, from built to only proposed
+const (
+	EnergyOperating    = "operating"
+	EnergyConstruction = "construction"
+	EnergyApproved     = "approved"
+	EnergyProposed     = "proposed"
+)
+
+// EnergyDevelopment is a wind or solar farm read from a developments CSV
+type EnergyDevelopment struct {
+	Name       string
+	Kind       string // EnergyWind or EnergySolar
+	Status     string // EnergyOperating, EnergyConstruction, EnergyApproved or EnergyProposed
+	CapacityMW *float64
+	Latitude   float64
+	Longitude  float64
+}
+
+// ParseEnergyDevelopmentsCSV reads wind and solar farms from a CSV with a
+// header row naming at least name, type (or technology/fuel), latitude (or
+// lat) and longitude (or lng/lon) columns, and optionally status and
+// capacity_mw (or capacity). Types are matched by whether they mention wind
+// or solar, so "Wind Farm" and "Solar PV" both work; hybrids count as wind.
+// Statuses are normalised (see energyStatus); withdrawn, refused and
+// decommissioned projects are skipped, as are rows without usable coordinates
+// inside Australia.
+func ParseEnergyDevelopmentsCSV(r io.Reader) ([]EnergyDevelopment, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read header: %w", err)
+	}
+
+	nameIdx, kindIdx, statusIdx, capacityIdx, latIdx, lngIdx := -1, -1, -1, -1, -1, -1
+	for i, col := range header {
+		switch strings.ToLower(strings.TrimSpace(col)) {
+		case "name", "project", "project_name":
+			if nameIdx == -1 {
+				nameIdx = i
+			}
+		case "type", "technology", "fuel", "fuel_type":
+			if kindIdx == -1 {
+				kindIdx = i
+			}
+		case "status", "stage":
+			if statusIdx == -1 {
+				statusIdx = i
+			}
+		case "capacity_mw", "capacity", "mw":
+			if capacityIdx == -1 {
+				capacityIdx = i
+			}
+		case "latitude", "lat":
+			latIdx = i
+		case "longitude", "lng", "lon":
+			lngIdx = i
+		}
+	}
+	if nameIdx == -1 || kindIdx == -1 || latIdx == -1 || lngIdx == -1 {
+		return nil, fmt.Errorf("required columns not found in CSV (need name, type, latitude, longitude)")
+	}
+
+	field := func(record []string, idx int) string {
+		if idx < 0 || idx >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[idx])
+	}
+
+	var developments []EnergyDevelopment
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read row: %w", err)
+		}
+
+		lat, err := strconv.ParseFloat(field(record, latIdx), 64)
+		if err != nil {
+			continue
+		}
+		lng, err := strconv.ParseFloat(field(record, lngIdx), 64)
+		if err != nil {
+			continue
+		}
+		// Roughly Australia, to skip swapped or missing coordinates
+		if lat < -44 || lat > -9 || lng < 112 || lng > 154 {
+			continue
+		}
+
+		kind := energyKind(field(record, kindIdx))
+		status, ok := energyStatus(field(record, statusIdx))
+		name := field(record, nameIdx)
+		if kind == "" || !ok || name == "" {
+			continue
+		}
+
+		development := EnergyDevelopment{Name: name, Kind: kind, Status: status, Latitude: lat, Longitude: lng}
+		if mw, err := strconv.ParseFloat(field(record, capacityIdx), 64); err == nil && mw > 0 {
+			development.CapacityMW = &mw
+		}
+		developments = append(developments, development)
+	}
+	return developments, nil
+}
+
+// energyKind returns EnergyWind or EnergySolar for a type, or "" for others
+// (batteries, hydro, gas)
+func energyKind(s string) string {
+	s = strings.ToLower(s)
+	switch {
+	case strings.Contains(s, "wind"):
+		return EnergyWind
+	case strings.Contains(s, "solar"), strings.Contains(s, "pv"):
+		return EnergySolar
+	}
+	return ""
+}
+
+// energyStatus normalises a development status. Anything not yet approved
+// (planning, under assessment, on exhibition, blank) is proposed. ok is false
+// for projects that won't be built or are gone.
+func energyStatus(s string) (status string, ok bool) {
+	s = strings.ToLower(s)
+	switch {
+	case strings.Contains(s, "withdrawn"), strings.Contains(s, "refused"), strings.Contains(s, "rejected"),
+		strings.Contains(s, "cancelled"), strings.Contains(s, "decommission"), strings.Contains(s, "lapsed"):
+		return "", false
+	case strings.Contains(s, "operat"), strings.Contains(s, "commission"), strings.Contains(s, "existing"):
+		return EnergyOperating, true
+	case strings.Contains(s, "construct"):
+		return EnergyConstruction, true
+	case strings.Contains(s, "approv"), strings.Contains(s, "determined"):
+		return EnergyApproved, true
+	}
+	return EnergyProposed, true
+}
@@ -0,0 +1,223 @@
+package geo
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+)
+
+// ExclusionFeature is a feature of an exclusion layer (a highway, mine or
+// wind farm) read from GeoJSON
+type ExclusionFeature struct {
+	Name     string
+	Geometry GeoJSONGeometry
+}
+
+// ReadExclusionFeatures reads the point, line and polygon features of a
+// GeoJSON file, named by their "name" (or "name_*") attribute if they have one
+func ReadExclusionFeatures(r io.Reader) ([]ExclusionFeature, error) {
+	var fc GeoJSONFeatureCollection
+	if err := json.NewDecoder(r).Decode(&fc); err != nil {
+		return nil, fmt.Errorf("failed to parse GeoJSON: %w", err)
+	}
+
+	var features []ExclusionFeature
+	for _, f := range fc.Features {
+		switch f.Geometry.Type {
+		case "Point", "MultiPoint", "LineString", "MultiLineString", "Polygon", "MultiPolygon":
+			features = append(features, ExclusionFeature{Name: attribute(f.Properties, "name"), Geometry: f.Geometry})
+		}
+	}
+	return features, nil
+}
+
+// maxChunkSegments is how many segments of a line each bounding box covers,
+// so a long highway isn't scanned end to end for every point
+const maxChunkSegments = 32
+
+// Features are the geometries of an exclusion layer, for testing whether
+// points are within a distance of any of them
+type Features struct {
+	shapes []shape
+}
+
+// shape is a part of a feature with its bounding box: points, a stretch of
+// line (or polygon edge), or a polygon's interior
+type shape struct {
+	points         [][2]float64 // [lng, lat]
+	path           ring         // Consecutive vertices, not closed
+	area           *Area
+	minLat, minLng float64
+	maxLat, maxLng float64
+}
+
+// NewFeatures reads the Point, LineString and Polygon (and Multi*) features
+// of a feature collection. Other geometry types are ignored.
+func NewFeatures(fc *GeoJSONFeatureCollection) (*Features, error) {
+	f := &Features{}
+	for _, feature := range fc.Features {
+		g := feature.Geometry
+		var err error
+		switch g.Type {
+		case "Point":
+			var p [2]float64
+			if err = json.Unmarshal(g.Coordinates, &p); err == nil {
+				f.addPoints([][2]float64{p})
+			}
+		case "MultiPoint":
+			var points [][2]float64
+			if err = json.Unmarshal(g.Coordinates, &points); err == nil {
+				f.addPoints(points)
+			}
+		case "LineString":
+			var line ring
+			if err = json.Unmarshal(g.Coordinates, &line); err == nil {
+				f.addPath(line)
+			}
+		case "MultiLineString":
+			var lines []ring
+			if err = json.Unmarshal(g.Coordinates, &lines); err == nil {
+				for _, line := range lines {
+					f.addPath(line)
+				}
+			}
+		case "Polygon":
+			var polygon []ring
+			if err = json.Unmarshal(g.Coordinates, &polygon); err == nil {
+				f.addPolygon(polygon)
+			}
+		case "MultiPolygon":
+			var polygons [][]ring
+			if err = json.Unmarshal(g.Coordinates, &polygons); err == nil {
+				for _, polygon := range polygons {
+					f.addPolygon(polygon)
+				}
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", g.Type, err)
+		}
+	}
+	return f, nil
+}
+
+// Empty reports whether there are no features
+func (f *Features) Empty() bool {
+	return len(f.shapes) == 0
+}
+
+func (f *Features) addPoints(points [][2]float64) {
+	if len(points) > 0 {
+		f.shapes = append(f.shapes, newShape(shape{points: points}, points))
+	}
+}
+
+// addPath adds a line in chunks of up to maxChunkSegments segments
+func (f *Features) addPath(line ring) {
+	if len(line) == 1 {
+		f.addPoints([][2]float64{line[0]})
+		return
+	}
+	for start := 0; start < len(line)-1; start += maxChunkSegments {
+		chunk := line[start:min(start+maxChunkSegments+1, len(line))]
+		f.shapes = append(f.shapes, newShape(shape{path: chunk}, chunk))
+	}
+}
+
+// addPolygon adds a polygon's interior, and its rings as paths so points
+// outside are measured to its edge
+func (f *Features) addPolygon(polygon []ring) {
+	area := newArea()
+	area.add(polygon)
+	if area.Empty() {
+		return
+	}
+	f.shapes = append(f.shapes, newShape(shape{area: area}, polygon[0]))
+	for _, r := range polygon {
+		f.addPath(r)
+	}
+}
+
+// newShape sets s's bounding box to that of vertices
+func newShape(s shape, vertices [][2]float64) shape {
+	s.minLat, s.minLng = math.MaxFloat64, math.MaxFloat64
+	s.maxLat, s.maxLng = -math.MaxFloat64, -math.MaxFloat64
+	for _, v := range vertices {
+		s.minLng, s.maxLng = math.Min(s.minLng, v[0]), math.Max(s.maxLng, v[0])
+		s.minLat, s.maxLat = math.Min(s.minLat, v[1]), math.Max(s.maxLat, v[1])
+	}
+	return s
+}
+
+// Near reports whether a point is within km of any feature. A point inside
+// a polygon is 0 km from it.
+func (f *Features) Near(lat, lng, km float64) bool {
+	kmPerDegreeLng := kmPerDegreeLat * math.Cos(lat*math.Pi/180)
+	dLat, dLng := km/kmPerDegreeLat, km/kmPerDegreeLng
+
+	for i := range f.shapes {
+		s := &f.shapes[i]
+		if lat < s.minLat-dLat || lat > s.maxLat+dLat || lng < s.minLng-dLng || lng > s.maxLng+dLng {
+			continue
+		}
+		if s.distance(lat, lng, kmPerDegreeLng) <= km {
+			return true
+		}
+	}
+	return false
+}
+
+// Distance returns the distance in km from a point to the nearest feature,
+// or false if there are none. Like Near it measures on a flat projection
+// around the point, so distances over about 100 km are approximate.
+func (f *Features) Distance(lat, lng float64) (float64, bool) {
+	kmPerDegreeLng := kmPerDegreeLat * math.Cos(lat*math.Pi/180)
+
+	nearest := math.MaxFloat64
+	for i := range f.shapes {
+		s := &f.shapes[i]
+		// Skip shapes whose bounding box is further than the nearest so far
+		dx := math.Max(0, math.Max(s.minLng-lng, lng-s.maxLng)) * kmPerDegreeLng
+		dy := math.Max(0, math.Max(s.minLat-lat, lat-s.maxLat)) * kmPerDegreeLat
+		if math.Hypot(dx, dy) >= nearest {
+			continue
+		}
+		nearest = math.Min(nearest, s.distance(lat, lng, kmPerDegreeLng))
+	}
+	return nearest, nearest < math.MaxFloat64
+}
+
+// distance returns the distance in km from a point to the shape
+func (s *shape) distance(lat, lng, kmPerDegreeLng float64) float64 {
+	if s.area != nil {
+		if s.area.Contains(lat, lng) {
+			return 0
+		}
+		// Outside, its edges are measured as paths
+		return math.MaxFloat64
+	}
+	nearest := math.MaxFloat64
+	for _, p := range s.points {
+		nearest = math.Min(nearest, Haversine(lat, lng, p[1], p[0]))
+	}
+	for j := 1; j < len(s.path); j++ {
+		// Project onto a plane around the point, which is accurate enough
+		// over the few km an exclusion radius spans
+		ax, ay := (s.path[j-1][0]-lng)*kmPerDegreeLng, (s.path[j-1][1]-lat)*kmPerDegreeLat
+		bx, by := (s.path[j][0]-lng)*kmPerDegreeLng, (s.path[j][1]-lat)*kmPerDegreeLat
+		nearest = math.Min(nearest, segmentDistance(ax, ay, bx, by))
+	}
+	return nearest
+}
+
+// segmentDistance returns the distance from the origin to the segment from
+// (ax, ay) to (bx, by)
+func segmentDistance(ax, ay, bx, by float64) float64 {
+	dx, dy := bx-ax, by-ay
+	t := 0.0
+	if lengthSq := dx*dx + dy*dy; lengthSq > 0 {
+		t = math.Max(0, math.Min(1, -(ax*dx+ay*dy)/lengthSq))
+	}
+	return math.Hypot(ax+t*dx, ay+t*dy)
+}
@@ -0,0 +1,120 @@
+package geo
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Fire types, normalised from the fire history's codes and labels
+const (
+	FireWildfire       = "Wildfire"
+	FirePrescribedBurn = "Prescribed burn"
+)
+
+// FireExtent is the mapped extent of one past fire, from the NPWS fire
+// history
+type FireExtent struct {
+	Year     int    // Year the fire started
+	FireType string // FireWildfire, FirePrescribedBurn, or as given
+	Name     string // Fire name, if any
+	Geometry GeoJSONGeometry
+}
+
+var yearPattern = regexp.MustCompile(`\b(19|20)\d{2}\b`)
+
+// ReadFireHistory reads the Polygon and MultiPolygon features of a fire
+// history GeoJSON (such as the NPWS Fire History - Wildfires and Prescribed
+// Burns export). The year comes from a StartDate attribute (a date string or
+// epoch milliseconds), else from the season in the Label (e.g. "2019-20
+// Wildfire" is 2019). Features without a year are skipped.
+func ReadFireHistory(r io.Reader) ([]FireExtent, error) {
+	var fc GeoJSONFeatureCollection
+	if err := json.NewDecoder(r).Decode(&fc); err != nil {
+		return nil, fmt.Errorf("failed to parse GeoJSON: %w", err)
+	}
+
+	var fires []FireExtent
+	for _, f := range fc.Features {
+		if f.Geometry.Type != "Polygon" && f.Geometry.Type != "MultiPolygon" {
+			continue
+		}
+		label := attribute(f.Properties, "label", "season")
+		year := fireYear(attribute(f.Properties, "startdate", "start_date", "firedate", "fire_date"))
+		if year == 0 {
+			year = fireYear(label)
+		}
+		if year == 0 {
+			continue
+		}
+		fires = append(fires, FireExtent{
+			Year:     year,
+			FireType: fireType(attribute(f.Properties, "firetype", "fire_type", "type"), label),
+			Name:     attribute(f.Properties, "firename", "fire_name", "name"),
+			Geometry: f.Geometry,
+		})
+	}
+	return fires, nil
+}
+
+// fireYear parses the year from a date or season string, or epoch
+// milliseconds. Returns 0 if there's none.
+func fireYear(s string) int {
+	if ms, err := strconv.ParseInt(s, 10, 64); err == nil && len(s) >= 10 {
+		return time.UnixMilli(ms).UTC().Year()
+	}
+	year, _ := strconv.Atoi(yearPattern.FindString(s))
+	return year
+}
+
+// fireType normalises a fire type code or name (the NPWS data codes
+// wildfires 1 and prescribed burns 2), falling back to the label
+func fireType(code, label string) string {
+	for _, s := range []string{code, label} {
+		lower := strings.ToLower(s)
+		switch {
+		case lower == "1" || strings.Contains(lower, "wildfire") || strings.Contains(lower, "bushfire"):
+			return FireWildfire
+		case lower == "2" || strings.Contains(lower, "prescribed") || strings.Contains(lower, "hazard reduction"):
+			return FirePrescribedBurn
+		}
+	}
+	return code
+}
+
+// FireHistory is a set of fire extents, for finding the fires that burnt
+// part of a lot
+type FireHistory struct {
+	fires [][]boundedPolygon // Each fire's polygons
+}
+
+// NewFireHistory reads each feature of a feature collection as one fire's
+// extent
+func NewFireHistory(fc *GeoJSONFeatureCollection) (*FireHistory, error) {
+	h := &FireHistory{fires: make([][]boundedPolygon, len(fc.Features))}
+	for i, f := range fc.Features {
+		area, err := NewArea(&GeoJSONFeatureCollection{Features: []GeoJSONFeature{f}})
+		if err != nil {
+			return nil, fmt.Errorf("fire %d: %w", i, err)
+		}
+		h.fires[i] = boundPolygons(area.polygons)
+	}
+	return h, nil
+}
+
+// Burnt estimates the fraction (0-1) of a lot each fire burnt, sampled like
+// Overlay.CoveredFraction, keyed by the fire's feature index. Fires that
+// burnt none of it are left out.
+func (h *FireHistory) Burnt(lot *Area) map[int]float64 {
+	burnt := make(map[int]float64)
+	for i, polygons := range h.fires {
+		if fraction := coveredFraction(polygons, lot); fraction > 0 {
+			burnt[i] = fraction
+		}
+	}
+	return burnt
+}
@@ -0,0 +1,43 @@
+package geo
+
+import "math"
+
+// NSWBounds is a bounding box around New South Wales
+var NSWBounds = struct {
+	SWLat, SWLng, NELat, NELng float64
+}{-37.6, 140.9, -28.1, 153.7}
+
+// kmPerDegreeLat is the length of a degree of latitude
+const kmPerDegreeLat = 111.32
+
+// Grid is a regular grid of cell centres over a bounding box. Cells are a
+// fixed size in degrees, so they tile the map without gaps.
+type Grid struct {
+	LatStep float64
+	LngStep float64
+	Points  []MatrixPoint
+}
+
+// NewGrid lays a grid of cells spacingKm across over a bounding box, the
+// longitude step measured at the box's middle latitude
+func NewGrid(swLat, swLng, neLat, neLng, spacingKm float64) Grid {
+	midLat := (swLat + neLat) / 2
+	g := Grid{
+		LatStep: spacingKm / kmPerDegreeLat,
+		LngStep: spacingKm / (kmPerDegreeLat * math.Cos(midLat*math.Pi/180)),
+	}
+	for lat := swLat + g.LatStep/2; lat < neLat; lat += g.LatStep {
+		for lng := swLng + g.LngStep/2; lng < neLng; lng += g.LngStep {
+			g.Points = append(g.Points, MatrixPoint{Lat: lat, Lng: lng})
+		}
+	}
+	return g
+}
+
+// BoundsAround returns the bounding box reaching km from a point in each
+// direction
+func BoundsAround(lat, lng, km float64) (swLat, swLng, neLat, neLng float64) {
+	dLat := km / kmPerDegreeLat
+	dLng := km / (kmPerDegreeLat * math.Cos(lat*math.Pi/180))
+	return lat - dLat, lng - dLng, lat + dLat, lng + dLng
+}
@@ -0,0 +1,222 @@
+package geo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// NSW State Heritage Register item curtilages (ArcGIS REST)
+	nswStateHeritageURL = "https://mapprod3.environment.nsw.gov.au/arcgis/rest/services/Planning/SHR/MapServer/0/query"
+	// Heritage items and conservation areas mapped by local environmental plans
+	nswLocalHeritageURL = "https://mapprod3.environment.nsw.gov.au/arcgis/rest/services/Planning/EPI_Primary_Planning_Layers/MapServer/0/query"
+)
+
+// Heritage listings, most restrictive first
+const (
+	HeritageState = "state" // State Heritage Register
+	HeritageLocal = "local" // Local environmental plan schedule 5
+	HeritageNone  = "none"  // Checked, no listing
+)
+
+// HeritageItem is a heritage listing covering part of a lot
+type HeritageItem struct {
+	Listing string // HeritageState or HeritageLocal
+	ItemID  string // SHR number or LEP item number
+	Name    string
+	Class   string // e.g. "Item - General" or "Conservation Area - General"; may be empty
+}
+
+// HeritageClient checks lots against the NSW State Heritage Register and
+// local heritage item layers
+type HeritageClient struct {
+	httpClient *http.Client
+	stateURL   string
+	localURL   string
+}
+
+// NewHeritageClient creates a new heritage API client
+func NewHeritageClient() *HeritageClient {
+	return &HeritageClient{
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+		stateURL:   nswStateHeritageURL,
+		localURL:   nswLocalHeritageURL,
+	}
+}
+
+// FetchItemsOnLot returns the state and local heritage items whose areas
+// overlap a lot polygon. Items only touching its boundary (the neighbour's
+// listing) don't count.
+func (c *HeritageClient) FetchItemsOnLot(ctx context.Context, geom *LotGeometry) ([]HeritageItem, error) {
+	rings, err := lotRings(geom)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []HeritageItem
+	for _, layer := range []struct{ listing, url string }{
+		{HeritageState, c.stateURL},
+		{HeritageLocal, c.localURL},
+	} {
+		found, err := c.query(ctx, layer.url, layer.listing, rings)
+		if err != nil {
+			return nil, fmt.Errorf("%s heritage: %w", layer.listing, err)
+		}
+		items = append(items, found...)
+	}
+	return items, nil
+}
+
+// query returns the items on one layer overlapping a polygon
+func (c *HeritageClient) query(ctx context.Context, baseURL, listing string, rings [][][]float64) ([]HeritageItem, error) {
+	features, err := queryIntersecting(ctx, c.httpClient, baseURL, rings)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]HeritageItem, 0, len(features))
+	for _, attrs := range features {
+		item := HeritageItem{
+			Listing: listing,
+			ItemID:  featureAttr(attrs, "H_ID", "SHR_NUMBER", "ITEM_NO", "HERITAGE_ID", "OBJECTID"),
+			Name:    featureAttr(attrs, "H_NAME", "ITEM_NAME", "NAME", "LABEL"),
+			Class:   featureAttr(attrs, "LAY_CLASS", "CLASS"),
+		}
+		if item.ItemID == "" && item.Name == "" {
+			continue
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// queryIntersecting runs an interior-intersects query of a polygon against an
+// ArcGIS layer, returning the attributes of the features found. POSTed, as a
+// large lot's rings don't fit in a URL.
+func queryIntersecting(ctx context.Context, httpClient *http.Client, baseURL string, rings [][][]float64) ([]map[string]interface{}, error) {
+	geometry, err := json.Marshal(map[string]interface{}{
+		"rings":            rings,
+		"spatialReference": map[string]int{"wkid": 4326},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("encoding geometry: %w", err)
+	}
+
+	params := url.Values{}
+	params.Set("where", "1=1")
+	params.Set("outFields", "*")
+	params.Set("geometry", string(geometry))
+	params.Set("geometryType", "esriGeometryPolygon")
+	params.Set("inSR", "4326")
+	// DE-9IM: the interiors intersect, so shared boundaries don't match
+	params.Set("spatialRel", "esriSpatialRelRelation")
+	params.Set("relationParam", "T********")
+	params.Set("returnGeometry", "false")
+	params.Set("f", "json")
+
+	req, err := http.NewRequestWithContext(ctx, "POST", baseURL, strings.NewReader(params.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetching features: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("API returned %d: %s", resp.StatusCode, string(body))
+	}
+
+	var result struct {
+		Features []struct {
+			Attributes map[string]interface{} `json:"attributes"`
+		} `json:"features"`
+		// ArcGIS reports errors with a 200
+		Error *struct {
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("decoding response: %w", err)
+	}
+	if result.Error != nil {
+		return nil, fmt.Errorf("API error %d: %s", result.Error.Code, result.Error.Message)
+	}
+
+	features := make([]map[string]interface{}, len(result.Features))
+	for i, f := range result.Features {
+		features[i] = f.Attributes
+	}
+	return features, nil
+}
+
+// featureAttr returns the first of keys present in a feature's attributes
+// (compared case-insensitively, as the layers differ), as a string
+func featureAttr(attrs map[string]interface{}, keys ...string) string {
+	for _, key := range keys {
+		for k, v := range attrs {
+			if !strings.EqualFold(k, key) || v == nil {
+				continue
+			}
+			var s string
+			switch v := v.(type) {
+			case string:
+				s = strings.TrimSpace(v)
+			case float64:
+				s = fmt.Sprintf("%.0f", v)
+			default:
+				s = fmt.Sprint(v)
+			}
+			if s != "" {
+				return s
+			}
+		}
+	}
+	return ""
+}
+
+// lotRings returns a lot polygon or multipolygon's rings, as ArcGIS polygons
+// take them
+func lotRings(geom *LotGeometry) ([][][]float64, error) {
+	if geom == nil {
+		return nil, fmt.Errorf("nil geometry")
+	}
+
+	switch geom.Type {
+	case "Polygon":
+		var rings [][][]float64
+		if err := json.Unmarshal(geom.Coordinates, &rings); err != nil {
+			return nil, fmt.Errorf("parsing polygon coordinates: %w", err)
+		}
+		if len(rings) == 0 {
+			return nil, fmt.Errorf("empty polygon")
+		}
+		return rings, nil
+
+	case "MultiPolygon":
+		var polygons [][][][]float64
+		if err := json.Unmarshal(geom.Coordinates, &polygons); err != nil {
+			return nil, fmt.Errorf("parsing multipolygon coordinates: %w", err)
+		}
+		var rings [][][]float64
+		for _, polygon := range polygons {
+			rings = append(rings, polygon...)
+		}
+		if len(rings) == 0 {
+			return nil, fmt.Errorf("empty multipolygon")
+		}
+		return rings, nil
+	}
+	return nil, fmt.Errorf("unsupported geometry type: %s", geom.Type)
+}
@@ -0,0 +1,58 @@
+package geo
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/url"
+)
+
+// ImageryLinks are deep links to street-level and aerial imagery of a
+// property
+type ImageryLinks struct {
+	StreetView  string // Google Street View from the nearest road, facing the property
+	Aerial      string // NSW SIX Maps aerial imagery
+	GoogleEarth string // Google Earth 3D view
+}
+
+// NewImageryLinks builds the imagery links for a property. The Street View
+// panorama is taken from roadLat, roadLng looking at the property; pass the
+// property's own point when there's no road point, and Google picks the
+// nearest panorama without a heading.
+func NewImageryLinks(lat, lng, roadLat, roadLng float64) ImageryLinks {
+	streetView := "https://www.google.com/maps/@?api=1&map_action=pano&viewpoint=" + latLng(roadLat, roadLng)
+	if roadLat != lat || roadLng != lng {
+		streetView += fmt.Sprintf("&heading=%.0f", Bearing(roadLat, roadLng, lat, lng))
+	}
+	return ImageryLinks{
+		StreetView:  streetView,
+		Aerial:      "https://maps.six.nsw.gov.au/?search=" + url.QueryEscape(latLng(lat, lng)),
+		GoogleEarth: fmt.Sprintf("https://earth.google.com/web/@%.6f,%.6f,0a,1500d,35y,0h,0t,0r", lat, lng),
+	}
+}
+
+// latLng formats a point as "lat,lng" to six decimal places (about 0.1 m)
+func latLng(lat, lng float64) string {
+	return fmt.Sprintf("%.6f,%.6f", lat, lng)
+}
+
+// Bearing returns the initial compass bearing in degrees (0-360, clockwise
+// from north) from one point to another
+func Bearing(lat1, lng1, lat2, lng2 float64) float64 {
+	φ1, φ2 := lat1*math.Pi/180, lat2*math.Pi/180
+	Δλ := (lng2 - lng1) * math.Pi / 180
+	y := math.Sin(Δλ) * math.Cos(φ2)
+	x := math.Cos(φ1)*math.Sin(φ2) - math.Sin(φ1)*math.Cos(φ2)*math.Cos(Δλ)
+	return math.Mod(math.Atan2(y, x)*180/math.Pi+360, 360)
+}
+
+// NearestRoad returns the point on the nearest drivable road to a location,
+// as Valhalla snaps it for routing. Returns ErrNoRoute if there's no road
+// near enough.
+func (r *Router) NearestRoad(ctx context.Context, lat, lng float64) (roadLat, roadLng float64, err error) {
+	snap, err := r.LocateRoad(ctx, lat, lng, 0)
+	if err != nil {
+		return 0, 0, err
+	}
+	return snap.Lat, snap.Lng, nil
+}
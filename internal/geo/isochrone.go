@@ -69,13 +69,13 @@ func (g *IsochroneGenerator) GenerateIsochrone(ctx context.Context, lat, lng flo
 
 	resp, err := g.client.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("isochrone request failed: %w", err)
+		return nil, valhallaRequestError("isochrone", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("isochrone API error %d: %s", resp.StatusCode, string(body))
+		return nil, valhallaResponseError("isochrone", resp.StatusCode, body)
 	}
 
 	body, err := io.ReadAll(resp.Body)
@@ -99,17 +99,8 @@ func (g *IsochroneGenerator) GenerateIsochrone(ctx context.Context, lat, lng flo
 	return &result, nil
 }
 
-// Sutherland, NSW coordinates (origin point for drive time calculations)
-var Sutherland = struct {
-	Lat float64
-	Lng float64
-}{
-	Lat: -34.0309,
-	Lng: 151.0579,
-}
-
-// GenerateSutherlandIsochrones generates isochrones for Sutherland at specified intervals
-func (g *IsochroneGenerator) GenerateSutherlandIsochrones(ctx context.Context, intervals []int) (map[int]*GeoJSONFeatureCollection, error) {
+// GenerateAnchorIsochrones generates isochrones for an anchor at specified intervals
+func (g *IsochroneGenerator) GenerateAnchorIsochrones(ctx context.Context, anchor Anchor, intervals []int) (map[int]*GeoJSONFeatureCollection, error) {
 	results := make(map[int]*GeoJSONFeatureCollection)
 
 	for _, mins := range intervals {
@@ -119,7 +110,7 @@ func (g *IsochroneGenerator) GenerateSutherlandIsochrones(ctx context.Context, i
 		default:
 		}
 
-		iso, err := g.GenerateIsochrone(ctx, Sutherland.Lat, Sutherland.Lng, mins)
+		iso, err := g.GenerateIsochrone(ctx, anchor.Lat, anchor.Lng, mins)
 		if err != nil {
 			return results, fmt.Errorf("failed to generate %d min isochrone: %w", mins, err)
 		}
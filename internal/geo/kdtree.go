@@ -0,0 +1,155 @@
+package geo
+
+import (
+	"math"
+	"sort"
+)
+
+// PointIndex is a KD-tree over points on the earth's surface for nearest
+// neighbour queries. Points are stored as unit vectors, where straight-line
+// (chord) distance orders points the same as great-circle distance, so
+// results match a brute-force Haversine scan. Build it once per point set and
+// reuse it; it's read-only after NewPointIndex and safe for concurrent use.
+type PointIndex struct {
+	// points is in tree order: the node for a range is its middle element,
+	// with its left subtree before it and right subtree after
+	points []indexPoint
+}
+
+type indexPoint struct {
+	xyz      [3]float64
+	lat, lng float64
+	index    int // Position in the caller's slice
+}
+
+// Neighbor is a point found by a PointIndex query
+type Neighbor struct {
+	Index      int // Position in the slice the index was built from
+	DistanceKm float64
+}
+
+// NewPointIndex builds an index over n points, coord returning the ith
+func NewPointIndex(n int, coord func(i int) (lat, lng float64)) *PointIndex {
+	points := make([]indexPoint, n)
+	for i := range points {
+		lat, lng := coord(i)
+		points[i] = indexPoint{xyz: unitVector(lat, lng), lat: lat, lng: lng, index: i}
+	}
+	build(points, 0)
+	return &PointIndex{points: points}
+}
+
+// unitVector converts coordinates to a point on the unit sphere
+func unitVector(lat, lng float64) [3]float64 {
+	latRad := lat * math.Pi / 180
+	lngRad := lng * math.Pi / 180
+	return [3]float64{
+		math.Cos(latRad) * math.Cos(lngRad),
+		math.Cos(latRad) * math.Sin(lngRad),
+		math.Sin(latRad),
+	}
+}
+
+// build arranges points into tree order, splitting on axes in turn
+func build(points []indexPoint, axis int) {
+	if len(points) <= 1 {
+		return
+	}
+	sort.Slice(points, func(i, j int) bool { return points[i].xyz[axis] < points[j].xyz[axis] })
+	mid := len(points) / 2
+	next := (axis + 1) % 3
+	build(points[:mid], next)
+	build(points[mid+1:], next)
+}
+
+// Len returns the number of points in the index
+func (idx *PointIndex) Len() int {
+	return len(idx.points)
+}
+
+// Nearest returns the k points nearest to a location, closest first. Fewer
+// are returned if the index holds fewer than k.
+func (idx *PointIndex) Nearest(lat, lng float64, k int) []Neighbor {
+	if k <= 0 || len(idx.points) == 0 {
+		return nil
+	}
+
+	s := search{query: unitVector(lat, lng), k: k}
+	s.visit(idx.points, 0)
+
+	neighbors := make([]Neighbor, len(s.found))
+	for i, f := range s.found {
+		p := idx.points[f.pos]
+		neighbors[i] = Neighbor{Index: p.index, DistanceKm: Haversine(lat, lng, p.lat, p.lng)}
+	}
+	return neighbors
+}
+
+// search holds the k best points found so far, by squared chord distance
+type search struct {
+	query [3]float64
+	k     int
+	found []candidate // Sorted, closest first
+	base  int         // Offset of the subtree being visited within idx.points
+}
+
+type candidate struct {
+	pos  int
+	dist float64
+}
+
+// worst returns the distance a point must beat to be kept
+func (s *search) worst() float64 {
+	if len(s.found) < s.k {
+		return math.MaxFloat64
+	}
+	return s.found[len(s.found)-1].dist
+}
+
+func (s *search) visit(points []indexPoint, axis int) {
+	if len(points) == 0 {
+		return
+	}
+	mid := len(points) / 2
+	p := points[mid]
+
+	var dist float64
+	for i := range p.xyz {
+		d := p.xyz[i] - s.query[i]
+		dist += d * d
+	}
+	if dist < s.worst() {
+		s.insert(candidate{pos: s.base + mid, dist: dist})
+	}
+
+	next := (axis + 1) % 3
+	base := s.base
+	left, right := points[:mid], points[mid+1:]
+	nearBase, farBase := base, base+mid+1
+	near, far := left, right
+	diff := s.query[axis] - p.xyz[axis]
+	if diff > 0 {
+		near, far = right, left
+		nearBase, farBase = farBase, nearBase
+	}
+
+	s.base = nearBase
+	s.visit(near, next)
+	// The far side can only hold closer points if the split plane is closer
+	if diff*diff < s.worst() {
+		s.base = farBase
+		s.visit(far, next)
+	}
+	s.base = base
+}
+
+// insert adds a candidate in order, dropping the furthest beyond k
+func (s *search) insert(c candidate) {
+	i := sort.Search(len(s.found), func(i int) bool { return s.found[i].dist > c.dist })
+	s.found = append(s.found, candidate{})
+	copy(s.found[i+1:], s.found[i:])
+	s.found[i] = c
+	if len(s.found) > s.k {
+		s.found = s.found[:s.k]
+	}
+}
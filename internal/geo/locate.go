@@ -0,0 +1,123 @@
+package geo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// RoadSnap is the point on a mapped road Valhalla snaps a location to for
+// routing, with the road's details
+type RoadSnap struct {
+	Lat        float64
+	Lng        float64
+	DistanceKm float64  // Straight-line distance from the location
+	WayID      int64    // OpenStreetMap way ID, 0 if Valhalla didn't say
+	Names      []string // Road names, if any
+	Class      string   // Valhalla road class, e.g. "tertiary" or "service_other"
+	Use        string   // Valhalla edge use, e.g. "road", "track" or "driveway"
+}
+
+// valhallaLocateResponse is the part of a verbose /locate response used,
+// one entry per location
+type valhallaLocateResponse []struct {
+	Edges []struct {
+		WayID         int64   `json:"way_id"`
+		CorrelatedLat float64 `json:"correlated_lat"`
+		CorrelatedLon float64 `json:"correlated_lon"`
+		EdgeInfo      struct {
+			WayID int64    `json:"way_id"`
+			Names []string `json:"names"`
+		} `json:"edge_info"`
+		Edge struct {
+			Classification struct {
+				Classification string `json:"classification"`
+				Use            string `json:"use"`
+			} `json:"classification"`
+		} `json:"edge"`
+	} `json:"edges"`
+}
+
+// LocateRoad returns the nearest of the drivable roads Valhalla would snap a
+// location to, searching up to searchKm away (0 for Valhalla's default, 35
+// km). Returns ErrNoRoute if there's none within it.
+func (r *Router) LocateRoad(ctx context.Context, lat, lng, searchKm float64) (*RoadSnap, error) {
+	snaps, err := r.LocateRoads(ctx, []MatrixPoint{{Lat: lat, Lng: lng}}, searchKm)
+	if err != nil {
+		return nil, err
+	}
+	if snaps[0] == nil {
+		return nil, fmt.Errorf("%w: no road near %.5f, %.5f", ErrNoRoute, lat, lng)
+	}
+	return snaps[0], nil
+}
+
+// LocateRoads is LocateRoad for several locations in one request, returning
+// nil for those with no road within searchKm
+func (r *Router) LocateRoads(ctx context.Context, points []MatrixPoint, searchKm float64) ([]*RoadSnap, error) {
+	locations := make([]string, len(points))
+	for i, p := range points {
+		if searchKm > 0 {
+			locations[i] = fmt.Sprintf(`{"lat":%f,"lon":%f,"search_cutoff":%.0f}`, p.Lat, p.Lng, searchKm*1000)
+		} else {
+			locations[i] = fmt.Sprintf(`{"lat":%f,"lon":%f}`, p.Lat, p.Lng)
+		}
+	}
+	requestJSON := `{"locations":[` + strings.Join(locations, ",") + `],"costing":"auto","verbose":true}`
+
+	req, err := http.NewRequestWithContext(ctx, "GET", r.baseURL+"/locate?json="+url.QueryEscape(requestJSON), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "FarmSearch/1.0")
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, valhallaRequestError("locate", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, valhallaResponseError("locate", resp.StatusCode, body)
+	}
+
+	var result valhallaLocateResponse
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse locate response: %w", err)
+	}
+	if len(result) != len(points) {
+		return nil, fmt.Errorf("locate returned %d locations for %d", len(result), len(points))
+	}
+
+	snaps := make([]*RoadSnap, len(points))
+	for i, location := range result {
+		for _, edge := range location.Edges {
+			km := Haversine(points[i].Lat, points[i].Lng, edge.CorrelatedLat, edge.CorrelatedLon)
+			if snaps[i] != nil && km >= snaps[i].DistanceKm {
+				continue
+			}
+			wayID := edge.WayID
+			if wayID == 0 {
+				wayID = edge.EdgeInfo.WayID
+			}
+			snaps[i] = &RoadSnap{
+				Lat:        edge.CorrelatedLat,
+				Lng:        edge.CorrelatedLon,
+				DistanceKm: km,
+				WayID:      wayID,
+				Names:      edge.EdgeInfo.Names,
+				Class:      edge.Edge.Classification.Classification,
+				Use:        edge.Edge.Classification.Use,
+			}
+		}
+	}
+	return snaps, nil
+}
@@ -0,0 +1,73 @@
+package geo
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Minimum lot sizes mapped by local environmental plans (ArcGIS REST)
+const nswLotSizeURL = "https://mapprod3.environment.nsw.gov.au/arcgis/rest/services/Planning/EPI_Primary_Planning_Layers/MapServer/4/query"
+
+// LotSizeClient looks up the LEP minimum lot size over lots
+type LotSizeClient struct {
+	httpClient *http.Client
+	url        string
+}
+
+// NewLotSizeClient creates a new minimum lot size API client
+func NewLotSizeClient() *LotSizeClient {
+	return &LotSizeClient{
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+		url:        nswLotSizeURL,
+	}
+}
+
+// FetchMinLotSize returns the minimum lot size in square metres over a lot
+// polygon: the largest of the mapped areas overlapping it, as the most
+// restrictive decides whether it can be subdivided. 0 if none is mapped
+// (e.g. zones whose LEP leaves it to a development control plan).
+func (c *LotSizeClient) FetchMinLotSize(ctx context.Context, geom *LotGeometry) (float64, error) {
+	rings, err := lotRings(geom)
+	if err != nil {
+		return 0, err
+	}
+	features, err := queryIntersecting(ctx, c.httpClient, c.url, rings)
+	if err != nil {
+		return 0, fmt.Errorf("minimum lot size: %w", err)
+	}
+
+	var largest float64
+	for _, attrs := range features {
+		largest = math.Max(largest, lotSizeSqm(attrs))
+	}
+	return largest, nil
+}
+
+// lotSizeSqm returns a lot size area's minimum in square metres, from its
+// size and units ("m²" or "ha"). 0 if it has none.
+func lotSizeSqm(attrs map[string]interface{}) float64 {
+	var size float64
+	for k, v := range attrs {
+		if !strings.EqualFold(k, "LOT_SIZE") {
+			continue
+		}
+		switch v := v.(type) {
+		case float64:
+			size = v
+		case string:
+			size, _ = strconv.ParseFloat(strings.TrimSpace(v), 64)
+		}
+	}
+	if size <= 0 {
+		return 0
+	}
+	if strings.EqualFold(featureAttr(attrs, "UNITS", "UNIT"), "ha") {
+		size *= 10000
+	}
+	return size
+}
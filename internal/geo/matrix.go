@@ -0,0 +1,183 @@
+package geo
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// MatrixPoint is a location in a drive time matrix
+type MatrixPoint struct {
+	Lat float64
+	Lng float64
+}
+
+// valhallaMatrixResponse represents the Valhalla sources_to_targets API response
+type valhallaMatrixResponse struct {
+	SourcesToTargets [][]struct {
+		Time     *float64 `json:"time"`     // Duration in seconds, null if unreachable
+		Distance *float64 `json:"distance"` // Distance in kilometers
+	} `json:"sources_to_targets"`
+}
+
+// GetMatrix calculates drive times between every pair of points with a single
+// Valhalla matrix request. result[i][j] is the route from points[i] to
+// points[j], or nil if there's no route.
+func (r *Router) GetMatrix(ctx context.Context, points []MatrixPoint) ([][]*RouteResult, error) {
+	return r.sourcesToTargets(ctx, points, points)
+}
+
+// GetDriveTimesTo calculates the drive time from each of sources to target
+// with a single Valhalla matrix request. result[i] is the route from
+// sources[i], or nil if there's no route.
+func (r *Router) GetDriveTimesTo(ctx context.Context, sources []MatrixPoint, target MatrixPoint) ([]*RouteResult, error) {
+	matrix, err := r.sourcesToTargets(ctx, sources, []MatrixPoint{target})
+	if err != nil {
+		return nil, err
+	}
+	results := make([]*RouteResult, len(sources))
+	for i, row := range matrix {
+		results[i] = row[0]
+	}
+	return results, nil
+}
+
+// sourcesToTargets makes a Valhalla matrix request. result[i][j] is the
+// route from sources[i] to targets[j], or nil if there's no route.
+func (r *Router) sourcesToTargets(ctx context.Context, sources, targets []MatrixPoint) ([][]*RouteResult, error) {
+	locations := func(points []MatrixPoint) []map[string]float64 {
+		locs := make([]map[string]float64, len(points))
+		for i, p := range points {
+			locs[i] = map[string]float64{"lat": p.Lat, "lon": p.Lng}
+		}
+		return locs
+	}
+	requestJSON, err := json.Marshal(map[string]interface{}{
+		"sources": locations(sources),
+		"targets": locations(targets),
+		"costing": "auto",
+		"units":   "kilometers",
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// POST rather than ?json= since the request grows with the number of points
+	req, err := http.NewRequestWithContext(ctx, "POST", r.baseURL+"/sources_to_targets", bytes.NewReader(requestJSON))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("User-Agent", "FarmSearch/1.0")
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, valhallaRequestError("matrix", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, valhallaResponseError("matrix", resp.StatusCode, body)
+	}
+
+	var result valhallaMatrixResponse
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse matrix response: %w", err)
+	}
+	if len(result.SourcesToTargets) != len(sources) {
+		return nil, fmt.Errorf("matrix response has %d rows, expected %d", len(result.SourcesToTargets), len(sources))
+	}
+
+	matrix := make([][]*RouteResult, len(sources))
+	for i, row := range result.SourcesToTargets {
+		matrix[i] = make([]*RouteResult, len(targets))
+		for j, cell := range row {
+			if j >= len(targets) || cell.Time == nil {
+				continue
+			}
+			distance := 0.0
+			if cell.Distance != nil {
+				distance = *cell.Distance
+			}
+			// Apply 10% buffer to account for traffic, as for single routes
+			matrix[i][j] = &RouteResult{
+				DurationMins: (*cell.Time / 60.0) * 1.1,
+				DistanceKm:   distance,
+			}
+		}
+	}
+
+	return matrix, nil
+}
+
+// VisitOrder finds a short round trip from start through every stop and back,
+// using nearest neighbour followed by 2-opt improvement. It's not guaranteed
+// optimal, but is close for the handful of stops in an inspection day. Legs
+// without a route count as unreachable. Returns the visiting order (starting
+// and ending with start) and its total drive time in minutes.
+func VisitOrder(matrix [][]*RouteResult, start int, stops []int) ([]int, float64) {
+	const unreachable = 1e9
+	cost := func(from, to int) float64 {
+		if from == to {
+			return 0
+		}
+		if matrix[from][to] == nil {
+			return unreachable
+		}
+		return matrix[from][to].DurationMins
+	}
+
+	// Nearest neighbour
+	tour := []int{start}
+	remaining := append([]int(nil), stops...)
+	for len(remaining) > 0 {
+		last := tour[len(tour)-1]
+		best := 0
+		for i := 1; i < len(remaining); i++ {
+			if cost(last, remaining[i]) < cost(last, remaining[best]) {
+				best = i
+			}
+		}
+		tour = append(tour, remaining[best])
+		remaining = append(remaining[:best], remaining[best+1:]...)
+	}
+	tour = append(tour, start)
+
+	total := func(t []int) float64 {
+		sum := 0.0
+		for i := 0; i < len(t)-1; i++ {
+			sum += cost(t[i], t[i+1])
+		}
+		return sum
+	}
+
+	// 2-opt: reverse segments while that shortens the trip. Drive times aren't
+	// symmetric, so compare whole-trip totals rather than just the swapped legs.
+	best := total(tour)
+	for improved := true; improved; {
+		improved = false
+		for i := 1; i < len(tour)-2; i++ {
+			for j := i + 1; j < len(tour)-1; j++ {
+				candidate := append([]int(nil), tour...)
+				for a, b := i, j; a < b; a, b = a+1, b-1 {
+					candidate[a], candidate[b] = candidate[b], candidate[a]
+				}
+				if t := total(candidate); t < best-0.01 {
+					tour, best = candidate, t
+					improved = true
+				}
+			}
+		}
+	}
+
+	return tour, best
+}
@@ -0,0 +1,77 @@
+package geo
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Noise source layers, imported as exclusion layers so exclude_near can use
+// them too
+const (
+	NoiseHighways = "highways"
+	NoiseRailways = "railways"
+	NoiseRunways  = "runways"
+)
+
+// NoiseLayers are the noise source layers, in the order they're reported
+var NoiseLayers = []string{NoiseHighways, NoiseRailways, NoiseRunways}
+
+// noiseHighways are the OSM highway classes loud enough to count: motorways
+// and trunk roads (the Hume, Pacific and New England highways are trunk)
+var noiseHighways = map[string]bool{"motorway": true, "trunk": true}
+
+// ReadNoiseSources reads the motorways and trunk roads, active railway lines
+// and airport runways of an OSM GeoJSON export (from osmium export or
+// osmtogeojson, so tags may be properties or under properties.tags), by
+// layer. Link roads, sidings, yards and disused or tourist railways are
+// skipped, as are features other than lines and polygons.
+func ReadNoiseSources(r io.Reader) (map[string][]ExclusionFeature, error) {
+	var fc GeoJSONFeatureCollection
+	if err := json.NewDecoder(r).Decode(&fc); err != nil {
+		return nil, fmt.Errorf("failed to parse GeoJSON: %w", err)
+	}
+
+	layers := make(map[string][]ExclusionFeature)
+	for _, f := range fc.Features {
+		switch f.Geometry.Type {
+		case "LineString", "MultiLineString", "Polygon", "MultiPolygon":
+		default:
+			continue
+		}
+
+		var layer string
+		switch {
+		case noiseHighways[osmTag(f.Properties, "highway")]:
+			layer = NoiseHighways
+		case osmTag(f.Properties, "railway") == "rail" && osmTag(f.Properties, "service") == "" &&
+			osmTag(f.Properties, "usage") != "tourism":
+			layer = NoiseRailways
+		case osmTag(f.Properties, "aeroway") == "runway":
+			layer = NoiseRunways
+		default:
+			continue
+		}
+
+		name := osmTag(f.Properties, "name")
+		if name == "" {
+			name = osmTag(f.Properties, "ref")
+		}
+		layers[layer] = append(layers[layer], ExclusionFeature{Name: name, Geometry: f.Geometry})
+	}
+	return layers, nil
+}
+
+// osmTag returns an OSM tag of a feature's properties, or ""
+func osmTag(props map[string]interface{}, key string) string {
+	if v, ok := props[key].(string); ok {
+		return strings.TrimSpace(v)
+	}
+	if tags, ok := props["tags"].(map[string]interface{}); ok {
+		if v, ok := tags[key].(string); ok {
+			return strings.TrimSpace(v)
+		}
+	}
+	return ""
+}
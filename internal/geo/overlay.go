@@ -0,0 +1,122 @@
+package geo
+
+import "math"
+
+// Planning overlay layers, imported as exclusion layers so exclude_near can
+// use them too
+const (
+	OverlayKoalaHabitat = "koala-habitat"       // Koala habitat SEPP development application map
+	OverlayBiodiversity = "biodiversity-values" // Biodiversity Offsets Scheme values map
+)
+
+// OverlayLayers are the overlay layers, in the order they're reported
+var OverlayLayers = []string{OverlayKoalaHabitat, OverlayBiodiversity}
+
+// overlayGrid is the number of sample points along each side of a lot's
+// bounding box when measuring its coverage, so a lot is sampled at up to
+// overlayGrid² points (about 0.1% of a square lot per point)
+const overlayGrid = 32
+
+// Overlay is a layer of polygons, such as mapped koala habitat, for
+// measuring how much of a lot they cover
+type Overlay struct {
+	polygons []boundedPolygon
+}
+
+// boundedPolygon is a polygon with the bounding box of its outer ring
+type boundedPolygon struct {
+	rings          []ring
+	minLat, minLng float64
+	maxLat, maxLng float64
+}
+
+// NewOverlay reads the Polygon and MultiPolygon features of a feature
+// collection. Other geometry types are ignored.
+func NewOverlay(fc *GeoJSONFeatureCollection) (*Overlay, error) {
+	area, err := NewArea(fc)
+	if err != nil {
+		return nil, err
+	}
+	return &Overlay{polygons: boundPolygons(area.polygons)}, nil
+}
+
+// boundPolygons pairs polygons with their bounding boxes
+func boundPolygons(polygons [][]ring) []boundedPolygon {
+	bounded := make([]boundedPolygon, len(polygons))
+	for i, polygon := range polygons {
+		p := boundedPolygon{rings: polygon, minLat: math.MaxFloat64, minLng: math.MaxFloat64,
+			maxLat: -math.MaxFloat64, maxLng: -math.MaxFloat64}
+		for _, v := range polygon[0] {
+			p.minLng = math.Min(p.minLng, v[0])
+			p.maxLng = math.Max(p.maxLng, v[0])
+			p.minLat = math.Min(p.minLat, v[1])
+			p.maxLat = math.Max(p.maxLat, v[1])
+		}
+		bounded[i] = p
+	}
+	return bounded
+}
+
+// Empty reports whether the overlay has no polygons
+func (o *Overlay) Empty() bool {
+	return len(o.polygons) == 0
+}
+
+// CoveredFraction estimates the fraction (0-1) of a lot the overlay covers,
+// from a grid of points over the lot. A lot too thin for any grid point to
+// fall inside it is tested at the middle of its bounding box.
+func (o *Overlay) CoveredFraction(lot *Area) float64 {
+	return coveredFraction(o.polygons, lot)
+}
+
+// coveredFraction is CoveredFraction for any set of polygons
+func coveredFraction(polygons []boundedPolygon, lot *Area) float64 {
+	if lot.Empty() {
+		return 0
+	}
+	swLat, swLng, neLat, neLng := lot.Bounds()
+
+	// Only the polygons overlapping the lot's bounding box can cover it
+	var candidates []boundedPolygon
+	for _, p := range polygons {
+		if p.maxLat >= swLat && p.minLat <= neLat && p.maxLng >= swLng && p.minLng <= neLng {
+			candidates = append(candidates, p)
+		}
+	}
+	if len(candidates) == 0 {
+		return 0
+	}
+
+	covered := func(lat, lng float64) bool {
+		for _, p := range candidates {
+			if lat >= p.minLat && lat <= p.maxLat && lng >= p.minLng && lng <= p.maxLng &&
+				polygonContains(p.rings, lat, lng) {
+				return true
+			}
+		}
+		return false
+	}
+
+	// Sample at cell centres, so points don't sit on the lot's edges
+	inside, hits := 0, 0
+	for i := 0; i < overlayGrid; i++ {
+		lat := swLat + (float64(i)+0.5)*(neLat-swLat)/overlayGrid
+		for j := 0; j < overlayGrid; j++ {
+			lng := swLng + (float64(j)+0.5)*(neLng-swLng)/overlayGrid
+			if !lot.Contains(lat, lng) {
+				continue
+			}
+			inside++
+			if covered(lat, lng) {
+				hits++
+			}
+		}
+	}
+	if inside == 0 {
+		if covered((swLat+neLat)/2, (swLng+neLng)/2) {
+			return 1
+		}
+		return 0
+	}
+	return float64(hits) / float64(inside)
+}
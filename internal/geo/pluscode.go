@@ -0,0 +1,66 @@
+package geo
+
+import (
+	"math"
+	"net/url"
+	"strconv"
+)
+
+// Open Location Code (plus code) encoding
+const (
+	plusCodeAlphabet = "23456789CFGHJMPQRVWX"
+	plusCodeBase     = 20
+	plusCodePairs    = 5 // Pairs of digits before the grid refinement
+	plusCodeGrid     = 5 // Grid digits computed; the first is kept
+	plusCodeRows     = 5
+	plusCodeCols     = 4
+
+	// Precision of the full code in units per degree: the pairs resolve
+	// 1/8000 degree, each grid digit 5 rows and 4 columns further
+	plusCodeLatPrecision = 8000 * 3125 // 5^5
+	plusCodeLngPrecision = 8000 * 1024 // 4^5
+)
+
+// PlusCode returns the 11-digit Open Location Code of a point (e.g.
+// "4RRH46J5+FPX"), which identifies a roughly 3 m square and can be typed
+// into Google Maps in place of an address
+func PlusCode(lat, lng float64) string {
+	lat = math.Max(-90, math.Min(90, lat))
+	latVal := int64(math.Floor(math.Round((lat+90)*plusCodeLatPrecision*1e6) / 1e6))
+	lngVal := int64(math.Floor(math.Round((lng+180)*plusCodeLngPrecision*1e6) / 1e6))
+
+	// The north pole is encoded just south of it, and longitudes wrap
+	if maxLat := int64(180 * plusCodeLatPrecision); latVal >= maxLat {
+		latVal = maxLat - 1
+	}
+	lngRange := int64(360 * plusCodeLngPrecision)
+	lngVal = (lngVal%lngRange + lngRange) % lngRange
+
+	// Digits come out least significant first
+	grid := make([]byte, plusCodeGrid)
+	for i := plusCodeGrid - 1; i >= 0; i-- {
+		grid[i] = plusCodeAlphabet[(latVal%plusCodeRows)*plusCodeCols+lngVal%plusCodeCols]
+		latVal /= plusCodeRows
+		lngVal /= plusCodeCols
+	}
+	pairs := make([]byte, 2*plusCodePairs)
+	for i := 2*plusCodePairs - 2; i >= 0; i -= 2 {
+		pairs[i+1] = plusCodeAlphabet[lngVal%plusCodeBase]
+		lngVal /= plusCodeBase
+		pairs[i] = plusCodeAlphabet[latVal%plusCodeBase]
+		latVal /= plusCodeBase
+	}
+	return string(pairs[:8]) + "+" + string(pairs[8:]) + string(grid[:1])
+}
+
+// PlusCodeURL returns the plus.codes page for a code, which shows it on a
+// map with directions
+func PlusCodeURL(code string) string {
+	return "https://plus.codes/" + url.PathEscape(code)
+}
+
+// GoogleMapsURL returns a Google Maps link dropping a pin at a point
+func GoogleMapsURL(lat, lng float64) string {
+	return "https://www.google.com/maps/search/?api=1&query=" +
+		strconv.FormatFloat(lat, 'f', 6, 64) + "," + strconv.FormatFloat(lng, 'f', 6, 64)
+}
@@ -0,0 +1,186 @@
+package geo
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ParsePolygon reads a search area drawn on the map, given as GeoJSON (a
+// Polygon or MultiPolygon geometry, or a Feature or FeatureCollection of
+// them) or as WKT ("POLYGON((150.1 -34.2, 150.5 -34.2, 150.5 -34.6, 150.1
+// -34.2))" or MULTIPOLYGON). Coordinates are longitude then latitude in
+// both. Errors if there's no polygon with at least three vertices.
+func ParsePolygon(s string) (*Area, error) {
+	s = strings.TrimSpace(s)
+
+	var area *Area
+	if strings.HasPrefix(s, "{") {
+		fc, err := parseGeoJSONPolygon(s)
+		if err != nil {
+			return nil, err
+		}
+		if area, err = NewArea(fc); err != nil {
+			return nil, err
+		}
+	} else {
+		polygons, err := parseWKTPolygon(s)
+		if err != nil {
+			return nil, err
+		}
+		area = newArea()
+		for _, polygon := range polygons {
+			area.add(polygon)
+		}
+	}
+
+	if area.Empty() {
+		return nil, errors.New("no polygon with at least three vertices")
+	}
+	if area.minLat < -90 || area.maxLat > 90 || area.minLng < -180 || area.maxLng > 180 {
+		return nil, errors.New("polygon coordinates out of range (expected lng lat)")
+	}
+	return area, nil
+}
+
+// parseGeoJSONPolygon wraps a GeoJSON geometry or feature in a feature
+// collection for NewArea
+func parseGeoJSONPolygon(s string) (*GeoJSONFeatureCollection, error) {
+	var object struct {
+		Type        string           `json:"type"`
+		Coordinates json.RawMessage  `json:"coordinates"`
+		Geometry    *GeoJSONGeometry `json:"geometry"`
+		Features    []GeoJSONFeature `json:"features"`
+	}
+	if err := json.Unmarshal([]byte(s), &object); err != nil {
+		return nil, fmt.Errorf("invalid GeoJSON: %w", err)
+	}
+
+	switch object.Type {
+	case "FeatureCollection":
+		return &GeoJSONFeatureCollection{Type: object.Type, Features: object.Features}, nil
+	case "Feature":
+		if object.Geometry == nil {
+			return nil, errors.New("GeoJSON feature has no geometry")
+		}
+		return &GeoJSONFeatureCollection{Features: []GeoJSONFeature{{Geometry: *object.Geometry}}}, nil
+	case "Polygon", "MultiPolygon":
+		geometry := GeoJSONGeometry{Type: object.Type, Coordinates: object.Coordinates}
+		return &GeoJSONFeatureCollection{Features: []GeoJSONFeature{{Geometry: geometry}}}, nil
+	}
+	return nil, fmt.Errorf("unsupported GeoJSON type %q (expected Polygon or MultiPolygon)", object.Type)
+}
+
+// parseWKTPolygon parses a WKT POLYGON or MULTIPOLYGON into polygons of rings
+func parseWKTPolygon(s string) ([][]ring, error) {
+	upper := strings.ToUpper(s)
+	w := &wktReader{s: s}
+
+	var polygons [][]ring
+	var err error
+	switch {
+	case strings.HasPrefix(upper, "MULTIPOLYGON"):
+		w.pos = len("MULTIPOLYGON")
+		err = w.list(func() error {
+			polygon, err := w.polygon()
+			polygons = append(polygons, polygon)
+			return err
+		})
+	case strings.HasPrefix(upper, "POLYGON"):
+		w.pos = len("POLYGON")
+		var polygon []ring
+		polygon, err = w.polygon()
+		polygons = [][]ring{polygon}
+	default:
+		return nil, errors.New("expected GeoJSON or a WKT POLYGON or MULTIPOLYGON")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid WKT: %w", err)
+	}
+	if w.peek() != 0 {
+		return nil, fmt.Errorf("invalid WKT: unexpected %q at %d", w.s[w.pos], w.pos)
+	}
+	return polygons, nil
+}
+
+// wktReader reads the parenthesised coordinate lists of WKT
+type wktReader struct {
+	s   string
+	pos int
+}
+
+// peek returns the next non-space character, or 0 at the end
+func (w *wktReader) peek() byte {
+	for w.pos < len(w.s) && unicode.IsSpace(rune(w.s[w.pos])) {
+		w.pos++
+	}
+	if w.pos >= len(w.s) {
+		return 0
+	}
+	return w.s[w.pos]
+}
+
+// expect consumes the next non-space character, which must be c
+func (w *wktReader) expect(c byte) error {
+	if w.peek() != c {
+		return fmt.Errorf("expected %q at %d", c, w.pos)
+	}
+	w.pos++
+	return nil
+}
+
+// list reads "(item, item, ...)"
+func (w *wktReader) list(item func() error) error {
+	if err := w.expect('('); err != nil {
+		return err
+	}
+	for {
+		if err := item(); err != nil {
+			return err
+		}
+		if w.peek() != ',' {
+			return w.expect(')')
+		}
+		w.pos++
+	}
+}
+
+// polygon reads "((lng lat, ...), (hole), ...)"
+func (w *wktReader) polygon() ([]ring, error) {
+	var polygon []ring
+	err := w.list(func() error {
+		var r ring
+		err := w.list(func() error {
+			lng, err := w.number()
+			if err != nil {
+				return err
+			}
+			lat, err := w.number()
+			if err != nil {
+				return err
+			}
+			r = append(r, [2]float64{lng, lat})
+			return nil
+		})
+		polygon = append(polygon, r)
+		return err
+	})
+	return polygon, err
+}
+
+// number reads a coordinate
+func (w *wktReader) number() (float64, error) {
+	w.peek()
+	start := w.pos
+	for w.pos < len(w.s) && strings.IndexByte("+-.0123456789eE", w.s[w.pos]) >= 0 {
+		w.pos++
+	}
+	value, err := strconv.ParseFloat(w.s[start:w.pos], 64)
+	if err != nil {
+		return 0, fmt.Errorf("expected a coordinate at %d", start)
+	}
+	return value, nil
+}
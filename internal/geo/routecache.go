@@ -0,0 +1,77 @@
+package geo
+
+import (
+	"math"
+	"sync"
+)
+
+// routeCacheCellDeg is the grid RouteCache snaps route ends to: 0.0025° is
+// about 280 m north-south and 230 m east-west across NSW, well inside the
+// error of a listing's coordinates
+const routeCacheCellDeg = 0.0025
+
+// RouteCache remembers routes by the grid cells of their ends, so a batch of
+// drive times skips asking Valhalla again for properties next to one another
+// (e.g. duplicate listings or subdivided lots) going to the same town, school
+// or anchor. Routers with different profiles can share one. Failed routes
+// aren't cached. Safe for concurrent use.
+type RouteCache struct {
+	mu     sync.Mutex
+	routes map[routeCacheKey]RouteResult
+	stats  RouteCacheStats
+}
+
+// RouteCacheStats counts a RouteCache's lookups
+type RouteCacheStats struct {
+	Hits   int
+	Misses int
+}
+
+// HitRate returns the share of lookups answered from the cache, from 0 to 1
+func (s RouteCacheStats) HitRate() float64 {
+	if s.Hits+s.Misses == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(s.Hits+s.Misses)
+}
+
+type routeCacheKey struct {
+	profile                        Profile
+	fromLat, fromLng, toLat, toLng int32
+}
+
+// NewRouteCache creates an empty RouteCache
+func NewRouteCache() *RouteCache {
+	return &RouteCache{routes: make(map[routeCacheKey]RouteResult)}
+}
+
+// Stats returns the cache's hits and misses so far
+func (c *RouteCache) Stats() RouteCacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.stats
+}
+
+func newRouteCacheKey(profile Profile, fromLat, fromLng, toLat, toLng float64) routeCacheKey {
+	cell := func(deg float64) int32 { return int32(math.Round(deg / routeCacheCellDeg)) }
+	return routeCacheKey{profile, cell(fromLat), cell(fromLng), cell(toLat), cell(toLng)}
+}
+
+// get returns the cached route between the ends' cells, counting the lookup
+func (c *RouteCache) get(key routeCacheKey) (*RouteResult, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	route, ok := c.routes[key]
+	if !ok {
+		c.stats.Misses++
+		return nil, false
+	}
+	c.stats.Hits++
+	return &route, true
+}
+
+func (c *RouteCache) put(key routeCacheKey, route *RouteResult) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.routes[key] = *route
+}
@@ -13,8 +13,19 @@ import (
 type Router struct {
 	client  *http.Client
 	baseURL string
+	cache   *RouteCache // GetRoute's, if set
+	profile Profile     // GetRoute's; ProfileAuto if empty
 }
 
+// Profile is the Valhalla costing a Router's GetRoute travels by
+type Profile string
+
+const (
+	ProfileAuto       Profile = "auto"
+	ProfilePedestrian Profile = "pedestrian"
+	ProfileBicycle    Profile = "bicycle"
+)
+
 // RouteResult contains the result of a route calculation
 type RouteResult struct {
 	DurationMins float64 // Drive time in minutes
@@ -56,16 +67,58 @@ type RouteWithShape struct {
 	Coordinates  [][]float64 `json:"coordinates"` // [[lng, lat], ...]
 }
 
-// GetDriveTime calculates the drive time from a property to Sutherland
-func (r *Router) GetDriveTime(ctx context.Context, fromLat, fromLng float64) (*RouteResult, error) {
-	return r.GetRoute(ctx, fromLat, fromLng, Sutherland.Lat, Sutherland.Lng)
+// WithRouteCache returns a copy of the router whose GetRoute answers from
+// cache where it can, for batch jobs
+func (r *Router) WithRouteCache(cache *RouteCache) *Router {
+	cached := *r
+	cached.cache = cache
+	return &cached
+}
+
+// WithProfile returns a copy of the router whose GetRoute walks or cycles
+// (or drives) instead
+func (r *Router) WithProfile(profile Profile) *Router {
+	profiled := *r
+	profiled.profile = profile
+	return &profiled
+}
+
+// costing returns the router's profile, driving if none was set
+func (r *Router) costing() Profile {
+	if r.profile == "" {
+		return ProfileAuto
+	}
+	return r.profile
+}
+
+// GetDriveTime calculates the drive time from a property to an anchor
+func (r *Router) GetDriveTime(ctx context.Context, fromLat, fromLng float64, anchor Anchor) (*RouteResult, error) {
+	return r.GetRoute(ctx, fromLat, fromLng, anchor.Lat, anchor.Lng)
 }
 
-// GetRoute calculates the drive time between two points
+// GetRoute calculates the travel time between two points by the router's
+// profile (driving unless WithProfile), or returns one cached for points in
+// the same grid cells
 func (r *Router) GetRoute(ctx context.Context, fromLat, fromLng, toLat, toLng float64) (*RouteResult, error) {
+	if r.cache == nil {
+		return r.getRoute(ctx, fromLat, fromLng, toLat, toLng)
+	}
+	key := newRouteCacheKey(r.costing(), fromLat, fromLng, toLat, toLng)
+	if route, ok := r.cache.get(key); ok {
+		return route, nil
+	}
+	route, err := r.getRoute(ctx, fromLat, fromLng, toLat, toLng)
+	if err != nil {
+		return nil, err
+	}
+	r.cache.put(key, route)
+	return route, nil
+}
+
+func (r *Router) getRoute(ctx context.Context, fromLat, fromLng, toLat, toLng float64) (*RouteResult, error) {
 	// Build compact request JSON (no whitespace - required for URL encoding)
-	requestJSON := fmt.Sprintf(`{"locations":[{"lat":%f,"lon":%f},{"lat":%f,"lon":%f}],"costing":"auto","units":"kilometers"}`,
-		fromLat, fromLng, toLat, toLng)
+	requestJSON := fmt.Sprintf(`{"locations":[{"lat":%f,"lon":%f},{"lat":%f,"lon":%f}],"costing":"%s","units":"kilometers"}`,
+		fromLat, fromLng, toLat, toLng, r.costing())
 
 	url := fmt.Sprintf("%s/route?json=%s", r.baseURL, requestJSON)
 
@@ -78,13 +131,13 @@ func (r *Router) GetRoute(ctx context.Context, fromLat, fromLng, toLat, toLng fl
 
 	resp, err := r.client.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("route request failed: %w", err)
+		return nil, valhallaRequestError("route", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("route API error %d: %s", resp.StatusCode, string(body))
+		return nil, valhallaResponseError("route", resp.StatusCode, body)
 	}
 
 	body, err := io.ReadAll(resp.Body)
@@ -97,8 +150,12 @@ func (r *Router) GetRoute(ctx context.Context, fromLat, fromLng, toLat, toLng fl
 		return nil, fmt.Errorf("failed to parse route response: %w", err)
 	}
 
-	// Apply 10% buffer to account for traffic, stops, and real-world conditions
-	durationMins := (result.Trip.Summary.Time / 60.0) * 1.1
+	// Apply 10% buffer to account for traffic, stops, and real-world
+	// conditions; walking and cycling times are taken as they are
+	durationMins := result.Trip.Summary.Time / 60.0
+	if r.costing() == ProfileAuto {
+		durationMins *= 1.1
+	}
 
 	return &RouteResult{
 		DurationMins: durationMins,
@@ -123,13 +180,13 @@ func (r *Router) GetRouteWithShape(ctx context.Context, fromLat, fromLng, toLat,
 
 	resp, err := r.client.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("route request failed: %w", err)
+		return nil, valhallaRequestError("route", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("route API error %d: %s", resp.StatusCode, string(body))
+		return nil, valhallaResponseError("route", resp.StatusCode, body)
 	}
 
 	body, err := io.ReadAll(resp.Body)
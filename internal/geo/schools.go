@@ -9,6 +9,7 @@ import (
 	"net/http"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -24,6 +25,10 @@ type School struct {
 // SchoolData holds NSW schools data
 type SchoolData struct {
 	Schools []School
+
+	mu         sync.Mutex
+	index      *PointIndex
+	indexedLen int // len(Schools) when index was built
 }
 
 // NewSchoolData creates a new school data store
@@ -143,20 +148,27 @@ func (sd *SchoolData) parseCSV(r io.Reader) error {
 	return nil
 }
 
+// pointIndex returns the index over Schools, (re)building it if schools
+// have been loaded since it was built
+func (sd *SchoolData) pointIndex() *PointIndex {
+	sd.mu.Lock()
+	defer sd.mu.Unlock()
+	if sd.index == nil || sd.indexedLen != len(sd.Schools) {
+		sd.index = NewPointIndex(len(sd.Schools), func(i int) (float64, float64) {
+			return sd.Schools[i].Latitude, sd.Schools[i].Longitude
+		})
+		sd.indexedLen = len(sd.Schools)
+	}
+	return sd.index
+}
+
 // FindNearestSchool finds the nearest school to a given location
 func (sd *SchoolData) FindNearestSchool(lat, lng float64) (School, float64) {
-	var nearest School
-	minDist := math.MaxFloat64
-
-	for _, school := range sd.Schools {
-		dist := Haversine(lat, lng, school.Latitude, school.Longitude)
-		if dist < minDist {
-			minDist = dist
-			nearest = school
-		}
+	nearest := sd.pointIndex().Nearest(lat, lng, 1)
+	if len(nearest) == 0 {
+		return School{}, math.MaxFloat64
 	}
-
-	return nearest, minDist
+	return sd.Schools[nearest[0].Index], nearest[0].DistanceKm
 }
 
 // NearestSchoolResult holds school and distance info
@@ -169,38 +181,36 @@ type NearestSchoolResult struct {
 	DistanceKm float64
 }
 
-// FindTwoNearestSchools finds the two nearest schools to a given location
-func (sd *SchoolData) FindTwoNearestSchools(lat, lng float64) (NearestSchoolResult, NearestSchoolResult) {
-	var first, second NearestSchoolResult
-	first.DistanceKm = math.MaxFloat64
-	second.DistanceKm = math.MaxFloat64
-
-	for _, school := range sd.Schools {
-		dist := Haversine(lat, lng, school.Latitude, school.Longitude)
-		if dist < first.DistanceKm {
-			// Current first becomes second
-			second = first
-			// New first
-			first = NearestSchoolResult{
-				Name:       school.Name,
-				Type:       school.Type,
-				Suburb:     school.Suburb,
-				Latitude:   school.Latitude,
-				Longitude:  school.Longitude,
-				DistanceKm: dist,
-			}
-		} else if dist < second.DistanceKm {
-			second = NearestSchoolResult{
-				Name:       school.Name,
-				Type:       school.Type,
-				Suburb:     school.Suburb,
-				Latitude:   school.Latitude,
-				Longitude:  school.Longitude,
-				DistanceKm: dist,
-			}
+// FindNearestSchools finds the k nearest schools to a given location, closest first
+func (sd *SchoolData) FindNearestSchools(lat, lng float64, k int) []NearestSchoolResult {
+	nearest := sd.pointIndex().Nearest(lat, lng, k)
+	results := make([]NearestSchoolResult, len(nearest))
+	for i, n := range nearest {
+		school := sd.Schools[n.Index]
+		results[i] = NearestSchoolResult{
+			Name:       school.Name,
+			Type:       school.Type,
+			Suburb:     school.Suburb,
+			Latitude:   school.Latitude,
+			Longitude:  school.Longitude,
+			DistanceKm: n.DistanceKm,
 		}
 	}
+	return results
+}
 
+// FindTwoNearestSchools finds the two nearest schools to a given location
+func (sd *SchoolData) FindTwoNearestSchools(lat, lng float64) (NearestSchoolResult, NearestSchoolResult) {
+	first := NearestSchoolResult{DistanceKm: math.MaxFloat64}
+	second := NearestSchoolResult{DistanceKm: math.MaxFloat64}
+
+	nearest := sd.FindNearestSchools(lat, lng, 2)
+	if len(nearest) > 0 {
+		first = nearest[0]
+	}
+	if len(nearest) > 1 {
+		second = nearest[1]
+	}
 	return first, second
 }
 
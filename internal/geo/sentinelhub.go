@@ -0,0 +1,270 @@
+package geo
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// Sentinel Hub's own deployment, and the Copernicus Data Space
+	// Ecosystem's, which serves the same APIs
+	sentinelHubURL      = "https://services.sentinel-hub.com"
+	sentinelHubTokenURL = "https://services.sentinel-hub.com/auth/realms/main/protocol/openid-connect/token"
+	copernicusURL       = "https://sh.dataspace.copernicus.eu"
+	copernicusTokenURL  = "https://identity.dataspace.copernicus.eu/auth/realms/CDSE/protocol/openid-connect/token"
+
+	// Pixel size of the statistics in degrees, about Sentinel-2's 10 m
+	sentinelHubResolution = 0.0001
+
+	// Days with more than this share of the box cloudy or missing are skipped
+	sentinelHubMaxNoData = 0.5
+)
+
+// sentinelHubEvalscript computes each pixel's NDVI and whether it's woody,
+// masking clouds, cloud shadow and snow with the scene classification
+var sentinelHubEvalscript = fmt.Sprintf(`//VERSION=3
+function setup() {
+  return {
+    input: [{bands: ["B04", "B08", "SCL", "dataMask"]}],
+    output: [
+      {id: "ndvi", bands: 1, sampleType: "FLOAT32"},
+      {id: "woody", bands: 1, sampleType: "FLOAT32"},
+      {id: "dataMask", bands: 1}
+    ]
+  };
+}
+function evaluatePixel(s) {
+  let ndvi = (s.B08 - s.B04) / (s.B08 + s.B04);
+  let clear = s.dataMask == 1 && [3, 8, 9, 10, 11].indexOf(s.SCL) < 0 ? 1 : 0;
+  return {ndvi: [ndvi], woody: [ndvi > %g ? 1 : 0], dataMask: [clear]};
+}`, WoodyNDVI)
+
+// SentinelHubClient is a VegetationSource measuring Sentinel-2 L2A NDVI
+// with the Sentinel Hub Statistical API
+type SentinelHubClient struct {
+	httpClient   *http.Client
+	baseURL      string
+	tokenURL     string
+	clientID     string
+	clientSecret string
+
+	mu          sync.Mutex
+	token       string
+	tokenExpiry time.Time
+}
+
+// newSentinelHubClientFromEnv creates a Sentinel Hub client for the
+// deployment at baseURL from SENTINELHUB_CLIENT_ID and
+// SENTINELHUB_CLIENT_SECRET (an OAuth client), with SENTINELHUB_URL and
+// SENTINELHUB_TOKEN_URL overriding the deployment. Returns nil if no client
+// ID is set.
+func newSentinelHubClientFromEnv(baseURL, tokenURL string) *SentinelHubClient {
+	clientID := os.Getenv("SENTINELHUB_CLIENT_ID")
+	if clientID == "" {
+		return nil
+	}
+	c := &SentinelHubClient{
+		httpClient:   &http.Client{Timeout: 60 * time.Second},
+		baseURL:      baseURL,
+		tokenURL:     tokenURL,
+		clientID:     clientID,
+		clientSecret: os.Getenv("SENTINELHUB_CLIENT_SECRET"),
+	}
+	if u := os.Getenv("SENTINELHUB_URL"); u != "" {
+		c.baseURL = strings.TrimRight(u, "/")
+	}
+	if u := os.Getenv("SENTINELHUB_TOKEN_URL"); u != "" {
+		c.tokenURL = u
+	}
+	return c
+}
+
+// Snapshot averages the cloud-free days between from and to
+func (c *SentinelHubClient) Snapshot(ctx context.Context, bbox BBox, from, to time.Time) (*VegetationSnapshot, error) {
+	days, err := c.Observations(ctx, bbox, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot := &VegetationSnapshot{From: from, To: to}
+	for _, day := range days {
+		snapshot.MeanNDVI += day.MeanNDVI
+		snapshot.WoodyCover += day.WoodyCover
+		snapshot.Observations++
+	}
+	if snapshot.Observations == 0 {
+		return nil, fmt.Errorf("no cloud-free observations between %s and %s",
+			from.Format("2006-01-02"), to.Format("2006-01-02"))
+	}
+	snapshot.MeanNDVI /= float64(snapshot.Observations)
+	snapshot.WoodyCover /= float64(snapshot.Observations)
+	return snapshot, nil
+}
+
+// Observations returns the days between from and to with enough clear
+// pixels, a year per request to keep responses small
+func (c *SentinelHubClient) Observations(ctx context.Context, bbox BBox, from, to time.Time) ([]VegetationObservation, error) {
+	var observations []VegetationObservation
+	for start := from; start.Before(to); start = start.AddDate(1, 0, 0) {
+		end := start.AddDate(1, 0, 0)
+		if end.After(to) {
+			end = to
+		}
+		days, err := c.statistics(ctx, bbox, start, end, "P1D")
+		if err != nil {
+			return nil, err
+		}
+		observations = append(observations, days...)
+	}
+	return observations, nil
+}
+
+// statistics runs a Statistical API request over bbox, returning the
+// intervals (an ISO 8601 duration, e.g. "P1D") with enough clear pixels
+func (c *SentinelHubClient) statistics(ctx context.Context, bbox BBox, from, to time.Time, interval string) ([]VegetationObservation, error) {
+	token, err := c.accessToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	request := map[string]interface{}{
+		"input": map[string]interface{}{
+			"bounds": map[string]interface{}{
+				"bbox":       []float64{bbox.MinLng, bbox.MinLat, bbox.MaxLng, bbox.MaxLat},
+				"properties": map[string]string{"crs": "http://www.opengis.net/def/crs/OGC/1.3/CRS84"},
+			},
+			"data": []map[string]interface{}{{
+				"type":       "sentinel-2-l2a",
+				"dataFilter": map[string]interface{}{"maxCloudCoverage": 50},
+			}},
+		},
+		"aggregation": map[string]interface{}{
+			"timeRange": map[string]string{
+				"from": from.UTC().Format(time.RFC3339),
+				"to":   to.UTC().Format(time.RFC3339),
+			},
+			"aggregationInterval": map[string]string{"of": interval, "lastIntervalBehavior": "SHORTEN"},
+			"evalscript":          sentinelHubEvalscript,
+			"resx":                sentinelHubResolution,
+			"resy":                sentinelHubResolution,
+		},
+	}
+	body, err := json.Marshal(request)
+	if err != nil {
+		return nil, fmt.Errorf("encoding request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/api/v1/statistics", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetching statistics: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("statistics API returned %d: %s", resp.StatusCode, string(body))
+	}
+
+	type bandStats struct {
+		Bands map[string]struct {
+			Stats struct {
+				Mean        interface{} `json:"mean"` // "NaN" when every pixel is masked
+				SampleCount int         `json:"sampleCount"`
+				NoDataCount int         `json:"noDataCount"`
+			} `json:"stats"`
+		} `json:"bands"`
+	}
+	var result struct {
+		Data []struct {
+			Interval struct {
+				From time.Time `json:"from"`
+			} `json:"interval"`
+			Outputs map[string]bandStats `json:"outputs"`
+			Error   *struct {
+				Type string `json:"type"`
+			} `json:"error"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("decoding statistics: %w", err)
+	}
+
+	var intervals []VegetationObservation
+	for _, d := range result.Data {
+		if d.Error != nil {
+			continue
+		}
+		ndvi, ok1 := d.Outputs["ndvi"].Bands["B0"]
+		woody, ok2 := d.Outputs["woody"].Bands["B0"]
+		ndviMean, ok3 := ndvi.Stats.Mean.(float64)
+		woodyMean, ok4 := woody.Stats.Mean.(float64)
+		if !ok1 || !ok2 || !ok3 || !ok4 || ndvi.Stats.SampleCount == 0 {
+			continue
+		}
+		if float64(ndvi.Stats.NoDataCount)/float64(ndvi.Stats.SampleCount) > sentinelHubMaxNoData {
+			continue
+		}
+		intervals = append(intervals, VegetationObservation{Date: d.Interval.From, MeanNDVI: ndviMean, WoodyCover: woodyMean})
+	}
+	return intervals, nil
+}
+
+// accessToken returns a cached OAuth token, fetching a new one with the
+// client credentials when it's about to expire
+func (c *SentinelHubClient) accessToken(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.token != "" && time.Now().Before(c.tokenExpiry) {
+		return c.token, nil
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "client_credentials")
+	form.Set("client_id", c.clientID)
+	form.Set("client_secret", c.clientSecret)
+
+	req, err := http.NewRequestWithContext(ctx, "POST", c.tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("creating token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("fetching token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("token endpoint returned %d: %s", resp.StatusCode, string(body))
+	}
+	var token struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return "", fmt.Errorf("decoding token: %w", err)
+	}
+
+	// Renew a minute early so a token doesn't expire mid-request
+	c.token = token.AccessToken
+	c.tokenExpiry = time.Now().Add(time.Duration(token.ExpiresIn)*time.Second - time.Minute)
+	return c.token, nil
+}
@@ -0,0 +1,122 @@
+package geo
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Boundary is a suburb, locality or local government area polygon read from
+// an ABS GeoJSON export
+type Boundary struct {
+	Code     string
+	Name     string
+	State    string
+	Geometry GeoJSONGeometry
+}
+
+// ReadSuburbBoundaries reads the Polygon and MultiPolygon features of an
+// ABS SAL GeoJSON export in one state (e.g. "New South Wales"; "" for all).
+// Attribute names vary by ASGS edition (SAL_CODE21, SAL_CODE_2021, and
+// SSC_CODE16 for the older State Suburbs), so they're matched by prefix.
+func ReadSuburbBoundaries(r io.Reader, state string) ([]Boundary, error) {
+	return readBoundaries(r, state, []string{"sal_code", "ssc_code"}, []string{"sal_name", "ssc_name"})
+}
+
+// ReadLGABoundaries reads the Polygon and MultiPolygon features of an ABS
+// Local Government Areas (LGA) GeoJSON export in one state, as
+// ReadSuburbBoundaries does
+func ReadLGABoundaries(r io.Reader, state string) ([]Boundary, error) {
+	return readBoundaries(r, state, []string{"lga_code"}, []string{"lga_name"})
+}
+
+// ReadPostcodeBoundaries reads the Polygon and MultiPolygon features of an
+// ABS Postal Areas (POA) GeoJSON export. Postal areas have no state, so
+// every one is read; a boundary's Code and Name are both its postcode.
+func ReadPostcodeBoundaries(r io.Reader) ([]Boundary, error) {
+	return readBoundaries(r, "", []string{"poa_code"}, []string{"poa_name", "poa_code"})
+}
+
+// SuburbName returns a SAL boundary's name as listings write it, without
+// the state ABS adds to tell same-named suburbs apart ("Richmond (NSW)")
+func SuburbName(name string) string {
+	if i := strings.LastIndex(name, " ("); i > 0 && strings.HasSuffix(name, ")") {
+		return name[:i]
+	}
+	return name
+}
+
+// suburbAbbreviations are the abbreviations listings use in suburb names,
+// by the word ABS spells out
+var suburbAbbreviations = map[string]string{
+	"MT": "MOUNT", "ST": "SAINT", "PT": "POINT", "NTH": "NORTH", "STH": "SOUTH", "UPR": "UPPER", "LWR": "LOWER",
+}
+
+// SameSuburb reports whether two spellings of a suburb name are the same
+// suburb, ignoring case, punctuation and common abbreviations ("Mt
+// Victoria", "MOUNT VICTORIA")
+func SameSuburb(a, b string) bool {
+	return suburbKey(a) == suburbKey(b)
+}
+
+func suburbKey(name string) string {
+	words := strings.FieldsFunc(strings.ToUpper(name), func(r rune) bool {
+		return r == ' ' || r == '-' || r == '.' || r == '\''
+	})
+	for i, w := range words {
+		if full, ok := suburbAbbreviations[w]; ok {
+			words[i] = full
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+// readBoundaries reads the polygons of an ABS GeoJSON export in one state,
+// with codes and names from the attributes with the given prefixes
+func readBoundaries(r io.Reader, state string, codePrefixes, namePrefixes []string) ([]Boundary, error) {
+	var fc GeoJSONFeatureCollection
+	if err := json.NewDecoder(r).Decode(&fc); err != nil {
+		return nil, fmt.Errorf("failed to parse GeoJSON: %w", err)
+	}
+
+	var boundaries []Boundary
+	for _, f := range fc.Features {
+		if f.Geometry.Type != "Polygon" && f.Geometry.Type != "MultiPolygon" {
+			continue // ABS exports have null geometry for "No usual address" areas
+		}
+		b := Boundary{
+			Code:     attribute(f.Properties, codePrefixes...),
+			Name:     attribute(f.Properties, namePrefixes...),
+			State:    attribute(f.Properties, "ste_name", "state_name"),
+			Geometry: f.Geometry,
+		}
+		if b.Code == "" || b.Name == "" {
+			continue
+		}
+		if state != "" && !strings.EqualFold(b.State, state) {
+			continue
+		}
+		boundaries = append(boundaries, b)
+	}
+	return boundaries, nil
+}
+
+// attribute returns the first feature property whose name starts with one
+// of prefixes, ignoring case, as a string
+func attribute(props map[string]interface{}, prefixes ...string) string {
+	for _, prefix := range prefixes {
+		for key, v := range props {
+			if !strings.HasPrefix(strings.ToLower(key), prefix) || v == nil {
+				continue
+			}
+			switch v := v.(type) {
+			case string:
+				return strings.TrimSpace(v)
+			case float64:
+				return fmt.Sprintf("%.0f", v)
+			}
+		}
+	}
+	return ""
+}
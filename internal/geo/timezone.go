@@ -0,0 +1,17 @@
+package geo
+
+import (
+	"time"
+	_ "time/tzdata" // Embedded so the zone loads on servers without zoneinfo
+)
+
+// SydneyTime is the time zone listing times (inspections, auctions) are given in
+var SydneyTime = mustLoadLocation("Australia/Sydney")
+
+func mustLoadLocation(name string) *time.Location {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		panic(err)
+	}
+	return loc
+}
@@ -0,0 +1,84 @@
+package geo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// ErrValhallaUnavailable means Valhalla couldn't be reached or isn't serving
+// requests (yet), so every route will fail until it's back
+var ErrValhallaUnavailable = errors.New("valhalla unavailable")
+
+// ErrNoRoute means Valhalla is up but found no route between the locations,
+// e.g. one is on an island or too far from any road
+var ErrNoRoute = errors.New("no route found")
+
+// noRouteCodes are the Valhalla error codes for locations that can't be
+// routed between: unconnected regions (170), no road near a location (171),
+// no path (442, 443)
+var noRouteCodes = map[int]bool{170: true, 171: true, 442: true, 443: true}
+
+// valhallaRequestError wraps a request that got no response from Valhalla
+func valhallaRequestError(api string, err error) error {
+	return fmt.Errorf("%s request failed: %w: %w", api, ErrValhallaUnavailable, err)
+}
+
+// valhallaResponseError classifies a non-200 Valhalla response
+func valhallaResponseError(api string, status int, body []byte) error {
+	if status >= 500 {
+		return fmt.Errorf("%s API error %d: %w: %s", api, status, ErrValhallaUnavailable, string(body))
+	}
+
+	var valhallaErr struct {
+		Code    int    `json:"error_code"`
+		Message string `json:"error"`
+	}
+	if json.Unmarshal(body, &valhallaErr) == nil && noRouteCodes[valhallaErr.Code] {
+		return fmt.Errorf("%w: %s (code %d)", ErrNoRoute, valhallaErr.Message, valhallaErr.Code)
+	}
+	return fmt.Errorf("%s API error %d: %s", api, status, string(body))
+}
+
+// Status checks that Valhalla is up. Any response short of a server error
+// counts, as versions before 3.1 have no /status endpoint.
+func (r *Router) Status(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", r.baseURL+"/status", nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("User-Agent", "FarmSearch/1.0")
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return valhallaRequestError("status", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode >= 500 {
+		return fmt.Errorf("status API error %d: %w", resp.StatusCode, ErrValhallaUnavailable)
+	}
+	return nil
+}
+
+// WaitUntilReady polls Status every interval until Valhalla is up, giving up
+// with the last error after wait (or at once if wait is 0). A freshly
+// started container takes a while to load its tiles.
+func (r *Router) WaitUntilReady(ctx context.Context, wait, interval time.Duration) error {
+	deadline := time.Now().Add(wait)
+	for {
+		err := r.Status(ctx)
+		if err == nil || !time.Now().Add(interval).Before(deadline) {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(interval):
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package geo
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+)
+
+// WoodyNDVI is the NDVI over which a pixel is counted as woody vegetation
+// (trees and dense shrub) rather than pasture, crop or bare ground
+const WoodyNDVI = 0.6
+
+// BBox is a bounding box in degrees
+type BBox struct {
+	MinLat, MinLng float64
+	MaxLat, MaxLng float64
+}
+
+// VegetationSnapshot summarises the vegetation in a bounding box over a time
+// window, from the cloud-free observations in it
+type VegetationSnapshot struct {
+	From, To     time.Time
+	MeanNDVI     float64
+	WoodyCover   float64 // Fraction (0-1) of pixels with NDVI over WoodyNDVI
+	Observations int     // Cloud-free scenes averaged
+}
+
+// VegetationObservation is the vegetation over a bounding box on one
+// cloud-free day
+type VegetationObservation struct {
+	Date       time.Time
+	MeanNDVI   float64
+	WoodyCover float64 // Fraction (0-1) of pixels with NDVI over WoodyNDVI
+}
+
+// VegetationSource is a satellite data backend that measures vegetation
+// over an area, such as Sentinel Hub's Sentinel-2 statistics. Other backends
+// (e.g. NSW imagery) can be plugged in by implementing it and adding them to
+// NewVegetationSourceFromEnv.
+type VegetationSource interface {
+	// Snapshot returns the mean vegetation over bbox between from and to.
+	// Errors if there's no cloud-free observation in the window.
+	Snapshot(ctx context.Context, bbox BBox, from, to time.Time) (*VegetationSnapshot, error)
+	// Observations returns each cloud-free day's vegetation over bbox
+	// between from and to, oldest first
+	Observations(ctx context.Context, bbox BBox, from, to time.Time) ([]VegetationObservation, error)
+}
+
+// NewVegetationSourceFromEnv creates the vegetation backend VEGETATION_SOURCE
+// names: "sentinelhub" (the default) or "copernicus" (Sentinel Hub's APIs on
+// the Copernicus Data Space Ecosystem), configured by the SENTINELHUB_* vars,
+// or "none". Returns nil without an error if the backend has no credentials.
+func NewVegetationSourceFromEnv() (VegetationSource, error) {
+	var client *SentinelHubClient
+	switch name := os.Getenv("VEGETATION_SOURCE"); name {
+	case "", "sentinelhub":
+		client = newSentinelHubClientFromEnv(sentinelHubURL, sentinelHubTokenURL)
+	case "copernicus":
+		client = newSentinelHubClientFromEnv(copernicusURL, copernicusTokenURL)
+	case "none":
+	default:
+		return nil, fmt.Errorf("unknown VEGETATION_SOURCE %q (want sentinelhub, copernicus or none)", name)
+	}
+	if client == nil {
+		return nil, nil // A nil *SentinelHubClient would make a non-nil interface
+	}
+	return client, nil
+}
+
+// AreaBBox returns the bounding box of areas' polygons taken together; ok is
+// false if they're all empty
+func AreaBBox(areas ...*Area) (bbox BBox, ok bool) {
+	for _, a := range areas {
+		if a.Empty() {
+			continue
+		}
+		if !ok {
+			bbox = BBox{MinLat: a.minLat, MinLng: a.minLng, MaxLat: a.maxLat, MaxLng: a.maxLng}
+			ok = true
+			continue
+		}
+		bbox.MinLat = min(bbox.MinLat, a.minLat)
+		bbox.MinLng = min(bbox.MinLng, a.minLng)
+		bbox.MaxLat = max(bbox.MaxLat, a.maxLat)
+		bbox.MaxLng = max(bbox.MaxLng, a.maxLng)
+	}
+	return bbox, ok
+}
+
+// minNDVIMonths is how many calendar months need observations for an
+// NDVISummary, so a run of cloudy months doesn't skew the range
+const minNDVIMonths = 9
+
+// NDVISummary is the seasonal pattern of NDVI over an area: the mean of
+// each calendar month over the years observed, their average and range
+type NDVISummary struct {
+	Monthly       [12]*float64 // January first; nil for months never observed
+	Mean          float64      // Average of the monthly means
+	SeasonalRange float64      // Greenest month's mean less the brownest's
+	Observations  int
+}
+
+// SummariseNDVI groups observations by calendar month into an NDVISummary;
+// ok is false if fewer than minNDVIMonths months were observed
+func SummariseNDVI(observations []VegetationObservation) (summary NDVISummary, ok bool) {
+	var sums [12]float64
+	var counts [12]int
+	for _, o := range observations {
+		m := o.Date.Month() - 1
+		sums[m] += o.MeanNDVI
+		counts[m]++
+	}
+
+	months := 0
+	lo, hi := 0.0, 0.0
+	for m := range sums {
+		if counts[m] == 0 {
+			continue
+		}
+		mean := sums[m] / float64(counts[m])
+		summary.Monthly[m] = &mean
+		summary.Mean += mean
+		if months == 0 || mean < lo {
+			lo = mean
+		}
+		if months == 0 || mean > hi {
+			hi = mean
+		}
+		months++
+	}
+	if months < minNDVIMonths {
+		return NDVISummary{}, false
+	}
+	summary.Mean /= float64(months)
+	summary.SeasonalRange = hi - lo
+	summary.Observations = len(observations)
+	return summary, true
+}
@@ -0,0 +1,100 @@
+package geo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const what3wordsURL = "https://api.what3words.com/v3/convert-to-3wa"
+
+// What3WordsClient converts points to what3words addresses. A point's
+// address never changes, so each is only fetched once per process.
+type What3WordsClient struct {
+	httpClient *http.Client
+	baseURL    string
+	apiKey     string
+
+	mu    sync.Mutex
+	cache map[[2]float64]What3Words
+}
+
+// What3Words is a what3words address and its map link
+type What3Words struct {
+	Words string // e.g. "filled.count.soap"
+	URL   string // e.g. "https://w3w.co/filled.count.soap"
+}
+
+// NewWhat3WordsClient creates a what3words API client, or returns nil
+// without an API key
+func NewWhat3WordsClient(apiKey string) *What3WordsClient {
+	if apiKey == "" {
+		return nil
+	}
+	return &What3WordsClient{
+		httpClient: &http.Client{Timeout: 5 * time.Second},
+		baseURL:    what3wordsURL,
+		apiKey:     apiKey,
+		cache:      make(map[[2]float64]What3Words),
+	}
+}
+
+// Convert returns the what3words address of the 3 m square containing a
+// point
+func (c *What3WordsClient) Convert(ctx context.Context, lat, lng float64) (What3Words, error) {
+	key := [2]float64{lat, lng}
+	c.mu.Lock()
+	cached, ok := c.cache[key]
+	c.mu.Unlock()
+	if ok {
+		return cached, nil
+	}
+
+	params := url.Values{}
+	params.Set("coordinates", strconv.FormatFloat(lat, 'f', 6, 64)+","+strconv.FormatFloat(lng, 'f', 6, 64))
+	params.Set("language", "en")
+	params.Set("format", "json")
+	params.Set("key", c.apiKey)
+
+	req, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+"?"+params.Encode(), nil)
+	if err != nil {
+		return What3Words{}, fmt.Errorf("creating request: %w", err)
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return What3Words{}, fmt.Errorf("fetching what3words address: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Words string `json:"words"`
+		Map   string `json:"map"`
+		Error *struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return What3Words{}, fmt.Errorf("decoding response (status %d): %w", resp.StatusCode, err)
+	}
+	if result.Error != nil {
+		return What3Words{}, fmt.Errorf("what3words %s: %s", result.Error.Code, result.Error.Message)
+	}
+	if resp.StatusCode != http.StatusOK || result.Words == "" {
+		return What3Words{}, fmt.Errorf("what3words returned %d with no address", resp.StatusCode)
+	}
+
+	w := What3Words{Words: result.Words, URL: result.Map}
+	if w.URL == "" {
+		w.URL = "https://w3w.co/" + result.Words
+	}
+	c.mu.Lock()
+	c.cache[key] = w
+	c.mu.Unlock()
+	return w, nil
+}
@@ -0,0 +1,243 @@
+// Package gnaf reads G-NAF, the Geocoded National Address File, and matches
+// listing addresses to its records. Releases are published quarterly at
+// https://data.gov.au/data/dataset/geocoded-national-address-file-g-naf as a
+// zip of pipe-separated (PSV) files, one per table and state.
+package gnaf
+
+import (
+	"archive/zip"
+	"bufio"
+	"database/sql"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"farm-search/internal/models"
+)
+
+// street is a STREET_LOCALITY row
+type street struct {
+	name, streetType, suffix string
+}
+
+// Read reads a state's current, principal addresses from a G-NAF release
+// (the zip, or a directory it was extracted to), keeping those in the
+// localities keep accepts (every locality if keep is nil). Localities and
+// street names are upper case, as G-NAF has them; a street's type is in full
+// ("LANE"). Addresses without a default geocode have no coordinates.
+func Read(path, state string, keep func(locality string) bool) ([]models.GNAFAddress, error) {
+	state = strings.ToUpper(state)
+
+	// Localities, then their streets, then the addresses on them, then where
+	// those are: each table only needs the rows the one before kept
+	localities := make(map[string]string)
+	err := readTable(path, state, "LOCALITY", func(row func(string) string) error {
+		name := row("LOCALITY_NAME")
+		if row("DATE_RETIRED") == "" && (keep == nil || keep(name)) {
+			localities[row("LOCALITY_PID")] = name
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(localities) == 0 {
+		return nil, nil
+	}
+
+	streets := make(map[string]street)
+	err = readTable(path, state, "STREET_LOCALITY", func(row func(string) string) error {
+		if _, ok := localities[row("LOCALITY_PID")]; ok && row("DATE_RETIRED") == "" {
+			streets[row("STREET_LOCALITY_PID")] = street{row("STREET_NAME"), row("STREET_TYPE_CODE"), row("STREET_SUFFIX_CODE")}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var addresses []models.GNAFAddress
+	index := make(map[string]int)
+	err = readTable(path, state, "ADDRESS_DETAIL", func(row func(string) string) error {
+		locality, ok := localities[row("LOCALITY_PID")]
+		// Aliases and secondary addresses (flats, units) duplicate a
+		// principal one
+		if !ok || row("DATE_RETIRED") != "" || row("ALIAS_PRINCIPAL") == "A" || row("PRIMARY_SECONDARY") == "S" {
+			return nil
+		}
+		s := streets[row("STREET_LOCALITY_PID")]
+		a := models.GNAFAddress{
+			PID:           row("ADDRESS_DETAIL_PID"),
+			NumberFirst:   nullString(row("NUMBER_FIRST_PREFIX") + row("NUMBER_FIRST") + row("NUMBER_FIRST_SUFFIX")),
+			NumberLast:    nullString(row("NUMBER_LAST_PREFIX") + row("NUMBER_LAST") + row("NUMBER_LAST_SUFFIX")),
+			LotNumber:     nullString(row("LOT_NUMBER_PREFIX") + row("LOT_NUMBER") + row("LOT_NUMBER_SUFFIX")),
+			StreetName:    nullString(s.name),
+			StreetType:    nullString(s.streetType),
+			Locality:      locality,
+			State:         state,
+			Postcode:      nullString(row("POSTCODE")),
+			LegalParcelID: nullString(row("LEGAL_PARCEL_ID")),
+		}
+		a.Address = format(a, s.suffix)
+		index[a.PID] = len(addresses)
+		addresses = append(addresses, a)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = readTable(path, state, "ADDRESS_DEFAULT_GEOCODE", func(row func(string) string) error {
+		i, ok := index[row("ADDRESS_DETAIL_PID")]
+		if !ok || row("DATE_RETIRED") != "" {
+			return nil
+		}
+		lat, err1 := strconv.ParseFloat(row("LATITUDE"), 64)
+		lng, err2 := strconv.ParseFloat(row("LONGITUDE"), 64)
+		if err1 == nil && err2 == nil {
+			addresses[i].Latitude = sql.NullFloat64{Float64: lat, Valid: true}
+			addresses[i].Longitude = sql.NullFloat64{Float64: lng, Valid: true}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return addresses, nil
+}
+
+// format writes an address the way G-NAF's own views do, e.g. "289 SCOTTS
+// LANE, CURRABUBULA NSW 2342" or "LOT 2 OLD BATHURST ROAD, ..."
+func format(a models.GNAFAddress, streetSuffix string) string {
+	var parts []string
+	switch {
+	case a.NumberFirst.Valid && a.NumberLast.Valid:
+		parts = append(parts, a.NumberFirst.String+"-"+a.NumberLast.String)
+	case a.NumberFirst.Valid:
+		parts = append(parts, a.NumberFirst.String)
+	case a.LotNumber.Valid:
+		parts = append(parts, "LOT "+a.LotNumber.String)
+	}
+	for _, s := range []string{a.StreetName.String, a.StreetType.String, streetSuffix} {
+		if s != "" {
+			parts = append(parts, s)
+		}
+	}
+	place := a.Locality + " " + a.State
+	if a.Postcode.Valid {
+		place += " " + a.Postcode.String
+	}
+	if len(parts) == 0 {
+		return place
+	}
+	return strings.Join(parts, " ") + ", " + place
+}
+
+// readTable calls fn for each row of a state's table, e.g.
+// NSW_ADDRESS_DETAIL_psv.psv, with a lookup of the row's columns by name
+func readTable(path, state, table string, fn func(row func(string) string) error) error {
+	name := strings.ToUpper(state + "_" + table + "_psv.psv")
+	r, err := openTable(path, name)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	if !scanner.Scan() {
+		if err := scanner.Err(); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		return fmt.Errorf("%s is empty", name)
+	}
+	cols := make(map[string]int)
+	for i, col := range strings.Split(strings.TrimPrefix(scanner.Text(), "\ufeff"), "|") {
+		cols[strings.ToUpper(strings.TrimSpace(col))] = i
+	}
+
+	var fields []string
+	row := func(col string) string {
+		if i, ok := cols[col]; ok && i < len(fields) {
+			return strings.TrimSpace(fields[i])
+		}
+		return ""
+	}
+	for scanner.Scan() {
+		fields = strings.Split(scanner.Text(), "|")
+		if err := fn(row); err != nil {
+			return err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	return nil
+}
+
+// openTable opens the file called name (ignoring case) anywhere in a G-NAF
+// zip or directory
+func openTable(path, name string) (io.ReadCloser, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+
+	if info.IsDir() {
+		var found string
+		err := filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !d.IsDir() && strings.EqualFold(d.Name(), name) {
+				found = p
+				return fs.SkipAll
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to search %s: %w", path, err)
+		}
+		if found == "" {
+			return nil, fmt.Errorf("no %s in %s", name, path)
+		}
+		return os.Open(found)
+	}
+
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open zip %s: %w", path, err)
+	}
+	for _, f := range zr.File {
+		if strings.EqualFold(filepath.Base(f.Name), name) {
+			rc, err := f.Open()
+			if err != nil {
+				zr.Close()
+				return nil, fmt.Errorf("failed to open %s in %s: %w", f.Name, path, err)
+			}
+			return zipEntry{rc, zr}, nil
+		}
+	}
+	zr.Close()
+	return nil, fmt.Errorf("no %s in %s", name, path)
+}
+
+// zipEntry is a file in a zip, closing the zip with it
+type zipEntry struct {
+	io.ReadCloser
+	zip *zip.ReadCloser
+}
+
+func (e zipEntry) Close() error {
+	e.ReadCloser.Close()
+	return e.zip.Close()
+}
+
+// nullString returns a valid NullString for non-empty strings
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
@@ -0,0 +1,115 @@
+package gnaf
+
+import (
+	"strconv"
+	"strings"
+
+	"farm-search/internal/geo"
+	"farm-search/internal/models"
+)
+
+// How a listing was matched to a G-NAF address
+const (
+	MatchNumber  = "number"  // Same street and house number
+	MatchLot     = "lot"     // Same street and lot number
+	MatchNearest = "nearest" // Same street, nearest address to the listing's coordinates
+)
+
+// nearestMatchKm is how far the nearest address on a listing's street may be
+// from its coordinates for a MatchNearest
+const nearestMatchKm = 1.0
+
+// Listing is what matching needs from a property
+type Listing struct {
+	Address  string
+	Lat, Lng float64
+	HasCoord bool
+}
+
+// Match finds the G-NAF address a listing is at among the addresses in its
+// locality: the one on the same street with its house (or lot) number, or
+// failing a number, the nearest one on that street to the listing's
+// coordinates (within 1 km). The street is matched by name, preferring the
+// same road type since listings mix them up. ok is false if there's no
+// match.
+func Match(l Listing, candidates []models.GNAFAddress) (match models.GNAFAddress, kind string, ok bool) {
+	street, parsed := geo.ParseStreet(l.Address)
+	if !parsed {
+		return match, "", false
+	}
+
+	var onStreet, sameType []models.GNAFAddress
+	for _, c := range candidates {
+		if !c.StreetName.Valid || !street.NameMatches(c.StreetName.String) {
+			continue
+		}
+		onStreet = append(onStreet, c)
+		if c.StreetType.String == street.Type {
+			sameType = append(sameType, c)
+		}
+	}
+	if len(sameType) > 0 {
+		onStreet = sameType
+	}
+	if len(onStreet) == 0 {
+		return match, "", false
+	}
+
+	var numbered []models.GNAFAddress
+	kind = MatchNearest
+	switch {
+	case street.Number != "":
+		for _, c := range onStreet {
+			if hasNumber(c, street.Number) {
+				numbered = append(numbered, c)
+			}
+		}
+		kind = MatchNumber
+	case street.Lot != "":
+		for _, c := range onStreet {
+			if strings.EqualFold(c.LotNumber.String, street.Lot) {
+				numbered = append(numbered, c)
+			}
+		}
+		kind = MatchLot
+	}
+	if len(numbered) == 1 || len(numbered) > 1 && !l.HasCoord {
+		return numbered[0], kind, true
+	}
+	if len(numbered) > 1 {
+		onStreet = numbered
+	} else {
+		kind = MatchNearest
+	}
+
+	// Several at that number (a street in two parts, say), or no number to
+	// go by: the nearest
+	if !l.HasCoord {
+		return match, "", false
+	}
+	bestKm := nearestMatchKm
+	for _, c := range onStreet {
+		if !c.Latitude.Valid || !c.Longitude.Valid {
+			continue
+		}
+		if km := geo.Haversine(l.Lat, l.Lng, c.Latitude.Float64, c.Longitude.Float64); km <= bestKm {
+			match, bestKm, ok = c, km, true
+		}
+	}
+	return match, kind, ok
+}
+
+// hasNumber reports whether an address is at a house number, itself or in
+// its range ("12-14")
+func hasNumber(a models.GNAFAddress, number string) bool {
+	if strings.EqualFold(a.NumberFirst.String, number) {
+		return true
+	}
+	n, err := strconv.Atoi(number)
+	if err != nil || !a.NumberLast.Valid {
+		return false
+	}
+	first, err1 := strconv.Atoi(a.NumberFirst.String)
+	last, err2 := strconv.Atoi(a.NumberLast.String)
+	return err1 == nil && err2 == nil && first <= n && n <= last
+}
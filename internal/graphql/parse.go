@@ -0,0 +1,371 @@
+// Package graphql implements the subset of GraphQL the API's /api/graphql
+// endpoint needs: a single query operation of fields with arguments,
+// aliases, variables and nested selections, resolved by projecting the Go
+// values the REST handlers already return. Fragments, directives, mutations
+// and introspection aren't supported.
+package graphql
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Field is one selected field: name(args) { selections }, under alias if given
+type Field struct {
+	Alias      string
+	Name       string
+	Args       map[string]Value
+	Selections []*Field
+}
+
+// Key returns the name the field's value is returned under
+func (f *Field) Key() string {
+	if f.Alias != "" {
+		return f.Alias
+	}
+	return f.Name
+}
+
+// Value is an argument value: a literal, or a variable reference
+type Value struct {
+	Variable string      // Set for $name
+	Literal  interface{} // int64, float64, string, bool, nil, []interface{} or an enum value's name
+}
+
+// Resolve returns the value, looking variables up in vars
+func (v Value) Resolve(vars map[string]interface{}) (interface{}, error) {
+	if v.Variable == "" {
+		return v.Literal, nil
+	}
+	value, ok := vars[v.Variable]
+	if !ok {
+		return nil, fmt.Errorf("variable $%s is not set", v.Variable)
+	}
+	return value, nil
+}
+
+// Parse parses a query document with one query operation, returning its
+// top-level selections
+func Parse(query string) ([]*Field, error) {
+	p := &parser{src: query}
+	p.next()
+
+	if p.tok == "query" {
+		p.next()
+		if p.kind == tokName {
+			p.next() // Operation name
+		}
+		if p.tok == "(" {
+			if err := p.skipVariableDefinitions(); err != nil {
+				return nil, err
+			}
+		}
+	} else if p.kind == tokName {
+		return nil, fmt.Errorf("only queries are supported, not %s", p.tok)
+	}
+
+	fields, err := p.selectionSet()
+	if err != nil {
+		return nil, err
+	}
+	if p.kind != tokEOF {
+		return nil, p.errorf("expected end of query, found %q (only one operation is supported)", p.tok)
+	}
+	return fields, nil
+}
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokPunct
+	tokName
+	tokInt
+	tokFloat
+	tokString
+)
+
+type parser struct {
+	src  string
+	pos  int
+	tok  string
+	kind tokenKind
+	err  error
+}
+
+func (p *parser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("syntax error at %d: %s", p.pos, fmt.Sprintf(format, args...))
+}
+
+// next reads the next token, skipping whitespace, commas and comments
+func (p *parser) next() {
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		if c == '#' {
+			for p.pos < len(p.src) && p.src[p.pos] != '\n' {
+				p.pos++
+			}
+			continue
+		}
+		if c != ',' && !unicode.IsSpace(rune(c)) {
+			break
+		}
+		p.pos++
+	}
+	if p.pos >= len(p.src) {
+		p.tok, p.kind = "", tokEOF
+		return
+	}
+
+	start := p.pos
+	c := p.src[p.pos]
+	switch {
+	case c == '_' || unicode.IsLetter(rune(c)):
+		for p.pos < len(p.src) && (p.src[p.pos] == '_' || unicode.IsLetter(rune(p.src[p.pos])) || unicode.IsDigit(rune(p.src[p.pos]))) {
+			p.pos++
+		}
+		p.tok, p.kind = p.src[start:p.pos], tokName
+	case c == '-' || unicode.IsDigit(rune(c)):
+		p.pos++
+		p.kind = tokInt
+		for p.pos < len(p.src) {
+			d := p.src[p.pos]
+			if d == '.' || d == 'e' || d == 'E' || ((d == '+' || d == '-') && p.kind == tokFloat) {
+				p.kind = tokFloat
+			} else if !unicode.IsDigit(rune(d)) {
+				break
+			}
+			p.pos++
+		}
+		p.tok = p.src[start:p.pos]
+	case c == '"':
+		p.readString()
+	case c == '.' && strings.HasPrefix(p.src[p.pos:], "..."):
+		p.pos += 3
+		p.tok, p.kind = "...", tokPunct
+	default:
+		p.pos++
+		p.tok, p.kind = string(c), tokPunct
+	}
+}
+
+// readString reads a quoted string, unescaping it as JSON does
+func (p *parser) readString() {
+	start := p.pos
+	p.pos++
+	for p.pos < len(p.src) && p.src[p.pos] != '"' {
+		if p.src[p.pos] == '\\' {
+			p.pos++
+		}
+		p.pos++
+	}
+	if p.pos >= len(p.src) {
+		p.err = p.errorf("unterminated string")
+		p.tok, p.kind = "", tokEOF
+		return
+	}
+	p.pos++
+	s, err := strconv.Unquote(p.src[start:p.pos])
+	if err != nil {
+		p.err = p.errorf("invalid string %s", p.src[start:p.pos])
+	}
+	p.tok, p.kind = s, tokString
+}
+
+func (p *parser) expect(tok string) error {
+	if p.err != nil {
+		return p.err
+	}
+	if p.kind != tokPunct || p.tok != tok {
+		return p.errorf("expected %q, found %q", tok, p.tok)
+	}
+	p.next()
+	return nil
+}
+
+func (p *parser) name() (string, error) {
+	if p.err != nil {
+		return "", p.err
+	}
+	if p.kind != tokName {
+		return "", p.errorf("expected a name, found %q", p.tok)
+	}
+	name := p.tok
+	p.next()
+	return name, nil
+}
+
+// selectionSet parses { field ... }
+func (p *parser) selectionSet() ([]*Field, error) {
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+	var fields []*Field
+	for !(p.kind == tokPunct && p.tok == "}") {
+		if p.kind == tokPunct && p.tok == "..." {
+			return nil, p.errorf("fragments aren't supported")
+		}
+		field, err := p.field()
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, field)
+	}
+	p.next()
+	if len(fields) == 0 {
+		return nil, p.errorf("empty selection")
+	}
+	return fields, nil
+}
+
+// field parses alias: name(args) { selections }
+func (p *parser) field() (*Field, error) {
+	name, err := p.name()
+	if err != nil {
+		return nil, err
+	}
+	f := &Field{Name: name}
+	if p.kind == tokPunct && p.tok == ":" {
+		p.next()
+		if f.Name, err = p.name(); err != nil {
+			return nil, err
+		}
+		f.Alias = name
+	}
+	if p.kind == tokPunct && p.tok == "(" {
+		p.next()
+		f.Args = make(map[string]Value)
+		for !(p.kind == tokPunct && p.tok == ")") {
+			arg, err := p.name()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect(":"); err != nil {
+				return nil, err
+			}
+			if f.Args[arg], err = p.value(); err != nil {
+				return nil, err
+			}
+		}
+		p.next()
+	}
+	if p.kind == tokPunct && p.tok == "@" {
+		return nil, p.errorf("directives aren't supported")
+	}
+	if p.kind == tokPunct && p.tok == "{" {
+		if f.Selections, err = p.selectionSet(); err != nil {
+			return nil, err
+		}
+	}
+	return f, p.err
+}
+
+// value parses an argument value
+func (p *parser) value() (Value, error) {
+	if p.err != nil {
+		return Value{}, p.err
+	}
+	tok := p.tok
+	switch p.kind {
+	case tokPunct:
+		switch tok {
+		case "$":
+			p.next()
+			name, err := p.name()
+			return Value{Variable: name}, err
+		case "[":
+			p.next()
+			var list []interface{}
+			for !(p.kind == tokPunct && p.tok == "]") {
+				v, err := p.value()
+				if err != nil {
+					return Value{}, err
+				}
+				if v.Variable != "" {
+					return Value{}, p.errorf("variables in lists aren't supported")
+				}
+				list = append(list, v.Literal)
+			}
+			p.next()
+			return Value{Literal: list}, nil
+		}
+	case tokInt:
+		p.next()
+		n, err := strconv.ParseInt(tok, 10, 64)
+		if err != nil {
+			return Value{}, p.errorf("invalid integer %s", tok)
+		}
+		return Value{Literal: n}, nil
+	case tokFloat:
+		p.next()
+		f, err := strconv.ParseFloat(tok, 64)
+		if err != nil {
+			return Value{}, p.errorf("invalid number %s", tok)
+		}
+		return Value{Literal: f}, nil
+	case tokString:
+		p.next()
+		return Value{Literal: tok}, p.err
+	case tokName:
+		p.next()
+		switch tok {
+		case "true":
+			return Value{Literal: true}, nil
+		case "false":
+			return Value{Literal: false}, nil
+		case "null":
+			return Value{}, nil
+		}
+		return Value{Literal: tok}, nil // An enum value
+	}
+	return Value{}, p.errorf("expected a value, found %q", tok)
+}
+
+// skipVariableDefinitions skips ($name: Type = default, ...): variables are
+// looked up when used, and their types aren't checked
+func (p *parser) skipVariableDefinitions() error {
+	p.next()
+	for !(p.kind == tokPunct && p.tok == ")") {
+		if err := p.expect("$"); err != nil {
+			return err
+		}
+		if _, err := p.name(); err != nil {
+			return err
+		}
+		if err := p.expect(":"); err != nil {
+			return err
+		}
+		if err := p.skipType(); err != nil {
+			return err
+		}
+		if p.kind == tokPunct && p.tok == "=" {
+			p.next()
+			if _, err := p.value(); err != nil {
+				return err
+			}
+		}
+	}
+	p.next()
+	return nil
+}
+
+// skipType skips a type reference: Name, [Type], either followed by !
+func (p *parser) skipType() error {
+	if p.kind == tokPunct && p.tok == "[" {
+		p.next()
+		if err := p.skipType(); err != nil {
+			return err
+		}
+		if err := p.expect("]"); err != nil {
+			return err
+		}
+	} else if _, err := p.name(); err != nil {
+		return err
+	}
+	if p.kind == tokPunct && p.tok == "!" {
+		p.next()
+	}
+	return p.err
+}
@@ -0,0 +1,139 @@
+package graphql
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Object is a JSON object whose members keep the order they were selected in
+type Object []Member
+
+// Member is one of an Object's fields
+type Member struct {
+	Key   string
+	Value interface{}
+}
+
+// MarshalJSON writes the members in order
+func (o Object) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, m := range o {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := json.Marshal(m.Key)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(m.Value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// Project picks the selected fields out of v, a struct (or pointer to or
+// slice of structs) as the REST API would encode it: fields are named by
+// their JSON tags, and a field without a selection is returned whole, the
+// way the API returns it. Selecting a field the type doesn't have is an
+// error; nil values are null.
+func Project(v interface{}, selections []*Field) (interface{}, error) {
+	return project(reflect.ValueOf(v), selections)
+}
+
+func project(v reflect.Value, selections []*Field) (interface{}, error) {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil, nil
+		}
+		v = v.Elem()
+	}
+	if !v.IsValid() {
+		return nil, nil
+	}
+	if len(selections) == 0 {
+		return v.Interface(), nil
+	}
+
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return nil, nil
+		}
+		list := make([]interface{}, v.Len())
+		for i := range list {
+			var err error
+			if list[i], err = project(v.Index(i), selections); err != nil {
+				return nil, err
+			}
+		}
+		return list, nil
+	case reflect.Struct:
+		fields := jsonFields(v.Type())
+		obj := make(Object, 0, len(selections))
+		for _, sel := range selections {
+			if sel.Name == "__typename" {
+				obj = append(obj, Member{sel.Key(), v.Type().Name()})
+				continue
+			}
+			index, ok := fields[sel.Name]
+			if !ok {
+				return nil, fmt.Errorf("cannot query field %q on type %s", sel.Name, v.Type().Name())
+			}
+			if len(sel.Args) > 0 {
+				return nil, fmt.Errorf("field %q takes no arguments", sel.Name)
+			}
+			fv, err := v.FieldByIndexErr(index)
+			if err != nil {
+				obj = append(obj, Member{sel.Key(), nil}) // Through a nil embedded pointer
+				continue
+			}
+			value, err := project(fv, sel.Selections)
+			if err != nil {
+				return nil, err
+			}
+			obj = append(obj, Member{sel.Key(), value})
+		}
+		return obj, nil
+	}
+	return nil, fmt.Errorf("cannot select fields of %s", v.Type())
+}
+
+// jsonFields maps a struct type's JSON field names to their field indexes,
+// including the fields of embedded structs
+func jsonFields(t reflect.Type) map[string][]int {
+	fields := make(map[string][]int)
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" || (!f.IsExported() && !f.Anonymous) {
+			continue
+		}
+		ft := f.Type
+		if ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+		if f.Anonymous && name == "" && ft.Kind() == reflect.Struct {
+			for embedded, index := range jsonFields(ft) {
+				if _, ok := fields[embedded]; !ok {
+					fields[embedded] = append([]int{i}, index...)
+				}
+			}
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields[name] = []int{i}
+	}
+	return fields
+}
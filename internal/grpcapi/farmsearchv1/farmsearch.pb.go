@@ -0,0 +1,645 @@
+// Property search and detail over gRPC, with grpc-gateway bindings that
+// mirror the REST endpoints. Served by internal/grpcapi over
+// internal/service's PropertyService, as the HTTP handlers and pkg/farmsearch
+// are. After changing it, regenerate internal/grpcapi/farmsearchv1 with
+// make proto, which runs
+//
+//   protoc -I proto -I third_party/googleapis \
+//     --go_out=. --go_opt=module=farm-search \
+//     --go-grpc_out=. --go-grpc_opt=module=farm-search \
+//     --grpc-gateway_out=. --grpc-gateway_opt=module=farm-search \
+//     proto/farmsearch/v1/farmsearch.proto
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.10
+// 	protoc        (unknown)
+// source: farmsearch/v1/farmsearch.proto
+
+package farmsearchv1
+
+import (
+	_ "google.golang.org/genproto/googleapis/api/annotations"
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	structpb "google.golang.org/protobuf/types/known/structpb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type SearchPropertiesRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Filter and sort in GET /api/properties' query string syntax, e.g.
+	// "price_max=900000&land_size_min=400000&sort=-first_seen_at" (land sizes
+	// are in m²), so the filters stay defined in one place as they grow
+	Query         string `protobuf:"bytes,1,opt,name=query,proto3" json:"query,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SearchPropertiesRequest) Reset() {
+	*x = SearchPropertiesRequest{}
+	mi := &file_farmsearch_v1_farmsearch_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SearchPropertiesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SearchPropertiesRequest) ProtoMessage() {}
+
+func (x *SearchPropertiesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_farmsearch_v1_farmsearch_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SearchPropertiesRequest.ProtoReflect.Descriptor instead.
+func (*SearchPropertiesRequest) Descriptor() ([]byte, []int) {
+	return file_farmsearch_v1_farmsearch_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *SearchPropertiesRequest) GetQuery() string {
+	if x != nil {
+		return x.Query
+	}
+	return ""
+}
+
+type SearchPropertiesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Properties    []*Property            `protobuf:"bytes,1,rep,name=properties,proto3" json:"properties,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SearchPropertiesResponse) Reset() {
+	*x = SearchPropertiesResponse{}
+	mi := &file_farmsearch_v1_farmsearch_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SearchPropertiesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SearchPropertiesResponse) ProtoMessage() {}
+
+func (x *SearchPropertiesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_farmsearch_v1_farmsearch_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SearchPropertiesResponse.ProtoReflect.Descriptor instead.
+func (*SearchPropertiesResponse) Descriptor() ([]byte, []int) {
+	return file_farmsearch_v1_farmsearch_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *SearchPropertiesResponse) GetProperties() []*Property {
+	if x != nil {
+		return x.Properties
+	}
+	return nil
+}
+
+// Property is a search result (models.PropertyListItem)
+type Property struct {
+	state                  protoimpl.MessageState `protogen:"open.v1"`
+	Id                     int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Lat                    float64                `protobuf:"fixed64,2,opt,name=lat,proto3" json:"lat,omitempty"`
+	Lng                    float64                `protobuf:"fixed64,3,opt,name=lng,proto3" json:"lng,omitempty"`
+	PriceText              string                 `protobuf:"bytes,4,opt,name=price_text,json=priceText,proto3" json:"price_text,omitempty"`
+	PropertyType           string                 `protobuf:"bytes,5,opt,name=property_type,json=propertyType,proto3" json:"property_type,omitempty"`
+	Address                string                 `protobuf:"bytes,6,opt,name=address,proto3" json:"address,omitempty"`
+	Suburb                 string                 `protobuf:"bytes,7,opt,name=suburb,proto3" json:"suburb,omitempty"`
+	Source                 string                 `protobuf:"bytes,8,opt,name=source,proto3" json:"source,omitempty"`
+	DriveTimePrimary       *int32                 `protobuf:"varint,9,opt,name=drive_time_primary,json=driveTimePrimary,proto3,oneof" json:"drive_time_primary,omitempty"`
+	AskingVsLandValueRatio *float64               `protobuf:"fixed64,10,opt,name=asking_vs_land_value_ratio,json=askingVsLandValueRatio,proto3,oneof" json:"asking_vs_land_value_ratio,omitempty"`
+	Score                  *float64               `protobuf:"fixed64,11,opt,name=score,proto3,oneof" json:"score,omitempty"`
+	unknownFields          protoimpl.UnknownFields
+	sizeCache              protoimpl.SizeCache
+}
+
+func (x *Property) Reset() {
+	*x = Property{}
+	mi := &file_farmsearch_v1_farmsearch_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Property) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Property) ProtoMessage() {}
+
+func (x *Property) ProtoReflect() protoreflect.Message {
+	mi := &file_farmsearch_v1_farmsearch_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Property.ProtoReflect.Descriptor instead.
+func (*Property) Descriptor() ([]byte, []int) {
+	return file_farmsearch_v1_farmsearch_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Property) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Property) GetLat() float64 {
+	if x != nil {
+		return x.Lat
+	}
+	return 0
+}
+
+func (x *Property) GetLng() float64 {
+	if x != nil {
+		return x.Lng
+	}
+	return 0
+}
+
+func (x *Property) GetPriceText() string {
+	if x != nil {
+		return x.PriceText
+	}
+	return ""
+}
+
+func (x *Property) GetPropertyType() string {
+	if x != nil {
+		return x.PropertyType
+	}
+	return ""
+}
+
+func (x *Property) GetAddress() string {
+	if x != nil {
+		return x.Address
+	}
+	return ""
+}
+
+func (x *Property) GetSuburb() string {
+	if x != nil {
+		return x.Suburb
+	}
+	return ""
+}
+
+func (x *Property) GetSource() string {
+	if x != nil {
+		return x.Source
+	}
+	return ""
+}
+
+func (x *Property) GetDriveTimePrimary() int32 {
+	if x != nil && x.DriveTimePrimary != nil {
+		return *x.DriveTimePrimary
+	}
+	return 0
+}
+
+func (x *Property) GetAskingVsLandValueRatio() float64 {
+	if x != nil && x.AskingVsLandValueRatio != nil {
+		return *x.AskingVsLandValueRatio
+	}
+	return 0
+}
+
+func (x *Property) GetScore() float64 {
+	if x != nil && x.Score != nil {
+		return *x.Score
+	}
+	return 0
+}
+
+type GetPropertyRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetPropertyRequest) Reset() {
+	*x = GetPropertyRequest{}
+	mi := &file_farmsearch_v1_farmsearch_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetPropertyRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetPropertyRequest) ProtoMessage() {}
+
+func (x *GetPropertyRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_farmsearch_v1_farmsearch_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetPropertyRequest.ProtoReflect.Descriptor instead.
+func (*GetPropertyRequest) Descriptor() ([]byte, []int) {
+	return file_farmsearch_v1_farmsearch_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *GetPropertyRequest) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+// PropertyDetail has the detail view's core fields typed; everything else
+// GET /api/properties/:id returns (enrichment, hazards, sales, events, ...)
+// is in extra under the same JSON names, so new attributes reach clients
+// without a schema change
+type PropertyDetail struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	Id               int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	ExternalId       string                 `protobuf:"bytes,2,opt,name=external_id,json=externalId,proto3" json:"external_id,omitempty"`
+	Source           string                 `protobuf:"bytes,3,opt,name=source,proto3" json:"source,omitempty"`
+	Url              string                 `protobuf:"bytes,4,opt,name=url,proto3" json:"url,omitempty"`
+	Address          string                 `protobuf:"bytes,5,opt,name=address,proto3" json:"address,omitempty"`
+	Suburb           string                 `protobuf:"bytes,6,opt,name=suburb,proto3" json:"suburb,omitempty"`
+	State            string                 `protobuf:"bytes,7,opt,name=state,proto3" json:"state,omitempty"`
+	Postcode         string                 `protobuf:"bytes,8,opt,name=postcode,proto3" json:"postcode,omitempty"`
+	Lat              float64                `protobuf:"fixed64,9,opt,name=lat,proto3" json:"lat,omitempty"`
+	Lng              float64                `protobuf:"fixed64,10,opt,name=lng,proto3" json:"lng,omitempty"`
+	PriceMin         *int64                 `protobuf:"varint,11,opt,name=price_min,json=priceMin,proto3,oneof" json:"price_min,omitempty"`
+	PriceMax         *int64                 `protobuf:"varint,12,opt,name=price_max,json=priceMax,proto3,oneof" json:"price_max,omitempty"`
+	PriceText        string                 `protobuf:"bytes,13,opt,name=price_text,json=priceText,proto3" json:"price_text,omitempty"`
+	PropertyType     string                 `protobuf:"bytes,14,opt,name=property_type,json=propertyType,proto3" json:"property_type,omitempty"`
+	Bedrooms         *int64                 `protobuf:"varint,15,opt,name=bedrooms,proto3,oneof" json:"bedrooms,omitempty"`
+	Bathrooms        *int64                 `protobuf:"varint,16,opt,name=bathrooms,proto3,oneof" json:"bathrooms,omitempty"`
+	LandSizeSqm      *float64               `protobuf:"fixed64,17,opt,name=land_size_sqm,json=landSizeSqm,proto3,oneof" json:"land_size_sqm,omitempty"`
+	Description      string                 `protobuf:"bytes,18,opt,name=description,proto3" json:"description,omitempty"`
+	Images           []string               `protobuf:"bytes,19,rep,name=images,proto3" json:"images,omitempty"`
+	DriveTimePrimary *int32                 `protobuf:"varint,20,opt,name=drive_time_primary,json=driveTimePrimary,proto3,oneof" json:"drive_time_primary,omitempty"`
+	Tags             []string               `protobuf:"bytes,21,rep,name=tags,proto3" json:"tags,omitempty"`
+	Extra            *structpb.Struct       `protobuf:"bytes,22,opt,name=extra,proto3" json:"extra,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *PropertyDetail) Reset() {
+	*x = PropertyDetail{}
+	mi := &file_farmsearch_v1_farmsearch_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PropertyDetail) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PropertyDetail) ProtoMessage() {}
+
+func (x *PropertyDetail) ProtoReflect() protoreflect.Message {
+	mi := &file_farmsearch_v1_farmsearch_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PropertyDetail.ProtoReflect.Descriptor instead.
+func (*PropertyDetail) Descriptor() ([]byte, []int) {
+	return file_farmsearch_v1_farmsearch_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *PropertyDetail) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *PropertyDetail) GetExternalId() string {
+	if x != nil {
+		return x.ExternalId
+	}
+	return ""
+}
+
+func (x *PropertyDetail) GetSource() string {
+	if x != nil {
+		return x.Source
+	}
+	return ""
+}
+
+func (x *PropertyDetail) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+func (x *PropertyDetail) GetAddress() string {
+	if x != nil {
+		return x.Address
+	}
+	return ""
+}
+
+func (x *PropertyDetail) GetSuburb() string {
+	if x != nil {
+		return x.Suburb
+	}
+	return ""
+}
+
+func (x *PropertyDetail) GetState() string {
+	if x != nil {
+		return x.State
+	}
+	return ""
+}
+
+func (x *PropertyDetail) GetPostcode() string {
+	if x != nil {
+		return x.Postcode
+	}
+	return ""
+}
+
+func (x *PropertyDetail) GetLat() float64 {
+	if x != nil {
+		return x.Lat
+	}
+	return 0
+}
+
+func (x *PropertyDetail) GetLng() float64 {
+	if x != nil {
+		return x.Lng
+	}
+	return 0
+}
+
+func (x *PropertyDetail) GetPriceMin() int64 {
+	if x != nil && x.PriceMin != nil {
+		return *x.PriceMin
+	}
+	return 0
+}
+
+func (x *PropertyDetail) GetPriceMax() int64 {
+	if x != nil && x.PriceMax != nil {
+		return *x.PriceMax
+	}
+	return 0
+}
+
+func (x *PropertyDetail) GetPriceText() string {
+	if x != nil {
+		return x.PriceText
+	}
+	return ""
+}
+
+func (x *PropertyDetail) GetPropertyType() string {
+	if x != nil {
+		return x.PropertyType
+	}
+	return ""
+}
+
+func (x *PropertyDetail) GetBedrooms() int64 {
+	if x != nil && x.Bedrooms != nil {
+		return *x.Bedrooms
+	}
+	return 0
+}
+
+func (x *PropertyDetail) GetBathrooms() int64 {
+	if x != nil && x.Bathrooms != nil {
+		return *x.Bathrooms
+	}
+	return 0
+}
+
+func (x *PropertyDetail) GetLandSizeSqm() float64 {
+	if x != nil && x.LandSizeSqm != nil {
+		return *x.LandSizeSqm
+	}
+	return 0
+}
+
+func (x *PropertyDetail) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *PropertyDetail) GetImages() []string {
+	if x != nil {
+		return x.Images
+	}
+	return nil
+}
+
+func (x *PropertyDetail) GetDriveTimePrimary() int32 {
+	if x != nil && x.DriveTimePrimary != nil {
+		return *x.DriveTimePrimary
+	}
+	return 0
+}
+
+func (x *PropertyDetail) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+func (x *PropertyDetail) GetExtra() *structpb.Struct {
+	if x != nil {
+		return x.Extra
+	}
+	return nil
+}
+
+var File_farmsearch_v1_farmsearch_proto protoreflect.FileDescriptor
+
+const file_farmsearch_v1_farmsearch_proto_rawDesc = "" +
+	"\n" +
+	"\x1efarmsearch/v1/farmsearch.proto\x12\rfarmsearch.v1\x1a\x1cgoogle/api/annotations.proto\x1a\x1cgoogle/protobuf/struct.proto\"/\n" +
+	"\x17SearchPropertiesRequest\x12\x14\n" +
+	"\x05query\x18\x01 \x01(\tR\x05query\"S\n" +
+	"\x18SearchPropertiesResponse\x127\n" +
+	"\n" +
+	"properties\x18\x01 \x03(\v2\x17.farmsearch.v1.PropertyR\n" +
+	"properties\"\x9b\x03\n" +
+	"\bProperty\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x10\n" +
+	"\x03lat\x18\x02 \x01(\x01R\x03lat\x12\x10\n" +
+	"\x03lng\x18\x03 \x01(\x01R\x03lng\x12\x1d\n" +
+	"\n" +
+	"price_text\x18\x04 \x01(\tR\tpriceText\x12#\n" +
+	"\rproperty_type\x18\x05 \x01(\tR\fpropertyType\x12\x18\n" +
+	"\aaddress\x18\x06 \x01(\tR\aaddress\x12\x16\n" +
+	"\x06suburb\x18\a \x01(\tR\x06suburb\x12\x16\n" +
+	"\x06source\x18\b \x01(\tR\x06source\x121\n" +
+	"\x12drive_time_primary\x18\t \x01(\x05H\x00R\x10driveTimePrimary\x88\x01\x01\x12?\n" +
+	"\x1aasking_vs_land_value_ratio\x18\n" +
+	" \x01(\x01H\x01R\x16askingVsLandValueRatio\x88\x01\x01\x12\x19\n" +
+	"\x05score\x18\v \x01(\x01H\x02R\x05score\x88\x01\x01B\x15\n" +
+	"\x13_drive_time_primaryB\x1d\n" +
+	"\x1b_asking_vs_land_value_ratioB\b\n" +
+	"\x06_score\"$\n" +
+	"\x12GetPropertyRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\"\xf8\x05\n" +
+	"\x0ePropertyDetail\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x1f\n" +
+	"\vexternal_id\x18\x02 \x01(\tR\n" +
+	"externalId\x12\x16\n" +
+	"\x06source\x18\x03 \x01(\tR\x06source\x12\x10\n" +
+	"\x03url\x18\x04 \x01(\tR\x03url\x12\x18\n" +
+	"\aaddress\x18\x05 \x01(\tR\aaddress\x12\x16\n" +
+	"\x06suburb\x18\x06 \x01(\tR\x06suburb\x12\x14\n" +
+	"\x05state\x18\a \x01(\tR\x05state\x12\x1a\n" +
+	"\bpostcode\x18\b \x01(\tR\bpostcode\x12\x10\n" +
+	"\x03lat\x18\t \x01(\x01R\x03lat\x12\x10\n" +
+	"\x03lng\x18\n" +
+	" \x01(\x01R\x03lng\x12 \n" +
+	"\tprice_min\x18\v \x01(\x03H\x00R\bpriceMin\x88\x01\x01\x12 \n" +
+	"\tprice_max\x18\f \x01(\x03H\x01R\bpriceMax\x88\x01\x01\x12\x1d\n" +
+	"\n" +
+	"price_text\x18\r \x01(\tR\tpriceText\x12#\n" +
+	"\rproperty_type\x18\x0e \x01(\tR\fpropertyType\x12\x1f\n" +
+	"\bbedrooms\x18\x0f \x01(\x03H\x02R\bbedrooms\x88\x01\x01\x12!\n" +
+	"\tbathrooms\x18\x10 \x01(\x03H\x03R\tbathrooms\x88\x01\x01\x12'\n" +
+	"\rland_size_sqm\x18\x11 \x01(\x01H\x04R\vlandSizeSqm\x88\x01\x01\x12 \n" +
+	"\vdescription\x18\x12 \x01(\tR\vdescription\x12\x16\n" +
+	"\x06images\x18\x13 \x03(\tR\x06images\x121\n" +
+	"\x12drive_time_primary\x18\x14 \x01(\x05H\x05R\x10driveTimePrimary\x88\x01\x01\x12\x12\n" +
+	"\x04tags\x18\x15 \x03(\tR\x04tags\x12-\n" +
+	"\x05extra\x18\x16 \x01(\v2\x17.google.protobuf.StructR\x05extraB\f\n" +
+	"\n" +
+	"_price_minB\f\n" +
+	"\n" +
+	"_price_maxB\v\n" +
+	"\t_bedroomsB\f\n" +
+	"\n" +
+	"_bathroomsB\x10\n" +
+	"\x0e_land_size_sqmB\x15\n" +
+	"\x13_drive_time_primary2\xd2\x02\n" +
+	"\x0ePropertySearch\x12{\n" +
+	"\x10SearchProperties\x12&.farmsearch.v1.SearchPropertiesRequest\x1a'.farmsearch.v1.SearchPropertiesResponse\"\x16\x82\xd3\xe4\x93\x02\x10\x12\x0e/v1/properties\x12U\n" +
+	"\x10StreamProperties\x12&.farmsearch.v1.SearchPropertiesRequest\x1a\x17.farmsearch.v1.Property0\x01\x12l\n" +
+	"\vGetProperty\x12!.farmsearch.v1.GetPropertyRequest\x1a\x1d.farmsearch.v1.PropertyDetail\"\x1b\x82\xd3\xe4\x93\x02\x15\x12\x13/v1/properties/{id}B+Z)farm-search/internal/grpcapi/farmsearchv1b\x06proto3"
+
+var (
+	file_farmsearch_v1_farmsearch_proto_rawDescOnce sync.Once
+	file_farmsearch_v1_farmsearch_proto_rawDescData []byte
+)
+
+func file_farmsearch_v1_farmsearch_proto_rawDescGZIP() []byte {
+	file_farmsearch_v1_farmsearch_proto_rawDescOnce.Do(func() {
+		file_farmsearch_v1_farmsearch_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_farmsearch_v1_farmsearch_proto_rawDesc), len(file_farmsearch_v1_farmsearch_proto_rawDesc)))
+	})
+	return file_farmsearch_v1_farmsearch_proto_rawDescData
+}
+
+var file_farmsearch_v1_farmsearch_proto_msgTypes = make([]protoimpl.MessageInfo, 5)
+var file_farmsearch_v1_farmsearch_proto_goTypes = []any{
+	(*SearchPropertiesRequest)(nil),  // 0: farmsearch.v1.SearchPropertiesRequest
+	(*SearchPropertiesResponse)(nil), // 1: farmsearch.v1.SearchPropertiesResponse
+	(*Property)(nil),                 // 2: farmsearch.v1.Property
+	(*GetPropertyRequest)(nil),       // 3: farmsearch.v1.GetPropertyRequest
+	(*PropertyDetail)(nil),           // 4: farmsearch.v1.PropertyDetail
+	(*structpb.Struct)(nil),          // 5: google.protobuf.Struct
+}
+var file_farmsearch_v1_farmsearch_proto_depIdxs = []int32{
+	2, // 0: farmsearch.v1.SearchPropertiesResponse.properties:type_name -> farmsearch.v1.Property
+	5, // 1: farmsearch.v1.PropertyDetail.extra:type_name -> google.protobuf.Struct
+	0, // 2: farmsearch.v1.PropertySearch.SearchProperties:input_type -> farmsearch.v1.SearchPropertiesRequest
+	0, // 3: farmsearch.v1.PropertySearch.StreamProperties:input_type -> farmsearch.v1.SearchPropertiesRequest
+	3, // 4: farmsearch.v1.PropertySearch.GetProperty:input_type -> farmsearch.v1.GetPropertyRequest
+	1, // 5: farmsearch.v1.PropertySearch.SearchProperties:output_type -> farmsearch.v1.SearchPropertiesResponse
+	2, // 6: farmsearch.v1.PropertySearch.StreamProperties:output_type -> farmsearch.v1.Property
+	4, // 7: farmsearch.v1.PropertySearch.GetProperty:output_type -> farmsearch.v1.PropertyDetail
+	5, // [5:8] is the sub-list for method output_type
+	2, // [2:5] is the sub-list for method input_type
+	2, // [2:2] is the sub-list for extension type_name
+	2, // [2:2] is the sub-list for extension extendee
+	0, // [0:2] is the sub-list for field type_name
+}
+
+func init() { file_farmsearch_v1_farmsearch_proto_init() }
+func file_farmsearch_v1_farmsearch_proto_init() {
+	if File_farmsearch_v1_farmsearch_proto != nil {
+		return
+	}
+	file_farmsearch_v1_farmsearch_proto_msgTypes[2].OneofWrappers = []any{}
+	file_farmsearch_v1_farmsearch_proto_msgTypes[4].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_farmsearch_v1_farmsearch_proto_rawDesc), len(file_farmsearch_v1_farmsearch_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   5,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_farmsearch_v1_farmsearch_proto_goTypes,
+		DependencyIndexes: file_farmsearch_v1_farmsearch_proto_depIdxs,
+		MessageInfos:      file_farmsearch_v1_farmsearch_proto_msgTypes,
+	}.Build()
+	File_farmsearch_v1_farmsearch_proto = out.File
+	file_farmsearch_v1_farmsearch_proto_goTypes = nil
+	file_farmsearch_v1_farmsearch_proto_depIdxs = nil
+}
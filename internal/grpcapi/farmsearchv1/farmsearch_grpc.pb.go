@@ -0,0 +1,229 @@
+// Property search and detail over gRPC, with grpc-gateway bindings that
+// mirror the REST endpoints. Served by internal/grpcapi over
+// internal/service's PropertyService, as the HTTP handlers and pkg/farmsearch
+// are. After changing it, regenerate internal/grpcapi/farmsearchv1 with
+// make proto, which runs
+//
+//   protoc -I proto -I third_party/googleapis \
+//     --go_out=. --go_opt=module=farm-search \
+//     --go-grpc_out=. --go-grpc_opt=module=farm-search \
+//     --grpc-gateway_out=. --grpc-gateway_opt=module=farm-search \
+//     proto/farmsearch/v1/farmsearch.proto
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: farmsearch/v1/farmsearch.proto
+
+package farmsearchv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	PropertySearch_SearchProperties_FullMethodName = "/farmsearch.v1.PropertySearch/SearchProperties"
+	PropertySearch_StreamProperties_FullMethodName = "/farmsearch.v1.PropertySearch/StreamProperties"
+	PropertySearch_GetProperty_FullMethodName      = "/farmsearch.v1.PropertySearch/GetProperty"
+)
+
+// PropertySearchClient is the client API for PropertySearch service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type PropertySearchClient interface {
+	// SearchProperties returns the canonical properties matching a filter, in
+	// its sort order, like GET /api/properties
+	SearchProperties(ctx context.Context, in *SearchPropertiesRequest, opts ...grpc.CallOption) (*SearchPropertiesResponse, error)
+	// StreamProperties is SearchProperties one property per message, for
+	// result sets too large to hold in one response (no limit returns every
+	// match, as the map does)
+	StreamProperties(ctx context.Context, in *SearchPropertiesRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Property], error)
+	// GetProperty returns a property's details, like GET /api/properties/:id.
+	// A duplicate listing's ID resolves to its canonical property. NOT_FOUND
+	// if there's no such property.
+	GetProperty(ctx context.Context, in *GetPropertyRequest, opts ...grpc.CallOption) (*PropertyDetail, error)
+}
+
+type propertySearchClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewPropertySearchClient(cc grpc.ClientConnInterface) PropertySearchClient {
+	return &propertySearchClient{cc}
+}
+
+func (c *propertySearchClient) SearchProperties(ctx context.Context, in *SearchPropertiesRequest, opts ...grpc.CallOption) (*SearchPropertiesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SearchPropertiesResponse)
+	err := c.cc.Invoke(ctx, PropertySearch_SearchProperties_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *propertySearchClient) StreamProperties(ctx context.Context, in *SearchPropertiesRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Property], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &PropertySearch_ServiceDesc.Streams[0], PropertySearch_StreamProperties_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[SearchPropertiesRequest, Property]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type PropertySearch_StreamPropertiesClient = grpc.ServerStreamingClient[Property]
+
+func (c *propertySearchClient) GetProperty(ctx context.Context, in *GetPropertyRequest, opts ...grpc.CallOption) (*PropertyDetail, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PropertyDetail)
+	err := c.cc.Invoke(ctx, PropertySearch_GetProperty_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// PropertySearchServer is the server API for PropertySearch service.
+// All implementations must embed UnimplementedPropertySearchServer
+// for forward compatibility.
+type PropertySearchServer interface {
+	// SearchProperties returns the canonical properties matching a filter, in
+	// its sort order, like GET /api/properties
+	SearchProperties(context.Context, *SearchPropertiesRequest) (*SearchPropertiesResponse, error)
+	// StreamProperties is SearchProperties one property per message, for
+	// result sets too large to hold in one response (no limit returns every
+	// match, as the map does)
+	StreamProperties(*SearchPropertiesRequest, grpc.ServerStreamingServer[Property]) error
+	// GetProperty returns a property's details, like GET /api/properties/:id.
+	// A duplicate listing's ID resolves to its canonical property. NOT_FOUND
+	// if there's no such property.
+	GetProperty(context.Context, *GetPropertyRequest) (*PropertyDetail, error)
+	mustEmbedUnimplementedPropertySearchServer()
+}
+
+// UnimplementedPropertySearchServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedPropertySearchServer struct{}
+
+func (UnimplementedPropertySearchServer) SearchProperties(context.Context, *SearchPropertiesRequest) (*SearchPropertiesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SearchProperties not implemented")
+}
+func (UnimplementedPropertySearchServer) StreamProperties(*SearchPropertiesRequest, grpc.ServerStreamingServer[Property]) error {
+	return status.Errorf(codes.Unimplemented, "method StreamProperties not implemented")
+}
+func (UnimplementedPropertySearchServer) GetProperty(context.Context, *GetPropertyRequest) (*PropertyDetail, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetProperty not implemented")
+}
+func (UnimplementedPropertySearchServer) mustEmbedUnimplementedPropertySearchServer() {}
+func (UnimplementedPropertySearchServer) testEmbeddedByValue()                        {}
+
+// UnsafePropertySearchServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to PropertySearchServer will
+// result in compilation errors.
+type UnsafePropertySearchServer interface {
+	mustEmbedUnimplementedPropertySearchServer()
+}
+
+func RegisterPropertySearchServer(s grpc.ServiceRegistrar, srv PropertySearchServer) {
+	// If the following call pancis, it indicates UnimplementedPropertySearchServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&PropertySearch_ServiceDesc, srv)
+}
+
+func _PropertySearch_SearchProperties_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SearchPropertiesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PropertySearchServer).SearchProperties(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PropertySearch_SearchProperties_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PropertySearchServer).SearchProperties(ctx, req.(*SearchPropertiesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PropertySearch_StreamProperties_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(SearchPropertiesRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(PropertySearchServer).StreamProperties(m, &grpc.GenericServerStream[SearchPropertiesRequest, Property]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type PropertySearch_StreamPropertiesServer = grpc.ServerStreamingServer[Property]
+
+func _PropertySearch_GetProperty_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetPropertyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PropertySearchServer).GetProperty(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PropertySearch_GetProperty_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PropertySearchServer).GetProperty(ctx, req.(*GetPropertyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// PropertySearch_ServiceDesc is the grpc.ServiceDesc for PropertySearch service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var PropertySearch_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "farmsearch.v1.PropertySearch",
+	HandlerType: (*PropertySearchServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "SearchProperties",
+			Handler:    _PropertySearch_SearchProperties_Handler,
+		},
+		{
+			MethodName: "GetProperty",
+			Handler:    _PropertySearch_GetProperty_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamProperties",
+			Handler:       _PropertySearch_StreamProperties_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "farmsearch/v1/farmsearch.proto",
+}
@@ -0,0 +1,206 @@
+// Package grpcapi serves property search and detail over gRPC, and over
+// HTTP through grpc-gateway, for typed clients such as a mobile companion
+// app. The service is defined in proto/farmsearch/v1/farmsearch.proto and
+// answered by the same PropertyService as the HTTP handlers.
+package grpcapi
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"farm-search/internal/geo"
+	"farm-search/internal/grpcapi/farmsearchv1"
+	"farm-search/internal/models"
+	"farm-search/internal/service"
+)
+
+// searchTimeout bounds a search, as GET /api/properties does, since a drive
+// time area filter may have to generate its isochrone
+const searchTimeout = 60 * time.Second
+
+// Server implements the PropertySearch service
+type Server struct {
+	farmsearchv1.UnimplementedPropertySearchServer
+	properties *service.PropertyService
+}
+
+// NewServer creates a Server answering from properties
+func NewServer(properties *service.PropertyService) *Server {
+	return &Server{properties: properties}
+}
+
+// Serve serves the service over gRPC on lis until it fails
+func (s *Server) Serve(lis net.Listener) error {
+	gs := grpc.NewServer()
+	farmsearchv1.RegisterPropertySearchServer(gs, s)
+	return gs.Serve(lis)
+}
+
+// Gateway returns the grpc-gateway routes (under /v1), calling the service
+// in-process. Fields keep their proto names, as the REST API's JSON does;
+// StreamProperties isn't served over HTTP.
+func (s *Server) Gateway(ctx context.Context) (http.Handler, error) {
+	mux := runtime.NewServeMux(runtime.WithMarshalerOption(runtime.MIMEWildcard, &runtime.JSONPb{
+		MarshalOptions:   protojson.MarshalOptions{UseProtoNames: true},
+		UnmarshalOptions: protojson.UnmarshalOptions{DiscardUnknown: true},
+	}))
+	if err := farmsearchv1.RegisterPropertySearchHandlerServer(ctx, mux, s); err != nil {
+		return nil, err
+	}
+	return mux, nil
+}
+
+// SearchProperties returns the properties matching a GET /api/properties
+// query string
+func (s *Server) SearchProperties(ctx context.Context, req *farmsearchv1.SearchPropertiesRequest) (*farmsearchv1.SearchPropertiesResponse, error) {
+	properties, err := s.search(ctx, req.GetQuery())
+	if err != nil {
+		return nil, err
+	}
+	resp := &farmsearchv1.SearchPropertiesResponse{Properties: make([]*farmsearchv1.Property, len(properties))}
+	for i := range properties {
+		resp.Properties[i] = propertyMessage(&properties[i])
+	}
+	return resp, nil
+}
+
+// StreamProperties is SearchProperties one property per message
+func (s *Server) StreamProperties(req *farmsearchv1.SearchPropertiesRequest, stream grpc.ServerStreamingServer[farmsearchv1.Property]) error {
+	properties, err := s.search(stream.Context(), req.GetQuery())
+	if err != nil {
+		return err
+	}
+	for i := range properties {
+		if err := stream.Send(propertyMessage(&properties[i])); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetProperty returns a property's details
+func (s *Server) GetProperty(ctx context.Context, req *farmsearchv1.GetPropertyRequest) (*farmsearchv1.PropertyDetail, error) {
+	p, err := s.properties.Detail(ctx, req.GetId())
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, status.Errorf(codes.NotFound, "property %d not found", req.GetId())
+	}
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return detailMessage(p)
+}
+
+// search parses a filter query string and lists its matches
+func (s *Server) search(ctx context.Context, query string) ([]models.PropertyListItem, error) {
+	values, err := url.ParseQuery(query)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid filter: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(ctx, searchTimeout)
+	defer cancel()
+	properties, err := s.properties.List(ctx, service.ParsePropertyFilter(values))
+	if err != nil {
+		return nil, status.Error(listErrorCode(err), err.Error())
+	}
+	return properties, nil
+}
+
+// listErrorCode returns the status code for a failed search, as
+// routingErrorStatus does for the HTTP API
+func listErrorCode(err error) codes.Code {
+	switch {
+	case errors.Is(err, geo.ErrValhallaUnavailable):
+		return codes.Unavailable
+	case errors.Is(err, geo.ErrNoRoute):
+		return codes.FailedPrecondition
+	case errors.Is(err, context.DeadlineExceeded):
+		return codes.DeadlineExceeded
+	}
+	return codes.Internal
+}
+
+func propertyMessage(p *models.PropertyListItem) *farmsearchv1.Property {
+	m := &farmsearchv1.Property{
+		Id:                     p.ID,
+		Lat:                    p.Latitude,
+		Lng:                    p.Longitude,
+		PriceText:              p.PriceText,
+		PropertyType:           p.PropertyType,
+		Address:                p.Address,
+		Suburb:                 p.Suburb,
+		Source:                 p.Source,
+		AskingVsLandValueRatio: p.AskingVsLandValue,
+		Score:                  p.Score,
+	}
+	if p.DriveTimePrimary != nil {
+		m.DriveTimePrimary = proto.Int32(int32(*p.DriveTimePrimary))
+	}
+	return m
+}
+
+// detailFields are the PropertyDetail JSON fields the message types; the
+// rest go in its extra
+var detailFields = []string{
+	"id", "external_id", "source", "url", "address", "suburb", "state", "postcode", "lat", "lng",
+	"price_min", "price_max", "price_text", "property_type", "bedrooms", "bathrooms", "land_size_sqm",
+	"description", "images", "drive_time_primary", "tags",
+}
+
+func detailMessage(p *models.PropertyDetail) (*farmsearchv1.PropertyDetail, error) {
+	m := &farmsearchv1.PropertyDetail{
+		Id:           p.ID,
+		ExternalId:   p.ExternalID,
+		Source:       p.Source,
+		Url:          p.URL,
+		Address:      p.Address,
+		Suburb:       p.Suburb,
+		State:        p.State,
+		Postcode:     p.Postcode,
+		Lat:          p.Latitude,
+		Lng:          p.Longitude,
+		PriceMin:     p.PriceMin,
+		PriceMax:     p.PriceMax,
+		PriceText:    p.PriceText,
+		PropertyType: p.PropertyType,
+		Bedrooms:     p.Bedrooms,
+		Bathrooms:    p.Bathrooms,
+		LandSizeSqm:  p.LandSizeSqm,
+		Description:  p.Description,
+		Images:       p.Images,
+		Tags:         p.Tags,
+	}
+	if p.DriveTimePrimary != nil {
+		m.DriveTimePrimary = proto.Int32(int32(*p.DriveTimePrimary))
+	}
+
+	// Everything else, under the names GET /api/properties/:id uses
+	data, err := json.Marshal(p)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	var extra map[string]interface{}
+	if err := json.Unmarshal(data, &extra); err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	for _, field := range detailFields {
+		delete(extra, field)
+	}
+	if m.Extra, err = structpb.NewStruct(extra); err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return m, nil
+}
@@ -0,0 +1,149 @@
+// Package jobs runs tool and scraper commands on cron schedules inside the
+// server, one run per task at a time.
+package jobs
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cronMacros are the shorthand schedules ParseCron accepts
+var cronMacros = map[string]string{
+	"@hourly":   "0 * * * *",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@weekly":   "0 0 * * 0",
+	"@monthly":  "0 0 1 * *",
+}
+
+// Schedule is a parsed five-field cron expression: minute, hour, day of
+// month, month and day of week (0 or 7 is Sunday)
+type Schedule struct {
+	minutes, hours, days, months, weekdays uint64 // Bit n set if n matches
+
+	// As in cron, when both days of the month and the week are restricted (the
+	// field doesn't start with *) a day matching either runs
+	anyDay, anyWeekday bool
+}
+
+// ParseCron parses a cron expression like "30 2 * * *" (02:30 daily) or
+// "0 */6 * * 1-5" (every 6 hours on weekdays), or a macro (@hourly, @daily,
+// @midnight, @weekly, @monthly). Fields take *, numbers, ranges (a-b), steps
+// (*/n, a-b/n) and lists of those (a,b).
+func ParseCron(expr string) (*Schedule, error) {
+	expr = strings.TrimSpace(expr)
+	if macro, ok := cronMacros[expr]; ok {
+		expr = macro
+	}
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron expression %q needs 5 fields (minute hour day month weekday)", expr)
+	}
+
+	s := &Schedule{anyDay: strings.HasPrefix(fields[2], "*"), anyWeekday: strings.HasPrefix(fields[4], "*")}
+	for i, f := range []struct {
+		name     string
+		min, max int
+		bits     *uint64
+	}{
+		{"minute", 0, 59, &s.minutes},
+		{"hour", 0, 23, &s.hours},
+		{"day", 1, 31, &s.days},
+		{"month", 1, 12, &s.months},
+		{"weekday", 0, 7, &s.weekdays},
+	} {
+		bits, err := parseCronField(fields[i], f.min, f.max)
+		if err != nil {
+			return nil, fmt.Errorf("cron %s %q: %w", f.name, fields[i], err)
+		}
+		*f.bits = bits
+	}
+	// Sunday is both 0 and 7
+	if s.weekdays&(1<<7) != 0 {
+		s.weekdays |= 1
+	}
+	return s, nil
+}
+
+// parseCronField returns the values a field matches as bits
+func parseCronField(field string, min, max int) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		rangePart, step := part, 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			n, err := strconv.Atoi(part[i+1:])
+			if err != nil || n < 1 {
+				return 0, fmt.Errorf("bad step %q", part[i+1:])
+			}
+			rangePart, step = part[:i], n
+		}
+
+		lo, hi := min, max
+		switch {
+		case rangePart == "*":
+		case strings.Contains(rangePart, "-"):
+			a, b, _ := strings.Cut(rangePart, "-")
+			var errA, errB error
+			lo, errA = strconv.Atoi(a)
+			hi, errB = strconv.Atoi(b)
+			if errA != nil || errB != nil {
+				return 0, fmt.Errorf("bad range %q", rangePart)
+			}
+		default:
+			n, err := strconv.Atoi(rangePart)
+			if err != nil {
+				return 0, fmt.Errorf("bad value %q", rangePart)
+			}
+			lo, hi = n, n
+			// "5/15" means from 5 to the end, every 15
+			if step > 1 {
+				hi = max
+			}
+		}
+		if lo < min || hi > max || lo > hi {
+			return 0, fmt.Errorf("%q is outside %d-%d", rangePart, min, max)
+		}
+		for n := lo; n <= hi; n += step {
+			bits |= 1 << n
+		}
+	}
+	return bits, nil
+}
+
+// maxCronSearch bounds how far ahead Next looks, for expressions no date
+// matches (e.g. the 31st of February)
+const maxCronSearch = 5 * 366 * 24 * time.Hour
+
+// Next returns the first time after t the schedule matches, to the minute, in
+// t's location; zero if none does within five years
+func (s *Schedule) Next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.Add(maxCronSearch)
+	for t.Before(limit) {
+		switch {
+		case s.months&(1<<uint(t.Month())) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+		case !s.dayMatches(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+		case s.hours&(1<<uint(t.Hour())) == 0:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+		case s.minutes&(1<<uint(t.Minute())) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// dayMatches reports whether t's day of the month and week match
+func (s *Schedule) dayMatches(t time.Time) bool {
+	day := s.days&(1<<uint(t.Day())) != 0
+	weekday := s.weekdays&(1<<uint(t.Weekday())) != 0
+	if !s.anyDay && !s.anyWeekday {
+		return day || weekday
+	}
+	return day && weekday
+}
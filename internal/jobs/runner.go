@@ -0,0 +1,293 @@
+package jobs
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+)
+
+// Task is a command run on a cron schedule, e.g.
+// {"name": "drivetimes", "cron": "30 2 * * *", "command": ["bin/tools", "drivetimes"]}
+type Task struct {
+	Name    string   `json:"name"`
+	Cron    string   `json:"cron"`              // See ParseCron; in the server's local time
+	Command []string `json:"command"`           // Program and arguments, run from the server's working directory
+	Timeout string   `json:"timeout,omitempty"` // e.g. "2h": longer runs are killed. None by default
+}
+
+// Config is a jobs file: the tasks the server runs
+type Config struct {
+	Tasks []Task `json:"tasks"`
+}
+
+// LoadConfig reads a jobs file (see scripts/jobs.example.json)
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read jobs file: %w", err)
+	}
+	var c Config
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("failed to parse jobs file %s: %w", path, err)
+	}
+	return &c, nil
+}
+
+// TaskStatus is a task's schedule and runs since the server started
+type TaskStatus struct {
+	Name      string     `json:"name"`
+	Cron      string     `json:"cron"`
+	Command   []string   `json:"command"`
+	Running   bool       `json:"running"`
+	StartedAt *time.Time `json:"started_at,omitempty"` // Of the run in progress
+	NextRunAt *time.Time `json:"next_run_at,omitempty"`
+	Runs      int        `json:"runs"`
+	Failures  int        `json:"failures"`
+	Skipped   int        `json:"skipped"` // Runs that came due while the last was still going
+	LastRun   *RunResult `json:"last_run,omitempty"`
+}
+
+// RunResult is how a finished run went
+type RunResult struct {
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+	DurationMs int64     `json:"duration_ms"`
+	Error      string    `json:"error,omitempty"` // Why it failed (exit status, timeout); empty if it succeeded
+}
+
+// task is a Task with its parsed schedule and status
+type task struct {
+	Task
+	schedule *Schedule
+	timeout  time.Duration
+
+	mu     sync.Mutex
+	status TaskStatus
+}
+
+// Runner runs tasks on their schedules. A task is never run twice at once: a
+// run that comes due while the last is still going is skipped.
+type Runner struct {
+	tasks []*task
+
+	mu  sync.Mutex
+	ctx context.Context // From Start; runs started with RunNow are killed when it's done
+}
+
+// Errors from RunNow
+var (
+	ErrUnknownTask = errors.New("no such task")
+	ErrRunning     = errors.New("task is already running")
+	ErrNotStarted  = errors.New("jobs runner isn't started")
+)
+
+// NewRunner checks a config's tasks and prepares them to run
+func NewRunner(c *Config) (*Runner, error) {
+	r := &Runner{}
+	names := make(map[string]bool)
+	for _, t := range c.Tasks {
+		if t.Name == "" {
+			return nil, fmt.Errorf("a task has no name")
+		}
+		if names[t.Name] {
+			return nil, fmt.Errorf("task %s is listed twice", t.Name)
+		}
+		names[t.Name] = true
+		if len(t.Command) == 0 {
+			return nil, fmt.Errorf("task %s has no command", t.Name)
+		}
+		schedule, err := ParseCron(t.Cron)
+		if err != nil {
+			return nil, fmt.Errorf("task %s: %w", t.Name, err)
+		}
+		var timeout time.Duration
+		if t.Timeout != "" {
+			if timeout, err = time.ParseDuration(t.Timeout); err != nil || timeout <= 0 {
+				return nil, fmt.Errorf("task %s: bad timeout %q", t.Name, t.Timeout)
+			}
+		}
+		r.tasks = append(r.tasks, &task{
+			Task:     t,
+			schedule: schedule,
+			timeout:  timeout,
+			status:   TaskStatus{Name: t.Name, Cron: t.Cron, Command: t.Command},
+		})
+	}
+	return r, nil
+}
+
+// Start runs each task on its schedule until ctx is done. Runs in progress
+// are killed then.
+func (r *Runner) Start(ctx context.Context) {
+	r.mu.Lock()
+	r.ctx = ctx
+	r.mu.Unlock()
+	for _, t := range r.tasks {
+		go t.loop(ctx)
+	}
+}
+
+// Names returns the tasks' names, in config order
+func (r *Runner) Names() []string {
+	names := make([]string, len(r.tasks))
+	for i, t := range r.tasks {
+		names[i] = t.Name
+	}
+	return names
+}
+
+// RunNow starts a run of the named task outside its schedule and returns once
+// it's started. It counts like a scheduled run, and it's refused with
+// ErrRunning rather than run twice at once.
+func (r *Runner) RunNow(name string) error {
+	r.mu.Lock()
+	ctx := r.ctx
+	r.mu.Unlock()
+	if ctx == nil {
+		return ErrNotStarted
+	}
+	for _, t := range r.tasks {
+		if t.Name != name {
+			continue
+		}
+		t.mu.Lock()
+		if t.status.Running {
+			t.mu.Unlock()
+			return ErrRunning
+		}
+		started := time.Now()
+		t.status.Running = true
+		t.status.StartedAt = &started
+		t.mu.Unlock()
+
+		log.Printf("Job %s: run requested", t.Name)
+		go t.run(ctx, started)
+		return nil
+	}
+	return ErrUnknownTask
+}
+
+// Status returns each task's schedule and runs, in config order
+func (r *Runner) Status() []TaskStatus {
+	statuses := make([]TaskStatus, len(r.tasks))
+	for i, t := range r.tasks {
+		t.mu.Lock()
+		statuses[i] = t.status
+		t.mu.Unlock()
+	}
+	return statuses
+}
+
+// loop waits for each of the task's scheduled times and starts a run
+func (t *task) loop(ctx context.Context) {
+	for {
+		next := t.schedule.Next(time.Now())
+		if next.IsZero() {
+			log.Printf("Job %s: %q never comes due; not scheduling it", t.Name, t.Cron)
+			return
+		}
+		t.mu.Lock()
+		t.status.NextRunAt = &next
+		t.mu.Unlock()
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		t.mu.Lock()
+		if t.status.Running {
+			t.status.Skipped++
+			log.Printf("Job %s: skipping the %s run, still running since %s",
+				t.Name, next.Format("15:04"), t.status.StartedAt.Format(time.RFC3339))
+			t.mu.Unlock()
+			continue
+		}
+		started := time.Now()
+		t.status.Running = true
+		t.status.StartedAt = &started
+		t.mu.Unlock()
+
+		go t.run(ctx, started)
+	}
+}
+
+// run runs the task's command once, logging its output line by line
+func (t *task) run(ctx context.Context, started time.Time) {
+	if t.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, t.timeout)
+		defer cancel()
+	}
+	log.Printf("Job %s: starting %v", t.Name, t.Command)
+	cmd := exec.CommandContext(ctx, t.Command[0], t.Command[1:]...)
+	out := &lineLogger{prefix: "Job " + t.Name + ": "}
+	cmd.Stdout = out
+	cmd.Stderr = out
+	err := cmd.Run()
+	out.flush()
+	if ctx.Err() == context.DeadlineExceeded {
+		err = fmt.Errorf("timed out after %s", t.timeout)
+	}
+
+	result := &RunResult{StartedAt: started, FinishedAt: time.Now()}
+	result.DurationMs = result.FinishedAt.Sub(started).Milliseconds()
+	if err != nil {
+		result.Error = err.Error()
+		log.Printf("Job %s: failed after %s: %v", t.Name, result.FinishedAt.Sub(started).Round(time.Second), err)
+	} else {
+		log.Printf("Job %s: done in %s", t.Name, result.FinishedAt.Sub(started).Round(time.Second))
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.status.Running = false
+	t.status.StartedAt = nil
+	t.status.Runs++
+	if err != nil {
+		t.status.Failures++
+	}
+	t.status.LastRun = result
+}
+
+// lineLogger logs what's written to it a line at a time, with a prefix
+type lineLogger struct {
+	prefix string
+	mu     sync.Mutex
+	buf    []byte
+}
+
+func (l *lineLogger) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.buf = append(l.buf, p...)
+	for {
+		i := bytes.IndexByte(l.buf, '\n')
+		if i < 0 {
+			break
+		}
+		log.Print(l.prefix + string(l.buf[:i]))
+		l.buf = l.buf[i+1:]
+	}
+	return len(p), nil
+}
+
+// flush logs a last line without a newline
+func (l *lineLogger) flush() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.buf) > 0 {
+		log.Print(l.prefix + string(l.buf))
+		l.buf = nil
+	}
+}
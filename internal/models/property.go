@@ -2,6 +2,7 @@ package models
 
 import (
 	"database/sql"
+	"encoding/json"
 	"time"
 )
 
@@ -20,15 +21,167 @@ type Property struct {
 	PriceMin     sql.NullInt64   `db:"price_min" json:"price_min"`
 	PriceMax     sql.NullInt64   `db:"price_max" json:"price_max"`
 	PriceText    sql.NullString  `db:"price_text" json:"price_text"`
-	PropertyType sql.NullString  `db:"property_type" json:"property_type"`
+	PropertyType sql.NullString  `db:"property_type" json:"property_type"` // As the source lists it; saved as a canonical type
 	Bedrooms     sql.NullInt64   `db:"bedrooms" json:"bedrooms"`
 	Bathrooms    sql.NullInt64   `db:"bathrooms" json:"bathrooms"`
+	Carspaces    sql.NullInt64   `db:"carspaces" json:"carspaces"` // Garage, carport and parking spaces
 	LandSizeSqm  sql.NullFloat64 `db:"land_size_sqm" json:"land_size_sqm"`
 	Description  sql.NullString  `db:"description" json:"description"`
 	Images       sql.NullString  `db:"images" json:"images"` // JSON array
 	ListedAt     sql.NullTime    `db:"listed_at" json:"listed_at"`
 	ScrapedAt    time.Time       `db:"scraped_at" json:"scraped_at"`
 	UpdatedAt    time.Time       `db:"updated_at" json:"updated_at"`
+	// Where Latitude/Longitude came from ('listing', 'geocoder' or 'manual')
+	// and how likely they are to be on the property (0-1). Set on save from
+	// the listing if the scraper left them empty.
+	CoordSource     sql.NullString  `db:"coord_source" json:"coord_source"`
+	CoordConfidence sql.NullFloat64 `db:"coord_confidence" json:"coord_confidence"`
+	// Upcoming inspections and auction, for sources that report them.
+	// nil if the source doesn't; saved separately to property_events.
+	Events []PropertyEvent `db:"-" json:"-"`
+	// Videos and floorplans, for sources that report them. nil if the source
+	// doesn't; saved separately to property_images. Photos are Images.
+	Media []PropertyMedia `db:"-" json:"-"`
+}
+
+// PropertyMedia is a listing's video or floorplan
+type PropertyMedia struct {
+	Kind string `db:"kind" json:"kind"` // 'video' or 'floorplan'
+	URL  string `db:"url" json:"url"`
+}
+
+// PropertyEvent is an open-for-inspection time or auction for a listing
+type PropertyEvent struct {
+	ID         int64          `db:"id" json:"id"`
+	PropertyID int64          `db:"property_id" json:"property_id"`
+	Kind       string         `db:"kind" json:"kind"` // 'inspection' or 'auction'
+	StartsAt   time.Time      `db:"starts_at" json:"starts_at"`
+	EndsAt     sql.NullTime   `db:"ends_at" json:"ends_at"`
+	Location   sql.NullString `db:"location" json:"location"` // Auction venue if not on site
+	Source     string         `db:"source" json:"source"`
+}
+
+// PropertyChange is an entry in the listing change log
+type PropertyChange struct {
+	PropertyID  int64     `db:"property_id" json:"property_id"`
+	Kind        string    `db:"kind" json:"kind"` // 'new', 'price_drop' or 'back_on_market'
+	ChangedAt   time.Time `db:"changed_at" json:"changed_at"`
+	PriceBefore *int64    `db:"price_before" json:"price_before,omitempty"` // Asking prices either side of a drop
+	PriceAfter  *int64    `db:"price_after" json:"price_after,omitempty"`
+}
+
+// RecentChange is a property that recently came on the market, dropped its
+// price or came back on the market
+type RecentChange struct {
+	PropertyListItem
+	Change      string    `json:"change"` // 'new', 'price_drop' or 'back_on_market'
+	ChangedAt   time.Time `json:"changed_at"`
+	PriceBefore *int64    `json:"price_before,omitempty"`
+	PriceAfter  *int64    `json:"price_after,omitempty"`
+}
+
+// ListingVersion is a listing's asking price, type and land size over a
+// period, from the listing history
+type ListingVersion struct {
+	PropertyID   int64      `db:"property_id"` // Canonical property
+	ListingID    int64      `db:"listing_id"`
+	Source       string     `db:"source"`
+	ValidFrom    time.Time  `db:"valid_from"`
+	ValidTo      *time.Time `db:"valid_to"` // nil while still listed
+	PriceMin     *int64     `db:"price_min"`
+	PriceMax     *int64     `db:"price_max"`
+	PriceText    *string    `db:"price_text"`
+	PropertyType *string    `db:"property_type"`
+	LandSizeSqm  *float64   `db:"land_size_sqm"`
+}
+
+// Active reports whether the version was current at t
+func (v *ListingVersion) Active(t time.Time) bool {
+	return !v.ValidFrom.After(t) && (v.ValidTo == nil || v.ValidTo.After(t))
+}
+
+// HistoricalProperty is a property as it was listed at a point in time:
+// price and type are as they were then, everything else as it is now
+type HistoricalProperty struct {
+	PropertyListItem
+	PriceMin    *int64    `json:"price_min,omitempty"`
+	PriceMax    *int64    `json:"price_max,omitempty"`
+	LandSizeSqm *float64  `json:"land_size_sqm,omitempty"`
+	ListedSince time.Time `json:"listed_since"` // When the listing shown came on the market
+}
+
+// ActiveListingCount is how many properties were on the market on a date
+type ActiveListingCount struct {
+	Date   string `json:"date"` // YYYY-MM-DD, counted at the end of the day in Sydney time
+	Active int    `json:"active"`
+}
+
+// TrendPoint is a market metric over a period
+type TrendPoint struct {
+	Date     string   `json:"date"`     // Start of the period, YYYY-MM-DD in Sydney time
+	Value    *float64 `json:"value"`    // nil for a median price with no prices
+	Listings int      `json:"listings"` // Properties on the market at the end of the period
+}
+
+// Rental represents a rental listing, used to gauge rental yield for nearby properties for sale
+type Rental struct {
+	ID           int64           `db:"id" json:"id"`
+	ExternalID   string          `db:"external_id" json:"external_id"`
+	Source       string          `db:"source" json:"source"`
+	URL          string          `db:"url" json:"url"`
+	Address      sql.NullString  `db:"address" json:"address"`
+	Suburb       sql.NullString  `db:"suburb" json:"suburb"`
+	State        string          `db:"state" json:"state"`
+	Postcode     sql.NullString  `db:"postcode" json:"postcode"`
+	Latitude     sql.NullFloat64 `db:"latitude" json:"latitude"`
+	Longitude    sql.NullFloat64 `db:"longitude" json:"longitude"`
+	WeeklyRent   sql.NullInt64   `db:"weekly_rent" json:"weekly_rent"`
+	RentText     sql.NullString  `db:"rent_text" json:"rent_text"`
+	PropertyType sql.NullString  `db:"property_type" json:"property_type"`
+	Bedrooms     sql.NullInt64   `db:"bedrooms" json:"bedrooms"`
+	Bathrooms    sql.NullInt64   `db:"bathrooms" json:"bathrooms"`
+	LandSizeSqm  sql.NullFloat64 `db:"land_size_sqm" json:"land_size_sqm"`
+	Description  sql.NullString  `db:"description" json:"description"`
+	Images       sql.NullString  `db:"images" json:"images"` // JSON array
+	ScrapedAt    time.Time       `db:"scraped_at" json:"scraped_at"`
+	UpdatedAt    time.Time       `db:"updated_at" json:"updated_at"`
+}
+
+// RentalListItem is a rental near a property, for rental yield estimates
+type RentalListItem struct {
+	ID           int64    `db:"id" json:"id"`
+	Source       string   `db:"source" json:"source"`
+	URL          string   `db:"url" json:"url"`
+	Address      string   `db:"address" json:"address"`
+	Suburb       string   `db:"suburb" json:"suburb"`
+	Latitude     float64  `db:"latitude" json:"lat"`
+	Longitude    float64  `db:"longitude" json:"lng"`
+	WeeklyRent   int64    `db:"weekly_rent" json:"weekly_rent"`
+	RentText     string   `db:"rent_text" json:"rent_text"`
+	PropertyType string   `db:"property_type" json:"property_type"`
+	Bedrooms     *int64   `db:"bedrooms" json:"bedrooms,omitempty"`
+	LandSizeSqm  *float64 `db:"land_size_sqm" json:"land_size_sqm,omitempty"`
+	DistanceKm   float64  `db:"-" json:"distance_km"`
+}
+
+// AuctionResult is a weekly auction outcome, linked to a property by address where one matches
+type AuctionResult struct {
+	ID           int64          `db:"id" json:"id"`
+	PropertyID   sql.NullInt64  `db:"property_id" json:"property_id"`
+	Source       string         `db:"source" json:"source"`
+	City         string         `db:"city" json:"city"`
+	AuctionDate  string         `db:"auction_date" json:"auction_date"` // YYYY-MM-DD
+	Address      string         `db:"address" json:"address"`
+	Suburb       string         `db:"suburb" json:"suburb"`
+	State        string         `db:"state" json:"state"`
+	Postcode     sql.NullString `db:"postcode" json:"postcode"`
+	PropertyType sql.NullString `db:"property_type" json:"property_type"`
+	Bedrooms     sql.NullInt64  `db:"bedrooms" json:"bedrooms"`
+	Result       string         `db:"result" json:"result"` // sold, sold_prior, sold_after, passed_in, withdrawn
+	SoldPrice    sql.NullInt64  `db:"sold_price" json:"sold_price"`
+	Agency       sql.NullString `db:"agency" json:"agency"`
+	URL          sql.NullString `db:"url" json:"url"`
+	ScrapedAt    time.Time      `db:"scraped_at" json:"scraped_at"`
 }
 
 // PropertyDistance represents pre-computed distance from a property to a target
@@ -63,15 +216,21 @@ type School struct {
 
 // PropertyListItem is a lightweight property for map markers
 type PropertyListItem struct {
-	ID              int64   `db:"id" json:"id"`
-	Latitude        float64 `db:"latitude" json:"lat"`
-	Longitude       float64 `db:"longitude" json:"lng"`
-	PriceText       string  `db:"price_text" json:"price_text"`
-	PropertyType    string  `db:"property_type" json:"property_type"`
-	Address         string  `db:"address" json:"address"`
-	Suburb          string  `db:"suburb" json:"suburb"`
-	Source          string  `db:"source" json:"source"`
-	DriveTimeSydney *int    `db:"drive_time_sydney" json:"drive_time_sydney,omitempty"`
+	ID                int64    `db:"id" json:"id"`
+	Latitude          float64  `db:"latitude" json:"lat"`
+	Longitude         float64  `db:"longitude" json:"lng"`
+	PriceText         string   `db:"price_text" json:"price_text"`
+	PropertyType      string   `db:"property_type" json:"property_type"`
+	Address           string   `db:"address" json:"address"`
+	Suburb            string   `db:"suburb" json:"suburb"`
+	Source            string   `db:"source" json:"source"`
+	DriveTimePrimary  *int     `db:"drive_time_primary" json:"drive_time_primary,omitempty"`
+	AskingVsLandValue *float64 `db:"asking_vs_land_value_ratio" json:"asking_vs_land_value_ratio,omitempty"` // Asking price (midpoint) / land value
+	Score             *float64 `db:"score" json:"score,omitempty"`                                           // 0-100 under the requested score profile
+
+	// SortValue is the value of the list's sort column, when it isn't one of
+	// the above, for merging lists from region databases
+	SortValue interface{} `db:"sort_value" json:"-"`
 }
 
 // PropertySource represents a listing source for a property
@@ -80,6 +239,636 @@ type PropertySource struct {
 	URL    string `json:"url"`
 }
 
+// PropertyLink is a duplicate listing linked to its canonical property, with
+// both listings' source and address for reviewing the match
+type PropertyLink struct {
+	CanonicalID      int64      `db:"canonical_id" json:"canonical_id"`
+	DuplicateID      int64      `db:"duplicate_id" json:"duplicate_id"`
+	MatchType        string     `db:"match_type" json:"match_type"` // 'coords', 'address', 'gnaf' or 'manual'
+	CreatedAt        *time.Time `db:"created_at" json:"created_at,omitempty"`
+	ConfirmedAt      *time.Time `db:"confirmed_at" json:"confirmed_at,omitempty"` // nil until a person confirms it
+	CanonicalSource  string     `db:"canonical_source" json:"canonical_source"`
+	CanonicalAddress string     `db:"canonical_address" json:"canonical_address"`
+	DuplicateSource  string     `db:"duplicate_source" json:"duplicate_source"`
+	DuplicateAddress string     `db:"duplicate_address" json:"duplicate_address"`
+}
+
+// DuplicateSuspect is a pair of listings from different sources, neither
+// linked as a duplicate, near enough and similar enough in land size to be
+// the same property, for someone to approve or reject
+type DuplicateSuspect struct {
+	CanonicalID          int64    `db:"canonical_id" json:"canonical_id"` // The lower ID, canonical if approved as is
+	DuplicateID          int64    `db:"duplicate_id" json:"duplicate_id"`
+	DistanceM            float64  `db:"-" json:"distance_m"`
+	CanonicalSource      string   `db:"canonical_source" json:"canonical_source"`
+	CanonicalAddress     string   `db:"canonical_address" json:"canonical_address"`
+	CanonicalLandSizeSqm *float64 `db:"canonical_land_size_sqm" json:"canonical_land_size_sqm,omitempty"`
+	DuplicateSource      string   `db:"duplicate_source" json:"duplicate_source"`
+	DuplicateAddress     string   `db:"duplicate_address" json:"duplicate_address"`
+	DuplicateLandSizeSqm *float64 `db:"duplicate_land_size_sqm" json:"duplicate_land_size_sqm,omitempty"`
+
+	CanonicalLat float64 `db:"canonical_lat" json:"-"`
+	CanonicalLng float64 `db:"canonical_lng" json:"-"`
+	DuplicateLat float64 `db:"duplicate_lat" json:"-"`
+	DuplicateLng float64 `db:"duplicate_lng" json:"-"`
+}
+
+// PropertyLinkAudit is a manual create, confirm or reject of a property link
+type PropertyLinkAudit struct {
+	ID                  int64     `db:"id" json:"id"`
+	Action              string    `db:"action" json:"action"` // 'create', 'confirm' or 'reject'
+	CanonicalID         int64     `db:"canonical_id" json:"canonical_id"`
+	DuplicateID         int64     `db:"duplicate_id" json:"duplicate_id"`
+	PreviousCanonicalID *int64    `db:"previous_canonical_id" json:"previous_canonical_id,omitempty"` // For 'create', if the duplicate was linked elsewhere
+	MatchType           string    `db:"match_type" json:"match_type"`
+	Note                string    `db:"note" json:"note,omitempty"`
+	CreatedAt           time.Time `db:"created_at" json:"created_at"`
+}
+
+// AuctionSummary is an auction outcome shown in property details
+type AuctionSummary struct {
+	AuctionDate string `db:"auction_date" json:"auction_date"`
+	Result      string `db:"result" json:"result"`
+	SoldPrice   *int64 `db:"sold_price" json:"sold_price,omitempty"`
+	Agency      string `db:"agency" json:"agency,omitempty"`
+	Source      string `db:"source" json:"source"`
+}
+
+// HistoricalSale is one lot in a NSW Valuer General recorded sale
+type HistoricalSale struct {
+	ID             int64           `db:"id" json:"id"`
+	LotIDString    string          `db:"lot_id_string" json:"lot_id_string"`
+	DealingNumber  string          `db:"dealing_number" json:"dealing_number"`
+	DistrictCode   sql.NullString  `db:"district_code" json:"district_code"`
+	VGPropertyID   sql.NullString  `db:"vg_property_id" json:"vg_property_id"`
+	Address        sql.NullString  `db:"address" json:"address"`
+	Locality       sql.NullString  `db:"locality" json:"locality"`
+	Postcode       sql.NullString  `db:"postcode" json:"postcode"`
+	AreaSqm        sql.NullFloat64 `db:"area_sqm" json:"area_sqm"`
+	ContractDate   string          `db:"contract_date" json:"contract_date"` // YYYY-MM-DD
+	SettlementDate sql.NullString  `db:"settlement_date" json:"settlement_date"`
+	PurchasePrice  int64           `db:"purchase_price" json:"purchase_price"`
+	LotCount       int             `db:"lot_count" json:"lot_count"`
+	Zoning         sql.NullString  `db:"zoning" json:"zoning"`
+	Nature         sql.NullString  `db:"nature" json:"nature"`
+	PrimaryPurpose sql.NullString  `db:"primary_purpose" json:"primary_purpose"`
+	ImportedAt     time.Time       `db:"imported_at" json:"imported_at"`
+}
+
+// GNAFAddress is a current, principal address from G-NAF, the national
+// address file, as imported by tools gnaf
+type GNAFAddress struct {
+	PID           string          `db:"address_detail_pid" json:"pid"` // e.g. "GANSW710280564"
+	Address       string          `db:"address" json:"address"`        // Formatted, e.g. "289 SCOTTS LANE, CURRABUBULA NSW 2342"
+	NumberFirst   sql.NullString  `db:"number_first" json:"number_first"`
+	NumberLast    sql.NullString  `db:"number_last" json:"number_last"`
+	LotNumber     sql.NullString  `db:"lot_number" json:"lot_number"`
+	StreetName    sql.NullString  `db:"street_name" json:"street_name"`
+	StreetType    sql.NullString  `db:"street_type" json:"street_type"` // In full, e.g. "LANE"
+	Locality      string          `db:"locality" json:"locality"`
+	State         string          `db:"state" json:"state"`
+	Postcode      sql.NullString  `db:"postcode" json:"postcode"`
+	LegalParcelID sql.NullString  `db:"legal_parcel_id" json:"legal_parcel_id"` // e.g. "12/DP753790"
+	Latitude      sql.NullFloat64 `db:"latitude" json:"lat"`
+	Longitude     sql.NullFloat64 `db:"longitude" json:"lng"`
+}
+
+// PriorSale is a recorded sale of a property's land shown in property details.
+// The price covers every lot in the sale, which may include lots outside the property.
+type PriorSale struct {
+	ContractDate  string   `json:"contract_date"`
+	PurchasePrice int64    `json:"purchase_price"`
+	AreaSqm       *float64 `json:"area_sqm,omitempty"`
+	LotCount      int      `json:"lot_count"`
+	Lots          []string `json:"lots"` // This property's lots included in the sale
+}
+
+// Amenity is a school, hospital or other point of interest near properties.
+// Towns aren't stored as amenities; they're embedded in internal/geo.
+type Amenity struct {
+	ID         int64          `db:"id" json:"id"`
+	Type       string         `db:"type" json:"type"`
+	Name       string         `db:"name" json:"name"`
+	Detail     sql.NullString `db:"detail" json:"detail"`
+	Latitude   float64        `db:"latitude" json:"latitude"`
+	Longitude  float64        `db:"longitude" json:"longitude"`
+	Source     string         `db:"source" json:"source"`
+	ImportedAt time.Time      `db:"imported_at" json:"imported_at"`
+}
+
+// DriveTimeCell is a drive time grid cell, with the drive time from its
+// centre to the anchor
+type DriveTimeCell struct {
+	Lat           float64   `db:"lat" json:"lat"`
+	Lng           float64   `db:"lng" json:"lng"`
+	LatStep       float64   `db:"lat_step" json:"lat_step"`
+	LngStep       float64   `db:"lng_step" json:"lng_step"`
+	DriveTimeMins int       `db:"drive_time_mins" json:"drive_time_mins"`
+	DistanceKm    float64   `db:"distance_km" json:"distance_km"`
+	ComputedAt    time.Time `db:"computed_at" json:"computed_at"`
+}
+
+// SuburbBoundary is an ABS Suburbs and Localities (SAL) polygon
+type SuburbBoundary struct {
+	Code       string    `db:"sal_code" json:"sal_code"`
+	Name       string    `db:"name" json:"name"`
+	State      string    `db:"state" json:"state"`
+	Geometry   string    `db:"geometry" json:"-"` // GeoJSON Polygon or MultiPolygon
+	ImportedAt time.Time `db:"imported_at" json:"imported_at"`
+}
+
+// PostcodeBoundary is an ABS Postal Areas (POA) polygon
+type PostcodeBoundary struct {
+	Postcode   string    `db:"postcode" json:"postcode"`
+	Geometry   string    `db:"geometry" json:"-"` // GeoJSON Polygon or MultiPolygon
+	ImportedAt time.Time `db:"imported_at" json:"imported_at"`
+}
+
+// BiosecurityArea is a Local Land Services region or declared weed zone
+// polygon, from a `tools biosecurity` import
+type BiosecurityArea struct {
+	ID         int64     `db:"id" json:"id"`
+	Kind       string    `db:"kind" json:"kind"` // 'lls_region' or 'weed_zone'
+	Name       string    `db:"name" json:"name"`
+	Category   string    `db:"category" json:"category,omitempty"`
+	Geometry   string    `db:"geometry" json:"-"` // GeoJSON Polygon or MultiPolygon
+	ImportedAt time.Time `db:"imported_at" json:"imported_at"`
+}
+
+// WeedZone is a declared priority weed zone a property is in
+type WeedZone struct {
+	Weed     string `json:"weed"`
+	Category string `json:"category,omitempty"` // e.g. "Containment"
+}
+
+// FireExtent is the mapped extent of a past wildfire or prescribed burn, from
+// a `tools fires` import
+type FireExtent struct {
+	ID         int64     `db:"id" json:"id"`
+	Year       int       `db:"year" json:"year"`
+	FireType   string    `db:"fire_type" json:"fire_type"` // 'Wildfire' or 'Prescribed burn'
+	Name       string    `db:"name" json:"name,omitempty"`
+	Geometry   string    `db:"geometry" json:"-"` // GeoJSON Polygon or MultiPolygon
+	ImportedAt time.Time `db:"imported_at" json:"imported_at"`
+}
+
+// BuildingFootprint is an imported building outline's centroid and plan area
+type BuildingFootprint struct {
+	ID         int64     `db:"id" json:"id"`
+	Latitude   float64   `db:"latitude" json:"lat"`
+	Longitude  float64   `db:"longitude" json:"lng"`
+	AreaSqm    float64   `db:"area_sqm" json:"area_sqm"`
+	ImportedAt time.Time `db:"imported_at" json:"imported_at"`
+}
+
+// EnergyDevelopment is an operating or planned wind or solar farm, from a
+// DA register or EPBC listing import
+type EnergyDevelopment struct {
+	ID         int64           `db:"id" json:"id"`
+	Name       string          `db:"name" json:"name"`
+	Kind       string          `db:"kind" json:"kind"`     // 'wind' or 'solar'
+	Status     string          `db:"status" json:"status"` // 'operating', 'construction', 'approved' or 'proposed'
+	CapacityMW sql.NullFloat64 `db:"capacity_mw" json:"capacity_mw"`
+	Latitude   float64         `db:"latitude" json:"latitude"`
+	Longitude  float64         `db:"longitude" json:"longitude"`
+	Source     string          `db:"source" json:"source"`
+	ImportedAt time.Time       `db:"imported_at" json:"imported_at"`
+}
+
+// NearbyDevelopment is a property's nearest energy development of a kind
+type NearbyDevelopment struct {
+	Name       string
+	Status     string
+	DistanceKm float64
+}
+
+// ExclusionFeature is a feature of an exclusion layer, e.g. a highway or a
+// wind farm
+type ExclusionFeature struct {
+	ID         int64     `db:"id" json:"id"`
+	Layer      string    `db:"layer" json:"layer"`
+	Name       string    `db:"name" json:"name"`
+	Geometry   string    `db:"geometry" json:"-"` // GeoJSON geometry
+	ImportedAt time.Time `db:"imported_at" json:"imported_at"`
+}
+
+// ExclusionLayer is an imported exclusion layer and its size
+type ExclusionLayer struct {
+	Layer      string    `db:"layer" json:"layer"`
+	Features   int       `db:"features" json:"features"`
+	ImportedAt time.Time `db:"imported_at" json:"imported_at"`
+}
+
+// SuburbStats summarises the listings inside a suburb's boundary
+type SuburbStats struct {
+	Boundary               SuburbBoundary  `json:"-"`
+	Listings               int             `json:"listings"`
+	Priced                 int             `json:"priced"`                              // Listings with an asking price
+	MedianPrice            *float64        `json:"median_price,omitempty"`              // Of asking price midpoints
+	MedianDriveTimePrimary *float64        `json:"median_drive_time_primary,omitempty"` // Minutes
+	Velocity               *SuburbVelocity `json:"velocity,omitempty"`                  // nil until `tools velocity` has run
+	MarketHeat             *float64        `json:"market_heat,omitempty"`               // Typical suburb's ema_days over this one's: above 1 is faster
+}
+
+// SuburbVelocity is how quickly listings in a suburb go off the market,
+// from spells in the listing history that have ended
+type SuburbVelocity struct {
+	Code       string    `db:"sal_code" json:"sal_code"`
+	OffMarket  int       `db:"off_market" json:"off_market"`   // Listings that went off the market in the last 12 months
+	MedianDays *float64  `db:"median_days" json:"median_days"` // Their median days on the market
+	EMADays    *float64  `db:"ema_days" json:"ema_days"`       // Exponential moving average of monthly medians
+	Months     int       `db:"months" json:"months"`           // Months with data in the average
+	ComputedAt time.Time `db:"computed_at" json:"computed_at"`
+}
+
+// Distribution is a histogram of a field's values over a filtered set of
+// properties
+type Distribution struct {
+	Field   string               `json:"field"`
+	Scale   string               `json:"scale"`   // "linear" or "log" bucket widths
+	Count   int                  `json:"count"`   // Properties with a value, across the buckets
+	Missing int                  `json:"missing"` // Properties without a value (or, on a log scale, a positive one)
+	Min     *float64             `json:"min,omitempty"`
+	Max     *float64             `json:"max,omitempty"`
+	Buckets []DistributionBucket `json:"buckets"`
+}
+
+// DistributionBucket counts the values from Min up to Max; the last bucket
+// includes its Max
+type DistributionBucket struct {
+	Min   float64 `json:"min"`
+	Max   float64 `json:"max"`
+	Count int     `json:"count"`
+}
+
+// Neighbour is another listing near a property, with the straight-line
+// distance to it
+type Neighbour struct {
+	PropertyListItem
+	DistanceKm float64 `json:"distance_km"`
+}
+
+// NearbyAmenity is an amenity near a property, with the drive time to it if
+// one has been calculated
+type NearbyAmenity struct {
+	Name          string  `json:"name"`
+	Detail        string  `json:"detail,omitempty"`
+	Latitude      float64 `json:"lat"`
+	Longitude     float64 `json:"lng"`
+	DistanceKm    float64 `json:"distance_km"`
+	DriveTimeMins *int    `json:"drive_time_mins,omitempty"`
+}
+
+// RouteReview is a route whose road distance looked implausible next to the
+// straight-line distance, held back from the drive time columns for review
+type RouteReview struct {
+	ID            int64      `db:"id" json:"id"`
+	PropertyID    int64      `db:"property_id" json:"property_id"`
+	Address       string     `db:"address" json:"address"`
+	TargetType    string     `db:"target_type" json:"target_type"` // 'anchor', 'town', 'school' or 'poi'
+	TargetName    string     `db:"target_name" json:"target_name"`
+	StraightKm    float64    `db:"straight_km" json:"straight_km"`
+	RoadKm        float64    `db:"road_km" json:"road_km"`
+	Ratio         float64    `db:"ratio" json:"ratio"`
+	DriveTimeMins int        `db:"drive_time_mins" json:"drive_time_mins"`
+	Status        string     `db:"status" json:"status"` // 'pending', 'accepted' or 'rejected'
+	FlaggedAt     time.Time  `db:"flagged_at" json:"flagged_at"`
+	ResolvedAt    *time.Time `db:"resolved_at" json:"resolved_at,omitempty"`
+}
+
+// GeocodeReview is a geocoded point tools geocode held back from a
+// property's coordinates because the cadastre didn't back it up
+type GeocodeReview struct {
+	ID           int64      `db:"id" json:"id"`
+	PropertyID   int64      `db:"property_id" json:"property_id"`
+	Address      string     `db:"address" json:"address"` // As geocoded
+	Provider     string     `db:"provider" json:"provider"`
+	Latitude     float64    `db:"latitude" json:"lat"`
+	Longitude    float64    `db:"longitude" json:"lng"`
+	Confidence   float64    `db:"confidence" json:"confidence"`
+	Reason       string     `db:"reason" json:"reason"` // 'no_lot' or 'address_mismatch'
+	LotIDString  *string    `db:"lot_id_string" json:"lot_id_string,omitempty"`
+	FoundAddress *string    `db:"found_address" json:"found_address,omitempty"`
+	Status       string     `db:"status" json:"status"` // 'pending', 'accepted' or 'rejected'
+	FlaggedAt    time.Time  `db:"flagged_at" json:"flagged_at"`
+	ResolvedAt   *time.Time `db:"resolved_at" json:"resolved_at,omitempty"`
+}
+
+// RoadSnap is the nearest mapped road point to a property that couldn't be
+// routed to the anchor, as `tools unroutable` found it
+type RoadSnap struct {
+	PropertyID int64     `db:"property_id" json:"property_id"`
+	Lat        *float64  `db:"snap_lat" json:"snap_lat"` // Nil if no road within the search distance
+	Lng        *float64  `db:"snap_lng" json:"snap_lng"`
+	DistanceKm *float64  `db:"snap_km" json:"snap_km"`
+	WayID      *int64    `db:"way_id" json:"way_id,omitempty"`
+	RoadName   *string   `db:"road_name" json:"road_name,omitempty"`
+	RoadClass  *string   `db:"road_class" json:"road_class,omitempty"`
+	RoadUse    *string   `db:"road_use" json:"road_use,omitempty"`
+	Routable   *bool     `db:"routable" json:"routable"` // Whether the snap point routes to the anchor
+	CheckedAt  time.Time `db:"checked_at" json:"checked_at"`
+}
+
+// LandValueRecord is a NSW Valuer General land value for one lot
+type LandValueRecord struct {
+	ID           int64           `db:"id" json:"id"`
+	LotIDString  string          `db:"lot_id_string" json:"lot_id_string"`
+	VGPropertyID string          `db:"vg_property_id" json:"vg_property_id"`
+	DistrictCode sql.NullString  `db:"district_code" json:"district_code"`
+	Address      sql.NullString  `db:"address" json:"address"`
+	Locality     sql.NullString  `db:"locality" json:"locality"`
+	Postcode     sql.NullString  `db:"postcode" json:"postcode"`
+	ZoneCode     sql.NullString  `db:"zone_code" json:"zone_code"`
+	AreaSqm      sql.NullFloat64 `db:"area_sqm" json:"area_sqm"`
+	LandValue    int64           `db:"land_value" json:"land_value"`
+	BaseDate     sql.NullString  `db:"base_date" json:"base_date"` // YYYY-MM-DD
+	LotCount     int             `db:"lot_count" json:"lot_count"`
+	ImportedAt   time.Time       `db:"imported_at" json:"imported_at"`
+}
+
+// LotLandValue is a lot's land value shown in property details
+type LotLandValue struct {
+	LotIDString string `db:"lot_id_string" json:"lot_id_string"`
+	LandValue   int64  `db:"land_value" json:"land_value"`
+	BaseDate    string `db:"base_date" json:"base_date,omitempty"`
+	LotCount    int    `db:"lot_count" json:"lot_count"` // Lots the value covers
+}
+
+// EnrichmentTarget is a property's location and nearby places, for the
+// enrichment steps that fill in derived columns
+type EnrichmentTarget struct {
+	ID             int64   `db:"id"`
+	Latitude       float64 `db:"latitude"`
+	Longitude      float64 `db:"longitude"`
+	OriginLat      float64 `db:"origin_lat"` // Where drive times are routed from: the access point, else the listing point
+	OriginLng      float64 `db:"origin_lng"`
+	Address        string  `db:"address"`
+	Suburb         string  `db:"suburb"`
+	NearestTown1   string  `db:"nearest_town_1"`
+	NearestTown2   string  `db:"nearest_town_2"`
+	NearestSchool1 string  `db:"nearest_school_1"`
+	NearestSchool2 string  `db:"nearest_school_2"`
+}
+
+// NearbyPlace is a town or school near a property
+type NearbyPlace struct {
+	Name       string
+	DistanceKm float64
+	Latitude   float64
+	Longitude  float64
+}
+
+// SavedNearest is a property's saved two nearest towns or schools, for
+// checking against changed town or school data
+type SavedNearest struct {
+	ID        int64    `db:"id"`
+	Latitude  float64  `db:"latitude"`
+	Longitude float64  `db:"longitude"`
+	Name1     string   `db:"name_1"`
+	Km1       *float64 `db:"km_1"`
+	Name2     string   `db:"name_2"`
+	Km2       *float64 `db:"km_2"`
+}
+
+// SavedSearch is a named set of property filters, e.g. for an RSS feed of new matches
+type SavedSearch struct {
+	ID        int64     `db:"id" json:"id"`
+	Name      string    `db:"name" json:"name"`
+	Query     string    `db:"query" json:"query"` // /api/properties query string, e.g. "price_max=1500000&land_size_min=40"
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+}
+
+// RegionBoundary is a suburb or LGA polygon a watchlist can follow
+type RegionBoundary struct {
+	Kind     string `db:"kind" json:"kind"` // 'suburb' or 'lga'
+	Code     string `db:"code" json:"code"` // SAL or LGA code
+	Name     string `db:"name" json:"name"`
+	State    string `db:"state" json:"state"`
+	Geometry string `db:"geometry" json:"-"` // GeoJSON Polygon or MultiPolygon
+}
+
+// Watchlist is a suburb or LGA subscribed to for weekly market summaries
+type Watchlist struct {
+	ID        int64     `db:"id" json:"id"`
+	Kind      string    `db:"kind" json:"kind"` // 'suburb' or 'lga'
+	Code      string    `db:"code" json:"code"` // SAL or LGA code
+	Name      string    `db:"name" json:"name"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+}
+
+// WatchlistReport is a watchlist's market summary over a period, as
+// archived by `tools watchlist-reports`
+type WatchlistReport struct {
+	ID          int64            `json:"id"`
+	WatchlistID int64            `json:"watchlist_id"`
+	PeriodStart time.Time        `json:"period_start"`
+	PeriodEnd   time.Time        `json:"period_end"`
+	Summary     WatchlistSummary `json:"summary"`
+	CreatedAt   time.Time        `json:"created_at"`
+}
+
+// WatchlistSummary is what changed on a suburb or LGA's market over a period
+type WatchlistSummary struct {
+	Region              string                 `json:"region"`
+	Listings            int                    `json:"listings"`                        // On the market at the end of the period
+	NewListings         []PropertyListItem     `json:"new_listings"`                    // Came on the market during it
+	PriceChanges        []WatchlistPriceChange `json:"price_changes"`                   // On the market throughout, asking a different price at the end
+	Delisted            int                    `json:"delisted"`                        // Went off the market during it
+	MedianPrice         *float64               `json:"median_price,omitempty"`          // Median asking price (range midpoint) at the end
+	PreviousMedianPrice *float64               `json:"previous_median_price,omitempty"` // and at the start
+	MedianChangePct     *float64               `json:"median_change_pct,omitempty"`
+}
+
+// WatchlistPriceChange is a property whose asking price changed over a
+// watchlist report's period
+type WatchlistPriceChange struct {
+	PropertyListItem
+	PriceBefore int64 `json:"price_before"` // Asking price (top of the range) at the start
+	PriceAfter  int64 `json:"price_after"`
+}
+
+// UserPOI is a place of people's own (a friend's house, a favourite
+// trailhead) properties' distances and drive times are measured to
+type UserPOI struct {
+	ID        int64     `db:"id" json:"id"`
+	Name      string    `db:"name" json:"name"` // e.g. "Mum"
+	Latitude  float64   `db:"latitude" json:"lat"`
+	Longitude float64   `db:"longitude" json:"lng"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+}
+
+// PropertyPOITime is the distance and drive time from a property to a user POI
+type PropertyPOITime struct {
+	POIID         int64   `db:"poi_id" json:"poi_id"`
+	Name          string  `db:"name" json:"name"`
+	DistanceKm    float64 `db:"distance_km" json:"distance_km"`                   // Straight line
+	DriveTimeMins *int    `db:"drive_time_mins" json:"drive_time_mins,omitempty"` // Absent if unroutable or held for review
+}
+
+// ShareLink is a snapshot of the frontend's filter state behind a short token
+type ShareLink struct {
+	Token     string          `json:"token"`
+	Filters   json.RawMessage `json:"filters"` // As posted, e.g. {"version":5,"filters":{"price-max":10}}
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// UserData is everything people have entered rather than scraped, for moving
+// between database files or backing up apart from the listings. Properties
+// are identified by listing source and external ID, as database IDs differ
+// between files.
+type UserData struct {
+	Version        int                 `json:"version"`
+	ExportedAt     time.Time           `json:"exported_at"`
+	Tags           []UserTag           `json:"tags"`
+	SavedSearches  []SavedSearch       `json:"saved_searches"`
+	ScoreProfiles  []ScoreProfile      `json:"score_profiles"`
+	ShareLinks     []ShareLink         `json:"share_links"`
+	Coordinates    []UserCoordinates   `json:"coordinates"`     // Manually corrected
+	PropertyLinks  []UserPropertyLink  `json:"property_links"`  // Manually linked duplicates
+	LinkRejections []UserLinkRejection `json:"link_rejections"` // Pairs marked not the same
+}
+
+// UserTag is a tag on a property
+type UserTag struct {
+	Source     string    `db:"source" json:"source"`
+	ExternalID string    `db:"external_id" json:"external_id"`
+	Tag        string    `db:"tag" json:"tag"`
+	TaggedAt   time.Time `db:"tagged_at" json:"tagged_at"`
+}
+
+// UserCoordinates are a property's manually corrected coordinates
+type UserCoordinates struct {
+	Source     string  `db:"source" json:"source"`
+	ExternalID string  `db:"external_id" json:"external_id"`
+	Latitude   float64 `db:"latitude" json:"lat"`
+	Longitude  float64 `db:"longitude" json:"lng"`
+}
+
+// UserPropertyLink is a duplicate listing manually linked to its canonical
+// property
+type UserPropertyLink struct {
+	CanonicalSource     string `db:"canonical_source" json:"canonical_source"`
+	CanonicalExternalID string `db:"canonical_external_id" json:"canonical_external_id"`
+	DuplicateSource     string `db:"duplicate_source" json:"duplicate_source"`
+	DuplicateExternalID string `db:"duplicate_external_id" json:"duplicate_external_id"`
+}
+
+// UserLinkRejection is a pair of listings a person said aren't the same
+// property
+type UserLinkRejection struct {
+	SourceA     string    `db:"source_a" json:"source_a"`
+	ExternalIDA string    `db:"external_id_a" json:"external_id_a"`
+	SourceB     string    `db:"source_b" json:"source_b"`
+	ExternalIDB string    `db:"external_id_b" json:"external_id_b"`
+	Note        *string   `db:"note" json:"note,omitempty"`
+	RejectedAt  time.Time `db:"rejected_at" json:"rejected_at"`
+}
+
+// UserDataImport counts what an import added to each section of UserData,
+// and what it skipped as already there or for a property not in the database
+type UserDataImport struct {
+	Imported map[string]int `json:"imported"`
+	Skipped  map[string]int `json:"skipped"`
+}
+
+// Attachment is a file attached to a property, e.g. a contract or soil test
+type Attachment struct {
+	ID          int64     `db:"id" json:"id"`
+	PropertyID  int64     `db:"property_id" json:"property_id"`
+	Kind        string    `db:"kind" json:"kind"`
+	Filename    string    `db:"filename" json:"filename"`
+	ContentType string    `db:"content_type" json:"content_type"`
+	SizeBytes   int64     `db:"size_bytes" json:"size_bytes"`
+	SHA256      string    `db:"sha256" json:"sha256"`
+	StorageKey  string    `db:"storage_key" json:"-"`
+	Note        *string   `db:"note" json:"note,omitempty"`
+	UploadedAt  time.Time `db:"uploaded_at" json:"uploaded_at"`
+}
+
+// AuditEntry is one change made through the API. Before and After are the
+// changed record as JSON, omitted when it didn't exist before or after.
+type AuditEntry struct {
+	ID         int64           `db:"id" json:"id"`
+	CreatedAt  time.Time       `db:"created_at" json:"created_at"`
+	User       *string         `db:"user" json:"user,omitempty"`
+	RemoteAddr *string         `db:"remote_addr" json:"remote_addr,omitempty"`
+	Action     string          `db:"action" json:"action"`
+	Entity     string          `db:"entity" json:"entity"`
+	EntityID   *string         `db:"entity_id" json:"entity_id,omitempty"`
+	Before     json.RawMessage `db:"-" json:"before,omitempty"`
+	After      json.RawMessage `db:"-" json:"after,omitempty"`
+}
+
+// TagCount is a tag and how many properties have it
+type TagCount struct {
+	Tag   string `db:"tag" json:"tag"`
+	Count int    `db:"count" json:"count"`
+}
+
+// FilterFacets counts the properties a filter option would match alongside
+// the other active filters, by option
+type FilterFacets struct {
+	PropertyTypes map[string]int `json:"property_types"`
+	Tags          map[string]int `json:"tags"`
+}
+
+// SearchMatch is the state of a property matching a saved search, as
+// snapshotted and compared by saved search diffs
+type SearchMatch struct {
+	PropertyID   int64    `db:"property_id" json:"id"`
+	Address      *string  `db:"address" json:"address,omitempty"`
+	PriceText    *string  `db:"price_text" json:"price_text,omitempty"`
+	PriceMin     *int64   `db:"price_min" json:"price_min,omitempty"`
+	PriceMax     *int64   `db:"price_max" json:"price_max,omitempty"`
+	LandSizeSqm  *float64 `db:"land_size_sqm" json:"land_size_sqm,omitempty"`
+	PropertyType *string  `db:"property_type" json:"property_type,omitempty"`
+}
+
+// SearchMatchChange is a property that matched a saved search before and
+// now, with the fields that changed in between
+type SearchMatchChange struct {
+	Property SearchMatch   `json:"property"`
+	Changes  []FieldChange `json:"changes"`
+}
+
+// FieldChange is one field's value before and after
+type FieldChange struct {
+	Field  string      `json:"field"`
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+// SavedSearchDiff is how a saved search's matches changed since a snapshot
+type SavedSearchDiff struct {
+	SearchID   int64               `json:"saved_search_id"`
+	Since      time.Time           `json:"since"`
+	BaselineAt time.Time           `json:"baseline_at"` // When the snapshot compared against was taken
+	New        []SearchMatch       `json:"new"`         // Match now but didn't then
+	Removed    []SearchMatch       `json:"removed"`     // Matched then (as they were) but don't now
+	Changed    []SearchMatchChange `json:"changed"`
+}
+
+// ScoreProfile is a named set of weights for scoring properties, e.g. one per
+// person searching
+type ScoreProfile struct {
+	ID        int64              `json:"id"`
+	Name      string             `json:"name"`
+	Weights   map[string]float64 `json:"weights"` // Criterion key -> relative weight
+	CreatedAt time.Time          `json:"created_at"`
+	UpdatedAt time.Time          `json:"updated_at"`
+}
+
+// ScoringInput is what a property is scored on; nil where unknown
+type ScoringInput struct {
+	PropertyID        int64    `db:"id"`
+	DriveTimePrimary  *int     `db:"drive_time_primary"`
+	DriveTimeTown     *int     `db:"nearest_town_1_mins"`
+	DriveTimeSchool   *int     `db:"nearest_school_1_mins"`
+	PriceMid          *float64 `db:"price_mid"`
+	LandSizeSqm       *float64 `db:"land_size_sqm"`
+	AskingVsLandValue *float64 `db:"asking_vs_land_value_ratio"`
+}
+
 // CadastralLot represents a land parcel from NSW DCDB
 type CadastralLot struct {
 	ID          int64   `db:"id" json:"id"`
@@ -91,6 +880,67 @@ type CadastralLot struct {
 	CentroidLat float64 `db:"centroid_lat" json:"centroid_lat"`
 	CentroidLng float64 `db:"centroid_lng" json:"centroid_lng"`
 	FetchedAt   string  `db:"fetched_at" json:"fetched_at"`
+	LandValue   *int64  `db:"land_value" json:"land_value,omitempty"` // Latest NSW VG land value
+
+	HeritageCheckedAt *string  `db:"heritage_checked_at" json:"heritage_checked_at,omitempty"`
+	MinLotSizeSqm     *float64 `db:"min_lot_size_sqm" json:"min_lot_size_sqm,omitempty"` // LEP minimum lot size; 0 if none mapped, nil if not checked
+}
+
+// LotDetail is a cadastral lot with what's recorded about it and the
+// properties on it, for looking it up by its Lot/DP reference
+type LotDetail struct {
+	CadastralLot
+	ZoneCode          *string `db:"zone_code"`            // Zoning recorded by the NSW VG
+	LandValueBaseDate *string `db:"land_value_base_date"` // YYYY-MM-DD
+
+	HeritageItems []HeritageItem
+	Overlays      []LotOverlay
+	Fires         []LotFire
+	Properties    []PropertyListItem // Canonical properties linked to the lot
+}
+
+// HeritageItem is a heritage listing overlapping one of a property's lots
+type HeritageItem struct {
+	LotIDString string `db:"lot_id_string" json:"lot_id_string"`
+	Listing     string `db:"listing" json:"listing"` // 'state' (State Heritage Register) or 'local' (LEP)
+	ItemID      string `db:"item_id" json:"item_id"`
+	Name        string `db:"name" json:"name"`
+	Class       string `db:"class" json:"class,omitempty"` // e.g. "Conservation Area - General"
+}
+
+// LotOverlay is a planning overlay covering part of one of a property's lots
+type LotOverlay struct {
+	LotIDString string  `db:"lot_id_string" json:"lot_id_string"`
+	Overlay     string  `db:"overlay" json:"overlay"`           // 'koala-habitat' or 'biodiversity-values'
+	AffectedPct float64 `db:"affected_pct" json:"affected_pct"` // Percentage of the lot covered
+}
+
+// LotFire is a past fire that burnt part of one of a property's lots
+type LotFire struct {
+	LotIDString string  `db:"lot_id_string" json:"lot_id_string"`
+	Year        int     `db:"year" json:"year"`
+	FireType    string  `db:"fire_type" json:"fire_type"`
+	Name        string  `db:"name" json:"name,omitempty"`
+	BurntPct    float64 `db:"burnt_pct" json:"burnt_pct"` // Percentage of the lot burnt
+}
+
+// ShareLocation is a point in the forms for sharing it precisely, e.g. an
+// inspection meeting spot on an unnamed road
+type ShareLocation struct {
+	Lat           float64 `json:"lat"`
+	Lng           float64 `json:"lng"`
+	PlusCode      string  `json:"plus_code"`     // Open Location Code, e.g. "4RRH46V8+74M"
+	PlusCodeURL   string  `json:"plus_code_url"` // plus.codes page
+	MapsURL       string  `json:"maps_url"`      // Google Maps pin
+	What3Words    string  `json:"what3words,omitempty"`
+	What3WordsURL string  `json:"what3words_url,omitempty"`
+}
+
+// PropertyShare is a property's point and the centroid of its lots, for
+// sharing
+type PropertyShare struct {
+	Point       ShareLocation  `json:"point"`
+	LotCentroid *ShareLocation `json:"lot_centroid,omitempty"`
 }
 
 // PropertyDetail is the full property info for popup/modal
@@ -100,23 +950,41 @@ type PropertyDetail struct {
 	Source             string           `json:"source"`
 	URL                string           `json:"url"`
 	Sources            []PropertySource `json:"sources,omitempty"` // All sources where this property is listed
+	Tags               []string         `json:"tags,omitempty"`
+	HeritageListing    *string          `json:"heritage_listing,omitempty"` // 'state', 'local' or 'none'; absent if not checked
+	HeritageItems      []HeritageItem   `json:"heritage_items,omitempty"`
+	Attachments        []Attachment     `json:"attachments,omitempty"`
+	AuctionResults     []AuctionSummary `json:"auction_results,omitempty"`
+	PriorSales         []PriorSale      `json:"prior_sales,omitempty"`
+	LandValue          *int64           `json:"land_value,omitempty"`           // Total NSW VG land value of the property's lots
+	LandValueBaseDate  *string          `json:"land_value_base_date,omitempty"` // Base date of the latest value
+	LotLandValues      []LotLandValue   `json:"lot_land_values,omitempty"`
+	AskingVsLandValue  *float64         `json:"asking_vs_land_value_ratio,omitempty"` // Asking price (midpoint) / land value
 	Address            string           `json:"address"`
 	Suburb             string           `json:"suburb"`
 	State              string           `json:"state"`
 	Postcode           string           `json:"postcode"`
 	Latitude           float64          `json:"lat"`
 	Longitude          float64          `json:"lng"`
+	CoordSource        string           `json:"coord_source,omitempty"`     // 'listing', 'geocoder' or 'manual'
+	CoordConfidence    *float64         `json:"coord_confidence,omitempty"` // 0-1
 	PriceMin           *int64           `json:"price_min,omitempty"`
 	PriceMax           *int64           `json:"price_max,omitempty"`
 	PriceText          string           `json:"price_text"`
 	PropertyType       string           `json:"property_type"`
+	PropertyTypeRaw    string           `json:"property_type_raw"`
 	Bedrooms           *int64           `json:"bedrooms,omitempty"`
 	Bathrooms          *int64           `json:"bathrooms,omitempty"`
+	Carspaces          *int64           `json:"carspaces,omitempty"`
+	DwellingCount      *int             `json:"dwelling_count,omitempty"` // Estimated from the description; 0 if it describes none
 	LandSizeSqm        *float64         `json:"land_size_sqm,omitempty"`
 	Description        string           `json:"description"`
 	Images             []string         `json:"images"`
+	Videos             []string         `json:"videos,omitempty"`     // From the listing and its duplicates
+	Floorplans         []string         `json:"floorplans,omitempty"` // Likewise
 	ListedAt           *string          `json:"listed_at,omitempty"`
-	DriveTimeSydney    *int             `json:"drive_time_sydney,omitempty"`     // Drive time to Sutherland in minutes
+	FirstSeenAt        *string          `json:"first_seen_at,omitempty"`         // When any scraper first saved the listing
+	DriveTimePrimary   *int             `json:"drive_time_primary,omitempty"`    // Drive time to the anchor in minutes
 	NearestTown1       *string          `json:"nearest_town_1,omitempty"`        // Name of nearest town
 	NearestTown1Km     *float64         `json:"nearest_town_1_km,omitempty"`     // Distance to nearest town
 	NearestTown1Mins   *int             `json:"nearest_town_1_mins,omitempty"`   // Drive time to nearest town in minutes
@@ -133,4 +1001,107 @@ type PropertyDetail struct {
 	NearestSchool2Mins *int             `json:"nearest_school_2_mins,omitempty"` // Drive time to second nearest school in minutes
 	NearestSchool2Lat  *float64         `json:"nearest_school_2_lat,omitempty"`  // Latitude of second nearest school
 	NearestSchool2Lng  *float64         `json:"nearest_school_2_lng,omitempty"`  // Longitude of second nearest school
+
+	// Nearest wind and solar farms, operating or planned
+	NearestWindFarm        *string  `json:"nearest_wind_farm,omitempty"`
+	NearestWindFarmStatus  *string  `json:"nearest_wind_farm_status,omitempty"`
+	NearestWindFarmKm      *float64 `json:"nearest_wind_farm_km,omitempty"`
+	NearestSolarFarm       *string  `json:"nearest_solar_farm,omitempty"`
+	NearestSolarFarmStatus *string  `json:"nearest_solar_farm_status,omitempty"`
+	NearestSolarFarmKm     *float64 `json:"nearest_solar_farm_km,omitempty"`
+
+	// Noise proxies: distances to the nearest highway, railway and runway
+	HighwayKm *float64 `json:"highway_km,omitempty"`
+	RailwayKm *float64 `json:"railway_km,omitempty"`
+	RunwayKm  *float64 `json:"runway_km,omitempty"`
+
+	// Local Land Services region and declared weed zones, for management
+	// obligations
+	LLSRegion *string    `json:"lls_region,omitempty"`
+	WeedZones []WeedZone `json:"weed_zones,omitempty"`
+
+	// Planning overlays restricting clearing: percentage of the lots' area
+	// covered, and the lots affected
+	KoalaHabitatPct *float64     `json:"koala_habitat_pct,omitempty"`
+	BiodiversityPct *float64     `json:"biodiversity_pct,omitempty"`
+	OverlayLots     []LotOverlay `json:"overlay_lots,omitempty"`
+
+	// Fire history: the most recent burn over the lots, the number of years
+	// in the last 30 with one, and the fires by lot
+	LastBurnYear *int      `json:"last_burn_year,omitempty"`
+	BurnCount30y *int      `json:"burn_count_30y,omitempty"`
+	LotFires     []LotFire `json:"lot_fires,omitempty"`
+
+	// Imagery links: Street View from the nearest road, NSW aerial imagery
+	// and Google Earth
+	StreetViewURL  *string `json:"street_view_url,omitempty"`
+	AerialURL      *string `json:"aerial_url,omitempty"`
+	GoogleEarthURL *string `json:"google_earth_url,omitempty"`
+
+	// Vegetation change over the lots since the baseline year, from
+	// Sentinel-2: woody cover (percentage points) and mean NDVI, and whether
+	// the loss suggests recent large-scale clearing
+	WoodyCoverChange     *float64 `json:"woody_cover_change,omitempty"`
+	NDVIChange           *float64 `json:"ndvi_change,omitempty"`
+	ClearingBaselineYear *int     `json:"clearing_baseline_year,omitempty"`
+	ClearingFlagged      *bool    `json:"clearing_flagged,omitempty"`
+
+	// Pasture greenness: mean NDVI over the lots, the seasonal range between
+	// the greenest and brownest months, and the monthly means (January first,
+	// null for months never observed)
+	NDVIMean          *float64   `json:"ndvi_mean,omitempty"`
+	NDVISeasonalRange *float64   `json:"ndvi_seasonal_range,omitempty"`
+	NDVIMonthly       []*float64 `json:"ndvi_monthly,omitempty"`
+
+	// Buildings on the lots, from the imported footprints: how many, their
+	// total and largest plan area, and whether one is house-sized
+	BuildingCount      *int     `json:"building_count,omitempty"`
+	BuildingAreaSqm    *float64 `json:"building_area_sqm,omitempty"`
+	LargestBuildingSqm *float64 `json:"largest_building_sqm,omitempty"`
+	HasDwelling        *bool    `json:"has_dwelling,omitempty"`
+
+	// LEP minimum lot size over the lots (the largest, 0 if none is mapped),
+	// and how many times over the lots' total area would meet it
+	MinLotSizeSqm    *float64 `json:"min_lot_size_sqm,omitempty"`
+	SubdivisionRatio *float64 `json:"subdivision_ratio,omitempty"`
+
+	// Walking and cycling time to the nearest town's centre, only for
+	// properties within 10 km of it
+	NearestTown1WalkMins  *int `json:"nearest_town_1_walk_mins,omitempty"`
+	NearestTown1CycleMins *int `json:"nearest_town_1_cycle_mins,omitempty"`
+
+	// Driveway/gate candidate drive times are routed from, and whether it was
+	// found from the lot boundary ("lot"), the listing point ("point") or no
+	// road was near ("none")
+	AccessLat    *float64 `json:"access_lat,omitempty"`
+	AccessLng    *float64 `json:"access_lng,omitempty"`
+	AccessSource *string  `json:"access_source,omitempty"`
+
+	// The G-NAF address the listing is matched to, the canonical form of its
+	// address, and how it was matched: by house number ("number"), lot number
+	// ("lot") or as the nearest on its street ("nearest")
+	GNAFPID     *string `json:"gnaf_pid,omitempty"`
+	GNAFAddress *string `json:"gnaf_address,omitempty"`
+	GNAFMatch   *string `json:"gnaf_match,omitempty"`
+
+	// The suburb and postcode whose boundaries the pin is in, whether the
+	// listing names others, and what it named if they were corrected to the
+	// boundaries' (see tools boundarycheck)
+	BoundarySuburb   *string `json:"boundary_suburb,omitempty"`
+	BoundaryPostcode *string `json:"boundary_postcode,omitempty"`
+	SuburbMismatch   *bool   `json:"suburb_mismatch,omitempty"`
+	PostcodeMismatch *bool   `json:"postcode_mismatch,omitempty"`
+	ListedSuburb     *string `json:"listed_suburb,omitempty"`
+	ListedPostcode   *string `json:"listed_postcode,omitempty"`
+
+	// Distance and drive time to each user POI (friends' houses, favourite
+	// trailheads), nearest by drive time first
+	POITimes []PropertyPOITime `json:"poi_times,omitempty"`
+
+	// Plus codes, what3words addresses and map links for the property's
+	// point and lots; only filled in for the detail API
+	Share *PropertyShare `json:"share,omitempty"`
+
+	// Fields taken from a duplicate listing, mapped to that listing's source
+	MergedFields map[string]string `json:"merged_fields,omitempty"`
 }
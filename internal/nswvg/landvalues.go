@@ -0,0 +1,135 @@
+package nswvg
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LandValue is the latest unimproved land value of one VG property. A VG
+// property may cover several lots; the value is for all of them together.
+type LandValue struct {
+	DistrictCode string
+	PropertyID   string // VG property ID
+	Address      string
+	Locality     string
+	Postcode     string
+	ZoneCode     string
+	AreaSqm      float64 // 0 if not recorded
+	LandValue    int64
+	BaseDate     string   // YYYY-MM-DD (valuing year's base date, usually 1 July)
+	Lots         []string // Lot IDs in cadastral format, e.g. "1//DP123456"
+}
+
+// ReadLandValues reads every land value in a land value .csv file, a zip of
+// them (the monthly statewide archive holds one file per district), or a
+// directory of either, calling fn for each property
+func ReadLandValues(path string, fn func(LandValue) error) error {
+	return walkFiles(path, ".csv", func(name string, r io.Reader) error {
+		if err := parseLandValues(r, fn); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		return nil
+	})
+}
+
+// parseLandValues parses a land value CSV, locating columns by header name
+func parseLandValues(r io.Reader, fn func(LandValue) error) error {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.LazyQuotes = true
+
+	header, err := cr.Read()
+	if err == io.EOF {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read header: %w", err)
+	}
+	cols := make(map[string]int)
+	for i, name := range header {
+		cols[strings.ToUpper(strings.TrimSpace(name))] = i
+	}
+	for _, required := range []string{"PROPERTY ID", "LAND VALUE 1", "PROPERTY DESCRIPTION"} {
+		if _, ok := cols[required]; !ok {
+			return fmt.Errorf("missing column %q", required)
+		}
+	}
+
+	for {
+		row, err := cr.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read row: %w", err)
+		}
+
+		field := func(name string) string {
+			if i, ok := cols[name]; ok && i < len(row) {
+				return strings.TrimSpace(row[i])
+			}
+			return ""
+		}
+
+		value, err := strconv.ParseInt(field("LAND VALUE 1"), 10, 64)
+		if err != nil || value <= 0 {
+			continue
+		}
+
+		lv := LandValue{
+			DistrictCode: field("DISTRICT CODE"),
+			PropertyID:   field("PROPERTY ID"),
+			Locality:     field("SUBURB NAME"),
+			Postcode:     field("POSTCODE"),
+			ZoneCode:     field("ZONE CODE"),
+			LandValue:    value,
+			BaseDate:     formatBaseDate(field("BASE DATE 1")),
+		}
+
+		var parts []string
+		if unit := field("UNIT NUMBER"); unit != "" {
+			parts = append(parts, unit+"/"+field("HOUSE NUMBER"))
+		} else if num := field("HOUSE NUMBER"); num != "" {
+			parts = append(parts, num)
+		}
+		if street := field("STREET NAME"); street != "" {
+			parts = append(parts, street)
+		}
+		lv.Address = strings.Join(parts, " ")
+
+		if area, err := strconv.ParseFloat(field("AREA"), 64); err == nil && area > 0 {
+			if field("AREA TYPE") == "H" {
+				area *= 10000
+			}
+			lv.AreaSqm = area
+		}
+
+		// Descriptions list each lot, e.g. "1/DP123456, 2/DP123456"
+		for _, desc := range strings.Split(field("PROPERTY DESCRIPTION"), ",") {
+			if lot := NormalizeLotID(desc); lot != "" {
+				lv.Lots = append(lv.Lots, lot)
+			}
+		}
+		if len(lv.Lots) == 0 {
+			continue
+		}
+
+		if err := fn(lv); err != nil {
+			return err
+		}
+	}
+}
+
+// formatBaseDate converts a DD/MM/YYYY base date to YYYY-MM-DD
+func formatBaseDate(s string) string {
+	for _, layout := range []string{"02/01/2006", "2/1/2006", "20060102", "2006-01-02"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t.Format("2006-01-02")
+		}
+	}
+	return ""
+}
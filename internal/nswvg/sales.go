@@ -0,0 +1,277 @@
+// Package nswvg reads the NSW Valuer General's bulk data files: property sales
+// information (PSI) and land values. Files are published at
+// https://www.valuergeneral.nsw.gov.au/land_value_summaries/ as zip archives of
+// semicolon-delimited .DAT files.
+package nswvg
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Sale is one sale from the PSI data. A sale covers one or more lots and its
+// price is for the whole dealing, not each lot.
+type Sale struct {
+	DistrictCode   string
+	PropertyID     string // VG property ID
+	SaleCounter    string
+	Address        string
+	Locality       string
+	Postcode       string
+	AreaSqm        float64 // 0 if not recorded
+	ContractDate   string  // YYYY-MM-DD
+	SettlementDate string  // YYYY-MM-DD
+	PurchasePrice  int64
+	Zoning         string
+	Nature         string // V = vacant land, R = residence, 3 = other
+	PrimaryPurpose string
+	DealingNumber  string
+	Lots           []string // Lot IDs in cadastral format, e.g. "1//DP123456"
+}
+
+// ReadSales reads every sale in a PSI .DAT file, a zip of them (yearly
+// archives containing weekly zips are handled), or a directory of either,
+// calling fn for each sale
+func ReadSales(path string, fn func(Sale) error) error {
+	return walkFiles(path, ".dat", func(name string, r io.Reader) error {
+		if err := parseSales(r, fn); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		return nil
+	})
+}
+
+// walkFiles calls fn with the contents of every file with extension ext
+// (lowercase, e.g. ".dat") under path, which may be a file, a zip or a directory
+func walkFiles(path, ext string, fn func(name string, r io.Reader) error) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", path, err)
+	}
+
+	if info.IsDir() {
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return fmt.Errorf("failed to read directory: %w", err)
+		}
+		for _, e := range entries {
+			if err := walkFiles(filepath.Join(path, e.Name()), ext, fn); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".zip":
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		return walkZip(path, data, ext, fn)
+	case ext:
+		f, err := os.Open(path)
+		if err != nil {
+			return fmt.Errorf("failed to open %s: %w", path, err)
+		}
+		defer f.Close()
+		return fn(path, f)
+	}
+	return nil
+}
+
+// walkZip calls fn for each file with extension ext in a zip archive,
+// descending into nested zips
+func walkZip(name string, data []byte, ext string, fn func(name string, r io.Reader) error) error {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return fmt.Errorf("failed to open zip %s: %w", name, err)
+	}
+
+	for _, f := range zr.File {
+		fileExt := strings.ToLower(filepath.Ext(f.Name))
+		if fileExt != ".zip" && fileExt != ext {
+			continue
+		}
+
+		rc, err := f.Open()
+		if err != nil {
+			return fmt.Errorf("failed to open %s in %s: %w", f.Name, name, err)
+		}
+		if fileExt == ".zip" {
+			inner, err := io.ReadAll(rc)
+			rc.Close()
+			if err != nil {
+				return fmt.Errorf("failed to read %s in %s: %w", f.Name, name, err)
+			}
+			if err := walkZip(f.Name, inner, ext, fn); err != nil {
+				return err
+			}
+			continue
+		}
+
+		err = fn(name+"/"+f.Name, rc)
+		rc.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseSales parses PSI records. Each sale is a B record followed by C records
+// giving the legal description of each lot in it.
+func parseSales(r io.Reader, fn func(Sale) error) error {
+	var current *Sale
+	flush := func() error {
+		if current == nil {
+			return nil
+		}
+		sale := *current
+		current = nil
+		if len(sale.Lots) == 0 {
+			return nil
+		}
+		return fn(sale)
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		fields := strings.Split(scanner.Text(), ";")
+		switch fields[0] {
+		case "B":
+			if err := flush(); err != nil {
+				return err
+			}
+			current = parseSaleRecord(fields)
+		case "C":
+			if current != nil && len(fields) > 5 {
+				if lot := NormalizeLotID(fields[5]); lot != "" {
+					current.Lots = append(current.Lots, lot)
+				}
+			}
+		default:
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	return flush()
+}
+
+// parseSaleRecord parses a B (sale) record. Returns nil if it has no price or contract date.
+func parseSaleRecord(f []string) *Sale {
+	if len(f) < 24 {
+		return nil
+	}
+	price, err := strconv.ParseInt(strings.TrimSpace(f[15]), 10, 64)
+	if err != nil || price <= 0 {
+		return nil
+	}
+	contractDate := formatDATDate(f[13])
+	if contractDate == "" {
+		return nil
+	}
+
+	sale := &Sale{
+		DistrictCode:   strings.TrimSpace(f[1]),
+		PropertyID:     strings.TrimSpace(f[2]),
+		SaleCounter:    strings.TrimSpace(f[3]),
+		Locality:       strings.TrimSpace(f[9]),
+		Postcode:       strings.TrimSpace(f[10]),
+		ContractDate:   contractDate,
+		SettlementDate: formatDATDate(f[14]),
+		PurchasePrice:  price,
+		Zoning:         strings.TrimSpace(f[16]),
+		Nature:         strings.TrimSpace(f[17]),
+		PrimaryPurpose: strings.TrimSpace(f[18]),
+		DealingNumber:  strings.TrimSpace(f[23]),
+	}
+
+	// Address from unit, house number and street
+	var parts []string
+	if unit := strings.TrimSpace(f[6]); unit != "" {
+		parts = append(parts, unit+"/")
+	}
+	for _, p := range []string{f[7], f[8]} {
+		if p = strings.TrimSpace(p); p != "" {
+			parts = append(parts, p)
+		}
+	}
+	sale.Address = strings.Replace(strings.Join(parts, " "), "/ ", "/", 1)
+
+	if area, err := strconv.ParseFloat(strings.TrimSpace(f[11]), 64); err == nil && area > 0 {
+		if strings.TrimSpace(f[12]) == "H" {
+			area *= 10000
+		}
+		sale.AreaSqm = area
+	}
+
+	return sale
+}
+
+// formatDATDate converts a CCYYMMDD date to YYYY-MM-DD
+func formatDATDate(s string) string {
+	s = strings.TrimSpace(s)
+	if len(s) != 8 {
+		return ""
+	}
+	if _, err := strconv.Atoi(s); err != nil {
+		return ""
+	}
+	return s[:4] + "-" + s[4:6] + "-" + s[6:]
+}
+
+var (
+	planPattern       = regexp.MustCompile(`^[A-Z]{2}\d+$`)
+	spacedPlanPattern = regexp.MustCompile(`\s*(DP|SP)\s*(\d+)`)
+)
+
+// NormalizeLotID converts a VG legal description like "1/DP123456",
+// "LOT 1 DP 123456" or "7/3/758123" to the cadastral lot ID format
+// ("lot/section/plan", e.g. "1//DP123456"). Returns "" if it can't be parsed.
+func NormalizeLotID(desc string) string {
+	desc = strings.ToUpper(strings.TrimSpace(desc))
+	desc = strings.TrimPrefix(desc, "LOT")
+	desc = strings.Join(strings.Fields(desc), " ")
+
+	// "1 DP 123456" -> "1/DP123456"
+	desc = spacedPlanPattern.ReplaceAllString(desc, "/$1$2")
+	desc = strings.ReplaceAll(desc, " ", "")
+
+	var parts []string
+	for _, p := range strings.Split(desc, "/") {
+		if p != "" {
+			parts = append(parts, p)
+		}
+	}
+	if len(parts) < 2 || len(parts) > 3 {
+		return ""
+	}
+
+	plan := parts[len(parts)-1]
+	if _, err := strconv.Atoi(plan); err == nil {
+		plan = "DP" + plan
+	}
+	if !planPattern.MatchString(plan) {
+		return ""
+	}
+
+	section := ""
+	if len(parts) == 3 {
+		section = parts[1]
+	}
+	return parts[0] + "/" + section + "/" + plan
+}
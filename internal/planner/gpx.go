@@ -0,0 +1,63 @@
+package planner
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+)
+
+type gpxPoint struct {
+	Lat  float64 `xml:"lat,attr"`
+	Lon  float64 `xml:"lon,attr"`
+	Name string  `xml:"name"`
+	Desc string  `xml:"desc,omitempty"`
+}
+
+type gpxDoc struct {
+	XMLName   xml.Name   `xml:"gpx"`
+	Version   string     `xml:"version,attr"`
+	Creator   string     `xml:"creator,attr"`
+	Namespace string     `xml:"xmlns,attr"`
+	Waypoints []gpxPoint `xml:"wpt"`
+	Route     struct {
+		Name   string     `xml:"name"`
+		Points []gpxPoint `xml:"rtept"`
+	} `xml:"rte"`
+}
+
+// WriteGPX writes a plan as a GPX route from the origin through each visit
+// and back, with a waypoint per visit. Navigation apps work out the roads.
+func WriteGPX(w io.Writer, plan *Plan) error {
+	doc := gpxDoc{
+		Version:   "1.1",
+		Creator:   "Farm Search",
+		Namespace: "http://www.topografix.com/GPX/1/1",
+	}
+	doc.Route.Name = "Inspections " + plan.Date
+
+	origin := gpxPoint{Lat: plan.OriginLat, Lon: plan.OriginLng, Name: plan.Origin}
+	doc.Route.Points = append(doc.Route.Points, origin)
+	for i, v := range plan.Visits {
+		point := gpxPoint{
+			Lat:  v.Lat,
+			Lon:  v.Lng,
+			Name: fmt.Sprintf("%d. %s", i+1, v.Name),
+			Desc: fmt.Sprintf("Arrive %s, inspect %s-%s",
+				v.Arrive.Format("3:04pm"), v.Start.Format("3:04pm"), v.Depart.Format("3:04pm")),
+		}
+		doc.Waypoints = append(doc.Waypoints, point)
+		doc.Route.Points = append(doc.Route.Points, point)
+	}
+	doc.Route.Points = append(doc.Route.Points, origin)
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
@@ -0,0 +1,116 @@
+package planner
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// CalendarEvent is an event in an iCalendar feed
+type CalendarEvent struct {
+	UID         string // Stable across feed refreshes so calendars update rather than duplicate
+	Summary     string
+	Description string
+	Location    string
+	URL         string
+	Start       time.Time
+	End         time.Time
+	Lat, Lng    float64 // Optional; written as GEO if set
+}
+
+// WriteICS writes events as an iCalendar (RFC 5545) calendar named name
+func WriteICS(w io.Writer, name string, events []CalendarEvent) error {
+	stamp := time.Now().UTC().Format("20060102T150405Z")
+
+	lines := []string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//Farm Search//Farm Search//EN",
+		"CALSCALE:GREGORIAN",
+		"METHOD:PUBLISH",
+		"X-WR-CALNAME:" + escapeICS(name),
+	}
+	for _, e := range events {
+		lines = append(lines,
+			"BEGIN:VEVENT",
+			"UID:"+e.UID,
+			"DTSTAMP:"+stamp,
+			"DTSTART:"+e.Start.UTC().Format("20060102T150405Z"),
+			"DTEND:"+e.End.UTC().Format("20060102T150405Z"),
+			"SUMMARY:"+escapeICS(e.Summary),
+		)
+		if e.Description != "" {
+			lines = append(lines, "DESCRIPTION:"+escapeICS(e.Description))
+		}
+		if e.Location != "" {
+			lines = append(lines, "LOCATION:"+escapeICS(e.Location))
+		}
+		if e.URL != "" {
+			lines = append(lines, "URL:"+e.URL)
+		}
+		if e.Lat != 0 || e.Lng != 0 {
+			lines = append(lines, fmt.Sprintf("GEO:%.6f;%.6f", e.Lat, e.Lng))
+		}
+		lines = append(lines, "END:VEVENT")
+	}
+	lines = append(lines, "END:VCALENDAR")
+
+	for _, line := range lines {
+		if _, err := io.WriteString(w, foldICS(line)+"\r\n"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// escapeICS escapes text property values
+func escapeICS(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+	return r.Replace(s)
+}
+
+// foldICS folds lines longer than 75 octets, continuing them with a leading
+// space, without splitting UTF-8 characters
+func foldICS(line string) string {
+	if len(line) <= 75 {
+		return line
+	}
+
+	var b strings.Builder
+	width := 0
+	for _, r := range line {
+		size := len(string(r))
+		if width+size > 75 {
+			b.WriteString("\r\n ")
+			width = 1
+		}
+		b.WriteRune(r)
+		width += size
+	}
+	return b.String()
+}
+
+// PlanEvents converts a plan's visits into calendar events
+func PlanEvents(plan *Plan) []CalendarEvent {
+	var events []CalendarEvent
+	for _, v := range plan.Visits {
+		description := fmt.Sprintf("Inspection %s-%s. %.0f min drive from previous stop.",
+			v.Window.Start.Format("3:04pm"), v.Window.End.Format("3:04pm"), v.DriveMins)
+		if v.URL != "" {
+			description += "\n" + v.URL
+		}
+		events = append(events, CalendarEvent{
+			UID:         fmt.Sprintf("plan-%s-%d@farm-search", plan.Date, v.PropertyID),
+			Summary:     "Inspect " + v.Name,
+			Description: description,
+			Location:    v.Name,
+			URL:         v.URL,
+			Start:       v.Start,
+			End:         v.Depart,
+			Lat:         v.Lat,
+			Lng:         v.Lng,
+		})
+	}
+	return events
+}
@@ -0,0 +1,165 @@
+// Package planner builds inspection day plans: which favorited properties to
+// visit in what order, fitted around their open-for-inspection times, and
+// exports them as calendar events and GPX routes.
+package planner
+
+import (
+	"time"
+
+	"farm-search/internal/geo"
+)
+
+// Stop is a property to visit. Index is its row in the drive time matrix.
+type Stop struct {
+	Index      int
+	PropertyID int64
+	Name       string
+	URL        string
+	Lat        float64
+	Lng        float64
+	Windows    []Window // Open-for-inspection times on the day
+}
+
+// Window is an open-for-inspection time
+type Window struct {
+	Start time.Time
+	End   time.Time
+}
+
+// Origin is where the day starts and ends. Index is its row in the drive time matrix.
+type Origin struct {
+	Index int
+	Name  string
+	Lat   float64
+	Lng   float64
+}
+
+// Visit is a scheduled stop
+type Visit struct {
+	PropertyID int64     `json:"property_id"`
+	Name       string    `json:"name"`
+	URL        string    `json:"url,omitempty"`
+	Lat        float64   `json:"lat"`
+	Lng        float64   `json:"lng"`
+	DriveMins  float64   `json:"drive_mins"` // From the previous stop
+	DriveKm    float64   `json:"drive_km"`
+	Arrive     time.Time `json:"arrive"`
+	Start      time.Time `json:"start"` // Arrival, or the inspection opening if arriving early
+	Depart     time.Time `json:"depart"`
+	Window     Window    `json:"-"`
+}
+
+// Missed is a stop that couldn't be fitted into the day
+type Missed struct {
+	PropertyID int64  `json:"property_id"`
+	Name       string `json:"name"`
+	Reason     string `json:"reason"`
+}
+
+// Plan is an inspection day
+type Plan struct {
+	Date            string    `json:"date"` // YYYY-MM-DD
+	Origin          string    `json:"origin"`
+	OriginLat       float64   `json:"origin_lat"`
+	OriginLng       float64   `json:"origin_lng"`
+	Depart          time.Time `json:"depart"`
+	Visits          []Visit   `json:"visits"`
+	Missed          []Missed  `json:"missed,omitempty"`
+	ReturnDriveMins float64   `json:"return_drive_mins"`
+	Return          time.Time `json:"return"`
+	TotalDriveMins  float64   `json:"total_drive_mins"`
+	TotalDriveKm    float64   `json:"total_drive_km"`
+}
+
+// Schedule plans the day greedily: from the current stop, go to whichever
+// remaining property's inspection can be finished soonest, waiting for it to
+// open if needed. Each visit takes visitLength, or the whole inspection if it's
+// shorter. Properties with no inspection on the day, or whose inspections
+// can't be reached in time, are returned as missed.
+func Schedule(matrix [][]*geo.RouteResult, origin Origin, stops []Stop, date string, depart time.Time, visitLength time.Duration) *Plan {
+	plan := &Plan{
+		Date:      date,
+		Origin:    origin.Name,
+		OriginLat: origin.Lat,
+		OriginLng: origin.Lng,
+		Depart:    depart,
+		Visits:    []Visit{},
+	}
+
+	var remaining []Stop
+	for _, s := range stops {
+		if len(s.Windows) == 0 {
+			plan.Missed = append(plan.Missed, Missed{PropertyID: s.PropertyID, Name: s.Name, Reason: "no inspection on this day"})
+			continue
+		}
+		remaining = append(remaining, s)
+	}
+
+	at := origin.Index
+	now := depart
+	for len(remaining) > 0 {
+		best := -1
+		var bestVisit Visit
+		for i, s := range remaining {
+			leg := matrix[at][s.Index]
+			if leg == nil {
+				continue
+			}
+			arrive := now.Add(time.Duration(leg.DurationMins * float64(time.Minute)))
+			for _, w := range s.Windows {
+				need := visitLength
+				if length := w.End.Sub(w.Start); length < need {
+					need = length
+				}
+				start := arrive
+				if start.Before(w.Start) {
+					start = w.Start
+				}
+				finish := start.Add(need)
+				if finish.After(w.End) {
+					continue
+				}
+				if best < 0 || finish.Before(bestVisit.Depart) {
+					best = i
+					bestVisit = Visit{
+						PropertyID: s.PropertyID,
+						Name:       s.Name,
+						URL:        s.URL,
+						Lat:        s.Lat,
+						Lng:        s.Lng,
+						DriveMins:  leg.DurationMins,
+						DriveKm:    leg.DistanceKm,
+						Arrive:     arrive,
+						Start:      start,
+						Depart:     finish,
+						Window:     w,
+					}
+				}
+			}
+		}
+		if best < 0 {
+			break
+		}
+
+		plan.Visits = append(plan.Visits, bestVisit)
+		plan.TotalDriveMins += bestVisit.DriveMins
+		plan.TotalDriveKm += bestVisit.DriveKm
+		at = remaining[best].Index
+		now = bestVisit.Depart
+		remaining = append(remaining[:best], remaining[best+1:]...)
+	}
+
+	for _, s := range remaining {
+		plan.Missed = append(plan.Missed, Missed{PropertyID: s.PropertyID, Name: s.Name, Reason: "can't reach an inspection in time"})
+	}
+
+	plan.Return = now
+	if leg := matrix[at][origin.Index]; leg != nil && at != origin.Index {
+		plan.ReturnDriveMins = leg.DurationMins
+		plan.TotalDriveMins += leg.DurationMins
+		plan.TotalDriveKm += leg.DistanceKm
+		plan.Return = now.Add(time.Duration(leg.DurationMins * float64(time.Minute)))
+	}
+
+	return plan
+}
@@ -0,0 +1,278 @@
+// Package publish renders a read-only snapshot of properties for static
+// hosting: an HTML page with a map, their GeoJSON and their images, so a
+// shortlist can be shared without access to the database or the server.
+package publish
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"farm-search/internal/models"
+)
+
+//go:embed index.html
+var indexHTML string
+
+var indexTemplate = template.Must(template.New("index").Parse(indexHTML))
+
+// maxImageBytes caps the size of an image downloaded into a snapshot
+const maxImageBytes = 10 << 20
+
+// imageExtensions maps the image types listings use to file extensions
+var imageExtensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/webp": ".webp",
+	"image/gif":  ".gif",
+}
+
+// Options controls what a snapshot shows
+type Options struct {
+	Title      string // Page heading, e.g. "Shortlist for Mum and Dad"
+	AnchorName string // What drive_time_primary is measured to, e.g. "Sutherland"
+	MaxImages  int    // Images downloaded per property; 0 for none
+}
+
+// Stats counts what a snapshot holds
+type Stats struct {
+	Properties   int
+	Images       int
+	FailedImages int
+}
+
+// Publisher writes snapshots, downloading listing images into them
+type Publisher struct {
+	httpClient *http.Client
+}
+
+// NewPublisher creates a new Publisher
+func NewPublisher() *Publisher {
+	return &Publisher{httpClient: &http.Client{Timeout: 30 * time.Second}}
+}
+
+// propertyView is a property as the snapshot page shows it
+type propertyView struct {
+	ID           int64
+	Address      string
+	Suburb       string
+	PriceText    string
+	PropertyType string
+	Description  string
+	URL          string
+	Facts        []string // e.g. "40.5 ha", "3 beds", "12 min to Bega"
+	Images       []string // Paths relative to the snapshot
+}
+
+// Write renders properties into dir as index.html, properties.geojson and
+// images/{id}/, in the order given. The images directory is replaced, so
+// re-publishing into the same directory drops the images of properties no
+// longer included. Only listing details go in; tags, attachments and notes
+// stay private. An image that can't be downloaded is left out.
+func (p *Publisher) Write(ctx context.Context, dir string, properties []*models.PropertyDetail, opts Options) (Stats, error) {
+	stats := Stats{Properties: len(properties)}
+	imagesDir := filepath.Join(dir, "images")
+	if err := os.RemoveAll(imagesDir); err != nil {
+		return stats, fmt.Errorf("failed to clear images: %w", err)
+	}
+	if err := os.MkdirAll(imagesDir, 0755); err != nil {
+		return stats, fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	views := make([]propertyView, 0, len(properties))
+	for i, prop := range properties {
+		view := newPropertyView(prop, opts.AnchorName)
+		for n, src := range prop.Images {
+			if len(view.Images) >= opts.MaxImages {
+				break
+			}
+			rel, err := p.downloadImage(ctx, dir, prop.ID, n+1, src)
+			if err != nil {
+				log.Printf("  Warning: Could not download image %d of property %d: %v", n+1, prop.ID, err)
+				stats.FailedImages++
+				continue
+			}
+			view.Images = append(view.Images, rel)
+			stats.Images++
+		}
+		log.Printf("[%d/%d] Property %d (%s): %d images", i+1, len(properties), prop.ID, view.Address, len(view.Images))
+		views = append(views, view)
+	}
+
+	geojson := featureCollection(views, properties)
+	data, err := json.MarshalIndent(geojson, "", "  ")
+	if err != nil {
+		return stats, err
+	}
+	if err := os.WriteFile(filepath.Join(dir, "properties.geojson"), data, 0644); err != nil {
+		return stats, fmt.Errorf("failed to write GeoJSON: %w", err)
+	}
+
+	f, err := os.Create(filepath.Join(dir, "index.html"))
+	if err != nil {
+		return stats, fmt.Errorf("failed to write index.html: %w", err)
+	}
+	defer f.Close()
+	err = indexTemplate.Execute(f, map[string]interface{}{
+		"Title":       opts.Title,
+		"GeneratedAt": time.Now().Format("2 January 2006"),
+		"Properties":  views,
+		// Embedded as well as written out, so the page works opened from disk
+		"GeoJSON": geojson,
+	})
+	if err != nil {
+		return stats, fmt.Errorf("failed to render index.html: %w", err)
+	}
+	return stats, f.Close()
+}
+
+// downloadImage saves a listing image as images/{id}/{n}.{ext}, returning
+// its path relative to the snapshot
+func (p *Publisher) downloadImage(ctx context.Context, dir string, propertyID int64, n int, src string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, src, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("status %d", resp.StatusCode)
+	}
+	contentType, _, _ := strings.Cut(resp.Header.Get("Content-Type"), ";")
+	ext, ok := imageExtensions[strings.TrimSpace(strings.ToLower(contentType))]
+	if !ok {
+		return "", fmt.Errorf("not an image: %q", contentType)
+	}
+
+	rel := path.Join("images", fmt.Sprint(propertyID), fmt.Sprintf("%d%s", n, ext))
+	target := filepath.Join(dir, filepath.FromSlash(rel))
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return "", err
+	}
+	f, err := os.Create(target)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	written, err := io.Copy(f, io.LimitReader(resp.Body, maxImageBytes+1))
+	if err == nil && written > maxImageBytes {
+		err = fmt.Errorf("larger than %d MB", maxImageBytes>>20)
+	}
+	if err != nil {
+		f.Close()
+		os.Remove(target)
+		return "", err
+	}
+	return rel, f.Close()
+}
+
+// newPropertyView picks the details a snapshot shows from a property
+func newPropertyView(p *models.PropertyDetail, anchorName string) propertyView {
+	v := propertyView{
+		ID:           p.ID,
+		Address:      p.Address,
+		Suburb:       p.Suburb,
+		PriceText:    p.PriceText,
+		PropertyType: p.PropertyType,
+		Description:  p.Description,
+		URL:          p.URL,
+		Facts:        []string{},
+	}
+	if v.Address == "" {
+		v.Address = p.Suburb
+	}
+	if v.PriceText == "" {
+		v.PriceText = "Contact agent"
+	}
+
+	if p.LandSizeSqm != nil && *p.LandSizeSqm > 0 {
+		v.Facts = append(v.Facts, formatLandSize(*p.LandSizeSqm))
+	}
+	if p.Bedrooms != nil && *p.Bedrooms > 0 {
+		v.Facts = append(v.Facts, fmt.Sprintf("%d beds", *p.Bedrooms))
+	}
+	if p.Bathrooms != nil && *p.Bathrooms > 0 {
+		v.Facts = append(v.Facts, fmt.Sprintf("%d baths", *p.Bathrooms))
+	}
+	if p.Carspaces != nil && *p.Carspaces > 0 {
+		v.Facts = append(v.Facts, fmt.Sprintf("%d cars", *p.Carspaces))
+	}
+	if p.DriveTimePrimary != nil && anchorName != "" {
+		v.Facts = append(v.Facts, fmt.Sprintf("%s to %s", formatDriveTime(*p.DriveTimePrimary), anchorName))
+	}
+	if p.NearestTown1 != nil {
+		switch {
+		case p.NearestTown1Mins != nil:
+			v.Facts = append(v.Facts, fmt.Sprintf("%s to %s", formatDriveTime(*p.NearestTown1Mins), *p.NearestTown1))
+		case p.NearestTown1Km != nil:
+			v.Facts = append(v.Facts, fmt.Sprintf("%.0f km to %s", *p.NearestTown1Km, *p.NearestTown1))
+		}
+	}
+	return v
+}
+
+// featureCollection returns the properties as GeoJSON points, with the
+// details the snapshot shows and their first image
+func featureCollection(views []propertyView, properties []*models.PropertyDetail) map[string]interface{} {
+	features := make([]map[string]interface{}, 0, len(views))
+	for i, v := range views {
+		p := properties[i]
+		props := map[string]interface{}{
+			"id":            v.ID,
+			"address":       v.Address,
+			"suburb":        v.Suburb,
+			"price_text":    v.PriceText,
+			"property_type": v.PropertyType,
+			"url":           v.URL,
+			"facts":         v.Facts,
+		}
+		if p.LandSizeSqm != nil {
+			props["land_size_sqm"] = *p.LandSizeSqm
+		}
+		if len(v.Images) > 0 {
+			props["image"] = v.Images[0]
+		}
+		features = append(features, map[string]interface{}{
+			"type": "Feature",
+			"geometry": map[string]interface{}{
+				"type":        "Point",
+				"coordinates": []float64{p.Longitude, p.Latitude},
+			},
+			"properties": props,
+		})
+	}
+	return map[string]interface{}{"type": "FeatureCollection", "features": features}
+}
+
+// formatLandSize formats a land size in hectares, or square metres under one
+func formatLandSize(sqm float64) string {
+	if sqm >= 10000 {
+		return fmt.Sprintf("%g ha", math.Round(sqm/1000)/10)
+	}
+	return fmt.Sprintf("%.0f sqm", sqm)
+}
+
+// formatDriveTime formats minutes as e.g. "45 min" or "1 h 20 min"
+func formatDriveTime(mins int) string {
+	if mins < 60 {
+		return fmt.Sprintf("%d min", mins)
+	}
+	if mins%60 == 0 {
+		return fmt.Sprintf("%d h", mins/60)
+	}
+	return fmt.Sprintf("%d h %d min", mins/60, mins%60)
+}
@@ -0,0 +1,80 @@
+// Package sanitize cleans scraped text for storage. The database applies it
+// as listings are saved, so every writer stores descriptions the same way.
+package sanitize
+
+import (
+	"html"
+	"regexp"
+	"strings"
+)
+
+var (
+	// Blocks whose content should never end up in a description
+	scriptStylePattern = regexp.MustCompile(`(?is)<(script|style)[^>]*>.*?</(script|style)>`)
+	// HTML comments, which tagPattern leaves alone
+	commentPattern = regexp.MustCompile(`(?s)<!--.*?-->`)
+	// Tags that end a line of text
+	lineBreakTagPattern = regexp.MustCompile(`(?i)<br\s*/?>|</(p|div|h[1-6]|ul|ol|tr)>`)
+	// List items become bullets
+	listItemTagPattern = regexp.MustCompile(`(?i)<li[^>]*>`)
+	// Any remaining tag. Only tag names count, so a "< 10ha" or "<$500k>" in
+	// the text is kept
+	tagPattern = regexp.MustCompile(`</?[a-zA-Z][^>]*>`)
+	// Bullet characters agents use at the start of a line
+	bulletPattern = regexp.MustCompile(`^(?:[-*•·▪●◦‣–]|&bull;)\s*`)
+	// Runs of spaces and tabs
+	spacePattern = regexp.MustCompile(`[ \t]+`)
+	// Three or more newlines
+	blankLinesPattern = regexp.MustCompile(`\n{3,}`)
+)
+
+// Description converts a listing description into plain text.
+// Sources return descriptions with varying amounts of HTML: REA and Domain include
+// <br> and <b> tags, FarmBuy and FarmProperty include entities, and some agents
+// double-encode entities or use assorted bullet characters. This strips tags,
+// decodes entities, normalizes bullets to "• " and collapses whitespace so every
+// source is stored the same way.
+func Description(raw string) string {
+	if raw == "" {
+		return ""
+	}
+
+	s := strings.ReplaceAll(raw, "\r\n", "\n")
+	s = strings.ReplaceAll(s, "\r", "\n")
+
+	// Tags to text structure
+	s = scriptStylePattern.ReplaceAllString(s, "")
+	s = commentPattern.ReplaceAllString(s, "")
+	s = lineBreakTagPattern.ReplaceAllString(s, "\n")
+	s = listItemTagPattern.ReplaceAllString(s, "\n• ")
+	s = tagPattern.ReplaceAllString(s, "")
+
+	// Decode entities (twice, as some agents' feeds double-encode e.g. "&amp;amp;")
+	s = html.UnescapeString(s)
+	if strings.Contains(s, "&") {
+		s = html.UnescapeString(s)
+	}
+	// Entity decoding can reveal tags that were escaped in the source
+	s = lineBreakTagPattern.ReplaceAllString(s, "\n")
+	s = tagPattern.ReplaceAllString(s, "")
+
+	s = strings.ReplaceAll(s, " ", " ")
+	s = strings.ReplaceAll(s, "​", "")
+
+	// Normalize each line: collapse spaces, unify bullets
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		line = strings.TrimSpace(spacePattern.ReplaceAllString(line, " "))
+		if bulletPattern.MatchString(line) {
+			line = bulletPattern.ReplaceAllString(line, "• ")
+			if line == "• " {
+				line = ""
+			}
+		}
+		lines[i] = line
+	}
+	s = strings.Join(lines, "\n")
+
+	s = blankLinesPattern.ReplaceAllString(s, "\n\n")
+	return strings.TrimSpace(s)
+}
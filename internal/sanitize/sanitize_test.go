@@ -0,0 +1,56 @@
+package sanitize
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var updateGolden = flag.Bool("update", false, "rewrite testdata golden files from the current output")
+
+// TestDescriptionGolden runs each source's sample description in
+// testdata/<source>.in through Description and compares it with
+// <source>.golden. Run with -update to rewrite the golden files.
+func TestDescriptionGolden(t *testing.T) {
+	inputs, err := filepath.Glob(filepath.Join("testdata", "*.in"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(inputs) == 0 {
+		t.Fatal("no testdata/*.in files")
+	}
+
+	for _, input := range inputs {
+		source := strings.TrimSuffix(filepath.Base(input), ".in")
+		t.Run(source, func(t *testing.T) {
+			raw, err := os.ReadFile(input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := Description(string(raw))
+
+			golden := strings.TrimSuffix(input, ".in") + ".golden"
+			if *updateGolden {
+				if err := os.WriteFile(golden, []byte(got), 0o644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("%v (run with -update to create it)", err)
+			}
+			if got != string(want) {
+				t.Errorf("Description(%s) =\n%s\n\nwant:\n%s", input, got, want)
+			}
+		})
+	}
+}
+
+func TestDescriptionEmpty(t *testing.T) {
+	if got := Description(""); got != "" {
+		t.Errorf("Description(\"\") = %q, want \"\"", got)
+	}
+}
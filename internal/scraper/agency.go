@@ -0,0 +1,469 @@
+package scraper
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"log"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"farm-search/internal/models"
+	"farm-search/internal/units"
+)
+
+// AgencySite describes a rural agency network's own listings website.
+// These sites all render a paged list of search results linking to detail
+// pages that carry schema.org JSON-LD, so one scraper handles every site and
+// adding a new agency only needs a new entry in AgencySites.
+type AgencySite struct {
+	Source      string         // Source stored with listings, e.g. "elders"
+	Name        string         // Display name used in logs
+	BaseURL     string         // Site root, without trailing slash
+	SearchPath  string         // Rural-for-sale search results path; %s = state slug, %d = page number
+	ListingLink *regexp.Regexp // Matches detail page links; group 1 = URL or path, group 2 = listing ID
+}
+
+// AgencySites are the rural agency sites that can be scraped. Large holdings
+// are often listed here before (or instead of) REA and Domain.
+var AgencySites = []AgencySite{
+	{
+		Source:      "elders",
+		Name:        "Elders",
+		BaseURL:     "https://www.eldersrealestate.com.au",
+		SearchPath:  "/buy/rural/%s/?sort=date-desc&page=%d",
+		ListingLink: regexp.MustCompile(`href="((?:https://www\.eldersrealestate\.com\.au)?/property/[a-z0-9\-]+-(\d{5,})/?)"`),
+	},
+	{
+		Source:      "raywhite-rural",
+		Name:        "Ray White Rural",
+		BaseURL:     "https://raywhiteruralnsw.com.au",
+		SearchPath:  "/properties/rural?state=%s&sort=updatedAt&page=%d",
+		ListingLink: regexp.MustCompile(`href="((?:https://raywhiteruralnsw\.com\.au)?/properties/[a-z\-]+/[a-z]+/[a-z0-9\-]+/[a-z\-]+/(\d{5,}))"`),
+	},
+	{
+		Source:      "nutrien",
+		Name:        "Nutrien Harcourts",
+		BaseURL:     "https://nutrienharcourts.com.au",
+		SearchPath:  "/listings/rural-for-sale?state=%s&sort=newest&page=%d",
+		ListingLink: regexp.MustCompile(`href="((?:https://nutrienharcourts\.com\.au)?/listing/([A-Z]{1,3}\d{5,})[^"]*)"`),
+	},
+}
+
+// agencySite returns the agency site with the given source, if there is one
+func agencySite(source string) (AgencySite, bool) {
+	for _, site := range AgencySites {
+		if site.Source == source {
+			return site, true
+		}
+	}
+	return AgencySite{}, false
+}
+
+// AgencyScraper handles scraping from a rural agency site
+type AgencyScraper struct {
+	client      *http.Client
+	userAgent   string
+	site        AgencySite
+	profile     SearchProfile
+	diagnostics *ParseDiagnostics
+	fixtures    *FixtureRecorder
+}
+
+// NewAgencyScraper creates a new scraper for the given agency site
+func NewAgencyScraper(site AgencySite) *AgencyScraper {
+	return &AgencyScraper{
+		client: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+		userAgent: "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36",
+		site:      site,
+		profile:   DefaultSearchProfile(),
+	}
+}
+
+// Site returns the agency site this scraper targets
+func (s *AgencyScraper) Site() AgencySite {
+	return s.site
+}
+
+// SetSearchProfile sets the land size and price limits listings are filtered against
+func (s *AgencyScraper) SetSearchProfile(profile SearchProfile) {
+	s.profile = profile
+}
+
+// SetDiagnostics sets the collector that records which extraction path parsed each page
+func (s *AgencyScraper) SetDiagnostics(d *ParseDiagnostics) {
+	s.diagnostics = d
+}
+
+// SetFixtureRecorder sets the recorder that saves fetched pages as parser fixtures
+func (s *AgencyScraper) SetFixtureRecorder(r *FixtureRecorder) {
+	s.fixtures = r
+}
+
+// agencyLink is a listing found on a search results page
+type agencyLink struct {
+	id  string
+	url string
+}
+
+// ScrapeListingsWithExistsCheck scrapes property listings with optional duplicate detection.
+// If existsChecker is provided and a page contains no new properties, scraping stops early.
+// Detail pages are only fetched for listings that haven't been scraped before.
+func (s *AgencyScraper) ScrapeListingsWithExistsCheck(ctx context.Context, state string, maxPages int, existsChecker ExistsChecker) ([]models.Property, error) {
+	var allListings []models.Property
+
+	for page := 1; maxPages <= 0 || page <= maxPages; page++ {
+		select {
+		case <-ctx.Done():
+			return allListings, ctx.Err()
+		default:
+		}
+
+		log.Printf("Scraping %s page %d for %s...", s.site.Name, page, state)
+
+		links, hasMore, err := s.scrapePage(ctx, state, page)
+		if err != nil {
+			log.Printf("Error scraping page %d: %v", page, err)
+			break
+		}
+
+		// If we have an exists checker, check if any properties on this page are new
+		existsMap := make(map[string]bool)
+		if existsChecker != nil && len(links) > 0 {
+			externalIDs := make([]string, len(links))
+			for i, l := range links {
+				externalIDs[i] = l.id
+			}
+
+			existing, err := existsChecker(externalIDs)
+			if err != nil {
+				log.Printf("Warning: failed to check existing properties: %v", err)
+			} else {
+				existsMap = existing
+				newCount := 0
+				for _, l := range links {
+					if !existsMap[l.id] {
+						newCount++
+					}
+				}
+
+				log.Printf("Page %d: %d listings, %d new, %d already scraped", page, len(links), newCount, len(links)-newCount)
+
+				// If no new properties on this page, stop pagination
+				if newCount == 0 {
+					log.Printf("No new properties found on page %d, stopping pagination (all %d already scraped)", page, len(links))
+					break
+				}
+			}
+		}
+
+		var listings []models.Property
+		for _, l := range links {
+			if existsMap[l.id] {
+				continue
+			}
+
+			listing, err := s.FetchListingDetails(ctx, l.url, l.id)
+			if err != nil {
+				log.Printf("Error fetching %s listing %s: %v", s.site.Name, l.id, err)
+				continue
+			}
+
+			// Agency search URLs don't take land size or price filters, so apply the profile here
+			if s.profile.Matches(listing) {
+				listings = append(listings, *listing)
+			}
+
+			// Rate limiting between detail fetches
+			time.Sleep(500 * time.Millisecond)
+		}
+
+		allListings = append(allListings, listings...)
+		log.Printf("Found %d listings on page %d (total: %d)", len(listings), page, len(allListings))
+
+		if !hasMore || len(links) == 0 {
+			break
+		}
+
+		// Rate limiting between pages
+		time.Sleep(1 * time.Second)
+	}
+
+	return allListings, nil
+}
+
+// scrapePage fetches one page of search results and returns the listings linked from it
+func (s *AgencyScraper) scrapePage(ctx context.Context, state string, page int) ([]agencyLink, bool, error) {
+	// Search results are sorted newest first so early-stop logic works
+	searchURL := s.site.BaseURL + fmt.Sprintf(s.site.SearchPath, strings.ToLower(state), page)
+
+	body, err := s.fetch(ctx, searchURL)
+	if err != nil {
+		return nil, false, err
+	}
+	s.fixtures.Capture(s.site.Source, "search", searchURL, "html", body)
+
+	var links []agencyLink
+	seenIDs := make(map[string]bool)
+	for _, match := range s.site.ListingLink.FindAllStringSubmatch(body, -1) {
+		if len(match) < 3 || seenIDs[match[2]] {
+			continue
+		}
+		seenIDs[match[2]] = true
+
+		link := html.UnescapeString(match[1])
+		if strings.HasPrefix(link, "/") {
+			link = s.site.BaseURL + link
+		}
+		links = append(links, agencyLink{id: match[2], url: link})
+	}
+	s.diagnostics.Record(s.site.Source, "listing_links", len(links))
+
+	// Check if there are more pages
+	hasMore := strings.Contains(body, `rel="next"`) ||
+		strings.Contains(body, fmt.Sprintf("page=%d", page+1))
+
+	return links, hasMore, nil
+}
+
+// FetchListingDetails fetches full details for a single listing
+func (s *AgencyScraper) FetchListingDetails(ctx context.Context, listingURL, listingID string) (*models.Property, error) {
+	body, err := s.fetch(ctx, listingURL)
+	if err != nil {
+		return nil, err
+	}
+	s.fixtures.Capture(s.site.Source, "detail", listingURL, "html", body)
+
+	return parseStructuredListing(body, s.site.Source, listingURL, listingID), nil
+}
+
+var (
+	agencyJSONLDPattern   = regexp.MustCompile(`<script[^>]+type="application/ld(?:\+|&#x2B;)json"[^>]*>([\s\S]*?)</script>`)
+	agencyMetaPattern     = regexp.MustCompile(`<meta[^>]+property="og:(title|description|image)"[^>]+content="([^"]*)"`)
+	agencyPricePattern    = regexp.MustCompile(`<[a-z]+[^>]*class="[^"]*price[^"]*"[^>]*>\s*([^<]+?)\s*<`)
+	agencyLatLngPattern   = regexp.MustCompile(`data-lat(?:itude)?="(-?\d+\.\d+)"[^>]*data-(?:lng|lon|longitude)="(-?\d+\.\d+)"`)
+	agencyPostcodePattern = regexp.MustCompile(`\b(NSW|VIC|QLD|SA|WA|TAS|NT|ACT)\s+(\d{4})\b`)
+)
+
+// parseStructuredListing extracts a listing from a detail page (agency listings and
+// Gumtree ads). Details come from the page's JSON-LD where present, with Open Graph
+// tags and the visible price and land size text filling the gaps.
+func parseStructuredListing(body, source, listingURL, listingID string) *models.Property {
+	now := time.Now()
+	listing := &models.Property{
+		ExternalID:   listingID,
+		Source:       source,
+		URL:          listingURL,
+		State:        "NSW",
+		PropertyType: sql.NullString{String: "rural", Valid: true},
+		ScrapedAt:    now,
+		UpdatedAt:    now,
+	}
+
+	var images []string
+	for _, match := range agencyJSONLDPattern.FindAllStringSubmatch(body, -1) {
+		var data interface{}
+		if err := json.Unmarshal([]byte(strings.ReplaceAll(match[1], "&#x2B;", "+")), &data); err != nil {
+			continue
+		}
+		images = append(images, applyAgencyJSONLD(listing, data)...)
+	}
+
+	// Open Graph tags fill in anything the JSON-LD didn't have
+	for _, match := range agencyMetaPattern.FindAllStringSubmatch(body, -1) {
+		content := strings.TrimSpace(html.UnescapeString(match[2]))
+		if content == "" {
+			continue
+		}
+		switch match[1] {
+		case "title":
+			if !listing.Address.Valid {
+				listing.Address = sql.NullString{String: content, Valid: true}
+			}
+		case "description":
+			if !listing.Description.Valid {
+				listing.Description = sql.NullString{String: content, Valid: true}
+			}
+		case "image":
+			if len(images) == 0 {
+				images = append(images, content)
+			}
+		}
+	}
+
+	if len(images) > 0 {
+		imgJSON, _ := json.Marshal(dedupeStrings(images))
+		listing.Images = sql.NullString{String: string(imgJSON), Valid: true}
+	}
+
+	if !listing.Latitude.Valid {
+		if m := agencyLatLngPattern.FindStringSubmatch(body); len(m) == 3 {
+			lat, errLat := strconv.ParseFloat(m[1], 64)
+			lng, errLng := strconv.ParseFloat(m[2], 64)
+			if errLat == nil && errLng == nil {
+				listing.Latitude = sql.NullFloat64{Float64: lat, Valid: true}
+				listing.Longitude = sql.NullFloat64{Float64: lng, Valid: true}
+			}
+		}
+	}
+
+	if !listing.PriceText.Valid {
+		if m := agencyPricePattern.FindStringSubmatch(body); len(m) > 1 {
+			listing.PriceText = sql.NullString{String: html.UnescapeString(m[1]), Valid: true}
+		}
+	}
+	if listing.PriceText.Valid && strings.Contains(listing.PriceText.String, "$") {
+		min, max := extractPriceRange(listing.PriceText.String)
+		if min > 0 {
+			listing.PriceMin = sql.NullInt64{Int64: min, Valid: true}
+			listing.PriceMax = sql.NullInt64{Int64: max, Valid: true}
+		}
+	}
+
+	if sqm := units.FindLandSize(body); sqm > 0 {
+		listing.LandSizeSqm = sql.NullFloat64{Float64: sqm, Valid: true}
+	}
+	if listing.Description.Valid {
+		listing.Carspaces = findCarspaces(listing.Description.String)
+	}
+
+	if !listing.Postcode.Valid {
+		if m := agencyPostcodePattern.FindStringSubmatch(body); len(m) == 3 {
+			listing.Postcode = sql.NullString{String: m[2], Valid: true}
+		}
+	}
+	if listing.Postcode.Valid {
+		listing.State = stateFromPostcode(listing.Postcode.String)
+	}
+
+	return listing
+}
+
+// applyAgencyJSONLD copies address, coordinates, description and price from a
+// JSON-LD document (an object, array or @graph) into the listing, and returns
+// any image URLs it contains
+func applyAgencyJSONLD(listing *models.Property, data interface{}) []string {
+	var images []string
+
+	switch v := data.(type) {
+	case []interface{}:
+		for _, item := range v {
+			images = append(images, applyAgencyJSONLD(listing, item)...)
+		}
+	case map[string]interface{}:
+		if graph, ok := v["@graph"]; ok {
+			images = append(images, applyAgencyJSONLD(listing, graph)...)
+		}
+
+		if name, ok := v["name"].(string); ok && !listing.Address.Valid {
+			if _, hasAddress := v["address"]; hasAddress {
+				listing.Address = sql.NullString{String: name, Valid: true}
+			}
+		}
+		if desc, ok := v["description"].(string); ok && !listing.Description.Valid {
+			listing.Description = sql.NullString{String: desc, Valid: true}
+		}
+
+		switch img := v["image"].(type) {
+		case string:
+			images = append(images, img)
+		case []interface{}:
+			for _, i := range img {
+				if u, ok := i.(string); ok {
+					images = append(images, u)
+				}
+			}
+		}
+
+		if addr, ok := v["address"].(map[string]interface{}); ok {
+			if street, ok := addr["streetAddress"].(string); ok && street != "" {
+				listing.Address = sql.NullString{String: street, Valid: true}
+			}
+			if locality, ok := addr["addressLocality"].(string); ok && locality != "" {
+				listing.Suburb = sql.NullString{String: locality, Valid: true}
+			}
+			if postcode, ok := addr["postalCode"].(string); ok && postcode != "" {
+				listing.Postcode = sql.NullString{String: postcode, Valid: true}
+			}
+		}
+
+		if geo, ok := v["geo"].(map[string]interface{}); ok {
+			lat, latOK := jsonNumber(geo["latitude"])
+			lng, lngOK := jsonNumber(geo["longitude"])
+			if latOK && lngOK && lat != 0 && lng != 0 {
+				listing.Latitude = sql.NullFloat64{Float64: lat, Valid: true}
+				listing.Longitude = sql.NullFloat64{Float64: lng, Valid: true}
+			}
+		}
+
+		if offers, ok := v["offers"]; ok {
+			images = append(images, applyAgencyJSONLD(listing, offers)...)
+		}
+		if price, ok := jsonNumber(v["price"]); ok && price > 0 && !listing.PriceText.Valid {
+			listing.PriceText = sql.NullString{String: fmt.Sprintf("$%.0f", price), Valid: true}
+		}
+	}
+
+	return images
+}
+
+// jsonNumber reads a JSON-LD number, which sites write as either a number or a string
+func jsonNumber(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case string:
+		f, err := strconv.ParseFloat(strings.ReplaceAll(n, ",", ""), 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// dedupeStrings removes repeated values, keeping the first occurrence's order
+func dedupeStrings(values []string) []string {
+	seen := make(map[string]bool)
+	var result []string
+	for _, v := range values {
+		if !seen[v] {
+			seen[v] = true
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+func (s *AgencyScraper) fetch(ctx context.Context, url string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return "", err
+	}
+
+	req.Header.Set("User-Agent", s.userAgent)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8")
+	req.Header.Set("Accept-Language", "en-AU,en;q=0.9")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("HTTP %d: %s", resp.StatusCode, resp.Status)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+
+	return string(body), nil
+}
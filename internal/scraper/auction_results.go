@@ -0,0 +1,283 @@
+package scraper
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"farm-search/internal/models"
+)
+
+// AuctionCities are the cities Domain publishes weekly auction results for
+var AuctionCities = []string{"sydney", "canberra", "melbourne", "brisbane", "adelaide"}
+
+// AuctionResultsScraper scrapes Domain's weekly auction results pages
+// (domain.com.au/auction-results/<city>/), which list every reported auction
+// for the past Saturday with its outcome and, where disclosed, the sold price
+type AuctionResultsScraper struct {
+	web      *DomainWebScraper
+	baseURL  string
+	allTypes bool
+}
+
+// NewAuctionResultsScraper creates a new auction results scraper.
+// Only rural, acreage and land results are kept unless allTypes is set.
+func NewAuctionResultsScraper(allTypes bool) *AuctionResultsScraper {
+	return &AuctionResultsScraper{
+		web:      NewDomainWebScraper(),
+		baseURL:  "https://www.domain.com.au/auction-results",
+		allTypes: allTypes,
+	}
+}
+
+// SetFixtureRecorder sets the recorder that saves fetched pages as parser fixtures
+func (s *AuctionResultsScraper) SetFixtureRecorder(r *FixtureRecorder) {
+	s.web.SetFixtureRecorder(r)
+}
+
+// ScrapeResults fetches the latest auction results for a city
+func (s *AuctionResultsScraper) ScrapeResults(ctx context.Context, city string) ([]models.AuctionResult, error) {
+	pageURL := fmt.Sprintf("%s/%s/", s.baseURL, strings.ToLower(city))
+
+	body, err := s.web.fetch(ctx, pageURL)
+	if err != nil {
+		return nil, err
+	}
+	s.web.fixtures.Capture("domain-auctions", "search", pageURL, "html", body)
+
+	startMarker := `<script id="__NEXT_DATA__" type="application/json">`
+	start := strings.Index(body, startMarker)
+	if start < 0 {
+		return nil, fmt.Errorf("no __NEXT_DATA__ on auction results page")
+	}
+	start += len(startMarker)
+	end := strings.Index(body[start:], "</script>")
+	if end < 0 {
+		return nil, fmt.Errorf("unterminated __NEXT_DATA__ on auction results page")
+	}
+
+	var data interface{}
+	if err := json.Unmarshal([]byte(body[start:start+end]), &data); err != nil {
+		return nil, fmt.Errorf("failed to parse __NEXT_DATA__: %w", err)
+	}
+
+	// Results are published for the Saturday just gone; the page data usually
+	// carries the date, otherwise fall back to the most recent Saturday
+	auctionDate := findAuctionDate(data)
+	if auctionDate == "" {
+		auctionDate = lastSaturday(time.Now()).Format("2006-01-02")
+	}
+
+	now := time.Now()
+	var results []models.AuctionResult
+	seen := make(map[string]bool)
+	for _, item := range findAuctionItems(data, 0) {
+		r := parseAuctionItem(item)
+		if r == nil {
+			continue
+		}
+		if !s.allTypes && !isRuralAuctionType(r.PropertyType.String) {
+			continue
+		}
+
+		key := strings.ToLower(r.Address + "|" + r.Suburb)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		r.Source = "domain"
+		r.City = strings.ToLower(city)
+		r.AuctionDate = auctionDate
+		r.ScrapedAt = now
+		results = append(results, *r)
+	}
+
+	return results, nil
+}
+
+// findAuctionItems walks the page data for auction result objects: objects with
+// a result code and a suburb
+func findAuctionItems(data interface{}, depth int) []map[string]interface{} {
+	if depth > 12 {
+		return nil
+	}
+
+	var items []map[string]interface{}
+	switch v := data.(type) {
+	case map[string]interface{}:
+		_, hasResult := v["result"].(string)
+		_, hasSuburb := v["suburb"]
+		if hasResult && hasSuburb {
+			return []map[string]interface{}{v}
+		}
+		for _, child := range v {
+			items = append(items, findAuctionItems(child, depth+1)...)
+		}
+	case []interface{}:
+		for _, child := range v {
+			items = append(items, findAuctionItems(child, depth+1)...)
+		}
+	}
+	return items
+}
+
+// findAuctionDate looks for the results date in the page data
+func findAuctionDate(data interface{}) string {
+	var found string
+	var walk func(v interface{}, depth int)
+	walk = func(v interface{}, depth int) {
+		if found != "" || depth > 6 {
+			return
+		}
+		switch m := v.(type) {
+		case map[string]interface{}:
+			for _, key := range []string{"auctionDate", "resultsDate", "date"} {
+				if s, ok := m[key].(string); ok {
+					if t, err := time.Parse("2006-01-02", s[:min(len(s), 10)]); err == nil {
+						found = t.Format("2006-01-02")
+						return
+					}
+				}
+			}
+			for _, child := range m {
+				walk(child, depth+1)
+			}
+		case []interface{}:
+			for _, child := range m {
+				walk(child, depth+1)
+			}
+		}
+	}
+	walk(data, 0)
+	return found
+}
+
+// parseAuctionItem converts one auction result object into an AuctionResult.
+// Domain writes the address either as a single string or as street parts.
+func parseAuctionItem(item map[string]interface{}) *models.AuctionResult {
+	str := func(key string) string {
+		if s, ok := item[key].(string); ok {
+			return strings.TrimSpace(s)
+		}
+		return ""
+	}
+
+	r := &models.AuctionResult{
+		Suburb: toTitleCase(str("suburb")),
+		State:  strings.ToUpper(str("state")),
+		Result: normalizeAuctionResult(str("result")),
+	}
+	if r.State == "" {
+		r.State = "NSW"
+	}
+
+	address := str("address")
+	if address == "" {
+		var parts []string
+		if unit := str("unitNumber"); unit != "" {
+			parts = append(parts, unit+"/"+str("streetNumber"))
+		} else if num := str("streetNumber"); num != "" {
+			parts = append(parts, num)
+		}
+		for _, key := range []string{"streetName", "streetType"} {
+			if v := str(key); v != "" {
+				parts = append(parts, v)
+			}
+		}
+		address = strings.Join(parts, " ")
+	}
+	if address == "" || r.Suburb == "" || r.Result == "" {
+		return nil
+	}
+	r.Address = address
+
+	if v := str("postcode"); v != "" {
+		r.Postcode = sql.NullString{String: v, Valid: true}
+	}
+	if v := str("propertyType"); v != "" {
+		r.PropertyType = sql.NullString{String: v, Valid: true}
+	}
+	if beds, ok := jsonNumber(item["bedrooms"]); ok && beds > 0 {
+		r.Bedrooms = sql.NullInt64{Int64: int64(beds), Valid: true}
+	}
+	if price, ok := jsonNumber(item["price"]); ok && price > 0 {
+		r.SoldPrice = sql.NullInt64{Int64: int64(price), Valid: true}
+	}
+	if v := str("agencyName"); v != "" {
+		r.Agency = sql.NullString{String: v, Valid: true}
+	} else if v := str("agency"); v != "" {
+		r.Agency = sql.NullString{String: v, Valid: true}
+	}
+	for _, key := range []string{"propertyDetailsUrl", "url"} {
+		if v := str(key); v != "" {
+			if strings.HasPrefix(v, "/") {
+				v = "https://www.domain.com.au" + v
+			}
+			r.URL = sql.NullString{String: v, Valid: true}
+			break
+		}
+	}
+
+	return r
+}
+
+// normalizeAuctionResult maps Domain's result codes and labels to our result values
+func normalizeAuctionResult(code string) string {
+	c := strings.ToUpper(strings.TrimSpace(code))
+	switch {
+	case c == "":
+		return ""
+	case c == "AUSP" || strings.Contains(c, "PRIOR"):
+		return "sold_prior"
+	case c == "AUSA" || strings.Contains(c, "AFTER"):
+		return "sold_after"
+	case c == "AUWD" || strings.Contains(c, "WITHDRAWN"):
+		return "withdrawn"
+	case c == "AUPI" || c == "AUPN" || c == "AUVB" || c == "AUHB" ||
+		strings.Contains(c, "PASSED") || strings.Contains(c, "VENDOR") || strings.Contains(c, "UNSOLD"):
+		return "passed_in"
+	case c == "AUSLD" || c == "AUSD" || strings.Contains(c, "SOLD"):
+		return "sold"
+	default:
+		return strings.ToLower(c)
+	}
+}
+
+// isRuralAuctionType reports whether a Domain property type is rural, acreage or land
+func isRuralAuctionType(propertyType string) bool {
+	t := strings.ToLower(propertyType)
+	for _, keyword := range []string{"rural", "acreage", "farm", "land", "lifestyle"} {
+		if strings.Contains(t, keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+// lastSaturday returns the most recent Saturday on or before t
+func lastSaturday(t time.Time) time.Time {
+	offset := (int(t.Weekday()) - int(time.Saturday) + 7) % 7
+	return t.AddDate(0, 0, -offset)
+}
+
+// AuctionClearance summarises a set of auction results: the clearance rate is
+// the share of reported auctions that sold (before, at or after auction)
+func AuctionClearance(results []models.AuctionResult) (sold, reported int, rate float64) {
+	for _, r := range results {
+		if r.Result == "withdrawn" {
+			continue
+		}
+		reported++
+		if strings.HasPrefix(r.Result, "sold") {
+			sold++
+		}
+	}
+	if reported > 0 {
+		rate = float64(sold) / float64(reported) * 100
+	}
+	return sold, reported, rate
+}
@@ -15,10 +15,10 @@ import (
 
 	"github.com/chromedp/cdproto/cdp"
 	"github.com/chromedp/cdproto/network"
-	"github.com/chromedp/cdproto/page"
 	"github.com/chromedp/chromedp"
 
 	"farm-search/internal/models"
+	"farm-search/internal/units"
 )
 
 // BrowserScraper uses headless Chrome to scrape REA with stealth mode
@@ -29,6 +29,13 @@ type BrowserScraper struct {
 	cookies     []*network.CookieParam // Cookies to inject
 	cookiesSet  bool                   // Track if cookies have been set
 	userDataDir string                 // Path to Chrome user data directory for persistent sessions
+	remoteURL   string                 // DevTools URL of an already-running Chrome to connect to instead of launching one
+	diagnostics *ParseDiagnostics
+	fixtures    *FixtureRecorder
+
+	pool         *SessionPool // Warmed tabs reused across pages
+	poolSize     int          // Maximum number of open tabs
+	tabPageLimit int          // Close and replace a tab after this many pages
 }
 
 // Cookie represents a browser cookie for JSON serialization
@@ -46,10 +53,31 @@ type Cookie struct {
 // NewBrowserScraper creates a new browser-based scraper
 func NewBrowserScraper(headless bool) *BrowserScraper {
 	return &BrowserScraper{
-		headless: headless,
+		headless:     headless,
+		poolSize:     DefaultBrowserSessions,
+		tabPageLimit: DefaultBrowserRecycleAfter,
 	}
 }
 
+// SetSessionPool sets how many browser tabs are kept warm and how many pages each
+// tab loads before it is closed and replaced. Must be called before Start.
+func (s *BrowserScraper) SetSessionPool(size, recycleAfter int) {
+	s.poolSize = size
+	s.tabPageLimit = recycleAfter
+}
+
+// SetRemoteURL connects to an already-running Chrome over the DevTools protocol
+// instead of launching a local browser. The URL can be a browser websocket URL
+// (ws://host:9222/devtools/browser/...) or the DevTools HTTP endpoint
+// (http://host:9222), as exposed by `chrome --remote-debugging-port=9222`,
+// browserless and similar browser farms.
+//
+// This allows scraping through a desktop Chrome where the Kasada challenge has
+// already been passed by hand. Headless and user data dir settings don't apply.
+func (s *BrowserScraper) SetRemoteURL(url string) {
+	s.remoteURL = url
+}
+
 // SetUserDataDir sets the Chrome user data directory to use an existing browser profile
 // This allows reusing an existing session where Kasada challenges have been solved
 //
@@ -62,6 +90,16 @@ func (s *BrowserScraper) SetUserDataDir(dir string) {
 	log.Printf("Using Chrome user data directory: %s", dir)
 }
 
+// SetDiagnostics sets the collector that records which extraction path parsed each page
+func (s *BrowserScraper) SetDiagnostics(d *ParseDiagnostics) {
+	s.diagnostics = d
+}
+
+// SetFixtureRecorder sets the recorder that saves fetched pages as parser fixtures
+func (s *BrowserScraper) SetFixtureRecorder(r *FixtureRecorder) {
+	s.fixtures = r
+}
+
 // LoadCookiesFromFile loads cookies from a JSON file
 // The file should contain an array of cookie objects with name, value, domain fields
 // You can export cookies from your browser using extensions like "EditThisCookie" or "Cookie-Editor"
@@ -122,6 +160,14 @@ func (s *BrowserScraper) SetCookies(cookies []*network.CookieParam) {
 
 // Start initializes the browser with stealth mode settings
 func (s *BrowserScraper) Start() error {
+	// Connect to a remote browser if configured; it keeps its own flags and profile
+	if s.remoteURL != "" {
+		log.Printf("Connecting to remote browser at %s", s.remoteURL)
+		s.allocCtx, s.cancel = chromedp.NewRemoteAllocator(context.Background(), s.remoteURL)
+		s.pool = NewSessionPool(s.allocCtx, s.poolSize, s.tabPageLimit, s.cookies)
+		return nil
+	}
+
 	// Build options based on headless mode
 	var opts []chromedp.ExecAllocatorOption
 
@@ -182,11 +228,15 @@ func (s *BrowserScraper) Start() error {
 	}
 
 	s.allocCtx, s.cancel = chromedp.NewExecAllocator(context.Background(), opts...)
+	s.pool = NewSessionPool(s.allocCtx, s.poolSize, s.tabPageLimit, s.cookies)
 	return nil
 }
 
 // Stop closes the browser
 func (s *BrowserScraper) Stop() {
+	if s.pool != nil {
+		s.pool.Close()
+	}
 	if s.cancel != nil {
 		s.cancel()
 	}
@@ -362,30 +412,23 @@ func (s *BrowserScraper) scrapePage(ctx context.Context, region, propertyType st
 		region, pageNum,
 	)
 
-	// Create a new browser context for each page
-	taskCtx, cancel := chromedp.NewContext(s.allocCtx)
-	defer cancel()
+	// Reuse a warmed tab from the pool so a solved challenge carries over between pages
+	// (the stealth preload script is installed when the tab is opened)
+	sess, err := s.pool.Acquire(ctx)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get browser session: %w", err)
+	}
+	ok := false // Set once the page loads without errors or bot protection
+	defer func() { s.pool.Release(sess, ok) }()
 
 	// Set timeout
-	taskCtx, cancel = context.WithTimeout(taskCtx, 60*time.Second)
+	taskCtx, cancel := context.WithTimeout(sess.ctx, 60*time.Second)
 	defer cancel()
 
 	var html string
 	var pageURL string
 
-	// Step 1: Add preload script that runs BEFORE any page JavaScript
-	// This is the key to avoiding detection - the script runs before Kasada's fingerprinting
-	err := chromedp.Run(taskCtx,
-		chromedp.ActionFunc(func(ctx context.Context) error {
-			_, err := page.AddScriptToEvaluateOnNewDocument(preloadStealthScript()).Do(ctx)
-			return err
-		}),
-	)
-	if err != nil {
-		log.Printf("Warning: failed to add preload script: %v", err)
-	}
-
-	// Step 2: Navigate to the actual page
+	// Navigate to the actual page
 	err = chromedp.Run(taskCtx,
 		chromedp.Navigate(searchURL),
 		chromedp.WaitReady("body"),
@@ -394,7 +437,7 @@ func (s *BrowserScraper) scrapePage(ctx context.Context, region, propertyType st
 		return nil, false, fmt.Errorf("navigation failed: %w", err)
 	}
 
-	// Step 3: Inject stealth script and wait
+	// Inject stealth script and wait
 	err = chromedp.Run(taskCtx,
 		chromedp.Evaluate(stealthScript(), nil),
 		chromedp.Sleep(5*time.Second),
@@ -492,6 +535,9 @@ func (s *BrowserScraper) scrapePage(ctx context.Context, region, propertyType st
 		return nil, false, fmt.Errorf("access denied by server")
 	}
 
+	ok = true
+	s.fixtures.Capture("rea-browser", "search", pageURL, "html", html)
+
 	// Parse the HTML to extract listings
 	listings, hasMore := s.parseListingsPage(html, propertyType)
 
@@ -503,16 +549,21 @@ func (s *BrowserScraper) parseListingsPage(html, propertyType string) ([]models.
 	var listings []models.Property
 
 	// Try multiple JSON extraction patterns
-	jsonPatterns := []string{
+	jsonPatterns := []struct {
+		name    string // Extraction path name for parse diagnostics
+		pattern string
+	}{
 		// Primary: ArgonautExchange (main data store)
-		`window\.ArgonautExchange\s*=\s*(\{.+?\});?\s*</script>`,
+		{"argonaut", `window\.ArgonautExchange\s*=\s*(\{.+?\});?\s*</script>`},
 		// Alternative: __NEXT_DATA__ (Next.js pages)
-		`<script[^>]*id="__NEXT_DATA__"[^>]*>(\{.+?\})</script>`,
+		{"next_data", `<script[^>]*id="__NEXT_DATA__"[^>]*>(\{.+?\})</script>`},
 		// Alternative: Initial state
-		`window\.__INITIAL_STATE__\s*=\s*(\{.+?\});?\s*</script>`,
+		{"initial_state", `window\.__INITIAL_STATE__\s*=\s*(\{.+?\});?\s*</script>`},
 	}
 
-	for _, pattern := range jsonPatterns {
+	path := ""
+	for _, jp := range jsonPatterns {
+		pattern := jp.pattern
 		re := regexp.MustCompile(pattern)
 		matches := re.FindStringSubmatch(html)
 		if len(matches) >= 2 {
@@ -521,6 +572,7 @@ func (s *BrowserScraper) parseListingsPage(html, propertyType string) ([]models.
 				listings = s.extractListingsFromJSON(data, propertyType)
 				if len(listings) > 0 {
 					log.Printf("Extracted %d listings from JSON (pattern: %s)", len(listings), pattern[:30])
+					path = jp.name
 					break
 				}
 			}
@@ -532,8 +584,10 @@ func (s *BrowserScraper) parseListingsPage(html, propertyType string) ([]models.
 		listings = s.parseListingCards(html, propertyType)
 		if len(listings) > 0 {
 			log.Printf("Extracted %d listings from HTML cards", len(listings))
+			path = "html_cards"
 		}
 	}
+	s.diagnostics.Record("rea-browser", path, len(listings))
 
 	// Check if there are more pages using multiple indicators
 	hasMore := strings.Contains(html, `rel="next"`) ||
@@ -641,8 +695,9 @@ func (s *BrowserScraper) parseListingURL(path, listingID, propertyType string) *
 		listing.Postcode = sql.NullString{String: matches[1], Valid: true}
 	}
 
-	// Extract suburb (word before -nsw-)
-	suburbPattern := regexp.MustCompile(`-([a-z][a-z\+]+)-nsw-\d{4}`)
+	// Extract suburb (word before -nsw-), anchored on the postcode and ID so
+	// the digits of an ID alone aren't taken for a postcode
+	suburbPattern := regexp.MustCompile(`-([a-z][a-z\+]+)-nsw-\d{4}-\d+$`)
 	if matches := suburbPattern.FindStringSubmatch(strings.ToLower(path)); len(matches) > 1 {
 		suburb := strings.ReplaceAll(matches[1], "+", " ")
 		listing.Suburb = sql.NullString{String: toTitleCase(suburb), Valid: true}
@@ -913,6 +968,11 @@ func (s *BrowserScraper) parseJSONListing(data interface{}, propertyType string)
 				listing.Bathrooms = sql.NullInt64{Int64: int64(val), Valid: true}
 			}
 		}
+		if parking, ok := features["parkingSpaces"].(map[string]interface{}); ok {
+			if val, ok := parking["value"].(float64); ok {
+				listing.Carspaces = sql.NullInt64{Int64: int64(val), Valid: true}
+			}
+		}
 	}
 	// Try top-level features
 	if !listing.Bedrooms.Valid {
@@ -923,12 +983,17 @@ func (s *BrowserScraper) parseJSONListing(data interface{}, propertyType string)
 			listing.Bathrooms = sql.NullInt64{Int64: int64(baths), Valid: true}
 		}
 	}
+	if !listing.Carspaces.Valid {
+		if cars, ok := m["carspaces"].(float64); ok {
+			listing.Carspaces = sql.NullInt64{Int64: int64(cars), Valid: true}
+		}
+	}
 
 	// Extract land size (try multiple patterns)
 	if propertySizes, ok := m["propertySizes"].(map[string]interface{}); ok {
 		if land, ok := propertySizes["land"].(map[string]interface{}); ok {
 			if size, ok := land["displayValue"].(string); ok {
-				if sqm := parseLandSize(size); sqm > 0 {
+				if sqm := units.ParseLandSize(size); sqm > 0 {
 					listing.LandSizeSqm = sql.NullFloat64{Float64: sqm, Valid: true}
 				}
 			}
@@ -936,7 +1001,7 @@ func (s *BrowserScraper) parseJSONListing(data interface{}, propertyType string)
 			if sizeVal, ok := land["value"].(float64); ok {
 				if unit, ok := land["sizeUnit"].(map[string]interface{}); ok {
 					if unitName, ok := unit["name"].(string); ok {
-						if sqm := convertToSqm(sizeVal, unitName); sqm > 0 {
+						if sqm, ok := units.ToSqm(sizeVal, unitName); ok && sqm > 0 {
 							listing.LandSizeSqm = sql.NullFloat64{Float64: sqm, Valid: true}
 						}
 					}
@@ -947,7 +1012,7 @@ func (s *BrowserScraper) parseJSONListing(data interface{}, propertyType string)
 	// Try top-level landSize
 	if !listing.LandSizeSqm.Valid {
 		if landSize, ok := m["landSize"].(string); ok {
-			if sqm := parseLandSize(landSize); sqm > 0 {
+			if sqm := units.ParseLandSize(landSize); sqm > 0 {
 				listing.LandSizeSqm = sql.NullFloat64{Float64: sqm, Valid: true}
 			}
 		}
@@ -1053,33 +1118,22 @@ func extractPriceRange(priceText string) (min, max int64) {
 	return 0, 0
 }
 
-// convertToSqm converts land size to square meters based on unit
-func convertToSqm(value float64, unit string) float64 {
-	unit = strings.ToLower(unit)
-	switch {
-	case strings.Contains(unit, "hectare") || unit == "ha":
-		return value * 10000
-	case strings.Contains(unit, "acre") || unit == "ac":
-		return value * 4046.86
-	case strings.Contains(unit, "m²") || strings.Contains(unit, "sqm") || unit == "m2":
-		return value
-	default:
-		return value
-	}
-}
-
 // FetchListingDetails fetches full details for a single listing using browser
 func (s *BrowserScraper) FetchListingDetails(ctx context.Context, listingURL string) (*models.Property, error) {
-	taskCtx, cancel := chromedp.NewContext(s.allocCtx)
-	defer cancel()
+	sess, err := s.pool.Acquire(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get browser session: %w", err)
+	}
+	ok := false // Set once the page loads without errors or bot protection
+	defer func() { s.pool.Release(sess, ok) }()
 
-	taskCtx, cancel = context.WithTimeout(taskCtx, 45*time.Second)
+	taskCtx, cancel := context.WithTimeout(sess.ctx, 45*time.Second)
 	defer cancel()
 
 	var html string
 
 	// Navigate with stealth mode
-	err := chromedp.Run(taskCtx,
+	err = chromedp.Run(taskCtx,
 		// Set headers
 		network.Enable(),
 		network.SetExtraHTTPHeaders(network.Headers{
@@ -1114,6 +1168,8 @@ func (s *BrowserScraper) FetchListingDetails(ctx context.Context, listingURL str
 		return nil, fmt.Errorf("blocked by bot protection")
 	}
 
+	ok = true
+	s.fixtures.Capture("rea-browser", "detail", listingURL, "html", html)
 	return s.parseListingDetails(html, listingURL)
 }
 
@@ -1247,6 +1303,9 @@ func (s *BrowserScraper) parseListingDetails(html, listingURL string) (*models.P
 				if jsonListing.Bathrooms.Valid && !listing.Bathrooms.Valid {
 					listing.Bathrooms = jsonListing.Bathrooms
 				}
+				if jsonListing.Carspaces.Valid && !listing.Carspaces.Valid {
+					listing.Carspaces = jsonListing.Carspaces
+				}
 				if jsonListing.Images.Valid && !listing.Images.Valid {
 					listing.Images = jsonListing.Images
 				}
@@ -1279,23 +1338,8 @@ func (s *BrowserScraper) parseListingDetails(html, listingURL string) (*models.P
 
 	// Extract land size from page
 	if !listing.LandSizeSqm.Valid {
-		landPatterns := []*regexp.Regexp{
-			regexp.MustCompile(`([\d,.]+)\s*(hectares?|ha|acres?|ac)\b`),
-			regexp.MustCompile(`land[:\s]+size[:\s]*([\d,.]+)\s*(m²|sqm|ha|hectares?|acres?)`),
-			regexp.MustCompile(`([\d,.]+)\s*(m²|sqm|square\s*m)`),
-		}
-
-		for _, pattern := range landPatterns {
-			if landMatches := pattern.FindStringSubmatch(strings.ToLower(html)); len(landMatches) >= 3 {
-				sizeStr := strings.ReplaceAll(landMatches[1], ",", "")
-				if size, err := strconv.ParseFloat(sizeStr, 64); err == nil {
-					sqm := convertToSqm(size, landMatches[2])
-					if sqm > 0 {
-						listing.LandSizeSqm = sql.NullFloat64{Float64: sqm, Valid: true}
-						break
-					}
-				}
-			}
+		if sqm := units.FindLandSize(html); sqm > 0 {
+			listing.LandSizeSqm = sql.NullFloat64{Float64: sqm, Valid: true}
 		}
 	}
 
@@ -1353,6 +1397,11 @@ func (s *BrowserScraper) parseListingDetails(html, listingURL string) (*models.P
 		}
 	}
 
+	// Car spaces from the description if not in JSON
+	if !listing.Carspaces.Valid && listing.Description.Valid {
+		listing.Carspaces = findCarspaces(listing.Description.String)
+	}
+
 	return listing, nil
 }
 
@@ -1367,35 +1416,5 @@ func toTitleCase(s string) string {
 	return strings.Join(words, " ")
 }
 
-// parseLandSizeBrowser converts land size strings to square meters
-func parseLandSizeBrowser(sizeStr string) float64 {
-	sizeStr = strings.ToLower(strings.TrimSpace(sizeStr))
-	sizeStr = strings.ReplaceAll(sizeStr, ",", "")
-
-	// Extract numeric value
-	numPattern := regexp.MustCompile(`([\d.]+)`)
-	matches := numPattern.FindStringSubmatch(sizeStr)
-	if len(matches) < 2 {
-		return 0
-	}
-
-	value, err := strconv.ParseFloat(matches[1], 64)
-	if err != nil {
-		return 0
-	}
-
-	// Convert to square meters based on unit
-	switch {
-	case strings.Contains(sizeStr, "ha") || strings.Contains(sizeStr, "hectare"):
-		return value * 10000
-	case strings.Contains(sizeStr, "acre"):
-		return value * 4046.86
-	case strings.Contains(sizeStr, "m²") || strings.Contains(sizeStr, "sqm") || strings.Contains(sizeStr, "m2"):
-		return value
-	default:
-		return value
-	}
-}
-
 // Ensure we don't have unused imports
 var _ = cdp.Node{}
@@ -0,0 +1,224 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/chromedp"
+)
+
+// Default session pool settings
+const (
+	DefaultBrowserSessions     = 1
+	DefaultBrowserRecycleAfter = 20
+)
+
+// browserSession is a browser tab that is kept open between pages so the
+// cookies and state from a solved Kasada challenge carry over
+type browserSession struct {
+	id      int
+	ctx     context.Context
+	cancel  context.CancelFunc
+	pages   int
+	created time.Time
+}
+
+// SessionPool keeps warmed browser tabs alive across page loads.
+// All tabs share one browser process (and so one cookie jar); a tab is closed
+// and replaced after recycleAfter pages, or as soon as a health check or page
+// load fails on it.
+type SessionPool struct {
+	allocCtx     context.Context
+	cookies      []*network.CookieParam
+	size         int
+	recycleAfter int
+
+	mu         sync.Mutex
+	browserCtx context.Context
+	closeAll   context.CancelFunc
+	idle       chan *browserSession
+	open       int
+	nextID     int
+}
+
+// NewSessionPool creates a pool of up to size tabs on the given allocator.
+// Cookies are injected into the browser when it is first started.
+func NewSessionPool(allocCtx context.Context, size, recycleAfter int, cookies []*network.CookieParam) *SessionPool {
+	if size < 1 {
+		size = DefaultBrowserSessions
+	}
+	if recycleAfter < 1 {
+		recycleAfter = DefaultBrowserRecycleAfter
+	}
+	return &SessionPool{
+		allocCtx:     allocCtx,
+		cookies:      cookies,
+		size:         size,
+		recycleAfter: recycleAfter,
+		idle:         make(chan *browserSession, size),
+	}
+}
+
+// startBrowser launches the shared browser process if it isn't already running.
+// Must be called with p.mu held.
+func (p *SessionPool) startBrowser() error {
+	if p.browserCtx != nil && p.browserCtx.Err() == nil {
+		return nil
+	}
+
+	browserCtx, cancel := chromedp.NewContext(p.allocCtx)
+	if err := chromedp.Run(browserCtx); err != nil {
+		cancel()
+		return fmt.Errorf("failed to start browser: %w", err)
+	}
+
+	if len(p.cookies) > 0 {
+		err := chromedp.Run(browserCtx, chromedp.ActionFunc(func(ctx context.Context) error {
+			return network.SetCookies(p.cookies).Do(ctx)
+		}))
+		if err != nil {
+			log.Printf("Warning: failed to set cookies: %v", err)
+		} else {
+			log.Printf("Injected %d cookies into browser", len(p.cookies))
+		}
+	}
+
+	p.browserCtx = browserCtx
+	p.closeAll = cancel
+	return nil
+}
+
+// newSession opens a new tab with the stealth preload script installed
+func (p *SessionPool) newSession() (*browserSession, error) {
+	p.mu.Lock()
+	if err := p.startBrowser(); err != nil {
+		p.open--
+		p.mu.Unlock()
+		return nil, err
+	}
+	browserCtx := p.browserCtx
+	p.nextID++
+	id := p.nextID
+	p.mu.Unlock()
+
+	tabCtx, cancel := chromedp.NewContext(browserCtx)
+
+	// Preload script runs before any page JavaScript on every navigation in this tab,
+	// which is the key to avoiding detection by Kasada's fingerprinting
+	err := chromedp.Run(tabCtx,
+		chromedp.ActionFunc(func(ctx context.Context) error {
+			_, err := page.AddScriptToEvaluateOnNewDocument(preloadStealthScript()).Do(ctx)
+			return err
+		}),
+	)
+	if err != nil {
+		cancel()
+		p.mu.Lock()
+		p.open--
+		p.mu.Unlock()
+		return nil, fmt.Errorf("failed to open browser tab: %w", err)
+	}
+
+	log.Printf("Opened browser session %d", id)
+	return &browserSession{id: id, ctx: tabCtx, cancel: cancel, created: time.Now()}, nil
+}
+
+// healthy checks that a session's tab still responds
+func (p *SessionPool) healthy(sess *browserSession) bool {
+	if sess.ctx.Err() != nil {
+		return false
+	}
+	checkCtx, cancel := context.WithTimeout(sess.ctx, 5*time.Second)
+	defer cancel()
+
+	var state string
+	if err := chromedp.Run(checkCtx, chromedp.Evaluate(`document.readyState`, &state)); err != nil {
+		log.Printf("Browser session %d failed health check: %v", sess.id, err)
+		return false
+	}
+	return true
+}
+
+// Acquire returns an idle healthy session, opening a new one if the pool has
+// room, or waits for one to be released
+func (p *SessionPool) Acquire(ctx context.Context) (*browserSession, error) {
+	for {
+		select {
+		case sess := <-p.idle:
+			if p.healthy(sess) {
+				return sess, nil
+			}
+			p.discard(sess)
+			continue
+		default:
+		}
+
+		p.mu.Lock()
+		if p.open < p.size {
+			p.open++
+			p.mu.Unlock()
+			return p.newSession()
+		}
+		p.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case sess := <-p.idle:
+			if p.healthy(sess) {
+				return sess, nil
+			}
+			p.discard(sess)
+		}
+	}
+}
+
+// Release returns a session to the pool after a page load. Sessions that
+// failed, or have reached the recycle limit, are closed instead.
+func (p *SessionPool) Release(sess *browserSession, ok bool) {
+	sess.pages++
+	if !ok {
+		log.Printf("Recycling browser session %d after failed page", sess.id)
+		p.discard(sess)
+		return
+	}
+	if sess.pages >= p.recycleAfter {
+		log.Printf("Recycling browser session %d after %d pages", sess.id, sess.pages)
+		p.discard(sess)
+		return
+	}
+	p.idle <- sess
+}
+
+// discard closes a session's tab and frees its slot in the pool
+func (p *SessionPool) discard(sess *browserSession) {
+	sess.cancel()
+	p.mu.Lock()
+	p.open--
+	p.mu.Unlock()
+}
+
+// Close closes every session and the shared browser
+func (p *SessionPool) Close() {
+	for {
+		select {
+		case sess := <-p.idle:
+			sess.cancel()
+		default:
+			p.mu.Lock()
+			if p.closeAll != nil {
+				p.closeAll()
+			}
+			p.browserCtx = nil
+			p.closeAll = nil
+			p.open = 0
+			p.mu.Unlock()
+			return
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package scraper
+
+import "testing"
+
+func TestBrowserParseListingsPage(t *testing.T) {
+	tests := []struct {
+		fixture     string
+		wantHasMore bool
+		want        []listingFields
+	}{
+		{
+			// ArgonautExchange rpiResults across tiers
+			fixture:     "search-rpi.html",
+			wantHasMore: true,
+			want: []listingFields{
+				{
+					// Price range from "$1.15m - $1.25m"; floorplans aren't photos
+					ExternalID:   "143000001",
+					Source:       "rea",
+					URL:          "https://www.realestate.com.au/property-rural-nsw-wattle+flat-143000001",
+					Address:      "1204 Sofala Road",
+					Suburb:       "Wattle Flat",
+					State:        "NSW",
+					Postcode:     "2795",
+					PriceText:    "$1.15m - $1.25m",
+					PriceMin:     1150000,
+					PriceMax:     1250000,
+					PropertyType: "rural",
+					Bedrooms:     4,
+					Bathrooms:    2,
+					Carspaces:    3,
+					LandSizeSqm:  hectares(48.56),
+					Latitude:     -33.1342,
+					Longitude:    149.6931,
+					Images:       []string{"https://i2.au.reastatic.net/800x600/3a1f0c2b9e/image.jpg"},
+				},
+				{
+					// Flat fields: streetAddress, top-level coordinates and features
+					ExternalID:   "143000002",
+					Source:       "rea",
+					URL:          "https://www.realestate.com.au/property-land-nsw-oberon-143000002",
+					Address:      "Lot 3 Edith Road",
+					Suburb:       "Oberon",
+					State:        "NSW",
+					Postcode:     "2787",
+					PriceText:    "Contact Agent",
+					PropertyType: "land",
+					LandSizeSqm:  acres(20),
+					Latitude:     -33.7052,
+					Longitude:    149.8571,
+					Images:       []string{"https://i2.au.reastatic.net/800x600/aa11bb22cc/image.jpg"},
+				},
+			},
+		},
+		{
+			// __NEXT_DATA__ listingData with a numeric ID and land size in sizeUnit
+			fixture:     "search-next.html",
+			wantHasMore: false,
+			want: []listingFields{
+				{
+					ExternalID:   "143000004",
+					Source:       "rea",
+					URL:          "https://www.realestate.com.au/property-rural-nsw-orange-143000004",
+					Address:      "77 Cargo Road, Orange NSW 2800",
+					Suburb:       "Orange",
+					State:        "NSW",
+					Postcode:     "2800",
+					PriceText:    "Offers over $2,000,000",
+					PriceMin:     2000000,
+					PriceMax:     2000000,
+					PropertyType: "rural",
+					Carspaces:    4,
+					LandSizeSqm:  acres(100),
+					Description:  "Vineyard and cellar door.",
+					Images:       []string{"https://i2.au.reastatic.net/800x600/ffee001122/image.jpg"},
+				},
+			},
+		},
+		{
+			// Card links in either quote style, and a data-listing-id card
+			// without a link
+			fixture:     "search-cards.html",
+			wantHasMore: false,
+			want: []listingFields{
+				{
+					ExternalID:   "143000005",
+					Source:       "rea",
+					URL:          "https://www.realestate.com.au/property-rural-12+ridge+road-millthorpe-nsw-2798-143000005",
+					Address:      "12 Ridge Road",
+					Suburb:       "Millthorpe",
+					State:        "NSW",
+					Postcode:     "2798",
+					PropertyType: "rural",
+				},
+				{
+					ExternalID:   "143000006",
+					Source:       "rea",
+					URL:          "https://www.realestate.com.au/property-rural-nsw-143000006",
+					State:        "NSW",
+					PropertyType: "rural",
+				},
+				{
+					ExternalID:   "143000007",
+					Source:       "rea",
+					URL:          "https://www.realestate.com.au/property-land-3+pine+lane-blayney-nsw-2799-143000007",
+					Address:      "3 Pine Lane",
+					Suburb:       "Blayney",
+					State:        "NSW",
+					Postcode:     "2799",
+					PropertyType: "rural",
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			s := NewBrowserScraper(true)
+			listings, hasMore := s.parseListingsPage(readFixture(t, "rea-browser", tt.fixture), "rural")
+			if hasMore != tt.wantHasMore {
+				t.Errorf("hasMore = %v, want %v", hasMore, tt.wantHasMore)
+			}
+			checkListings(t, listings, tt.want)
+		})
+	}
+}
+
+func TestBrowserParseListingDetails(t *testing.T) {
+	tests := []struct {
+		fixture string
+		url     string
+		want    listingFields
+	}{
+		{
+			// JSON-LD Residence with string coordinates, ArgonautExchange for
+			// the price, features and land size, and gallery images from the
+			// page with escaped slashes
+			fixture: "detail.html",
+			url:     "https://www.realestate.com.au/property-rural-nsw-millthorpe-143000005",
+			want: listingFields{
+				ExternalID:  "143000005",
+				Source:      "rea",
+				URL:         "https://www.realestate.com.au/property-rural-nsw-millthorpe-143000005",
+				Address:     "12 Ridge Road",
+				Suburb:      "Millthorpe",
+				State:       "NSW",
+				Postcode:    "2798",
+				PriceText:   "$1,380,000",
+				Bedrooms:    4,
+				Bathrooms:   2,
+				Carspaces:   3, // "three car garage" in the description
+				LandSizeSqm: acres(25),
+				Latitude:    -33.4459,
+				Longitude:   149.1853,
+				Description: "Renovated farmhouse on 25 acres with a three car garage.",
+				Images: []string{
+					"https://i2.au.reastatic.net/1024x768/abc/image.jpg",
+					"https://i2.au.reastatic.net/1024x768/def/image.jpg",
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			s := NewBrowserScraper(true)
+			listing, err := s.parseListingDetails(readFixture(t, "rea-browser", tt.fixture), tt.url)
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkListing(t, fieldsOf(t, *listing), tt.want)
+		})
+	}
+}
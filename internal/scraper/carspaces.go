@@ -0,0 +1,42 @@
+package scraper
+
+import (
+	"database/sql"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// carspacesPattern finds car space counts in listing text: "2 car garage",
+// "3-car carport", "double garage", "4 parking spaces", "2 Parking"
+var carspacesPattern = regexp.MustCompile(`(?i)\b(\d{1,2}|one|two|three|four|five|six|single|double|triple)[\s-]*(?:car\b|cars\b|car spaces?\b|garage|carport|parking\b|parking spaces?\b)`)
+
+// maxCarspaces is the most car spaces findCarspaces believes; bigger numbers
+// are usually about something else (a "40 car" showground nearby)
+const maxCarspaces = 20
+
+// carspaceWords are the counts carspacesPattern matches as words
+var carspaceWords = map[string]int64{
+	"one": 1, "single": 1, "two": 2, "double": 2, "three": 3, "triple": 3,
+	"four": 4, "five": 5, "six": 6,
+}
+
+// findCarspaces returns the most car spaces text mentions in one place, for
+// sources without a structured count. Garages and carports listed separately
+// aren't added together, so it errs low.
+func findCarspaces(text string) sql.NullInt64 {
+	var most int64
+	for _, m := range carspacesPattern.FindAllStringSubmatch(text, -1) {
+		n, err := strconv.ParseInt(m[1], 10, 64)
+		if err != nil {
+			n = carspaceWords[strings.ToLower(m[1])]
+		}
+		if n > most && n <= maxCarspaces {
+			most = n
+		}
+	}
+	if most == 0 {
+		return sql.NullInt64{}
+	}
+	return sql.NullInt64{Int64: most, Valid: true}
+}
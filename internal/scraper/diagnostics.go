@@ -0,0 +1,121 @@
+package scraper
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"farm-search/internal/db"
+)
+
+// Extraction paths recorded when nothing on a page could be parsed
+const parsePathNone = "none"
+
+// ParseDiagnostics counts which extraction path produced listings for each page
+// a scraper parses. Sites change their embedded JSON without warning (REA does
+// this roughly yearly), which otherwise shows up only as a run that quietly
+// returns zero listings. Counts are saved per run so a path that stops working
+// can be flagged against the previous run.
+type ParseDiagnostics struct {
+	mu     sync.Mutex
+	counts map[string]map[string]*db.ParseStat // source -> path -> counts
+}
+
+// NewParseDiagnostics creates an empty diagnostics collector
+func NewParseDiagnostics() *ParseDiagnostics {
+	return &ParseDiagnostics{counts: make(map[string]map[string]*db.ParseStat)}
+}
+
+// Record notes that a page from source was parsed using path, yielding the given
+// number of listings. Pages where no path matched should be recorded with an
+// empty path. Safe to call on a nil collector.
+func (d *ParseDiagnostics) Record(source, path string, listings int) {
+	if d == nil {
+		return
+	}
+	if path == "" || listings == 0 {
+		path = parsePathNone
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	paths, ok := d.counts[source]
+	if !ok {
+		paths = make(map[string]*db.ParseStat)
+		d.counts[source] = paths
+	}
+	stat, ok := paths[path]
+	if !ok {
+		stat = &db.ParseStat{Source: source, Path: path}
+		paths[path] = stat
+	}
+	stat.Pages++
+	stat.Listings += listings
+}
+
+// Stats returns the recorded counts, ordered by source then path
+func (d *ParseDiagnostics) Stats() []db.ParseStat {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var stats []db.ParseStat
+	for _, paths := range d.counts {
+		for _, stat := range paths {
+			stats = append(stats, *stat)
+		}
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Source != stats[j].Source {
+			return stats[i].Source < stats[j].Source
+		}
+		return stats[i].Path < stats[j].Path
+	})
+	return stats
+}
+
+// LogSummary logs the per-path counts for the run
+func (d *ParseDiagnostics) LogSummary() {
+	stats := d.Stats()
+	if len(stats) == 0 {
+		return
+	}
+	log.Println("Parse diagnostics (source / path: pages, listings):")
+	for _, st := range stats {
+		log.Printf("  %s / %s: %d pages, %d listings", st.Source, st.Path, st.Pages, st.Listings)
+	}
+}
+
+// CheckRegressions compares this run's counts against the previous saved run for
+// each source and returns an alert for every path that produced listings last
+// time but none now
+func (d *ParseDiagnostics) CheckRegressions(database *db.DB, runAt time.Time) ([]string, error) {
+	current := make(map[string]map[string]int)
+	for _, st := range d.Stats() {
+		if current[st.Source] == nil {
+			current[st.Source] = make(map[string]int)
+		}
+		current[st.Source][st.Path] += st.Listings
+	}
+
+	var alerts []string
+	for source, paths := range current {
+		previous, err := database.GetPreviousParseStats(source, runAt)
+		if err != nil {
+			return nil, err
+		}
+		for _, prev := range previous {
+			if prev.Path == parsePathNone || prev.Listings == 0 {
+				continue
+			}
+			if paths[prev.Path] == 0 {
+				alerts = append(alerts, fmt.Sprintf("%s: extraction path %q returned %d listings on %s but none this run",
+					source, prev.Path, prev.Listings, prev.RunAt.Format("2006-01-02 15:04")))
+			}
+		}
+	}
+	sort.Strings(alerts)
+	return alerts, nil
+}
@@ -13,14 +13,18 @@ import (
 	"strings"
 	"time"
 
+	"farm-search/internal/geo"
 	"farm-search/internal/models"
 )
 
 // DomainScraper handles fetching listings from Domain.com.au via their official API
 type DomainScraper struct {
-	client  *http.Client
-	apiKey  string
-	baseURL string
+	client      *http.Client
+	apiKey      string
+	baseURL     string
+	profile     SearchProfile
+	diagnostics *ParseDiagnostics
+	fixtures    *FixtureRecorder
 }
 
 // NewDomainScraper creates a new Domain API scraper
@@ -31,9 +35,25 @@ func NewDomainScraper(apiKey string) *DomainScraper {
 		},
 		apiKey:  apiKey,
 		baseURL: "https://api.domain.com.au",
+		profile: DefaultSearchProfile(),
 	}
 }
 
+// SetSearchProfile sets the land size, price and property type filters used for searches
+func (s *DomainScraper) SetSearchProfile(profile SearchProfile) {
+	s.profile = profile
+}
+
+// SetDiagnostics sets the collector that records which extraction path parsed each page
+func (s *DomainScraper) SetDiagnostics(d *ParseDiagnostics) {
+	s.diagnostics = d
+}
+
+// SetFixtureRecorder sets the recorder that saves fetched pages as parser fixtures
+func (s *DomainScraper) SetFixtureRecorder(r *FixtureRecorder) {
+	s.fixtures = r
+}
+
 // DomainSearchRequest represents the request body for residential search
 type DomainSearchRequest struct {
 	ListingType          string           `json:"listingType"`
@@ -93,6 +113,7 @@ type DomainListing struct {
 	HasVideo           bool                   `json:"hasVideo,omitempty"`
 	Labels             []string               `json:"labels,omitempty"`
 	AuctionSchedule    *DomainAuction         `json:"auctionSchedule,omitempty"`
+	InspectionSchedule *DomainInspections     `json:"inspectionSchedule,omitempty"`
 	DateListed         string                 `json:"dateListed,omitempty"`
 	DateUpdated        string                 `json:"dateUpdated,omitempty"`
 	ListingSlug        string                 `json:"listingSlug,omitempty"`
@@ -132,9 +153,9 @@ type DomainPriceDetails struct {
 	PriceTo      int64  `json:"priceTo,omitempty"`
 }
 
-// DomainMedia represents a media item (image/video)
+// DomainMedia represents a media item (image, video or floorplan)
 type DomainMedia struct {
-	Category string `json:"category"` // "Image", "Video", etc.
+	Category string `json:"category"` // "Image", "Video", "FloorPlan", etc.
 	URL      string `json:"url"`
 }
 
@@ -167,6 +188,15 @@ type DomainAuction struct {
 	AuctionLocation string `json:"auctionLocation,omitempty"`
 }
 
+// DomainInspections represents open-for-inspection times
+type DomainInspections struct {
+	ByAppointment bool `json:"byAppointment,omitempty"`
+	Times         []struct {
+		OpeningTime string `json:"openingTime"`
+		ClosingTime string `json:"closingTime"`
+	} `json:"times,omitempty"`
+}
+
 // ScrapeListings fetches property listings from Domain API
 func (s *DomainScraper) ScrapeListings(ctx context.Context, state string, maxPages int) ([]models.Property, error) {
 	return s.ScrapeListingsWithExistsCheck(ctx, state, maxPages, nil)
@@ -177,28 +207,22 @@ func (s *DomainScraper) ScrapeListingsWithExistsCheck(ctx context.Context, state
 	var allListings []models.Property
 	pageSize := 100 // Max allowed by API
 
-	// Build search request for rural/farm properties in the specified state
-	// The API limits results to 1000 total, so we use filters to target our desired properties
+	// Build search request for properties in the specified state
+	// The API limits results to 1000 total, so we use the search profile's filters to target our desired properties
 	searchReq := DomainSearchRequest{
 		ListingType: "Sale",
-		PropertyTypes: []string{
-			"AcreageSemiRural",
-			"Farm",
-			"Rural",
-		},
 		Locations: []DomainLocation{
 			{
 				State: strings.ToUpper(state),
 			},
 		},
-		MinLandArea: intPtr(40000),   // 4 hectares minimum (40,000 sqm)
-		MaxPrice:    intPtr(2000000), // Max $2M to match other scrapers
-		PageSize:    pageSize,
+		PageSize: pageSize,
 		Sort: &DomainSort{
 			SortKey:   "DateListed",
 			Direction: "Descending",
 		},
 	}
+	s.profile.applyToDomainRequest(&searchReq)
 
 	for page := 1; maxPages <= 0 || page <= maxPages; page++ {
 		select {
@@ -234,6 +258,8 @@ func (s *DomainScraper) ScrapeListingsWithExistsCheck(ctx context.Context, state
 			}
 		}
 
+		s.diagnostics.Record("domain", "api", len(pageListings))
+
 		// Check for duplicates if checker is provided
 		if existsChecker != nil && len(pageListings) > 0 {
 			externalIDs := make([]string, len(pageListings))
@@ -313,8 +339,15 @@ func (s *DomainScraper) searchListings(ctx context.Context, req DomainSearchRequ
 		totalCount, _ = strconv.Atoi(tc)
 	}
 
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to read response: %w", err)
+	}
+	// POST requests share a URL, so key fixtures by the request body
+	s.fixtures.Capture("domain", "search", url+"#"+string(body), "json", string(respBody))
+
 	var results []DomainSearchResult
-	if err := json.NewDecoder(resp.Body).Decode(&results); err != nil {
+	if err := json.Unmarshal(respBody, &results); err != nil {
 		return nil, 0, fmt.Errorf("failed to decode response: %w", err)
 	}
 
@@ -386,6 +419,9 @@ func (s *DomainScraper) convertListing(listing *DomainListing) *models.Property
 		if details.Bathrooms != nil {
 			prop.Bathrooms = sql.NullInt64{Int64: int64(*details.Bathrooms), Valid: true}
 		}
+		if details.Carspaces != nil {
+			prop.Carspaces = sql.NullInt64{Int64: int64(*details.Carspaces), Valid: true}
+		}
 		if details.LandArea != nil && *details.LandArea > 0 {
 			prop.LandSizeSqm = sql.NullFloat64{Float64: *details.LandArea, Valid: true}
 		}
@@ -409,12 +445,8 @@ func (s *DomainScraper) convertListing(listing *DomainListing) *models.Property
 		prop.Description = sql.NullString{String: listing.Headline, Valid: true}
 	}
 	if listing.SummaryDescription != "" {
-		// Clean HTML tags from description
-		desc := strings.ReplaceAll(listing.SummaryDescription, "<br />", "\n")
-		desc = strings.ReplaceAll(desc, "<br>", "\n")
-		desc = strings.ReplaceAll(desc, "<b>", "")
-		desc = strings.ReplaceAll(desc, "</b>", "")
-		desc = strings.TrimSpace(desc)
+		// HTML is cleaned by sanitize.Description as it's saved
+		desc := strings.TrimSpace(listing.SummaryDescription)
 		if prop.Description.Valid {
 			prop.Description.String += "\n\n" + desc
 		} else {
@@ -422,11 +454,19 @@ func (s *DomainScraper) convertListing(listing *DomainListing) *models.Property
 		}
 	}
 
-	// Extract images
+	// Extract images, and videos and floorplans (HasVideo and HasFloorplan
+	// only say whether there are any)
 	var images []string
+	prop.Media = []models.PropertyMedia{}
 	for _, media := range listing.Media {
-		if media.Category == "Image" && media.URL != "" {
+		if media.URL == "" {
+			continue
+		}
+		switch kind := mediaKind(media.Category); kind {
+		case "image":
 			images = append(images, media.URL)
+		case "video", "floorplan":
+			prop.Media = append(prop.Media, models.PropertyMedia{Kind: kind, URL: media.URL})
 		}
 	}
 	if len(images) > 0 {
@@ -450,9 +490,46 @@ func (s *DomainScraper) convertListing(listing *DomainListing) *models.Property
 		}
 	}
 
+	prop.Events = domainListingEvents(listing)
+
 	return prop
 }
 
+// domainListingEvents extracts inspection times and the auction from a listing.
+// Domain gives times in Sydney local time without an offset. Returns an empty
+// (not nil) slice when there are none, so earlier events get cleared.
+func domainListingEvents(listing *DomainListing) []models.PropertyEvent {
+	parse := func(s string) (time.Time, bool) {
+		t, err := time.ParseInLocation("2006-01-02T15:04:05", strings.TrimSuffix(s, "Z"), geo.SydneyTime)
+		return t, err == nil
+	}
+
+	events := []models.PropertyEvent{}
+	if insp := listing.InspectionSchedule; insp != nil {
+		for _, slot := range insp.Times {
+			start, ok := parse(slot.OpeningTime)
+			if !ok {
+				continue
+			}
+			event := models.PropertyEvent{Kind: "inspection", StartsAt: start}
+			if end, ok := parse(slot.ClosingTime); ok && end.After(start) {
+				event.EndsAt = sql.NullTime{Time: end, Valid: true}
+			}
+			events = append(events, event)
+		}
+	}
+	if auction := listing.AuctionSchedule; auction != nil {
+		if start, ok := parse(auction.Time); ok {
+			event := models.PropertyEvent{Kind: "auction", StartsAt: start}
+			if loc := strings.TrimSpace(auction.AuctionLocation); loc != "" && !strings.EqualFold(loc, "on site") {
+				event.Location = sql.NullString{String: loc, Valid: true}
+			}
+			events = append(events, event)
+		}
+	}
+	return events
+}
+
 // FetchListingDetails fetches full details for a single listing by ID
 func (s *DomainScraper) FetchListingDetails(ctx context.Context, listingID int64) (*models.Property, error) {
 	url := fmt.Sprintf("%s/v1/listings/%d", s.baseURL, listingID)
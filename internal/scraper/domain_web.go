@@ -15,14 +15,17 @@ import (
 	"time"
 
 	"farm-search/internal/models"
+	"farm-search/internal/units"
 )
 
 // DomainWebScraper handles scraping from domain.com.au via traditional web scraping
 // This is an alternative to the API-based DomainScraper that doesn't require an API key
 type DomainWebScraper struct {
-	client    *http.Client
-	userAgent string
-	baseURL   string
+	client      *http.Client
+	userAgent   string
+	baseURL     string
+	diagnostics *ParseDiagnostics
+	fixtures    *FixtureRecorder
 }
 
 // NewDomainWebScraper creates a new Domain web scraper
@@ -36,6 +39,16 @@ func NewDomainWebScraper() *DomainWebScraper {
 	}
 }
 
+// SetDiagnostics sets the collector that records which extraction path parsed each page
+func (s *DomainWebScraper) SetDiagnostics(d *ParseDiagnostics) {
+	s.diagnostics = d
+}
+
+// SetFixtureRecorder sets the recorder that saves fetched pages as parser fixtures
+func (s *DomainWebScraper) SetFixtureRecorder(r *FixtureRecorder) {
+	s.fixtures = r
+}
+
 // DomainWebConfig holds configuration for the web scraper
 type DomainWebConfig struct {
 	// StartURL is the full URL to start scraping from (with all filters applied)
@@ -45,10 +58,32 @@ type DomainWebConfig struct {
 
 // DefaultDomainWebConfig returns default configuration
 func DefaultDomainWebConfig() DomainWebConfig {
+	return DomainWebConfigForProfile(DefaultSearchProfile(), DefaultRegionTargets().DomainWeb)
+}
+
+// DomainWebConfigForProfile builds a search URL for the target region, suburbs and areas
+// with the profile's price, land size and property type filters applied
+func DomainWebConfigForProfile(profile SearchProfile, targets DomainWebTargets) DomainWebConfig {
+	// Sorted by most recently updated so early-stop pagination works
+	q := profile.domainWebQuery()
+	if len(targets.Suburbs) > 0 {
+		q.Set("suburb", strings.Join(targets.Suburbs, ","))
+	}
+	if len(targets.Areas) > 0 {
+		q.Set("area", strings.Join(targets.Areas, ","))
+	}
+	q.Set("sort", "dateupdated-desc")
+
+	region := targets.Region
+	if region == "" {
+		region = "nsw"
+	}
+	channel := "sale"
+	if profile.Renting() {
+		channel = "rent"
+	}
 	return DomainWebConfig{
-		// Default search: Illawarra, Southern Highlands, Hunter Valley, Central Coast regions
-		// Price up to $2M, land size 10+ hectares, sorted by most recently updated
-		StartURL: "https://www.domain.com.au/sale/illawarra-and-south-coast-nsw/?suburb=goulburn-nsw-2580,marulan-nsw-2579,bowral-nsw-2576,berry-nsw-2535,tallong-nsw-2579,kangaroo-valley-nsw-2577,nowra-nsw-2541,katoomba-nsw-2780,lithgow-nsw-2790,cessnock-nsw-2325,mellong-nsw-2756,taralga-nsw-2580,braidwood-nsw-2622&area=southern-highlands-nsw,hunter-valley-upper-nsw,central-coast-and-region-nsw&price=0-2000000&landsize=100000-any&landsizeunit=ha&sort=dateupdated-desc",
+		StartURL: fmt.Sprintf("https://www.domain.com.au/%s/%s/?%s", channel, region, q.Encode()),
 	}
 }
 
@@ -138,6 +173,7 @@ func (s *DomainWebScraper) scrapePage(ctx context.Context, startURL string, page
 	if err != nil {
 		return nil, false, err
 	}
+	s.fixtures.Capture("domain-web", "search", searchURL, "html", body)
 
 	// Log page size for debugging
 	log.Printf("Received %d bytes from Domain for page %d", len(body), page)
@@ -151,6 +187,7 @@ func (s *DomainWebScraper) scrapePage(ctx context.Context, startURL string, page
 func (s *DomainWebScraper) parsePage(html string) ([]models.Property, bool) {
 	var listings []models.Property
 	hasMore := false
+	path := ""
 
 	// Domain embeds JSON data in the page that we can extract
 	// Look for __NEXT_DATA__ script tag (Next.js app)
@@ -166,6 +203,7 @@ func (s *DomainWebScraper) parsePage(html string) ([]models.Property, bool) {
 			listings, hasMore = s.parseNextDataWithPagination(jsonStr)
 			if len(listings) > 0 {
 				log.Printf("Extracted %d listings from __NEXT_DATA__", len(listings))
+				path = "next_data"
 			}
 		}
 	}
@@ -175,13 +213,20 @@ func (s *DomainWebScraper) parsePage(html string) ([]models.Property, bool) {
 		// Try to find listing cards in HTML
 		listings = s.parseListingCards(html)
 		hasMore = s.hasMorePages(html)
+		if len(listings) > 0 {
+			path = "html_cards"
+		}
 	}
 
 	// If still no listings, try extracting from window.__INITIAL_STATE__ or similar
 	if len(listings) == 0 {
 		listings = s.parseInitialState(html)
 		hasMore = s.hasMorePages(html)
+		if len(listings) > 0 {
+			path = "initial_state"
+		}
 	}
+	s.diagnostics.Record("domain-web", path, len(listings))
 
 	return listings, hasMore
 }
@@ -357,33 +402,28 @@ func (s *DomainWebScraper) parseDomainListing(data interface{}) *models.Property
 			if unit, ok := features["landUnit"].(string); ok {
 				landUnit = unit
 			}
-			// Convert to sqm
-			var sqm float64
-			switch strings.ToLower(landUnit) {
-			case "ha", "hectare", "hectares":
-				sqm = landSize * 10000
-			case "ac", "acre", "acres":
-				sqm = landSize * 4046.86
-			case "m2", "sqm", "m²":
-				sqm = landSize
-			default:
-				// Assume hectares for large values, sqm for small
+			sqm, ok := units.ToSqm(landSize, landUnit)
+			if !ok {
+				// Assume hectares for small values, sqm for large
 				if landSize > 100 {
-					sqm = landSize // assume sqm
+					sqm = landSize
 				} else {
-					sqm = landSize * 10000 // assume hectares
+					sqm = landSize * units.SqmPerHectare
 				}
 			}
 			listing.LandSizeSqm = sql.NullFloat64{Float64: sqm, Valid: true}
 		}
 
-		// Bedrooms/bathrooms
+		// Bedrooms/bathrooms/car spaces
 		if beds, ok := features["beds"].(float64); ok && beds > 0 {
 			listing.Bedrooms = sql.NullInt64{Int64: int64(beds), Valid: true}
 		}
 		if baths, ok := features["baths"].(float64); ok && baths > 0 {
 			listing.Bathrooms = sql.NullInt64{Int64: int64(baths), Valid: true}
 		}
+		if parking, ok := features["parking"].(float64); ok && parking > 0 {
+			listing.Carspaces = sql.NullInt64{Int64: int64(parking), Valid: true}
+		}
 	}
 
 	// Extract images
@@ -569,13 +609,16 @@ func (s *DomainWebScraper) parseListingData(data interface{}) *models.Property {
 		}
 	}
 
-	// Extract features (bedrooms, bathrooms)
+	// Extract features (bedrooms, bathrooms, car spaces)
 	if beds, ok := m["bedrooms"].(float64); ok && beds > 0 {
 		listing.Bedrooms = sql.NullInt64{Int64: int64(beds), Valid: true}
 	}
 	if baths, ok := m["bathrooms"].(float64); ok && baths > 0 {
 		listing.Bathrooms = sql.NullInt64{Int64: int64(baths), Valid: true}
 	}
+	if cars, ok := m["carspaces"].(float64); ok && cars > 0 {
+		listing.Carspaces = sql.NullInt64{Int64: int64(cars), Valid: true}
+	}
 
 	// Extract land size
 	if landSize, ok := m["landAreaSqm"].(float64); ok && landSize > 0 {
@@ -583,7 +626,7 @@ func (s *DomainWebScraper) parseListingData(data interface{}) *models.Property {
 	} else if landSize, ok := m["landSize"].(float64); ok && landSize > 0 {
 		listing.LandSizeSqm = sql.NullFloat64{Float64: landSize, Valid: true}
 	} else if landStr, ok := m["landArea"].(string); ok {
-		if sqm := parseLandSize(landStr); sqm > 0 {
+		if sqm := units.ParseLandSize(landStr); sqm > 0 {
 			listing.LandSizeSqm = sql.NullFloat64{Float64: sqm, Valid: true}
 		}
 	}
@@ -605,13 +648,25 @@ func (s *DomainWebScraper) parseListingData(data interface{}) *models.Property {
 		}
 	}
 
-	// Extract images
+	// Extract images, and videos and floorplans
 	var images []string
 	if mediaList, ok := m["media"].([]interface{}); ok {
+		listing.Media = []models.PropertyMedia{}
 		for _, media := range mediaList {
 			if mediaObj, ok := media.(map[string]interface{}); ok {
-				if url, ok := mediaObj["url"].(string); ok && url != "" {
+				url, _ := mediaObj["url"].(string)
+				if url == "" {
+					continue
+				}
+				category, _ := mediaObj["category"].(string)
+				if category == "" {
+					category, _ = mediaObj["type"].(string)
+				}
+				switch kind := mediaKind(category); kind {
+				case "image":
 					images = append(images, url)
+				case "video", "floorplan":
+					listing.Media = append(listing.Media, models.PropertyMedia{Kind: kind, URL: url})
 				}
 			}
 		}
@@ -883,6 +938,7 @@ func (s *DomainWebScraper) FetchListingDetails(ctx context.Context, listingURL s
 	if err != nil {
 		return nil, err
 	}
+	s.fixtures.Capture("domain-web", "detail", listingURL, "html", body)
 
 	return s.parseListingDetails(body, listingURL)
 }
@@ -1025,6 +1081,9 @@ func (s *DomainWebScraper) extractDetailsFromNextData(data map[string]interface{
 	if baths, ok := listingData["bathrooms"].(float64); ok && !listing.Bathrooms.Valid && baths > 0 {
 		listing.Bathrooms = sql.NullInt64{Int64: int64(baths), Valid: true}
 	}
+	if cars, ok := listingData["carspaces"].(float64); ok && !listing.Carspaces.Valid && cars > 0 {
+		listing.Carspaces = sql.NullInt64{Int64: int64(cars), Valid: true}
+	}
 	if landSize, ok := listingData["landAreaSqm"].(float64); ok && !listing.LandSizeSqm.Valid && landSize > 0 {
 		listing.LandSizeSqm = sql.NullFloat64{Float64: landSize, Valid: true}
 	}
@@ -1051,23 +1110,8 @@ func (s *DomainWebScraper) extractDetailsFromHTML(html string, listing *models.P
 
 	// Extract land size if not found
 	if !listing.LandSizeSqm.Valid {
-		landPatterns := []*regexp.Regexp{
-			regexp.MustCompile(`([\d,]+(?:\.\d+)?)\s*(hectares?|ha)\b`),
-			regexp.MustCompile(`([\d,]+(?:\.\d+)?)\s*(acres?)\b`),
-			regexp.MustCompile(`Land[:\s]*([\d,]+)\s*(m²|sqm|m2)`),
-		}
-		for _, pattern := range landPatterns {
-			if matches := pattern.FindStringSubmatch(strings.ToLower(html)); len(matches) >= 2 {
-				sizeStr := matches[1]
-				unit := ""
-				if len(matches) >= 3 {
-					unit = matches[2]
-				}
-				if sqm := parseLandSize(sizeStr + " " + unit); sqm > 0 {
-					listing.LandSizeSqm = sql.NullFloat64{Float64: sqm, Valid: true}
-					break
-				}
-			}
+		if sqm := units.FindLandSize(html); sqm > 0 {
+			listing.LandSizeSqm = sql.NullFloat64{Float64: sqm, Valid: true}
 		}
 	}
 
@@ -1088,6 +1132,11 @@ func (s *DomainWebScraper) extractDetailsFromHTML(html string, listing *models.P
 			}
 		}
 	}
+	// Car spaces from the description if not found (the page's other
+	// listings mention parking too)
+	if !listing.Carspaces.Valid && listing.Description.Valid {
+		listing.Carspaces = findCarspaces(listing.Description.String)
+	}
 
 	// Extract images if not found
 	if !listing.Images.Valid {
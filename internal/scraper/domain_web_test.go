@@ -0,0 +1,129 @@
+package scraper
+
+import "testing"
+
+func TestDomainWebParsePage(t *testing.T) {
+	tests := []struct {
+		fixture     string
+		wantHasMore bool
+		want        []listingFields
+	}{
+		{
+			// __NEXT_DATA__ listingsMap, with coordinates and land sizes in either unit
+			fixture:     "search-next.html",
+			wantHasMore: true,
+			want: []listingFields{
+				{
+					ExternalID:   "2019876543",
+					Source:       "domain-web",
+					URL:          "https://www.domain.com.au/120-glenroy-road-black-springs-nsw-2787-2019876543",
+					Address:      "120 Glenroy Road",
+					Suburb:       "Black Springs",
+					State:        "NSW",
+					Postcode:     "2787",
+					PriceText:    "$1,450,000",
+					PropertyType: "Rural",
+					Bedrooms:     4,
+					Bathrooms:    2,
+					Carspaces:    2,
+					LandSizeSqm:  hectares(64.5),
+					Latitude:     -33.8431,
+					Longitude:    149.7212,
+					Images:       []string{"https://rimages.domain.com.au/1.jpg", "https://rimages.domain.com.au/2.jpg"},
+				},
+				{
+					// Absolute URL kept; 0 beds left unset
+					ExternalID:   "2019876544",
+					Source:       "domain-web",
+					URL:          "https://www.domain.com.au/lot-2-mutton-falls-road-tarana-nsw-2787-2019876544",
+					Address:      "Lot 2 Mutton Falls Road",
+					Suburb:       "Tarana",
+					State:        "NSW",
+					Postcode:     "2787",
+					PriceText:    "Expressions of Interest",
+					PropertyType: "Vacant land",
+					LandSizeSqm:  acres(80),
+				},
+			},
+		},
+		{
+			// No embedded data: listing links, with the suburb and postcode
+			// from the URL and non-listing links skipped
+			fixture:     "search-cards.html",
+			wantHasMore: true,
+			want: []listingFields{
+				{
+					ExternalID: "2019876543",
+					Source:     "domain-web",
+					URL:        "https://www.domain.com.au/120-glenroy-road-black-springs-nsw-2787-2019876543",
+					Suburb:     "Black Springs",
+					State:      "NSW",
+					Postcode:   "2787",
+				},
+				{
+					ExternalID: "2019876544",
+					Source:     "domain-web",
+					URL:        "https://www.domain.com.au/14-ridge-street-mount-david-nsw-2795-2019876544",
+					Suburb:     "Mount David",
+					State:      "NSW",
+					Postcode:   "2795",
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			s := NewDomainWebScraper()
+			listings, hasMore := s.parsePage(readFixture(t, "domain-web", tt.fixture))
+			if hasMore != tt.wantHasMore {
+				t.Errorf("hasMore = %v, want %v", hasMore, tt.wantHasMore)
+			}
+			checkListings(t, listings, tt.want)
+		})
+	}
+}
+
+func TestDomainWebParseListingDetails(t *testing.T) {
+	tests := []struct {
+		fixture string
+		url     string
+		want    listingFields
+	}{
+		{
+			// JSON-LD, then __NEXT_DATA__ for the price, features and land
+			// size, then car spaces from the description
+			fixture: "detail.html",
+			url:     "https://www.domain.com.au/120-glenroy-road-black-springs-nsw-2787-2019876543",
+			want: listingFields{
+				ExternalID:  "2019876543",
+				Source:      "domain-web",
+				URL:         "https://www.domain.com.au/120-glenroy-road-black-springs-nsw-2787-2019876543",
+				Address:     "120 Glenroy Road, Black Springs NSW 2787",
+				Suburb:      "Black Springs",
+				State:       "NSW",
+				Postcode:    "2787",
+				PriceText:   "$1,450,000",
+				Bedrooms:    4,
+				Bathrooms:   2,
+				Carspaces:   2,
+				LandSizeSqm: 647497,
+				Latitude:    -33.8431,
+				Longitude:   149.7212,
+				Description: "Well watered 160 acres with a four bedroom home and double garage.",
+				Images:      []string{"https://rimages.domain.com.au/1.jpg"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			s := NewDomainWebScraper()
+			listing, err := s.parseListingDetails(readFixture(t, "domain-web", tt.fixture), tt.url)
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkListing(t, fieldsOf(t, *listing), tt.want)
+		})
+	}
+}
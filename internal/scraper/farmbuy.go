@@ -14,13 +14,17 @@ import (
 	"time"
 
 	"farm-search/internal/models"
+	"farm-search/internal/units"
 )
 
 // FarmBuyScraper handles scraping from farmbuy.com
 type FarmBuyScraper struct {
-	client    *http.Client
-	userAgent string
-	baseURL   string
+	client      *http.Client
+	userAgent   string
+	baseURL     string
+	profile     SearchProfile
+	diagnostics *ParseDiagnostics
+	fixtures    *FixtureRecorder
 }
 
 // NewFarmBuyScraper creates a new FarmBuy scraper
@@ -31,9 +35,25 @@ func NewFarmBuyScraper() *FarmBuyScraper {
 		},
 		userAgent: "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36",
 		baseURL:   "https://farmbuy.com",
+		profile:   DefaultSearchProfile(),
 	}
 }
 
+// SetSearchProfile sets the land size and price limits listings are filtered against
+func (s *FarmBuyScraper) SetSearchProfile(profile SearchProfile) {
+	s.profile = profile
+}
+
+// SetDiagnostics sets the collector that records which extraction path parsed each page
+func (s *FarmBuyScraper) SetDiagnostics(d *ParseDiagnostics) {
+	s.diagnostics = d
+}
+
+// SetFixtureRecorder sets the recorder that saves fetched pages as parser fixtures
+func (s *FarmBuyScraper) SetFixtureRecorder(r *FixtureRecorder) {
+	s.fixtures = r
+}
+
 // FarmBuy embedded JSON structure
 type farmBuyListing struct {
 	ID         string `json:"id"`
@@ -113,7 +133,7 @@ func (s *FarmBuyScraper) ScrapeListingsWithExistsCheck(ctx context.Context, stat
 		allListings = append(allListings, listings...)
 		log.Printf("Found %d listings on page %d (total: %d)", len(listings), page, len(allListings))
 
-		if !hasMore || len(listings) == 0 {
+		if !hasMore {
 			break
 		}
 
@@ -132,13 +152,44 @@ func (s *FarmBuyScraper) scrapePage(ctx context.Context, state string, page int)
 	if err != nil {
 		return nil, false, err
 	}
+	s.fixtures.Capture("farmbuy", "search", searchURL, "html", body)
 
+	listings, fromTiles, hasMore := s.parseSearchPage(body, state, page)
+	for i := range listings {
+		// Fetch detail page to get all images and description (map marker
+		// listings are only a fallback, so skip them)
+		if !fromTiles || listings[i].URL == "" {
+			continue
+		}
+		images, description, err := s.fetchDetailPage(ctx, listings[i].URL)
+		if err != nil {
+			log.Printf("Error fetching detail page for %s: %v", listings[i].ExternalID, err)
+		} else {
+			if len(images) > 0 {
+				imgJSON, _ := json.Marshal(images)
+				listings[i].Images = sql.NullString{String: string(imgJSON), Valid: true}
+			}
+			if description != "" {
+				listings[i].Description = sql.NullString{String: description, Valid: true}
+			}
+		}
+		// Rate limiting between detail fetches
+		time.Sleep(300 * time.Millisecond)
+	}
+
+	return listings, hasMore, nil
+}
+
+// parseSearchPage extracts the listings on a search results page that match
+// the search profile, with coordinates from the page's map markers, and
+// whether there's a next page. fromTiles is false if the page had no tile
+// JSON and the listings came from the map markers alone.
+func (s *FarmBuyScraper) parseSearchPage(body, state string, page int) (listings []models.Property, fromTiles, hasMore bool) {
 	// Extract embedded JSON from property tiles
 	// Pattern: <li data-propertyid="123456"...><script type="application/json"> {...} </script>
 	jsonPattern := regexp.MustCompile(`<li data-propertyid="(\d+)"[^>]*>.*?<script type="application/json">\s*(\{[^<]+\})\s*</script>`)
 	matches := jsonPattern.FindAllStringSubmatch(body, -1)
 
-	var listings []models.Property
 	seenIDs := make(map[string]bool)
 
 	for _, match := range matches {
@@ -161,42 +212,39 @@ func (s *FarmBuyScraper) scrapePage(ctx context.Context, state string, page int)
 		}
 
 		listing := s.convertListing(&data)
+		if listing != nil && !s.profile.Matches(listing) {
+			// FarmBuy search URLs don't take land size or price filters, so apply the profile here
+			// (before fetching the detail page to avoid wasted requests)
+			continue
+		}
 		if listing != nil {
-			// Fetch detail page to get all images and description
-			if data.URL != "" {
-				images, description, err := s.fetchDetailPage(ctx, data.URL)
-				if err != nil {
-					log.Printf("Error fetching detail page for %s: %v", propertyID, err)
-				} else {
-					if len(images) > 0 {
-						imgJSON, _ := json.Marshal(images)
-						listing.Images = sql.NullString{String: string(imgJSON), Valid: true}
-					}
-					if description != "" {
-						listing.Description = sql.NullString{String: description, Valid: true}
-					}
-				}
-				// Rate limiting between detail fetches
-				time.Sleep(300 * time.Millisecond)
-			}
 			listings = append(listings, *listing)
 		}
 	}
 
 	// If no embedded JSON found, fall back to fetching coordinates from map markers
-	if len(listings) == 0 {
+	if len(matches) == 0 {
 		log.Printf("No embedded JSON found, trying map markers...")
 		listings = s.extractFromMapMarkers(body, state)
+		s.diagnostics.Record("farmbuy", "map_markers", len(listings))
 	} else {
+		// Count every tile parsed, including those the search profile filtered out
+		s.diagnostics.Record("farmbuy", "tile_json", len(seenIDs))
 		// Enrich listings with coordinates from map markers
 		s.enrichWithCoordinates(body, listings)
 	}
 
 	// Check if there are more pages (pagination uses query params: ?page=N)
-	hasMore := strings.Contains(body, fmt.Sprintf("page=%d", page+1)) ||
+	hasMore = strings.Contains(body, fmt.Sprintf("page=%d", page+1)) ||
 		strings.Contains(body, `rel="next"`)
 
-	return listings, hasMore, nil
+	// An empty page means we've run off the end, even if pagination links are present.
+	// (A page whose listings were all filtered out by the search profile still has more.)
+	if len(matches) == 0 && len(listings) == 0 {
+		hasMore = false
+	}
+
+	return listings, len(matches) > 0, hasMore
 }
 
 func (s *FarmBuyScraper) convertListing(data *farmBuyListing) *models.Property {
@@ -242,13 +290,13 @@ func (s *FarmBuyScraper) convertListing(data *farmBuyListing) *models.Property {
 
 	// Land size
 	if data.LandArea != "" {
-		sqm := parseLandSizeString(data.LandArea)
+		sqm := units.ParseLandSize(data.LandArea)
 		if sqm > 0 {
 			listing.LandSizeSqm = sql.NullFloat64{Float64: sqm, Valid: true}
 		}
 	}
 
-	// Bedrooms/Bathrooms
+	// Bedrooms/Bathrooms/Car spaces
 	if data.Meta.Bed != "" {
 		if beds, err := strconv.ParseFloat(data.Meta.Bed, 64); err == nil && beds > 0 {
 			listing.Bedrooms = sql.NullInt64{Int64: int64(beds), Valid: true}
@@ -259,6 +307,11 @@ func (s *FarmBuyScraper) convertListing(data *farmBuyListing) *models.Property {
 			listing.Bathrooms = sql.NullInt64{Int64: int64(baths), Valid: true}
 		}
 	}
+	if data.Meta.Car != "" {
+		if cars, err := strconv.ParseFloat(data.Meta.Car, 64); err == nil && cars > 0 {
+			listing.Carspaces = sql.NullInt64{Int64: int64(cars), Valid: true}
+		}
+	}
 
 	// Image (can be string or bool)
 	if imgURL, ok := data.MainTileImageURL.(string); ok && imgURL != "" {
@@ -377,32 +430,24 @@ func (s *FarmBuyScraper) extractFromMapMarkers(body, state string) []models.Prop
 	return listings
 }
 
-// parseLandSizeString converts land size strings like "280ha" or "691.90ac" to square meters
-func parseLandSizeString(sizeStr string) float64 {
-	sizeStr = strings.ToLower(strings.TrimSpace(sizeStr))
-	sizeStr = strings.ReplaceAll(sizeStr, ",", "")
-
-	// Extract numeric value and unit
-	numPattern := regexp.MustCompile(`([\d.]+)\s*(ha|hectares?|ac|acres?)`)
-	matches := numPattern.FindStringSubmatch(sizeStr)
-	if len(matches) < 3 {
-		return 0
-	}
-
-	value, err := strconv.ParseFloat(matches[1], 64)
+// RefreshListing refetches a saved listing's detail page. FarmBuy detail pages
+// only add images and the full description to what the search results give,
+// so the listing is returned with just those updated.
+func (s *FarmBuyScraper) RefreshListing(ctx context.Context, listing models.Property) (*models.Property, error) {
+	images, description, err := s.fetchDetailPage(ctx, listing.URL)
 	if err != nil {
-		return 0
+		return nil, err
 	}
-
-	unit := matches[2]
-	switch {
-	case strings.HasPrefix(unit, "ha") || strings.HasPrefix(unit, "hectare"):
-		return value * 10000
-	case strings.HasPrefix(unit, "ac") || strings.HasPrefix(unit, "acre"):
-		return value * 4046.86
-	default:
-		return 0
+	now := time.Now()
+	listing.ScrapedAt, listing.UpdatedAt = now, now
+	if len(images) > 0 {
+		imgJSON, _ := json.Marshal(images)
+		listing.Images = sql.NullString{String: string(imgJSON), Valid: true}
 	}
+	if description != "" {
+		listing.Description = sql.NullString{String: description, Valid: true}
+	}
+	return &listing, nil
 }
 
 // fetchDetailPage fetches images and description from a property detail page
@@ -411,7 +456,15 @@ func (s *FarmBuyScraper) fetchDetailPage(ctx context.Context, url string) ([]str
 	if err != nil {
 		return nil, "", err
 	}
+	s.fixtures.Capture("farmbuy", "detail", url, "html", body)
 
+	images, description := s.parseDetailPage(body)
+	return images, description, nil
+}
+
+// parseDetailPage extracts the full-size images and description from a
+// property detail page
+func (s *FarmBuyScraper) parseDetailPage(body string) ([]string, string) {
 	// Extract full-size images from farmbuycdn (1920_ prefix for full size)
 	// Pattern: farmbuycdn.clodflare.pushcreative.com.au/PROPERTYID/1920_*
 	imgPattern := regexp.MustCompile(`https://farmbuycdn\.clodflare\.pushcreative\.com\.au/\d+/1920_[^"]+\.(jpg|jpeg|png|webp)`)
@@ -430,7 +483,7 @@ func (s *FarmBuyScraper) fetchDetailPage(ctx context.Context, url string) ([]str
 	// Extract description from <div id="propertyprofile" class="user-content">
 	description := s.extractDescription(body)
 
-	return images, description, nil
+	return images, description
 }
 
 // extractDescription extracts the property description from HTML
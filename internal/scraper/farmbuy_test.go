@@ -0,0 +1,136 @@
+package scraper
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFarmBuyParseSearchPage(t *testing.T) {
+	tests := []struct {
+		fixture       string
+		page          int
+		wantFromTiles bool
+		wantHasMore   bool
+		want          []listingFields
+	}{
+		{
+			// Tile JSON with coordinates from the map markers. The repeated
+			// tile is skipped and the 2.5 ha one is below the search profile.
+			fixture:       "search.html",
+			page:          1,
+			wantFromTiles: true,
+			wantHasMore:   true,
+			want: []listingFields{
+				{
+					ExternalID:   "363892",
+					Source:       "farmbuy",
+					URL:          "https://farmbuy.com/property/363892-bylong-valley-way-rylstone",
+					Address:      "2310 Bylong Valley Way",
+					Suburb:       "Rylstone",
+					State:        "NSW",
+					Postcode:     "2849",
+					PriceText:    "$1,695,000",
+					PropertyType: "grazing",
+					Bedrooms:     4,
+					Bathrooms:    2,
+					Carspaces:    3,
+					LandSizeSqm:  hectares(404.7),
+					Latitude:     -32.8724,
+					Longitude:    149.9712,
+					Images:       []string{"https://farmbuycdn.clodflare.pushcreative.com.au/363892/640_main.jpg"},
+				},
+				{
+					// No state, types or image, and a 0,0 marker
+					ExternalID:   "363893",
+					Source:       "farmbuy",
+					URL:          "https://farmbuy.com/property/363893-lue-road-lue",
+					Address:      "45 Lue Road",
+					Suburb:       "Lue",
+					State:        "NSW",
+					Postcode:     "2850",
+					PriceText:    "Auction",
+					PropertyType: "rural",
+					LandSizeSqm:  acres(65),
+				},
+			},
+		},
+		{
+			// No tile JSON: listings from the map markers alone
+			fixture:       "search-markers.html",
+			page:          3,
+			wantFromTiles: false,
+			wantHasMore:   false,
+			want: []listingFields{
+				{
+					ExternalID:   "363892",
+					Source:       "farmbuy",
+					URL:          "https://farmbuy.com/property/363892-bylong-valley-way-rylstone",
+					Address:      "2310 Bylong Valley Way",
+					Suburb:       "Rylstone",
+					State:        "NSW",
+					PropertyType: "rural",
+					Latitude:     -32.8724,
+					Longitude:    149.9712,
+				},
+				{
+					ExternalID:   "363895",
+					Source:       "farmbuy",
+					URL:          "https://farmbuy.com/property/363895-goolma-road-gulgong",
+					Suburb:       "Gulgong",
+					State:        "NSW",
+					PropertyType: "rural",
+					Latitude:     -32.5911,
+					Longitude:    149.5870,
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			s := NewFarmBuyScraper()
+			listings, fromTiles, hasMore := s.parseSearchPage(readFixture(t, "farmbuy", tt.fixture), "nsw", tt.page)
+			if fromTiles != tt.wantFromTiles {
+				t.Errorf("fromTiles = %v, want %v", fromTiles, tt.wantFromTiles)
+			}
+			if hasMore != tt.wantHasMore {
+				t.Errorf("hasMore = %v, want %v", hasMore, tt.wantHasMore)
+			}
+			checkListings(t, listings, tt.want)
+		})
+	}
+}
+
+func TestFarmBuyParseDetailPage(t *testing.T) {
+	tests := []struct {
+		fixture         string
+		wantImages      []string
+		wantDescription string
+	}{
+		{
+			// Full-size images only, once each; the description stops at the
+			// first <h4> section. Entities beyond the common ones are left for
+			// SanitizeDescription.
+			fixture: "detail.html",
+			wantImages: []string{
+				"https://farmbuycdn.clodflare.pushcreative.com.au/363892/1920_aerial.jpg",
+				"https://farmbuycdn.clodflare.pushcreative.com.au/363892/1920_yards.webp",
+			},
+			wantDescription: "Highly productive grazing & cropping country.\n\n" +
+				`Reliable 650mm rainfall &ndash; "Glenroy" has it all.`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			s := NewFarmBuyScraper()
+			images, description := s.parseDetailPage(readFixture(t, "farmbuy", tt.fixture))
+			if !reflect.DeepEqual(images, tt.wantImages) {
+				t.Errorf("images = %q, want %q", images, tt.wantImages)
+			}
+			if description != tt.wantDescription {
+				t.Errorf("description = %q, want %q", description, tt.wantDescription)
+			}
+		})
+	}
+}
@@ -14,13 +14,16 @@ import (
 	"time"
 
 	"farm-search/internal/models"
+	"farm-search/internal/units"
 )
 
 // FarmPropertyScraper handles scraping from farmproperty.com.au
 type FarmPropertyScraper struct {
-	client    *http.Client
-	userAgent string
-	baseURL   string
+	client      *http.Client
+	userAgent   string
+	baseURL     string
+	diagnostics *ParseDiagnostics
+	fixtures    *FixtureRecorder
 }
 
 // NewFarmPropertyScraper creates a new FarmProperty scraper
@@ -34,6 +37,16 @@ func NewFarmPropertyScraper() *FarmPropertyScraper {
 	}
 }
 
+// SetDiagnostics sets the collector that records which extraction path parsed each page
+func (s *FarmPropertyScraper) SetDiagnostics(d *ParseDiagnostics) {
+	s.diagnostics = d
+}
+
+// SetFixtureRecorder sets the recorder that saves fetched pages as parser fixtures
+func (s *FarmPropertyScraper) SetFixtureRecorder(r *FixtureRecorder) {
+	s.fixtures = r
+}
+
 // ScrapeListings scrapes property listings from FarmProperty
 func (s *FarmPropertyScraper) ScrapeListings(ctx context.Context, state string, maxPages int) ([]models.Property, error) {
 	return s.ScrapeListingsWithExistsCheck(ctx, state, maxPages, nil)
@@ -110,6 +123,7 @@ func (s *FarmPropertyScraper) scrapePage(ctx context.Context, state string, page
 	if err != nil {
 		return nil, false, err
 	}
+	s.fixtures.Capture("farmproperty", "search", searchURL, "html", body)
 
 	// Find property links
 	linkPattern := regexp.MustCompile(`href="/property/(\d+)-([^"]+)"`)
@@ -144,6 +158,7 @@ func (s *FarmPropertyScraper) scrapePage(ctx context.Context, state string, page
 		// Rate limiting between detail fetches
 		time.Sleep(500 * time.Millisecond)
 	}
+	s.diagnostics.Record("farmproperty", "property_links", len(listings))
 
 	// Check if there are more pages
 	hasMore := strings.Contains(body, `rel="next"`) ||
@@ -158,6 +173,7 @@ func (s *FarmPropertyScraper) FetchListingDetails(ctx context.Context, listingUR
 	if err != nil {
 		return nil, err
 	}
+	s.fixtures.Capture("farmproperty", "detail", listingURL, "html", body)
 
 	return s.parseListingDetails(body, listingURL, listingID)
 }
@@ -273,21 +289,13 @@ func (s *FarmPropertyScraper) parseListingDetails(html, listingURL, listingID st
 	}
 
 	// Extract land size
-	landPattern := regexp.MustCompile(`([\d,.]+)\s*(hectares?|ha|acres?|ac)\b`)
-	if matches := landPattern.FindStringSubmatch(strings.ToLower(html)); len(matches) >= 3 {
-		sizeStr := strings.ReplaceAll(matches[1], ",", "")
-		if size, err := strconv.ParseFloat(sizeStr, 64); err == nil {
-			unit := matches[2]
-			var sqm float64
-			if strings.HasPrefix(unit, "hectare") || unit == "ha" {
-				sqm = size * 10000
-			} else if strings.HasPrefix(unit, "acre") || unit == "ac" {
-				sqm = size * 4046.86
-			}
-			if sqm > 0 {
-				listing.LandSizeSqm = sql.NullFloat64{Float64: sqm, Valid: true}
-			}
-		}
+	if sqm := units.FindLandSize(html); sqm > 0 {
+		listing.LandSizeSqm = sql.NullFloat64{Float64: sqm, Valid: true}
+	}
+
+	// Car spaces: FarmProperty has no feature counts, so from the description
+	if listing.Description.Valid {
+		listing.Carspaces = findCarspaces(listing.Description.String)
 	}
 
 	// Extract property type from page content
@@ -0,0 +1,93 @@
+package scraper
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+)
+
+// DefaultFixtureDir is where captured pages are saved when fixture capture is
+// enabled (relative to the repository root): the parser tests' testdata, so
+// every captured page is replayed by go test
+const DefaultFixtureDir = "internal/scraper/testdata"
+
+// FixtureRecorder saves the raw pages scrapers fetch so parser changes can be
+// checked against real markup. Each capture is written as
+// <dir>/<source>/<kind>-<hash>.<ext> with a .meta.json sidecar recording where
+// it came from. Contact details and API keys are redacted before writing.
+type FixtureRecorder struct {
+	dir string
+}
+
+// FixtureMeta describes where a captured fixture came from
+type FixtureMeta struct {
+	Source     string    `json:"source"`
+	Kind       string    `json:"kind"` // "search" or "detail"
+	URL        string    `json:"url"`
+	CapturedAt time.Time `json:"captured_at"`
+}
+
+// NewFixtureRecorder creates a recorder that writes fixtures under dir
+func NewFixtureRecorder(dir string) *FixtureRecorder {
+	if dir == "" {
+		dir = DefaultFixtureDir
+	}
+	return &FixtureRecorder{dir: dir}
+}
+
+var (
+	fixtureEmailPattern  = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+	fixturePhonePattern  = regexp.MustCompile(`(?:\+?61[ \-]?|\b0)[2-478](?:[ \-]?\d){8}\b`)
+	fixtureAPIKeyPattern = regexp.MustCompile(`(?i)((?:api_key|apikey|key|token)=)[^&"'\s]+`)
+)
+
+// sanitizeFixture redacts agent email addresses, phone numbers and API keys
+func sanitizeFixture(body string) string {
+	body = fixtureEmailPattern.ReplaceAllString(body, "redacted@example.com")
+	body = fixturePhonePattern.ReplaceAllString(body, "0400000000")
+	body = fixtureAPIKeyPattern.ReplaceAllString(body, "${1}REDACTED")
+	return body
+}
+
+// Capture writes a fetched page as a fixture. ext is the file extension ("html" or "json").
+// Failures are logged rather than returned so capture never interrupts a scrape.
+// Safe to call on a nil recorder.
+func (r *FixtureRecorder) Capture(source, kind, pageURL, ext, body string) {
+	if r == nil || body == "" {
+		return
+	}
+
+	dir := filepath.Join(r.dir, source)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		log.Printf("Warning: failed to create fixture directory: %v", err)
+		return
+	}
+
+	sum := sha1.Sum([]byte(pageURL))
+	base := filepath.Join(dir, fmt.Sprintf("%s-%s", kind, hex.EncodeToString(sum[:])[:12]))
+
+	if err := os.WriteFile(base+"."+ext, []byte(sanitizeFixture(body)), 0644); err != nil {
+		log.Printf("Warning: failed to write fixture: %v", err)
+		return
+	}
+
+	meta := FixtureMeta{
+		Source:     source,
+		Kind:       kind,
+		URL:        sanitizeFixture(pageURL),
+		CapturedAt: time.Now(),
+	}
+	data, _ := json.MarshalIndent(meta, "", "  ")
+	if err := os.WriteFile(base+".meta.json", data, 0644); err != nil {
+		log.Printf("Warning: failed to write fixture metadata: %v", err)
+		return
+	}
+
+	log.Printf("Captured %s %s fixture: %s.%s", source, kind, base, ext)
+}
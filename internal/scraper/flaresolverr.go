@@ -0,0 +1,88 @@
+package scraper
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// FlareSolverrClient fetches pages through a FlareSolverr-compatible service,
+// which loads them in its own browser and solves anti-bot challenges.
+// It's a cheaper (self-hosted) alternative to ScrapingBee for bot-protected pages.
+type FlareSolverrClient struct {
+	baseURL    string
+	httpClient *http.Client
+	maxTimeout time.Duration
+}
+
+// NewFlareSolverrClient creates a client for the FlareSolverr service at baseURL
+// (e.g. "http://localhost:8191")
+func NewFlareSolverrClient(baseURL string) *FlareSolverrClient {
+	return &FlareSolverrClient{
+		baseURL: strings.TrimSuffix(strings.TrimSuffix(baseURL, "/"), "/v1"),
+		httpClient: &http.Client{
+			Timeout: 150 * time.Second, // Allow for maxTimeout plus FlareSolverr's own overhead
+		},
+		maxTimeout: 120 * time.Second,
+	}
+}
+
+// flareSolverrRequest is the request body for the /v1 endpoint
+type flareSolverrRequest struct {
+	Cmd        string `json:"cmd"`
+	URL        string `json:"url"`
+	MaxTimeout int    `json:"maxTimeout"` // Milliseconds
+}
+
+// flareSolverrResponse is the response body from the /v1 endpoint
+type flareSolverrResponse struct {
+	Status   string `json:"status"`
+	Message  string `json:"message"`
+	Solution struct {
+		URL      string `json:"url"`
+		Status   int    `json:"status"`
+		Response string `json:"response"`
+	} `json:"solution"`
+}
+
+// FetchHTML retrieves a URL through FlareSolverr and returns the page HTML
+func (c *FlareSolverrClient) FetchHTML(ctx context.Context, targetURL string) (string, error) {
+	reqBody, err := json.Marshal(flareSolverrRequest{
+		Cmd:        "request.get",
+		URL:        targetURL,
+		MaxTimeout: int(c.maxTimeout / time.Millisecond),
+	})
+	if err != nil {
+		return "", fmt.Errorf("marshalling request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/v1", bytes.NewReader(reqBody))
+	if err != nil {
+		return "", fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("executing request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var result flareSolverrResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("decoding response (HTTP %d): %w", resp.StatusCode, err)
+	}
+
+	if result.Status != "ok" {
+		return "", fmt.Errorf("FlareSolverr error: %s", result.Message)
+	}
+	if result.Solution.Status != 0 && result.Solution.Status != http.StatusOK {
+		return "", fmt.Errorf("HTTP %d from %s", result.Solution.Status, targetURL)
+	}
+
+	return result.Solution.Response, nil
+}
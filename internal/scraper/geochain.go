@@ -0,0 +1,364 @@
+package scraper
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// GeocodeProvider is a geocoding service a GeocoderChain can fall back
+// through
+type GeocodeProvider interface {
+	Name() string
+	GeocodeWithConfidence(ctx context.Context, address string) (*GeocodeResult, error)
+}
+
+// defaultGeocodeRates are each provider's requests per second unless
+// GEOCODE_RATE_LIMITS says otherwise: the public Nominatim's usage policy,
+// LocationIQ's free tier and well under Google's quota. A local Nominatim
+// isn't limited.
+var defaultGeocodeRates = map[string]float64{
+	"local-nominatim": 0,
+	"nominatim":       1,
+	"locationiq":      2,
+	"google":          25,
+}
+
+// limitedProvider is a provider with the time its next request may be made
+type limitedProvider struct {
+	GeocodeProvider
+	interval time.Duration
+	next     time.Time
+}
+
+// GeocoderChain geocodes with the first of its providers that knows the
+// address, in order, keeping each to its rate limit. It isn't safe for
+// concurrent use.
+type GeocoderChain struct {
+	providers []*limitedProvider
+}
+
+// NewGeocoderChain creates a chain of providers with the requests per second
+// in rates (defaultGeocodeRates for those not in it; 0 for no limit)
+func NewGeocoderChain(providers []GeocodeProvider, rates map[string]float64) *GeocoderChain {
+	c := &GeocoderChain{}
+	for _, p := range providers {
+		rate, ok := rates[p.Name()]
+		if !ok {
+			rate = defaultGeocodeRates[p.Name()]
+		}
+		lp := &limitedProvider{GeocodeProvider: p}
+		if rate > 0 {
+			lp.interval = time.Duration(float64(time.Second) / rate)
+		}
+		c.providers = append(c.providers, lp)
+	}
+	return c
+}
+
+// NewGeocoderChainFromEnv creates the chain a local Nominatim (NOMINATIM_URL),
+// LocationIQ (LOCATIONIQ_API_KEY) and Google (GOOGLE_GEOCODING_API_KEY) make
+// up, in that order, for those configured; the public Nominatim if none are.
+// GEOCODE_RATE_LIMITS overrides their requests per second, e.g.
+// "locationiq:1,google:10".
+func NewGeocoderChainFromEnv() (*GeocoderChain, error) {
+	rates, err := parseGeocodeRates(os.Getenv("GEOCODE_RATE_LIMITS"))
+	if err != nil {
+		return nil, err
+	}
+
+	var providers []GeocodeProvider
+	if u := os.Getenv("NOMINATIM_URL"); u != "" {
+		providers = append(providers, NewNominatimGeocoder(u))
+	}
+	if key := os.Getenv("LOCATIONIQ_API_KEY"); key != "" {
+		providers = append(providers, NewLocationIQGeocoder(key))
+	}
+	if key := os.Getenv("GOOGLE_GEOCODING_API_KEY"); key != "" {
+		providers = append(providers, NewGoogleGeocoder(key))
+	}
+	if len(providers) == 0 {
+		providers = append(providers, NewGeocoder())
+	}
+	return NewGeocoderChain(providers, rates), nil
+}
+
+// parseGeocodeRates parses "name:rps" pairs separated by commas
+func parseGeocodeRates(s string) (map[string]float64, error) {
+	rates := make(map[string]float64)
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part == "" {
+			continue
+		}
+		name, v, ok := strings.Cut(part, ":")
+		rate, err := strconv.ParseFloat(v, 64)
+		if _, known := defaultGeocodeRates[name]; !ok || err != nil || rate < 0 || !known {
+			return nil, fmt.Errorf("invalid GEOCODE_RATE_LIMITS entry %q (want provider:requests_per_second, provider one of local-nominatim, nominatim, locationiq or google)", part)
+		}
+		rates[name] = rate
+	}
+	return rates, nil
+}
+
+// Providers returns the names of the chain's providers, in order
+func (c *GeocoderChain) Providers() []string {
+	names := make([]string, len(c.providers))
+	for i, p := range c.providers {
+		names[i] = p.Name()
+	}
+	return names
+}
+
+// GeocodeWithConfidence tries each provider in turn until one matches the
+// address, moving on when one doesn't know it or fails (e.g. a quota
+// error). The error wraps ErrNoGeocodeMatch only if every provider answered
+// that it has no match.
+func (c *GeocoderChain) GeocodeWithConfidence(ctx context.Context, address string) (*GeocodeResult, error) {
+	var errs []error
+	noMatch := true
+	for _, p := range c.providers {
+		if wait := time.Until(p.next); wait > 0 {
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(wait):
+			}
+		}
+		result, err := p.GeocodeWithConfidence(ctx, address)
+		p.next = time.Now().Add(p.interval)
+		if err == nil {
+			return result, nil
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if errors.Is(err, ErrNoGeocodeMatch) {
+			// Not wrapped, so a failure elsewhere doesn't read as a miss
+			errs = append(errs, fmt.Errorf("%s: %v", p.Name(), err))
+			continue
+		}
+		noMatch = false
+		errs = append(errs, fmt.Errorf("%s: %w", p.Name(), err))
+	}
+	if noMatch {
+		return nil, fmt.Errorf("%w for address: %s", ErrNoGeocodeMatch, address)
+	}
+	return nil, errors.Join(errs...)
+}
+
+// getGeocodeJSON fetches a geocoding API URL into v
+func getGeocodeJSON(ctx context.Context, client *http.Client, reqURL string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", reqURL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		// Without the URL, which has the API key in it
+		if ue, ok := err.(*url.Error); ok {
+			err = ue.Err
+		}
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// LocationIQ answers 404 for an address it can't find
+	if resp.StatusCode == http.StatusNotFound {
+		return ErrNoGeocodeMatch
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+	return nil
+}
+
+// LocationIQGeocoder geocodes with LocationIQ's Nominatim-compatible search
+type LocationIQGeocoder struct {
+	client  *http.Client
+	baseURL string
+	apiKey  string
+}
+
+// NewLocationIQGeocoder creates a LocationIQ geocoder
+func NewLocationIQGeocoder(apiKey string) *LocationIQGeocoder {
+	return &LocationIQGeocoder{
+		client:  &http.Client{Timeout: 10 * time.Second},
+		baseURL: "https://us1.locationiq.com/v1",
+		apiKey:  apiKey,
+	}
+}
+
+// Name identifies the geocoder in GeocodeResult.Provider and logs
+func (g *LocationIQGeocoder) Name() string {
+	return "locationiq"
+}
+
+// locationIQConfidence scores a LocationIQ match by its match level, as
+// geocodeConfidence does Nominatim's place rank
+func locationIQConfidence(level string) float64 {
+	switch level {
+	case "venue", "building":
+		return 0.9
+	case "street":
+		return 0.6
+	case "neighbourhood", "city", "postalcode":
+		return 0.3
+	default:
+		return 0.1
+	}
+}
+
+// GeocodeWithConfidence converts an address to coordinates, scoring how
+// precise the match is
+func (g *LocationIQGeocoder) GeocodeWithConfidence(ctx context.Context, address string) (*GeocodeResult, error) {
+	params := url.Values{}
+	params.Set("key", g.apiKey)
+	params.Set("q", address)
+	params.Set("format", "json")
+	params.Set("limit", "5")
+	params.Set("countrycodes", "au")
+	params.Set("matchquality", "1")
+
+	var results []struct {
+		Lat          string `json:"lat"`
+		Lon          string `json:"lon"`
+		MatchQuality struct {
+			MatchLevel string `json:"matchlevel"`
+		} `json:"matchquality"`
+	}
+	if err := getGeocodeJSON(ctx, g.client, g.baseURL+"/search?"+params.Encode(), &results); err != nil {
+		if errors.Is(err, ErrNoGeocodeMatch) {
+			return nil, fmt.Errorf("%w for address: %s", ErrNoGeocodeMatch, address)
+		}
+		return nil, err
+	}
+	if len(results) == 0 {
+		return nil, fmt.Errorf("%w for address: %s", ErrNoGeocodeMatch, address)
+	}
+
+	matches := make([]GeocodeResult, len(results))
+	for i, result := range results {
+		matches[i] = GeocodeResult{
+			Confidence: locationIQConfidence(result.MatchQuality.MatchLevel),
+			Match:      result.MatchQuality.MatchLevel,
+			Provider:   g.Name(),
+		}
+		var err error
+		if matches[i].Lat, err = strconv.ParseFloat(result.Lat, 64); err != nil {
+			return nil, fmt.Errorf("failed to parse latitude: %w", err)
+		}
+		if matches[i].Lng, err = strconv.ParseFloat(result.Lon, 64); err != nil {
+			return nil, fmt.Errorf("failed to parse longitude: %w", err)
+		}
+	}
+
+	geocoded := &matches[0]
+	markAmbiguous(geocoded, matches[1:])
+	return geocoded, nil
+}
+
+// GoogleGeocoder geocodes with the Google Maps Geocoding API
+type GoogleGeocoder struct {
+	client  *http.Client
+	baseURL string
+	apiKey  string
+}
+
+// NewGoogleGeocoder creates a Google geocoder
+func NewGoogleGeocoder(apiKey string) *GoogleGeocoder {
+	return &GoogleGeocoder{
+		client:  &http.Client{Timeout: 10 * time.Second},
+		baseURL: "https://maps.googleapis.com/maps/api/geocode/json",
+		apiKey:  apiKey,
+	}
+}
+
+// Name identifies the geocoder in GeocodeResult.Provider and logs
+func (g *GoogleGeocoder) Name() string {
+	return "google"
+}
+
+// googleConfidence scores a Google match by its location type and what it
+// matched, as geocodeConfidence does Nominatim's place rank
+func googleConfidence(locationType string, types []string) float64 {
+	has := func(t ...string) bool {
+		return slices.ContainsFunc(types, func(s string) bool { return slices.Contains(t, s) })
+	}
+	switch {
+	case locationType == "ROOFTOP":
+		return 0.9
+	case locationType == "RANGE_INTERPOLATED", has("street_address", "premise", "subpremise", "route", "intersection"):
+		return 0.6
+	case has("locality", "sublocality", "neighborhood", "postal_code", "colloquial_area"):
+		return 0.3
+	default:
+		return 0.1
+	}
+}
+
+// GeocodeWithConfidence converts an address to coordinates, scoring how
+// precise the match is
+func (g *GoogleGeocoder) GeocodeWithConfidence(ctx context.Context, address string) (*GeocodeResult, error) {
+	params := url.Values{}
+	params.Set("address", address)
+	params.Set("components", "country:AU")
+	params.Set("key", g.apiKey)
+
+	var resp struct {
+		Status       string `json:"status"`
+		ErrorMessage string `json:"error_message"`
+		Results      []struct {
+			Types    []string `json:"types"`
+			Geometry struct {
+				Location struct {
+					Lat float64 `json:"lat"`
+					Lng float64 `json:"lng"`
+				} `json:"location"`
+				LocationType string `json:"location_type"`
+			} `json:"geometry"`
+		} `json:"results"`
+	}
+	if err := getGeocodeJSON(ctx, g.client, g.baseURL+"?"+params.Encode(), &resp); err != nil {
+		return nil, err
+	}
+	switch resp.Status {
+	case "OK":
+	case "ZERO_RESULTS":
+		return nil, fmt.Errorf("%w for address: %s", ErrNoGeocodeMatch, address)
+	default: // e.g. OVER_QUERY_LIMIT or REQUEST_DENIED
+		return nil, fmt.Errorf("%s: %s", resp.Status, resp.ErrorMessage)
+	}
+	if len(resp.Results) == 0 {
+		return nil, fmt.Errorf("%w for address: %s", ErrNoGeocodeMatch, address)
+	}
+
+	matches := make([]GeocodeResult, len(resp.Results))
+	for i, result := range resp.Results {
+		matches[i] = GeocodeResult{
+			Lat:        result.Geometry.Location.Lat,
+			Lng:        result.Geometry.Location.Lng,
+			Confidence: googleConfidence(result.Geometry.LocationType, result.Types),
+			Provider:   g.Name(),
+		}
+		if len(result.Types) > 0 {
+			matches[i].Match = result.Types[0]
+		}
+	}
+
+	geocoded := &matches[0]
+	markAmbiguous(geocoded, matches[1:])
+	return geocoded, nil
+}
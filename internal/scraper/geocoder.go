@@ -3,11 +3,15 @@ package scraper
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"strings"
 	"time"
+
+	"farm-search/internal/geo"
 )
 
 // Geocoder handles address geocoding using Nominatim
@@ -15,6 +19,7 @@ type Geocoder struct {
 	client    *http.Client
 	userAgent string
 	baseURL   string
+	name      string
 }
 
 // NominatimResult represents a geocoding result from Nominatim
@@ -24,6 +29,55 @@ type NominatimResult struct {
 	DisplayName string `json:"display_name"`
 	Type        string `json:"type"`
 	Importance  float64 `json:"importance"`
+	PlaceRank   int     `json:"place_rank"`  // 30 for a house, 26 for a street, ~16-20 for a suburb
+	AddressType string  `json:"addresstype"` // e.g. 'house', 'road', 'suburb', 'town'
+}
+
+// GeocodeResult is a geocoded point with how closely it matches the address
+type GeocodeResult struct {
+	Lat        float64
+	Lng        float64
+	Confidence float64 // 0-1, from how precise the match is
+	Match      string  // Nominatim address type, e.g. 'house' or 'suburb'
+	Ambiguous  bool    // Another match as precise was far away
+	Provider   string  // Which geocoder matched, e.g. 'nominatim'
+}
+
+// ErrNoGeocodeMatch is returned when a geocoder doesn't know an address
+var ErrNoGeocodeMatch = errors.New("no results found")
+
+// ambiguousKm is how far apart two equally precise matches for an address
+// have to be for it to count as ambiguous, e.g. a road name found in two
+// towns
+const ambiguousKm = 5
+
+// markAmbiguous flags r as ambiguous if another match at least as precise
+// is more than ambiguousKm from it, capping its confidence since the pin
+// may be in the wrong town altogether
+func markAmbiguous(r *GeocodeResult, others []GeocodeResult) {
+	for _, o := range others {
+		if o.Confidence >= r.Confidence && geo.Haversine(r.Lat, r.Lng, o.Lat, o.Lng) > ambiguousKm {
+			r.Ambiguous = true
+			r.Confidence = min(r.Confidence, 0.1)
+			return
+		}
+	}
+}
+
+// geocodeConfidence scores a Nominatim match by its place rank: an exact
+// house is nearly certain to be on the property, a street or suburb centre
+// may be kilometres away from a farm
+func geocodeConfidence(placeRank int) float64 {
+	switch {
+	case placeRank >= 30:
+		return 0.9
+	case placeRank >= 26:
+		return 0.6
+	case placeRank >= 16:
+		return 0.3
+	default:
+		return 0.1
+	}
 }
 
 // NewGeocoder creates a new Nominatim geocoder
@@ -34,23 +88,48 @@ func NewGeocoder() *Geocoder {
 		},
 		userAgent: "FarmSearch/1.0 (property search application)",
 		baseURL:   "https://nominatim.openstreetmap.org",
+		name:      "nominatim",
 	}
 }
 
+// NewNominatimGeocoder creates a geocoder for a self-hosted Nominatim at
+// baseURL, e.g. http://localhost:8088
+func NewNominatimGeocoder(baseURL string) *Geocoder {
+	g := NewGeocoder()
+	g.baseURL = strings.TrimRight(baseURL, "/")
+	g.name = "local-nominatim"
+	return g
+}
+
+// Name identifies the geocoder in GeocodeResult.Provider and logs
+func (g *Geocoder) Name() string {
+	return g.name
+}
+
 // Geocode converts an address to coordinates
 func (g *Geocoder) Geocode(ctx context.Context, address string) (lat, lng float64, err error) {
-	// Build the request URL
+	result, err := g.GeocodeWithConfidence(ctx, address)
+	if err != nil {
+		return 0, 0, err
+	}
+	return result.Lat, result.Lng, nil
+}
+
+// GeocodeWithConfidence converts an address to coordinates, scoring how
+// precise the match is
+func (g *Geocoder) GeocodeWithConfidence(ctx context.Context, address string) (*GeocodeResult, error) {
+	// Build the request URL (jsonv2 includes the place rank)
 	params := url.Values{}
 	params.Set("q", address)
-	params.Set("format", "json")
-	params.Set("limit", "1")
+	params.Set("format", "jsonv2")
+	params.Set("limit", "5") // Enough to tell an ambiguous address
 	params.Set("countrycodes", "au")
 
 	reqURL := fmt.Sprintf("%s/search?%s", g.baseURL, params.Encode())
 
 	req, err := http.NewRequestWithContext(ctx, "GET", reqURL, nil)
 	if err != nil {
-		return 0, 0, fmt.Errorf("failed to create request: %w", err)
+		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	// Nominatim requires a valid User-Agent
@@ -59,38 +138,47 @@ func (g *Geocoder) Geocode(ctx context.Context, address string) (lat, lng float6
 
 	resp, err := g.client.Do(req)
 	if err != nil {
-		return 0, 0, fmt.Errorf("request failed: %w", err)
+		return nil, fmt.Errorf("request failed: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return 0, 0, fmt.Errorf("HTTP %d: %s", resp.StatusCode, resp.Status)
+		return nil, fmt.Errorf("HTTP %d: %s", resp.StatusCode, resp.Status)
 	}
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return 0, 0, fmt.Errorf("failed to read response: %w", err)
+		return nil, fmt.Errorf("failed to read response: %w", err)
 	}
 
 	var results []NominatimResult
 	if err := json.Unmarshal(body, &results); err != nil {
-		return 0, 0, fmt.Errorf("failed to parse response: %w", err)
+		return nil, fmt.Errorf("failed to parse response: %w", err)
 	}
 
 	if len(results) == 0 {
-		return 0, 0, fmt.Errorf("no results found for address: %s", address)
+		return nil, fmt.Errorf("%w for address: %s", ErrNoGeocodeMatch, address)
 	}
 
 	// Parse coordinates
-	result := results[0]
-	if _, err := fmt.Sscanf(result.Lat, "%f", &lat); err != nil {
-		return 0, 0, fmt.Errorf("failed to parse latitude: %w", err)
-	}
-	if _, err := fmt.Sscanf(result.Lon, "%f", &lng); err != nil {
-		return 0, 0, fmt.Errorf("failed to parse longitude: %w", err)
+	matches := make([]GeocodeResult, len(results))
+	for i, result := range results {
+		matches[i] = GeocodeResult{
+			Confidence: geocodeConfidence(result.PlaceRank),
+			Match:      result.AddressType,
+			Provider:   g.name,
+		}
+		if _, err := fmt.Sscanf(result.Lat, "%f", &matches[i].Lat); err != nil {
+			return nil, fmt.Errorf("failed to parse latitude: %w", err)
+		}
+		if _, err := fmt.Sscanf(result.Lon, "%f", &matches[i].Lng); err != nil {
+			return nil, fmt.Errorf("failed to parse longitude: %w", err)
+		}
 	}
 
-	return lat, lng, nil
+	geocoded := &matches[0]
+	markAmbiguous(geocoded, matches[1:])
+	return geocoded, nil
 }
 
 // ReverseGeocode converts coordinates to an address
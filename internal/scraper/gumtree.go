@@ -0,0 +1,260 @@
+package scraper
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"html"
+	"io"
+	"log"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"farm-search/internal/models"
+	"farm-search/internal/units"
+)
+
+// GumtreeScraper handles scraping private-sale land listings from gumtree.com.au.
+// Ads are much less structured than agency listings: land size usually only
+// appears in the title or description, and many ads have no map location.
+type GumtreeScraper struct {
+	client      *http.Client
+	userAgent   string
+	baseURL     string
+	profile     SearchProfile
+	diagnostics *ParseDiagnostics
+	fixtures    *FixtureRecorder
+}
+
+// NewGumtreeScraper creates a new Gumtree scraper
+func NewGumtreeScraper() *GumtreeScraper {
+	return &GumtreeScraper{
+		client: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+		userAgent: "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36",
+		baseURL:   "https://www.gumtree.com.au",
+		profile:   DefaultSearchProfile(),
+	}
+}
+
+// SetSearchProfile sets the land size and price limits listings are filtered against
+func (s *GumtreeScraper) SetSearchProfile(profile SearchProfile) {
+	s.profile = profile
+}
+
+// SetDiagnostics sets the collector that records which extraction path parsed each page
+func (s *GumtreeScraper) SetDiagnostics(d *ParseDiagnostics) {
+	s.diagnostics = d
+}
+
+// SetFixtureRecorder sets the recorder that saves fetched pages as parser fixtures
+func (s *GumtreeScraper) SetFixtureRecorder(r *FixtureRecorder) {
+	s.fixtures = r
+}
+
+// gumtreeLocations maps state slugs to Gumtree's location IDs
+var gumtreeLocations = map[string]string{
+	"nsw": "3008839",
+	"vic": "3008845",
+	"qld": "3008840",
+	"sa":  "3008841",
+	"wa":  "3008846",
+	"tas": "3008844",
+	"nt":  "3008842",
+	"act": "3008838",
+}
+
+var (
+	gumtreeAdLinkPattern = regexp.MustCompile(`href="(/s-ad/[a-z0-9\-]+/land-for-sale/[a-z0-9\-]+/(\d{8,}))"`)
+	gumtreeLatLngPattern = regexp.MustCompile(`"lat(?:itude)?"\s*:\s*(-?\d+\.\d+)\s*,\s*"(?:lng|lon|longitude)"\s*:\s*(-?\d+\.\d+)`)
+	gumtreeWantedPattern = regexp.MustCompile(`(?i)\b(wanted|wtb|looking for|lease|for rent)\b`)
+)
+
+// ScrapeListingsWithExistsCheck scrapes land-for-sale ads with optional duplicate detection.
+// If existsChecker is provided and a page contains no new ads, scraping stops early.
+func (s *GumtreeScraper) ScrapeListingsWithExistsCheck(ctx context.Context, state string, maxPages int, existsChecker ExistsChecker) ([]models.Property, error) {
+	var allListings []models.Property
+
+	for page := 1; maxPages <= 0 || page <= maxPages; page++ {
+		select {
+		case <-ctx.Done():
+			return allListings, ctx.Err()
+		default:
+		}
+
+		log.Printf("Scraping Gumtree page %d for %s...", page, state)
+
+		links, hasMore, err := s.scrapePage(ctx, state, page)
+		if err != nil {
+			log.Printf("Error scraping page %d: %v", page, err)
+			break
+		}
+
+		// If we have an exists checker, check if any ads on this page are new
+		existsMap := make(map[string]bool)
+		if existsChecker != nil && len(links) > 0 {
+			externalIDs := make([]string, len(links))
+			for i, l := range links {
+				externalIDs[i] = l.id
+			}
+
+			existing, err := existsChecker(externalIDs)
+			if err != nil {
+				log.Printf("Warning: failed to check existing properties: %v", err)
+			} else {
+				existsMap = existing
+				newCount := 0
+				for _, l := range links {
+					if !existsMap[l.id] {
+						newCount++
+					}
+				}
+
+				log.Printf("Page %d: %d ads, %d new, %d already scraped", page, len(links), newCount, len(links)-newCount)
+
+				// If no new ads on this page, stop pagination
+				if newCount == 0 {
+					log.Printf("No new ads found on page %d, stopping pagination (all %d already scraped)", page, len(links))
+					break
+				}
+			}
+		}
+
+		var listings []models.Property
+		for _, l := range links {
+			if existsMap[l.id] {
+				continue
+			}
+
+			listing, err := s.FetchListingDetails(ctx, l.url, l.id)
+			if err != nil {
+				log.Printf("Error fetching Gumtree ad %s: %v", l.id, err)
+				continue
+			}
+
+			// Gumtree search URLs don't take land size or price filters, so apply the profile here
+			if listing != nil && s.profile.Matches(listing) {
+				listings = append(listings, *listing)
+			}
+
+			// Rate limiting between detail fetches
+			time.Sleep(1 * time.Second)
+		}
+
+		allListings = append(allListings, listings...)
+		log.Printf("Found %d listings on page %d (total: %d)", len(listings), page, len(allListings))
+
+		if !hasMore || len(links) == 0 {
+			break
+		}
+
+		// Rate limiting between pages
+		time.Sleep(2 * time.Second)
+	}
+
+	return allListings, nil
+}
+
+// scrapePage fetches one page of land-for-sale search results, newest first
+func (s *GumtreeScraper) scrapePage(ctx context.Context, state string, page int) ([]agencyLink, bool, error) {
+	location, ok := gumtreeLocations[strings.ToLower(state)]
+	if !ok {
+		return nil, false, fmt.Errorf("no Gumtree location for state %q", state)
+	}
+	searchURL := fmt.Sprintf("%s/s-land-for-sale/%s/page-%d/c20031l%s?sort=date",
+		s.baseURL, strings.ToLower(state), page, location)
+
+	body, err := s.fetch(ctx, searchURL)
+	if err != nil {
+		return nil, false, err
+	}
+	s.fixtures.Capture("gumtree", "search", searchURL, "html", body)
+
+	var links []agencyLink
+	seenIDs := make(map[string]bool)
+	for _, match := range gumtreeAdLinkPattern.FindAllStringSubmatch(body, -1) {
+		if seenIDs[match[2]] {
+			continue
+		}
+		seenIDs[match[2]] = true
+		links = append(links, agencyLink{id: match[2], url: s.baseURL + html.UnescapeString(match[1])})
+	}
+	s.diagnostics.Record("gumtree", "ad_links", len(links))
+
+	hasMore := strings.Contains(body, `rel="next"`) ||
+		strings.Contains(body, fmt.Sprintf("/page-%d/", page+1))
+
+	return links, hasMore, nil
+}
+
+// FetchListingDetails fetches a single ad. Returns nil (without an error) for
+// wanted-to-buy and lease ads, which Gumtree lists in the same category.
+func (s *GumtreeScraper) FetchListingDetails(ctx context.Context, adURL, adID string) (*models.Property, error) {
+	body, err := s.fetch(ctx, adURL)
+	if err != nil {
+		return nil, err
+	}
+	s.fixtures.Capture("gumtree", "detail", adURL, "html", body)
+
+	listing := parseStructuredListing(body, "gumtree", adURL, adID)
+	if listing.Address.Valid && gumtreeWantedPattern.MatchString(listing.Address.String) {
+		return nil, nil
+	}
+
+	// Private sellers often give the location only on the ad's map
+	if !listing.Latitude.Valid {
+		if m := gumtreeLatLngPattern.FindStringSubmatch(body); len(m) == 3 {
+			lat, errLat := strconv.ParseFloat(m[1], 64)
+			lng, errLng := strconv.ParseFloat(m[2], 64)
+			if errLat == nil && errLng == nil && lat != 0 && lng != 0 {
+				listing.Latitude = sql.NullFloat64{Float64: lat, Valid: true}
+				listing.Longitude = sql.NullFloat64{Float64: lng, Valid: true}
+			}
+		}
+	}
+
+	// Land size is usually only mentioned in the ad title or text
+	if !listing.LandSizeSqm.Valid && listing.Description.Valid {
+		if sqm := units.FindLandSize(listing.Description.String); sqm > 0 {
+			listing.LandSizeSqm = sql.NullFloat64{Float64: sqm, Valid: true}
+		}
+	}
+	// And so are garages and carports
+	if listing.Description.Valid {
+		listing.Carspaces = findCarspaces(listing.Description.String)
+	}
+
+	return listing, nil
+}
+
+func (s *GumtreeScraper) fetch(ctx context.Context, url string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return "", err
+	}
+
+	req.Header.Set("User-Agent", s.userAgent)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8")
+	req.Header.Set("Accept-Language", "en-AU,en;q=0.9")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("HTTP %d: %s", resp.StatusCode, resp.Status)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+
+	return string(body), nil
+}
@@ -0,0 +1,285 @@
+package scraper
+
+import (
+	"crypto/sha1"
+	"database/sql"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"farm-search/internal/models"
+	"farm-search/internal/units"
+)
+
+// ManualSource is the source recorded for listings imported from a file
+const ManualSource = "manual"
+
+// manualFieldAliases maps the column names (or JSON keys) accepted in import
+// files to the field they fill. Names are matched case-insensitively.
+var manualFieldAliases = map[string]string{
+	"id": "id", "external_id": "id",
+	"url": "url", "link": "url",
+	"title": "title", "name": "title",
+	"address": "address", "street": "address",
+	"suburb": "suburb", "town": "suburb", "locality": "suburb",
+	"state": "state", "postcode": "postcode",
+	"lat": "lat", "latitude": "lat",
+	"lng": "lng", "lon": "lng", "longitude": "lng",
+	"price": "price", "price_text": "price", "asking_price": "price",
+	"land_size": "land", "land": "land", "area": "land", "size": "land",
+	"land_size_ha": "land_ha", "hectares": "land_ha",
+	"land_size_acres": "land_acres", "acres": "land_acres",
+	"bedrooms": "bedrooms", "beds": "bedrooms",
+	"bathrooms": "bathrooms", "baths": "bathrooms",
+	"carspaces": "carspaces", "car_spaces": "carspaces", "cars": "carspaces", "parking": "carspaces",
+	"property_type": "type", "type": "type",
+	"description": "description", "notes": "description", "text": "description",
+	"images": "images", "photos": "images",
+	"listed_at": "listed", "listed": "listed", "date": "listed", "posted": "listed",
+}
+
+// LoadManualListings reads manually collected listings (e.g. copied out of
+// Facebook groups) from a .csv file with a header row or a .json file holding
+// an array of objects. Listings without an ID get one derived from their URL,
+// or from their address and price if there's no URL, so re-importing the same
+// file updates rather than duplicates them.
+func LoadManualListings(path, source string) ([]models.Property, error) {
+	var records []map[string]string
+	var err error
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		records, err = readManualCSV(path)
+	case ".json":
+		records, err = readManualJSON(path)
+	default:
+		return nil, fmt.Errorf("unsupported import file %q (expected .csv or .json)", path)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	var listings []models.Property
+	for i, fields := range records {
+		listing, err := manualListing(fields, source, now)
+		if err != nil {
+			log.Printf("Skipping record %d: %v", i+1, err)
+			continue
+		}
+		listings = append(listings, *listing)
+	}
+
+	return listings, nil
+}
+
+// readManualCSV reads a CSV file into one field map per row, keyed by the header row
+func readManualCSV(path string) ([]map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open import file: %w", err)
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = -1
+	rows, err := r.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse CSV: %w", err)
+	}
+	if len(rows) < 2 {
+		return nil, nil
+	}
+
+	header := rows[0]
+	var records []map[string]string
+	for _, row := range rows[1:] {
+		fields := make(map[string]string)
+		for i, value := range row {
+			if i < len(header) {
+				fields[header[i]] = value
+			}
+		}
+		records = append(records, fields)
+	}
+	return records, nil
+}
+
+// readManualJSON reads a JSON array of objects into one field map per object
+func readManualJSON(path string) ([]map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read import file: %w", err)
+	}
+
+	var objects []map[string]interface{}
+	if err := json.Unmarshal(data, &objects); err != nil {
+		return nil, fmt.Errorf("failed to parse JSON: %w", err)
+	}
+
+	var records []map[string]string
+	for _, obj := range objects {
+		fields := make(map[string]string)
+		for key, value := range obj {
+			switch v := value.(type) {
+			case nil:
+			case string:
+				fields[key] = v
+			case float64:
+				fields[key] = strconv.FormatFloat(v, 'f', -1, 64)
+			case []interface{}:
+				// Image lists are kept as JSON
+				b, _ := json.Marshal(v)
+				fields[key] = string(b)
+			default:
+				fields[key] = fmt.Sprint(v)
+			}
+		}
+		records = append(records, fields)
+	}
+	return records, nil
+}
+
+// manualListing maps one record's fields into a property
+func manualListing(raw map[string]string, source string, now time.Time) (*models.Property, error) {
+	fields := make(map[string]string)
+	for key, value := range raw {
+		if field, ok := manualFieldAliases[strings.ToLower(strings.TrimSpace(key))]; ok {
+			if value = strings.TrimSpace(value); value != "" {
+				fields[field] = value
+			}
+		}
+	}
+
+	listing := &models.Property{
+		Source:    source,
+		URL:       fields["url"],
+		State:     "NSW",
+		ScrapedAt: now,
+		UpdatedAt: now,
+	}
+
+	if v := fields["address"]; v != "" {
+		listing.Address = sql.NullString{String: v, Valid: true}
+	} else if v := fields["title"]; v != "" {
+		listing.Address = sql.NullString{String: v, Valid: true}
+	}
+	if v := fields["suburb"]; v != "" {
+		listing.Suburb = sql.NullString{String: v, Valid: true}
+	}
+	if v := fields["postcode"]; v != "" {
+		listing.Postcode = sql.NullString{String: v, Valid: true}
+		listing.State = stateFromPostcode(v)
+	}
+	if v := fields["state"]; v != "" {
+		listing.State = strings.ToUpper(v)
+	}
+
+	if fields["lat"] != "" || fields["lng"] != "" {
+		lat, errLat := strconv.ParseFloat(fields["lat"], 64)
+		lng, errLng := strconv.ParseFloat(fields["lng"], 64)
+		if errLat != nil || errLng != nil {
+			return nil, fmt.Errorf("invalid coordinates %q, %q", fields["lat"], fields["lng"])
+		}
+		listing.Latitude = sql.NullFloat64{Float64: lat, Valid: true}
+		listing.Longitude = sql.NullFloat64{Float64: lng, Valid: true}
+	}
+
+	if !listing.Address.Valid && !listing.Suburb.Valid && !listing.Latitude.Valid {
+		return nil, fmt.Errorf("no address, suburb or coordinates")
+	}
+
+	if v := fields["price"]; v != "" {
+		listing.PriceText = sql.NullString{String: v, Valid: true}
+		if min, max := extractPriceRange(v); min > 0 {
+			listing.PriceMin = sql.NullInt64{Int64: min, Valid: true}
+			listing.PriceMax = sql.NullInt64{Int64: max, Valid: true}
+		}
+	}
+
+	var sqm float64
+	if v := fields["land_ha"]; v != "" {
+		if ha, ok := units.ParseNumber(v); ok {
+			sqm = ha * units.SqmPerHectare
+		}
+	} else if v := fields["land_acres"]; v != "" {
+		if acres, ok := units.ParseNumber(v); ok {
+			sqm = acres * units.SqmPerAcre
+		}
+	} else if v := fields["land"]; v != "" {
+		sqm = units.ParseLandSize(v)
+	}
+	if sqm == 0 && fields["description"] != "" {
+		// Facebook posts usually only give the size in the text
+		sqm = units.FindLandSize(fields["description"])
+	}
+	if sqm > 0 {
+		listing.LandSizeSqm = sql.NullFloat64{Float64: sqm, Valid: true}
+	}
+
+	if v, err := strconv.ParseInt(fields["bedrooms"], 10, 64); err == nil {
+		listing.Bedrooms = sql.NullInt64{Int64: v, Valid: true}
+	}
+	if v, err := strconv.ParseInt(fields["bathrooms"], 10, 64); err == nil {
+		listing.Bathrooms = sql.NullInt64{Int64: v, Valid: true}
+	}
+	if v, err := strconv.ParseInt(fields["carspaces"], 10, 64); err == nil {
+		listing.Carspaces = sql.NullInt64{Int64: v, Valid: true}
+	} else if fields["description"] != "" {
+		listing.Carspaces = findCarspaces(fields["description"])
+	}
+
+	propertyType := "rural"
+	if v := fields["type"]; v != "" {
+		propertyType = strings.ToLower(v)
+	}
+	listing.PropertyType = sql.NullString{String: propertyType, Valid: true}
+
+	if v := fields["description"]; v != "" {
+		listing.Description = sql.NullString{String: v, Valid: true}
+	}
+
+	if v := fields["images"]; v != "" {
+		var images []string
+		if err := json.Unmarshal([]byte(v), &images); err != nil {
+			// Plain lists are separated by commas, pipes or whitespace
+			images = strings.FieldsFunc(v, func(r rune) bool {
+				return r == ',' || r == '|' || r == ' ' || r == '\n'
+			})
+		}
+		if len(images) > 0 {
+			imgJSON, _ := json.Marshal(images)
+			listing.Images = sql.NullString{String: string(imgJSON), Valid: true}
+		}
+	}
+
+	if v := fields["listed"]; v != "" {
+		for _, layout := range []string{time.RFC3339, "2006-01-02", "02/01/2006", "2/1/2006"} {
+			if t, err := time.Parse(layout, v); err == nil {
+				listing.ListedAt = sql.NullTime{Time: t, Valid: true}
+				break
+			}
+		}
+	}
+
+	listing.ExternalID = fields["id"]
+	if listing.ExternalID == "" {
+		key := listing.URL
+		if key == "" {
+			key = strings.ToLower(strings.Join([]string{
+				listing.Address.String, listing.Suburb.String, listing.PriceText.String,
+			}, "|"))
+		}
+		sum := sha1.Sum([]byte(key))
+		listing.ExternalID = hex.EncodeToString(sum[:])[:16]
+	}
+
+	return listing, nil
+}
@@ -0,0 +1,19 @@
+package scraper
+
+import "strings"
+
+// mediaKind maps a source's media category or type ("Image", "photo",
+// "Video", "FloorPlan") to where it's kept: "image" for Property.Images,
+// "video" or "floorplan" for Property.Media, or "" for anything else (virtual
+// tours, documents). Media with no category are images.
+func mediaKind(category string) string {
+	switch strings.NewReplacer(" ", "", "_", "", "-", "").Replace(strings.ToLower(category)) {
+	case "", "image", "photo":
+		return "image"
+	case "video", "youtube", "vimeo":
+		return "video"
+	case "floorplan", "floorplans":
+		return "floorplan"
+	}
+	return ""
+}
@@ -0,0 +1,95 @@
+package scraper
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+
+	"farm-search/internal/models"
+)
+
+// Where Config.Output sends a run's listings
+const (
+	OutputDB     = "db"     // Save to the database
+	OutputNDJSON = "ndjson" // Write to Config.NDJSON only
+	OutputBoth   = "both"   // Write to Config.NDJSON and save to the database
+)
+
+// maxNDJSONLine is the longest record ReadNDJSON accepts; descriptions and
+// image lists make a listing a few KB, so this only stops runaway input
+const maxNDJSONLine = 16 << 20
+
+// NDJSONRecord is one listing as written with -output ndjson: the parsed
+// listing as the scraper would have saved it (descriptions aren't sanitized
+// until it is), with its events, videos and floorplans and whether it was
+// scraped for sale or rent
+type NDJSONRecord struct {
+	models.Property
+	ListingType string                 `json:"listing_type"` // ListingTypeBuy or ListingTypeRent
+	Events      []models.PropertyEvent `json:"events,omitempty"`
+	Media       []models.PropertyMedia `json:"media,omitempty"`
+}
+
+// WriteNDJSON writes listings to w, one NDJSONRecord per line
+func WriteNDJSON(w io.Writer, listings []models.Property, listingType string) error {
+	enc := json.NewEncoder(w)
+	for _, l := range listings {
+		if err := enc.Encode(NDJSONRecord{Property: l, ListingType: listingType, Events: l.Events, Media: l.Media}); err != nil {
+			return fmt.Errorf("failed to write %s listing %s: %w", l.Source, l.ExternalID, err)
+		}
+	}
+	return nil
+}
+
+// ReadNDJSON reads the records WriteNDJSON wrote, skipping blank lines, with
+// each record's events and media put back on its listing. A record without a listing
+// type is for sale.
+func ReadNDJSON(r io.Reader) ([]NDJSONRecord, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxNDJSONLine)
+
+	var records []NDJSONRecord
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		var record NDJSONRecord
+		if err := json.Unmarshal([]byte(text), &record); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		if record.ExternalID == "" || record.Source == "" {
+			return nil, fmt.Errorf("line %d: listing has no external_id or source", line)
+		}
+		switch record.ListingType {
+		case "":
+			record.ListingType = ListingTypeBuy
+		case ListingTypeBuy, ListingTypeRent:
+		default:
+			return nil, fmt.Errorf("line %d: unknown listing type %q", line, record.ListingType)
+		}
+		record.Property.Events = record.Events
+		record.Property.Media = record.Media
+		records = append(records, record)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// writeNDJSON writes a run's listings to Config.NDJSON if it's set,
+// reporting whether they're to be saved to the database too
+func (s *Scraper) writeNDJSON(listings []models.Property) (saveToDB bool, err error) {
+	if s.config.NDJSON == nil {
+		return true, nil
+	}
+	if err := WriteNDJSON(s.config.NDJSON, listings, s.config.Profile.ListingType); err != nil {
+		return false, err
+	}
+	log.Printf("Wrote %d listings as NDJSON", len(listings))
+	return s.config.Output != OutputNDJSON, nil
+}
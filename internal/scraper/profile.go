@@ -0,0 +1,221 @@
+package scraper
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"farm-search/internal/models"
+)
+
+// SearchProfile describes the kind of property a scrape run is looking for.
+// It is applied to every source's search URL or API request so that land size,
+// price and property type limits live in one place instead of in each scraper.
+// Where to search is configured separately per source (see RegionTargets).
+type SearchProfile struct {
+	Name          string
+	ListingType   string   // "buy" (default) or "rent"; price limits only apply to "buy"
+	MinLandSqm    float64  // Minimum land size in square meters (0 = no minimum)
+	MaxLandSqm    float64  // Maximum land size in square meters (0 = no maximum)
+	MinPrice      int64    // Minimum price in dollars (0 = no minimum)
+	MaxPrice      int64    // Maximum price in dollars (0 = no maximum)
+	PropertyTypes []string // Property types: "house", "land", "acreage-semi-rural", "rural", "farm"
+}
+
+// DefaultSearchProfile returns the farm profile: 10+ hectares under $2M
+func DefaultSearchProfile() SearchProfile {
+	return SearchProfile{
+		Name:       "farm",
+		MinLandSqm: 100000, // 10 hectares
+		MaxPrice:   2000000,
+		PropertyTypes: []string{
+			"house",
+			"land",
+			"acreage-semi-rural",
+			"rural",
+			"farm",
+		},
+		ListingType: ListingTypeBuy,
+	}
+}
+
+// LifestyleSearchProfile returns a profile for smaller lifestyle blocks (1-4 hectares)
+func LifestyleSearchProfile() SearchProfile {
+	p := DefaultSearchProfile()
+	p.Name = "lifestyle"
+	p.MinLandSqm = 10000 // 1 hectare
+	p.MaxLandSqm = 40000 // 4 hectares
+	return p
+}
+
+// SearchProfileByName returns a built-in search profile by name
+func SearchProfileByName(name string) (SearchProfile, error) {
+	switch strings.ToLower(name) {
+	case "", "farm":
+		return DefaultSearchProfile(), nil
+	case "lifestyle":
+		return LifestyleSearchProfile(), nil
+	default:
+		return SearchProfile{}, fmt.Errorf("unknown search profile %q (expected farm or lifestyle)", name)
+	}
+}
+
+// Renting reports whether the profile searches rental listings instead of sales
+func (p SearchProfile) Renting() bool {
+	return p.ListingType == ListingTypeRent
+}
+
+// Matches reports whether a scraped listing falls inside the profile's land size
+// and price limits. Listings with an unknown land size or price are kept, since
+// many sources only expose those on the detail page.
+func (p SearchProfile) Matches(listing *models.Property) bool {
+	if listing.LandSizeSqm.Valid && listing.LandSizeSqm.Float64 > 0 {
+		if p.MinLandSqm > 0 && listing.LandSizeSqm.Float64 < p.MinLandSqm {
+			return false
+		}
+		if p.MaxLandSqm > 0 && listing.LandSizeSqm.Float64 > p.MaxLandSqm {
+			return false
+		}
+	}
+	if p.Renting() {
+		return true
+	}
+	if p.MaxPrice > 0 && listing.PriceMin.Valid && listing.PriceMin.Int64 > p.MaxPrice {
+		return false
+	}
+	if p.MinPrice > 0 && listing.PriceMax.Valid && listing.PriceMax.Int64 < p.MinPrice {
+		return false
+	}
+	return true
+}
+
+// reaTypeSlugs maps our property types to REA URL slugs
+var reaTypeSlugs = map[string]string{
+	"house":              "house",
+	"land":               "land",
+	"acreage-semi-rural": "acreage",
+	"rural":              "rural",
+	"farm":               "rural",
+}
+
+// domainAPITypes maps our property types to Domain API property types
+var domainAPITypes = map[string]string{
+	"house":              "House",
+	"land":               "VacantLand",
+	"acreage-semi-rural": "AcreageSemiRural",
+	"rural":              "Rural",
+	"farm":               "Farm",
+}
+
+// domainWebTypes maps our property types to Domain website ptype values
+var domainWebTypes = map[string]string{
+	"house":              "house",
+	"land":               "vacant-land",
+	"acreage-semi-rural": "acreage-semi-rural",
+	"rural":              "rural",
+	"farm":               "farm",
+}
+
+// mapPropertyTypes converts profile property types using a source-specific mapping,
+// dropping unknown types and duplicates while preserving order
+func mapPropertyTypes(types []string, mapping map[string]string) []string {
+	seen := make(map[string]bool)
+	var result []string
+	for _, t := range types {
+		mapped, ok := mapping[strings.ToLower(t)]
+		if !ok || seen[mapped] {
+			continue
+		}
+		seen[mapped] = true
+		result = append(result, mapped)
+	}
+	return result
+}
+
+// REASearchURL builds the REA map view search URL covering the given regions for the given page
+func (p SearchProfile) REASearchURL(regions []string, page int) string {
+	types := mapPropertyTypes(p.PropertyTypes, reaTypeSlugs)
+	if len(types) == 0 {
+		types = []string{"rural"}
+	}
+
+	// REA URL segments: property-<types>-size-<min>[-<max>]-between-<min>-<max>-in-<regions>
+	path := "property-" + strings.Join(types, "-")
+	if p.MinLandSqm > 0 || p.MaxLandSqm > 0 {
+		path += fmt.Sprintf("-size-%d", int64(p.MinLandSqm))
+		if p.MaxLandSqm > 0 {
+			path += fmt.Sprintf("-%d", int64(p.MaxLandSqm))
+		}
+	}
+	channel := "buy"
+	if p.Renting() {
+		// Rental prices are weekly, so the profile's purchase price limits don't apply
+		channel = "rent"
+	} else {
+		maxPrice := "any"
+		if p.MaxPrice > 0 {
+			maxPrice = fmt.Sprintf("%d", p.MaxPrice)
+		}
+		path += fmt.Sprintf("-between-%d-%s", p.MinPrice, maxPrice)
+	}
+
+	locations := make([]string, len(regions))
+	for i, r := range regions {
+		locations[i] = strings.ReplaceAll(r, " ", "+")
+	}
+	path += "-in-" + strings.Join(locations, ";+")
+
+	return fmt.Sprintf("https://www.realestate.com.au/%s/%s/map-%d?includeSurrounding=false&activeSort=list-date", channel, path, page)
+}
+
+// applyToDomainRequest sets the listing type, land size, price and property type filters on a Domain API search request
+func (p SearchProfile) applyToDomainRequest(req *DomainSearchRequest) {
+	req.ListingType = "Sale"
+	if p.Renting() {
+		req.ListingType = "Rent"
+	}
+	req.PropertyTypes = mapPropertyTypes(p.PropertyTypes, domainAPITypes)
+	req.MinLandArea = nil
+	req.MaxLandArea = nil
+	req.MinPrice = nil
+	req.MaxPrice = nil
+	if p.MinLandSqm > 0 {
+		req.MinLandArea = intPtr(int(p.MinLandSqm))
+	}
+	if p.MaxLandSqm > 0 {
+		req.MaxLandArea = intPtr(int(p.MaxLandSqm))
+	}
+	if p.Renting() {
+		return
+	}
+	if p.MinPrice > 0 {
+		req.MinPrice = intPtr(int(p.MinPrice))
+	}
+	if p.MaxPrice > 0 {
+		req.MaxPrice = intPtr(int(p.MaxPrice))
+	}
+}
+
+// domainWebQuery returns the Domain website query parameters for the profile
+func (p SearchProfile) domainWebQuery() url.Values {
+	q := url.Values{}
+	if types := mapPropertyTypes(p.PropertyTypes, domainWebTypes); len(types) > 0 {
+		q.Set("ptype", strings.Join(types, ","))
+	}
+	if !p.Renting() {
+		maxPrice := "any"
+		if p.MaxPrice > 0 {
+			maxPrice = fmt.Sprintf("%d", p.MaxPrice)
+		}
+		q.Set("price", fmt.Sprintf("%d-%s", p.MinPrice, maxPrice))
+	}
+	if p.MinLandSqm > 0 || p.MaxLandSqm > 0 {
+		maxLand := "any"
+		if p.MaxLandSqm > 0 {
+			maxLand = fmt.Sprintf("%d", int64(p.MaxLandSqm))
+		}
+		q.Set("landsize", fmt.Sprintf("%d-%s", int64(p.MinLandSqm), maxLand))
+		q.Set("landsizeunit", "ha")
+	}
+	return q
+}
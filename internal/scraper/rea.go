@@ -15,6 +15,7 @@ import (
 	"time"
 
 	"farm-search/internal/models"
+	"farm-search/internal/units"
 )
 
 // ProxyProvider indicates which proxy service to use
@@ -24,6 +25,11 @@ type REAScraper struct {
 	userAgent   string
 	scrapingBee *ScrapingBeeClient
 	useProxy    bool
+	flareSolver *FlareSolverrClient // Used when ScrapingBee isn't configured
+	profile     SearchProfile
+	regions     []string // REA region names to search, e.g. "hunter region, nsw"
+	diagnostics *ParseDiagnostics
+	fixtures    *FixtureRecorder
 }
 
 // NewREAScraper creates a new REA scraper
@@ -33,6 +39,8 @@ func NewREAScraper() *REAScraper {
 			Timeout: 30 * time.Second,
 		},
 		userAgent: "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36",
+		profile:   DefaultSearchProfile(),
+		regions:   DefaultRegionTargets().REA,
 	}
 }
 
@@ -45,9 +53,39 @@ func NewREAScraperWithScrapingBee(apiKey string) *REAScraper {
 		userAgent:   "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36",
 		scrapingBee: NewScrapingBeeClient(apiKey),
 		useProxy:    true,
+		profile:     DefaultSearchProfile(),
+		regions:     DefaultRegionTargets().REA,
 	}
 }
 
+// NewREAScraperWithFlareSolverr creates a new REA scraper that fetches pages through
+// a FlareSolverr-compatible service at baseURL (e.g. "http://localhost:8191")
+func NewREAScraperWithFlareSolverr(baseURL string) *REAScraper {
+	s := NewREAScraper()
+	s.flareSolver = NewFlareSolverrClient(baseURL)
+	return s
+}
+
+// SetSearchProfile sets the land size, price and property type filters used in search URLs
+func (s *REAScraper) SetSearchProfile(profile SearchProfile) {
+	s.profile = profile
+}
+
+// SetRegions sets the REA region names to search (e.g. "hunter region, nsw")
+func (s *REAScraper) SetRegions(regions []string) {
+	s.regions = regions
+}
+
+// SetDiagnostics sets the collector that records which extraction path parsed each page
+func (s *REAScraper) SetDiagnostics(d *ParseDiagnostics) {
+	s.diagnostics = d
+}
+
+// SetFixtureRecorder sets the recorder that saves fetched pages as parser fixtures
+func (s *REAScraper) SetFixtureRecorder(r *FixtureRecorder) {
+	s.fixtures = r
+}
+
 // ExistsChecker is a function that checks if properties already exist in the database
 // It takes a slice of external IDs and returns a map of ID -> exists
 type ExistsChecker func(externalIDs []string) (map[string]bool, error)
@@ -121,24 +159,29 @@ func (s *REAScraper) ScrapeListingsWithExistsCheck(ctx context.Context, region,
 
 func (s *REAScraper) scrapePage(ctx context.Context, region, propertyType string, page int) ([]models.Property, bool, error) {
 	// Build the search URL - use map view for ~200 results per page with coordinates
-	// Targeting specific NSW regions within reasonable distance of Sydney
-	// Price range: $0 - $2,000,000, Size: 10+ hectares (100,000 sqm)
-	regions := "central+tablelands,+nsw;+southern+tablelands,+nsw;+hunter+region,+nsw;+southern+highlands+-+greater+region,+nsw;+illawarra+region,+nsw;+central+coast,+nsw;+blue+mountains+-+region,+nsw;+wollongong+-+greater+region,+nsw;+south+coast,+nsw"
-	searchURL := fmt.Sprintf(
-		"https://www.realestate.com.au/buy/property-house-land-acreage-rural-size-100000-between-0-2000000-in-%s/map-%d?includeSurrounding=false&activeSort=list-date",
-		regions, page,
-	)
+	// Searches the configured regions within the state in one combined URL; price range
+	// and land size come from the search profile
+	regions := reaRegionsInState(s.regions, region)
+	if len(regions) == 0 {
+		regions = []string{region}
+	}
+	searchURL := s.profile.REASearchURL(regions, page)
 
 	var body string
 	var err error
 
-	// Use ScrapingBee if configured, otherwise fall back to direct HTTP
+	// Use ScrapingBee or FlareSolverr if configured, otherwise fall back to direct HTTP
 	if s.useProxy && s.scrapingBee != nil {
 		opts := DefaultREAOptions()
 		body, err = s.scrapingBee.FetchHTML(ctx, searchURL, opts)
 		if err != nil {
 			return nil, false, fmt.Errorf("ScrapingBee fetch failed: %w", err)
 		}
+	} else if s.flareSolver != nil {
+		body, err = s.flareSolver.FetchHTML(ctx, searchURL)
+		if err != nil {
+			return nil, false, fmt.Errorf("FlareSolverr fetch failed: %w", err)
+		}
 	} else {
 		// Direct HTTP request (will likely fail due to Kasada)
 		req, err := http.NewRequestWithContext(ctx, "GET", searchURL, nil)
@@ -172,6 +215,8 @@ func (s *REAScraper) scrapePage(ctx context.Context, region, propertyType string
 		return nil, false, fmt.Errorf("blocked by Kasada bot protection")
 	}
 
+	s.fixtures.Capture("rea", "search", searchURL, "html", body)
+
 	// Log page size for debugging
 	log.Printf("Received %d bytes from REA for %s page %d", len(body), region, page)
 
@@ -218,6 +263,7 @@ func (s *REAScraper) parseListingsPage(html, propertyType string) ([]models.Prop
 			listings, hasMore := s.extractFromMapView(data, propertyType)
 			if len(listings) > 0 {
 				log.Printf("Extracted %d listings from map view (with coordinates)", len(listings))
+				s.diagnostics.Record("rea", "argonaut_map", len(listings))
 				return listings, hasMore
 			}
 
@@ -225,6 +271,7 @@ func (s *REAScraper) parseListingsPage(html, propertyType string) ([]models.Prop
 			listings = s.extractFromUrqlCache(data, propertyType)
 			if len(listings) > 0 {
 				hasMore := strings.Contains(html, `rel="next"`)
+				s.diagnostics.Record("rea", "argonaut_urql", len(listings))
 				return listings, hasMore
 			}
 
@@ -232,6 +279,7 @@ func (s *REAScraper) parseListingsPage(html, propertyType string) ([]models.Prop
 			listings = s.extractListingsFromJSON(data, propertyType)
 			if len(listings) > 0 {
 				hasMore := strings.Contains(html, `rel="next"`)
+				s.diagnostics.Record("rea", "argonaut_rpi", len(listings))
 				return listings, hasMore
 			}
 		}
@@ -239,6 +287,7 @@ func (s *REAScraper) parseListingsPage(html, propertyType string) ([]models.Prop
 
 	// If we can't find embedded JSON, try parsing listing cards from HTML
 	listings = s.parseListingCards(html, propertyType)
+	s.diagnostics.Record("rea", "html_cards", len(listings))
 
 	// Check if there are more pages
 	hasMore := strings.Contains(html, `rel="next"`) || strings.Contains(html, "Next page")
@@ -411,8 +460,11 @@ func (s *REAScraper) extractFromMapView(data map[string]interface{}, propertyTyp
 		return listings, false
 	}
 
-	// Navigate to buyMapSearch -> results -> items
+	// Navigate to buyMapSearch (rentMapSearch for rentals) -> results -> items
 	buyMapSearch, ok := innerData["buyMapSearch"].(map[string]interface{})
+	if !ok {
+		buyMapSearch, ok = innerData["rentMapSearch"].(map[string]interface{})
+	}
 	if !ok {
 		return listings, false
 	}
@@ -553,6 +605,11 @@ func (s *REAScraper) parseMapViewListing(m map[string]interface{}, lat, lng floa
 				listing.Bathrooms = sql.NullInt64{Int64: int64(val), Valid: true}
 			}
 		}
+		if parking, ok := features["parkingSpaces"].(map[string]interface{}); ok {
+			if val, ok := parking["value"].(float64); ok {
+				listing.Carspaces = sql.NullInt64{Int64: int64(val), Valid: true}
+			}
+		}
 	}
 
 	// Extract main image
@@ -616,8 +673,11 @@ func (s *REAScraper) extractFromUrqlCache(data map[string]interface{}, propertyT
 			continue
 		}
 
-		// Look for buySearch results
+		// Look for buySearch (rentSearch for rentals) results
 		buySearch, ok := innerData["buySearch"].(map[string]interface{})
+		if !ok {
+			buySearch, ok = innerData["rentSearch"].(map[string]interface{})
+		}
 		if !ok {
 			continue
 		}
@@ -719,8 +779,7 @@ func (s *REAScraper) parseUrqlListing(m map[string]interface{}, propertyType str
 
 	// Extract description
 	if desc, ok := m["description"].(string); ok {
-		// Clean HTML tags from description
-		desc = regexp.MustCompile(`<[^>]*>`).ReplaceAllString(desc, " ")
+		// HTML is cleaned by sanitize.Description as it's saved
 		desc = strings.TrimSpace(desc)
 		if len(desc) > 2000 {
 			desc = desc[:2000]
@@ -740,6 +799,11 @@ func (s *REAScraper) parseUrqlListing(m map[string]interface{}, propertyType str
 				listing.Bathrooms = sql.NullInt64{Int64: int64(val), Valid: true}
 			}
 		}
+		if parking, ok := features["parkingSpaces"].(map[string]interface{}); ok {
+			if val, ok := parking["value"].(float64); ok {
+				listing.Carspaces = sql.NullInt64{Int64: int64(val), Valid: true}
+			}
+		}
 	}
 
 	// Extract land size
@@ -757,7 +821,9 @@ func (s *REAScraper) parseUrqlListing(m map[string]interface{}, propertyType str
 			}
 			if displayValue != "" {
 				sizeStr := displayValue + " " + unit
-				listing.LandSizeSqm = sql.NullFloat64{Float64: parseLandSize(sizeStr), Valid: true}
+				if sqm := units.ParseLandSize(sizeStr); sqm > 0 {
+					listing.LandSizeSqm = sql.NullFloat64{Float64: sqm, Valid: true}
+				}
 			}
 		}
 	}
@@ -780,11 +846,38 @@ func (s *REAScraper) parseUrqlListing(m map[string]interface{}, propertyType str
 			imgJSON, _ := json.Marshal(images)
 			listing.Images = sql.NullString{String: string(imgJSON), Valid: true}
 		}
+		listing.Media = reaMedia(media)
 	}
 
 	return listing
 }
 
+// reaMedia extracts the floorplans (sized like the images) and videos from a
+// listing's media object
+func reaMedia(media map[string]interface{}) []models.PropertyMedia {
+	found := []models.PropertyMedia{}
+	if floorplans, ok := media["floorplans"].([]interface{}); ok {
+		for _, fp := range floorplans {
+			if fpMap, ok := fp.(map[string]interface{}); ok {
+				if templatedURL, ok := fpMap["templatedUrl"].(string); ok && templatedURL != "" {
+					url := strings.ReplaceAll(templatedURL, "{size}", "800x600")
+					found = append(found, models.PropertyMedia{Kind: "floorplan", URL: url})
+				}
+			}
+		}
+	}
+	if videos, ok := media["videos"].([]interface{}); ok {
+		for _, v := range videos {
+			if vMap, ok := v.(map[string]interface{}); ok {
+				if url, ok := vMap["url"].(string); ok && url != "" {
+					found = append(found, models.PropertyMedia{Kind: "video", URL: url})
+				}
+			}
+		}
+	}
+	return found
+}
+
 // extractListingsFromJSON extracts listings from the parsed JSON data (legacy format)
 func (s *REAScraper) extractListingsFromJSON(data map[string]interface{}, propertyType string) []models.Property {
 	var listings []models.Property
@@ -895,6 +988,11 @@ func (s *REAScraper) parseJSONListing(data interface{}, propertyType string) *mo
 				listing.Bathrooms = sql.NullInt64{Int64: int64(val), Valid: true}
 			}
 		}
+		if parking, ok := features["parkingSpaces"].(map[string]interface{}); ok {
+			if val, ok := parking["value"].(float64); ok {
+				listing.Carspaces = sql.NullInt64{Int64: int64(val), Valid: true}
+			}
+		}
 	}
 
 	// Extract land size
@@ -902,7 +1000,9 @@ func (s *REAScraper) parseJSONListing(data interface{}, propertyType string) *mo
 		if land, ok := propertySizes["land"].(map[string]interface{}); ok {
 			if size, ok := land["displayValue"].(string); ok {
 				// Parse the size string (e.g., "100 ha", "5000 m²")
-				listing.LandSizeSqm = sql.NullFloat64{Float64: parseLandSize(size), Valid: true}
+				if sqm := units.ParseLandSize(size); sqm > 0 {
+					listing.LandSizeSqm = sql.NullFloat64{Float64: sqm, Valid: true}
+				}
 			}
 		}
 	}
@@ -928,39 +1028,8 @@ func (s *REAScraper) parseJSONListing(data interface{}, propertyType string) *mo
 	return listing
 }
 
-// parseLandSize converts land size strings to square meters
-func parseLandSize(sizeStr string) float64 {
-	sizeStr = strings.ToLower(strings.TrimSpace(sizeStr))
-	sizeStr = strings.ReplaceAll(sizeStr, ",", "")
-
-	// Extract numeric value
-	numPattern := regexp.MustCompile(`([\d.]+)`)
-	matches := numPattern.FindStringSubmatch(sizeStr)
-	if len(matches) < 2 {
-		return 0
-	}
-
-	value, err := strconv.ParseFloat(matches[1], 64)
-	if err != nil {
-		return 0
-	}
-
-	// Convert to square meters based on unit
-	switch {
-	case strings.Contains(sizeStr, "ha") || strings.Contains(sizeStr, "hectare"):
-		return value * 10000
-	case strings.Contains(sizeStr, "acre"):
-		return value * 4046.86
-	case strings.Contains(sizeStr, "m²") || strings.Contains(sizeStr, "sqm") || strings.Contains(sizeStr, "m2"):
-		return value
-	default:
-		// Assume square meters if no unit
-		return value
-	}
-}
-
 // FetchListingDetails fetches full details for a single listing
-// Uses ScrapingBee if configured, otherwise falls back to direct HTTP (will likely be blocked)
+// Uses ScrapingBee or FlareSolverr if configured, otherwise falls back to direct HTTP (will likely be blocked)
 func (s *REAScraper) FetchListingDetails(ctx context.Context, listingURL string) (*models.Property, error) {
 	var body string
 	var err error
@@ -971,6 +1040,11 @@ func (s *REAScraper) FetchListingDetails(ctx context.Context, listingURL string)
 		if err != nil {
 			return nil, fmt.Errorf("ScrapingBee fetch failed: %w", err)
 		}
+	} else if s.flareSolver != nil {
+		body, err = s.flareSolver.FetchHTML(ctx, listingURL)
+		if err != nil {
+			return nil, fmt.Errorf("FlareSolverr fetch failed: %w", err)
+		}
 	} else {
 		// Direct HTTP request (will likely fail due to Kasada)
 		req, err := http.NewRequestWithContext(ctx, "GET", listingURL, nil)
@@ -1003,6 +1077,7 @@ func (s *REAScraper) FetchListingDetails(ctx context.Context, listingURL string)
 		return nil, fmt.Errorf("blocked by Kasada bot protection")
 	}
 
+	s.fixtures.Capture("rea", "detail", listingURL, "html", body)
 	return s.parseListingDetails(body, listingURL)
 }
 
@@ -1110,23 +1185,8 @@ func (s *REAScraper) parseListingDetails(html, listingURL string) (*models.Prope
 
 	// Extract land size from page if not already set
 	if !listing.LandSizeSqm.Valid {
-		landPatterns := []*regexp.Regexp{
-			regexp.MustCompile(`([\d,]+(?:\.\d+)?)\s*(hectares?|ha)\b`),
-			regexp.MustCompile(`([\d,]+(?:\.\d+)?)\s*(acres?)\b`),
-			regexp.MustCompile(`Land size[:\s]*([\d,]+(?:\.\d+)?)\s*(m²|sqm|hectares?|ha|acres?)`),
-		}
-		for _, pattern := range landPatterns {
-			if sizeMatches := pattern.FindStringSubmatch(strings.ToLower(html)); len(sizeMatches) >= 2 {
-				sizeStr := sizeMatches[1]
-				unit := ""
-				if len(sizeMatches) >= 3 {
-					unit = sizeMatches[2]
-				}
-				if sqm := parseLandSize(sizeStr + " " + unit); sqm > 0 {
-					listing.LandSizeSqm = sql.NullFloat64{Float64: sqm, Valid: true}
-					break
-				}
-			}
+		if sqm := units.FindLandSize(html); sqm > 0 {
+			listing.LandSizeSqm = sql.NullFloat64{Float64: sqm, Valid: true}
 		}
 	}
 
@@ -1147,6 +1207,10 @@ func (s *REAScraper) parseListingDetails(html, listingURL string) (*models.Prope
 			}
 		}
 	}
+	// Car spaces from the description if not already set
+	if !listing.Carspaces.Valid && listing.Description.Valid {
+		listing.Carspaces = findCarspaces(listing.Description.String)
+	}
 
 	// Extract images from page if not already set
 	if !listing.Images.Valid {
@@ -1217,7 +1281,7 @@ func (s *REAScraper) searchArgonautForDetails(data interface{}, listing *models.
 					}
 					if displayValue != "" && !listing.LandSizeSqm.Valid {
 						sizeStr := displayValue + " " + unit
-						if sqm := parseLandSize(sizeStr); sqm > 0 {
+						if sqm := units.ParseLandSize(sizeStr); sqm > 0 {
 							listing.LandSizeSqm = sql.NullFloat64{Float64: sqm, Valid: true}
 						}
 					}
@@ -1235,6 +1299,11 @@ func (s *REAScraper) searchArgonautForDetails(data interface{}, listing *models.
 						listing.Bathrooms = sql.NullInt64{Int64: int64(val), Valid: true}
 					}
 				}
+				if parking, ok := features["parkingSpaces"].(map[string]interface{}); ok {
+					if val, ok := parking["value"].(float64); ok && !listing.Carspaces.Valid {
+						listing.Carspaces = sql.NullInt64{Int64: int64(val), Valid: true}
+					}
+				}
 			}
 			// Extract price
 			if price, ok := v["price"].(map[string]interface{}); ok {
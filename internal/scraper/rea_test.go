@@ -0,0 +1,207 @@
+package scraper
+
+import (
+	"testing"
+
+	"farm-search/internal/models"
+)
+
+func TestREAParseListingsPage(t *testing.T) {
+	tests := []struct {
+		fixture     string
+		wantHasMore bool
+		want        []listingFields
+	}{
+		{
+			// Map view: ~200 listings a page with their coordinates
+			fixture:     "search-map.html",
+			wantHasMore: true,
+			want: []listingFields{
+				{
+					ExternalID:   "143000001",
+					Source:       "rea",
+					URL:          "https://www.realestate.com.au/property-rural-nsw-wattle+flat-143000001",
+					Address:      "1204 Sofala Road",
+					Suburb:       "Wattle Flat",
+					State:        "NSW",
+					Postcode:     "2795",
+					PriceText:    "$1,150,000 - $1,250,000",
+					PropertyType: "Rural",
+					Bedrooms:     4,
+					Bathrooms:    2,
+					Carspaces:    3,
+					Latitude:     -33.1342,
+					Longitude:    149.6931,
+					Images:       []string{"https://i2.au.reastatic.net/800x600/3a1f0c2b9e/image.jpg"},
+				},
+				{
+					// No features or pin: left unset, not zero
+					ExternalID:   "143000002",
+					Source:       "rea",
+					URL:          "https://www.realestate.com.au/property-land-nsw-oberon-143000002",
+					Address:      "Lot 3 Edith Road",
+					Suburb:       "Oberon",
+					State:        "NSW",
+					Postcode:     "2787",
+					PriceText:    "Contact Agent",
+					PropertyType: "Vacant land",
+				},
+			},
+		},
+		{
+			// List view from the urql cache: descriptions, land sizes and media
+			fixture:     "search-list.html",
+			wantHasMore: true,
+			want: []listingFields{
+				{
+					ExternalID:   "143000003",
+					Source:       "rea",
+					URL:          "https://www.realestate.com.au/property-rural-nsw-mudgee-143000003",
+					Address:      "88 Hill End Road",
+					Suburb:       "Mudgee",
+					State:        "NSW",
+					Postcode:     "2850",
+					PriceText:    "$895,000",
+					PropertyType: "rural",
+					Bedrooms:     3,
+					Bathrooms:    1,
+					LandSizeSqm:  hectares(16.2),
+					Description:  "<p>40 acres with a <b>renovated cottage</b>.</p>",
+					Images: []string{
+						"https://i2.au.reastatic.net/800x600/3a1f0c2b9e/image.jpg",
+						"https://i2.au.reastatic.net/800x600/7bd02e11aa/image.jpg",
+					},
+					Media: []models.PropertyMedia{
+						{Kind: "floorplan", URL: "https://i2.au.reastatic.net/800x600/5c0ffee000/floorplan.jpg"},
+						{Kind: "video", URL: "https://www.youtube.com/watch?v=dQw4w9WgXcQ"},
+					},
+				},
+			},
+		},
+		{
+			// No embedded JSON: listings from the card links alone
+			fixture:     "search-cards.html",
+			wantHasMore: true,
+			want: []listingFields{
+				{
+					ExternalID:   "143000001",
+					Source:       "rea",
+					URL:          "https://www.realestate.com.au/property-rural-1204+sofala+road-wattle-nsw-2795-143000001",
+					Address:      "1204 Sofala Road",
+					Suburb:       "Wattle",
+					State:        "NSW",
+					Postcode:     "2795",
+					PropertyType: "rural",
+				},
+				{
+					ExternalID:   "143000002",
+					Source:       "rea",
+					URL:          "https://www.realestate.com.au/property-land-lot+3+edith+road-oberon-nsw-2787-143000002",
+					Address:      "Lot 3 Edith Road",
+					Suburb:       "Oberon",
+					State:        "NSW",
+					Postcode:     "2787",
+					PropertyType: "rural",
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			s := &REAScraper{}
+			listings, hasMore := s.parseListingsPage(readFixture(t, "rea", tt.fixture), "rural")
+			if hasMore != tt.wantHasMore {
+				t.Errorf("hasMore = %v, want %v", hasMore, tt.wantHasMore)
+			}
+			checkListings(t, listings, tt.want)
+		})
+	}
+}
+
+func TestREAParseListingDetails(t *testing.T) {
+	tests := []struct {
+		fixture string
+		url     string
+		want    listingFields
+	}{
+		{
+			// JSON-LD for the address, description, coordinates and images;
+			// ArgonautExchange for the price, land size and features
+			fixture: "detail.html",
+			url:     "https://www.realestate.com.au/property-rural-nsw-wattle+flat-143000001",
+			want: listingFields{
+				ExternalID:  "143000001",
+				Source:      "rea",
+				URL:         "https://www.realestate.com.au/property-rural-nsw-wattle+flat-143000001",
+				Address:     "1204 Sofala Road, Wattle Flat",
+				Suburb:      "Wattle Flat",
+				State:       "NSW",
+				Postcode:    "2795",
+				PriceText:   "$1,150,000 - $1,250,000",
+				Bedrooms:    4,
+				Bathrooms:   2,
+				Carspaces:   2, // "double carport" in the description
+				LandSizeSqm: acres(120),
+				Latitude:    -33.1342,
+				Longitude:   149.6931,
+				Description: "Productive 120 acre grazing block with a 4 bedroom homestead, double carport and machinery shed.",
+				Images: []string{
+					"https://i2.au.reastatic.net/800x600/3a1f0c2b9e/image.jpg",
+					"https://i2.au.reastatic.net/800x600/7bd02e11aa/image.jpg",
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			s := &REAScraper{}
+			listing, err := s.parseListingDetails(readFixture(t, "rea", tt.fixture), tt.url)
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkListing(t, fieldsOf(t, *listing), tt.want)
+		})
+	}
+
+	if _, err := (&REAScraper{}).parseListingDetails("<html></html>", "https://www.realestate.com.au/buy"); err == nil {
+		t.Error("parseListingDetails accepted a URL without a listing ID")
+	}
+}
+
+// A land size that doesn't parse (an unknown unit, no number) leaves the
+// listing without one rather than a size of zero
+func TestREAUnparsedLandSize(t *testing.T) {
+	for _, size := range []string{"100 furlongs", "Contact agent", "-5 ha"} {
+		t.Run(size, func(t *testing.T) {
+			s := &REAScraper{}
+
+			urql := s.parseUrqlListing(map[string]interface{}{
+				"id": "143000009",
+				"propertySizes": map[string]interface{}{
+					"land": map[string]interface{}{"displayValue": size},
+				},
+			}, "rural")
+			if urql == nil {
+				t.Fatal("parseUrqlListing returned nil")
+			}
+			if urql.LandSizeSqm.Valid {
+				t.Errorf("parseUrqlListing land size = %v, want none", urql.LandSizeSqm.Float64)
+			}
+
+			listing := s.parseJSONListing(map[string]interface{}{
+				"id": "143000009",
+				"propertySizes": map[string]interface{}{
+					"land": map[string]interface{}{"displayValue": size},
+				},
+			}, "rural")
+			if listing == nil {
+				t.Fatal("parseJSONListing returned nil")
+			}
+			if listing.LandSizeSqm.Valid {
+				t.Errorf("parseJSONListing land size = %v, want none", listing.LandSizeSqm.Float64)
+			}
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"farm-search/internal/models"
+)
+
+// Refresh refetches saved listings through their source's detail page (or
+// API) and saves them again, for a listing that looks stale or mangled.
+// Values the detail page doesn't give keep what's stored, as with any save,
+// including the coordinates. Listings that fail are logged and skipped; an
+// error is returned only if none could be refreshed.
+func (s *Scraper) Refresh(ctx context.Context, listings []models.Property) error {
+	if s.browser != nil && s.config.ScrapingBeeKey == "" {
+		for _, l := range listings {
+			if l.Source == "rea" {
+				if err := s.browser.Start(); err != nil {
+					return fmt.Errorf("failed to start browser: %w", err)
+				}
+				defer s.browser.Stop()
+				break
+			}
+		}
+	}
+
+	var refreshed []models.Property
+	for i, stored := range listings {
+		if i > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(s.config.DelayBetween):
+			}
+		}
+
+		log.Printf("Refreshing %s listing %s (property %d): %s", stored.Source, stored.ExternalID, stored.ID, stored.URL)
+		listing, err := s.fetchListing(ctx, stored)
+		if err != nil {
+			log.Printf("Failed to refresh property %d: %v", stored.ID, err)
+			continue
+		}
+
+		// Save over the stored row even if the page's ID parsed differently
+		listing.ExternalID, listing.Source = stored.ExternalID, stored.Source
+		if listing.URL == "" {
+			listing.URL = stored.URL
+		}
+		if !listing.Latitude.Valid || !listing.Longitude.Valid {
+			listing.Latitude, listing.Longitude = stored.Latitude, stored.Longitude
+			listing.CoordSource, listing.CoordConfidence = stored.CoordSource, stored.CoordConfidence
+		}
+		refreshed = append(refreshed, *listing)
+	}
+	if len(refreshed) == 0 {
+		return fmt.Errorf("no listings refreshed")
+	}
+
+	if s.config.DryRun {
+		return s.reportDryRun(refreshed)
+	}
+	if saveToDB, err := s.writeNDJSON(refreshed); err != nil || !saveToDB {
+		return err
+	}
+	saved, err := s.saveListings(ctx, refreshed)
+	if err != nil {
+		return fmt.Errorf("failed to save listings: %w", err)
+	}
+	if err := s.db.FindDuplicateProperties(); err != nil {
+		log.Printf("Warning: failed to find duplicate properties: %v", err)
+	}
+	log.Printf("Refreshed %d of %d listings", saved, len(listings))
+	return nil
+}
+
+// fetchListing fetches a saved listing again with its source's detail fetcher
+func (s *Scraper) fetchListing(ctx context.Context, stored models.Property) (*models.Property, error) {
+	var listing *models.Property
+	var err error
+
+	switch source := stored.Source; source {
+	case "farmproperty":
+		listing, err = s.farmProperty.FetchListingDetails(ctx, stored.URL, stored.ExternalID)
+	case "farmbuy":
+		listing, err = s.farmBuy.RefreshListing(ctx, stored)
+	case "gumtree":
+		listing, err = s.gumtree.FetchListingDetails(ctx, stored.URL, stored.ExternalID)
+		if err == nil && listing == nil {
+			return nil, fmt.Errorf("ad is no longer a property for sale")
+		}
+	case "rea":
+		if s.browser != nil && s.config.ScrapingBeeKey == "" {
+			listing, err = s.browser.FetchListingDetails(ctx, stored.URL)
+		} else {
+			listing, err = s.rea.FetchListingDetails(ctx, stored.URL)
+		}
+	case "domain":
+		if s.domain == nil {
+			return nil, fmt.Errorf("Domain API listings need an API key (use -domain-api-key)")
+		}
+		id, perr := strconv.ParseInt(stored.ExternalID, 10, 64)
+		if perr != nil {
+			return nil, fmt.Errorf("invalid Domain listing ID %q", stored.ExternalID)
+		}
+		listing, err = s.domain.FetchListingDetails(ctx, id)
+	case "domain-web":
+		listing, err = s.domainWeb.FetchListingDetails(ctx, stored.URL)
+	default:
+		for _, agency := range s.agencies {
+			if agency.Site().Source == source {
+				listing, err = agency.FetchListingDetails(ctx, stored.URL, stored.ExternalID)
+				break
+			}
+		}
+		if listing == nil && err == nil {
+			return nil, fmt.Errorf("no detail fetcher for source %q", source)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return listing, nil
+}
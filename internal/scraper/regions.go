@@ -0,0 +1,231 @@
+package scraper
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// RegionTargets lists where each source should search, in that source's own
+// location vocabulary (state slugs, REA region names, Domain suburb/area slugs)
+type RegionTargets struct {
+	FarmProperty []string         `json:"farmproperty"` // State slugs, e.g. "nsw"
+	FarmBuy      []string         `json:"farmbuy"`      // State slugs, e.g. "nsw"
+	REA          []string         `json:"rea"`          // REA region names, e.g. "central tablelands, nsw"
+	Domain       []string         `json:"domain"`       // Domain API states, e.g. "nsw"
+	Agency       []string         `json:"agency"`       // State slugs for the rural agency sites (Elders, Ray White Rural, Nutrien)
+	Gumtree      []string         `json:"gumtree"`      // State slugs, e.g. "nsw"
+	DomainWeb    DomainWebTargets `json:"domain_web"`
+}
+
+// DomainWebTargets holds the location parts of a Domain website search URL
+type DomainWebTargets struct {
+	Region  string   `json:"region"`  // URL path region, e.g. "illawarra-and-south-coast-nsw"
+	Suburbs []string `json:"suburbs"` // Suburb slugs, e.g. "goulburn-nsw-2580"
+	Areas   []string `json:"areas"`   // Area slugs, e.g. "southern-highlands-nsw"
+}
+
+// australianStates are the valid state slugs for state-based sources
+var australianStates = map[string]bool{
+	"nsw": true, "vic": true, "qld": true, "sa": true,
+	"wa": true, "tas": true, "nt": true, "act": true,
+}
+
+var (
+	reaRegionPattern      = regexp.MustCompile(`^[a-z0-9 \-]+, (nsw|vic|qld|sa|wa|tas|nt|act)$`)
+	domainSuburbPattern   = regexp.MustCompile(`^[a-z0-9\-]+-(nsw|vic|qld|sa|wa|tas|nt|act)-\d{4}$`)
+	domainLocationPattern = regexp.MustCompile(`^[a-z0-9\-]+$`)
+)
+
+// DefaultRegionTargets returns the default search areas: all of NSW for the
+// state-based sources, and the regions within reasonable distance of Sydney
+// for REA and Domain web
+func DefaultRegionTargets() RegionTargets {
+	return RegionTargets{
+		FarmProperty: []string{"nsw"},
+		FarmBuy:      []string{"nsw"},
+		REA: []string{
+			"central tablelands, nsw",
+			"southern tablelands, nsw",
+			"hunter region, nsw",
+			"southern highlands - greater region, nsw",
+			"illawarra region, nsw",
+			"central coast, nsw",
+			"blue mountains - region, nsw",
+			"wollongong - greater region, nsw",
+			"south coast, nsw",
+		},
+		Domain: []string{"nsw"},
+		DomainWeb: DomainWebTargets{
+			Region: "illawarra-and-south-coast-nsw",
+			Suburbs: []string{
+				"goulburn-nsw-2580",
+				"marulan-nsw-2579",
+				"bowral-nsw-2576",
+				"berry-nsw-2535",
+				"tallong-nsw-2579",
+				"kangaroo-valley-nsw-2577",
+				"nowra-nsw-2541",
+				"katoomba-nsw-2780",
+				"lithgow-nsw-2790",
+				"cessnock-nsw-2325",
+				"mellong-nsw-2756",
+				"taralga-nsw-2580",
+				"braidwood-nsw-2622",
+			},
+			Areas: []string{
+				"southern-highlands-nsw",
+				"hunter-valley-upper-nsw",
+				"central-coast-and-region-nsw",
+			},
+		},
+		Agency:  []string{"nsw"},
+		Gumtree: []string{"nsw"},
+	}
+}
+
+// LoadRegionTargets reads region targets from a JSON file.
+// Sources missing from the file keep their default targets.
+func LoadRegionTargets(path string) (RegionTargets, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return RegionTargets{}, fmt.Errorf("failed to read regions file: %w", err)
+	}
+
+	var fileTargets RegionTargets
+	if err := json.Unmarshal(data, &fileTargets); err != nil {
+		return RegionTargets{}, fmt.Errorf("failed to parse regions file: %w", err)
+	}
+
+	targets := DefaultRegionTargets()
+	if fileTargets.FarmProperty != nil {
+		targets.FarmProperty = fileTargets.FarmProperty
+	}
+	if fileTargets.FarmBuy != nil {
+		targets.FarmBuy = fileTargets.FarmBuy
+	}
+	if fileTargets.REA != nil {
+		targets.REA = fileTargets.REA
+	}
+	if fileTargets.Domain != nil {
+		targets.Domain = fileTargets.Domain
+	}
+	if fileTargets.DomainWeb.Region != "" {
+		targets.DomainWeb.Region = fileTargets.DomainWeb.Region
+	}
+	if fileTargets.DomainWeb.Suburbs != nil {
+		targets.DomainWeb.Suburbs = fileTargets.DomainWeb.Suburbs
+	}
+	if fileTargets.DomainWeb.Areas != nil {
+		targets.DomainWeb.Areas = fileTargets.DomainWeb.Areas
+	}
+	if fileTargets.Agency != nil {
+		targets.Agency = fileTargets.Agency
+	}
+	if fileTargets.Gumtree != nil {
+		targets.Gumtree = fileTargets.Gumtree
+	}
+
+	return targets, nil
+}
+
+// Validate checks that every target is in the format its source's URL scheme expects
+func (t RegionTargets) Validate() error {
+	var problems []string
+
+	checkStates := func(source string, states []string) {
+		for _, st := range states {
+			if !australianStates[st] {
+				problems = append(problems, fmt.Sprintf("%s: %q is not a state slug (expected e.g. \"nsw\")", source, st))
+			}
+		}
+	}
+	checkStates("farmproperty", t.FarmProperty)
+	checkStates("farmbuy", t.FarmBuy)
+	checkStates("domain", t.Domain)
+	checkStates("agency", t.Agency)
+	checkStates("gumtree", t.Gumtree)
+
+	for _, r := range t.REA {
+		if !reaRegionPattern.MatchString(r) {
+			problems = append(problems, fmt.Sprintf("rea: %q is not a region name (expected e.g. \"hunter region, nsw\")", r))
+		}
+	}
+
+	if t.DomainWeb.Region != "" && !domainLocationPattern.MatchString(t.DomainWeb.Region) {
+		problems = append(problems, fmt.Sprintf("domain_web: region %q is not a URL slug", t.DomainWeb.Region))
+	}
+	for _, s := range t.DomainWeb.Suburbs {
+		if !domainSuburbPattern.MatchString(s) {
+			problems = append(problems, fmt.Sprintf("domain_web: suburb %q is not a suburb slug (expected e.g. \"goulburn-nsw-2580\")", s))
+		}
+	}
+	for _, a := range t.DomainWeb.Areas {
+		if !domainLocationPattern.MatchString(a) {
+			problems = append(problems, fmt.Sprintf("domain_web: area %q is not a URL slug", a))
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid region targets:\n  %s", strings.Join(problems, "\n  "))
+	}
+	return nil
+}
+
+// LogTargets logs the targets that will be used for the selected source
+func (t RegionTargets) LogTargets(source string) {
+	if source == "farmproperty" || source == "all" {
+		log.Printf("FarmProperty targets: %s", strings.Join(t.FarmProperty, ", "))
+	}
+	if source == "farmbuy" || source == "all" {
+		log.Printf("FarmBuy targets: %s", strings.Join(t.FarmBuy, ", "))
+	}
+	if source == "rea" || source == "all" {
+		log.Printf("REA targets: %s", strings.Join(t.REA, "; "))
+	}
+	if source == "domain" || source == "all" {
+		log.Printf("Domain API targets: %s", strings.Join(t.Domain, ", "))
+	}
+	if source == "domain-web" || source == "all" {
+		log.Printf("Domain web targets: region %s, %d suburbs, %d areas",
+			t.DomainWeb.Region, len(t.DomainWeb.Suburbs), len(t.DomainWeb.Areas))
+	}
+	if _, ok := agencySite(source); ok || source == "agencies" || source == "all" {
+		log.Printf("Agency site targets: %s", strings.Join(t.Agency, ", "))
+	}
+	if source == "gumtree" || source == "all" {
+		log.Printf("Gumtree targets: %s", strings.Join(t.Gumtree, ", "))
+	}
+}
+
+// reaStates returns the distinct states covered by a list of REA region names, in order
+func reaStates(regions []string) []string {
+	seen := make(map[string]bool)
+	var states []string
+	for _, r := range regions {
+		idx := strings.LastIndex(r, ", ")
+		if idx < 0 {
+			continue
+		}
+		st := r[idx+2:]
+		if !seen[st] {
+			seen[st] = true
+			states = append(states, st)
+		}
+	}
+	return states
+}
+
+// reaRegionsInState returns the REA region names that belong to the given state
+func reaRegionsInState(regions []string, state string) []string {
+	var result []string
+	for _, r := range regions {
+		if strings.HasSuffix(r, ", "+strings.ToLower(state)) {
+			result = append(result, r)
+		}
+	}
+	return result
+}
@@ -0,0 +1,84 @@
+package scraper
+
+import (
+	"database/sql"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"farm-search/internal/models"
+)
+
+// Listing types selectable with Config.ListingType
+const (
+	ListingTypeBuy  = "buy"
+	ListingTypeRent = "rent"
+)
+
+var (
+	rentAmountPattern  = regexp.MustCompile(`\$\s*([\d,]+(?:\.\d+)?)`)
+	rentMonthlyPattern = regexp.MustCompile(`(?i)(per\s*month|/\s*month|\bpcm\b|\bp\.?c\.?m\.?\b|monthly)`)
+	rentAnnualPattern  = regexp.MustCompile(`(?i)(per\s*annum|/\s*year|\bp\.?a\.?\b|annually|per\s*year)`)
+)
+
+// parseWeeklyRent extracts the weekly rent from display text like "$650 per week",
+// "$650pw", "$2,800 pcm" or "$36,000 p.a.". Monthly and annual amounts are
+// converted to weekly. Returns 0 if no amount is found.
+func parseWeeklyRent(text string) int64 {
+	m := rentAmountPattern.FindStringSubmatch(text)
+	if len(m) < 2 {
+		return 0
+	}
+	amount, err := strconv.ParseFloat(strings.ReplaceAll(m[1], ",", ""), 64)
+	if err != nil || amount <= 0 {
+		return 0
+	}
+
+	switch {
+	case rentMonthlyPattern.MatchString(text):
+		amount = amount * 12 / 52
+	case rentAnnualPattern.MatchString(text):
+		amount = amount / 52
+	}
+	return int64(amount + 0.5)
+}
+
+// RentalFromProperty converts a listing scraped in rent mode into a rental.
+// Scrapers parse rentals with the same code as sales, so the weekly rent is
+// taken from the price fields (or parsed from the price text).
+func RentalFromProperty(p *models.Property) models.Rental {
+	r := models.Rental{
+		ExternalID:   p.ExternalID,
+		Source:       p.Source,
+		URL:          p.URL,
+		Address:      p.Address,
+		Suburb:       p.Suburb,
+		State:        p.State,
+		Postcode:     p.Postcode,
+		Latitude:     p.Latitude,
+		Longitude:    p.Longitude,
+		RentText:     p.PriceText,
+		PropertyType: p.PropertyType,
+		Bedrooms:     p.Bedrooms,
+		Bathrooms:    p.Bathrooms,
+		LandSizeSqm:  p.LandSizeSqm,
+		Description:  p.Description,
+		Images:       p.Images,
+		ScrapedAt:    p.ScrapedAt,
+		UpdatedAt:    p.UpdatedAt,
+	}
+
+	var weekly int64
+	if p.PriceText.Valid {
+		weekly = parseWeeklyRent(p.PriceText.String)
+	}
+	// Domain's API reports rentals' price as the weekly rent
+	if weekly == 0 && p.PriceMin.Valid && p.PriceMin.Int64 > 0 && p.PriceMin.Int64 < 10000 {
+		weekly = p.PriceMin.Int64
+	}
+	if weekly > 0 {
+		r.WeeklyRent = sql.NullInt64{Int64: weekly, Valid: true}
+	}
+
+	return r
+}
@@ -2,12 +2,16 @@ package scraper
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
+	"io"
 	"log"
+	"sort"
 	"sync"
 	"time"
 
 	"farm-search/internal/db"
+	"farm-search/internal/events"
 	"farm-search/internal/models"
 )
 
@@ -16,18 +20,28 @@ type Config struct {
 	MaxPages       int
 	DelayBetween   time.Duration
 	Workers        int
-	PropertyTypes  []string
-	Regions        []string
-	UseBrowser     bool   // Use headless browser to bypass bot protection
-	Headless       bool   // Run browser in headless mode (no visible window)
-	Source         string // Which source to scrape: "rea", "farmproperty", "farmbuy", "domain", "domain-web", or "all"
-	SkipGeocode    bool   // Skip geocoding for properties without coordinates
-	CookieFile     string // Path to JSON file containing cookies for REA authentication
-	UserDataDir    string // Path to Chrome user data directory for persistent sessions
-	ScrapingBeeKey string // ScrapingBee API key for bypassing bot protection (used for REA)
-	DomainAPIKey   string // Domain.com.au API key for their official API
-	DomainWebURL   string // Custom URL for domain-web scraper (overrides default)
-	FullRefresh    bool   // Continue scraping all pages even if properties already exist
+	Profile        SearchProfile // Land size, price and property type filters applied to every source
+	Regions        RegionTargets // Where each source searches, in that source's location vocabulary
+	UseBrowser     bool          // Use headless browser to bypass bot protection
+	BrowserTabs    int           // Number of browser tabs kept warm and reused across pages
+	TabPageLimit   int           // Replace a browser tab after it has loaded this many pages
+	Headless       bool          // Run browser in headless mode (no visible window)
+	Source         string        // Which source to scrape: "rea", "farmproperty", "farmbuy", "domain", "domain-web", an agency site, "agencies", "gumtree", or "all"
+	SkipGeocode    bool          // Skip geocoding for properties without coordinates
+	CookieFile     string        // Path to JSON file containing cookies for REA authentication
+	UserDataDir    string        // Path to Chrome user data directory for persistent sessions
+	CDPURL         string        // DevTools URL of a running Chrome to use instead of launching one (ws:// or http://)
+	ScrapingBeeKey string        // ScrapingBee API key for bypassing bot protection (used for REA)
+	FlareSolverURL string        // FlareSolverr-compatible service URL, used for REA when there's no ScrapingBee key or browser
+	DomainAPIKey   string        // Domain.com.au API key for their official API
+	DomainWebURL   string        // Custom URL for domain-web scraper (overrides default)
+	FullRefresh    bool          // Continue scraping all pages even if properties already exist
+	FixtureDir     string        // Save fetched pages as parser fixtures under this directory ("" = disabled)
+	DryRun         bool          // Search and parse as usual but save nothing; log what would have been saved
+	Output         string        // Where listings go: OutputDB (or ""), OutputNDJSON or OutputBoth
+	NDJSON         io.Writer     // Where OutputNDJSON and OutputBoth write listings, one JSON record per line
+
+	Events events.Publisher // Where saved listings that are new or changed are announced, if set
 }
 
 // DefaultConfig returns default scraper settings
@@ -36,18 +50,14 @@ func DefaultConfig() Config {
 		MaxPages:     0, // 0 = all pages
 		DelayBetween: 2 * time.Second,
 		Workers:      3,
-		PropertyTypes: []string{
-			"rural",
-			"farm",
-			"acreage-semi-rural",
-		},
-		Regions: []string{
-			"nsw",
-		},
-		UseBrowser:  false,          // Default to HTTP (FarmProperty doesn't need browser)
-		Headless:    true,           // Run headless by default
-		Source:      "farmproperty", // Default to FarmProperty (no bot protection)
-		SkipGeocode: true,           // Skip geocoding by default (run separately)
+		Profile:      DefaultSearchProfile(),
+		Regions:      DefaultRegionTargets(),
+		UseBrowser:   false,          // Default to HTTP (FarmProperty doesn't need browser)
+		Headless:     true,           // Run headless by default
+		Source:       "farmproperty", // Default to FarmProperty (no bot protection)
+		SkipGeocode:  true,           // Skip geocoding by default (run separately)
+		BrowserTabs:  DefaultBrowserSessions,
+		TabPageLimit: DefaultBrowserRecycleAfter,
 	}
 }
 
@@ -61,7 +71,10 @@ type Scraper struct {
 	farmBuy      *FarmBuyScraper
 	domain       *DomainScraper
 	domainWeb    *DomainWebScraper
+	agencies     []*AgencyScraper
+	gumtree      *GumtreeScraper
 	geo          *Geocoder
+	diagnostics  *ParseDiagnostics
 }
 
 // New creates a new Scraper instance
@@ -71,26 +84,69 @@ func New(database *db.DB, config Config) *Scraper {
 		config:       config,
 		farmProperty: NewFarmPropertyScraper(),
 		farmBuy:      NewFarmBuyScraper(),
+		gumtree:      NewGumtreeScraper(),
 		domainWeb:    NewDomainWebScraper(),
 		geo:          NewGeocoder(),
+		diagnostics:  NewParseDiagnostics(),
 	}
 
 	// Use ScrapingBee for REA if API key is provided
 	if config.ScrapingBeeKey != "" {
 		s.rea = NewREAScraperWithScrapingBee(config.ScrapingBeeKey)
 		log.Println("REA scraper configured to use ScrapingBee")
+	} else if config.FlareSolverURL != "" && !config.UseBrowser {
+		s.rea = NewREAScraperWithFlareSolverr(config.FlareSolverURL)
+		log.Printf("REA scraper configured to use FlareSolverr at %s", config.FlareSolverURL)
 	} else {
 		s.rea = NewREAScraper()
 	}
+	s.rea.SetSearchProfile(config.Profile)
+	s.rea.SetRegions(config.Regions.REA)
+	s.farmBuy.SetSearchProfile(config.Profile)
+	s.gumtree.SetSearchProfile(config.Profile)
+	s.rea.SetDiagnostics(s.diagnostics)
+	s.farmProperty.SetDiagnostics(s.diagnostics)
+	s.farmBuy.SetDiagnostics(s.diagnostics)
+	s.domainWeb.SetDiagnostics(s.diagnostics)
+	s.gumtree.SetDiagnostics(s.diagnostics)
+
+	// Capture fetched pages as parser fixtures if requested
+	var fixtures *FixtureRecorder
+	if config.FixtureDir != "" {
+		fixtures = NewFixtureRecorder(config.FixtureDir)
+		log.Printf("Capturing fetched pages as fixtures in %s", config.FixtureDir)
+	}
+	s.rea.SetFixtureRecorder(fixtures)
+	s.farmProperty.SetFixtureRecorder(fixtures)
+	s.farmBuy.SetFixtureRecorder(fixtures)
+	s.domainWeb.SetFixtureRecorder(fixtures)
+	s.gumtree.SetFixtureRecorder(fixtures)
+
+	for _, site := range AgencySites {
+		agency := NewAgencyScraper(site)
+		agency.SetSearchProfile(config.Profile)
+		agency.SetDiagnostics(s.diagnostics)
+		agency.SetFixtureRecorder(fixtures)
+		s.agencies = append(s.agencies, agency)
+	}
 
 	// Initialize Domain API scraper if API key is provided
 	if config.DomainAPIKey != "" {
 		s.domain = NewDomainScraper(config.DomainAPIKey)
+		s.domain.SetSearchProfile(config.Profile)
+		s.domain.SetDiagnostics(s.diagnostics)
+		s.domain.SetFixtureRecorder(fixtures)
 		log.Println("Domain API scraper configured with API key")
 	}
 
 	if config.UseBrowser {
 		s.browser = NewBrowserScraper(config.Headless)
+		s.browser.SetSessionPool(config.BrowserTabs, config.TabPageLimit)
+		if config.CDPURL != "" {
+			s.browser.SetRemoteURL(config.CDPURL)
+		}
+		s.browser.SetDiagnostics(s.diagnostics)
+		s.browser.SetFixtureRecorder(fixtures)
 	}
 
 	return s
@@ -118,29 +174,45 @@ func (s *Scraper) Run(ctx context.Context) error {
 	log.Println("Starting scraper...")
 	startTime := time.Now()
 
+	p := s.config.Profile
+	log.Printf("Search profile %q: land %.1f-%.1f ha, price $%d-$%d (0 = no limit)",
+		p.Name, p.MinLandSqm/10000, p.MaxLandSqm/10000, p.MinPrice, p.MaxPrice)
+	s.config.Regions.LogTargets(s.config.Source)
+	if p.Renting() {
+		log.Println("Scraping rental listings (saved to the rentals table)")
+		if _, agency := agencySite(s.config.Source); agency || s.config.Source == "agencies" ||
+			s.config.Source == "farmproperty" || s.config.Source == "farmbuy" || s.config.Source == "gumtree" {
+			log.Printf("Warning: %s only lists properties for sale, nothing to scrape in rent mode", s.config.Source)
+		}
+	}
+
 	// Start browser if using browser mode for REA
 	if s.config.UseBrowser && s.browser != nil && (s.config.Source == "rea" || s.config.Source == "all") {
 		if err := s.browser.Start(); err != nil {
 			return fmt.Errorf("failed to start browser: %w", err)
 		}
 		defer s.browser.Stop()
-		log.Println("Browser started in headless mode")
+		if s.config.CDPURL != "" {
+			log.Println("Using remote browser for REA scraping")
+		} else {
+			log.Println("Browser started in headless mode")
+		}
 	}
 
 	var allListings []models.Property
 	var mu sync.Mutex
 
 	// Scrape FarmProperty if selected
-	if s.config.Source == "farmproperty" || s.config.Source == "all" {
+	if (s.config.Source == "farmproperty" || s.config.Source == "all") && !p.Renting() {
 		// Create exists checker to stop pagination when we hit already-scraped properties
 		var existsChecker ExistsChecker
 		if !s.config.FullRefresh {
 			existsChecker = func(externalIDs []string) (map[string]bool, error) {
-				return s.db.PropertiesExist(externalIDs, "farmproperty")
+				return s.listingsExist(externalIDs, "farmproperty")
 			}
 		}
 
-		for _, region := range s.config.Regions {
+		for _, region := range s.config.Regions.FarmProperty {
 			log.Printf("Scraping FarmProperty for %s...", region)
 
 			listings, err := s.farmProperty.ScrapeListingsWithExistsCheck(ctx, region, s.config.MaxPages, existsChecker)
@@ -165,16 +237,16 @@ func (s *Scraper) Run(ctx context.Context) error {
 	}
 
 	// Scrape FarmBuy if selected
-	if s.config.Source == "farmbuy" || s.config.Source == "all" {
+	if (s.config.Source == "farmbuy" || s.config.Source == "all") && !p.Renting() {
 		// Create exists checker to stop pagination when we hit already-scraped properties
 		var existsChecker ExistsChecker
 		if !s.config.FullRefresh {
 			existsChecker = func(externalIDs []string) (map[string]bool, error) {
-				return s.db.PropertiesExist(externalIDs, "farmbuy")
+				return s.listingsExist(externalIDs, "farmbuy")
 			}
 		}
 
-		for _, region := range s.config.Regions {
+		for _, region := range s.config.Regions.FarmBuy {
 			log.Printf("Scraping FarmBuy for %s...", region)
 
 			listings, err := s.farmBuy.ScrapeListingsWithExistsCheck(ctx, region, s.config.MaxPages, existsChecker)
@@ -198,6 +270,79 @@ func (s *Scraper) Run(ctx context.Context) error {
 		}
 	}
 
+	// Scrape the rural agency sites (Elders, Ray White Rural, Nutrien) if selected
+	for _, agency := range s.agencies {
+		site := agency.Site()
+		if (s.config.Source != site.Source && s.config.Source != "agencies" && s.config.Source != "all") || p.Renting() {
+			continue
+		}
+
+		// Create exists checker to stop pagination when we hit already-scraped properties
+		var existsChecker ExistsChecker
+		if !s.config.FullRefresh {
+			existsChecker = func(externalIDs []string) (map[string]bool, error) {
+				return s.listingsExist(externalIDs, site.Source)
+			}
+		}
+
+		for _, region := range s.config.Regions.Agency {
+			log.Printf("Scraping %s for %s...", site.Name, region)
+
+			listings, err := agency.ScrapeListingsWithExistsCheck(ctx, region, s.config.MaxPages, existsChecker)
+			if err != nil {
+				log.Printf("Error scraping %s %s: %v", site.Name, region, err)
+				continue
+			}
+
+			mu.Lock()
+			allListings = append(allListings, listings...)
+			mu.Unlock()
+
+			log.Printf("Found %d listings from %s for %s", len(listings), site.Name, region)
+
+			// Respect rate limits
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(s.config.DelayBetween):
+			}
+		}
+	}
+
+	// Scrape Gumtree private-sale ads if selected
+	if (s.config.Source == "gumtree" || s.config.Source == "all") && !p.Renting() {
+		// Create exists checker to stop pagination when we hit already-scraped ads
+		var existsChecker ExistsChecker
+		if !s.config.FullRefresh {
+			existsChecker = func(externalIDs []string) (map[string]bool, error) {
+				return s.listingsExist(externalIDs, "gumtree")
+			}
+		}
+
+		for _, region := range s.config.Regions.Gumtree {
+			log.Printf("Scraping Gumtree for %s...", region)
+
+			listings, err := s.gumtree.ScrapeListingsWithExistsCheck(ctx, region, s.config.MaxPages, existsChecker)
+			if err != nil {
+				log.Printf("Error scraping Gumtree %s: %v", region, err)
+				continue
+			}
+
+			mu.Lock()
+			allListings = append(allListings, listings...)
+			mu.Unlock()
+
+			log.Printf("Found %d listings from Gumtree for %s", len(listings), region)
+
+			// Respect rate limits
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(s.config.DelayBetween):
+			}
+		}
+	}
+
 	// Scrape REA if selected
 	if s.config.Source == "rea" || s.config.Source == "all" {
 		// Log which method we're using
@@ -205,6 +350,8 @@ func (s *Scraper) Run(ctx context.Context) error {
 			log.Println("Using ScrapingBee for REA scraping")
 		} else if s.browser != nil {
 			log.Println("Using browser for REA scraping (may be blocked by Kasada)")
+		} else if s.config.FlareSolverURL != "" {
+			log.Println("Using FlareSolverr for REA scraping")
 		} else {
 			log.Println("Using direct HTTP for REA scraping (will likely be blocked)")
 		}
@@ -214,21 +361,24 @@ func (s *Scraper) Run(ctx context.Context) error {
 		var existsChecker ExistsChecker
 		if !s.config.FullRefresh {
 			existsChecker = func(externalIDs []string) (map[string]bool, error) {
-				return s.db.PropertiesExist(externalIDs, "rea")
+				return s.listingsExist(externalIDs, "rea")
 			}
 		}
 
-		// REA uses a single combined URL for all rural property types
-		// so we only need to iterate over regions, not property types
-		for _, region := range s.config.Regions {
+		// REA uses a single combined URL for all property types and all target regions
+		// within a state, so we only need to iterate over states
+		for _, region := range reaStates(s.config.Regions.REA) {
 			log.Printf("Scraping REA rural properties in %s...", region)
 
 			var listings []models.Property
 			var err error
 
-			// Priority: ScrapingBee > Browser > Direct HTTP
-			// Note: REA scraper already uses ScrapingBee if configured
+			// Priority: ScrapingBee > Browser > FlareSolverr > Direct HTTP
+			// Note: REA scraper already uses ScrapingBee or FlareSolverr if configured
 			if s.browser != nil && s.config.ScrapingBeeKey == "" {
+				if p.Renting() {
+					log.Println("Warning: the browser scraper only searches sales listings")
+				}
 				listings, err = s.browser.ScrapeListings(ctx, region, "rural", s.config.MaxPages)
 			} else {
 				listings, err = s.rea.ScrapeListingsWithExistsCheck(ctx, region, "rural", s.config.MaxPages, existsChecker)
@@ -265,11 +415,11 @@ func (s *Scraper) Run(ctx context.Context) error {
 		var existsChecker ExistsChecker
 		if !s.config.FullRefresh {
 			existsChecker = func(externalIDs []string) (map[string]bool, error) {
-				return s.db.PropertiesExist(externalIDs, "domain")
+				return s.listingsExist(externalIDs, "domain")
 			}
 		}
 
-		for _, region := range s.config.Regions {
+		for _, region := range s.config.Regions.Domain {
 			log.Printf("Fetching Domain API listings for %s...", region)
 
 			listings, err := s.domain.ScrapeListingsWithExistsCheck(ctx, region, s.config.MaxPages, existsChecker)
@@ -304,14 +454,14 @@ func (s *Scraper) Run(ctx context.Context) error {
 		var existsChecker ExistsChecker
 		if !s.config.FullRefresh {
 			existsChecker = func(externalIDs []string) (map[string]bool, error) {
-				return s.db.PropertiesExist(externalIDs, "domain-web")
+				return s.listingsExist(externalIDs, "domain-web")
 			}
 		} else {
 			log.Println("Full refresh enabled - will scrape all pages")
 		}
 
-		// Use custom URL if provided, otherwise use default
-		config := DefaultDomainWebConfig()
+		// Use custom URL if provided, otherwise use the default search area with the profile's filters
+		config := DomainWebConfigForProfile(s.config.Profile, s.config.Regions.DomainWeb)
 		if s.config.DomainWebURL != "" {
 			config.StartURL = s.config.DomainWebURL
 		}
@@ -328,7 +478,7 @@ func (s *Scraper) Run(ctx context.Context) error {
 		}
 	}
 
-	_ = startTime // Used later
+	s.reportParseDiagnostics(startTime)
 
 	log.Printf("Total listings found: %d", len(allListings))
 
@@ -339,14 +489,16 @@ func (s *Scraper) Run(ctx context.Context) error {
 			if !allListings[i].Latitude.Valid || !allListings[i].Longitude.Valid {
 				addr := formatAddress(&allListings[i])
 				if addr != "" {
-					lat, lng, err := s.geo.Geocode(ctx, addr)
+					result, err := s.geo.GeocodeWithConfidence(ctx, addr)
 					if err != nil {
 						log.Printf("Geocoding failed for %s: %v", addr, err)
 					} else {
-						allListings[i].Latitude.Float64 = lat
+						allListings[i].Latitude.Float64 = result.Lat
 						allListings[i].Latitude.Valid = true
-						allListings[i].Longitude.Float64 = lng
+						allListings[i].Longitude.Float64 = result.Lng
 						allListings[i].Longitude.Valid = true
+						allListings[i].CoordSource = sql.NullString{String: "geocoder", Valid: true}
+						allListings[i].CoordConfidence = sql.NullFloat64{Float64: result.Confidence, Valid: true}
 						geocoded++
 					}
 
@@ -364,8 +516,22 @@ func (s *Scraper) Run(ctx context.Context) error {
 		log.Printf("Skipped geocoding (run with -geocode to enable)")
 	}
 
+	if s.config.DryRun {
+		return s.reportDryRun(allListings)
+	}
+	if saveToDB, err := s.writeNDJSON(allListings); err != nil || !saveToDB {
+		return err
+	}
+
+	// Rentals are kept separately from properties for sale and aren't deduplicated
+	if p.Renting() {
+		saved := s.saveRentals(allListings)
+		log.Printf("Scraping complete: %d rentals saved in %s", saved, time.Since(startTime))
+		return nil
+	}
+
 	// Save to database
-	saved, err := s.saveListings(allListings)
+	saved, err := s.saveListings(ctx, allListings)
 	if err != nil {
 		return fmt.Errorf("failed to save listings: %w", err)
 	}
@@ -381,30 +547,182 @@ func (s *Scraper) Run(ctx context.Context) error {
 	return nil
 }
 
-func (s *Scraper) saveListings(listings []models.Property) (int, error) {
-	saved := 0
-	skipped := 0
+// reportParseDiagnostics logs which extraction paths were used this run, saves the
+// counts, and raises an alert for any path that worked last run but found nothing now
+func (s *Scraper) reportParseDiagnostics(runAt time.Time) {
+	s.diagnostics.LogSummary()
 
-	for _, listing := range listings {
-		// Skip properties without coordinates - they can't be shown on the map
-		if !listing.Latitude.Valid || !listing.Longitude.Valid {
-			skipped++
-			continue
+	alerts, err := s.diagnostics.CheckRegressions(s.db, runAt)
+	if err != nil {
+		log.Printf("Warning: failed to check parse diagnostics: %v", err)
+	}
+	for _, alert := range alerts {
+		log.Printf("ALERT: %s (site markup may have changed)", alert)
+	}
+
+	if s.config.DryRun {
+		return
+	}
+	if err := s.db.SaveParseStats(runAt, s.diagnostics.Stats()); err != nil {
+		log.Printf("Warning: failed to save parse diagnostics: %v", err)
+	}
+}
+
+// listingsExist checks which listings have already been saved, in the rentals
+// table when scraping rentals and the properties table otherwise
+func (s *Scraper) listingsExist(externalIDs []string, source string) (map[string]bool, error) {
+	if s.config.Profile.Renting() {
+		return s.db.RentalsExist(externalIDs, source)
+	}
+	return s.db.PropertiesExist(externalIDs, source)
+}
+
+// saveRentals saves listings scraped in rent mode to the rentals table, one
+// transaction per source
+func (s *Scraper) saveRentals(listings []models.Property) int {
+	noRent := 0
+	bySource := make(map[string][]models.Rental)
+	var sources []string
+
+	for i := range listings {
+		rental := RentalFromProperty(&listings[i])
+		if !rental.WeeklyRent.Valid {
+			noRent++
 		}
+		if _, ok := bySource[rental.Source]; !ok {
+			sources = append(sources, rental.Source)
+		}
+		bySource[rental.Source] = append(bySource[rental.Source], rental)
+	}
 
-		err := s.db.UpsertProperty(&listing)
+	var total db.SaveResult
+	for _, source := range sources {
+		result, err := s.db.SaveRentals(bySource[source])
 		if err != nil {
-			log.Printf("Failed to save listing %s: %v", listing.ExternalID, err)
+			log.Printf("Failed to save %s rentals: %v", source, err)
 			continue
 		}
-		saved++
+		for _, err := range result.Errors {
+			log.Printf("Failed to save rental: %v", err)
+		}
+		log.Printf("Saved %s rentals: %d new, %d updated, %d failed",
+			source, result.Inserted, result.Updated, result.Failed)
+		total.Add(result)
 	}
 
+	if noRent > 0 {
+		log.Printf("%d rentals had no parseable weekly rent", noRent)
+	}
+	return total.Saved()
+}
+
+// saveListings saves listings with coordinates, one transaction per source so
+// a crash mid-save leaves each source's previous data intact rather than half
+// updated, publishing each source's new and changed listings once it's saved
+func (s *Scraper) saveListings(ctx context.Context, listings []models.Property) (int, error) {
+	sources, bySource, skipped := groupListings(listings)
 	if skipped > 0 {
 		log.Printf("Skipped %d properties without coordinates", skipped)
 	}
 
-	return saved, nil
+	var total db.SaveResult
+	for _, source := range sources {
+		result, err := s.db.SaveProperties(bySource[source])
+		if err != nil {
+			return total.Saved(), fmt.Errorf("failed to save %s listings: %w", source, err)
+		}
+		for _, err := range result.Errors {
+			log.Printf("Failed to save listing: %v", err)
+		}
+		log.Printf("Saved %s listings: %d new, %d updated, %d failed",
+			source, result.Inserted, result.Updated, result.Failed)
+		total.Add(result)
+		events.Send(ctx, s.config.Events, events.Saved(result))
+	}
+
+	// Copy the saved listings to the property list, which only reads
+	if _, err := s.db.RefreshPropertySearch(); err != nil {
+		log.Printf("Failed to refresh property search: %v", err)
+	}
+	return total.Saved(), nil
+}
+
+// groupListings prepares listings for saving, grouped by source in the order
+// sources first appear: descriptions are normalized to plain text, and
+// listings without coordinates are dropped (they can't be shown on the map)
+// and counted as skipped
+func groupListings(listings []models.Property) (sources []string, bySource map[string][]models.Property, skipped int) {
+	bySource = make(map[string][]models.Property)
+	for _, listing := range listings {
+		if !listing.Latitude.Valid || !listing.Longitude.Valid {
+			skipped++
+			continue
+		}
+
+		if _, ok := bySource[listing.Source]; !ok {
+			sources = append(sources, listing.Source)
+		}
+		bySource[listing.Source] = append(bySource[listing.Source], listing)
+	}
+	return sources, bySource, skipped
+}
+
+// reportDryRun logs what a run would have saved, per source: pages parsed,
+// listings found, and how many would be new, updated or unchanged. Rentals
+// are only split into new and existing. Also logs the proxy credits the run
+// used, so a configuration can be costed before it's scheduled.
+func (s *Scraper) reportDryRun(listings []models.Property) error {
+	pages := make(map[string]int)
+	for _, st := range s.diagnostics.Stats() {
+		pages[st.Source] += st.Pages
+	}
+	found := make(map[string]int)
+	for _, l := range listings {
+		found[l.Source]++
+	}
+	var sources []string
+	for source := range pages {
+		sources = append(sources, source)
+	}
+	for source := range found {
+		if _, ok := pages[source]; !ok {
+			sources = append(sources, source)
+		}
+	}
+	sort.Strings(sources)
+
+	log.Println("Dry run: nothing was saved. Per source:")
+	if s.config.Profile.Renting() {
+		bySource := make(map[string][]string)
+		for _, l := range listings {
+			bySource[l.Source] = append(bySource[l.Source], l.ExternalID)
+		}
+		for _, source := range sources {
+			existing, err := s.db.RentalsExist(bySource[source], source)
+			if err != nil {
+				return fmt.Errorf("failed to check %s rentals: %w", source, err)
+			}
+			log.Printf("  %-14s %d pages, %d rentals: %d new, %d existing",
+				source, pages[source], found[source], found[source]-len(existing), len(existing))
+		}
+	} else {
+		_, bySource, _ := groupListings(listings)
+		for _, source := range sources {
+			preview, err := s.db.PreviewProperties(bySource[source])
+			if err != nil {
+				return fmt.Errorf("failed to preview %s listings: %w", source, err)
+			}
+			log.Printf("  %-14s %d pages, %d listings: %d new, %d updated, %d unchanged, %d without coordinates",
+				source, pages[source], found[source], preview.New, preview.Updated, preview.Unchanged,
+				found[source]-len(bySource[source]))
+		}
+	}
+
+	if s.rea.scrapingBee != nil {
+		requests, credits := s.rea.scrapingBee.Usage()
+		log.Printf("ScrapingBee: %d requests, %d credits", requests, credits)
+	}
+	return nil
 }
 
 func formatAddress(p *models.Property) string {
@@ -6,6 +6,8 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"strconv"
+	"sync"
 	"time"
 )
 
@@ -15,6 +17,10 @@ type ScrapingBeeClient struct {
 	apiKey     string
 	httpClient *http.Client
 	baseURL    string
+
+	mu       sync.Mutex
+	requests int // Successful (billed) requests
+	credits  int // Credits those requests cost
 }
 
 // NewScrapingBeeClient creates a new ScrapingBee client
@@ -120,15 +126,43 @@ func (c *ScrapingBeeClient) Fetch(ctx context.Context, targetURL string, opts Sc
 		return nil, fmt.Errorf("ScrapingBee error (HTTP %d): %s", resp.StatusCode, string(body))
 	}
 
-	// Check remaining credits from headers
-	if credits := resp.Header.Get("Spb-Cost"); credits != "" {
-		// Log credit usage for monitoring (can be parsed if needed)
-		// The "Spb-Cost" header shows how many credits this request used
+	// The "Spb-Cost" header shows how many credits this request used
+	cost, err := strconv.Atoi(resp.Header.Get("Spb-Cost"))
+	if err != nil {
+		cost = estimatedCredits(opts)
 	}
+	c.mu.Lock()
+	c.requests++
+	c.credits += cost
+	c.mu.Unlock()
 
 	return body, nil
 }
 
+// Usage returns how many successful requests the client has made and the
+// credits they cost
+func (c *ScrapingBeeClient) Usage() (requests, credits int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.requests, c.credits
+}
+
+// estimatedCredits is ScrapingBee's published cost of a request with opts,
+// for responses that don't report it
+func estimatedCredits(opts ScrapingBeeOptions) int {
+	switch {
+	case opts.Stealth:
+		return 75
+	case opts.Premium && opts.RenderJS:
+		return 25
+	case opts.Premium:
+		return 10
+	case opts.RenderJS:
+		return 5
+	}
+	return 1
+}
+
 // FetchHTML is a convenience method that returns the response as a string
 func (c *ScrapingBeeClient) FetchHTML(ctx context.Context, targetURL string, opts ScrapingBeeOptions) (string, error) {
 	body, err := c.Fetch(ctx, targetURL, opts)
@@ -0,0 +1,99 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"farm-search/internal/db"
+	"farm-search/internal/geo"
+)
+
+// A property's lots are sampled at accessBoundaryPoints points along their
+// boundaries, each snapped to a road at most accessSearchKm away: a gate
+// further from the road than that isn't one
+const (
+	accessBoundaryPoints = 48
+	accessSearchKm       = 1.0
+)
+
+// AccessPoints finds a driveway or gate candidate for each property with
+// cadastral lots: the road point nearest its lots' boundary, or nearest the
+// listing point if that's closer. Drive times are routed from it rather than
+// from a listing point that may be mid-paddock, and recomputed when it moves.
+// Properties with no road near are saved as 'none' and routed from the
+// listing point. Needs a router; stops like DriveTimesToAnchor if Valhalla
+// goes down.
+func (s *EnrichmentService) AccessPoints(ctx context.Context, all bool) (EnrichmentStats, error) {
+	properties, err := s.targets(db.StepAccessPoint, all, "access points")
+	stats := EnrichmentStats{Total: len(properties)}
+	if err != nil || len(properties) == 0 {
+		return stats, err
+	}
+
+	log.Printf("Finding access points for %d properties...", len(properties))
+
+	for i, p := range properties {
+		lots, err := s.db.GetPropertyLots(p.ID)
+		if err != nil {
+			log.Printf("[%d/%d] Failed to get lots for property %d: %v", i+1, len(properties), p.ID, err)
+			stats.Failed++
+			continue
+		}
+
+		// The listing point goes first, so it wins ties
+		points := []geo.MatrixPoint{{Lat: p.Latitude, Lng: p.Longitude}}
+		perLot := accessBoundaryPoints / max(len(lots), 1)
+		for _, lot := range lots {
+			area, err := geo.ParsePolygon(lot.Geometry)
+			if err != nil {
+				log.Printf("  Warning: Could not parse lot %s: %v", lot.LotIDString, err)
+				continue
+			}
+			points = append(points, area.BoundaryPoints(max(perLot, 4))...)
+		}
+
+		snaps, err := s.router.LocateRoads(ctx, points, accessSearchKm)
+		if errors.Is(err, geo.ErrValhallaUnavailable) {
+			return stats, err
+		}
+		if err != nil {
+			log.Printf("[%d/%d] Failed for property %d (%s): %v", i+1, len(properties), p.ID, location(p), err)
+			stats.Failed++
+			continue
+		}
+
+		nearest := -1
+		for j, snap := range snaps {
+			if snap != nil && (nearest < 0 || snap.DistanceKm < snaps[nearest].DistanceKm) {
+				nearest = j
+			}
+		}
+
+		var lat, lng *float64
+		source, from := "none", ""
+		if nearest >= 0 {
+			lat, lng = &snaps[nearest].Lat, &snaps[nearest].Lng
+			source, from = "point", "listing point"
+			if nearest > 0 {
+				source, from = "lot", "lot boundary"
+			}
+		}
+		if err := s.db.UpdatePropertyAccessPoint(p.ID, lat, lng, source); err != nil {
+			log.Printf("[%d/%d] Failed to save for property %d: %v", i+1, len(properties), p.ID, err)
+			stats.Failed++
+			continue
+		}
+
+		if nearest < 0 {
+			log.Printf("[%d/%d] Property %d (%s): no road within %.0f m, routing from the listing point",
+				i+1, len(properties), p.ID, location(p), accessSearchKm*1000)
+		} else {
+			log.Printf("[%d/%d] Property %d (%s): access at %.5f, %.5f from the %s (%.0f m from the listing point)",
+				i+1, len(properties), p.ID, location(p), *lat, *lng, from, geo.Haversine(p.Latitude, p.Longitude, *lat, *lng)*1000)
+		}
+		s.recomputed(p.ID, db.StepAccessPoint)
+		stats.Success++
+	}
+	return stats, nil
+}
@@ -0,0 +1,156 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"farm-search/internal/attachments"
+	"farm-search/internal/db"
+	"farm-search/internal/models"
+)
+
+// ErrInvalidAttachment is returned for an upload that can't be attached
+var ErrInvalidAttachment = errors.New("invalid attachment")
+
+// AttachmentKinds are the kinds of document a property can have attached
+var AttachmentKinds = []string{"contract", "section-10-7", "soil-test", "survey", "report", "other"}
+
+// AttachmentService attaches documents to properties, keeping the files in an
+// attachment store and their details in the database
+type AttachmentService struct {
+	db    *db.DB
+	store attachments.Store
+}
+
+// NewAttachmentService creates a new AttachmentService
+func NewAttachmentService(database *db.DB, store attachments.Store) *AttachmentService {
+	return &AttachmentService{db: database, store: store}
+}
+
+// Add stores body and attaches it to a property. Documents for a duplicate
+// listing are attached to its canonical property; an empty kind is "other".
+func (s *AttachmentService) Add(ctx context.Context, propertyID int64, a *models.Attachment, body io.ReadSeeker) error {
+	if a.Kind == "" {
+		a.Kind = "other"
+	}
+	if !validAttachmentKind(a.Kind) {
+		return fmt.Errorf("%w: kind must be one of %s", ErrInvalidAttachment, strings.Join(AttachmentKinds, ", "))
+	}
+	name := sanitizeFilename(a.Filename)
+	if name == "" {
+		return fmt.Errorf("%w: missing filename", ErrInvalidAttachment)
+	}
+
+	canonicalID, err := s.db.GetCanonicalPropertyID(propertyID)
+	if err != nil {
+		return err
+	}
+	if _, err := s.db.GetProperty(canonicalID); err != nil {
+		return fmt.Errorf("%w: %d", ErrPropertyNotFound, propertyID)
+	}
+
+	hash := sha256.New()
+	size, err := io.Copy(hash, body)
+	if err != nil {
+		return fmt.Errorf("failed to read attachment: %w", err)
+	}
+	if size == 0 {
+		return fmt.Errorf("%w: file is empty", ErrInvalidAttachment)
+	}
+	if _, err := body.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to read attachment: %w", err)
+	}
+
+	// A random part keeps re-uploads of the same filename apart
+	nonce := make([]byte, 8)
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+	key := fmt.Sprintf("properties/%d/%s-%s", canonicalID, hex.EncodeToString(nonce), name)
+	if err := s.store.Put(ctx, key, body); err != nil {
+		return fmt.Errorf("failed to store attachment: %w", err)
+	}
+
+	a.PropertyID = canonicalID
+	a.Filename = name
+	a.SizeBytes = size
+	a.SHA256 = hex.EncodeToString(hash.Sum(nil))
+	a.StorageKey = key
+	if err := s.db.CreateAttachment(a); err != nil {
+		s.store.Delete(ctx, key)
+		return err
+	}
+	return nil
+}
+
+// List returns a property's attachments, newest first
+func (s *AttachmentService) List(propertyID int64) ([]models.Attachment, error) {
+	canonicalID, err := s.db.GetCanonicalPropertyID(propertyID)
+	if err != nil {
+		return nil, err
+	}
+	return s.db.ListAttachments(canonicalID)
+}
+
+// Get returns an attachment's details
+func (s *AttachmentService) Get(id int64) (*models.Attachment, error) {
+	return s.db.GetAttachment(id)
+}
+
+// Open opens an attachment's file for reading
+func (s *AttachmentService) Open(ctx context.Context, a *models.Attachment) (io.ReadCloser, error) {
+	return s.store.Open(ctx, a.StorageKey)
+}
+
+// Delete removes an attachment and its file, reporting whether it existed
+func (s *AttachmentService) Delete(ctx context.Context, id int64) (bool, error) {
+	a, err := s.db.GetAttachment(id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if err := s.store.Delete(ctx, a.StorageKey); err != nil {
+		return false, err
+	}
+	return s.db.DeleteAttachment(id)
+}
+
+func validAttachmentKind(kind string) bool {
+	for _, k := range AttachmentKinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// sanitizeFilename reduces an uploaded filename to its base name in letters,
+// digits, dots, dashes and underscores, so it is safe in a storage key and a
+// Content-Disposition header
+func sanitizeFilename(name string) string {
+	name = path.Base(strings.ReplaceAll(name, `\`, "/"))
+	var b strings.Builder
+	for _, r := range name {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '-', r == '_':
+			b.WriteRune(r)
+		case r == ' ':
+			b.WriteRune('_')
+		}
+	}
+	clean := strings.Trim(b.String(), ".")
+	if len(clean) > 100 {
+		clean = clean[len(clean)-100:]
+	}
+	return clean
+}
@@ -0,0 +1,125 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+
+	"farm-search/internal/db"
+	"farm-search/internal/geo"
+	"farm-search/internal/models"
+)
+
+// biosecurityArea is an imported biosecurity area with its parsed polygons
+type biosecurityArea struct {
+	models.BiosecurityArea
+	area *geo.Area
+}
+
+// Biosecurity saves the Local Land Services region and declared weed zones
+// each property's coordinates fall in, for the management obligations they
+// bring. Only applies once regions or weed zones have been imported with
+// `tools biosecurity`.
+func (s *EnrichmentService) Biosecurity(all bool) (EnrichmentStats, error) {
+	properties, err := s.targets(db.StepBiosecurity, all, "biosecurity region")
+	stats := EnrichmentStats{Total: len(properties)}
+	if err != nil || len(properties) == 0 {
+		return stats, err
+	}
+
+	rows, err := s.db.GetBiosecurityAreas()
+	if err != nil {
+		return stats, err
+	}
+	var regions, weedZones []biosecurityArea
+	for _, row := range rows {
+		var geometry geo.GeoJSONGeometry
+		if err := json.Unmarshal([]byte(row.Geometry), &geometry); err != nil {
+			return stats, fmt.Errorf("failed to parse geometry of biosecurity area %d: %w", row.ID, err)
+		}
+		area, err := geo.NewArea(&geo.GeoJSONFeatureCollection{Features: []geo.GeoJSONFeature{{Geometry: geometry}}})
+		if err != nil {
+			return stats, fmt.Errorf("biosecurity area %d: %w", row.ID, err)
+		}
+		switch row.Kind {
+		case geo.BiosecurityLLSRegion:
+			regions = append(regions, biosecurityArea{row, area})
+		case geo.BiosecurityWeedZone:
+			weedZones = append(weedZones, biosecurityArea{row, area})
+		}
+	}
+
+	log.Printf("Matching %d properties to %d Local Land Services regions and %d weed zones...",
+		len(properties), len(regions), len(weedZones))
+
+	for i, p := range properties {
+		var region *string
+		for _, r := range regions {
+			if r.area.Contains(p.Latitude, p.Longitude) {
+				region = &r.Name
+				break
+			}
+		}
+
+		// Areas are ordered by name and category, so zones come out sorted;
+		// the same weed and zone split over several polygons is listed once
+		zones := []models.WeedZone{}
+		seen := make(map[models.WeedZone]bool)
+		for _, z := range weedZones {
+			zone := models.WeedZone{Weed: z.Name, Category: z.Category}
+			if !seen[zone] && z.area.Contains(p.Latitude, p.Longitude) {
+				seen[zone] = true
+				zones = append(zones, zone)
+			}
+		}
+		encoded, err := json.Marshal(zones)
+		if err != nil {
+			return stats, err
+		}
+
+		if err := s.db.UpdatePropertyBiosecurity(p.ID, region, string(encoded)); err != nil {
+			log.Printf("[%d/%d] Failed to save for property %d: %v", i+1, len(properties), p.ID, err)
+			stats.Failed++
+			continue
+		}
+
+		names := make([]string, len(zones))
+		for j, z := range zones {
+			names[j] = z.Weed
+		}
+		regionName := "none"
+		if region != nil {
+			regionName = *region
+		}
+		log.Printf("[%d/%d] Property %d (%s): %s LLS, weeds: %s",
+			i+1, len(properties), p.ID, p.Suburb, regionName, strings.Join(names, ", "))
+		s.recomputed(p.ID, db.StepBiosecurity)
+		stats.Success++
+	}
+	return stats, nil
+}
+
+// MarkBiosecurityChanged is MarkChangedInputs for the imported biosecurity
+// areas alone, for right after an import
+func (s *EnrichmentService) MarkBiosecurityChanged() error {
+	input, err := s.biosecurityInput()
+	if err != nil || input == nil {
+		return err
+	}
+	return s.markIfChanged(*input)
+}
+
+// biosecurityInput returns the imported regions and weed zones' version as
+// an enrichment input, so reimporting either recomputes every property. Nil
+// if none have been imported.
+func (s *EnrichmentService) biosecurityInput() (*enrichmentInput, error) {
+	version, err := s.db.GetBiosecurityAreaVersion()
+	if err != nil {
+		return nil, err
+	}
+	if version == "0|" {
+		return nil, nil
+	}
+	return &enrichmentInput{"biosecurity_areas", version, []db.EnrichmentStep{db.StepBiosecurity}}, nil
+}
@@ -0,0 +1,132 @@
+package service
+
+import (
+	"fmt"
+
+	"farm-search/internal/db"
+	"farm-search/internal/geo"
+)
+
+// boundaryFixMinConfidence is how far a pin must be trusted to correct a
+// listing's suburb or postcode by it; a pin geocoded from only the suburb
+// can't say the suburb is wrong. Pins saved before confidence was recorded
+// came from listings.
+const boundaryFixMinConfidence = 0.8
+
+// BoundaryCheckResult counts what a boundary check found
+type BoundaryCheckResult struct {
+	Checked            int // Properties with coordinates
+	Outside            int // Pins outside every suburb boundary
+	SuburbMismatches   int
+	PostcodeMismatches int
+	SuburbsFixed       int
+	PostcodesFixed     int
+}
+
+// BoundaryCheckService checks properties' listed suburbs and postcodes
+// against the suburb and postcode boundaries their pins are in. Listings
+// near a boundary often name the neighbouring (better known) suburb.
+type BoundaryCheckService struct {
+	db *db.DB
+}
+
+// NewBoundaryCheckService creates a new BoundaryCheckService
+func NewBoundaryCheckService(database *db.DB) *BoundaryCheckService {
+	return &BoundaryCheckService{db: database}
+}
+
+// namedArea is a parsed suburb or postcode boundary
+type namedArea struct {
+	name string
+	area *geo.Area
+}
+
+// Check finds the suburb (see `tools suburbs`) and postcode (`tools
+// postcodes`) each property's pin is in and flags listings naming others.
+// With fix, a mismatched suburb or postcode is corrected to the boundary's
+// where the pin is trusted, keeping the listing's. A listing without a
+// suburb or postcode isn't a mismatch, but is filled in with fix.
+func (s *BoundaryCheckService) Check(fix bool) (*BoundaryCheckResult, error) {
+	suburbBoundaries, err := s.db.GetSuburbBoundaries()
+	if err != nil {
+		return nil, err
+	}
+	var suburbs []namedArea
+	for _, b := range suburbBoundaries {
+		area, err := boundaryArea(b.Geometry)
+		if err != nil {
+			return nil, fmt.Errorf("suburb %s: %w", b.Name, err)
+		}
+		if !area.Empty() {
+			suburbs = append(suburbs, namedArea{geo.SuburbName(b.Name), area})
+		}
+	}
+	postcodeBoundaries, err := s.db.GetPostcodeBoundaries()
+	if err != nil {
+		return nil, err
+	}
+	var postcodes []namedArea
+	for _, b := range postcodeBoundaries {
+		area, err := boundaryArea(b.Geometry)
+		if err != nil {
+			return nil, fmt.Errorf("postcode %s: %w", b.Postcode, err)
+		}
+		if !area.Empty() {
+			postcodes = append(postcodes, namedArea{b.Postcode, area})
+		}
+	}
+	if len(suburbs) == 0 && len(postcodes) == 0 {
+		return nil, fmt.Errorf("no suburb or postcode boundaries imported")
+	}
+
+	properties, err := s.db.GetPropertiesToCheckBoundaries()
+	if err != nil {
+		return nil, err
+	}
+	result := &BoundaryCheckResult{}
+	checks := make([]db.BoundaryCheck, 0, len(properties))
+	for _, p := range properties {
+		result.Checked++
+		lat, lng := p.Latitude.Float64, p.Longitude.Float64
+		c := db.BoundaryCheck{
+			PropertyID: p.ID,
+			Suburb:     containing(suburbs, lat, lng),
+			Postcode:   containing(postcodes, lat, lng),
+		}
+		if c.Suburb == "" {
+			result.Outside++
+		}
+		trusted := !p.CoordConfidence.Valid || p.CoordConfidence.Float64 >= boundaryFixMinConfidence
+
+		listed := p.Suburb.String
+		c.SuburbMismatch = c.Suburb != "" && listed != "" && !geo.SameSuburb(listed, c.Suburb)
+		c.FixSuburb = fix && trusted && c.Suburb != "" && (c.SuburbMismatch || listed == "")
+		if c.SuburbMismatch {
+			result.SuburbMismatches++
+		}
+
+		listed = p.Postcode.String
+		c.PostcodeMismatch = c.Postcode != "" && listed != "" && listed != c.Postcode
+		c.FixPostcode = fix && trusted && c.Postcode != "" && (c.PostcodeMismatch || listed == "")
+		if c.PostcodeMismatch {
+			result.PostcodeMismatches++
+		}
+		checks = append(checks, c)
+	}
+
+	result.SuburbsFixed, result.PostcodesFixed, err = s.db.SaveBoundaryChecks(checks)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// containing returns the name of the area containing a point, or ""
+func containing(areas []namedArea, lat, lng float64) string {
+	for _, a := range areas {
+		if a.area.Contains(lat, lng) {
+			return a.name
+		}
+	}
+	return ""
+}
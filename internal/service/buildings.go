@@ -0,0 +1,118 @@
+package service
+
+import (
+	"log"
+	"math"
+
+	"farm-search/internal/db"
+	"farm-search/internal/geo"
+)
+
+// A building between these plan areas counts as a possible dwelling: smaller
+// is a garage or water tank, larger a machinery or poultry shed. Sheds of a
+// house's size count too, so has_dwelling = 0 is the reliable signal.
+const (
+	dwellingMinSqm = 60.0
+	dwellingMaxSqm = 800.0
+)
+
+// Buildings counts the imported building footprints with their centroid on
+// each property's cadastral lots, saving the count, their total and largest
+// plan area and whether any is house-sized, so land with no dwelling is
+// flagged whatever the listing says. Only applies once footprints have been
+// imported with `tools buildings`.
+func (s *EnrichmentService) Buildings(all bool) (EnrichmentStats, error) {
+	properties, err := s.targets(db.StepBuildings, all, "building footprints")
+	stats := EnrichmentStats{Total: len(properties)}
+	if err != nil || len(properties) == 0 {
+		return stats, err
+	}
+
+	log.Printf("Counting buildings on the lots of %d properties...", len(properties))
+
+	for i, p := range properties {
+		lots, err := s.db.GetPropertyLots(p.ID)
+		if err != nil {
+			log.Printf("[%d/%d] Failed to get lots for property %d: %v", i+1, len(properties), p.ID, err)
+			stats.Failed++
+			continue
+		}
+
+		// A building is counted once even if overlapping lots both hold it
+		seen := make(map[int64]bool)
+		var totalSqm, largestSqm float64
+		hasDwelling := false
+		failed := false
+		for _, lot := range lots {
+			area, err := geo.ParsePolygon(lot.Geometry)
+			if err != nil {
+				log.Printf("  Warning: Could not parse lot %s: %v", lot.LotIDString, err)
+				failed = true
+				break
+			}
+			footprints, err := s.db.GetBuildingFootprintsWithin(area.Bounds())
+			if err != nil {
+				log.Printf("  Warning: Could not get buildings for lot %s: %v", lot.LotIDString, err)
+				failed = true
+				break
+			}
+			for _, b := range footprints {
+				if seen[b.ID] || !area.Contains(b.Latitude, b.Longitude) {
+					continue
+				}
+				seen[b.ID] = true
+				totalSqm += b.AreaSqm
+				largestSqm = math.Max(largestSqm, b.AreaSqm)
+				if b.AreaSqm >= dwellingMinSqm && b.AreaSqm <= dwellingMaxSqm {
+					hasDwelling = true
+				}
+			}
+		}
+		if failed {
+			log.Printf("[%d/%d] Failed for property %d (%s)", i+1, len(properties), p.ID, location(p))
+			stats.Failed++
+			continue
+		}
+
+		totalSqm, largestSqm = math.Round(totalSqm), math.Round(largestSqm)
+		if err := s.db.UpdatePropertyBuildings(p.ID, len(seen), totalSqm, largestSqm, hasDwelling); err != nil {
+			log.Printf("[%d/%d] Failed to save for property %d: %v", i+1, len(properties), p.ID, err)
+			stats.Failed++
+			continue
+		}
+
+		dwelling := "no dwelling"
+		if hasDwelling {
+			dwelling = "dwelling"
+		}
+		log.Printf("[%d/%d] Property %d (%s): %d buildings, %.0f sqm (largest %.0f sqm), %s",
+			i+1, len(properties), p.ID, location(p), len(seen), totalSqm, largestSqm, dwelling)
+		s.recomputed(p.ID, db.StepBuildings)
+		stats.Success++
+	}
+	return stats, nil
+}
+
+// MarkBuildingsChanged is MarkChangedInputs for the building footprints
+// alone, for `tools buildings` to call after reimporting them
+func (s *EnrichmentService) MarkBuildingsChanged() error {
+	input, err := s.buildingsInput()
+	if err != nil || input == nil {
+		return err
+	}
+	return s.markIfChanged(*input)
+}
+
+// buildingsInput returns the building footprints' version as an enrichment
+// input, so reimporting them recounts every property. Nil if none have been
+// imported.
+func (s *EnrichmentService) buildingsInput() (*enrichmentInput, error) {
+	version, err := s.db.GetBuildingFootprintVersion()
+	if err != nil {
+		return nil, err
+	}
+	if version == "0|" {
+		return nil, nil
+	}
+	return &enrichmentInput{"building_footprints", version, []db.EnrichmentStep{db.StepBuildings}}, nil
+}
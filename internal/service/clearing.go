@@ -0,0 +1,115 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"farm-search/internal/db"
+	"farm-search/internal/geo"
+)
+
+const (
+	// clearingYears is how far back the baseline snapshot is taken
+	clearingYears = 5
+	// clearingWindowDays is the length of each snapshot's window, ending
+	// today and clearingYears ago so both are the same season
+	clearingWindowDays = 90
+	// clearingFlagPts is the loss of woody cover in percentage points that
+	// flags a property for recent large-scale clearing
+	clearingFlagPts = 10.0
+)
+
+// WithVegetation returns a copy of the service that measures vegetation
+// change with source
+func (s *EnrichmentService) WithVegetation(source geo.VegetationSource) *EnrichmentService {
+	scoped := *s
+	scoped.vegetation = source
+	return &scoped
+}
+
+// Clearing compares the vegetation over each property's cadastral lots
+// (their bounding box) now with the same season clearingYears ago, saving
+// the change in woody cover and mean NDVI and flagging properties that lost
+// at least clearingFlagPts of woody cover for closer inspection. Needs a
+// vegetation source, and lots from CadastralLots.
+func (s *EnrichmentService) Clearing(ctx context.Context, all bool) (EnrichmentStats, error) {
+	properties, err := s.targets(db.StepClearing, all, "clearing check")
+	stats := EnrichmentStats{Total: len(properties)}
+	if err != nil || len(properties) == 0 {
+		return stats, err
+	}
+
+	to := time.Now().UTC().Truncate(24 * time.Hour)
+	from := to.AddDate(0, 0, -clearingWindowDays)
+	baselineFrom, baselineTo := from.AddDate(-clearingYears, 0, 0), to.AddDate(-clearingYears, 0, 0)
+	log.Printf("Comparing vegetation for %d properties between %d and %d...",
+		len(properties), baselineTo.Year(), to.Year())
+
+	for i, p := range properties {
+		bbox, err := s.lotsBBox(p.ID)
+		if err != nil {
+			log.Printf("[%d/%d] Failed for property %d (%s): %v", i+1, len(properties), p.ID, location(p), err)
+			stats.Failed++
+			continue
+		}
+
+		baseline, err := s.vegetation.Snapshot(ctx, bbox, baselineFrom, baselineTo)
+		if err != nil {
+			log.Printf("[%d/%d] Failed baseline for property %d (%s): %v", i+1, len(properties), p.ID, location(p), err)
+			stats.Failed++
+			continue
+		}
+		recent, err := s.vegetation.Snapshot(ctx, bbox, from, to)
+		if err != nil {
+			log.Printf("[%d/%d] Failed for property %d (%s): %v", i+1, len(properties), p.ID, location(p), err)
+			stats.Failed++
+			continue
+		}
+
+		woodyChange := roundPct(recent.WoodyCover - baseline.WoodyCover)
+		ndviChange := roundNDVI(recent.MeanNDVI - baseline.MeanNDVI)
+		flagged := woodyChange <= -clearingFlagPts
+		if err := s.db.UpdatePropertyClearing(p.ID, woodyChange, ndviChange, baselineTo.Year(), flagged); err != nil {
+			log.Printf("[%d/%d] Failed to save for property %d: %v", i+1, len(properties), p.ID, err)
+			stats.Failed++
+			continue
+		}
+
+		note := ""
+		if flagged {
+			note = " - flagged for clearing"
+		}
+		log.Printf("[%d/%d] Property %d (%s): woody cover %.0f%% -> %.0f%% (%+.1f pts), NDVI %+.3f%s",
+			i+1, len(properties), p.ID, location(p), baseline.WoodyCover*100, recent.WoodyCover*100, woodyChange, ndviChange, note)
+		s.recomputed(p.ID, db.StepClearing)
+		stats.Success++
+	}
+	return stats, nil
+}
+
+// lotsBBox returns the bounding box of a property's cadastral lots, skipping
+// any that don't parse
+func (s *EnrichmentService) lotsBBox(propertyID int64) (geo.BBox, error) {
+	lots, err := s.db.GetPropertyLots(propertyID)
+	if err != nil {
+		return geo.BBox{}, fmt.Errorf("getting lots: %w", err)
+	}
+
+	var areas []*geo.Area
+	for _, lot := range lots {
+		area, err := geo.ParsePolygon(lot.Geometry)
+		if err != nil {
+			log.Printf("  Warning: Could not parse lot %s: %v", lot.LotIDString, err)
+			continue
+		}
+		areas = append(areas, area)
+	}
+	bbox, ok := geo.AreaBBox(areas...)
+	if !ok {
+		return geo.BBox{}, errors.New("no lot boundaries")
+	}
+	return bbox, nil
+}
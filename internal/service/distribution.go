@@ -0,0 +1,91 @@
+package service
+
+import (
+	"context"
+	"math"
+
+	"farm-search/internal/db"
+	"farm-search/internal/models"
+)
+
+// DistributionFields are the fields Distribution can bucket: land size, and
+// price as the asking range's midpoint
+var DistributionFields = []string{"land_size_sqm", "price"}
+
+// MaxDistributionBuckets caps the buckets a distribution is split into
+const MaxDistributionBuckets = 100
+
+// Distribution buckets field's values over the canonical properties
+// matching f into equal-width buckets between the smallest and largest
+// value, or equal ratios with logScale (for land sizes, which span hectares
+// to thousands). Sorting and pagination in f are ignored.
+func (s *PropertyService) Distribution(ctx context.Context, f db.PropertyFilter, field string, buckets int, logScale bool) (*models.Distribution, error) {
+	f.Sort, f.Limit, f.Offset = "", 0, 0
+	matches, err := s.List(ctx, f)
+	if err != nil {
+		return nil, err
+	}
+	inputs, err := s.db.GetScoringInputs()
+	if err != nil {
+		return nil, err
+	}
+	fieldValues := make(map[int64]float64, len(inputs))
+	for _, in := range inputs {
+		v := in.LandSizeSqm
+		if field == "price" {
+			v = in.PriceMid
+		}
+		if v != nil && (!logScale || *v > 0) {
+			fieldValues[in.PropertyID] = *v
+		}
+	}
+
+	d := &models.Distribution{Field: field, Scale: "linear", Buckets: []models.DistributionBucket{}}
+	if logScale {
+		d.Scale = "log"
+	}
+	var values []float64
+	for _, m := range matches {
+		if v, ok := fieldValues[m.ID]; ok {
+			values = append(values, v)
+		} else {
+			d.Missing++
+		}
+	}
+	d.Count = len(values)
+	if len(values) == 0 {
+		return d, nil
+	}
+
+	lo, hi := values[0], values[0]
+	for _, v := range values {
+		lo, hi = math.Min(lo, v), math.Max(hi, v)
+	}
+	d.Min, d.Max = &lo, &hi
+	if lo == hi {
+		buckets = 1
+	}
+
+	// Bucket on the log of the values for a log scale
+	scale, unscale := func(v float64) float64 { return v }, func(v float64) float64 { return v }
+	if logScale {
+		scale, unscale = math.Log, math.Exp
+	}
+	start, width := scale(lo), (scale(hi)-scale(lo))/float64(buckets)
+	for i := 0; i < buckets; i++ {
+		d.Buckets = append(d.Buckets, models.DistributionBucket{
+			Min: unscale(start + float64(i)*width),
+			Max: unscale(start + float64(i+1)*width),
+		})
+	}
+	// Exact ends, rather than rounded through the scale
+	d.Buckets[0].Min, d.Buckets[buckets-1].Max = lo, hi
+	for _, v := range values {
+		i := buckets - 1
+		if width > 0 {
+			i = min(int((scale(v)-start)/width), buckets-1)
+		}
+		d.Buckets[i].Count++
+	}
+	return d, nil
+}
@@ -0,0 +1,84 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"farm-search/internal/geo"
+	"farm-search/internal/models"
+)
+
+// DriveTimeGridOptions is the area and resolution of a drive time grid
+type DriveTimeGridOptions struct {
+	SWLat, SWLng, NELat, NELng float64
+	SpacingKm                  float64
+	// Points further than this from the anchor in a straight line are
+	// skipped (0 for no limit). Valhalla rejects matrix requests over its
+	// max_matrix_distance, 400 km by default.
+	MaxKm float64
+	// Points per matrix request
+	BatchSize int
+}
+
+// DriveTimeGrid computes drive times to the anchor from the centre of every
+// cell of a grid, with Valhalla matrix requests, and replaces the stored
+// grid with them. Cells without a route, or whose route is shorter than the
+// straight line (snapped to the wrong road), count as failed and aren't
+// stored. Nothing is saved if Valhalla goes down part way or nothing routes.
+func (s *EnrichmentService) DriveTimeGrid(ctx context.Context, opts DriveTimeGridOptions) (EnrichmentStats, error) {
+	grid := geo.NewGrid(opts.SWLat, opts.SWLng, opts.NELat, opts.NELng, opts.SpacingKm)
+	var points []geo.MatrixPoint
+	for _, p := range grid.Points {
+		if opts.MaxKm <= 0 || geo.Haversine(p.Lat, p.Lng, s.anchor.Lat, s.anchor.Lng) <= opts.MaxKm {
+			points = append(points, p)
+		}
+	}
+	stats := EnrichmentStats{Total: len(points)}
+	log.Printf("Calculating drive times to %s for %d grid points (%.0f km apart)...", s.anchor.Name, len(points), opts.SpacingKm)
+
+	batchSize := opts.BatchSize
+	if batchSize <= 0 {
+		batchSize = 100
+	}
+	anchor := geo.MatrixPoint{Lat: s.anchor.Lat, Lng: s.anchor.Lng}
+	var cells []models.DriveTimeCell
+	for start := 0; start < len(points); start += batchSize {
+		batch := points[start:min(start+batchSize, len(points))]
+		results, err := s.router.GetDriveTimesTo(ctx, batch, anchor)
+		if errors.Is(err, geo.ErrValhallaUnavailable) {
+			return stats, err
+		}
+		if err != nil {
+			log.Printf("[%d/%d] Matrix request failed: %v", start+len(batch), len(points), err)
+			stats.Failed += len(batch)
+			continue
+		}
+
+		for i, result := range results {
+			p := batch[i]
+			if result == nil || result.DistanceKm < geo.Haversine(p.Lat, p.Lng, anchor.Lat, anchor.Lng)*minRoadRatio {
+				stats.Failed++
+				continue
+			}
+			cells = append(cells, models.DriveTimeCell{
+				Lat:           p.Lat,
+				Lng:           p.Lng,
+				LatStep:       grid.LatStep,
+				LngStep:       grid.LngStep,
+				DriveTimeMins: int(result.DurationMins + 0.5),
+				DistanceKm:    result.DistanceKm,
+			})
+			stats.Success++
+		}
+		log.Printf("[%d/%d] %d routed so far", start+len(batch), len(points), stats.Success)
+	}
+
+	if len(cells) == 0 {
+		return stats, errors.New("no grid points could be routed; the stored grid is unchanged")
+	}
+	if _, err := s.db.ReplaceDriveTimeGrid(cells); err != nil {
+		return stats, err
+	}
+	return stats, nil
+}
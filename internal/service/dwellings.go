@@ -0,0 +1,173 @@
+package service
+
+import (
+	"database/sql"
+	"html"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"farm-search/internal/db"
+)
+
+// secondaryDwellingPatterns find the kinds of dwelling a listing can have
+// besides its main house, each counted once however often it's mentioned.
+// They're matched in order with earlier matches blanked out, so a
+// "self-contained granny flat" or "second manager's cottage" is one dwelling.
+var secondaryDwellingPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)\bgrann(?:y|ie) flats?\b`),
+	regexp.MustCompile(`(?i)\bmanagers?(?:'s?)? (?:residence|cottage|house|home|quarters)\b`),
+	regexp.MustCompile(`(?i)\b(?:workers?(?:'s?)?|staff|shearers?(?:'s?)?) (?:cottage|house|home|residence|accommodation|quarters)\b`),
+	regexp.MustCompile(`(?i)\bguest (?:cottage|house|home|residence|quarters)\b`),
+	regexp.MustCompile(`(?i)\bself[- ]contained (?:[\w-]+ ){0,2}(?:cottage|studio|unit|apartment|cabin|flat)\b`),
+	regexp.MustCompile(`(?i)\b(?:second|secondary|additional|separate|2nd)[ ,]+(?:[\w-]+ ){0,3}(?:home|house|dwelling|residence|cottage|homestead)\b`),
+}
+
+// relativeToHouse matches a secondary dwelling pattern's match that's about
+// the main house: "separate from the house", "additional to the home"
+var relativeToHouse = regexp.MustCompile(`(?i) (?:from|to|of) `)
+
+// dwellingCountPattern finds a listing saying how many dwellings it has:
+// "two homes", "3 x residences", "not one but two houses"
+var dwellingCountPattern = regexp.MustCompile(`(?i)\b(two|three|four|five|six|[2-9])\s*(?:x\s*)?((?:[\w-]+ ){0,2})(?:homes|houses|dwellings|residences|homesteads|cottages)\b`)
+
+// onlyBefore matches "only" before a count, usually of the houses in a
+// community title estate ("only 9 houses share the common land")
+var onlyBefore = regexp.MustCompile(`(?i)\bonly\s*$`)
+
+// dualOccupancyPattern is a listing with two dwellings by another name
+var dualOccupancyPattern = regexp.MustCompile(`(?i)\bdual[- ](?:occupancy|occupancies|living|residence|residences|dwellings?)\b`)
+
+// mainDwellingPattern is a listing mentioning a house of any kind
+var mainDwellingPattern = regexp.MustCompile(`(?i)\b(?:homestead|farmhouse|farm house|residence|house|cottage|dwelling)\b`)
+
+// dwellingCountWords are the counts dwellingCountPattern matches as words
+var dwellingCountWords = map[string]int{"two": 2, "three": 3, "four": 4, "five": 5, "six": 6}
+
+// hypotheticalDwelling matches the words before a dwelling that make it one
+// the land could have rather than has: "potential for a granny flat",
+// "approved DA for a second dwelling", "build your dream home"
+var hypotheticalDwelling = regexp.MustCompile(`(?i)\b(?:potential|possib\w*|opportunit\w*|option|scope|could|would|suitable for|ideal for|room for|space for|conver(?:t|sion)\w*|build|future|plans? for|da|approval|permit\w*|permissible|consent|allow\w*|zon\w*|stca|subject to|eligib\w*|entitle\w*)\s*(?:[\w'-]+\W+){0,6}$`)
+
+// hypotheticalAfter matches the words just after a dwelling that make it
+// hypothetical, or a place for one: "dual occupancy (STCA)", "house site"
+var hypotheticalAfter = regexp.MustCompile(`(?i)^\W*(?:\(?stca|subject to|opportunit|potential|approval|entitlement|rights?\b|eligib|permit|site|pad\b|block|plans?\b|envelope)`)
+
+// notAHouse matches the word before "house" in buildings that aren't homes
+var notAHouse = regexp.MustCompile(`(?i)\b(?:pump|hot|green|glass|shearing|wool|meat|chook|hen|dog|tree|boat|bath|club|ware|packing|power|out|store|wash|coach|carriage|school|no|vacant|to)\s*$`)
+
+// estimateDwellings estimates how many dwellings a listing has from its
+// description and bedroom count: the main house (if it has bedrooms or the
+// description mentions one) plus each kind of secondary dwelling described,
+// or the count it states if that's more. Dwellings the land could have
+// ("potential for a granny flat", "dual occupancy STCA") don't count, and
+// neither do two mentions of one kind, so it errs low.
+func estimateDwellings(description string, bedrooms sql.NullInt64) int {
+	text := html.UnescapeString(description)
+
+	secondary := 0
+	blanked := text
+	for _, pattern := range secondaryDwellingPatterns {
+		found := false
+		for _, loc := range pattern.FindAllStringIndex(blanked, -1) {
+			// "separate from the house" is the main house
+			if !hypothetical(blanked, loc) && !relativeToHouse.MatchString(blanked[loc[0]:loc[1]]) {
+				found = true
+			}
+		}
+		if found {
+			secondary++
+		}
+		blanked = pattern.ReplaceAllStringFunc(blanked, func(m string) string { return strings.Repeat(" ", len(m)) })
+	}
+
+	count := 0
+	switch {
+	case secondary > 0:
+		// A granny flat or workers cottage is besides a main house
+		count = 1 + secondary
+	case bedrooms.Valid && bedrooms.Int64 > 0:
+		count = 1
+	default:
+		for _, loc := range mainDwellingPattern.FindAllStringIndex(text, -1) {
+			if !hypothetical(text, loc) && !notAHouse.MatchString(text[:loc[0]]) {
+				count = 1
+				break
+			}
+		}
+	}
+
+	for _, m := range dwellingCountPattern.FindAllStringSubmatchIndex(text, -1) {
+		// "2 bedroom house" and "two storey homes" are one
+		between := strings.ToLower(text[m[4]:m[5]])
+		if strings.Contains(between, "bed") || strings.Contains(between, "bath") || strings.Contains(between, "stor") ||
+			hypothetical(text, m[:2]) || onlyBefore.MatchString(text[:m[0]]) {
+			continue
+		}
+		word := strings.ToLower(text[m[2]:m[3]])
+		n, err := strconv.Atoi(word)
+		if err != nil {
+			n = dwellingCountWords[word]
+		}
+		count = max(count, n)
+	}
+	for _, loc := range dualOccupancyPattern.FindAllStringIndex(text, -1) {
+		if !hypothetical(text, loc) {
+			count = max(count, 2)
+		}
+	}
+	return count
+}
+
+// hypothetical reports whether the dwelling text[loc[0]:loc[1]] is one the
+// land could have rather than has, going by the rest of its sentence
+func hypothetical(text string, loc []int) bool {
+	start := strings.LastIndexAny(text[:loc[0]], ".!?;:\n•*") + 1
+	end := len(text)
+	if i := strings.IndexAny(text[loc[1]:], ".!?;:\n•*"); i >= 0 {
+		end = loc[1] + i
+	}
+	if end-loc[1] > 30 {
+		end = loc[1] + 30
+	}
+	return hypotheticalDwelling.MatchString(text[start:loc[0]]) || hypotheticalAfter.MatchString(text[loc[1]:end])
+}
+
+// Dwellings estimates the number of dwellings each property's listing
+// describes (see estimateDwellings), so multi-generational buyers can find
+// farms with a granny flat, workers cottage or second home. Properties
+// without a description are skipped; it's recomputed when the description
+// or bedroom count changes.
+func (s *EnrichmentService) Dwellings(all bool) (EnrichmentStats, error) {
+	properties, err := s.targets(db.StepDwellings, all, "dwelling counts")
+	stats := EnrichmentStats{Total: len(properties)}
+	if err != nil || len(properties) == 0 {
+		return stats, err
+	}
+
+	log.Printf("Estimating dwellings for %d properties...", len(properties))
+
+	for i, p := range properties {
+		description, bedrooms, err := s.db.GetPropertyListingText(p.ID)
+		if err != nil {
+			log.Printf("[%d/%d] Failed for property %d: %v", i+1, len(properties), p.ID, err)
+			stats.Failed++
+			continue
+		}
+
+		count := estimateDwellings(description, bedrooms)
+		if err := s.db.UpdatePropertyDwellingCount(p.ID, count); err != nil {
+			log.Printf("[%d/%d] Failed to save for property %d: %v", i+1, len(properties), p.ID, err)
+			stats.Failed++
+			continue
+		}
+
+		if count > 1 {
+			log.Printf("[%d/%d] Property %d (%s): %d dwellings", i+1, len(properties), p.ID, location(p), count)
+		}
+		s.recomputed(p.ID, db.StepDwellings)
+		stats.Success++
+	}
+	return stats, nil
+}
@@ -0,0 +1,130 @@
+package service
+
+import (
+	"cmp"
+	"fmt"
+	"log"
+	"slices"
+
+	"farm-search/internal/db"
+	"farm-search/internal/geo"
+	"farm-search/internal/models"
+)
+
+// energyIndex indexes energy developments of one kind for nearest queries
+type energyIndex struct {
+	developments []models.EnergyDevelopment
+	index        *geo.PointIndex
+}
+
+func newEnergyIndex(developments []models.EnergyDevelopment) *energyIndex {
+	if len(developments) == 0 {
+		return nil
+	}
+	return &energyIndex{
+		developments: developments,
+		index: geo.NewPointIndex(len(developments), func(i int) (float64, float64) {
+			return developments[i].Latitude, developments[i].Longitude
+		}),
+	}
+}
+
+// nearest returns the development nearest a point, or nil if there are none
+func (e *energyIndex) nearest(lat, lng float64) *models.NearbyDevelopment {
+	if e == nil {
+		return nil
+	}
+	neighbors := e.index.Nearest(lat, lng, 1)
+	if len(neighbors) == 0 {
+		return nil
+	}
+	d := e.developments[neighbors[0].Index]
+	return &models.NearbyDevelopment{Name: d.Name, Status: d.Status, DistanceKm: neighbors[0].DistanceKm}
+}
+
+// EnergyDevelopments saves each property's nearest wind and solar farm,
+// operating or planned, by straight-line distance. Only applies once
+// developments have been imported with `tools energy`.
+func (s *EnrichmentService) EnergyDevelopments(all bool) (EnrichmentStats, error) {
+	properties, err := s.targets(db.StepEnergyDevelopments, all, "energy development proximity")
+	stats := EnrichmentStats{Total: len(properties)}
+	if err != nil || len(properties) == 0 {
+		return stats, err
+	}
+
+	developments, err := s.db.GetEnergyDevelopments()
+	if err != nil {
+		return stats, err
+	}
+	byKind := make(map[string][]models.EnergyDevelopment)
+	for _, d := range developments {
+		byKind[d.Kind] = append(byKind[d.Kind], d)
+	}
+	wind, solar := newEnergyIndex(byKind[geo.EnergyWind]), newEnergyIndex(byKind[geo.EnergySolar])
+
+	log.Printf("Finding nearest of %d wind and %d solar farms for %d properties...",
+		len(byKind[geo.EnergyWind]), len(byKind[geo.EnergySolar]), len(properties))
+
+	for i, p := range properties {
+		nearestWind, nearestSolar := wind.nearest(p.Latitude, p.Longitude), solar.nearest(p.Latitude, p.Longitude)
+
+		if err := s.db.UpdatePropertyEnergyDevelopments(p.ID, nearestWind, nearestSolar); err != nil {
+			log.Printf("[%d/%d] Failed to save for property %d: %v", i+1, len(properties), p.ID, err)
+			stats.Failed++
+			continue
+		}
+
+		log.Printf("[%d/%d] Property %d (%s): wind %s, solar %s",
+			i+1, len(properties), p.ID, p.Suburb, developmentString(nearestWind), developmentString(nearestSolar))
+		s.recomputed(p.ID, db.StepEnergyDevelopments)
+		stats.Success++
+	}
+	return stats, nil
+}
+
+// developmentString formats a nearby development for logs
+func developmentString(d *models.NearbyDevelopment) string {
+	if d == nil {
+		return "none"
+	}
+	return fmt.Sprintf("%s (%s, %.1f km)", d.Name, d.Status, d.DistanceKm)
+}
+
+// MarkEnergyDevelopmentsChanged is MarkChangedInputs for the imported energy
+// developments alone, for right after an import
+func (s *EnrichmentService) MarkEnergyDevelopmentsChanged() error {
+	input, err := s.energyDevelopmentsInput()
+	if err != nil || input == nil {
+		return err
+	}
+	return s.markIfChanged(*input)
+}
+
+// energyDevelopmentsInput returns the imported developments as an enrichment
+// input, so a reimport that changes them recomputes every property. Nil if
+// none have been imported.
+func (s *EnrichmentService) energyDevelopmentsInput() (*enrichmentInput, error) {
+	developments, err := s.db.GetEnergyDevelopments()
+	if err != nil {
+		return nil, err
+	}
+	if len(developments) == 0 {
+		return nil, nil
+	}
+
+	// Only what's saved on properties, so reimporting the same file doesn't count
+	type fingerprinted struct {
+		Name, Kind, Status string
+		Lat, Lng           float64
+	}
+	value := make([]fingerprinted, len(developments))
+	for i, d := range developments {
+		value[i] = fingerprinted{d.Name, d.Kind, d.Status, d.Latitude, d.Longitude}
+	}
+	// Sorted, as reimporting one source moves its rows after the others'
+	slices.SortFunc(value, func(a, b fingerprinted) int {
+		return cmp.Or(cmp.Compare(a.Kind, b.Kind), cmp.Compare(a.Name, b.Name),
+			cmp.Compare(a.Lat, b.Lat), cmp.Compare(a.Lng, b.Lng), cmp.Compare(a.Status, b.Status))
+	})
+	return &enrichmentInput{"energy_developments", value, []db.EnrichmentStep{db.StepEnergyDevelopments}}, nil
+}
@@ -0,0 +1,714 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"farm-search/internal/db"
+	"farm-search/internal/events"
+	"farm-search/internal/geo"
+	"farm-search/internal/models"
+)
+
+// EnrichmentStats counts the properties an enrichment step updated or failed on
+type EnrichmentStats struct {
+	Total   int
+	Success int
+	Failed  int
+}
+
+// EnrichmentService fills in derived property columns (drive times, nearest
+// towns, schools and energy developments, noise source distances,
+// biosecurity regions, imagery links, cadastral lots and their heritage
+// listings, minimum lot size, overlay coverage, fire history, vegetation
+// change, NDVI and buildings), logging progress per property. Each step only
+// needs some dependencies; the rest may be nil.
+type EnrichmentService struct {
+	db         *db.DB
+	router     *geo.Router
+	schools    *geo.SchoolData
+	cadastral  *geo.CadastralClient
+	heritage   *geo.HeritageClient
+	lotSize    *geo.LotSizeClient
+	vegetation geo.VegetationSource
+	anchor     geo.Anchor // Primary drive times are to this
+	propertyID int64      // Only enrich this property, if set
+
+	events   events.Publisher              // Told which properties each Refresh enriched, if set
+	enriched map[int64][]db.EnrichmentStep // The steps a Refresh with events recomputed, per property
+}
+
+// NewEnrichmentService creates a new EnrichmentService, measuring primary
+// drive times to Sutherland until WithAnchor says otherwise
+func NewEnrichmentService(database *db.DB, router *geo.Router, schools *geo.SchoolData, cadastral *geo.CadastralClient) *EnrichmentService {
+	return &EnrichmentService{db: database, router: router, schools: schools, cadastral: cadastral, anchor: geo.Sutherland}
+}
+
+// WithAnchor returns a copy of the service that measures primary drive times
+// (and the drive time grid) to anchor
+func (s *EnrichmentService) WithAnchor(anchor geo.Anchor) *EnrichmentService {
+	scoped := *s
+	scoped.anchor = anchor
+	return &scoped
+}
+
+// ForProperty returns a copy of the service whose steps only enrich one property
+func (s *EnrichmentService) ForProperty(id int64) *EnrichmentService {
+	scoped := *s
+	scoped.propertyID = id
+	return &scoped
+}
+
+// targets loads the properties for step, logging if there are none
+func (s *EnrichmentService) targets(step db.EnrichmentStep, all bool, what string) ([]models.EnrichmentTarget, error) {
+	properties, err := s.db.GetPropertiesToEnrich(step, all, s.propertyID)
+	if err != nil {
+		return nil, err
+	}
+	if len(properties) == 0 {
+		log.Printf("No properties need %s", what)
+	}
+	return properties, nil
+}
+
+// recomputed clears a step's stale mark once it's been saved for a property
+func (s *EnrichmentService) recomputed(id int64, step db.EnrichmentStep) {
+	if err := s.db.ClearStaleStep(id, step); err != nil {
+		log.Printf("Failed to clear stale %s for property %d: %v", step, id, err)
+	}
+	if s.enriched != nil {
+		s.enriched[id] = append(s.enriched[id], step)
+	}
+}
+
+// A route is held for review rather than saved if its road distance is more
+// than MaxDetourRatio times the straight-line distance (and over minDetourKm
+// longer, as short trips around a river or range can legitimately be far
+// longer), or much shorter than the straight line, which no road can be.
+// Either usually means Valhalla snapped an end to the wrong road.
+const (
+	MaxDetourRatio = 2.5
+	minDetourKm    = 10.0
+	minRoadRatio   = 0.8
+)
+
+// errRouteHeld is returned for a route queued in route_reviews instead of saved
+var errRouteHeld = errors.New("implausible route held for review")
+
+// checkRoute returns errRouteHeld, queueing the route for review, if its road
+// distance is implausible next to the straight-line distance and a person
+// hasn't already accepted it
+func (s *EnrichmentService) checkRoute(p models.EnrichmentTarget, targetType, targetName string, lat, lng, roadKm float64, mins int) error {
+	straightKm := geo.Haversine(p.OriginLat, p.OriginLng, lat, lng)
+	if roadKm >= straightKm*minRoadRatio && (roadKm <= straightKm*MaxDetourRatio || roadKm-straightKm <= minDetourKm) {
+		return nil
+	}
+
+	accepted, err := s.db.IsRouteAccepted(p.ID, targetType, targetName, roadKm)
+	if err != nil {
+		return err
+	}
+	if accepted {
+		return nil
+	}
+
+	ratio := 0.0
+	if straightKm > 0 {
+		ratio = roadKm / straightKm
+	}
+	if err := s.db.FlagRouteReview(&models.RouteReview{
+		PropertyID:    p.ID,
+		TargetType:    targetType,
+		TargetName:    targetName,
+		StraightKm:    straightKm,
+		RoadKm:        roadKm,
+		Ratio:         ratio,
+		DriveTimeMins: mins,
+	}); err != nil {
+		return err
+	}
+	return fmt.Errorf("%w: %.1f km by road, %.1f km straight", errRouteHeld, roadKm, straightKm)
+}
+
+// driveMins returns the drive time in whole minutes from a property's access
+// point (or its listing point without one) to a target, if the route passes
+// checkRoute
+func (s *EnrichmentService) driveMins(ctx context.Context, p models.EnrichmentTarget, targetType, targetName string, lat, lng float64) (int, error) {
+	result, err := s.router.GetRoute(ctx, p.OriginLat, p.OriginLng, lat, lng)
+	if err != nil {
+		return 0, err
+	}
+	mins := int(result.DurationMins + 0.5)
+	if err := s.checkRoute(p, targetType, targetName, lat, lng, result.DistanceKm, mins); err != nil {
+		return 0, err
+	}
+	return mins, nil
+}
+
+// location returns a property's address for logging, or its suburb if it has none
+func location(p models.EnrichmentTarget) string {
+	if p.Address != "" {
+		return p.Address
+	}
+	return p.Suburb
+}
+
+// minsString formats an optional drive time for logging
+func minsString(mins *int) string {
+	if mins == nil {
+		return "N/A"
+	}
+	return fmt.Sprintf("%d min", *mins)
+}
+
+// DriveTimesToAnchor saves each property's drive time to the anchor. Needs a
+// router; stops with an ErrValhallaUnavailable error if Valhalla goes down.
+func (s *EnrichmentService) DriveTimesToAnchor(ctx context.Context, all bool) (EnrichmentStats, error) {
+	properties, err := s.targets(db.StepDriveTimePrimary, all, "drive time calculation")
+	stats := EnrichmentStats{Total: len(properties)}
+	if err != nil || len(properties) == 0 {
+		return stats, err
+	}
+
+	log.Printf("Calculating drive times for %d properties to %s...", len(properties), s.anchor.Name)
+	log.Printf("%s coordinates: %.4f, %.4f", s.anchor.Name, s.anchor.Lat, s.anchor.Lng)
+
+	for i, p := range properties {
+		result, err := s.router.GetDriveTime(ctx, p.OriginLat, p.OriginLng, s.anchor)
+		if errors.Is(err, geo.ErrValhallaUnavailable) {
+			return stats, err // Every other property would fail too
+		}
+		if err != nil {
+			log.Printf("[%d/%d] Failed for property %d (%s, %s): %v",
+				i+1, len(properties), p.ID, p.Address, p.Suburb, err)
+			stats.Failed++
+			continue
+		}
+
+		// Round to nearest minute
+		driveTimeMins := int(result.DurationMins + 0.5)
+
+		if err := s.checkRoute(p, db.RouteTargetAnchor, s.anchor.Name, s.anchor.Lat, s.anchor.Lng,
+			result.DistanceKm, driveTimeMins); err != nil {
+			log.Printf("[%d/%d] Not saved for property %d (%s, %s): %v",
+				i+1, len(properties), p.ID, p.Address, p.Suburb, err)
+			stats.Failed++
+			continue
+		}
+
+		// Save immediately so an interrupted run keeps its progress
+		if err := s.db.UpdatePropertyDriveTime(p.ID, driveTimeMins); err != nil {
+			log.Printf("[%d/%d] Failed to save drive time for property %d: %v",
+				i+1, len(properties), p.ID, err)
+			stats.Failed++
+			continue
+		}
+
+		log.Printf("[%d/%d] Property %d (%s): %d mins (%.1f km)",
+			i+1, len(properties), p.ID, location(p), driveTimeMins, result.DistanceKm)
+		s.recomputed(p.ID, db.StepDriveTimePrimary)
+		stats.Success++
+	}
+	return stats, nil
+}
+
+// NearestTowns saves each property's two nearest towns by straight-line distance
+func (s *EnrichmentService) NearestTowns(all bool) (EnrichmentStats, error) {
+	properties, err := s.targets(db.StepNearestTowns, all, "nearest town calculation")
+	stats := EnrichmentStats{Total: len(properties)}
+	if err != nil || len(properties) == 0 {
+		return stats, err
+	}
+
+	log.Printf("Calculating nearest towns for %d properties using %d towns...", len(properties), len(geo.NSWTowns))
+
+	for i, p := range properties {
+		town1, town2 := geo.FindTwoNearestTowns(p.Latitude, p.Longitude)
+
+		err := s.db.UpdatePropertyNearestTowns(p.ID,
+			models.NearbyPlace{Name: town1.Name, DistanceKm: town1.DistanceKm},
+			models.NearbyPlace{Name: town2.Name, DistanceKm: town2.DistanceKm})
+		if err != nil {
+			log.Printf("[%d/%d] Failed to save for property %d: %v", i+1, len(properties), p.ID, err)
+			stats.Failed++
+			continue
+		}
+
+		log.Printf("[%d/%d] Property %d (%s): %s (%.1f km), %s (%.1f km)",
+			i+1, len(properties), p.ID, p.Suburb,
+			town1.Name, town1.DistanceKm, town2.Name, town2.DistanceKm)
+		s.recomputed(p.ID, db.StepNearestTowns)
+		stats.Success++
+	}
+	return stats, nil
+}
+
+// TownDriveTimes saves drive times to each property's nearest towns, which
+// NearestTowns must have found first. Needs a router; stops like
+// DriveTimesToAnchor if Valhalla goes down.
+func (s *EnrichmentService) TownDriveTimes(ctx context.Context, all bool) (EnrichmentStats, error) {
+	properties, err := s.targets(db.StepTownDriveTimes, all, "town drive time calculation")
+	stats := EnrichmentStats{Total: len(properties)}
+	if err != nil || len(properties) == 0 {
+		return stats, err
+	}
+
+	townCoords := make(map[string]geo.Location)
+	for _, town := range geo.NSWTowns {
+		townCoords[town.Name] = town
+	}
+
+	log.Printf("Calculating drive times to nearest towns for %d properties...", len(properties))
+
+	for i, p := range properties {
+		var town1Mins, town2Mins *int
+
+		if town1, ok := townCoords[p.NearestTown1]; ok {
+			mins, err := s.driveMins(ctx, p, db.RouteTargetTown, town1.Name, town1.Latitude, town1.Longitude)
+			if errors.Is(err, geo.ErrValhallaUnavailable) {
+				return stats, err
+			}
+			if err != nil {
+				log.Printf("[%d/%d] Failed route to %s for property %d: %v",
+					i+1, len(properties), p.NearestTown1, p.ID, err)
+				stats.Failed++
+				continue
+			}
+			town1Mins = &mins
+		}
+
+		if town2, ok := townCoords[p.NearestTown2]; ok {
+			mins, err := s.driveMins(ctx, p, db.RouteTargetTown, town2.Name, town2.Latitude, town2.Longitude)
+			if errors.Is(err, geo.ErrValhallaUnavailable) {
+				return stats, err
+			}
+			if err != nil {
+				log.Printf("[%d/%d] Failed route to %s for property %d: %v",
+					i+1, len(properties), p.NearestTown2, p.ID, err)
+				// Continue anyway, we at least have town 1
+			} else {
+				town2Mins = &mins
+			}
+		}
+
+		if err := s.db.UpdatePropertyTownDriveTimes(p.ID, town1Mins, town2Mins); err != nil {
+			log.Printf("[%d/%d] Failed to save for property %d: %v", i+1, len(properties), p.ID, err)
+			stats.Failed++
+			continue
+		}
+
+		log.Printf("[%d/%d] Property %d (%s): %s (%s), %s (%s)",
+			i+1, len(properties), p.ID, p.Suburb,
+			p.NearestTown1, minsString(town1Mins), p.NearestTown2, minsString(town2Mins))
+		s.recomputed(p.ID, db.StepTownDriveTimes)
+		stats.Success++
+	}
+	return stats, nil
+}
+
+// NearestSchools saves each property's two nearest schools by straight-line
+// distance. Needs loaded school data.
+func (s *EnrichmentService) NearestSchools(all bool) (EnrichmentStats, error) {
+	properties, err := s.targets(db.StepNearestSchools, all, "nearest school calculation")
+	stats := EnrichmentStats{Total: len(properties)}
+	if err != nil || len(properties) == 0 {
+		return stats, err
+	}
+
+	log.Printf("Calculating nearest schools for %d properties...", len(properties))
+
+	for i, p := range properties {
+		school1, school2 := s.schools.FindTwoNearestSchools(p.Latitude, p.Longitude)
+
+		err := s.db.UpdatePropertyNearestSchools(p.ID, nearbySchool(school1), nearbySchool(school2))
+		if err != nil {
+			log.Printf("[%d/%d] Failed to save for property %d: %v", i+1, len(properties), p.ID, err)
+			stats.Failed++
+			continue
+		}
+
+		log.Printf("[%d/%d] Property %d (%s): %s (%.1f km), %s (%.1f km)",
+			i+1, len(properties), p.ID, p.Suburb,
+			school1.Name, school1.DistanceKm, school2.Name, school2.DistanceKm)
+		s.recomputed(p.ID, db.StepNearestSchools)
+		stats.Success++
+	}
+	return stats, nil
+}
+
+// SaveSchools stores the loaded schools as 'school' amenities for the nearby
+// endpoint, which can't download them per request. Skipped if none loaded,
+// as that means the download failed.
+func (s *EnrichmentService) SaveSchools() (int, error) {
+	if s.schools == nil || len(s.schools.Schools) == 0 {
+		return 0, nil
+	}
+
+	amenities := make([]models.Amenity, len(s.schools.Schools))
+	for i, school := range s.schools.Schools {
+		var detail []string
+		for _, part := range []string{school.Type, school.Suburb} {
+			if part != "" {
+				detail = append(detail, part)
+			}
+		}
+		amenities[i] = models.Amenity{
+			Name:      school.Name,
+			Detail:    sql.NullString{String: strings.Join(detail, ", "), Valid: len(detail) > 0},
+			Latitude:  school.Latitude,
+			Longitude: school.Longitude,
+		}
+	}
+	return s.db.ReplaceAmenities(AmenitySchool, "nsw-education", amenities)
+}
+
+func nearbySchool(s geo.NearestSchoolResult) models.NearbyPlace {
+	return models.NearbyPlace{Name: s.Name, DistanceKm: s.DistanceKm, Latitude: s.Latitude, Longitude: s.Longitude}
+}
+
+// SchoolDriveTimes saves drive times to each property's nearest schools, which
+// NearestSchools must have found first. Needs a router and loaded school
+// data; stops like DriveTimesToAnchor if Valhalla goes down.
+func (s *EnrichmentService) SchoolDriveTimes(ctx context.Context, all bool) (EnrichmentStats, error) {
+	properties, err := s.targets(db.StepSchoolDriveTimes, all, "school drive time calculation")
+	stats := EnrichmentStats{Total: len(properties)}
+	if err != nil || len(properties) == 0 {
+		return stats, err
+	}
+
+	schoolCoords := make(map[string]geo.School)
+	for _, school := range s.schools.Schools {
+		schoolCoords[school.Name] = school
+	}
+
+	log.Printf("Calculating drive times to nearest schools for %d properties...", len(properties))
+
+	for i, p := range properties {
+		var school1Mins, school2Mins *int
+		var school1Loc, school2Loc *models.NearbyPlace
+
+		if school1, ok := schoolCoords[p.NearestSchool1]; ok {
+			mins, err := s.driveMins(ctx, p, db.RouteTargetSchool, school1.Name, school1.Latitude, school1.Longitude)
+			if errors.Is(err, geo.ErrValhallaUnavailable) {
+				return stats, err
+			}
+			if err != nil {
+				log.Printf("[%d/%d] Failed route to %s for property %d: %v",
+					i+1, len(properties), p.NearestSchool1, p.ID, err)
+				stats.Failed++
+				continue
+			}
+			school1Mins = &mins
+			school1Loc = &models.NearbyPlace{Name: school1.Name, Latitude: school1.Latitude, Longitude: school1.Longitude}
+		}
+
+		if school2, ok := schoolCoords[p.NearestSchool2]; ok {
+			school2Loc = &models.NearbyPlace{Name: school2.Name, Latitude: school2.Latitude, Longitude: school2.Longitude}
+			mins, err := s.driveMins(ctx, p, db.RouteTargetSchool, school2.Name, school2.Latitude, school2.Longitude)
+			if errors.Is(err, geo.ErrValhallaUnavailable) {
+				return stats, err
+			}
+			if err != nil {
+				log.Printf("[%d/%d] Failed route to %s for property %d: %v",
+					i+1, len(properties), p.NearestSchool2, p.ID, err)
+				// Continue anyway, we at least have school 1
+			} else {
+				school2Mins = &mins
+			}
+		}
+
+		// Also saves the schools' coordinates for routing
+		if err := s.db.UpdatePropertySchoolDriveTimes(p.ID, school1Mins, school2Mins, school1Loc, school2Loc); err != nil {
+			log.Printf("[%d/%d] Failed to save for property %d: %v", i+1, len(properties), p.ID, err)
+			stats.Failed++
+			continue
+		}
+
+		log.Printf("[%d/%d] Property %d (%s): %s (%s), %s (%s)",
+			i+1, len(properties), p.ID, p.Suburb,
+			p.NearestSchool1, minsString(school1Mins), p.NearestSchool2, minsString(school2Mins))
+		s.recomputed(p.ID, db.StepSchoolDriveTimes)
+		stats.Success++
+	}
+	return stats, nil
+}
+
+// CadastralLots fetches the NSW cadastral lots at each property's coordinates
+// and links them to it. Needs a cadastral client.
+func (s *EnrichmentService) CadastralLots(ctx context.Context, all bool) (EnrichmentStats, error) {
+	properties, err := s.targets(db.StepCadastralLots, all, "cadastral lot lookup")
+	stats := EnrichmentStats{Total: len(properties)}
+	if err != nil || len(properties) == 0 {
+		return stats, err
+	}
+
+	log.Printf("Fetching cadastral lots for %d properties...", len(properties))
+
+	for i, p := range properties {
+		lots, err := s.cadastral.FetchLotsAtPoint(ctx, p.Longitude, p.Latitude)
+		if err != nil {
+			log.Printf("[%d/%d] Failed for property %d (%s): %v",
+				i+1, len(properties), p.ID, p.Suburb, err)
+			stats.Failed++
+			time.Sleep(500 * time.Millisecond) // Rate limiting
+			continue
+		}
+
+		if len(lots) == 0 {
+			log.Printf("[%d/%d] Property %d (%s): No lots found",
+				i+1, len(properties), p.ID, p.Suburb)
+			stats.Failed++
+			time.Sleep(500 * time.Millisecond)
+			continue
+		}
+
+		// Replace the links rather than adding to them, as the property may have moved
+		if err := s.db.UnlinkPropertyLots(p.ID); err != nil {
+			log.Printf("[%d/%d] Failed to unlink old lots for property %d: %v", i+1, len(properties), p.ID, err)
+			stats.Failed++
+			continue
+		}
+
+		for _, lot := range lots {
+			centroidLat, centroidLng, err := geo.CalculateLotCentroid(lot.Geometry)
+			if err != nil {
+				log.Printf("  Warning: Could not calculate centroid for lot %s: %v", lot.LotIDString, err)
+				centroidLat, centroidLng = p.Latitude, p.Longitude // Use property coords as fallback
+			}
+
+			geomJSON, err := geo.LotGeometryToJSON(lot.Geometry)
+			if err != nil {
+				log.Printf("  Warning: Could not serialize geometry for lot %s: %v", lot.LotIDString, err)
+				continue
+			}
+
+			lotID, err := s.db.SaveCadastralLot(
+				lot.LotIDString,
+				lot.LotNumber,
+				lot.PlanLabel,
+				lot.AreaSqm,
+				centroidLat,
+				centroidLng,
+				geomJSON,
+			)
+			if err != nil {
+				log.Printf("  Warning: Could not save lot %s: %v", lot.LotIDString, err)
+				continue
+			}
+
+			if err := s.db.LinkPropertyToLot(p.ID, lotID); err != nil {
+				log.Printf("  Warning: Could not link property %d to lot %d: %v", p.ID, lotID, err)
+				continue
+			}
+		}
+
+		log.Printf("[%d/%d] Property %d (%s): Found %d lots",
+			i+1, len(properties), p.ID, location(p), len(lots))
+		s.recomputed(p.ID, db.StepCadastralLots)
+		stats.Success++
+
+		// Rate limiting to avoid overloading NSW Spatial Services
+		time.Sleep(500 * time.Millisecond)
+	}
+	return stats, nil
+}
+
+// StepResult is the outcome of one step of a Refresh
+type StepResult struct {
+	Step    db.EnrichmentStep
+	Stats   EnrichmentStats
+	Skipped bool // The service lacks a dependency the step needs
+	Err     error
+}
+
+// Refresh runs each step the service has the dependencies for, in dependency
+// order, on the properties missing it or marked stale, then retotals the land
+// value of properties whose lots changed. With events, an enrichment.completed
+// is published for each property a step was recomputed for.
+func (s *EnrichmentService) Refresh(ctx context.Context) []StepResult {
+	if s.events != nil {
+		// A record of this run's own, so concurrent refreshes don't report
+		// each other's properties
+		scoped := *s
+		scoped.enriched = make(map[int64][]db.EnrichmentStep)
+		s = &scoped
+	}
+
+	steps := []struct {
+		name db.EnrichmentStep
+		run  func() (EnrichmentStats, error)
+		ok   bool
+	}{
+		// Lots come first for the access point drive times are routed from
+		{db.StepCadastralLots, func() (EnrichmentStats, error) { return s.CadastralLots(ctx, false) }, s.cadastral != nil},
+		{db.StepAccessPoint, func() (EnrichmentStats, error) { return s.AccessPoints(ctx, false) }, s.router != nil},
+		{db.StepDriveTimePrimary, func() (EnrichmentStats, error) { return s.DriveTimesToAnchor(ctx, false) }, s.router != nil},
+		{db.StepNearestTowns, func() (EnrichmentStats, error) { return s.NearestTowns(false) }, true},
+		{db.StepTownDriveTimes, func() (EnrichmentStats, error) { return s.TownDriveTimes(ctx, false) }, s.router != nil},
+		{db.StepTownWalkCycleTimes, func() (EnrichmentStats, error) { return s.TownWalkCycleTimes(ctx, false) }, s.router != nil},
+		{db.StepNearestSchools, func() (EnrichmentStats, error) { return s.NearestSchools(false) }, s.schools != nil},
+		{db.StepSchoolDriveTimes, func() (EnrichmentStats, error) { return s.SchoolDriveTimes(ctx, false) }, s.router != nil && s.schools != nil},
+		{db.StepPOIDriveTimes, func() (EnrichmentStats, error) { return s.POIDriveTimes(ctx, false) }, s.router != nil},
+		{db.StepHeritage, func() (EnrichmentStats, error) { return s.Heritage(ctx, false) }, s.heritage != nil},
+		{db.StepSubdivision, func() (EnrichmentStats, error) { return s.Subdivision(ctx, false) }, s.lotSize != nil},
+		{db.StepEnergyDevelopments, func() (EnrichmentStats, error) { return s.EnergyDevelopments(false) }, true},
+		{db.StepNoiseSources, func() (EnrichmentStats, error) { return s.NoiseDistances(false) }, true},
+		{db.StepOverlays, func() (EnrichmentStats, error) { return s.Overlays(false) }, true},
+		{db.StepBiosecurity, func() (EnrichmentStats, error) { return s.Biosecurity(false) }, true},
+		{db.StepFireHistory, func() (EnrichmentStats, error) { return s.FireHistory(false) }, true},
+		{db.StepBuildings, func() (EnrichmentStats, error) { return s.Buildings(false) }, true},
+		{db.StepDwellings, func() (EnrichmentStats, error) { return s.Dwellings(false) }, true},
+		{db.StepImageryLinks, func() (EnrichmentStats, error) { return s.ImageryLinks(ctx, false) }, s.router != nil},
+		{db.StepClearing, func() (EnrichmentStats, error) { return s.Clearing(ctx, false) }, s.vegetation != nil},
+		{db.StepNDVI, func() (EnrichmentStats, error) { return s.PastureNDVI(ctx, false) }, s.vegetation != nil},
+	}
+
+	var results []StepResult
+	for _, st := range steps {
+		result := StepResult{Step: st.name, Skipped: !st.ok}
+		if st.ok {
+			result.Stats, result.Err = st.run()
+		}
+		results = append(results, result)
+	}
+
+	n, err := s.db.RefreshStaleLandValues(s.propertyID)
+	if err != nil {
+		log.Printf("Failed to refresh land values: %v", err)
+	} else if n > 0 {
+		log.Printf("Refreshed land values for %d properties", n)
+	}
+	s.publishEnriched(ctx)
+	return results
+}
+
+// enrichmentInput is a target steps are computed against, with the steps to
+// recompute for every property when it changes (or, for towns and schools,
+// the properties recheck finds it changed)
+type enrichmentInput struct {
+	name  string
+	value interface{}
+	steps []db.EnrichmentStep
+}
+
+func (s *EnrichmentService) enrichmentInputs() ([]enrichmentInput, error) {
+	inputs := []enrichmentInput{
+		// Only the coordinates, so renaming the anchor doesn't recompute anything
+		{"drive_time_origin", struct{ Lat, Lng float64 }{s.anchor.Lat, s.anchor.Lng}, []db.EnrichmentStep{db.StepDriveTimePrimary}},
+		{"towns", geo.NSWTowns, []db.EnrichmentStep{db.StepNearestTowns, db.StepTownDriveTimes, db.StepTownWalkCycleTimes}},
+	}
+	// An empty list means the download failed, not that every school closed
+	if s.schools != nil && len(s.schools.Schools) > 0 {
+		inputs = append(inputs, enrichmentInput{"schools", s.schools.Schools,
+			[]db.EnrichmentStep{db.StepNearestSchools, db.StepSchoolDriveTimes}})
+	}
+	for _, imported := range []func() (*enrichmentInput, error){s.energyDevelopmentsInput, s.noiseSourcesInput,
+		s.overlaysInput, s.biosecurityInput, s.fireHistoryInput, s.buildingsInput} {
+		input, err := imported()
+		if err != nil {
+			return nil, err
+		}
+		if input != nil {
+			inputs = append(inputs, *input)
+		}
+	}
+	return inputs, nil
+}
+
+// MarkChangedInputs compares the anchor, town list, (if loaded) school list,
+// imported energy developments, noise source and overlay layers, biosecurity
+// areas, fire history and building footprints with those recorded at the last
+// run, marking the steps that depend on any that changed stale for every
+// property (or, for towns and schools, updating the nearest ones that changed
+// and marking their drive times stale). The first run only records them, taking the existing columns as
+// computed from them.
+func (s *EnrichmentService) MarkChangedInputs() error {
+	inputs, err := s.enrichmentInputs()
+	if err != nil {
+		return err
+	}
+	for _, input := range inputs {
+		if err := s.markIfChanged(input); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// markIfChanged marks input's steps stale if its fingerprint differs from the
+// recorded one, then records the new one. Towns and schools are rechecked
+// instead, as a change only affects the properties they're nearest to.
+func (s *EnrichmentService) markIfChanged(input enrichmentInput) error {
+	fingerprint, err := inputFingerprint(input)
+	if err != nil {
+		return err
+	}
+	previous, err := s.db.GetEnrichmentInput(input.name)
+	if err != nil {
+		return err
+	}
+	if previous == fingerprint {
+		return nil
+	}
+	if previous != "" {
+		if recheck := s.recheck(input.name); recheck != nil {
+			stats, err := recheck()
+			if err != nil {
+				return err
+			}
+			log.Printf("%s changed: updated %d of %d properties", input.name, stats.Success, stats.Total)
+		} else {
+			n, err := s.db.MarkStepsStale(input.name, input.steps...)
+			if err != nil {
+				return err
+			}
+			log.Printf("%s changed: marked %v stale for %d properties", input.name, input.steps, n)
+		}
+	}
+	return s.db.SetEnrichmentInput(input.name, fingerprint)
+}
+
+// recordInput records input's fingerprint without marking anything
+func (s *EnrichmentService) recordInput(input enrichmentInput) error {
+	fingerprint, err := inputFingerprint(input)
+	if err != nil {
+		return err
+	}
+	return s.db.SetEnrichmentInput(input.name, fingerprint)
+}
+
+func inputFingerprint(input enrichmentInput) (string, error) {
+	data, err := json.Marshal(input.value)
+	if err != nil {
+		return "", fmt.Errorf("failed to fingerprint %s: %w", input.name, err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// CorrectCoordinates saves manually corrected coordinates for a property,
+// then recomputes the derived columns that clears, as far as the service's
+// dependencies allow. Steps that can't run or fail are left missing for the
+// enrichment tools to fill in. Returns the steps recomputed and those left.
+func (s *EnrichmentService) CorrectCoordinates(ctx context.Context, id int64, lat, lng float64) (done, pending []db.EnrichmentStep, err error) {
+	if err := s.db.SetPropertyCoordinates(id, lat, lng, "manual", 1); err != nil {
+		return nil, nil, err
+	}
+
+	for _, result := range s.ForProperty(id).Refresh(ctx) {
+		if result.Skipped || result.Err != nil || result.Stats.Success == 0 {
+			pending = append(pending, result.Step)
+			continue
+		}
+		done = append(done, result.Step)
+	}
+	return done, pending, nil
+}
@@ -0,0 +1,41 @@
+package service
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"farm-search/internal/events"
+)
+
+// WithEvents returns a copy of the service whose Refresh publishes an
+// enrichment.completed event through publisher for each property it enriched
+func (s *EnrichmentService) WithEvents(publisher events.Publisher) *EnrichmentService {
+	scoped := *s
+	scoped.events = publisher
+	return &scoped
+}
+
+// publishEnriched publishes the properties a Refresh recomputed steps for,
+// with the steps, in ID order
+func (s *EnrichmentService) publishEnriched(ctx context.Context) {
+	if s.events == nil || len(s.enriched) == 0 {
+		return
+	}
+	ids := make([]int64, 0, len(s.enriched))
+	for id := range s.enriched {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	now := time.Now().UTC().Truncate(time.Second)
+	batch := make([]events.Event, 0, len(ids))
+	for _, id := range ids {
+		e := events.Event{Type: events.EnrichmentCompleted, Time: now, PropertyID: id}
+		for _, step := range s.enriched[id] {
+			e.Steps = append(e.Steps, string(step))
+		}
+		batch = append(batch, e)
+	}
+	events.Send(ctx, s.events, batch)
+}
@@ -0,0 +1,122 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"farm-search/internal/db"
+	"farm-search/internal/geo"
+)
+
+// MaxExclusionKm caps the distance of an exclude_near filter
+const MaxExclusionKm = 100
+
+// exclusionLayers keeps exclusion layers' features parsed, reparsing a layer
+// when it's reimported
+type exclusionLayers struct {
+	db *db.DB
+
+	mu     sync.Mutex
+	layers map[string]parsedLayer
+}
+
+type parsedLayer struct {
+	version  string
+	features *geo.Features
+}
+
+func newExclusionLayers(database *db.DB) *exclusionLayers {
+	return &exclusionLayers{db: database, layers: make(map[string]parsedLayer)}
+}
+
+// get returns a layer's parsed features; empty if it hasn't been imported
+func (l *exclusionLayers) get(layer string) (*geo.Features, error) {
+	version, err := l.db.GetExclusionLayerVersion(layer)
+	if err != nil {
+		return nil, err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if parsed, ok := l.layers[layer]; ok && parsed.version == version {
+		return parsed.features, nil
+	}
+
+	features, err := loadExclusionLayer(l.db, layer)
+	if err != nil {
+		return nil, err
+	}
+	l.layers[layer] = parsedLayer{version: version, features: features}
+	return features, nil
+}
+
+// loadExclusionLayer reads and parses an exclusion layer's features
+func loadExclusionLayer(database *db.DB, layer string) (*geo.Features, error) {
+	fc, err := exclusionLayerCollection(database, layer)
+	if err != nil {
+		return nil, err
+	}
+	features, err := geo.NewFeatures(fc)
+	if err != nil {
+		return nil, fmt.Errorf("exclusion layer %s: %w", layer, err)
+	}
+	return features, nil
+}
+
+// exclusionLayerCollection reads an exclusion layer's features as a feature
+// collection
+func exclusionLayerCollection(database *db.DB, layer string) (*geo.GeoJSONFeatureCollection, error) {
+	rows, err := database.GetExclusionFeatures(layer)
+	if err != nil {
+		return nil, err
+	}
+	fc := &geo.GeoJSONFeatureCollection{Features: make([]geo.GeoJSONFeature, 0, len(rows))}
+	for _, row := range rows {
+		var geometry geo.GeoJSONGeometry
+		if err := json.Unmarshal([]byte(row.Geometry), &geometry); err != nil {
+			return nil, fmt.Errorf("failed to parse geometry of %s feature %d: %w", layer, row.ID, err)
+		}
+		fc.Features = append(fc.Features, geo.GeoJSONFeature{Geometry: geometry})
+	}
+	return fc, nil
+}
+
+// SpatialFilter holds the tests of a filter the database can't do: a point
+// must be inside every area (drive time and drawn search areas) and outside
+// every exclusion
+type SpatialFilter struct {
+	areas        []*geo.Area
+	excludeAreas []*geo.Area
+	near         []nearExclusion
+}
+
+type nearExclusion struct {
+	features *geo.Features
+	km       float64
+}
+
+// Empty reports whether the filter has no tests, so every point matches
+func (sf *SpatialFilter) Empty() bool {
+	return len(sf.areas) == 0 && len(sf.excludeAreas) == 0 && len(sf.near) == 0
+}
+
+// Matches reports whether a point passes the filter
+func (sf *SpatialFilter) Matches(lat, lng float64) bool {
+	for _, area := range sf.areas {
+		if !area.Contains(lat, lng) {
+			return false
+		}
+	}
+	for _, area := range sf.excludeAreas {
+		if area.Contains(lat, lng) {
+			return false
+		}
+	}
+	for _, n := range sf.near {
+		if n.features.Near(lat, lng, n.km) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,54 @@
+package service
+
+import (
+	"context"
+
+	"farm-search/internal/db"
+	"farm-search/internal/models"
+)
+
+// Facets counts the canonical properties each filter option would match
+// alongside f's other filters, so option lists can show counts and avoid
+// dead ends. Property types are counted ignoring f's own type filter, as
+// choosing another type widens the results; tags are counted among f's
+// matches, as every chosen tag must be present. Pagination is ignored.
+func (s *PropertyService) Facets(ctx context.Context, f db.PropertyFilter) (*models.FilterFacets, error) {
+	f.Limit, f.Offset, f.Sort = 0, 0, ""
+	spatial, err := s.Spatial(ctx, f)
+	if err != nil {
+		return nil, err
+	}
+	anyType := f
+	anyType.PropertyTypes = nil
+
+	facets := &models.FilterFacets{PropertyTypes: make(map[string]int), Tags: make(map[string]int)}
+	for _, shard := range s.shards() {
+		matches, err := shard.listShard(anyType, spatial)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range matches {
+			if p.PropertyType != "" {
+				facets.PropertyTypes[p.PropertyType]++
+			}
+		}
+
+		if len(f.PropertyTypes) > 0 {
+			if matches, err = shard.listShard(f, spatial); err != nil {
+				return nil, err
+			}
+		}
+		ids := make([]int64, len(matches))
+		for i, p := range matches {
+			ids[i] = p.ID
+		}
+		tags, err := shard.db.CountPropertyTags(ids)
+		if err != nil {
+			return nil, err
+		}
+		for tag, n := range tags {
+			facets.Tags[tag] += n
+		}
+	}
+	return facets, nil
+}
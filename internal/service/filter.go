@@ -0,0 +1,282 @@
+package service
+
+import (
+	"cmp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"farm-search/internal/db"
+	"farm-search/internal/geo"
+)
+
+// ParsePropertyFilter extracts filter parameters from an /api/properties
+// query string, as also stored by saved searches. Invalid values are ignored.
+func ParsePropertyFilter(q map[string][]string) db.PropertyFilter {
+	filter := db.PropertyFilter{}
+
+	get := func(key string) string {
+		if vals, ok := q[key]; ok && len(vals) > 0 {
+			return vals[0]
+		}
+		return ""
+	}
+
+	// Parse price filters
+	if v := get("price_min"); v != "" {
+		if val, err := strconv.ParseInt(v, 10, 64); err == nil {
+			filter.PriceMin = &val
+		}
+	}
+	if v := get("price_max"); v != "" {
+		if val, err := strconv.ParseInt(v, 10, 64); err == nil {
+			filter.PriceMax = &val
+		}
+	}
+
+	// Parse property types; source spellings ("Mixed Farming") are accepted
+	// and filter by their canonical type
+	if v := get("type"); v != "" {
+		for _, t := range strings.Split(v, ",") {
+			if canonical := db.CanonicalPropertyType(t); canonical != "" && !slices.Contains(filter.PropertyTypes, canonical) {
+				filter.PropertyTypes = append(filter.PropertyTypes, canonical)
+			}
+		}
+	}
+
+	// Parse land size filters
+	if v := get("land_size_min"); v != "" {
+		if val, err := strconv.ParseFloat(v, 64); err == nil {
+			filter.LandSizeMin = &val
+		}
+	}
+	if v := get("land_size_max"); v != "" {
+		if val, err := strconv.ParseFloat(v, 64); err == nil {
+			filter.LandSizeMax = &val
+		}
+	}
+
+	// Parse distance filters
+	if v := get("distance_sydney_max"); v != "" {
+		if val, err := strconv.ParseFloat(v, 64); err == nil {
+			filter.DistanceSydneyMax = &val
+		}
+	}
+	if v := get("distance_town_max"); v != "" {
+		if val, err := strconv.ParseFloat(v, 64); err == nil {
+			filter.DistanceTownMax = &val
+		}
+	}
+	// Parse drive time filters
+	// drive_time_sydney_max is the name from before the anchor was configurable
+	if v := cmp.Or(get("drive_time_primary_max"), get("drive_time_sydney_max")); v != "" {
+		if val, err := strconv.Atoi(v); err == nil {
+			filter.DriveTimePrimaryMax = &val
+		}
+	}
+	if v := get("drive_time_town_max"); v != "" {
+		if val, err := strconv.Atoi(v); err == nil {
+			filter.DriveTimeTownMax = &val
+		}
+	}
+	if v := get("drive_time_school_max"); v != "" {
+		if val, err := strconv.Atoi(v); err == nil {
+			filter.DriveTimeSchoolMax = &val
+		}
+	}
+	if v := get("walk_time_town_max"); v != "" {
+		if val, err := strconv.Atoi(v); err == nil {
+			filter.WalkTimeTownMax = &val
+		}
+	}
+	if v := get("cycle_time_town_max"); v != "" {
+		if val, err := strconv.Atoi(v); err == nil {
+			filter.CycleTimeTownMax = &val
+		}
+	}
+	// Drive time to user POIs: the nearest (poi_drive_time_max=45) or named
+	// ones (near_poi=Mum:45,Climbing gym:30)
+	if v := get("poi_drive_time_max"); v != "" {
+		if val, err := strconv.Atoi(v); err == nil {
+			filter.POIDriveTimeMax = &val
+		}
+	}
+	for _, part := range strings.Split(get("near_poi"), ",") {
+		name, mins, ok := strings.Cut(strings.TrimSpace(part), ":")
+		if !ok || strings.TrimSpace(name) == "" {
+			continue
+		}
+		if val, err := strconv.Atoi(mins); err == nil && val > 0 {
+			filter.NearPOIs = append(filter.NearPOIs, db.POIDriveTime{Name: strings.TrimSpace(name), Mins: val})
+		}
+	}
+
+	// Parse drive time area filter (within_minutes of within_lat,within_lng)
+	if v := get("within_lat"); v != "" {
+		if val, err := strconv.ParseFloat(v, 64); err == nil && val >= -90 && val <= 90 {
+			filter.WithinLat = &val
+		}
+	}
+	if v := get("within_lng"); v != "" {
+		if val, err := strconv.ParseFloat(v, 64); err == nil && val >= -180 && val <= 180 {
+			filter.WithinLng = &val
+		}
+	}
+	if v := get("within_minutes"); v != "" {
+		if val, err := strconv.Atoi(v); err == nil && val >= 1 && val <= MaxIsochroneMinutes {
+			filter.WithinMinutes = &val
+		}
+	}
+
+	// Parse minimum distances to wind and solar farms
+	if v := get("wind_farm_min_km"); v != "" {
+		if val, err := strconv.ParseFloat(v, 64); err == nil {
+			filter.WindFarmMinKm = &val
+		}
+	}
+	if v := get("solar_farm_min_km"); v != "" {
+		if val, err := strconv.ParseFloat(v, 64); err == nil {
+			filter.SolarFarmMinKm = &val
+		}
+	}
+
+	// Parse noise source distance ranges
+	for _, r := range []struct {
+		name     string
+		min, max **float64
+	}{
+		{"highway", &filter.HighwayMinKm, &filter.HighwayMaxKm},
+		{"railway", &filter.RailwayMinKm, &filter.RailwayMaxKm},
+		{"runway", &filter.RunwayMinKm, &filter.RunwayMaxKm},
+	} {
+		if val, err := strconv.ParseFloat(get(r.name+"_min_km"), 64); err == nil {
+			*r.min = &val
+		}
+		if val, err := strconv.ParseFloat(get(r.name+"_max_km"), 64); err == nil {
+			*r.max = &val
+		}
+	}
+
+	// Parse planning overlay maximums (percent of the lots' area)
+	if val, err := strconv.ParseFloat(get("koala_habitat_max_pct"), 64); err == nil {
+		filter.KoalaHabitatMaxPct = &val
+	}
+	if val, err := strconv.ParseFloat(get("biodiversity_max_pct"), 64); err == nil {
+		filter.BiodiversityMaxPct = &val
+	}
+
+	// Parse vegetation limits: the clearing flag, and NDVI mean and seasonal range
+	filter.ExcludeClearing = get("exclude_clearing") == "true"
+	if val, err := strconv.ParseFloat(get("ndvi_min"), 64); err == nil {
+		filter.NDVIMin = &val
+	}
+	if val, err := strconv.ParseFloat(get("ndvi_range_max"), 64); err == nil {
+		filter.NDVIRangeMax = &val
+	}
+
+	// Parse dwelling presence (from building footprints)
+	if val, err := strconv.ParseBool(get("has_dwelling")); err == nil {
+		filter.HasDwelling = &val
+	}
+
+	// Parse minimum dwellings (estimated from the description)
+	if val, err := strconv.Atoi(get("dwelling_count_min")); err == nil {
+		filter.MinDwellings = &val
+	}
+
+	// Parse the boundary mismatch flag (suburb or postcode not the pin's)
+	filter.BoundaryMismatch = get("boundary_mismatch") == "true"
+
+	// Parse minimum subdivision ratio (lots' area over the minimum lot size)
+	if val, err := strconv.ParseFloat(get("subdivision_ratio_min"), 64); err == nil {
+		filter.SubdivisionRatioMin = &val
+	}
+
+	// Parse minimum bedrooms, bathrooms and car spaces
+	if val, err := strconv.Atoi(get("bedrooms_min")); err == nil {
+		filter.MinBedrooms = &val
+	}
+	if val, err := strconv.Atoi(get("bathrooms_min")); err == nil {
+		filter.MinBathrooms = &val
+	}
+	if val, err := strconv.Atoi(get("carspaces_min")); err == nil {
+		filter.MinCarspaces = &val
+	}
+
+	// Parse drawn search area (GeoJSON or WKT polygon, lng lat)
+	if v := get("polygon"); v != "" {
+		if area, err := geo.ParsePolygon(v); err == nil {
+			filter.SearchArea = area
+		}
+	}
+
+	// Parse exclusions: an area, and layer:km pairs (e.g. highways:2,wind-farms:10)
+	if v := get("exclude_polygon"); v != "" {
+		if area, err := geo.ParsePolygon(v); err == nil {
+			filter.ExcludeArea = area
+		}
+	}
+	for _, part := range strings.Split(get("exclude_near"), ",") {
+		layer, km, ok := strings.Cut(strings.TrimSpace(part), ":")
+		if !ok || layer == "" {
+			continue
+		}
+		if val, err := strconv.ParseFloat(km, 64); err == nil && val > 0 && val <= MaxExclusionKm {
+			filter.ExcludeNear = append(filter.ExcludeNear, db.ExclusionRadius{Layer: layer, Km: val})
+		}
+	}
+
+	// Score profile, for scores and sort=score
+	if v := get("profile"); v != "" {
+		if val, err := strconv.ParseInt(v, 10, 64); err == nil {
+			filter.ScoreProfileID = &val
+		}
+	}
+
+	// Parse tag filters (must have all of tags, none of exclude_tags)
+	filter.Tags = parseTags(get("tags"))
+	filter.ExcludeTags = parseTags(get("exclude_tags"))
+
+	// Sort key, e.g. asking_vs_land_value_ratio or -asking_vs_land_value_ratio
+	filter.Sort = get("sort")
+
+	// Parse map bounds (sw_lat,sw_lng,ne_lat,ne_lng)
+	if v := get("bounds"); v != "" {
+		parts := strings.Split(v, ",")
+		if len(parts) == 4 {
+			swLat, _ := strconv.ParseFloat(parts[0], 64)
+			swLng, _ := strconv.ParseFloat(parts[1], 64)
+			neLat, _ := strconv.ParseFloat(parts[2], 64)
+			neLng, _ := strconv.ParseFloat(parts[3], 64)
+			filter.SWLat = &swLat
+			filter.SWLng = &swLng
+			filter.NELat = &neLat
+			filter.NELng = &neLng
+		}
+	}
+
+	// Parse pagination
+	if v := get("limit"); v != "" {
+		if val, err := strconv.Atoi(v); err == nil && val > 0 && val <= MaxListLimit {
+			filter.Limit = val
+		}
+	}
+	if v := get("offset"); v != "" {
+		if val, err := strconv.Atoi(v); err == nil && val >= 0 {
+			filter.Offset = val
+		}
+	}
+
+	return filter
+}
+
+// parseTags splits a comma-separated tag list, dropping invalid tags
+func parseTags(v string) []string {
+	var tags []string
+	for _, t := range strings.Split(v, ",") {
+		if tag, err := NormalizeTag(t); err == nil {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
@@ -0,0 +1,129 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"farm-search/internal/db"
+	"farm-search/internal/geo"
+)
+
+// fireHistoryYears is how many years back burns are counted
+const fireHistoryYears = 30
+
+// FireHistory finds the past fires that burnt part of each property's
+// cadastral lots, saving them per lot along with the property's most recent
+// burn year and the number of years in the last 30 with a burn (a fire
+// season's overlapping extents count once). Only applies once a fire history
+// has been imported with `tools fires`.
+func (s *EnrichmentService) FireHistory(all bool) (EnrichmentStats, error) {
+	properties, err := s.targets(db.StepFireHistory, all, "fire history")
+	stats := EnrichmentStats{Total: len(properties)}
+	if err != nil || len(properties) == 0 {
+		return stats, err
+	}
+
+	fires, err := s.db.GetFireExtents()
+	if err != nil {
+		return stats, err
+	}
+	fc := &geo.GeoJSONFeatureCollection{Features: make([]geo.GeoJSONFeature, len(fires))}
+	for i, f := range fires {
+		if err := json.Unmarshal([]byte(f.Geometry), &fc.Features[i].Geometry); err != nil {
+			return stats, fmt.Errorf("failed to parse geometry of fire %d: %w", f.ID, err)
+		}
+	}
+	history, err := geo.NewFireHistory(fc)
+	if err != nil {
+		return stats, err
+	}
+
+	log.Printf("Checking %d properties against %d past fire extents...", len(properties), len(fires))
+	since := time.Now().Year() - fireHistoryYears
+
+	for i, p := range properties {
+		lots, err := s.db.GetPropertyLots(p.ID)
+		if err != nil {
+			log.Printf("[%d/%d] Failed to get lots for property %d: %v", i+1, len(properties), p.ID, err)
+			stats.Failed++
+			continue
+		}
+
+		var lastBurnYear *int
+		years := make(map[int]bool)
+		failed := false
+		for _, lot := range lots {
+			area, err := geo.ParsePolygon(lot.Geometry)
+			if err != nil {
+				log.Printf("  Warning: Could not parse lot %s: %v", lot.LotIDString, err)
+				failed = true
+				break
+			}
+
+			burntPct := make(map[int64]float64)
+			for j, fraction := range history.Burnt(area) {
+				fire := fires[j]
+				burntPct[fire.ID] = roundPct(fraction)
+				if lastBurnYear == nil || fire.Year > *lastBurnYear {
+					year := fire.Year
+					lastBurnYear = &year
+				}
+				if fire.Year > since {
+					years[fire.Year] = true
+				}
+			}
+			if err := s.db.ReplaceLotFires(lot.ID, burntPct); err != nil {
+				log.Printf("  Warning: Could not save fires for lot %s: %v", lot.LotIDString, err)
+				failed = true
+				break
+			}
+		}
+		if failed {
+			log.Printf("[%d/%d] Failed for property %d (%s)", i+1, len(properties), p.ID, p.Suburb)
+			stats.Failed++
+			continue
+		}
+
+		if err := s.db.UpdatePropertyFireHistory(p.ID, lastBurnYear, len(years)); err != nil {
+			log.Printf("[%d/%d] Failed to save for property %d: %v", i+1, len(properties), p.ID, err)
+			stats.Failed++
+			continue
+		}
+
+		last := "never burnt"
+		if lastBurnYear != nil {
+			last = fmt.Sprintf("last burnt %d", *lastBurnYear)
+		}
+		log.Printf("[%d/%d] Property %d (%s): %s, %d burns in %d years",
+			i+1, len(properties), p.ID, p.Suburb, last, len(years), fireHistoryYears)
+		s.recomputed(p.ID, db.StepFireHistory)
+		stats.Success++
+	}
+	return stats, nil
+}
+
+// MarkFireHistoryChanged is MarkChangedInputs for the fire history alone,
+// for right after an import
+func (s *EnrichmentService) MarkFireHistoryChanged() error {
+	input, err := s.fireHistoryInput()
+	if err != nil || input == nil {
+		return err
+	}
+	return s.markIfChanged(*input)
+}
+
+// fireHistoryInput returns the fire history's version as an enrichment
+// input, so reimporting it rechecks every property. Nil if none has been
+// imported.
+func (s *EnrichmentService) fireHistoryInput() (*enrichmentInput, error) {
+	version, err := s.db.GetFireExtentVersion()
+	if err != nil {
+		return nil, err
+	}
+	if version == "0|" {
+		return nil, nil
+	}
+	return &enrichmentInput{"fire_extents", version, []db.EnrichmentStep{db.StepFireHistory}}, nil
+}
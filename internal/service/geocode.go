@@ -0,0 +1,126 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"farm-search/internal/db"
+	"farm-search/internal/geo"
+	"farm-search/internal/models"
+)
+
+// Geocode verdicts from VerifyGeocode
+const (
+	GeocodeVerified        = "verified"
+	GeocodeUnverified      = "unverified" // Outside NSW, or no address points on the lot to go by
+	GeocodeNoLot           = db.GeocodeNoLot
+	GeocodeAddressMismatch = db.GeocodeAddressMismatch
+)
+
+const (
+	// geocodeLotSearchKm is how far from a geocoded point that isn't on a lot
+	// (a road, say) to look for the lot it's meant to be
+	geocodeLotSearchKm = 0.2
+	// geocodeAddressNearKm is how far outside its lot an address point may
+	// be and still count as the lot's (they're often at the gate)
+	geocodeAddressNearKm = 0.2
+)
+
+// GeocodeCheck is what VerifyGeocode found for a geocoded point
+type GeocodeCheck struct {
+	Verdict      string
+	LotIDString  string // The lot the point is on or nearest to, if any
+	FoundAddress string // The nearest address on the lot, for a mismatch
+}
+
+// VerifyGeocode checks a geocoded point for a property against the NSW
+// cadastre: the point should be on (or within 200 m of) a lot, and the
+// address points on or by that lot should be on the listing's road, or in
+// its suburb if it names no road. Only NSW properties can be checked.
+func (s *EnrichmentService) VerifyGeocode(ctx context.Context, p *models.Property, lat, lng float64) (*GeocodeCheck, error) {
+	if s.cadastral == nil || p.State != "NSW" {
+		return &GeocodeCheck{Verdict: GeocodeUnverified}, nil
+	}
+
+	lots, err := s.cadastral.FetchLotsAtPoint(ctx, lng, lat)
+	if err != nil {
+		return nil, fmt.Errorf("fetching lots at point: %w", err)
+	}
+	if len(lots) == 0 {
+		swLat, swLng, neLat, neLng := geo.BoundsAround(lat, lng, geocodeLotSearchKm)
+		if lots, err = s.cadastral.FetchLotsInBounds(ctx, swLng, swLat, neLng, neLat); err != nil {
+			return nil, fmt.Errorf("fetching lots near point: %w", err)
+		}
+	}
+	lot, area := nearestLot(lots, lat, lng)
+	if lot == nil {
+		return &GeocodeCheck{Verdict: GeocodeNoLot}, nil
+	}
+	check := &GeocodeCheck{LotIDString: lot.LotIDString}
+
+	swLat, swLng, neLat, neLng := area.Bounds()
+	margin := geocodeAddressNearKm / 111.0
+	points, err := s.cadastral.FetchAddressPointsInBounds(ctx, swLng-margin, swLat-margin, neLng+margin, neLat+margin)
+	if err != nil {
+		return nil, fmt.Errorf("fetching address points: %w", err)
+	}
+
+	nearestKm := math.Inf(1)
+	for _, point := range points {
+		km := geo.Haversine(lat, lng, point.Lat, point.Lng)
+		if !area.Contains(point.Lat, point.Lng) && km > geocodeAddressNearKm {
+			continue
+		}
+		match, ok := geo.AddressMatches(p.Address.String, p.Suburb.String, point.Address)
+		if !ok {
+			return &GeocodeCheck{Verdict: GeocodeUnverified, LotIDString: lot.LotIDString}, nil
+		}
+		if match {
+			check.Verdict = GeocodeVerified
+			check.FoundAddress = point.Address
+			return check, nil
+		}
+		if km < nearestKm {
+			nearestKm = km
+			check.FoundAddress = point.Address
+		}
+	}
+
+	if check.FoundAddress == "" {
+		check.Verdict = GeocodeUnverified
+	} else {
+		check.Verdict = GeocodeAddressMismatch
+	}
+	return check, nil
+}
+
+// nearestLot returns the lot containing a point, or else the one whose
+// centroid is nearest, with its parsed boundary. Nil if none have a usable
+// geometry.
+func nearestLot(lots []geo.LotFeature, lat, lng float64) (*geo.LotFeature, *geo.Area) {
+	var nearest *geo.LotFeature
+	var nearestArea *geo.Area
+	nearestKm := math.Inf(1)
+	for i := range lots {
+		geomJSON, err := geo.LotGeometryToJSON(lots[i].Geometry)
+		if err != nil {
+			continue
+		}
+		area, err := geo.ParsePolygon(geomJSON)
+		if err != nil {
+			continue
+		}
+		if area.Contains(lat, lng) {
+			return &lots[i], area
+		}
+		centroidLat, centroidLng, ok := geo.Centroid(area)
+		if !ok {
+			continue
+		}
+		if km := geo.Haversine(lat, lng, centroidLat, centroidLng); km < nearestKm {
+			nearest, nearestArea, nearestKm = &lots[i], area, km
+		}
+	}
+	return nearest, nearestArea
+}
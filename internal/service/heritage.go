@@ -0,0 +1,119 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"strings"
+	"time"
+
+	"farm-search/internal/db"
+	"farm-search/internal/geo"
+	"farm-search/internal/models"
+)
+
+// WithHeritage returns a copy of the service that checks lots against the
+// heritage layers with client
+func (s *EnrichmentService) WithHeritage(client *geo.HeritageClient) *EnrichmentService {
+	scoped := *s
+	scoped.heritage = client
+	return &scoped
+}
+
+// Heritage checks each property's cadastral lots against the NSW State
+// Heritage Register and local heritage items, saving the items per lot and the
+// most restrictive listing on the property. Lots already checked are reused
+// unless all is set (lots shared by neighbouring listings are only fetched
+// once). Needs a heritage client, and lots from CadastralLots.
+func (s *EnrichmentService) Heritage(ctx context.Context, all bool) (EnrichmentStats, error) {
+	properties, err := s.targets(db.StepHeritage, all, "heritage check")
+	stats := EnrichmentStats{Total: len(properties)}
+	if err != nil || len(properties) == 0 {
+		return stats, err
+	}
+
+	log.Printf("Checking heritage listings for %d properties...", len(properties))
+
+	for i, p := range properties {
+		lots, err := s.db.GetPropertyLots(p.ID)
+		if err != nil {
+			log.Printf("[%d/%d] Failed to get lots for property %d: %v", i+1, len(properties), p.ID, err)
+			stats.Failed++
+			continue
+		}
+
+		failed := false
+		for _, lot := range lots {
+			if lot.HeritageCheckedAt != nil && !all {
+				continue
+			}
+			if err := s.checkLotHeritage(ctx, lot); err != nil {
+				log.Printf("  Warning: Could not check lot %s: %v", lot.LotIDString, err)
+				failed = true
+			}
+			// Rate limiting to avoid overloading the planning portal
+			time.Sleep(500 * time.Millisecond)
+		}
+		// Partly checked lots would understate the listing, so leave it missing
+		if failed {
+			log.Printf("[%d/%d] Failed for property %d (%s)", i+1, len(properties), p.ID, p.Suburb)
+			stats.Failed++
+			continue
+		}
+
+		items, err := s.db.GetPropertyHeritageItems(p.ID)
+		if err != nil {
+			log.Printf("[%d/%d] Failed to get heritage items for property %d: %v", i+1, len(properties), p.ID, err)
+			stats.Failed++
+			continue
+		}
+		listing := heritageListing(items)
+		if err := s.db.UpdatePropertyHeritage(p.ID, listing); err != nil {
+			log.Printf("[%d/%d] Failed to save for property %d: %v", i+1, len(properties), p.ID, err)
+			stats.Failed++
+			continue
+		}
+
+		log.Printf("[%d/%d] Property %d (%s): %s (%d items on %d lots)",
+			i+1, len(properties), p.ID, location(p), listing, len(items), len(lots))
+		s.recomputed(p.ID, db.StepHeritage)
+		stats.Success++
+	}
+	return stats, nil
+}
+
+// checkLotHeritage fetches the heritage items on a lot and saves them
+func (s *EnrichmentService) checkLotHeritage(ctx context.Context, lot models.CadastralLot) error {
+	var geom geo.LotGeometry
+	if err := json.Unmarshal([]byte(lot.Geometry), &geom); err != nil {
+		return err
+	}
+	found, err := s.heritage.FetchItemsOnLot(ctx, &geom)
+	if err != nil {
+		return err
+	}
+
+	items := make([]models.HeritageItem, len(found))
+	for i, item := range found {
+		// Items without a number are keyed by name, so they're still unique per lot
+		itemID := item.ItemID
+		if itemID == "" {
+			itemID = strings.ToLower(item.Name)
+		}
+		items[i] = models.HeritageItem{Listing: item.Listing, ItemID: itemID, Name: item.Name, Class: item.Class}
+	}
+	return s.db.ReplaceLotHeritageItems(lot.ID, items)
+}
+
+// heritageListing returns the most restrictive listing of items: state, then
+// local, else none
+func heritageListing(items []models.HeritageItem) string {
+	listing := geo.HeritageNone
+	for _, item := range items {
+		if item.Listing == geo.HeritageState {
+			return geo.HeritageState
+		}
+		listing = geo.HeritageLocal
+	}
+	return listing
+}
@@ -0,0 +1,254 @@
+package service
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"time"
+
+	"farm-search/internal/db"
+	"farm-search/internal/geo"
+	"farm-search/internal/models"
+)
+
+// HistoryIntervals are the steps ActiveListings' dates can be spaced by
+var HistoryIntervals = []string{"day", "week", "month"}
+
+// MaxHistoryDates caps how many dates ActiveListings counts
+const MaxHistoryDates = 1000
+
+// marketHistory is one database's canonical properties matching a filter's
+// current-state part, with the versions of their listings
+type marketHistory struct {
+	properties map[int64]models.PropertyListItem
+	versions   []models.ListingVersion // Ordered by listing, oldest first
+}
+
+// historyFilter splits f into what listShard applies, to properties as they
+// are now, and a match for the fields listing history keeps (asking price,
+// type and land size) as they were, following ListProperties' rules
+func historyFilter(f db.PropertyFilter) (db.PropertyFilter, func(v *models.ListingVersion) bool) {
+	current := f
+	current.PriceMin, current.PriceMax, current.PropertyTypes = nil, nil, nil
+	current.LandSizeMin, current.LandSizeMax = nil, nil
+	current.Sort, current.Limit, current.Offset = "", 0, 0
+
+	match := func(v *models.ListingVersion) bool {
+		if f.PriceMin != nil && v.PriceMax != nil && *v.PriceMax < *f.PriceMin {
+			return false
+		}
+		if f.PriceMax != nil && v.PriceMin != nil && *v.PriceMin > *f.PriceMax {
+			return false
+		}
+		if len(f.PropertyTypes) > 0 && (v.PropertyType == nil || !slices.Contains(f.PropertyTypes, *v.PropertyType)) {
+			return false
+		}
+		if f.LandSizeMin != nil && (v.LandSizeSqm == nil || *v.LandSizeSqm < *f.LandSizeMin) {
+			return false
+		}
+		if f.LandSizeMax != nil && (v.LandSizeSqm == nil || *v.LandSizeSqm > *f.LandSizeMax) {
+			return false
+		}
+		return true
+	}
+	return current, match
+}
+
+// marketHistories loads the history of the properties matching current in
+// this and every region's database, pruned ones included
+func (s *PropertyService) marketHistories(ctx context.Context, current db.PropertyFilter) ([]marketHistory, error) {
+	current.IncludePruned = true
+	spatial, err := s.Spatial(ctx, current)
+	if err != nil {
+		return nil, err
+	}
+
+	var histories []marketHistory
+	for _, shard := range s.shards() {
+		matches, err := shard.listShard(current, spatial)
+		if err != nil {
+			return nil, err
+		}
+		versions, err := shard.db.GetListingHistory()
+		if err != nil {
+			return nil, err
+		}
+		h := marketHistory{properties: make(map[int64]models.PropertyListItem, len(matches))}
+		for _, m := range matches {
+			h.properties[m.ID] = m
+		}
+		for _, v := range versions {
+			if _, ok := h.properties[v.PropertyID]; ok {
+				h.versions = append(h.versions, v)
+			}
+		}
+		histories = append(histories, h)
+	}
+	return histories, nil
+}
+
+// within narrows h to the properties located where contains is true
+func (h *marketHistory) within(contains func(lat, lng float64) bool) {
+	for id, p := range h.properties {
+		if !contains(p.Latitude, p.Longitude) {
+			delete(h.properties, id)
+		}
+	}
+	versions := h.versions[:0]
+	for _, v := range h.versions {
+		if _, ok := h.properties[v.PropertyID]; ok {
+			versions = append(versions, v)
+		}
+	}
+	h.versions = versions
+}
+
+// askingPrice returns the midpoint of a version's asking price range, false
+// if it has no price
+func askingPrice(v *models.ListingVersion) (float64, bool) {
+	if v.PriceMin == nil {
+		return 0, false
+	}
+	top := *v.PriceMin
+	if v.PriceMax != nil {
+		top = *v.PriceMax
+	}
+	return float64(*v.PriceMin+top) / 2, true
+}
+
+// activeVersions returns the index of the version matching match current at
+// t for each property on the market then, the canonical listing's where more
+// than one of its listings was
+func (h *marketHistory) activeVersions(t time.Time, match func(v *models.ListingVersion) bool) map[int64]int {
+	active := make(map[int64]int)
+	for i := range h.versions {
+		v := &h.versions[i]
+		if !v.Active(t) || !match(v) {
+			continue
+		}
+		if j, ok := active[v.PropertyID]; !ok || (v.ListingID == v.PropertyID && h.versions[j].ListingID != v.PropertyID) {
+			active[v.PropertyID] = i
+		}
+	}
+	return active
+}
+
+// AsOf returns the canonical properties matching f that were on the market
+// at t, with their asking price, type and land size as they were then (and
+// f's price, type and land size filters applied to those), most recently
+// listed first. Other fields and filters are as things are now. Where more
+// than one of a property's listings was on the market, the canonical
+// listing's version is shown. f's sort is ignored; its limit (capped at
+// MaxListLimit) and offset apply.
+func (s *PropertyService) AsOf(ctx context.Context, f db.PropertyFilter, t time.Time) ([]models.HistoricalProperty, error) {
+	limit, offset := f.Limit, f.Offset
+	if limit <= 0 || limit > MaxListLimit {
+		limit = MaxListLimit
+	}
+	current, match := historyFilter(f)
+	histories, err := s.marketHistories(ctx, current)
+	if err != nil {
+		return nil, err
+	}
+
+	properties := []models.HistoricalProperty{}
+	for _, h := range histories {
+		for id, i := range h.activeVersions(t, match) {
+			v := h.versions[i]
+			item := h.properties[id]
+			item.Source, item.PriceText, item.PropertyType = v.Source, "", ""
+			if v.PriceText != nil {
+				item.PriceText = *v.PriceText
+			}
+			if v.PropertyType != nil {
+				item.PropertyType = *v.PropertyType
+			}
+			p := models.HistoricalProperty{
+				PropertyListItem: item,
+				PriceMin:         v.PriceMin,
+				PriceMax:         v.PriceMax,
+				LandSizeSqm:      v.LandSizeSqm,
+				ListedSince:      v.ValidFrom,
+			}
+			// Back through the versions before, while the listing was on the
+			// market without a break
+			for j := i - 1; j >= 0; j-- {
+				prev := h.versions[j]
+				if prev.ListingID != v.ListingID || prev.ValidTo == nil || !prev.ValidTo.Equal(p.ListedSince) {
+					break
+				}
+				p.ListedSince = prev.ValidFrom
+			}
+			properties = append(properties, p)
+		}
+	}
+
+	sort.Slice(properties, func(i, j int) bool {
+		if !properties[i].ListedSince.Equal(properties[j].ListedSince) {
+			return properties[i].ListedSince.After(properties[j].ListedSince)
+		}
+		return properties[i].ID < properties[j].ID
+	})
+	if offset >= len(properties) {
+		return []models.HistoricalProperty{}, nil
+	}
+	properties = properties[offset:]
+	if limit < len(properties) {
+		properties = properties[:limit]
+	}
+	return properties, nil
+}
+
+// HistoryDates returns the dates from from to to (inclusive) a day, week or
+// month apart, as midnight in Sydney time; false if there are more than
+// MaxHistoryDates
+func HistoryDates(from, to time.Time, interval string) ([]time.Time, bool) {
+	from = time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, geo.SydneyTime)
+	var dates []time.Time
+	for i := 0; ; i++ {
+		d := historyDate(from, i, interval)
+		if d.After(to) {
+			return dates, true
+		}
+		if len(dates) == MaxHistoryDates {
+			return nil, false
+		}
+		dates = append(dates, d)
+	}
+}
+
+// historyDate returns the date i intervals after from
+func historyDate(from time.Time, i int, interval string) time.Time {
+	switch interval {
+	case "week":
+		return from.AddDate(0, 0, 7*i)
+	case "month":
+		return from.AddDate(0, i, 0)
+	}
+	return from.AddDate(0, 0, i)
+}
+
+// ActiveListings counts the canonical properties matching f that were on
+// the market at the end of each date (or now, for today), by their asking
+// price, type and land size then, as AsOf lists them
+func (s *PropertyService) ActiveListings(ctx context.Context, f db.PropertyFilter, dates []time.Time) ([]models.ActiveListingCount, error) {
+	current, match := historyFilter(f)
+	histories, err := s.marketHistories(ctx, current)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	counts := make([]models.ActiveListingCount, len(dates))
+	for i, d := range dates {
+		end := d.AddDate(0, 0, 1)
+		if end.After(now) {
+			end = now
+		}
+		counts[i].Date = d.Format("2006-01-02")
+		for _, h := range histories {
+			counts[i].Active += len(h.activeVersions(end, match))
+		}
+	}
+	return counts, nil
+}
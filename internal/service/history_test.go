@@ -0,0 +1,98 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"farm-search/internal/db"
+	"farm-search/internal/models"
+)
+
+// Pruning delisted properties keeps their listing history, so the market as
+// of a date before the prune is listed as it was
+func TestAsOfUnchangedByPrune(t *testing.T) {
+	database, err := db.New(filepath.Join(t.TempDir(), "farm-search.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer database.Close()
+
+	now := time.Now().UTC().Truncate(time.Second)
+	listing := func(externalID string, lat float64, price int64) models.Property {
+		return models.Property{
+			ExternalID: externalID,
+			Source:     "domain",
+			URL:        "https://www.domain.com.au/" + externalID,
+			Suburb:     sql.NullString{String: "Wattle Flat", Valid: true},
+			Latitude:   sql.NullFloat64{Float64: lat, Valid: true},
+			Longitude:  sql.NullFloat64{Float64: 149.6931, Valid: true},
+			PriceMax:   sql.NullInt64{Int64: price, Valid: true},
+			ScrapedAt:  now,
+			UpdatedAt:  now,
+		}
+	}
+	result, err := database.SaveProperties([]models.Property{
+		listing("2019000001", -33.1342, 1250000), // Still listed
+		listing("2019000002", -33.2010, 890000),  // Delisted
+		listing("2019000003", -33.2011, 895000),  // Delisted duplicate of 2019000002
+	})
+	if err != nil || result.Failed > 0 {
+		t.Fatalf("saving listings: %v %v", err, result.Errors)
+	}
+	ids := make(map[string]int64)
+	for _, ref := range result.New {
+		ids[ref.ExternalID] = ref.ID
+	}
+	if err := database.CreatePropertyLink(ids["2019000002"], ids["2019000003"], "same farm"); err != nil {
+		t.Fatal(err)
+	}
+
+	// The delisted pair were on the market from ten months ago until eight
+	listed, lastScraped := now.AddDate(0, -10, 0), now.AddDate(0, -8, 0)
+	for _, externalID := range []string{"2019000002", "2019000003"} {
+		if _, err := database.Exec("UPDATE properties SET scraped_at = ? WHERE id = ?", lastScraped, ids[externalID]); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := database.Exec("UPDATE property_history SET valid_from = ? WHERE property_id = ?", listed, ids[externalID]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := database.RefreshPropertySearch(); err != nil {
+		t.Fatal(err)
+	}
+
+	properties := NewPropertyService(database, nil)
+	dates := []time.Time{now.AddDate(0, -11, 0), now.AddDate(0, -9, 0), now.AddDate(0, -7, 0), now.Add(time.Hour)}
+	asOf := func() [][]models.HistoricalProperty {
+		t.Helper()
+		var answers [][]models.HistoricalProperty
+		for _, date := range dates {
+			found, err := properties.AsOf(context.Background(), db.PropertyFilter{}, date)
+			if err != nil {
+				t.Fatal(err)
+			}
+			answers = append(answers, found)
+		}
+		return answers
+	}
+	before := asOf()
+	if len(before[1]) != 1 || before[1][0].ID != ids["2019000002"] {
+		t.Fatalf("as of nine months ago, got %+v, want only the delisted property", before[1])
+	}
+
+	pruned, err := database.PruneDelistedProperties(now.AddDate(0, -6, 0), false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pruned.Properties != 2 {
+		t.Fatalf("pruned %d properties, want 2", pruned.Properties)
+	}
+
+	if after := asOf(); !reflect.DeepEqual(before, after) {
+		t.Errorf("as-of answers changed by the prune:\nbefore %+v\nafter  %+v", before, after)
+	}
+}
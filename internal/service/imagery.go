@@ -0,0 +1,53 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"farm-search/internal/db"
+	"farm-search/internal/geo"
+)
+
+// ImageryLinks saves each property's Street View, NSW SIX Maps aerial and
+// Google Earth links. Street View is taken from the nearest road point
+// Valhalla snaps to, facing the property; a property with no road near it
+// gets Google's nearest panorama to its own point. Needs a router; stops
+// with an ErrValhallaUnavailable error if Valhalla goes down.
+func (s *EnrichmentService) ImageryLinks(ctx context.Context, all bool) (EnrichmentStats, error) {
+	properties, err := s.targets(db.StepImageryLinks, all, "imagery links")
+	stats := EnrichmentStats{Total: len(properties)}
+	if err != nil || len(properties) == 0 {
+		return stats, err
+	}
+
+	log.Printf("Generating imagery links for %d properties...", len(properties))
+
+	for i, p := range properties {
+		roadLat, roadLng, err := s.router.NearestRoad(ctx, p.Latitude, p.Longitude)
+		if errors.Is(err, geo.ErrValhallaUnavailable) {
+			return stats, err // Every other property would fail too
+		}
+		road := "nearest road"
+		if errors.Is(err, geo.ErrNoRoute) {
+			roadLat, roadLng, road = p.Latitude, p.Longitude, "no road nearby"
+		} else if err != nil {
+			log.Printf("[%d/%d] Failed for property %d (%s): %v", i+1, len(properties), p.ID, location(p), err)
+			stats.Failed++
+			continue
+		}
+
+		links := geo.NewImageryLinks(p.Latitude, p.Longitude, roadLat, roadLng)
+		if err := s.db.UpdatePropertyImageryLinks(p.ID, links.StreetView, links.Aerial, links.GoogleEarth); err != nil {
+			log.Printf("[%d/%d] Failed to save for property %d: %v", i+1, len(properties), p.ID, err)
+			stats.Failed++
+			continue
+		}
+
+		log.Printf("[%d/%d] Property %d (%s): Street View from %s (%.2f km away)",
+			i+1, len(properties), p.ID, location(p), road, geo.Haversine(p.Latitude, p.Longitude, roadLat, roadLng))
+		s.recomputed(p.ID, db.StepImageryLinks)
+		stats.Success++
+	}
+	return stats, nil
+}
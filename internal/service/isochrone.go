@@ -0,0 +1,97 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"farm-search/internal/db"
+	"farm-search/internal/geo"
+)
+
+// MaxIsochroneMinutes caps the drive time of on-demand isochrones (the
+// public Valhalla server stops at 90)
+const MaxIsochroneMinutes = 180
+
+// isochroneMaxAge is how long a generated isochrone is reused; the road
+// network changes slowly
+const isochroneMaxAge = 30 * 24 * time.Hour
+
+// IsochroneService generates drive time isochrones around any point through
+// Valhalla, caching them in the isochrones table
+type IsochroneService struct {
+	db  *db.DB
+	gen *geo.IsochroneGenerator
+
+	mu sync.Mutex // Held while generating, so concurrent misses don't all hit Valhalla
+}
+
+// NewIsochroneService creates a new IsochroneService
+func NewIsochroneService(database *db.DB, gen *geo.IsochroneGenerator) *IsochroneService {
+	return &IsochroneService{db: database, gen: gen}
+}
+
+// isochroneKey rounds an origin to 3 decimal places (~100 m), so clicks on
+// the same property share a cached isochrone
+func isochroneKey(lat, lng float64) (float64, float64) {
+	return math.Round(lat*1000) / 1000, math.Round(lng*1000) / 1000
+}
+
+// Get returns the isochrone of the area within minutes' drive of a point,
+// generating it if there's no recent one cached. cached reports whether it
+// came from the cache.
+func (s *IsochroneService) Get(ctx context.Context, lat, lng float64, minutes int) (iso *geo.GeoJSONFeatureCollection, cached bool, err error) {
+	lat, lng = isochroneKey(lat, lng)
+
+	if iso, err := s.cached(lat, lng, minutes); err != nil || iso != nil {
+		return iso, iso != nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Another request may have generated it while we waited
+	if iso, err := s.cached(lat, lng, minutes); err != nil || iso != nil {
+		return iso, iso != nil, err
+	}
+
+	iso, err = s.gen.GenerateIsochrone(ctx, lat, lng, minutes)
+	if err != nil {
+		return nil, false, err
+	}
+
+	data, err := json.Marshal(iso)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to encode isochrone: %w", err)
+	}
+	if err := s.db.SaveIsochrone(lat, lng, minutes, string(data)); err != nil {
+		return nil, false, err
+	}
+	return iso, false, nil
+}
+
+// Area returns the area within minutes' drive of a point, from its isochrone
+func (s *IsochroneService) Area(ctx context.Context, lat, lng float64, minutes int) (*geo.Area, error) {
+	iso, _, err := s.Get(ctx, lat, lng, minutes)
+	if err != nil {
+		return nil, err
+	}
+	return geo.NewArea(iso)
+}
+
+// cached returns a recent cached isochrone, or nil if there's none
+func (s *IsochroneService) cached(lat, lng float64, minutes int) (*geo.GeoJSONFeatureCollection, error) {
+	data, err := s.db.GetCachedIsochrone(lat, lng, minutes, time.Now().Add(-isochroneMaxAge))
+	if err != nil || data == "" {
+		return nil, err
+	}
+
+	var iso geo.GeoJSONFeatureCollection
+	if err := json.Unmarshal([]byte(data), &iso); err != nil {
+		return nil, fmt.Errorf("failed to decode cached isochrone: %w", err)
+	}
+	return &iso, nil
+}
@@ -0,0 +1,73 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"farm-search/internal/db"
+	"farm-search/internal/geo"
+	"farm-search/internal/models"
+)
+
+// ErrInvalidLotReference is returned for a lot search that isn't a Lot/DP
+// reference
+var ErrInvalidLotReference = errors.New(`not a Lot/DP reference, e.g. "Lot 12 DP1234567"`)
+
+// LotService looks up cadastral lots by their Lot/DP reference, for
+// cross-referencing contracts and agents' parcel descriptions against the map
+type LotService struct {
+	db        *db.DB
+	cadastral *geo.CadastralClient
+}
+
+// NewLotService creates a new LotService. Lots not stored locally are
+// fetched from NSW Spatial Services with cadastral, unless it's nil.
+func NewLotService(database *db.DB, cadastral *geo.CadastralClient) *LotService {
+	return &LotService{db: database, cadastral: cadastral}
+}
+
+// Search parses a Lot/DP reference such as "Lot 12 DP1234567" and returns
+// the lot with what's recorded about it and the properties on it. A lot not
+// stored yet is fetched from NSW Spatial Services and saved (not linked to
+// any property), so fetched reports whether it was. Nil if there's no such
+// lot.
+func (s *LotService) Search(ctx context.Context, ref string) (lot *models.LotDetail, fetched bool, err error) {
+	lotID, ok := geo.ParseLotReference(ref)
+	if !ok {
+		return nil, false, ErrInvalidLotReference
+	}
+
+	lot, err = s.db.GetLot(lotID)
+	if err != nil || lot != nil || s.cadastral == nil {
+		return lot, false, err
+	}
+
+	feature, err := s.cadastral.FetchLot(ctx, lotID)
+	if err != nil {
+		return nil, false, fmt.Errorf("fetching lot %s: %w", lotID, err)
+	}
+	if feature == nil {
+		return nil, false, nil
+	}
+	if err := s.save(feature); err != nil {
+		return nil, false, err
+	}
+
+	lot, err = s.db.GetLot(feature.LotIDString)
+	return lot, lot != nil, err
+}
+
+// save stores a lot fetched from NSW Spatial Services
+func (s *LotService) save(lot *geo.LotFeature) error {
+	centroidLat, centroidLng, err := geo.CalculateLotCentroid(lot.Geometry)
+	if err != nil {
+		return fmt.Errorf("calculating centroid for lot %s: %w", lot.LotIDString, err)
+	}
+	geomJSON, err := geo.LotGeometryToJSON(lot.Geometry)
+	if err != nil {
+		return fmt.Errorf("serializing geometry for lot %s: %w", lot.LotIDString, err)
+	}
+	_, err = s.db.SaveCadastralLot(lot.LotIDString, lot.LotNumber, lot.PlanLabel, lot.AreaSqm, centroidLat, centroidLng, geomJSON)
+	return err
+}
@@ -0,0 +1,78 @@
+package service
+
+import (
+	"context"
+	"log"
+	"math"
+	"time"
+
+	"farm-search/internal/db"
+	"farm-search/internal/geo"
+)
+
+// ndviYears is how many years of observations the NDVI summary averages, so
+// one drought or wet year doesn't dominate it
+const ndviYears = 3
+
+// PastureNDVI summarises the NDVI over each property's cadastral lots (their
+// bounding box) over the last ndviYears years by calendar month, saving the
+// mean of the monthly means as a proxy for pasture quality and the range
+// between the greenest and brownest months for how reliable it is through the
+// year. Needs a vegetation source, and lots from CadastralLots.
+func (s *EnrichmentService) PastureNDVI(ctx context.Context, all bool) (EnrichmentStats, error) {
+	properties, err := s.targets(db.StepNDVI, all, "NDVI summary")
+	stats := EnrichmentStats{Total: len(properties)}
+	if err != nil || len(properties) == 0 {
+		return stats, err
+	}
+
+	to := time.Now().UTC().Truncate(24 * time.Hour)
+	from := to.AddDate(-ndviYears, 0, 0)
+	log.Printf("Summarising NDVI for %d properties since %s...", len(properties), from.Format("2006-01-02"))
+
+	for i, p := range properties {
+		bbox, err := s.lotsBBox(p.ID)
+		if err != nil {
+			log.Printf("[%d/%d] Failed for property %d (%s): %v", i+1, len(properties), p.ID, location(p), err)
+			stats.Failed++
+			continue
+		}
+
+		observations, err := s.vegetation.Observations(ctx, bbox, from, to)
+		if err != nil {
+			log.Printf("[%d/%d] Failed for property %d (%s): %v", i+1, len(properties), p.ID, location(p), err)
+			stats.Failed++
+			continue
+		}
+		summary, ok := geo.SummariseNDVI(observations)
+		if !ok {
+			log.Printf("[%d/%d] Failed for property %d (%s): too few cloud-free months (%d days observed)",
+				i+1, len(properties), p.ID, location(p), len(observations))
+			stats.Failed++
+			continue
+		}
+
+		for m, mean := range summary.Monthly {
+			if mean != nil {
+				rounded := roundNDVI(*mean)
+				summary.Monthly[m] = &rounded
+			}
+		}
+		if err := s.db.UpdatePropertyNDVI(p.ID, roundNDVI(summary.Mean), roundNDVI(summary.SeasonalRange), summary.Monthly); err != nil {
+			log.Printf("[%d/%d] Failed to save for property %d: %v", i+1, len(properties), p.ID, err)
+			stats.Failed++
+			continue
+		}
+
+		log.Printf("[%d/%d] Property %d (%s): NDVI %.2f, seasonal range %.2f (%d days)",
+			i+1, len(properties), p.ID, location(p), summary.Mean, summary.SeasonalRange, summary.Observations)
+		s.recomputed(p.ID, db.StepNDVI)
+		stats.Success++
+	}
+	return stats, nil
+}
+
+// roundNDVI rounds an NDVI to 3 decimal places, well within its precision
+func roundNDVI(ndvi float64) float64 {
+	return math.Round(ndvi*1000) / 1000
+}
@@ -0,0 +1,142 @@
+package service
+
+import (
+	"sync"
+
+	"farm-search/internal/db"
+	"farm-search/internal/geo"
+	"farm-search/internal/models"
+)
+
+// AmenityTown is the amenity type for towns, which come from geo.NSWTowns
+// rather than the amenities table
+const AmenityTown = "town"
+
+// AmenitySchool is the amenity type the schools tools save schools as
+const AmenitySchool = "school"
+
+// NearbyService finds the amenities nearest a property. It keeps a KD-tree
+// per amenity type, rebuilt when that type is reimported.
+type NearbyService struct {
+	db *db.DB
+
+	mu      sync.Mutex
+	indexes map[string]*amenityIndex
+}
+
+type amenityIndex struct {
+	version   string
+	amenities []models.Amenity
+	index     *geo.PointIndex
+}
+
+// NewNearbyService creates a new NearbyService
+func NewNearbyService(database *db.DB) *NearbyService {
+	return &NearbyService{db: database, indexes: make(map[string]*amenityIndex)}
+}
+
+// amenityIndex returns the index for an amenity type, building it if the
+// type's amenities changed since it was last built
+func (s *NearbyService) amenityIndex(amenityType string) (*amenityIndex, error) {
+	version, err := s.db.GetAmenityVersion(amenityType)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if idx, ok := s.indexes[amenityType]; ok && idx.version == version {
+		return idx, nil
+	}
+
+	amenities, err := s.db.GetAmenities(amenityType)
+	if err != nil {
+		return nil, err
+	}
+	idx := &amenityIndex{
+		version:   version,
+		amenities: amenities,
+		index: geo.NewPointIndex(len(amenities), func(i int) (float64, float64) {
+			return amenities[i].Latitude, amenities[i].Longitude
+		}),
+	}
+	s.indexes[amenityType] = idx
+	return idx, nil
+}
+
+// Nearest returns the k nearest amenities of each type to a property,
+// closest first, with drive times where already calculated: the property's
+// nearest towns and schools columns, then property_distances. A type with no
+// amenities gets an empty list.
+func (s *NearbyService) Nearest(p *models.PropertyDetail, types []string, k int) (map[string][]models.NearbyAmenity, error) {
+	driveTimes, err := s.db.GetPropertyDriveTimes(p.ID)
+	if err != nil {
+		return nil, err
+	}
+	cached := func(amenityType, name string) *int {
+		for _, known := range knownDriveTimes(p, amenityType) {
+			if known.name != nil && *known.name == name && known.mins != nil {
+				return known.mins
+			}
+		}
+		if mins, ok := driveTimes[amenityType][name]; ok {
+			return &mins
+		}
+		return nil
+	}
+
+	nearby := make(map[string][]models.NearbyAmenity, len(types))
+	for _, amenityType := range types {
+		results := []models.NearbyAmenity{}
+
+		if amenityType == AmenityTown {
+			for _, n := range geo.NSWTownIndex().Nearest(p.Latitude, p.Longitude, k) {
+				town := geo.NSWTowns[n.Index]
+				results = append(results, models.NearbyAmenity{
+					Name:          town.Name,
+					Latitude:      town.Latitude,
+					Longitude:     town.Longitude,
+					DistanceKm:    n.DistanceKm,
+					DriveTimeMins: cached(amenityType, town.Name),
+				})
+			}
+		} else {
+			idx, err := s.amenityIndex(amenityType)
+			if err != nil {
+				return nil, err
+			}
+			for _, n := range idx.index.Nearest(p.Latitude, p.Longitude, k) {
+				a := idx.amenities[n.Index]
+				results = append(results, models.NearbyAmenity{
+					Name:          a.Name,
+					Detail:        a.Detail.String,
+					Latitude:      a.Latitude,
+					Longitude:     a.Longitude,
+					DistanceKm:    n.DistanceKm,
+					DriveTimeMins: cached(amenityType, a.Name),
+				})
+			}
+		}
+
+		nearby[amenityType] = results
+	}
+	return nearby, nil
+}
+
+// knownDriveTime is a drive time stored on a property for a nearest town or school
+type knownDriveTime struct {
+	name *string
+	mins *int
+}
+
+// knownDriveTimes returns the drive times stored on a property for a type's
+// nearest two
+func knownDriveTimes(p *models.PropertyDetail, amenityType string) []knownDriveTime {
+	switch amenityType {
+	case AmenityTown:
+		return []knownDriveTime{{p.NearestTown1, p.NearestTown1Mins}, {p.NearestTown2, p.NearestTown2Mins}}
+	case AmenitySchool:
+		return []knownDriveTime{{p.NearestSchool1, p.NearestSchool1Mins}, {p.NearestSchool2, p.NearestSchool2Mins}}
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+package service
+
+import (
+	"log"
+	"math"
+
+	"farm-search/internal/db"
+	"farm-search/internal/geo"
+	"farm-search/internal/models"
+)
+
+// nearestMovedKm is how far a saved nearest town or school's distance has to
+// be from the current one to count as moved, so rounding doesn't reroute
+const nearestMovedKm = 0.01
+
+// ChangedNearestTowns checks every property's saved nearest towns against the
+// current town list and saves only those that changed, marking their town
+// drive times stale for TownDriveTimes. After a town is added or its
+// coordinates corrected, that's just the properties it's now nearest to or
+// moved relative to, rather than all of them. Properties without nearest
+// towns are left to NearestTowns.
+func (s *EnrichmentService) ChangedNearestTowns() (EnrichmentStats, error) {
+	saved, err := s.db.GetSavedNearestTowns()
+	if err != nil {
+		return EnrichmentStats{}, err
+	}
+	log.Printf("Checking nearest towns for %d properties against %d towns...", len(saved), len(geo.NSWTowns))
+
+	return s.updateChangedNearest(saved, "towns", db.StepNearestTowns,
+		[]db.EnrichmentStep{db.StepTownDriveTimes, db.StepTownWalkCycleTimes},
+		func(p models.SavedNearest) (models.NearbyPlace, models.NearbyPlace) {
+			town1, town2 := geo.FindTwoNearestTowns(p.Latitude, p.Longitude)
+			return models.NearbyPlace{Name: town1.Name, DistanceKm: town1.DistanceKm},
+				models.NearbyPlace{Name: town2.Name, DistanceKm: town2.DistanceKm}
+		},
+		s.db.UpdatePropertyNearestTowns)
+}
+
+// ChangedNearestSchools is ChangedNearestTowns for schools. Needs loaded
+// school data.
+func (s *EnrichmentService) ChangedNearestSchools() (EnrichmentStats, error) {
+	saved, err := s.db.GetSavedNearestSchools()
+	if err != nil {
+		return EnrichmentStats{}, err
+	}
+	log.Printf("Checking nearest schools for %d properties against %d schools...", len(saved), len(s.schools.Schools))
+
+	return s.updateChangedNearest(saved, "schools", db.StepNearestSchools,
+		[]db.EnrichmentStep{db.StepSchoolDriveTimes},
+		func(p models.SavedNearest) (models.NearbyPlace, models.NearbyPlace) {
+			school1, school2 := s.schools.FindTwoNearestSchools(p.Latitude, p.Longitude)
+			return nearbySchool(school1), nearbySchool(school2)
+		},
+		s.db.UpdatePropertyNearestSchools)
+}
+
+// updateChangedNearest saves the nearest places find returns for each saved
+// property whose names or distances differ. A new name marks the drive times
+// stale through the database's triggers; the same names at new distances mean
+// a place moved, so routeSteps are marked here.
+func (s *EnrichmentService) updateChangedNearest(saved []models.SavedNearest, reason string, step db.EnrichmentStep, routeSteps []db.EnrichmentStep,
+	find func(models.SavedNearest) (models.NearbyPlace, models.NearbyPlace),
+	save func(int64, models.NearbyPlace, models.NearbyPlace) error) (EnrichmentStats, error) {
+	stats := EnrichmentStats{Total: len(saved)}
+	for _, p := range saved {
+		place1, place2 := find(p)
+		sameNames := place1.Name == p.Name1 && place2.Name == p.Name2
+		if sameNames && !nearestMoved(p.Km1, place1.DistanceKm) && !nearestMoved(p.Km2, place2.DistanceKm) {
+			continue
+		}
+
+		if err := save(p.ID, place1, place2); err != nil {
+			log.Printf("Failed to save %s for property %d: %v", step, p.ID, err)
+			stats.Failed++
+			continue
+		}
+		if sameNames {
+			if err := s.db.MarkPropertyStepsStale(p.ID, reason, routeSteps...); err != nil {
+				return stats, err
+			}
+		}
+
+		log.Printf("Property %d: %s, %s -> %s (%.1f km), %s (%.1f km)",
+			p.ID, p.Name1, p.Name2, place1.Name, place1.DistanceKm, place2.Name, place2.DistanceKm)
+		s.recomputed(p.ID, step)
+		stats.Success++
+	}
+	return stats, nil
+}
+
+// nearestMoved reports whether a saved distance differs from the current one
+func nearestMoved(savedKm *float64, km float64) bool {
+	return savedKm == nil || math.Abs(*savedKm-km) > nearestMovedKm
+}
+
+// RecheckNearest runs ChangedNearestTowns and, if school data is loaded,
+// ChangedNearestSchools whether or not the lists changed since the last run,
+// then records them so MarkChangedInputs doesn't check again
+func (s *EnrichmentService) RecheckNearest() (towns, schools EnrichmentStats, err error) {
+	inputs, err := s.enrichmentInputs()
+	if err != nil {
+		return towns, schools, err
+	}
+	for _, input := range inputs {
+		var stats *EnrichmentStats
+		switch input.name {
+		case "towns":
+			stats = &towns
+		case "schools":
+			stats = &schools
+		default:
+			continue
+		}
+		if *stats, err = s.recheck(input.name)(); err != nil {
+			return towns, schools, err
+		}
+		if err := s.recordInput(input); err != nil {
+			return towns, schools, err
+		}
+	}
+	return towns, schools, nil
+}
+
+// recheck returns the function that updates only the properties a change to
+// the named input affects, or nil if every property has to be recomputed
+func (s *EnrichmentService) recheck(name string) func() (EnrichmentStats, error) {
+	switch name {
+	case "towns":
+		return s.ChangedNearestTowns
+	case "schools":
+		return s.ChangedNearestSchools
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package service
+
+import (
+	"fmt"
+	"log"
+
+	"farm-search/internal/db"
+	"farm-search/internal/geo"
+)
+
+// NoiseDistances saves each property's distance to the nearest highway,
+// railway and runway, as noise proxies. Only applies once at least one of
+// the layers has been imported with `tools noise`; a layer that hasn't been
+// leaves its distance NULL.
+func (s *EnrichmentService) NoiseDistances(all bool) (EnrichmentStats, error) {
+	properties, err := s.targets(db.StepNoiseSources, all, "noise source distances")
+	stats := EnrichmentStats{Total: len(properties)}
+	if err != nil || len(properties) == 0 {
+		return stats, err
+	}
+
+	layers := make([]*geo.Features, len(geo.NoiseLayers))
+	for i, layer := range geo.NoiseLayers {
+		if layers[i], err = loadExclusionLayer(s.db, layer); err != nil {
+			return stats, err
+		}
+	}
+
+	log.Printf("Measuring distances to highways, railways and runways for %d properties...", len(properties))
+
+	for i, p := range properties {
+		distances := make([]*float64, len(layers))
+		for j, features := range layers {
+			if km, ok := features.Distance(p.Latitude, p.Longitude); ok {
+				distances[j] = &km
+			}
+		}
+
+		if err := s.db.UpdatePropertyNoiseDistances(p.ID, distances[0], distances[1], distances[2]); err != nil {
+			log.Printf("[%d/%d] Failed to save for property %d: %v", i+1, len(properties), p.ID, err)
+			stats.Failed++
+			continue
+		}
+
+		log.Printf("[%d/%d] Property %d (%s): highway %s, railway %s, runway %s",
+			i+1, len(properties), p.ID, p.Suburb, kmString(distances[0]), kmString(distances[1]), kmString(distances[2]))
+		s.recomputed(p.ID, db.StepNoiseSources)
+		stats.Success++
+	}
+	return stats, nil
+}
+
+// kmString formats a distance for logs
+func kmString(km *float64) string {
+	if km == nil {
+		return "?"
+	}
+	return fmt.Sprintf("%.1f km", *km)
+}
+
+// MarkNoiseSourcesChanged is MarkChangedInputs for the noise source layers
+// alone, for right after an import
+func (s *EnrichmentService) MarkNoiseSourcesChanged() error {
+	input, err := s.noiseSourcesInput()
+	if err != nil || input == nil {
+		return err
+	}
+	return s.markIfChanged(*input)
+}
+
+// noiseSourcesInput returns the noise source layers' versions as an
+// enrichment input, so reimporting any of them recomputes every property.
+// Nil if none have been imported.
+func (s *EnrichmentService) noiseSourcesInput() (*enrichmentInput, error) {
+	versions := make(map[string]string)
+	for _, layer := range geo.NoiseLayers {
+		version, err := s.db.GetExclusionLayerVersion(layer)
+		if err != nil {
+			return nil, err
+		}
+		if version != "0|" {
+			versions[layer] = version
+		}
+	}
+	if len(versions) == 0 {
+		return nil, nil
+	}
+	return &enrichmentInput{"noise_sources", versions, []db.EnrichmentStep{db.StepNoiseSources}}, nil
+}
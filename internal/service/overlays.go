@@ -0,0 +1,150 @@
+package service
+
+import (
+	"fmt"
+	"log"
+	"math"
+
+	"farm-search/internal/db"
+	"farm-search/internal/geo"
+)
+
+// Overlays measures how much of each property's cadastral lots the koala
+// habitat and biodiversity values overlays cover, saving the percentage per
+// lot and over all of the property's lots (weighted by lot area). Only
+// applies once an overlay has been imported with `tools overlays`; one that
+// hasn't been leaves its percentage NULL.
+func (s *EnrichmentService) Overlays(all bool) (EnrichmentStats, error) {
+	properties, err := s.targets(db.StepOverlays, all, "planning overlay")
+	stats := EnrichmentStats{Total: len(properties)}
+	if err != nil || len(properties) == 0 {
+		return stats, err
+	}
+
+	overlays := make([]*geo.Overlay, len(geo.OverlayLayers))
+	for i, layer := range geo.OverlayLayers {
+		fc, err := exclusionLayerCollection(s.db, layer)
+		if err != nil {
+			return stats, err
+		}
+		overlay, err := geo.NewOverlay(fc)
+		if err != nil {
+			return stats, fmt.Errorf("overlay %s: %w", layer, err)
+		}
+		if !overlay.Empty() {
+			overlays[i] = overlay
+		}
+	}
+
+	log.Printf("Measuring koala habitat and biodiversity values coverage for %d properties...", len(properties))
+
+	for i, p := range properties {
+		lots, err := s.db.GetPropertyLots(p.ID)
+		if err != nil {
+			log.Printf("[%d/%d] Failed to get lots for property %d: %v", i+1, len(properties), p.ID, err)
+			stats.Failed++
+			continue
+		}
+
+		// Area-weighted sums of each overlay's coverage over the lots
+		covered := make([]float64, len(overlays))
+		var totalWeight float64
+		failed := false
+		for _, lot := range lots {
+			area, err := geo.ParsePolygon(lot.Geometry)
+			if err != nil {
+				log.Printf("  Warning: Could not parse lot %s: %v", lot.LotIDString, err)
+				failed = true
+				break
+			}
+			weight := lot.AreaSqm
+			if weight <= 0 {
+				weight = 1
+			}
+			totalWeight += weight
+
+			affected := make(map[string]float64)
+			for j, overlay := range overlays {
+				if overlay == nil {
+					continue
+				}
+				fraction := overlay.CoveredFraction(area)
+				affected[geo.OverlayLayers[j]] = roundPct(fraction)
+				covered[j] += fraction * weight
+			}
+			if err := s.db.ReplaceLotOverlays(lot.ID, affected); err != nil {
+				log.Printf("  Warning: Could not save overlays for lot %s: %v", lot.LotIDString, err)
+				failed = true
+				break
+			}
+		}
+		if failed || totalWeight == 0 {
+			log.Printf("[%d/%d] Failed for property %d (%s)", i+1, len(properties), p.ID, p.Suburb)
+			stats.Failed++
+			continue
+		}
+
+		pcts := make([]*float64, len(overlays))
+		for j, overlay := range overlays {
+			if overlay != nil {
+				pct := roundPct(covered[j] / totalWeight)
+				pcts[j] = &pct
+			}
+		}
+		if err := s.db.UpdatePropertyOverlays(p.ID, pcts[0], pcts[1]); err != nil {
+			log.Printf("[%d/%d] Failed to save for property %d: %v", i+1, len(properties), p.ID, err)
+			stats.Failed++
+			continue
+		}
+
+		log.Printf("[%d/%d] Property %d (%s): koala habitat %s, biodiversity values %s",
+			i+1, len(properties), p.ID, p.Suburb, pctString(pcts[0]), pctString(pcts[1]))
+		s.recomputed(p.ID, db.StepOverlays)
+		stats.Success++
+	}
+	return stats, nil
+}
+
+// roundPct converts a fraction to a percentage to one decimal place, which is
+// all the sampling resolves
+func roundPct(fraction float64) float64 {
+	return math.Round(fraction*1000) / 10
+}
+
+// pctString formats a percentage for logs
+func pctString(pct *float64) string {
+	if pct == nil {
+		return "?"
+	}
+	return fmt.Sprintf("%.1f%%", *pct)
+}
+
+// MarkOverlaysChanged is MarkChangedInputs for the overlay layers alone, for
+// right after an import
+func (s *EnrichmentService) MarkOverlaysChanged() error {
+	input, err := s.overlaysInput()
+	if err != nil || input == nil {
+		return err
+	}
+	return s.markIfChanged(*input)
+}
+
+// overlaysInput returns the overlay layers' versions as an enrichment input,
+// so reimporting either recomputes every property. Nil if neither has been
+// imported.
+func (s *EnrichmentService) overlaysInput() (*enrichmentInput, error) {
+	versions := make(map[string]string)
+	for _, layer := range geo.OverlayLayers {
+		version, err := s.db.GetExclusionLayerVersion(layer)
+		if err != nil {
+			return nil, err
+		}
+		if version != "0|" {
+			versions[layer] = version
+		}
+	}
+	if len(versions) == 0 {
+		return nil, nil
+	}
+	return &enrichmentInput{"overlays", versions, []db.EnrichmentStep{db.StepOverlays}}, nil
+}
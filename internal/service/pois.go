@@ -0,0 +1,65 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"farm-search/internal/db"
+	"farm-search/internal/geo"
+)
+
+// POIDriveTimes saves the straight-line distance and drive time from each
+// property to the user POIs (friends' houses, favourite trailheads) it hasn't
+// been measured against yet, or to all of them if all is set or the step is
+// stale for it. Routed from the access point like the other drive times; a
+// route held for review or that fails is saved without a drive time. Needs a
+// router; stops like DriveTimesToAnchor if Valhalla goes down.
+func (s *EnrichmentService) POIDriveTimes(ctx context.Context, all bool) (EnrichmentStats, error) {
+	properties, err := s.targets(db.StepPOIDriveTimes, all, "POI drive time calculation")
+	stats := EnrichmentStats{Total: len(properties)}
+	if err != nil || len(properties) == 0 {
+		return stats, err
+	}
+
+	log.Printf("Calculating drive times to user POIs for %d properties...", len(properties))
+
+	for i, p := range properties {
+		pois, err := s.db.GetPOIsToRoute(p.ID, all)
+		if err != nil {
+			return stats, err
+		}
+
+		var saved []string
+		for _, poi := range pois {
+			km := geo.Haversine(p.Latitude, p.Longitude, poi.Latitude, poi.Longitude)
+			var mins *int
+			m, err := s.driveMins(ctx, p, db.RouteTargetPOI, poi.Name, poi.Latitude, poi.Longitude)
+			if errors.Is(err, geo.ErrValhallaUnavailable) {
+				return stats, err
+			}
+			if err != nil {
+				log.Printf("[%d/%d] No drive time to %s for property %d: %v", i+1, len(properties), poi.Name, p.ID, err)
+			} else {
+				mins = &m
+			}
+			if err := s.db.SavePropertyPOITime(p.ID, poi.ID, km, mins); err != nil {
+				log.Printf("[%d/%d] Failed to save for property %d: %v", i+1, len(properties), p.ID, err)
+				saved = nil
+				break
+			}
+			saved = append(saved, fmt.Sprintf("%s (%s)", poi.Name, minsString(mins)))
+		}
+		if len(saved) < len(pois) {
+			stats.Failed++
+			continue
+		}
+
+		log.Printf("[%d/%d] Property %d (%s): %s", i+1, len(properties), p.ID, location(p), strings.Join(saved, ", "))
+		s.recomputed(p.ID, db.StepPOIDriveTimes)
+		stats.Success++
+	}
+	return stats, nil
+}
@@ -0,0 +1,396 @@
+// Package service holds the business rules shared by the API server and the
+// command line tools, on top of the db package's queries.
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"farm-search/internal/db"
+	"farm-search/internal/geo"
+	"farm-search/internal/models"
+)
+
+// Errors returned by LinkDuplicate, RejectSuspect and AttachmentService.Add
+// for requests that can't be carried out
+var (
+	ErrPropertyNotFound = errors.New("property not found")
+	ErrSelfLink         = errors.New("a property can't be a duplicate of itself")
+	ErrLinked           = errors.New("the properties are linked; reject the link instead")
+)
+
+// MaxListLimit is the most properties a list request can ask for
+const MaxListLimit = 500
+
+// PropertyService looks up properties for display, showing each real-world
+// property once (its canonical listing) however many sites list it
+type PropertyService struct {
+	db         *db.DB
+	isochrones *IsochroneService
+	exclusions *exclusionLayers
+
+	// what3words is nil when no API key is configured
+	what3words *geo.What3WordsClient
+
+	// regions are the services for region databases federated in (see WithRegions)
+	regions []*PropertyService
+}
+
+// NewPropertyService creates a new PropertyService. isochrones generates the
+// areas for drive time area filters.
+func NewPropertyService(database *db.DB, isochrones *IsochroneService) *PropertyService {
+	return &PropertyService{db: database, isochrones: isochrones, exclusions: newExclusionLayers(database)}
+}
+
+// WithWhat3Words returns a copy of the service that adds what3words
+// addresses to Detail's share locations with client (nil for none)
+func (s *PropertyService) WithWhat3Words(client *geo.What3WordsClient) *PropertyService {
+	scoped := *s
+	scoped.what3words = client
+	return &scoped
+}
+
+// List returns canonical properties matching f. A limit over MaxListLimit is
+// capped; no limit returns every match, as the map needs. A drive time area
+// filter generates (or reuses) its isochrone, which can fail if Valhalla is
+// down.
+func (s *PropertyService) List(ctx context.Context, f db.PropertyFilter) ([]models.PropertyListItem, error) {
+	if f.Limit > MaxListLimit {
+		f.Limit = MaxListLimit
+	}
+	spatial, err := s.Spatial(ctx, f)
+	if err != nil {
+		return nil, err
+	}
+	if len(s.regions) > 0 {
+		return s.listRegions(f, spatial)
+	}
+	if spatial.Empty() {
+		return s.db.ListProperties(f)
+	}
+	return s.listSpatial(f, spatial)
+}
+
+// Spatial returns the point tests f needs beyond the database query: its
+// drive time area, whose isochrone may have to be generated, its drawn
+// search area, and its exclusions. An exclude_near layer that hasn't been
+// imported excludes nothing.
+func (s *PropertyService) Spatial(ctx context.Context, f db.PropertyFilter) (*SpatialFilter, error) {
+	sf := &SpatialFilter{}
+	if f.WithinLat != nil && f.WithinLng != nil && f.WithinMinutes != nil {
+		area, err := s.isochrones.Area(ctx, *f.WithinLat, *f.WithinLng, *f.WithinMinutes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get drive time area: %w", err)
+		}
+		sf.areas = append(sf.areas, area)
+	}
+	if f.SearchArea != nil {
+		sf.areas = append(sf.areas, f.SearchArea)
+	}
+	if f.ExcludeArea != nil {
+		sf.excludeAreas = append(sf.excludeAreas, f.ExcludeArea)
+	}
+	for _, r := range f.ExcludeNear {
+		features, err := s.exclusions.get(r.Layer)
+		if err != nil {
+			return nil, err
+		}
+		if !features.Empty() {
+			sf.near = append(sf.near, nearExclusion{features: features, km: r.Km})
+		}
+	}
+	return sf, nil
+}
+
+// listSpatial lists the properties matching f that pass its spatial tests.
+// The areas' bounding boxes narrow the query, then each match is tested
+// before paginating.
+func (s *PropertyService) listSpatial(f db.PropertyFilter, spatial *SpatialFilter) ([]models.PropertyListItem, error) {
+	for _, area := range spatial.areas {
+		if area.Empty() {
+			return []models.PropertyListItem{}, nil
+		}
+		swLat, swLng, neLat, neLng := area.Bounds()
+		if f.SWLat != nil && f.SWLng != nil && f.NELat != nil && f.NELng != nil {
+			swLat, swLng = max(swLat, *f.SWLat), max(swLng, *f.SWLng)
+			neLat, neLng = min(neLat, *f.NELat), min(neLng, *f.NELng)
+		}
+		f.SWLat, f.SWLng, f.NELat, f.NELng = &swLat, &swLng, &neLat, &neLng
+	}
+
+	limit, offset := f.Limit, f.Offset
+	f.Limit, f.Offset = 0, 0
+	candidates, err := s.db.ListProperties(f)
+	if err != nil {
+		return nil, err
+	}
+
+	properties := make([]models.PropertyListItem, 0, len(candidates))
+	for _, p := range candidates {
+		if spatial.Matches(p.Latitude, p.Longitude) {
+			properties = append(properties, p)
+		}
+	}
+
+	if offset >= len(properties) {
+		return []models.PropertyListItem{}, nil
+	}
+	properties = properties[offset:]
+	if limit > 0 && limit < len(properties) {
+		properties = properties[:limit]
+	}
+	return properties, nil
+}
+
+// Newest returns up to limit canonical properties matching f, most recently
+// first seen first. f's own sort and pagination are ignored.
+func (s *PropertyService) Newest(ctx context.Context, f db.PropertyFilter, limit int) ([]models.PropertyListItem, error) {
+	f.Sort = "-first_seen_at"
+	f.Limit = limit
+	f.Offset = 0
+	return s.List(ctx, f)
+}
+
+// Neighbours returns the other canonical properties within km of p's point,
+// closest first, so what else is for sale nearby can be seen alongside it
+func (s *PropertyService) Neighbours(p *models.PropertyDetail, km float64) ([]models.Neighbour, error) {
+	swLat, swLng, neLat, neLng := geo.BoundsAround(p.Latitude, p.Longitude, km)
+	candidates, err := s.listRegions(db.PropertyFilter{SWLat: &swLat, SWLng: &swLng, NELat: &neLat, NELng: &neLng}, &SpatialFilter{})
+	if err != nil {
+		return nil, err
+	}
+
+	neighbours := []models.Neighbour{}
+	for _, c := range candidates {
+		if c.ID == p.ID {
+			continue
+		}
+		if d := geo.Haversine(p.Latitude, p.Longitude, c.Latitude, c.Longitude); d <= km {
+			neighbours = append(neighbours, models.Neighbour{PropertyListItem: c, DistanceKm: d})
+		}
+	}
+	sort.Slice(neighbours, func(i, j int) bool {
+		return neighbours[i].DistanceKm < neighbours[j].DistanceKm
+	})
+	return neighbours, nil
+}
+
+// Get returns a property's details. A duplicate listing resolves to its
+// canonical property, whose details include every source's link.
+func (s *PropertyService) Get(id int64) (*models.PropertyDetail, error) {
+	_, p, err := s.find(id)
+	return p, err
+}
+
+// find returns a property's details and the service for the database it's
+// in: this one's, or a region's
+func (s *PropertyService) find(id int64) (*PropertyService, *models.PropertyDetail, error) {
+	var err error
+	for _, shard := range s.shards() {
+		var canonicalID int64
+		if canonicalID, err = shard.db.GetCanonicalPropertyID(id); err != nil {
+			return nil, nil, err
+		}
+		var p *models.PropertyDetail
+		if p, err = shard.db.GetProperty(canonicalID); err == nil {
+			return shard, p, nil
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return nil, nil, err
+		}
+	}
+	return nil, nil, err
+}
+
+// Detail is Get with the property's share locations: its point and the
+// centroid of its cadastral lots (if it has any) as plus codes, with map
+// links and, if configured, what3words addresses. A what3words lookup that
+// fails is left out rather than failing the request.
+func (s *PropertyService) Detail(ctx context.Context, id int64) (*models.PropertyDetail, error) {
+	shard, p, err := s.find(id)
+	if err != nil {
+		return nil, err
+	}
+
+	p.Share = &models.PropertyShare{Point: s.shareLocation(ctx, p.Latitude, p.Longitude)}
+	lots, err := shard.db.GetPropertyLots(p.ID)
+	if err != nil {
+		log.Printf("Warning: could not get lots for property %d: %v", p.ID, err)
+	}
+	var areas []*geo.Area
+	for _, lot := range lots {
+		if area, err := geo.ParsePolygon(lot.Geometry); err == nil {
+			areas = append(areas, area)
+		}
+	}
+	if lat, lng, ok := geo.Centroid(areas...); ok {
+		centroid := s.shareLocation(ctx, lat, lng)
+		p.Share.LotCentroid = &centroid
+	}
+	return p, nil
+}
+
+// shareLocation returns a point's plus code, links and what3words address
+func (s *PropertyService) shareLocation(ctx context.Context, lat, lng float64) models.ShareLocation {
+	code := geo.PlusCode(lat, lng)
+	loc := models.ShareLocation{
+		Lat:         lat,
+		Lng:         lng,
+		PlusCode:    code,
+		PlusCodeURL: geo.PlusCodeURL(code),
+		MapsURL:     geo.GoogleMapsURL(lat, lng),
+	}
+	if s.what3words != nil {
+		w, err := s.what3words.Convert(ctx, lat, lng)
+		if err != nil {
+			log.Printf("Warning: %v", err)
+		} else {
+			loc.What3Words, loc.What3WordsURL = w.Words, w.URL
+		}
+	}
+	return loc
+}
+
+// GetMany returns details for each of ids that exists, keyed by canonical
+// property ID, so duplicates of one property appear once
+func (s *PropertyService) GetMany(ids []int64) map[int64]*models.PropertyDetail {
+	properties := make(map[int64]*models.PropertyDetail, len(ids))
+	for _, id := range ids {
+		if p, err := s.Get(id); err == nil {
+			properties[p.ID] = p
+		}
+	}
+	return properties
+}
+
+// GetList returns details for each of ids that exists, in the order asked
+// for, with a duplicate listing resolved to its canonical property and each
+// property once. The IDs that don't exist are returned as missing.
+func (s *PropertyService) GetList(ids []int64) (properties []*models.PropertyDetail, missing []int64, err error) {
+	seen := make(map[int64]bool, len(ids))
+	for _, id := range ids {
+		p, err := s.Get(id)
+		if errors.Is(err, sql.ErrNoRows) {
+			missing = append(missing, id)
+			continue
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		if !seen[p.ID] {
+			seen[p.ID] = true
+			properties = append(properties, p)
+		}
+	}
+	return properties, missing, nil
+}
+
+// Events returns inspections and auctions between from and to for the given
+// canonical properties, including those reported on their duplicate listings
+// (e.g. Domain's times for a property shown from REA). Events are attributed
+// to the canonical property, with the same event from several sources once.
+func (s *PropertyService) Events(ids []int64, from, to time.Time) ([]models.PropertyEvent, error) {
+	duplicates, err := s.db.GetDuplicatePropertyIDs(ids)
+	if err != nil {
+		return nil, err
+	}
+	allIDs := append([]int64{}, ids...)
+	for duplicateID := range duplicates {
+		allIDs = append(allIDs, duplicateID)
+	}
+
+	events, err := s.db.GetPropertyEvents(allIDs, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	type eventKey struct {
+		propertyID int64
+		kind       string
+		start      time.Time
+	}
+	seen := make(map[eventKey]bool)
+	result := make([]models.PropertyEvent, 0, len(events))
+	for _, e := range events {
+		if canonicalID, ok := duplicates[e.PropertyID]; ok {
+			e.PropertyID = canonicalID
+		}
+		key := eventKey{e.PropertyID, e.Kind, e.StartsAt.UTC()}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		result = append(result, e)
+	}
+	return result, nil
+}
+
+// LinkDuplicate manually links duplicateID to canonicalID's canonical
+// property and merges its fields on. If canonicalID is a duplicate of
+// duplicateID, the two swap roles.
+func (s *PropertyService) LinkDuplicate(canonicalID, duplicateID int64, note string) error {
+	for _, id := range []int64{canonicalID, duplicateID} {
+		if _, err := s.db.GetProperty(id); err != nil {
+			return fmt.Errorf("%w: %d", ErrPropertyNotFound, id)
+		}
+	}
+
+	// Link to the root of canonicalID's group, unless that's duplicateID itself
+	root, err := s.db.GetCanonicalPropertyID(canonicalID)
+	if err != nil {
+		return err
+	}
+	if root != duplicateID {
+		canonicalID = root
+	}
+	if canonicalID == duplicateID {
+		return ErrSelfLink
+	}
+
+	if err := s.db.CreatePropertyLink(canonicalID, duplicateID, note); err != nil {
+		return err
+	}
+	_, err = s.db.MergeDuplicateProperties()
+	return err
+}
+
+// RejectSuspect records that two listings suspected of being duplicates
+// aren't the same property, so they're neither linked nor suspected again.
+// Listings already linked to each other have to have the link rejected.
+func (s *PropertyService) RejectSuspect(canonicalID, duplicateID int64, note string) error {
+	if canonicalID == duplicateID {
+		return ErrSelfLink
+	}
+	var roots [2]int64
+	for i, id := range []int64{canonicalID, duplicateID} {
+		if _, err := s.db.GetProperty(id); err != nil {
+			return fmt.Errorf("%w: %d", ErrPropertyNotFound, id)
+		}
+		root, err := s.db.GetCanonicalPropertyID(id)
+		if err != nil {
+			return err
+		}
+		roots[i] = root
+	}
+	if roots[0] == roots[1] {
+		return ErrLinked
+	}
+	return s.db.RejectDuplicateSuspect(canonicalID, duplicateID, note)
+}
+
+// RejectDuplicate unlinks duplicateID from its canonical property for good,
+// restoring the canonical property's own fields and re-merging from its
+// remaining duplicates. Reports whether duplicateID was linked.
+func (s *PropertyService) RejectDuplicate(duplicateID int64, note string) (bool, error) {
+	found, err := s.db.RejectPropertyLink(duplicateID, note)
+	if err != nil || !found {
+		return found, err
+	}
+	_, err = s.db.MergeDuplicateProperties()
+	return true, err
+}
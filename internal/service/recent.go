@@ -0,0 +1,90 @@
+package service
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"farm-search/internal/db"
+	"farm-search/internal/models"
+)
+
+// RecentChanges returns the canonical properties matching f that came on
+// the market, dropped their asking price or came back on the market since a
+// time, for the kinds of change asked for, most recent first. A property is
+// listed once per kind, at its latest change of that kind; a price drop
+// runs from the highest price before to the lowest after, over the period.
+// f's sort is ignored; its limit (capped at MaxListLimit) and offset apply
+// to the changes.
+func (s *PropertyService) RecentChanges(ctx context.Context, f db.PropertyFilter, since time.Time, kinds []string) ([]models.RecentChange, error) {
+	limit, offset := f.Limit, f.Offset
+	if limit <= 0 || limit > MaxListLimit {
+		limit = MaxListLimit
+	}
+	f.Sort, f.Limit, f.Offset = "", 0, 0
+	spatial, err := s.Spatial(ctx, f)
+	if err != nil {
+		return nil, err
+	}
+
+	recent := []models.RecentChange{}
+	for _, shard := range s.shards() {
+		changes, err := shard.db.GetPropertyChanges(since, kinds)
+		if err != nil {
+			return nil, err
+		}
+		if len(changes) == 0 {
+			continue
+		}
+		matches, err := shard.listShard(f, spatial)
+		if err != nil {
+			return nil, err
+		}
+		byID := make(map[int64]models.PropertyListItem, len(matches))
+		for _, m := range matches {
+			byID[m.ID] = m
+		}
+
+		type key struct {
+			id   int64
+			kind string
+		}
+		latest := make(map[key]int) // Index in recent
+		for _, c := range changes {
+			p, ok := byID[c.PropertyID]
+			if !ok {
+				continue
+			}
+			i, seen := latest[key{c.PropertyID, c.Kind}]
+			if !seen {
+				latest[key{c.PropertyID, c.Kind}] = len(recent)
+				recent = append(recent, models.RecentChange{
+					PropertyListItem: p, Change: c.Kind, ChangedAt: c.ChangedAt,
+					PriceBefore: c.PriceBefore, PriceAfter: c.PriceAfter,
+				})
+				continue
+			}
+			// Changes are oldest first, so this one is later
+			r := &recent[i]
+			r.ChangedAt = c.ChangedAt
+			if c.PriceBefore != nil && (r.PriceBefore == nil || *c.PriceBefore > *r.PriceBefore) {
+				r.PriceBefore = c.PriceBefore
+			}
+			if c.PriceAfter != nil && (r.PriceAfter == nil || *c.PriceAfter < *r.PriceAfter) {
+				r.PriceAfter = c.PriceAfter
+			}
+		}
+	}
+
+	sort.SliceStable(recent, func(i, j int) bool {
+		return recent[i].ChangedAt.After(recent[j].ChangedAt)
+	})
+	if offset >= len(recent) {
+		return []models.RecentChange{}, nil
+	}
+	recent = recent[offset:]
+	if limit < len(recent) {
+		recent = recent[:limit]
+	}
+	return recent, nil
+}
@@ -0,0 +1,177 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"farm-search/internal/db"
+	"farm-search/internal/models"
+)
+
+// ErrRegionIDsOverlap is returned by WithRegions for databases whose
+// property IDs overlap, since an ID has to say which database it's in
+var ErrRegionIDsOverlap = errors.New("property IDs overlap")
+
+// Region is a database of one state's or region's properties, scraped and
+// enriched on its own (tools and scrapers take it as -db)
+type Region struct {
+	Name string // e.g. "vic", for messages
+	DB   *db.DB
+}
+
+// WithRegions returns a copy of the service that also lists and looks up the
+// properties in regions' databases, so a national dataset needn't be one
+// file. Lists are merged in the filter's sort order, with spatial filters
+// checked against this service's isochrones and exclusion layers; an ID is
+// looked up in whichever database has it, so no two databases' ID ranges may
+// overlap (see db.SetPropertyIDBase). Everything else (tags, saved searches,
+// scores, duplicate links) stays with this service's database.
+func (s *PropertyService) WithRegions(regions []Region) (*PropertyService, error) {
+	type idRange struct {
+		name     string
+		min, max int64
+	}
+	var ranges []idRange
+	all := append([]Region{{Name: "primary", DB: s.db}}, regions...)
+	for _, r := range all {
+		lo, hi, err := r.DB.PropertyIDRange()
+		if err != nil {
+			return nil, fmt.Errorf("region %s: %w", r.Name, err)
+		}
+		if hi == 0 {
+			continue
+		}
+		for _, other := range ranges {
+			if lo <= other.max && other.min <= hi {
+				return nil, fmt.Errorf("%w: %s (%d-%d) and %s (%d-%d)", ErrRegionIDsOverlap,
+					other.name, other.min, other.max, r.Name, lo, hi)
+			}
+		}
+		ranges = append(ranges, idRange{r.Name, lo, hi})
+	}
+
+	scoped := *s
+	scoped.regions = make([]*PropertyService, len(regions))
+	for i, r := range regions {
+		region := *s
+		region.db = r.DB
+		region.regions = nil
+		scoped.regions[i] = &region
+	}
+	return &scoped, nil
+}
+
+// shards returns this service followed by its regions'
+func (s *PropertyService) shards() []*PropertyService {
+	return append([]*PropertyService{s}, s.regions...)
+}
+
+// listRegions lists the matches in this and every region's database, merged
+// in f's sort order (unsorted, by database), then paginates
+func (s *PropertyService) listRegions(f db.PropertyFilter, spatial *SpatialFilter) ([]models.PropertyListItem, error) {
+	limit, offset := f.Limit, f.Offset
+	if limit > 0 {
+		f.Limit = limit + offset
+	}
+	f.Offset = 0
+
+	properties := []models.PropertyListItem{}
+	for _, shard := range s.shards() {
+		matches, err := shard.listShard(f, spatial)
+		if err != nil {
+			return nil, err
+		}
+		properties = append(properties, matches...)
+	}
+	if len(s.regions) > 0 && f.Sort != "" {
+		sortListItems(properties, f.Sort)
+	}
+
+	if offset >= len(properties) {
+		return []models.PropertyListItem{}, nil
+	}
+	properties = properties[offset:]
+	if limit > 0 && limit < len(properties) {
+		properties = properties[:limit]
+	}
+	return properties, nil
+}
+
+// listShard lists the matches in this database alone, ignoring regions
+func (s *PropertyService) listShard(f db.PropertyFilter, spatial *SpatialFilter) ([]models.PropertyListItem, error) {
+	if spatial.Empty() {
+		return s.db.ListProperties(f)
+	}
+	return s.listSpatial(f, spatial)
+}
+
+// sortListItems sorts properties as ListProperties does for key ("-" first
+// for descending), with missing values last
+func sortListItems(properties []models.PropertyListItem, key string) {
+	descending := strings.HasPrefix(key, "-")
+	key = strings.TrimPrefix(key, "-")
+	sort.SliceStable(properties, func(i, j int) bool {
+		a, b := listSortValue(properties[i], key), listSortValue(properties[j], key)
+		switch {
+		case a == nil:
+			return false
+		case b == nil:
+			return true
+		}
+		c := compareSortValues(a, b)
+		if descending {
+			return c > 0
+		}
+		return c < 0
+	})
+}
+
+// listSortValue returns a listed property's value for a sort key, or nil
+func listSortValue(p models.PropertyListItem, key string) interface{} {
+	var v *float64
+	switch key {
+	case "score":
+		v = p.Score
+	case "asking_vs_land_value_ratio":
+		v = p.AskingVsLandValue
+	default:
+		return p.SortValue
+	}
+	if v == nil {
+		return nil
+	}
+	return *v
+}
+
+// compareSortValues compares two values of the same sort column
+func compareSortValues(a, b interface{}) int {
+	switch av := a.(type) {
+	case time.Time:
+		bv, _ := b.(time.Time)
+		return av.Compare(bv)
+	case string:
+		bv, _ := b.(string)
+		return strings.Compare(av, bv)
+	}
+	af, bf := sortNumber(a), sortNumber(b)
+	switch {
+	case af < bf:
+		return -1
+	case af > bf:
+		return 1
+	}
+	return 0
+}
+
+func sortNumber(v interface{}) float64 {
+	switch n := v.(type) {
+	case float64:
+		return n
+	case int64:
+		return float64(n)
+	}
+	return 0
+}
@@ -0,0 +1,153 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"time"
+
+	"farm-search/internal/db"
+	"farm-search/internal/models"
+)
+
+// ErrNoSnapshot is returned when diffing a saved search that has never been
+// snapshotted
+var ErrNoSnapshot = errors.New("saved search has no snapshots yet")
+
+// SavedSearchService snapshots what saved searches match so they can be
+// diffed over time
+type SavedSearchService struct {
+	db         *db.DB
+	properties *PropertyService
+}
+
+// NewSavedSearchService creates a new SavedSearchService
+func NewSavedSearchService(database *db.DB, properties *PropertyService) *SavedSearchService {
+	return &SavedSearchService{db: database, properties: properties}
+}
+
+// matchIDs returns the canonical properties a saved search matches now,
+// ignoring the sort and pagination in its query
+func (s *SavedSearchService) matchIDs(ctx context.Context, search *models.SavedSearch) ([]int64, error) {
+	// The query was validated when saved
+	values, _ := url.ParseQuery(search.Query)
+	f := ParsePropertyFilter(values)
+	f.Sort, f.Limit, f.Offset = "", 0, 0
+
+	matches, err := s.properties.List(ctx, f)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]int64, len(matches))
+	for i, m := range matches {
+		ids[i] = m.ID
+	}
+	return ids, nil
+}
+
+// Create saves a search and snapshots its matches as the baseline for diffs.
+// Matching runs first, so a query that can't be run (Valhalla down for a
+// drive time area) isn't saved.
+func (s *SavedSearchService) Create(ctx context.Context, search *models.SavedSearch) error {
+	ids, err := s.matchIDs(ctx, search)
+	if err != nil {
+		return err
+	}
+	if err := s.db.CreateSavedSearch(search); err != nil {
+		return err
+	}
+	_, err = s.db.SaveSearchSnapshot(search.ID, ids)
+	return err
+}
+
+// Snapshot records what a saved search matches now, returning the number of
+// matches
+func (s *SavedSearchService) Snapshot(ctx context.Context, search *models.SavedSearch) (int, error) {
+	ids, err := s.matchIDs(ctx, search)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := s.db.SaveSearchSnapshot(search.ID, ids); err != nil {
+		return 0, err
+	}
+	return len(ids), nil
+}
+
+// Diff compares what a saved search matches now with its latest snapshot at
+// or before since (or its earliest, if all are later; BaselineAt says which
+// was used). Properties that match in both but whose price, land size or
+// type changed are reported as changed.
+func (s *SavedSearchService) Diff(ctx context.Context, search *models.SavedSearch, since time.Time) (*models.SavedSearchDiff, error) {
+	baselineAt, before, found, err := s.db.GetSearchSnapshotAt(search.ID, since)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, ErrNoSnapshot
+	}
+
+	ids, err := s.matchIDs(ctx, search)
+	if err != nil {
+		return nil, err
+	}
+	now, err := s.db.GetSearchMatches(ids)
+	if err != nil {
+		return nil, err
+	}
+
+	diff := &models.SavedSearchDiff{
+		SearchID:   search.ID,
+		Since:      since,
+		BaselineAt: baselineAt,
+		New:        []models.SearchMatch{},
+		Removed:    []models.SearchMatch{},
+		Changed:    []models.SearchMatchChange{},
+	}
+
+	then := make(map[int64]models.SearchMatch, len(before))
+	for _, m := range before {
+		then[m.PropertyID] = m
+	}
+	for _, m := range now {
+		old, ok := then[m.PropertyID]
+		if !ok {
+			diff.New = append(diff.New, m)
+			continue
+		}
+		delete(then, m.PropertyID)
+		if changes := matchChanges(old, m); len(changes) > 0 {
+			diff.Changed = append(diff.Changed, models.SearchMatchChange{Property: m, Changes: changes})
+		}
+	}
+	for _, m := range before {
+		if _, ok := then[m.PropertyID]; ok {
+			diff.Removed = append(diff.Removed, m)
+		}
+	}
+	return diff, nil
+}
+
+// matchChanges lists the compared fields that differ between two states of
+// a property
+func matchChanges(before, after models.SearchMatch) []models.FieldChange {
+	var changes []models.FieldChange
+	add := func(field string, b, a interface{}, changed bool) {
+		if changed {
+			changes = append(changes, models.FieldChange{Field: field, Before: b, After: a})
+		}
+	}
+	add("price_text", before.PriceText, after.PriceText, !equalPtr(before.PriceText, after.PriceText))
+	add("price_min", before.PriceMin, after.PriceMin, !equalPtr(before.PriceMin, after.PriceMin))
+	add("price_max", before.PriceMax, after.PriceMax, !equalPtr(before.PriceMax, after.PriceMax))
+	add("land_size_sqm", before.LandSizeSqm, after.LandSizeSqm, !equalPtr(before.LandSizeSqm, after.LandSizeSqm))
+	add("property_type", before.PropertyType, after.PropertyType, !equalPtr(before.PropertyType, after.PropertyType))
+	return changes
+}
+
+// equalPtr reports whether two optional values are both unset or equal
+func equalPtr[T comparable](a, b *T) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
@@ -0,0 +1,214 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"farm-search/internal/db"
+	"farm-search/internal/models"
+)
+
+// ErrInvalidWeights is returned for a score profile whose weights can't be
+// scored with
+var ErrInvalidWeights = errors.New("invalid weights")
+
+// ScoreCriterion is something properties are scored on
+type ScoreCriterion struct {
+	Key         string `json:"key"`
+	Description string `json:"description"`
+	LowerBetter bool   `json:"lower_better"`
+
+	value func(in models.ScoringInput) *float64
+}
+
+// ScoreCriteria are the criteria a profile can weight. Rainfall, slope and
+// hazards need data sources before they can join.
+var ScoreCriteria = []ScoreCriterion{
+	{Key: "drive_time_primary", Description: "Drive time to the anchor", LowerBetter: true,
+		value: func(in models.ScoringInput) *float64 { return intValue(in.DriveTimePrimary) }},
+	{Key: "drive_time_town", Description: "Drive time to the nearest town", LowerBetter: true,
+		value: func(in models.ScoringInput) *float64 { return intValue(in.DriveTimeTown) }},
+	{Key: "drive_time_school", Description: "Drive time to the nearest school", LowerBetter: true,
+		value: func(in models.ScoringInput) *float64 { return intValue(in.DriveTimeSchool) }},
+	{Key: "price_per_ha", Description: "Asking price per hectare", LowerBetter: true,
+		value: func(in models.ScoringInput) *float64 {
+			if in.PriceMid == nil || in.LandSizeSqm == nil || *in.LandSizeSqm <= 0 {
+				return nil
+			}
+			v := *in.PriceMid / (*in.LandSizeSqm / 10000)
+			return &v
+		}},
+	{Key: "land_size", Description: "Land size", LowerBetter: false,
+		value: func(in models.ScoringInput) *float64 { return in.LandSizeSqm }},
+	{Key: "asking_vs_land_value", Description: "Asking price over land value", LowerBetter: true,
+		value: func(in models.ScoringInput) *float64 { return in.AskingVsLandValue }},
+}
+
+func intValue(v *int) *float64 {
+	if v == nil {
+		return nil
+	}
+	f := float64(*v)
+	return &f
+}
+
+// ScoringService manages score profiles and the property scores computed
+// from them
+type ScoringService struct {
+	db *db.DB
+}
+
+// NewScoringService creates a new ScoringService
+func NewScoringService(database *db.DB) *ScoringService {
+	return &ScoringService{db: database}
+}
+
+// ValidateWeights checks every weight is for a known criterion, none is
+// negative, and at least one is positive
+func ValidateWeights(weights map[string]float64) error {
+	var total float64
+	for key, w := range weights {
+		if criterion(key) == nil {
+			keys := make([]string, len(ScoreCriteria))
+			for i, c := range ScoreCriteria {
+				keys[i] = c.Key
+			}
+			return fmt.Errorf("%w: unknown criterion %q (known: %s)", ErrInvalidWeights, key, strings.Join(keys, ", "))
+		}
+		if w < 0 {
+			return fmt.Errorf("%w: %s weight is negative", ErrInvalidWeights, key)
+		}
+		total += w
+	}
+	if total == 0 {
+		return fmt.Errorf("%w: at least one weight must be positive", ErrInvalidWeights)
+	}
+	return nil
+}
+
+func criterion(key string) *ScoreCriterion {
+	for i := range ScoreCriteria {
+		if ScoreCriteria[i].Key == key {
+			return &ScoreCriteria[i]
+		}
+	}
+	return nil
+}
+
+// Create saves a new profile and scores every property with it
+func (s *ScoringService) Create(p *models.ScoreProfile) error {
+	if err := ValidateWeights(p.Weights); err != nil {
+		return err
+	}
+	if err := s.db.CreateScoreProfile(p); err != nil {
+		return err
+	}
+	_, err := s.Compute(p)
+	return err
+}
+
+// Update saves a profile's name and weights and rescores every property,
+// reporting whether the profile exists
+func (s *ScoringService) Update(p *models.ScoreProfile) (bool, error) {
+	if err := ValidateWeights(p.Weights); err != nil {
+		return false, err
+	}
+	found, err := s.db.UpdateScoreProfile(p)
+	if err != nil || !found {
+		return found, err
+	}
+	_, err = s.Compute(p)
+	return true, err
+}
+
+// ComputeAll rescores every property with every profile, e.g. after
+// scraping or enrichment changed their data. Returns the number of profiles.
+func (s *ScoringService) ComputeAll() (int, error) {
+	profiles, err := s.db.ListScoreProfiles()
+	if err != nil {
+		return 0, err
+	}
+	for i := range profiles {
+		if _, err := s.Compute(&profiles[i]); err != nil {
+			return i, fmt.Errorf("failed to score profile %q: %w", profiles[i].Name, err)
+		}
+	}
+	return len(profiles), nil
+}
+
+// Compute scores every canonical property with a profile and stores the
+// scores, returning how many properties were scored.
+//
+// Each criterion scores a property 0-1 by its percentile among the
+// properties that have a value (ties share their average rank), so units
+// don't matter and outliers don't squash everyone else. A property's score is
+// the weighted mean of its criteria scores, out of 100, over the criteria it
+// has values for; a property with none isn't scored.
+func (s *ScoringService) Compute(p *models.ScoreProfile) (int, error) {
+	inputs, err := s.db.GetScoringInputs()
+	if err != nil {
+		return 0, err
+	}
+
+	weighted := make(map[int64]float64, len(inputs))
+	weightSum := make(map[int64]float64, len(inputs))
+	for key, w := range p.Weights {
+		c := criterion(key)
+		if c == nil || w == 0 {
+			continue
+		}
+		for id, pct := range percentiles(inputs, c) {
+			weighted[id] += w * pct
+			weightSum[id] += w
+		}
+	}
+
+	scores := make(map[int64]float64, len(weighted))
+	for id, sum := range weighted {
+		scores[id] = 100 * sum / weightSum[id]
+	}
+	if err := s.db.ReplacePropertyScores(p.ID, scores); err != nil {
+		return 0, err
+	}
+	return len(scores), nil
+}
+
+// percentiles scores each property with a value for c 0 (worst) to 1 (best)
+// by its rank among them
+func percentiles(inputs []models.ScoringInput, c *ScoreCriterion) map[int64]float64 {
+	type ranked struct {
+		id    int64
+		value float64
+	}
+	var values []ranked
+	for _, in := range inputs {
+		if v := c.value(in); v != nil {
+			values = append(values, ranked{in.PropertyID, *v})
+		}
+	}
+	sort.Slice(values, func(i, j int) bool { return values[i].value < values[j].value })
+
+	pcts := make(map[int64]float64, len(values))
+	if len(values) == 1 {
+		pcts[values[0].id] = 1
+		return pcts
+	}
+	for i := 0; i < len(values); {
+		// Ties get the average of the ranks they span
+		j := i
+		for j < len(values) && values[j].value == values[i].value {
+			j++
+		}
+		pct := float64(i+j-1) / 2 / float64(len(values)-1)
+		if c.LowerBetter {
+			pct = 1 - pct
+		}
+		for k := i; k < j; k++ {
+			pcts[values[k].id] = pct
+		}
+		i = j
+	}
+	return pcts
+}
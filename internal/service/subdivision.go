@@ -0,0 +1,113 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"farm-search/internal/db"
+	"farm-search/internal/geo"
+	"farm-search/internal/models"
+)
+
+// WithLotSize returns a copy of the service that looks up minimum lot sizes
+// with client
+func (s *EnrichmentService) WithLotSize(client *geo.LotSizeClient) *EnrichmentService {
+	scoped := *s
+	scoped.lotSize = client
+	return &scoped
+}
+
+// Subdivision looks up the LEP minimum lot size over each property's
+// cadastral lots, saving the largest (the most restrictive) and the
+// subdivision ratio: the lots' total area over it, so a ratio of 2 or more
+// could in theory be split into that many lots. Lots already checked are
+// reused unless all is set. Needs a lot size client, and lots from
+// CadastralLots.
+func (s *EnrichmentService) Subdivision(ctx context.Context, all bool) (EnrichmentStats, error) {
+	properties, err := s.targets(db.StepSubdivision, all, "minimum lot size")
+	stats := EnrichmentStats{Total: len(properties)}
+	if err != nil || len(properties) == 0 {
+		return stats, err
+	}
+
+	log.Printf("Checking minimum lot sizes for %d properties...", len(properties))
+
+	for i, p := range properties {
+		lots, err := s.db.GetPropertyLots(p.ID)
+		if err != nil {
+			log.Printf("[%d/%d] Failed to get lots for property %d: %v", i+1, len(properties), p.ID, err)
+			stats.Failed++
+			continue
+		}
+
+		var minLotSqm, totalSqm float64
+		failed := false
+		for _, lot := range lots {
+			if lot.MinLotSizeSqm == nil || all {
+				sqm, err := s.checkLotSize(ctx, lot)
+				if err != nil {
+					log.Printf("  Warning: Could not check lot %s: %v", lot.LotIDString, err)
+					failed = true
+					continue
+				}
+				lot.MinLotSizeSqm = &sqm
+				// Rate limiting to avoid overloading the planning portal
+				time.Sleep(500 * time.Millisecond)
+			}
+			minLotSqm = math.Max(minLotSqm, *lot.MinLotSizeSqm)
+			totalSqm += lot.AreaSqm
+		}
+		// A lot left out could hold the largest minimum, so leave it missing
+		if failed {
+			log.Printf("[%d/%d] Failed for property %d (%s)", i+1, len(properties), p.ID, location(p))
+			stats.Failed++
+			continue
+		}
+
+		var ratio *float64
+		if minLotSqm > 0 {
+			r := math.Round(totalSqm/minLotSqm*100) / 100
+			ratio = &r
+		}
+		if err := s.db.UpdatePropertySubdivision(p.ID, minLotSqm, ratio); err != nil {
+			log.Printf("[%d/%d] Failed to save for property %d: %v", i+1, len(properties), p.ID, err)
+			stats.Failed++
+			continue
+		}
+
+		if ratio == nil {
+			log.Printf("[%d/%d] Property %d (%s): no minimum lot size mapped", i+1, len(properties), p.ID, location(p))
+		} else {
+			log.Printf("[%d/%d] Property %d (%s): minimum lot size %s, ratio %.2f",
+				i+1, len(properties), p.ID, location(p), formatLotSize(minLotSqm), *ratio)
+		}
+		s.recomputed(p.ID, db.StepSubdivision)
+		stats.Success++
+	}
+	return stats, nil
+}
+
+// checkLotSize fetches the minimum lot size over a lot and saves it
+func (s *EnrichmentService) checkLotSize(ctx context.Context, lot models.CadastralLot) (float64, error) {
+	var geom geo.LotGeometry
+	if err := json.Unmarshal([]byte(lot.Geometry), &geom); err != nil {
+		return 0, err
+	}
+	sqm, err := s.lotSize.FetchMinLotSize(ctx, &geom)
+	if err != nil {
+		return 0, err
+	}
+	return sqm, s.db.UpdateLotMinLotSize(lot.ID, sqm)
+}
+
+// formatLotSize formats a lot size in hectares, or square metres under one
+func formatLotSize(sqm float64) string {
+	if sqm >= 10000 {
+		return fmt.Sprintf("%g ha", math.Round(sqm/100)/100)
+	}
+	return fmt.Sprintf("%.0f sqm", sqm)
+}
@@ -0,0 +1,170 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+
+	"farm-search/internal/db"
+	"farm-search/internal/geo"
+	"farm-search/internal/models"
+)
+
+// SuburbStatsService summarises listings by suburb for choropleths. It keeps
+// the suburb polygons parsed, reparsing them when they're reimported.
+type SuburbStatsService struct {
+	db         *db.DB
+	properties *PropertyService
+
+	mu      sync.Mutex
+	version string
+	suburbs []suburbArea
+}
+
+type suburbArea struct {
+	boundary models.SuburbBoundary
+	area     *geo.Area
+}
+
+// NewSuburbStatsService creates a new SuburbStatsService
+func NewSuburbStatsService(database *db.DB, properties *PropertyService) *SuburbStatsService {
+	return &SuburbStatsService{db: database, properties: properties}
+}
+
+// areas returns the parsed suburb polygons, reparsing them if they changed
+// since they were last parsed
+func (s *SuburbStatsService) areas() ([]suburbArea, error) {
+	version, err := s.db.GetSuburbBoundaryVersion()
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.suburbs != nil && s.version == version {
+		return s.suburbs, nil
+	}
+
+	boundaries, err := s.db.GetSuburbBoundaries()
+	if err != nil {
+		return nil, err
+	}
+	suburbs := make([]suburbArea, 0, len(boundaries))
+	for _, b := range boundaries {
+		area, err := boundaryArea(b.Geometry)
+		if err != nil {
+			return nil, fmt.Errorf("suburb %s: %w", b.Name, err)
+		}
+		if !area.Empty() {
+			suburbs = append(suburbs, suburbArea{boundary: b, area: area})
+		}
+	}
+	s.version, s.suburbs = version, suburbs
+	return suburbs, nil
+}
+
+// boundaryArea parses a stored GeoJSON Polygon or MultiPolygon boundary
+func boundaryArea(geometry string) (*geo.Area, error) {
+	var g geo.GeoJSONGeometry
+	if err := json.Unmarshal([]byte(geometry), &g); err != nil {
+		return nil, fmt.Errorf("failed to parse geometry: %w", err)
+	}
+	return geo.NewArea(&geo.GeoJSONFeatureCollection{Features: []geo.GeoJSONFeature{{Geometry: g}}})
+}
+
+// Stats returns the suburbs containing canonical properties matching f,
+// with how many there are and their median asking price and drive time to
+// the anchor, and how quickly their listings go off the market (see
+// ComputeVelocity). Suburbs without matches are left out. Sorting and
+// pagination in f are ignored.
+func (s *SuburbStatsService) Stats(ctx context.Context, f db.PropertyFilter) ([]models.SuburbStats, error) {
+	suburbs, err := s.areas()
+	if err != nil {
+		return nil, err
+	}
+
+	f.Sort, f.Limit, f.Offset = "", 0, 0
+	matches, err := s.properties.List(ctx, f)
+	if err != nil {
+		return nil, err
+	}
+	inputs, err := s.db.GetScoringInputs()
+	if err != nil {
+		return nil, err
+	}
+	prices := make(map[int64]float64, len(inputs))
+	for _, in := range inputs {
+		if in.PriceMid != nil {
+			prices[in.PropertyID] = *in.PriceMid
+		}
+	}
+
+	type tally struct {
+		listings   int
+		prices     []float64
+		driveTimes []float64
+	}
+	tallies := make(map[int]*tally)
+	for _, m := range matches {
+		i := containingSuburb(suburbs, m.Latitude, m.Longitude)
+		if i < 0 {
+			continue
+		}
+		t := tallies[i]
+		if t == nil {
+			t = &tally{}
+			tallies[i] = t
+		}
+		t.listings++
+		if price, ok := prices[m.ID]; ok {
+			t.prices = append(t.prices, price)
+		}
+		if m.DriveTimePrimary != nil {
+			t.driveTimes = append(t.driveTimes, float64(*m.DriveTimePrimary))
+		}
+	}
+
+	stats := make([]models.SuburbStats, 0, len(tallies))
+	for i, t := range tallies {
+		stats = append(stats, models.SuburbStats{
+			Boundary:               suburbs[i].boundary,
+			Listings:               t.listings,
+			Priced:                 len(t.prices),
+			MedianPrice:            median(t.prices),
+			MedianDriveTimePrimary: median(t.driveTimes),
+		})
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Boundary.Name < stats[j].Boundary.Name })
+
+	velocities, err := s.db.GetSuburbVelocity()
+	if err != nil {
+		return nil, err
+	}
+	marketHeat(stats, velocities)
+	return stats, nil
+}
+
+// containingSuburb returns the index of the suburb containing a point, or -1
+func containingSuburb(suburbs []suburbArea, lat, lng float64) int {
+	for i := range suburbs {
+		if suburbs[i].area.Contains(lat, lng) {
+			return i
+		}
+	}
+	return -1
+}
+
+// median returns the median of values, or nil if there are none
+func median(values []float64) *float64 {
+	if len(values) == 0 {
+		return nil
+	}
+	sort.Float64s(values)
+	m := values[len(values)/2]
+	if len(values)%2 == 0 {
+		m = (values[len(values)/2-1] + m) / 2
+	}
+	return &m
+}
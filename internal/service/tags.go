@@ -0,0 +1,102 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"farm-search/internal/db"
+)
+
+// ErrInvalidTag is returned for a tag name that can't be used
+var ErrInvalidTag = errors.New("invalid tag")
+
+// maxTagLength caps tag names
+const maxTagLength = 50
+
+// NormalizeTag lowercases and trims a tag and turns spaces into dashes, so
+// "Needs water check" and "needs-water-check" are one tag. Tags may only hold
+// letters, digits, dashes and underscores.
+func NormalizeTag(tag string) (string, error) {
+	tag = strings.Join(strings.Fields(strings.ToLower(tag)), "-")
+	if tag == "" || len(tag) > maxTagLength {
+		return "", fmt.Errorf("%w: must be 1-%d characters", ErrInvalidTag, maxTagLength)
+	}
+	for _, r := range tag {
+		if (r < 'a' || r > 'z') && (r < '0' || r > '9') && r != '-' && r != '_' {
+			return "", fmt.Errorf("%w: %q may only contain letters, digits, dashes and underscores", ErrInvalidTag, tag)
+		}
+	}
+	return tag, nil
+}
+
+// TagSelection picks the properties a bulk tag change applies to: listed IDs,
+// or every property matching an /api/properties filter query string
+type TagSelection struct {
+	IDs   []int64 `json:"ids"`
+	Query *string `json:"query"`
+}
+
+// TagService tags and untags properties in bulk
+type TagService struct {
+	db         *db.DB
+	properties *PropertyService
+}
+
+// NewTagService creates a new TagService
+func NewTagService(database *db.DB, properties *PropertyService) *TagService {
+	return &TagService{db: database, properties: properties}
+}
+
+// Tag adds a tag to the selected properties, returning how many were
+// selected and how many didn't already have it
+func (s *TagService) Tag(ctx context.Context, tag string, sel TagSelection) (selected int, changed int64, err error) {
+	ids, err := s.selected(ctx, sel)
+	if err != nil {
+		return 0, 0, err
+	}
+	changed, err = s.db.TagProperties(tag, ids)
+	return len(ids), changed, err
+}
+
+// Untag removes a tag from the selected properties, returning how many were
+// selected and how many had it
+func (s *TagService) Untag(ctx context.Context, tag string, sel TagSelection) (selected int, changed int64, err error) {
+	ids, err := s.selected(ctx, sel)
+	if err != nil {
+		return 0, 0, err
+	}
+	changed, err = s.db.UntagProperties(tag, ids)
+	return len(ids), changed, err
+}
+
+// selected resolves a selection to canonical property IDs. Listed duplicates
+// are tagged on their canonical property, which is what lists and filters
+// show; IDs that don't exist are skipped.
+func (s *TagService) selected(ctx context.Context, sel TagSelection) ([]int64, error) {
+	if sel.Query != nil {
+		values, err := url.ParseQuery(strings.TrimPrefix(*sel.Query, "?"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid query: %w", err)
+		}
+		f := ParsePropertyFilter(values)
+		f.Sort, f.Limit, f.Offset = "", 0, 0
+		matches, err := s.properties.List(ctx, f)
+		if err != nil {
+			return nil, err
+		}
+		ids := make([]int64, len(matches))
+		for i, m := range matches {
+			ids[i] = m.ID
+		}
+		return ids, nil
+	}
+
+	ids := make([]int64, 0, len(sel.IDs))
+	for id := range s.properties.GetMany(sel.IDs) {
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
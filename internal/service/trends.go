@@ -0,0 +1,144 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"farm-search/internal/db"
+	"farm-search/internal/models"
+)
+
+// TrendMetrics are the metrics TimeSeries can chart
+var TrendMetrics = []string{"median_price", "new_listings", "delistings"}
+
+// ErrUnknownRegion is returned for a region that isn't an imported suburb
+var ErrUnknownRegion = errors.New("unknown region")
+
+// marketSpell is a time a property was on the market without a break,
+// through any of its listings; to is nil while it still is
+type marketSpell struct {
+	from time.Time
+	to   *time.Time
+}
+
+// spells merges each property's versions matching match into its spells on
+// the market
+func (h *marketHistory) spells(match func(v *models.ListingVersion) bool) map[int64][]marketSpell {
+	byProperty := make(map[int64][]marketSpell)
+	for i := range h.versions {
+		if v := &h.versions[i]; match(v) {
+			byProperty[v.PropertyID] = append(byProperty[v.PropertyID], marketSpell{v.ValidFrom, v.ValidTo})
+		}
+	}
+	for id, versions := range byProperty {
+		sort.Slice(versions, func(i, j int) bool { return versions[i].from.Before(versions[j].from) })
+		spells := []marketSpell{versions[0]}
+		for _, v := range versions[1:] {
+			last := &spells[len(spells)-1]
+			if last.to != nil && v.from.After(*last.to) {
+				spells = append(spells, v)
+				continue
+			}
+			if last.to != nil && (v.to == nil || v.to.After(*last.to)) {
+				last.to = v.to
+			}
+		}
+		byProperty[id] = spells
+	}
+	return byProperty
+}
+
+// TimeSeries charts metric over the periods from each of dates to the next
+// (the last running one interval on, to now at most) for the canonical
+// properties matching f, from the listing history: the median asking price
+// (the range's midpoint) of those on the market at the end of the period,
+// or how many came on the market (new_listings) or went off it (delistings)
+// during it. With region (a suburb's SAL code or name, see `tools suburbs`)
+// only properties in that suburb count. Price, type and land size filters
+// apply to the values at the time, as in PropertyService.AsOf.
+func (s *SuburbStatsService) TimeSeries(ctx context.Context, f db.PropertyFilter, region, metric string, dates []time.Time, interval string) ([]models.TrendPoint, error) {
+	current, match := historyFilter(f)
+	histories, err := s.properties.marketHistories(ctx, current)
+	if err != nil {
+		return nil, err
+	}
+	if region != "" {
+		if err := s.inRegion(histories, region); err != nil {
+			return nil, err
+		}
+	}
+
+	spells := make([]map[int64][]marketSpell, len(histories))
+	for i := range histories {
+		spells[i] = histories[i].spells(match)
+	}
+
+	now := time.Now()
+	points := make([]models.TrendPoint, len(dates))
+	for i, start := range dates {
+		end := historyDate(dates[0], i+1, interval)
+		if end.After(now) {
+			end = now
+		}
+		points[i].Date = start.Format("2006-01-02")
+
+		var prices []float64
+		var changed float64
+		for j := range histories {
+			h := &histories[j]
+			active := h.activeVersions(end, match)
+			points[i].Listings += len(active)
+			if metric == "median_price" {
+				for _, k := range active {
+					if price, ok := askingPrice(&h.versions[k]); ok {
+						prices = append(prices, price)
+					}
+				}
+				continue
+			}
+			for _, propertySpells := range spells[j] {
+				for _, spell := range propertySpells {
+					at := &spell.from
+					if metric == "delistings" {
+						at = spell.to
+					}
+					if at != nil && !at.Before(start) && at.Before(end) {
+						changed++
+					}
+				}
+			}
+		}
+		if metric == "median_price" {
+			points[i].Value = median(prices)
+		} else {
+			points[i].Value = &changed
+		}
+	}
+	return points, nil
+}
+
+// inRegion narrows histories to the properties in the suburbs with region
+// as their SAL code or name
+func (s *SuburbStatsService) inRegion(histories []marketHistory, region string) error {
+	suburbs, err := s.areas()
+	if err != nil {
+		return err
+	}
+	var areas []suburbArea
+	for _, sa := range suburbs {
+		if sa.boundary.Code == region || strings.EqualFold(sa.boundary.Name, region) {
+			areas = append(areas, sa)
+		}
+	}
+	if len(areas) == 0 {
+		return ErrUnknownRegion
+	}
+
+	for i := range histories {
+		histories[i].within(func(lat, lng float64) bool { return containingSuburb(areas, lat, lng) >= 0 })
+	}
+	return nil
+}
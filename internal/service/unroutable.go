@@ -0,0 +1,109 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"farm-search/internal/geo"
+	"farm-search/internal/models"
+)
+
+// DiagnoseUnroutable asks Valhalla where each property without a drive time
+// to the anchor (and no route held for review) snaps to the road network,
+// searching up to searchKm away, and whether the anchor can be routed to
+// from that road point. The snap is saved as a suggested corrected point;
+// coordinates aren't changed. Snaps of properties routed since the last run
+// are cleared first. Needs a router; stops like DriveTimesToAnchor if
+// Valhalla goes down.
+func (s *EnrichmentService) DiagnoseUnroutable(ctx context.Context, searchKm float64) (EnrichmentStats, error) {
+	cleared, err := s.db.ClearRoutedRoadSnaps()
+	if err != nil {
+		return EnrichmentStats{}, err
+	}
+	if cleared > 0 {
+		log.Printf("Cleared road snaps for %d properties routed since", cleared)
+	}
+
+	properties, err := s.db.GetUnroutableProperties(s.propertyID)
+	stats := EnrichmentStats{Total: len(properties)}
+	if err != nil {
+		return stats, err
+	}
+	if len(properties) == 0 {
+		log.Printf("No unroutable properties")
+		return stats, nil
+	}
+
+	log.Printf("Locating %d unroutable properties on the road network...", len(properties))
+
+	for i, p := range properties {
+		snap := &models.RoadSnap{PropertyID: p.ID}
+		road, err := s.router.LocateRoad(ctx, p.Latitude, p.Longitude, searchKm)
+		if errors.Is(err, geo.ErrValhallaUnavailable) {
+			return stats, err
+		}
+		if err != nil && !errors.Is(err, geo.ErrNoRoute) {
+			log.Printf("[%d/%d] Failed for property %d (%s): %v", i+1, len(properties), p.ID, location(p), err)
+			stats.Failed++
+			continue
+		}
+
+		var suggestion string
+		if road == nil {
+			suggestion = "no road within the search distance; check the coordinates"
+		} else {
+			snap.Lat, snap.Lng, snap.DistanceKm = &road.Lat, &road.Lng, &road.DistanceKm
+			if road.WayID != 0 {
+				snap.WayID = &road.WayID
+			}
+			if len(road.Names) > 0 {
+				name := strings.Join(road.Names, " / ")
+				snap.RoadName = &name
+			}
+			if road.Class != "" {
+				snap.RoadClass = &road.Class
+			}
+			if road.Use != "" {
+				snap.RoadUse = &road.Use
+			}
+
+			result, err := s.router.GetDriveTime(ctx, road.Lat, road.Lng, s.anchor)
+			if errors.Is(err, geo.ErrValhallaUnavailable) {
+				return stats, err
+			}
+			routable := err == nil
+			snap.Routable = &routable
+			suggestion = describeRoadSnap(road, s.anchor.Name, result)
+		}
+
+		if err := s.db.SaveRoadSnap(snap); err != nil {
+			log.Printf("[%d/%d] Failed to save for property %d: %v", i+1, len(properties), p.ID, err)
+			stats.Failed++
+			continue
+		}
+		log.Printf("[%d/%d] Property %d (%s, %.5f, %.5f): %s",
+			i+1, len(properties), p.ID, location(p), p.Latitude, p.Longitude, suggestion)
+		stats.Success++
+	}
+	return stats, nil
+}
+
+// describeRoadSnap describes a snap for logs, with the drive time to the
+// anchor from it if it could be routed
+func describeRoadSnap(road *geo.RoadSnap, anchor string, route *geo.RouteResult) string {
+	name := "unnamed road"
+	if len(road.Names) > 0 {
+		name = strings.Join(road.Names, " / ")
+	}
+	if road.Class != "" {
+		name += " (" + road.Class + ")"
+	}
+	desc := fmt.Sprintf("nearest road %s %.2f km away at %.5f, %.5f", name, road.DistanceKm, road.Lat, road.Lng)
+	if route == nil {
+		return desc + ", but it doesn't route to " + anchor + " either"
+	}
+	return desc + fmt.Sprintf(", which routes to %s in %.0f min", anchor, route.DurationMins)
+}
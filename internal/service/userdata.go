@@ -0,0 +1,346 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"farm-search/internal/db"
+	"farm-search/internal/models"
+)
+
+// UserDataVersion is the version of the format Export writes and Import reads
+const UserDataVersion = 1
+
+// ErrInvalidUserData is returned for an import that isn't a UserData export
+// this version can read
+var ErrInvalidUserData = errors.New("invalid user data")
+
+// Sections of UserData, as counted by an import
+const (
+	sectionTags           = "tags"
+	sectionSavedSearches  = "saved_searches"
+	sectionScoreProfiles  = "score_profiles"
+	sectionShareLinks     = "share_links"
+	sectionCoordinates    = "coordinates"
+	sectionPropertyLinks  = "property_links"
+	sectionLinkRejections = "link_rejections"
+)
+
+// UserDataService exports and imports what people have entered (tags, saved
+// searches, score profiles, share links, corrected coordinates and duplicate
+// decisions), so it survives moving to a fresh database or a re-scrape
+type UserDataService struct {
+	db         *db.DB
+	properties *PropertyService
+	searches   *SavedSearchService
+	scoring    *ScoringService
+}
+
+// NewUserDataService creates a new UserDataService
+func NewUserDataService(database *db.DB, properties *PropertyService, searches *SavedSearchService, scoring *ScoringService) *UserDataService {
+	return &UserDataService{db: database, properties: properties, searches: searches, scoring: scoring}
+}
+
+// Export returns all the user data in the database
+func (s *UserDataService) Export() (*models.UserData, error) {
+	data := &models.UserData{Version: UserDataVersion, ExportedAt: time.Now().UTC().Truncate(time.Second)}
+	var err error
+	if data.Tags, err = s.db.ExportUserTags(); err != nil {
+		return nil, err
+	}
+	if data.SavedSearches, err = s.db.ListSavedSearches(); err != nil {
+		return nil, err
+	}
+	if data.ScoreProfiles, err = s.db.ListScoreProfiles(); err != nil {
+		return nil, err
+	}
+	if data.ShareLinks, err = s.db.ListShareLinks(); err != nil {
+		return nil, err
+	}
+	if data.Coordinates, err = s.db.ExportManualCoordinates(); err != nil {
+		return nil, err
+	}
+	if data.PropertyLinks, err = s.db.ExportManualPropertyLinks(); err != nil {
+		return nil, err
+	}
+	if data.LinkRejections, err = s.db.ExportLinkRejections(); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// Import adds an export's user data to the database, matching properties by
+// listing source and external ID. Anything already there is skipped (saved
+// searches by name and query, score profiles by name, share links by
+// token), as is anything for a property not in the database, so importing
+// the same export twice changes nothing. The export is checked before
+// anything is imported; an error partway (e.g. Valhalla down for a saved
+// search's drive time area) leaves what was imported so far, and importing
+// again picks up the rest.
+func (s *UserDataService) Import(ctx context.Context, data *models.UserData) (*models.UserDataImport, error) {
+	if err := validateUserData(data); err != nil {
+		return nil, err
+	}
+
+	result := &models.UserDataImport{Imported: map[string]int{}, Skipped: map[string]int{}}
+	for _, section := range []string{sectionTags, sectionSavedSearches, sectionScoreProfiles, sectionShareLinks,
+		sectionCoordinates, sectionPropertyLinks, sectionLinkRejections} {
+		result.Imported[section], result.Skipped[section] = 0, 0
+	}
+	count := func(section string, imported bool) {
+		if imported {
+			result.Imported[section]++
+		} else {
+			result.Skipped[section]++
+		}
+	}
+
+	for _, step := range []func(context.Context, *models.UserData, func(string, bool)) error{
+		s.importScoreProfiles, s.importSavedSearches, s.importShareLinks, s.importTags,
+		s.importCoordinates, s.importPropertyLinks, s.importLinkRejections,
+	} {
+		if err := step(ctx, data, count); err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
+
+// validateUserData checks an import's version and the parts that would fail
+// partway through
+func validateUserData(data *models.UserData) error {
+	if data.Version != UserDataVersion {
+		return fmt.Errorf("%w: version %d, expected %d", ErrInvalidUserData, data.Version, UserDataVersion)
+	}
+	for _, p := range data.ScoreProfiles {
+		if p.Name == "" {
+			return fmt.Errorf("%w: score profile without a name", ErrInvalidUserData)
+		}
+		if err := ValidateWeights(p.Weights); err != nil {
+			return fmt.Errorf("%w: score profile %q: %v", ErrInvalidUserData, p.Name, err)
+		}
+	}
+	for _, search := range data.SavedSearches {
+		if search.Name == "" {
+			return fmt.Errorf("%w: saved search without a name", ErrInvalidUserData)
+		}
+		if _, err := url.ParseQuery(search.Query); err != nil {
+			return fmt.Errorf("%w: saved search %q: invalid query", ErrInvalidUserData, search.Name)
+		}
+	}
+	for _, link := range data.ShareLinks {
+		var filters map[string]interface{}
+		if link.Token == "" || json.Unmarshal(link.Filters, &filters) != nil {
+			return fmt.Errorf("%w: share link %q: needs a token and a filters object", ErrInvalidUserData, link.Token)
+		}
+	}
+	return nil
+}
+
+func (s *UserDataService) importScoreProfiles(ctx context.Context, data *models.UserData, count func(string, bool)) error {
+	existing, err := s.db.ListScoreProfiles()
+	if err != nil {
+		return err
+	}
+	names := make(map[string]bool, len(existing))
+	for _, p := range existing {
+		names[p.Name] = true
+	}
+	for _, p := range data.ScoreProfiles {
+		if names[p.Name] {
+			count(sectionScoreProfiles, false)
+			continue
+		}
+		profile := &models.ScoreProfile{Name: p.Name, Weights: p.Weights}
+		if err := s.scoring.Create(profile); err != nil {
+			return fmt.Errorf("importing score profile %q: %w", p.Name, err)
+		}
+		names[p.Name] = true
+		count(sectionScoreProfiles, true)
+	}
+	return nil
+}
+
+func (s *UserDataService) importSavedSearches(ctx context.Context, data *models.UserData, count func(string, bool)) error {
+	existing, err := s.db.ListSavedSearches()
+	if err != nil {
+		return err
+	}
+	type searchKey struct{ name, query string }
+	seen := make(map[searchKey]bool, len(existing))
+	for _, search := range existing {
+		seen[searchKey{search.Name, search.Query}] = true
+	}
+	for _, search := range data.SavedSearches {
+		// Validated above
+		values, _ := url.ParseQuery(search.Query)
+		key := searchKey{search.Name, values.Encode()}
+		if seen[key] {
+			count(sectionSavedSearches, false)
+			continue
+		}
+		if err := s.searches.Create(ctx, &models.SavedSearch{Name: key.name, Query: key.query}); err != nil {
+			return fmt.Errorf("importing saved search %q: %w", search.Name, err)
+		}
+		seen[key] = true
+		count(sectionSavedSearches, true)
+	}
+	return nil
+}
+
+func (s *UserDataService) importShareLinks(ctx context.Context, data *models.UserData, count func(string, bool)) error {
+	for _, link := range data.ShareLinks {
+		imported, err := s.db.ImportShareLink(link)
+		if err != nil {
+			return err
+		}
+		count(sectionShareLinks, imported)
+	}
+	return nil
+}
+
+func (s *UserDataService) importTags(ctx context.Context, data *models.UserData, count func(string, bool)) error {
+	for _, t := range data.Tags {
+		tag, err := NormalizeTag(t.Tag)
+		if err != nil {
+			count(sectionTags, false)
+			continue
+		}
+		id, err := s.db.FindPropertyID(t.Source, t.ExternalID)
+		if err != nil {
+			return err
+		}
+		if id == 0 {
+			count(sectionTags, false)
+			continue
+		}
+		imported, err := s.db.ImportTag(id, tag, t.TaggedAt)
+		if err != nil {
+			return err
+		}
+		count(sectionTags, imported)
+	}
+	return nil
+}
+
+// importCoordinates saves corrected coordinates without recomputing what
+// they clear, leaving that to `tools enrich`
+func (s *UserDataService) importCoordinates(ctx context.Context, data *models.UserData, count func(string, bool)) error {
+	for _, c := range data.Coordinates {
+		id, err := s.db.FindPropertyID(c.Source, c.ExternalID)
+		if err != nil {
+			return err
+		}
+		if id == 0 {
+			count(sectionCoordinates, false)
+			continue
+		}
+		p, err := s.db.GetProperty(id)
+		if err != nil {
+			return err
+		}
+		if p.CoordSource == "manual" && p.Latitude == c.Latitude && p.Longitude == c.Longitude {
+			count(sectionCoordinates, false)
+			continue
+		}
+		if err := s.db.SetPropertyCoordinates(id, c.Latitude, c.Longitude, "manual", 1); err != nil {
+			return err
+		}
+		count(sectionCoordinates, true)
+	}
+	return nil
+}
+
+func (s *UserDataService) importPropertyLinks(ctx context.Context, data *models.UserData, count func(string, bool)) error {
+	for _, link := range data.PropertyLinks {
+		canonicalID, err := s.db.FindPropertyID(link.CanonicalSource, link.CanonicalExternalID)
+		if err != nil {
+			return err
+		}
+		duplicateID, err := s.db.FindPropertyID(link.DuplicateSource, link.DuplicateExternalID)
+		if err != nil {
+			return err
+		}
+		if canonicalID == 0 || duplicateID == 0 {
+			count(sectionPropertyLinks, false)
+			continue
+		}
+
+		// Already linked, directly or through another duplicate
+		root, err := s.db.GetCanonicalPropertyID(canonicalID)
+		if err != nil {
+			return err
+		}
+		linkedTo, err := s.db.GetCanonicalPropertyID(duplicateID)
+		if err != nil {
+			return err
+		}
+		if root == linkedTo {
+			count(sectionPropertyLinks, false)
+			continue
+		}
+
+		err = s.properties.LinkDuplicate(canonicalID, duplicateID, "imported")
+		if errors.Is(err, ErrSelfLink) {
+			count(sectionPropertyLinks, false)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		count(sectionPropertyLinks, true)
+	}
+	return nil
+}
+
+func (s *UserDataService) importLinkRejections(ctx context.Context, data *models.UserData, count func(string, bool)) error {
+	for _, r := range data.LinkRejections {
+		idA, err := s.db.FindPropertyID(r.SourceA, r.ExternalIDA)
+		if err != nil {
+			return err
+		}
+		idB, err := s.db.FindPropertyID(r.SourceB, r.ExternalIDB)
+		if err != nil {
+			return err
+		}
+		if idA == 0 || idB == 0 || idA == idB {
+			count(sectionLinkRejections, false)
+			continue
+		}
+
+		// A pair linked here (e.g. by duplicate detection) is unlinked too
+		var note string
+		if r.Note != nil {
+			note = *r.Note
+		}
+		unlinked := false
+		for _, pair := range [][2]int64{{idA, idB}, {idB, idA}} {
+			link, err := s.db.GetPropertyLink(pair[1])
+			if err != nil {
+				return err
+			}
+			if link != nil && link.CanonicalID == pair[0] {
+				if _, err := s.properties.RejectDuplicate(pair[1], note); err != nil {
+					return err
+				}
+				unlinked = true
+				break
+			}
+		}
+		if unlinked {
+			count(sectionLinkRejections, true)
+			continue
+		}
+
+		imported, err := s.db.ImportLinkRejection(idA, idB, r.Note, r.RejectedAt)
+		if err != nil {
+			return err
+		}
+		count(sectionLinkRejections, imported)
+	}
+	return nil
+}
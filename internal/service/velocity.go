@@ -0,0 +1,140 @@
+package service
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"farm-search/internal/db"
+	"farm-search/internal/geo"
+	"farm-search/internal/models"
+)
+
+const (
+	velocityMonths    = 12 // The median covers listings going off the market this month and the 11 before
+	velocityEMAMonths = 24 // The moving average runs over this many months' medians
+	velocityEMASpan   = 6  // Its span in months: each month's median has weight 2/(span+1)
+	minHeatListings   = 3  // Listings off the market in velocityMonths before a suburb gets a market heat
+)
+
+// VelocityResult is what ComputeVelocity found
+type VelocityResult struct {
+	Spells   int // Completed spells on the market in the last velocityEMAMonths
+	Unplaced int // Of those, properties outside every suburb boundary
+	Suburbs  int // Suburbs saved
+}
+
+// ComputeVelocity works out how quickly listings in each suburb go off the
+// market and saves it, replacing the last run's. Each canonical property's
+// listings are merged into spells on the market (as for trends); a spell that
+// has ended counts its days in the month (Sydney time) it ended. Each suburb
+// gets the median of the last velocityMonths and an exponential moving
+// average of monthly medians, months without any left out. Listings aren't
+// scraped going under offer, so this is days until they left every source:
+// sold, withdrawn or expired alike, and spells that began before the history
+// did (seeded from first_seen_at) are cut short.
+func (s *SuburbStatsService) ComputeVelocity(ctx context.Context, now time.Time) (*VelocityResult, error) {
+	suburbs, err := s.areas()
+	if err != nil {
+		return nil, err
+	}
+	histories, err := s.properties.marketHistories(ctx, db.PropertyFilter{})
+	if err != nil {
+		return nil, err
+	}
+
+	result := &VelocityResult{}
+	thisMonth := velocityMonth(now)
+	all := func(*models.ListingVersion) bool { return true }
+	days := make(map[int]map[int][]float64) // By suburb, then months before this one
+	for i := range histories {
+		h := &histories[i]
+		for id, spells := range h.spells(all) {
+			p := h.properties[id]
+			suburb := -2 // Not looked up yet
+			for _, spell := range spells {
+				if spell.to == nil {
+					continue
+				}
+				ago := thisMonth - velocityMonth(*spell.to)
+				if ago < 0 || ago >= velocityEMAMonths {
+					continue
+				}
+				result.Spells++
+				if suburb == -2 {
+					suburb = containingSuburb(suburbs, p.Latitude, p.Longitude)
+				}
+				if suburb < 0 {
+					result.Unplaced++
+					continue
+				}
+				if days[suburb] == nil {
+					days[suburb] = make(map[int][]float64)
+				}
+				days[suburb][ago] = append(days[suburb][ago], spell.to.Sub(spell.from).Hours()/24)
+			}
+		}
+	}
+
+	alpha := 2.0 / (velocityEMASpan + 1)
+	velocities := make([]models.SuburbVelocity, 0, len(days))
+	for i, months := range days {
+		v := models.SuburbVelocity{Code: suburbs[i].boundary.Code}
+		var recent []float64
+		for ago := velocityEMAMonths - 1; ago >= 0; ago-- {
+			if ago < velocityMonths {
+				recent = append(recent, months[ago]...)
+			}
+			m := median(months[ago])
+			if m == nil {
+				continue
+			}
+			v.Months++
+			if v.EMADays == nil {
+				v.EMADays = m
+			} else {
+				ema := alpha**m + (1-alpha)**v.EMADays
+				v.EMADays = &ema
+			}
+		}
+		v.OffMarket = len(recent)
+		v.MedianDays = median(recent)
+		velocities = append(velocities, v)
+	}
+	sort.Slice(velocities, func(i, j int) bool { return velocities[i].Code < velocities[j].Code })
+
+	if result.Suburbs, err = s.db.ReplaceSuburbVelocity(velocities); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// velocityMonth numbers t's month in Sydney, consecutively across years
+func velocityMonth(t time.Time) int {
+	t = t.In(geo.SydneyTime)
+	return t.Year()*12 + int(t.Month()) - 1
+}
+
+// marketHeat sets each suburb's market heat: how much faster than the typical
+// suburb (the median of those with enough recent listings going off the
+// market) its listings go, by their moving averages
+func marketHeat(stats []models.SuburbStats, velocities map[string]models.SuburbVelocity) {
+	var typical []float64
+	for _, v := range velocities {
+		if v.OffMarket >= minHeatListings && v.EMADays != nil && *v.EMADays > 0 {
+			typical = append(typical, *v.EMADays)
+		}
+	}
+	m := median(typical)
+	for i := range stats {
+		v, ok := velocities[stats[i].Boundary.Code]
+		if !ok {
+			continue
+		}
+		stats[i].Velocity = &v
+		if m != nil && v.OffMarket >= minHeatListings && v.EMADays != nil && *v.EMADays > 0 {
+			heat := *m / *v.EMADays
+			stats[i].MarketHeat = &heat
+		}
+	}
+}
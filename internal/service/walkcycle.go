@@ -0,0 +1,74 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"farm-search/internal/db"
+	"farm-search/internal/geo"
+)
+
+// TownWalkCycleTimes saves walking and cycling times from each property on a
+// town's fringe (within db.TownFringeKm of its nearest town) to the town's
+// centre, for buyers who want to walk to the pub. Routed from the access
+// point like the drive times, by Valhalla's pedestrian and bicycle costing.
+// A property with neither route is left missing. Needs a router; stops like
+// DriveTimesToAnchor if Valhalla goes down.
+func (s *EnrichmentService) TownWalkCycleTimes(ctx context.Context, all bool) (EnrichmentStats, error) {
+	properties, err := s.targets(db.StepTownWalkCycleTimes, all, "town walking and cycling time calculation")
+	stats := EnrichmentStats{Total: len(properties)}
+	if err != nil || len(properties) == 0 {
+		return stats, err
+	}
+
+	townCoords := make(map[string]geo.Location)
+	for _, town := range geo.NSWTowns {
+		townCoords[town.Name] = town
+	}
+	walker := s.router.WithProfile(geo.ProfilePedestrian)
+	cyclist := s.router.WithProfile(geo.ProfileBicycle)
+
+	log.Printf("Calculating walking and cycling times to nearest towns for %d properties...", len(properties))
+
+	for i, p := range properties {
+		town, ok := townCoords[p.NearestTown1]
+		if !ok {
+			log.Printf("[%d/%d] Unknown town %s for property %d", i+1, len(properties), p.NearestTown1, p.ID)
+			stats.Failed++
+			continue
+		}
+
+		var mins [2]*int
+		for j, router := range []*geo.Router{walker, cyclist} {
+			result, err := router.GetRoute(ctx, p.OriginLat, p.OriginLng, town.Latitude, town.Longitude)
+			if errors.Is(err, geo.ErrValhallaUnavailable) {
+				return stats, err
+			}
+			if err != nil {
+				continue // No path on foot or by bike, e.g. only a highway in
+			}
+			m := int(result.DurationMins + 0.5)
+			mins[j] = &m
+		}
+		walkMins, cycleMins := mins[0], mins[1]
+		if walkMins == nil && cycleMins == nil {
+			log.Printf("[%d/%d] No walking or cycling route to %s for property %d",
+				i+1, len(properties), town.Name, p.ID)
+			stats.Failed++
+			continue
+		}
+
+		if err := s.db.UpdatePropertyTownWalkCycleTimes(p.ID, walkMins, cycleMins); err != nil {
+			log.Printf("[%d/%d] Failed to save for property %d: %v", i+1, len(properties), p.ID, err)
+			stats.Failed++
+			continue
+		}
+
+		log.Printf("[%d/%d] Property %d (%s): %s walking %s, cycling %s",
+			i+1, len(properties), p.ID, location(p), town.Name, minsString(walkMins), minsString(cycleMins))
+		s.recomputed(p.ID, db.StepTownWalkCycleTimes)
+		stats.Success++
+	}
+	return stats, nil
+}
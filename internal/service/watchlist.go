@@ -0,0 +1,148 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"farm-search/internal/db"
+	"farm-search/internal/models"
+)
+
+// WatchlistKinds are the regions a watchlist can follow
+var WatchlistKinds = []string{"suburb", "lga"}
+
+// WatchlistService subscribes to suburbs and LGAs and summarises what
+// changed on their markets, from the listing history
+type WatchlistService struct {
+	db         *db.DB
+	properties *PropertyService
+}
+
+// NewWatchlistService creates a new WatchlistService
+func NewWatchlistService(database *db.DB, properties *PropertyService) *WatchlistService {
+	return &WatchlistService{db: database, properties: properties}
+}
+
+// Watch subscribes to the suburb (kind "suburb", see `tools suburbs`) or LGA
+// ("lga", see `tools lgas`) with region as its code or name. Returns
+// ErrUnknownRegion if there's no such boundary, and false with the existing
+// watchlist if it's already watched.
+func (s *WatchlistService) Watch(kind, region string) (*models.Watchlist, bool, error) {
+	boundary, err := s.db.FindRegionBoundary(kind, region)
+	if err != nil {
+		return nil, false, err
+	}
+	if boundary == nil {
+		return nil, false, ErrUnknownRegion
+	}
+	w := &models.Watchlist{Kind: boundary.Kind, Code: boundary.Code, Name: boundary.Name}
+	created, err := s.db.CreateWatchlist(w)
+	if err != nil {
+		return nil, false, err
+	}
+	return w, created, nil
+}
+
+// Report summarises the canonical properties in w's region over [start,
+// end) and archives the summary: those that came on the market or went off
+// it, those on the market throughout whose listing's asking price changed,
+// and the median asking price (the range's midpoint) at the start and end.
+// Properties count where they are now, by their listings as they were then.
+func (s *WatchlistService) Report(ctx context.Context, w *models.Watchlist, start, end time.Time) (*models.WatchlistReport, error) {
+	boundary, err := s.db.FindRegionBoundary(w.Kind, w.Code)
+	if err != nil {
+		return nil, err
+	}
+	if boundary == nil {
+		return nil, fmt.Errorf("%s %s: %w", w.Kind, w.Name, ErrUnknownRegion)
+	}
+	area, err := boundaryArea(boundary.Geometry)
+	if err != nil {
+		return nil, fmt.Errorf("%s %s: %w", w.Kind, w.Name, err)
+	}
+
+	var f db.PropertyFilter
+	swLat, swLng, neLat, neLng := area.Bounds()
+	f.SWLat, f.SWLng, f.NELat, f.NELng = &swLat, &swLng, &neLat, &neLng
+	histories, err := s.properties.marketHistories(ctx, f)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := models.WatchlistSummary{
+		Region:       w.Name,
+		NewListings:  []models.PropertyListItem{},
+		PriceChanges: []models.WatchlistPriceChange{},
+	}
+	all := func(*models.ListingVersion) bool { return true }
+	var before, after []float64
+	for i := range histories {
+		h := &histories[i]
+		h.within(area.Contains)
+
+		for id, spells := range h.spells(all) {
+			listed := false
+			for _, spell := range spells {
+				if !listed && !spell.from.Before(start) && spell.from.Before(end) {
+					summary.NewListings = append(summary.NewListings, h.properties[id])
+					listed = true
+				}
+				if spell.to != nil && !spell.to.Before(start) && spell.to.Before(end) {
+					summary.Delisted++
+				}
+			}
+		}
+
+		atStart, atEnd := h.activeVersions(start, all), h.activeVersions(end, all)
+		summary.Listings += len(atEnd)
+		for _, j := range atStart {
+			if price, ok := askingPrice(&h.versions[j]); ok {
+				before = append(before, price)
+			}
+		}
+		for id, j := range atEnd {
+			v := &h.versions[j]
+			if price, ok := askingPrice(v); ok {
+				after = append(after, price)
+			}
+			// Prices of a property's other listings aren't comparable
+			k, ok := atStart[id]
+			if !ok || h.versions[k].ListingID != v.ListingID {
+				continue
+			}
+			from, to := topPrice(&h.versions[k]), topPrice(v)
+			if from != nil && to != nil && *from != *to {
+				summary.PriceChanges = append(summary.PriceChanges, models.WatchlistPriceChange{
+					PropertyListItem: h.properties[id],
+					PriceBefore:      *from,
+					PriceAfter:       *to,
+				})
+			}
+		}
+	}
+
+	summary.MedianPrice, summary.PreviousMedianPrice = median(after), median(before)
+	if summary.MedianPrice != nil && summary.PreviousMedianPrice != nil && *summary.PreviousMedianPrice > 0 {
+		pct := (*summary.MedianPrice/(*summary.PreviousMedianPrice) - 1) * 100
+		summary.MedianChangePct = &pct
+	}
+	sort.Slice(summary.NewListings, func(i, j int) bool { return summary.NewListings[i].ID < summary.NewListings[j].ID })
+	sort.Slice(summary.PriceChanges, func(i, j int) bool { return summary.PriceChanges[i].ID < summary.PriceChanges[j].ID })
+
+	report := &models.WatchlistReport{WatchlistID: w.ID, PeriodStart: start, PeriodEnd: end, Summary: summary}
+	if err := s.db.SaveWatchlistReport(report); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// topPrice returns the top of a version's asking price range, nil if it has
+// no price
+func topPrice(v *models.ListingVersion) *int64 {
+	if v.PriceMax != nil {
+		return v.PriceMax
+	}
+	return v.PriceMin
+}
@@ -0,0 +1,153 @@
+// Package units parses the land sizes listings quote ("280ha", "691.90 ac",
+// "Land size: 5,000 m²", "40–60 hectares") into square metres, the unit
+// land_size_sqm is stored in.
+package units
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Square metres per unit of land area
+const (
+	SqmPerHectare    = 10000
+	SqmPerAcre       = 4046.86
+	SqmPerSquareFoot = 0.09290304
+)
+
+const (
+	// A number with optional thousands separators or decimal point/comma
+	numberExpr = `(\d(?:[\d,.]*\d)?)`
+	// An optional upper bound, for ranges like "40-60 ha" or "40 to 60 ha"
+	rangeExpr = `(?:\s*(?:-|–|—|to)\s*(\d(?:[\d,.]*\d)?))?`
+
+	broadUnitExpr  = `hectares?|ha|acres?|ac`
+	squareUnitExpr = `m²|m2|sq\.?\s?m(?:etres?|eters?)?|square\s+m(?:etres?|eters?)|ft²|ft2|sq\.?\s?f(?:ee)?t|square\s+f(?:ee|oo)t`
+	// A unit must end the word, so "ha" doesn't match "hay"
+	unitEndExpr = `(?:[^\pL\d]|$)`
+)
+
+var (
+	// Hectares and acres are only ever land, so they're taken anywhere
+	broadPattern = regexp.MustCompile(numberExpr + rangeExpr + `\s*(` + broadUnitExpr + `)` + unitEndExpr)
+	// Square metres and feet in text are as often the house or a shed, so
+	// they only count when labelled as the land
+	labelledPattern = regexp.MustCompile(`land(?:\s+(?:size|area))?\s*:?\s*` + numberExpr + rangeExpr + `\s*(` + squareUnitExpr + `)` + unitEndExpr)
+	// A size on its own, e.g. a listing's land size field
+	sizePattern = regexp.MustCompile(numberExpr + rangeExpr + `\s*(` + broadUnitExpr + `|` + squareUnitExpr + `)` + unitEndExpr)
+	// A number with no unit, or with a word after it that isn't one
+	numberPattern = regexp.MustCompile(numberExpr + `\s*(\pL*)`)
+	// A size field that's negative, e.g. "-5 ha"
+	negativePattern = regexp.MustCompile(`^\s*(?:-|−)\s*\d`)
+)
+
+// ToSqm converts an area in unit ("ha", "acres", "m²", "sq ft", ...) to
+// square metres, reporting whether the unit is known
+func ToSqm(value float64, unit string) (float64, bool) {
+	key := strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '.', '_', '-':
+			return -1
+		}
+		return r
+	}, strings.ToLower(unit))
+
+	switch key {
+	case "ha", "hectare", "hectares":
+		return value * SqmPerHectare, true
+	case "ac", "acre", "acres":
+		return value * SqmPerAcre, true
+	case "m2", "m²", "sqm", "sqmetre", "sqmetres", "sqmeter", "sqmeters",
+		"squaremetre", "squaremetres", "squaremeter", "squaremeters":
+		return value, true
+	case "ft2", "ft²", "sqft", "sqfeet", "squarefoot", "squarefeet":
+		return value * SqmPerSquareFoot, true
+	}
+	return 0, false
+}
+
+// FindLandSize returns the first land size in hectares or acres in text
+// such as a description or page, or failing that one in square metres or
+// feet labelled as the land ("Land size: 5,000 m²"). A range counts as its
+// lower bound, and zero sizes are passed over. Returns 0 if there's none.
+func FindLandSize(text string) float64 {
+	s := normalize(text)
+	for _, pattern := range []*regexp.Regexp{broadPattern, labelledPattern} {
+		for _, m := range pattern.FindAllStringSubmatch(s, -1) {
+			if sqm := quantity(m[1], m[3]); sqm > 0 {
+				return sqm
+			}
+		}
+	}
+	return 0
+}
+
+// ParseLandSize parses a land size field such as "100 ha", "5,000 m²" or
+// "40-60 acres" (as its lower bound). A number without a unit is taken as
+// square metres. Returns 0 if there's no number, the unit is unknown
+// ("100 furlongs") or the size is zero or negative.
+func ParseLandSize(s string) float64 {
+	s = normalize(s)
+	if negativePattern.MatchString(s) {
+		return 0
+	}
+	if m := sizePattern.FindStringSubmatch(s); m != nil {
+		if sqm := quantity(m[1], m[3]); sqm > 0 {
+			return sqm
+		}
+	}
+	if m := numberPattern.FindStringSubmatch(s); m != nil && m[2] == "" {
+		if value, ok := ParseNumber(m[1]); ok && value > 0 {
+			return value
+		}
+	}
+	return 0
+}
+
+// ParseNumber parses a number written with thousands separators and a
+// decimal point or comma: "1,234.5", "1.234,5", "12,5" and "1,200" all work.
+// A lone comma followed by three digits is taken as a thousands separator.
+func ParseNumber(s string) (float64, bool) {
+	s = strings.TrimSpace(s)
+	lastComma, lastDot := strings.LastIndex(s, ","), strings.LastIndex(s, ".")
+	switch {
+	case lastComma >= 0 && lastDot >= 0:
+		// Whichever comes last is the decimal separator
+		if lastComma > lastDot {
+			s = strings.ReplaceAll(s, ".", "")
+			s = strings.Replace(s, ",", ".", 1)
+		} else {
+			s = strings.ReplaceAll(s, ",", "")
+		}
+	case lastComma >= 0:
+		if strings.Count(s, ",") == 1 && len(s)-lastComma-1 != 3 {
+			s = strings.Replace(s, ",", ".", 1)
+		} else {
+			s = strings.ReplaceAll(s, ",", "")
+		}
+	case strings.Count(s, ".") > 1:
+		s = strings.ReplaceAll(s, ".", "")
+	}
+	value, err := strconv.ParseFloat(s, 64)
+	return value, err == nil
+}
+
+// quantity converts a matched number and unit to square metres, or 0
+func quantity(number, unit string) float64 {
+	value, ok := ParseNumber(number)
+	if !ok {
+		return 0
+	}
+	sqm, _ := ToSqm(value, unit)
+	return sqm
+}
+
+// normalize lowercases s and turns the spaces and superscripts that come
+// from HTML into plain ones
+func normalize(s string) string {
+	return strings.NewReplacer(
+		"\u00a0", " ", "&nbsp;", " ",
+		"&sup2;", "²", "&#178;", "²",
+	).Replace(strings.ToLower(s))
+}
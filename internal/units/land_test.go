@@ -0,0 +1,182 @@
+package units
+
+import (
+	"math"
+	"testing"
+)
+
+// approxEqual allows for float rounding in the unit conversions
+func approxEqual(a, b float64) bool {
+	return math.Abs(a-b) <= 1e-9*math.Max(1, math.Abs(b))
+}
+
+func TestParseLandSize(t *testing.T) {
+	tests := []struct {
+		in   string
+		want float64
+	}{
+		// Hectares
+		{"100 ha", 100 * SqmPerHectare},
+		{"100ha", 100 * SqmPerHectare},
+		{"100 HA", 100 * SqmPerHectare},
+		{"1 hectare", SqmPerHectare},
+		{"2.5 hectares", 2.5 * SqmPerHectare},
+
+		// Acres
+		{"40 acres", 40 * SqmPerAcre},
+		{"1 acre", SqmPerAcre},
+		{"691.90 ac", 691.90 * SqmPerAcre},
+
+		// Square metres
+		{"5,000 m²", 5000},
+		{"5000 m2", 5000},
+		{"5000sqm", 5000},
+		{"5000 sq m", 5000},
+		{"5000 square metres", 5000},
+		{"5000 m&sup2;", 5000},
+
+		// Square feet
+		{"10,000 sq ft", 10000 * SqmPerSquareFoot},
+		{"10000 ft²", 10000 * SqmPerSquareFoot},
+		{"1000 square feet", 1000 * SqmPerSquareFoot},
+
+		// Ranges count as their lower bound
+		{"10–12 ha", 10 * SqmPerHectare},
+		{"10-12 ha", 10 * SqmPerHectare},
+		{"10 to 12 acres", 10 * SqmPerAcre},
+
+		// Decimal commas and thousands separators
+		{"12,5 ha", 12.5 * SqmPerHectare},
+		{"1.234,5 m²", 1234.5},
+		{"1,234.5 ha", 1234.5 * SqmPerHectare},
+		{"1,200 acres", 1200 * SqmPerAcre},
+		{"1.200.000 m²", 1200000},
+		{"5&nbsp;ha", 5 * SqmPerHectare},
+
+		// No unit: square metres
+		{"5000", 5000},
+		{"5,000", 5000},
+
+		// Unknown units, no number
+		{"100 furlongs", 0},
+		{"100 hay", 0},
+		{"n/a", 0},
+		{"", 0},
+
+		// Zero and negative sizes
+		{"0 ha", 0},
+		{"0.0 ac", 0},
+		{"0", 0},
+		{"0-10 ha", 0},
+		{"-5 ha", 0},
+		{"−5 ha", 0},
+		{"-5", 0},
+	}
+
+	for _, tt := range tests {
+		if got := ParseLandSize(tt.in); !approxEqual(got, tt.want) {
+			t.Errorf("ParseLandSize(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestFindLandSize(t *testing.T) {
+	tests := []struct {
+		in   string
+		want float64
+	}{
+		{"Beautiful 280ha farm", 280 * SqmPerHectare},
+		{"Lots of hay and 40 acres of river flats", 40 * SqmPerAcre},
+		{"Set on 10–12 ha of rolling hills", 10 * SqmPerHectare},
+		{"12,5 ha", 12.5 * SqmPerHectare},
+		{"Price $1,200 / 20 acres", 20 * SqmPerAcre},
+		// A dash before a size is a bullet, not a minus sign
+		{"Features:\n- 5 ha orchard", 5 * SqmPerHectare},
+
+		// Hectares and acres come before labelled square metres
+		{"Land size: 5,000 m², with 2 acres fenced", 2 * SqmPerAcre},
+
+		// Square metres and feet only when labelled as the land
+		{"Land size: 5,000 m²", 5000},
+		{"Land area 1,000 sq ft", 1000 * SqmPerSquareFoot},
+		{"House 250 m² on a quiet street", 0},
+		{"Shed 200 sqm", 0},
+
+		// Zero sizes are passed over
+		{"0 ha cleared, 12 ha timbered", 12 * SqmPerHectare},
+		{"Land: 0 m²", 0},
+
+		// Nothing to find
+		{"Contact agent", 0},
+		{"", 0},
+	}
+
+	for _, tt := range tests {
+		if got := FindLandSize(tt.in); !approxEqual(got, tt.want) {
+			t.Errorf("FindLandSize(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestToSqm(t *testing.T) {
+	tests := []struct {
+		value  float64
+		unit   string
+		want   float64
+		wantOK bool
+	}{
+		{2, "ha", 2 * SqmPerHectare, true},
+		{2, "Hectares", 2 * SqmPerHectare, true},
+		{2, "ac", 2 * SqmPerAcre, true},
+		{2, "Acres", 2 * SqmPerAcre, true},
+		{2, "m²", 2, true},
+		{2, "m2", 2, true},
+		{2, "sqm", 2, true},
+		{2, "sq. m", 2, true},
+		{2, "square metres", 2, true},
+		{2, "sq ft", 2 * SqmPerSquareFoot, true},
+		{2, "sq_ft", 2 * SqmPerSquareFoot, true},
+		{2, "ft²", 2 * SqmPerSquareFoot, true},
+		{2, "square feet", 2 * SqmPerSquareFoot, true},
+		{0, "ha", 0, true},
+
+		// Unknown or missing units
+		{2, "", 0, false},
+		{2, "furlongs", 0, false},
+		{2, "km2", 0, false},
+	}
+
+	for _, tt := range tests {
+		got, ok := ToSqm(tt.value, tt.unit)
+		if ok != tt.wantOK || !approxEqual(got, tt.want) {
+			t.Errorf("ToSqm(%v, %q) = %v, %v, want %v, %v", tt.value, tt.unit, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestParseNumber(t *testing.T) {
+	tests := []struct {
+		in     string
+		want   float64
+		wantOK bool
+	}{
+		{"42", 42, true},
+		{" 42 ", 42, true},
+		{"1,234.5", 1234.5, true},
+		{"1.234,5", 1234.5, true},
+		{"12,5", 12.5, true},
+		{"1,200", 1200, true}, // A lone comma before three digits separates thousands
+		{"1,200,000", 1200000, true},
+		{"1.200.000", 1200000, true},
+		{"0", 0, true},
+		{"", 0, false},
+		{"abc", 0, false},
+	}
+
+	for _, tt := range tests {
+		got, ok := ParseNumber(tt.in)
+		if ok != tt.wantOK || !approxEqual(got, tt.want) {
+			t.Errorf("ParseNumber(%q) = %v, %v, want %v, %v", tt.in, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
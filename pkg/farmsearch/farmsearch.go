@@ -0,0 +1,235 @@
+// Package farmsearch embeds the farm search engine in other Go programs:
+// searching a farm-search database, looking up property details and
+// enriching properties, as the server and tools do, without shelling out to
+// the CLI or calling the HTTP API.
+//
+//	engine, err := farmsearch.Open("data/farm-search.db", farmsearch.Options{ValhallaURL: "http://localhost:8002"})
+//	if err != nil { ... }
+//	defer engine.Close()
+//
+//	filter, err := farmsearch.ParseFilter("price_max=900000&land_size_min=400000&sort=-first_seen_at")
+//	properties, err := engine.Search(ctx, filter)
+//	detail, err := engine.Property(ctx, properties[0].ID)
+//
+// The types are the ones the API serves as JSON, so their fields and tags
+// match its documentation.
+package farmsearch
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+
+	"farm-search/internal/db"
+	"farm-search/internal/events"
+	"farm-search/internal/geo"
+	"farm-search/internal/models"
+	"farm-search/internal/service"
+)
+
+type (
+	// Filter selects and sorts properties, as GET /api/properties' query
+	// parameters do; see ParseFilter
+	Filter = db.PropertyFilter
+	// Property is a search result: a canonical property's list fields
+	Property = models.PropertyListItem
+	// PropertyDetail is everything known about a property, as served by
+	// GET /api/properties/:id
+	PropertyDetail = models.PropertyDetail
+	// StepResult is the outcome of one enrichment step
+	StepResult = service.StepResult
+	// EnrichmentStep names an enrichment step, e.g. "drive_time_primary"
+	EnrichmentStep = db.EnrichmentStep
+	// Publisher sends events, such as enrichment.completed, to a bus or
+	// webhook; implement it to receive them in-process
+	Publisher = events.Publisher
+	// Event is one change to a property
+	Event = events.Event
+)
+
+// ErrNotFound is returned for a property ID that isn't in the database
+var ErrNotFound = errors.New("property not found")
+
+// Options configures an Engine. The zero value searches and enriches
+// without Valhalla, measuring drive times to Sutherland.
+type Options struct {
+	// ValhallaURL generates drive time area filters' isochrones and routes
+	// the drive time steps; without one only isochrones already generated
+	// can be filtered by and the steps are skipped
+	ValhallaURL string
+	// Anchor is where primary drive times are measured to, as
+	// "Name:lat,lng"; the default is Sutherland
+	Anchor string
+	// Events are told what Enrich recomputed, if set (see EventsFromEnv)
+	Events Publisher
+}
+
+// EnrichOptions picks the network data sources enrichment may use; the
+// steps needing a source that's off are skipped. The vegetation steps use
+// the backend VEGETATION_SOURCE configures, if any.
+type EnrichOptions struct {
+	Schools   bool // Download NSW school data for the school steps
+	Cadastral bool // Fetch cadastral lots from NSW Spatial Services
+	Heritage  bool // Check lots against the NSW heritage layers
+	LotSize   bool // Check lots against the LEP minimum lot size layer
+}
+
+// AllSources enables every network data source, as tools enrich does by default
+var AllSources = EnrichOptions{Schools: true, Cadastral: true, Heritage: true, LotSize: true}
+
+// Engine is an open farm-search database. Safe for concurrent use.
+type Engine struct {
+	db         *db.DB
+	properties *service.PropertyService
+	router     *geo.Router // nil without Valhalla
+	anchor     geo.Anchor
+	events     events.Publisher
+}
+
+// Open opens (creating if need be) and migrates the database at path
+func Open(path string, opts Options) (*Engine, error) {
+	anchor := geo.Sutherland
+	if opts.Anchor != "" {
+		var err error
+		if anchor, err = geo.ParseAnchor(opts.Anchor); err != nil {
+			return nil, err
+		}
+	}
+
+	database, err := db.New(path)
+	if err != nil {
+		return nil, err
+	}
+	e := &Engine{db: database, anchor: anchor, events: opts.Events}
+	if opts.ValhallaURL != "" {
+		e.router = geo.NewRouter(opts.ValhallaURL).WithRouteCache(geo.NewRouteCache())
+	}
+	// Without Valhalla only drive time areas already generated can be used
+	isochrones := service.NewIsochroneService(database, geo.NewIsochroneGenerator(opts.ValhallaURL))
+	e.properties = service.NewPropertyService(database, isochrones)
+	return e, nil
+}
+
+// EventsFromEnv returns the publisher EVENTS_URL configures, or nil if it
+// isn't set
+func EventsFromEnv() (Publisher, error) {
+	return events.FromEnv()
+}
+
+// Close closes the database
+func (e *Engine) Close() error {
+	return e.db.Close()
+}
+
+// ParseFilter parses a GET /api/properties query string, e.g.
+// "price_max=900000&tags=shortlist&sort=-first_seen_at"
+func ParseFilter(query string) (Filter, error) {
+	values, err := url.ParseQuery(query)
+	if err != nil {
+		return Filter{}, fmt.Errorf("invalid filter: %w", err)
+	}
+	return service.ParsePropertyFilter(values), nil
+}
+
+// Search returns the canonical properties matching f, in its sort order.
+// Without a limit every match is returned; a limit is capped at 500.
+func (e *Engine) Search(ctx context.Context, f Filter) ([]Property, error) {
+	return e.properties.List(ctx, f)
+}
+
+// Property returns a property's details. A duplicate listing's ID resolves
+// to its canonical property.
+func (e *Engine) Property(ctx context.Context, id int64) (*PropertyDetail, error) {
+	p, err := e.properties.Detail(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return p, err
+}
+
+// Enrich runs the enrichment steps, as tools enrich does: each for the
+// properties missing it or marked stale, after marking stale the steps whose
+// inputs (the anchor, towns, schools and imported layers) changed, then
+// rescores every property and updates the property list. The drive time steps are skipped if Valhalla
+// isn't up.
+func (e *Engine) Enrich(ctx context.Context, opts EnrichOptions) ([]StepResult, error) {
+	enrichment, err := e.enrichment(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	if err := enrichment.MarkChangedInputs(); err != nil {
+		return nil, fmt.Errorf("failed to check enrichment inputs: %w", err)
+	}
+	results := enrichment.Refresh(ctx)
+	if _, err := service.NewScoringService(e.db).ComputeAll(); err != nil {
+		return results, fmt.Errorf("failed to rescore properties: %w", err)
+	}
+	if _, err := e.db.RefreshPropertySearch(); err != nil {
+		return results, err
+	}
+	return results, nil
+}
+
+// EnrichProperty runs the enrichment steps one property is missing or has
+// stale, and updates its row in the property list. Scores aren't recomputed,
+// as for a manual coordinate correction; Enrich rescores everything.
+func (e *Engine) EnrichProperty(ctx context.Context, id int64, opts EnrichOptions) ([]StepResult, error) {
+	if _, err := e.db.GetProperty(id); errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	enrichment, err := e.enrichment(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	results := enrichment.ForProperty(id).Refresh(ctx)
+	if _, err := e.db.RefreshPropertySearch(); err != nil {
+		return results, err
+	}
+	return results, nil
+}
+
+// enrichment returns an enrichment service with the sources opts enables
+func (e *Engine) enrichment(ctx context.Context, opts EnrichOptions) (*service.EnrichmentService, error) {
+	router := e.router
+	if router != nil && router.Status(ctx) != nil {
+		router = nil
+	}
+
+	var schools *geo.SchoolData
+	if opts.Schools {
+		schools = geo.NewSchoolData()
+		if err := schools.LoadFromNSWData(ctx); err != nil {
+			return nil, fmt.Errorf("failed to load school data: %w", err)
+		}
+	}
+	var cadastral *geo.CadastralClient
+	if opts.Cadastral {
+		cadastral = geo.NewCadastralClient()
+	}
+
+	enrichment := service.NewEnrichmentService(e.db, router, schools, cadastral).
+		WithAnchor(e.anchor).WithEvents(e.events)
+	if opts.Heritage {
+		enrichment = enrichment.WithHeritage(geo.NewHeritageClient())
+	}
+	if opts.LotSize {
+		enrichment = enrichment.WithLotSize(geo.NewLotSizeClient())
+	}
+	source, err := geo.NewVegetationSourceFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	if source != nil {
+		enrichment = enrichment.WithVegetation(source)
+	}
+	if schools != nil {
+		if _, err := enrichment.SaveSchools(); err != nil {
+			return nil, fmt.Errorf("failed to save schools: %w", err)
+		}
+	}
+	return enrichment, nil
+}
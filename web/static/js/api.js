@@ -16,11 +16,44 @@ const API = {
         if (filters.landSizeMax) params.set('land_size_max', filters.landSizeMax);
         if (filters.distanceSydneyMax) params.set('distance_sydney_max', filters.distanceSydneyMax);
         if (filters.distanceTownMax) params.set('distance_town_max', filters.distanceTownMax);
-        if (filters.driveTimeSydneyMax) params.set('drive_time_sydney_max', filters.driveTimeSydneyMax);
+        if (filters.driveTimePrimaryMax) params.set('drive_time_primary_max', filters.driveTimePrimaryMax);
         if (filters.driveTimeTownMax) params.set('drive_time_town_max', filters.driveTimeTownMax);
         if (filters.driveTimeSchoolMax) params.set('drive_time_school_max', filters.driveTimeSchoolMax);
+        if (filters.windFarmMinKm) params.set('wind_farm_min_km', filters.windFarmMinKm);
+        if (filters.solarFarmMinKm) params.set('solar_farm_min_km', filters.solarFarmMinKm);
+        // Planning overlays: max percent of the land (0 = not affected at all)
+        if (filters.koalaHabitatMaxPct !== undefined) params.set('koala_habitat_max_pct', filters.koalaHabitatMaxPct);
+        if (filters.biodiversityMaxPct !== undefined) params.set('biodiversity_max_pct', filters.biodiversityMaxPct);
+        // Pasture greenness: minimum mean NDVI
+        if (filters.ndviMin) params.set('ndvi_min', filters.ndviMin);
+        // Dwelling on the lots, from building footprints: true or false
+        if (filters.hasDwelling !== undefined) params.set('has_dwelling', filters.hasDwelling);
+        // Noise proxies: {highway: {min, max}, railway: {...}, runway: {...}} in km
+        if (filters.noise) {
+            for (const [source, range] of Object.entries(filters.noise)) {
+                if (range.min) params.set(`${source}_min_km`, range.min);
+                if (range.max) params.set(`${source}_max_km`, range.max);
+            }
+        }
+        if (filters.within) {
+            params.set('within_lat', filters.within.lat);
+            params.set('within_lng', filters.within.lng);
+            params.set('within_minutes', filters.within.minutes);
+        }
+        // Drawn search area: a GeoJSON Polygon geometry ([lng, lat] rings)
+        if (filters.polygon) params.set('polygon', JSON.stringify(filters.polygon));
+        // Exclusions: a GeoJSON Polygon to avoid, and {layer: km} radii around imported layers
+        if (filters.excludePolygon) params.set('exclude_polygon', JSON.stringify(filters.excludePolygon));
+        if (filters.excludeNear) {
+            const near = Object.entries(filters.excludeNear).map(([layer, km]) => `${layer}:${km}`);
+            if (near.length > 0) params.set('exclude_near', near.join(','));
+        }
+        if (filters.tags && filters.tags.length > 0) params.set('tags', filters.tags.join(','));
+        if (filters.excludeTags && filters.excludeTags.length > 0) params.set('exclude_tags', filters.excludeTags.join(','));
         if (filters.bounds) params.set('bounds', filters.bounds);
         if (filters.limit) params.set('limit', filters.limit);
+        if (filters.profile) params.set('profile', filters.profile);
+        if (filters.sort) params.set('sort', filters.sort);
 
         const response = await fetch(`${this.baseUrl}/properties?${params}`);
         if (!response.ok) {
@@ -38,6 +71,62 @@ const API = {
         return response.json();
     },
 
+    // Fetch several properties' details in one request: {properties, missing}
+    async getPropertyDetails(ids) {
+        const response = await fetch(`${this.baseUrl}/properties/details`, {
+            method: 'POST',
+            headers: { 'Content-Type': 'application/json' },
+            body: JSON.stringify({ ids })
+        });
+        if (!response.ok) {
+            throw new Error(`Failed to fetch properties: ${response.statusText}`);
+        }
+        return response.json();
+    },
+
+    // Fetch the other listings within km of a property, closest first
+    async getNeighbours(id, km = 5) {
+        const response = await fetch(`${this.baseUrl}/properties/${id}/neighbours?km=${km}`);
+        if (!response.ok) {
+            throw new Error(`Failed to fetch neighbours: ${response.statusText}`);
+        }
+        return response.json();
+    },
+
+    // Look up a lot by a Lot/DP reference, e.g. "Lot 12 DP1234567" (a GeoJSON Feature, or null if there's no such lot)
+    async searchLot(query) {
+        const response = await fetch(`${this.baseUrl}/lots?q=${encodeURIComponent(query)}`);
+        if (response.status === 404) {
+            return null;
+        }
+        if (!response.ok) {
+            throw new Error(await response.text());
+        }
+        return response.json();
+    },
+
+    // Save filter state behind a share link: {token, url}
+    async createShareLink(state) {
+        const response = await fetch(`${this.baseUrl}/share`, {
+            method: 'POST',
+            headers: { 'Content-Type': 'application/json' },
+            body: JSON.stringify(state)
+        });
+        if (!response.ok) {
+            throw new Error(await response.text());
+        }
+        return response.json();
+    },
+
+    // Fetch the filter state behind a share link: {token, filters, created_at}
+    async getShareLink(token) {
+        const response = await fetch(`${this.baseUrl}/share/${encodeURIComponent(token)}`);
+        if (!response.ok) {
+            throw new Error(`Failed to fetch share link: ${response.statusText}`);
+        }
+        return response.json();
+    },
+
     // Fetch filter options
     async getFilterOptions() {
         const response = await fetch(`${this.baseUrl}/filters/options`);
@@ -47,6 +136,45 @@ const API = {
         return response.json();
     },
 
+    // Fetch the imported exclusion layers (for exclude_near)
+    async getExclusionLayers() {
+        const response = await fetch(`${this.baseUrl}/exclusion-layers`);
+        if (!response.ok) {
+            throw new Error(`Failed to fetch exclusion layers: ${response.statusText}`);
+        }
+        return response.json();
+    },
+
+    // Add (or remove) a tag on properties: selection is {ids: [...]} or {query: 'filter query string'}
+    async setTag(tag, selection, add = true) {
+        const action = add ? 'add' : 'remove';
+        const response = await fetch(`${this.baseUrl}/tags/${encodeURIComponent(tag)}/${action}`, {
+            method: 'POST',
+            headers: { 'Content-Type': 'application/json' },
+            body: JSON.stringify(selection)
+        });
+        if (!response.ok) {
+            throw new Error(`Failed to ${action} tag: ${await response.text()}`);
+        }
+        return response.json();
+    },
+
+    // Attach a document (a File) to a property; kind is e.g. 'contract' or 'soil-test'
+    async uploadAttachment(propertyId, file, kind = 'other', note = '') {
+        const form = new FormData();
+        form.append('file', file);
+        form.append('kind', kind);
+        if (note) form.append('note', note);
+        const response = await fetch(`${this.baseUrl}/properties/${propertyId}/attachments`, {
+            method: 'POST',
+            body: form
+        });
+        if (!response.ok) {
+            throw new Error(`Failed to upload attachment: ${await response.text()}`);
+        }
+        return response.json();
+    },
+
     // Trigger scraper
     async triggerScrape() {
         const response = await fetch(`${this.baseUrl}/scrape/trigger`, {
@@ -67,6 +195,15 @@ const API = {
         return response.json();
     },
 
+    // Fetch an isochrone around any point, generated on demand by the server
+    async getIsochroneAround(lat, lng, minutes) {
+        const response = await fetch(`${this.baseUrl}/isochrone?lat=${lat}&lng=${lng}&minutes=${minutes}`);
+        if (!response.ok) {
+            return null; // Valhalla may be down or unable to reach the point
+        }
+        return response.json();
+    },
+
     // Fetch property boundaries (cadastral lots) within map bounds
     // Accepts same filters as getProperties to ensure boundaries match visible properties
     async getBoundaries(bounds, zoom, filters = {}) {
@@ -86,9 +223,40 @@ const API = {
         if (filters.landSizeMax) params.set('land_size_max', filters.landSizeMax);
         if (filters.distanceSydneyMax) params.set('distance_sydney_max', filters.distanceSydneyMax);
         if (filters.distanceTownMax) params.set('distance_town_max', filters.distanceTownMax);
-        if (filters.driveTimeSydneyMax) params.set('drive_time_sydney_max', filters.driveTimeSydneyMax);
+        if (filters.driveTimePrimaryMax) params.set('drive_time_primary_max', filters.driveTimePrimaryMax);
         if (filters.driveTimeTownMax) params.set('drive_time_town_max', filters.driveTimeTownMax);
         if (filters.driveTimeSchoolMax) params.set('drive_time_school_max', filters.driveTimeSchoolMax);
+        if (filters.windFarmMinKm) params.set('wind_farm_min_km', filters.windFarmMinKm);
+        if (filters.solarFarmMinKm) params.set('solar_farm_min_km', filters.solarFarmMinKm);
+        // Planning overlays: max percent of the land (0 = not affected at all)
+        if (filters.koalaHabitatMaxPct !== undefined) params.set('koala_habitat_max_pct', filters.koalaHabitatMaxPct);
+        if (filters.biodiversityMaxPct !== undefined) params.set('biodiversity_max_pct', filters.biodiversityMaxPct);
+        // Pasture greenness: minimum mean NDVI
+        if (filters.ndviMin) params.set('ndvi_min', filters.ndviMin);
+        // Dwelling on the lots, from building footprints: true or false
+        if (filters.hasDwelling !== undefined) params.set('has_dwelling', filters.hasDwelling);
+        // Noise proxies: {highway: {min, max}, railway: {...}, runway: {...}} in km
+        if (filters.noise) {
+            for (const [source, range] of Object.entries(filters.noise)) {
+                if (range.min) params.set(`${source}_min_km`, range.min);
+                if (range.max) params.set(`${source}_max_km`, range.max);
+            }
+        }
+        if (filters.within) {
+            params.set('within_lat', filters.within.lat);
+            params.set('within_lng', filters.within.lng);
+            params.set('within_minutes', filters.within.minutes);
+        }
+        // Drawn search area: a GeoJSON Polygon geometry ([lng, lat] rings)
+        if (filters.polygon) params.set('polygon', JSON.stringify(filters.polygon));
+        // Exclusions: a GeoJSON Polygon to avoid, and {layer: km} radii around imported layers
+        if (filters.excludePolygon) params.set('exclude_polygon', JSON.stringify(filters.excludePolygon));
+        if (filters.excludeNear) {
+            const near = Object.entries(filters.excludeNear).map(([layer, km]) => `${layer}:${km}`);
+            if (near.length > 0) params.set('exclude_near', near.join(','));
+        }
+        if (filters.tags && filters.tags.length > 0) params.set('tags', filters.tags.join(','));
+        if (filters.excludeTags && filters.excludeTags.length > 0) params.set('exclude_tags', filters.excludeTags.join(','));
 
         const response = await fetch(`${this.baseUrl}/boundaries?${params}`);
         if (!response.ok) {
@@ -231,12 +231,22 @@ const App = {
       () => this.loadProperties(),
     );
 
+    // A share link (/s/{token} redirects to /?share={token}) replaces the saved filters
+    const shareToken = new URLSearchParams(window.location.search).get("share");
+    if (shareToken) {
+      await Filters.loadShared(shareToken);
+      history.replaceState(null, "", "/");
+    }
+
     // Setup property sidebar
     this.initPropertySidebar();
 
     // Setup layer switcher
     this.initLayerSwitcher();
 
+    // Setup Lot/DP search
+    this.initLotSearch();
+
     // Initialize fullscreen modal
     FullscreenModal.init();
 
@@ -266,9 +276,11 @@ const App = {
 
       const data = await API.getProperties(filters);
 
-      PropertyMap.addPropertyMarkers(data.properties, (id) => {
-        this.showPropertyDetails(id);
-      });
+      PropertyMap.addPropertyMarkers(
+        data.properties,
+        (id) => this.showPropertyDetails(id),
+        (ids) => this.showPropertyGroup(ids),
+      );
 
       // Update map filters so boundaries match visible properties
       PropertyMap.setFilters(filters);
@@ -304,6 +316,7 @@ const App = {
       const property = await API.getProperty(id);
       this.currentProperty = property;
       this.renderPropertySidebar(property);
+      this.loadNeighbours(property);
       // Clear any previous route when opening a new property
       PropertyMap.clearRoute();
     } catch (err) {
@@ -315,6 +328,43 @@ const App = {
     }
   },
 
+  // List overlapping markers' properties in the sidebar, fetched in one
+  // request, each opening its details
+  async showPropertyGroup(ids) {
+    const container = document.getElementById("property-detail");
+    container.innerHTML =
+      '<div class="loading-spinner" style="margin: 40px auto;"></div>';
+    this.showPropertySidebar();
+    this.currentProperty = null;
+    PropertyMap.clearRoute();
+
+    let data;
+    try {
+      data = await API.getPropertyDetails(ids);
+    } catch (err) {
+      console.error("Failed to load properties:", err);
+      container.innerHTML =
+        '<p style="color: #dc2626; text-align: center;">Failed to load properties.</p>';
+      return;
+    }
+
+    container.innerHTML = `
+            <div class="property-group">
+                <strong>${data.properties.length} properties here</strong>
+                ${data.properties
+                  .map(
+                    (p) => `
+                <div class="neighbour-item" data-id="${p.id}">
+                    ${p.address || p.suburb} · ${p.price_text || "Contact Agent"}${p.land_size_sqm ? ` · ${formatLandSize(p.land_size_sqm)}` : ""}
+                </div>`,
+                  )
+                  .join("")}
+            </div>`;
+    container.querySelectorAll(".neighbour-item").forEach((item) => {
+      item.addEventListener("click", () => this.showPropertyDetails(parseInt(item.dataset.id, 10)));
+    });
+  },
+
   // Render property details in sidebar
   renderPropertySidebar(property) {
     const container = document.getElementById("property-detail");
@@ -346,13 +396,39 @@ const App = {
             `;
     }
 
+    // Plus codes (and what3words) for sharing inspection spots on unnamed roads
+    let shareHtml = "";
+    if (property.share) {
+      const shareLine = (label, loc) => `
+                <div class="share-location">
+                    <span class="share-label">${label}</span>
+                    <a href="${loc.plus_code_url}" target="_blank" rel="noopener">${loc.plus_code}</a>
+                    ${loc.what3words ? `<a href="${loc.what3words_url}" target="_blank" rel="noopener">///${loc.what3words}</a>` : ""}
+                    <a href="${loc.maps_url}" target="_blank" rel="noopener">Map</a>
+                </div>`;
+      shareHtml = `
+            <div class="share-locations">
+                ${shareLine("Pin", property.share.point)}
+                ${property.share.lot_centroid ? shareLine("Lot centre", property.share.lot_centroid) : ""}
+            </div>`;
+    }
+
+    // Street View, aerial imagery and Google Earth, generated by the imagery_links step
+    const imageryLinks = [
+      ["Street View", property.street_view_url],
+      ["Aerial (SIX Maps)", property.aerial_url],
+      ["Google Earth", property.google_earth_url],
+    ].filter(([, url]) => url)
+      .map(([label, url]) => `<a href="${url}" target="_blank" rel="noopener">${label}</a>`);
+    const imageryHtml = imageryLinks.length > 0 ? `<div class="imagery-links">${imageryLinks.join("")}</div>` : "";
+
     // Format drive time if available
     let driveTimeHtml = "";
-    if (property.drive_time_sydney) {
-      const hours = Math.floor(property.drive_time_sydney / 60);
-      const mins = property.drive_time_sydney % 60;
+    if (property.drive_time_primary) {
+      const hours = Math.floor(property.drive_time_primary / 60);
+      const mins = property.drive_time_primary % 60;
       const timeStr = hours > 0 ? `${hours}h ${mins}m` : `${mins} min`;
-      driveTimeHtml = `<div class="drive-time-info">${timeStr} drive to Sutherland</div>`;
+      driveTimeHtml = `<div class="drive-time-info">${timeStr} drive to ${window.ANCHOR.name}</div>`;
     }
 
     // Format nearest towns if available (show drive time if available, otherwise distance)
@@ -375,6 +451,14 @@ const App = {
         townsContent += `<span class="town-item clickable" data-town="${property.nearest_town_2}">${property.nearest_town_2} (${town2Info})</span>`;
       }
 
+      // Walking and cycling times, only worked out on a town's fringe
+      const walkCycle = [];
+      if (property.nearest_town_1_walk_mins) walkCycle.push(`${property.nearest_town_1_walk_mins} min walk`);
+      if (property.nearest_town_1_cycle_mins) walkCycle.push(`${property.nearest_town_1_cycle_mins} min cycle`);
+      if (walkCycle.length > 0) {
+        townsContent += `<div class="walk-cycle">${walkCycle.join(" · ")} to ${property.nearest_town_1}</div>`;
+      }
+
       nearestTownsHtml = `<div class="nearest-towns">${townsContent}</div>`;
     }
 
@@ -403,19 +487,150 @@ const App = {
       nearestSchoolsHtml = `<div class="nearest-schools">${schoolsContent}</div>`;
     }
 
+    // Flag wind and solar farms (operating or planned) within 10 km
+    const energyFlags = [];
+    if (property.nearest_wind_farm_km !== undefined && property.nearest_wind_farm_km < 10) {
+      energyFlags.push(`${property.nearest_wind_farm} wind farm (${property.nearest_wind_farm_status}, ${property.nearest_wind_farm_km.toFixed(1)} km)`);
+    }
+    if (property.nearest_solar_farm_km !== undefined && property.nearest_solar_farm_km < 10) {
+      energyFlags.push(`${property.nearest_solar_farm} solar farm (${property.nearest_solar_farm_status}, ${property.nearest_solar_farm_km.toFixed(1)} km)`);
+    }
+    const energyHtml = energyFlags.length > 0
+      ? `<div class="energy-developments">${energyFlags.join("<br>")}</div>`
+      : "";
+
+    // Noise proxies: distances to the nearest highway, railway and runway
+    const noiseItems = [
+      ["Highway", property.highway_km],
+      ["Railway", property.railway_km],
+      ["Runway", property.runway_km],
+    ].filter(([, km]) => km !== undefined)
+      .map(([label, km]) => `<span class="noise-item">${label} ${km < 10 ? km.toFixed(1) : km.toFixed(0)} km</span>`);
+    const noiseHtml = noiseItems.length > 0 ? `<div class="noise-distances">${noiseItems.join("")}</div>` : "";
+
+    // Planning overlays restricting clearing and development, as a share of the land
+    const overlayFlags = [
+      ["Koala habitat", property.koala_habitat_pct],
+      ["Biodiversity values map", property.biodiversity_pct],
+    ].filter(([, pct]) => pct > 0)
+      .map(([label, pct]) => `${label}: ${pct < 1 ? "<1" : pct.toFixed(0)}% of the land`);
+    const overlaysHtml = overlayFlags.length > 0
+      ? `<div class="planning-overlays">${overlayFlags.join("<br>")}</div>`
+      : "";
+
+    // Local Land Services region and declared weed zones (management obligations)
+    let biosecurityHtml = "";
+    if (property.lls_region || (property.weed_zones && property.weed_zones.length > 0)) {
+      const weeds = (property.weed_zones || [])
+        .map((z) => `<span class="weed-zone">${z.weed}${z.category ? ` (${z.category})` : ""}</span>`)
+        .join("");
+      biosecurityHtml = `
+            <div class="biosecurity">
+                ${property.lls_region ? `<div>${property.lls_region} Local Land Services</div>` : ""}
+                ${weeds ? `<div>Declared weeds: ${weeds}</div>` : ""}
+            </div>`;
+    }
+
+    // Fire history over the lots: recently burnt land may need fencing, pasture and infrastructure work
+    let fireHistoryHtml = "";
+    if (property.burn_count_30y !== undefined) {
+      const latest = property.lot_fires && property.lot_fires.length > 0 ? property.lot_fires[0] : null;
+      const last = property.last_burn_year
+        ? `Last burnt ${property.last_burn_year}${latest && latest.fire_type ? ` (${latest.fire_type.toLowerCase()})` : ""}`
+        : "No recorded fires";
+      const count = property.burn_count_30y === 1 ? "1 burn" : `${property.burn_count_30y} burns`;
+      fireHistoryHtml = `<div class="fire-history">${last} · ${count} in the last 30 years</div>`;
+    }
+
+    // Woody cover change from Sentinel-2: a large loss is worth checking against clearing approvals
+    let clearingHtml = "";
+    if (property.woody_cover_change !== undefined) {
+      const change = property.woody_cover_change;
+      const cover = change === 0 ? "No change in woody cover" : `Woody cover ${change > 0 ? "up" : "down"} ${Math.abs(change)} pts`;
+      clearingHtml = `
+            <div class="vegetation-change${property.clearing_flagged ? " flagged" : ""}">
+                ${property.clearing_flagged ? "<strong>Possible recent clearing</strong> · " : ""}${cover} since ${property.clearing_baseline_year}
+            </div>`;
+    }
+
+    // Pasture greenness: mean NDVI over the lots, and the range between the greenest and brownest months
+    let ndviHtml = "";
+    if (property.ndvi_mean !== undefined) {
+      const monthNames = ["Jan", "Feb", "Mar", "Apr", "May", "Jun", "Jul", "Aug", "Sep", "Oct", "Nov", "Dec"];
+      const months = (property.ndvi_monthly || [])
+        .map((ndvi, i) => ({ ndvi, month: monthNames[i] }))
+        .filter((m) => m.ndvi !== null);
+      const greenest = months.reduce((a, b) => (b.ndvi > a.ndvi ? b : a), months[0]);
+      const brownest = months.reduce((a, b) => (b.ndvi < a.ndvi ? b : a), months[0]);
+      ndviHtml = `
+            <div class="ndvi-summary">
+                Mean NDVI ${property.ndvi_mean.toFixed(2)} · seasonal range ${property.ndvi_seasonal_range.toFixed(2)}
+                ${greenest ? `<div>Greenest ${greenest.month} (${greenest.ndvi.toFixed(2)}), brownest ${brownest.month} (${brownest.ndvi.toFixed(2)})</div>` : ""}
+            </div>`;
+    }
+
+    // Buildings on the lots, from footprints: no house-sized one usually means vacant land
+    let buildingsHtml = "";
+    if (property.building_count !== undefined) {
+      const count = property.building_count === 1 ? "1 building" : `${property.building_count} buildings`;
+      buildingsHtml = property.has_dwelling
+        ? `<div class="buildings">${count} · ${property.building_area_sqm.toLocaleString()} sqm (largest ${property.largest_building_sqm.toLocaleString()} sqm)</div>`
+        : `<div class="buildings no-dwelling"><strong>No dwelling mapped</strong> · ${property.building_count > 0 ? `${count}, largest ${property.largest_building_sqm.toLocaleString()} sqm` : "no buildings"}</div>`;
+    }
+
+    // Subdivision potential: the lots' area over the LEP minimum lot size
+    let subdivisionHtml = "";
+    if (property.subdivision_ratio !== undefined) {
+      const lots = Math.floor(property.subdivision_ratio);
+      subdivisionHtml = `
+            <div class="subdivision${lots >= 2 ? " potential" : ""}">
+                Minimum lot size ${formatLandSize(property.min_lot_size_sqm)} · ratio ${property.subdivision_ratio.toFixed(2)}
+                ${lots >= 2 ? `<div><strong>Could subdivide into up to ${lots} lots</strong></div>` : ""}
+            </div>`;
+    } else if (property.min_lot_size_sqm === 0) {
+      subdivisionHtml = `<div class="subdivision">No minimum lot size mapped</div>`;
+    }
+
+    // Heritage listings limit what can be built, so they go above everything else
+    let heritageHtml = "";
+    if (property.heritage_listing === "state" || property.heritage_listing === "local") {
+      const register = property.heritage_listing === "state" ? "State Heritage Register" : "Local heritage (LEP)";
+      const names = [...new Set((property.heritage_items || []).map((item) => item.name || item.item_id))];
+      heritageHtml = `
+            <div class="heritage-listing ${property.heritage_listing}">
+                <strong>Heritage listed: ${register}</strong>
+                ${names.length > 0 ? `<div>${names.join(", ")}</div>` : ""}
+            </div>`;
+    }
+
     container.innerHTML = `
             <h2>${property.address || "Property Details"}</h2>
+            ${heritageHtml}
             <div class="price">${property.price_text || "Contact Agent"}</div>
             <div class="property-meta">
                 ${property.land_size_sqm ? `<span>${formatLandSize(property.land_size_sqm)}</span>` : ""}
                 ${property.bedrooms ? `<span>${property.bedrooms} beds</span>` : ""}
                 ${property.bathrooms ? `<span>${property.bathrooms} baths</span>` : ""}
+                ${property.carspaces ? `<span>${property.carspaces} cars</span>` : ""}
+                ${property.dwelling_count > 1 ? `<span>${property.dwelling_count} dwellings</span>` : ""}
             </div>
+            ${buildingsHtml}
+            ${subdivisionHtml}
             ${driveTimeHtml}
             ${nearestTownsHtml}
             ${nearestSchoolsHtml}
+            ${energyHtml}
+            ${overlaysHtml}
+            ${biosecurityHtml}
+            ${fireHistoryHtml}
+            ${clearingHtml}
+            ${ndviHtml}
+            ${noiseHtml}
+            <div class="neighbours"></div>
             ${imagesHtml}
             <div class="description">${property.description || "No description available."}</div>
+            ${imageryHtml}
+            ${shareHtml}
             ${sourcesHtml}
         `;
 
@@ -451,6 +666,35 @@ const App = {
     });
   },
 
+  // List the other listings within 5 km under the property's details, each
+  // opening that property, for adding to the same trip
+  async loadNeighbours(property) {
+    let data;
+    try {
+      data = await API.getNeighbours(property.id);
+    } catch (err) {
+      console.error("Failed to load neighbours:", err);
+      return;
+    }
+    // The sidebar may have moved on to another property meanwhile
+    const el = document.querySelector("#property-detail .neighbours");
+    if (!el || this.currentProperty !== property || data.neighbours.length === 0) return;
+
+    el.innerHTML = `
+            <strong>Also for sale within ${data.km} km</strong>
+            ${data.neighbours
+              .map(
+                (n) => `
+            <div class="neighbour-item" data-id="${n.id}">
+                ${n.address || n.suburb} · ${n.price_text || "Contact Agent"} (${n.distance_km.toFixed(1)} km)
+            </div>`,
+              )
+              .join("")}`;
+    el.querySelectorAll(".neighbour-item").forEach((item) => {
+      item.addEventListener("click", () => this.showPropertyDetails(parseInt(item.dataset.id, 10)));
+    });
+  },
+
   // Initialize property sidebar functionality
   initPropertySidebar() {
     const sidebar = document.getElementById("property-sidebar");
@@ -500,6 +744,48 @@ const App = {
     });
   },
 
+  // Initialize the Lot/DP search box: outline the lot, and open the property on it if there's one
+  initLotSearch() {
+    const form = document.getElementById("lot-search");
+    const input = document.getElementById("lot-search-input");
+    const status = document.getElementById("lot-search-status");
+
+    form.addEventListener("submit", async (e) => {
+      e.preventDefault();
+      const query = input.value.trim();
+      status.classList.remove("error");
+      if (!query) {
+        status.textContent = "";
+        PropertyMap.clearLot();
+        return;
+      }
+
+      status.textContent = "Searching...";
+      try {
+        const lot = await API.searchLot(query);
+        if (!lot) {
+          status.textContent = "No such lot found";
+          status.classList.add("error");
+          PropertyMap.clearLot();
+          return;
+        }
+
+        PropertyMap.showLot(lot);
+        const props = lot.properties;
+        const listings = props.properties || [];
+        status.textContent = `${props.lot_id} · ${formatLandSize(Math.round(props.area_sqm))}` +
+          (listings.length > 0 ? ` · ${listings.length === 1 ? "1 listing" : `${listings.length} listings`}` : " · not listed");
+        if (listings.length > 0) {
+          this.showPropertyDetails(listings[0].id);
+        }
+      } catch (err) {
+        console.error("Lot search failed:", err);
+        status.textContent = err.message;
+        status.classList.add("error");
+      }
+    });
+  },
+
   showPropertySidebar() {
     document.getElementById("property-sidebar").classList.remove("hidden");
   },
@@ -526,6 +812,11 @@ function formatSourceName(source) {
     farmbuy: "FarmBuy",
     rea: "realestate.com.au",
     domain: "Domain",
+    elders: "Elders",
+    "raywhite-rural": "Ray White Rural",
+    nutrien: "Nutrien Harcourts",
+    gumtree: "Gumtree",
+    manual: "Manual entry",
   };
   return names[source] || source.toUpperCase();
 }
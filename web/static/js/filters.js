@@ -11,9 +11,13 @@ const Filters = {
     filterSchema: {
         'price-max': { type: 'number', min: 0, max: 36 },
         'land-size-min': { type: 'number', min: 0, max: 10 },
-        'drive-time-sydney': { type: 'number', min: 15, max: 255 },
+        'drive-time-primary': { type: 'number', min: 15, max: 255 },
         'drive-time-town': { type: 'number', min: 5, max: 60 },
         'drive-time-school': { type: 'number', min: 5, max: 60 },
+        'wind-farm-min': { type: 'number', min: 0, max: 30 },
+        'clearing-overlay-max': { type: 'string', allowed: ['', '0', '10', '25', '50'] },
+        'ndvi-min': { type: 'string', allowed: ['', '0.3', '0.4', '0.5', '0.6'] },
+        'dwelling': { type: 'string', allowed: ['', 'true', 'false'] },
         'isochrone-overlay': { type: 'string', allowed: ['', '60', '90', '120', '150', '180'] }
     },
 
@@ -54,10 +58,10 @@ const Filters = {
             filters.landSizeMin = (landSizeIdx + 1) * 10 * 10000;
         }
 
-        // Drive time to Sutherland (in minutes)
-        const driveTime = document.getElementById('drive-time-sydney');
+        // Drive time to the anchor (in minutes)
+        const driveTime = document.getElementById('drive-time-primary');
         if (parseInt(driveTime.value, 10) < parseInt(driveTime.max, 10)) {
-            filters.driveTimeSydneyMax = parseInt(driveTime.value, 10);
+            filters.driveTimePrimaryMax = parseInt(driveTime.value, 10);
         }
 
         // Drive time to nearest town (in minutes)
@@ -72,6 +76,25 @@ const Filters = {
             filters.driveTimeSchoolMax = parseInt(driveTimeSchool.value, 10);
         }
 
+        // Minimum distance to a wind farm, operating or planned (in km, 0 = Any)
+        const windFarmMin = parseInt(document.getElementById('wind-farm-min').value, 10);
+        if (windFarmMin > 0) filters.windFarmMinKm = windFarmMin;
+
+        // Maximum share of the land under koala habitat or the biodiversity values map ('' = Any)
+        const overlayMax = document.getElementById('clearing-overlay-max').value;
+        if (overlayMax !== '') {
+            filters.koalaHabitatMaxPct = parseInt(overlayMax, 10);
+            filters.biodiversityMaxPct = parseInt(overlayMax, 10);
+        }
+
+        // Minimum mean NDVI over the lots ('' = Any)
+        const ndviMin = document.getElementById('ndvi-min').value;
+        if (ndviMin !== '') filters.ndviMin = parseFloat(ndviMin);
+
+        // Dwelling on the lots ('' = Any)
+        const dwelling = document.getElementById('dwelling').value;
+        if (dwelling !== '') filters.hasDwelling = dwelling === 'true';
+
         return filters;
     },
 
@@ -85,9 +108,9 @@ const Filters = {
         landSize.value = 10;
         this.updateRangeDisplay('land-size-min', 'Any');
 
-        const driveTime = document.getElementById('drive-time-sydney');
+        const driveTime = document.getElementById('drive-time-primary');
         driveTime.value = driveTime.max;
-        this.updateRangeDisplay('drive-time-sydney', 'Any');
+        this.updateRangeDisplay('drive-time-primary', 'Any');
 
         const driveTimeTown = document.getElementById('drive-time-town');
         driveTimeTown.value = driveTimeTown.max;
@@ -97,9 +120,16 @@ const Filters = {
         driveTimeSchool.value = driveTimeSchool.max;
         this.updateRangeDisplay('drive-time-school', 'Any');
 
+        document.getElementById('wind-farm-min').value = 0;
+        this.updateRangeDisplay('wind-farm-min', 'Any');
+
+        document.getElementById('clearing-overlay-max').value = '';
+        document.getElementById('ndvi-min').value = '';
+        document.getElementById('dwelling').value = '';
+
         document.getElementById('isochrone-overlay').value = '';
         if (typeof PropertyMap !== 'undefined') {
-            PropertyMap.setIsochrone('sutherland', '');
+            PropertyMap.setIsochrone(window.ANCHOR.slug, '');
         }
     },
 
@@ -144,6 +174,9 @@ const Filters = {
             onClear();
         });
 
+        // Share button - saves the current filters behind a link
+        document.getElementById('share-filters').addEventListener('click', () => this.share());
+
         // Price slider (max only)
         this.initPriceSlider('price-max', onApplyAndSave);
 
@@ -151,17 +184,29 @@ const Filters = {
         this.initLandSizeSlider('land-size-min', onApplyAndSave);
 
         // Drive time sliders
-        this.initDriveTimeSlider('drive-time-sydney', onApplyAndSave);
+        this.initDriveTimeSlider('drive-time-primary', onApplyAndSave);
         this.initDriveTimeSliderTown('drive-time-town', onApplyAndSave);
 
         // Drive time to school slider
         this.initDriveTimeSliderSchool('drive-time-school', onApplyAndSave);
 
+        // Wind farm distance slider
+        this.initMinDistanceSlider('wind-farm-min', onApplyAndSave);
+
+        // Koala habitat / biodiversity overlay dropdown
+        document.getElementById('clearing-overlay-max').addEventListener('change', onApplyAndSave);
+
+        // Pasture greenness dropdown
+        document.getElementById('ndvi-min').addEventListener('change', onApplyAndSave);
+
+        // Dwelling dropdown
+        document.getElementById('dwelling').addEventListener('change', onApplyAndSave);
+
         // Isochrone overlay dropdown - updates map display only (not filtering)
         document.getElementById('isochrone-overlay').addEventListener('change', (e) => {
             const minutes = e.target.value;
             if (typeof PropertyMap !== 'undefined') {
-                PropertyMap.setIsochrone('sutherland', minutes);
+                PropertyMap.setIsochrone(window.ANCHOR.slug, minutes);
             }
             this.save();
         });
@@ -170,7 +215,7 @@ const Filters = {
         if (hadSavedFilters) {
             const isochrone = document.getElementById('isochrone-overlay').value;
             if (isochrone && typeof PropertyMap !== 'undefined') {
-                PropertyMap.onReady(() => PropertyMap.setIsochrone('sutherland', isochrone));
+                PropertyMap.onReady(() => PropertyMap.setIsochrone(window.ANCHOR.slug, isochrone));
             }
         }
     },
@@ -297,6 +342,21 @@ const Filters = {
         input.addEventListener('change', onApply);
     },
 
+    // Initialize a minimum distance slider (5 km increments, 0 = Any)
+    initMinDistanceSlider(inputId, onApply) {
+        const input = document.getElementById(inputId);
+        const display = document.getElementById(`${inputId}-value`);
+
+        // Update display on input (while dragging)
+        input.addEventListener('input', () => {
+            const km = parseInt(input.value, 10);
+            display.textContent = km > 0 ? `${km} km` : 'Any';
+        });
+
+        // Apply filter on change (when released)
+        input.addEventListener('change', onApply);
+    },
+
     // Update results count display
     updateResultsCount(count) {
         document.getElementById('results-count').textContent = count;
@@ -364,9 +424,13 @@ const Filters = {
         return {
             'price-max': parseInt(document.getElementById('price-max').value, 10),
             'land-size-min': parseInt(document.getElementById('land-size-min').value, 10),
-            'drive-time-sydney': parseInt(document.getElementById('drive-time-sydney').value, 10),
+            'drive-time-primary': parseInt(document.getElementById('drive-time-primary').value, 10),
             'drive-time-town': parseInt(document.getElementById('drive-time-town').value, 10),
             'drive-time-school': parseInt(document.getElementById('drive-time-school').value, 10),
+            'wind-farm-min': parseInt(document.getElementById('wind-farm-min').value, 10),
+            'clearing-overlay-max': document.getElementById('clearing-overlay-max').value,
+            'ndvi-min': document.getElementById('ndvi-min').value,
+            'dwelling': document.getElementById('dwelling').value,
             'isochrone-overlay': document.getElementById('isochrone-overlay').value
         };
     },
@@ -433,10 +497,10 @@ const Filters = {
             this.updateRangeDisplay('land-size-min', display);
         }
 
-        if (filters['drive-time-sydney'] !== undefined) {
-            const el = document.getElementById('drive-time-sydney');
-            el.value = filters['drive-time-sydney'];
-            this.updateRangeDisplay('drive-time-sydney', this.formatDriveTime(filters['drive-time-sydney']));
+        if (filters['drive-time-primary'] !== undefined) {
+            const el = document.getElementById('drive-time-primary');
+            el.value = filters['drive-time-primary'];
+            this.updateRangeDisplay('drive-time-primary', this.formatDriveTime(filters['drive-time-primary']));
         }
 
         if (filters['drive-time-town'] !== undefined) {
@@ -453,12 +517,75 @@ const Filters = {
             this.updateRangeDisplay('drive-time-school', display);
         }
 
+        if (filters['wind-farm-min'] !== undefined) {
+            document.getElementById('wind-farm-min').value = filters['wind-farm-min'];
+            const km = filters['wind-farm-min'];
+            this.updateRangeDisplay('wind-farm-min', km > 0 ? `${km} km` : 'Any');
+        }
+
+        if (filters['clearing-overlay-max'] !== undefined) {
+            document.getElementById('clearing-overlay-max').value = filters['clearing-overlay-max'];
+        }
+
+        if (filters['ndvi-min'] !== undefined) {
+            document.getElementById('ndvi-min').value = filters['ndvi-min'];
+        }
+
+        if (filters['dwelling'] !== undefined) {
+            document.getElementById('dwelling').value = filters['dwelling'];
+        }
+
         // Restore isochrone overlay dropdown (but don't trigger load yet)
         if (filters['isochrone-overlay'] !== undefined) {
             document.getElementById('isochrone-overlay').value = filters['isochrone-overlay'];
         }
     },
 
+    // Save the current filters behind a share link and copy it to the clipboard
+    async share() {
+        const status = document.getElementById('share-status');
+        status.classList.remove('error');
+        status.textContent = 'Creating link...';
+        try {
+            const link = await API.createShareLink({ version: this.STORAGE_VERSION, filters: this.getUIState() });
+            const url = `${window.location.origin}${link.url}`;
+            try {
+                await navigator.clipboard.writeText(url);
+                status.textContent = `Copied ${url}`;
+            } catch (err) {
+                status.textContent = url;
+            }
+        } catch (err) {
+            console.error('[Filters] Failed to create share link:', err);
+            status.classList.add('error');
+            status.textContent = 'Could not create a share link';
+        }
+    },
+
+    // Restore the filters behind a share link in place of the saved ones,
+    // showing its isochrone overlay. Returns true if they were restored.
+    async loadShared(token) {
+        try {
+            const link = await API.getShareLink(token);
+            // Links made before a filter structure change no longer fit the UI
+            if (!this.validateSavedData(link.filters)) {
+                console.log('[Filters] Shared filters invalid, keeping current filters');
+                return false;
+            }
+            this.restoreUIState(link.filters.filters);
+            this.save();
+            const isochrone = document.getElementById('isochrone-overlay').value;
+            if (typeof PropertyMap !== 'undefined') {
+                PropertyMap.onReady(() => PropertyMap.setIsochrone(window.ANCHOR.slug, isochrone));
+            }
+            console.log('[Filters] Restored shared filters', token);
+            return true;
+        } catch (err) {
+            console.warn('[Filters] Failed to load shared filters:', err);
+            return false;
+        }
+    },
+
     // Clear saved filters from localStorage
     clearSaved() {
         try {
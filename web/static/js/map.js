@@ -6,6 +6,7 @@ const PropertyMap = {
     properties: [],  // Store properties for click lookups
     propertiesById: new Map(),  // Quick lookup by ID
     onViewDetailsCallback: null,
+    onViewGroupCallback: null,  // Called with the IDs when a click hits overlapping markers
     ready: false,     // Track if map is fully initialized
     readyCallbacks: [], // Callbacks to run when ready
     currentFilters: {},  // Current filter state for boundary loading
@@ -17,6 +18,8 @@ const PropertyMap = {
     boundariesLayerId: 'boundaries-layer',
     routeSourceId: 'route-source',
     routeLayerId: 'route-layer',
+    lotSearchSourceId: 'lot-search-source',
+    lotSearchLayerId: 'lot-search-layer',
     currentBaseLayer: 'streets',  // 'streets' or 'satellite'
     boundariesMinZoom: 12,  // Minimum zoom level to show boundaries
     boundariesLoading: false,  // Prevent concurrent boundary requests
@@ -31,6 +34,11 @@ const PropertyMap = {
         'farmbuy': '#22c55e',       // Green
         'rea': '#ef4444',           // Red
         'domain': '#8b5cf6',        // Purple
+        'elders': '#dc2626',        // Dark red
+        'raywhite-rural': '#eab308', // Yellow
+        'nutrien': '#0d9488',       // Teal
+        'gumtree': '#65a30d',       // Lime
+        'manual': '#64748b',        // Slate
         'default': '#2563eb'        // Blue
     },
 
@@ -177,6 +185,22 @@ const PropertyMap = {
                 }
             });
 
+            // Lot/DP search result outline, shown at any zoom
+            this.map.addSource(this.lotSearchSourceId, {
+                type: 'geojson',
+                data: { type: 'FeatureCollection', features: [] }
+            });
+            this.map.addLayer({
+                id: this.lotSearchLayerId,
+                type: 'line',
+                source: this.lotSearchSourceId,
+                paint: {
+                    'line-color': '#dc2626',
+                    'line-width': 3,
+                    'line-dasharray': [2, 1]
+                }
+            });
+
             // Route source (for drawing route to nearest town)
             this.map.addSource(this.routeSourceId, {
                 type: 'geojson',
@@ -237,13 +261,15 @@ const PropertyMap = {
                 this.loadBoundariesIfNeeded();
             });
 
-            // Click handler for property markers - opens sidebar directly
+            // Click handler for property markers - opens sidebar directly, or
+            // lists the properties when the click hits overlapping markers
             this.map.on('click', this.propertiesLayerId, (e) => {
                 if (e.features && e.features.length > 0) {
-                    const feature = e.features[0];
-                    const propertyId = feature.properties.id;
-                    if (this.onViewDetailsCallback) {
-                        this.onViewDetailsCallback(propertyId);
+                    const ids = [...new Set(e.features.map(f => f.properties.id))];
+                    if (ids.length > 1 && this.onViewGroupCallback) {
+                        this.onViewGroupCallback(ids);
+                    } else if (this.onViewDetailsCallback) {
+                        this.onViewDetailsCallback(ids[0]);
                     }
                 }
             });
@@ -270,10 +296,11 @@ const PropertyMap = {
     },
 
     // Add property markers to the map using GeoJSON source
-    addPropertyMarkers(properties, onViewDetails) {
+    addPropertyMarkers(properties, onViewDetails, onViewGroup) {
         this.properties = properties;
         this.propertiesById.clear();
         this.onViewDetailsCallback = onViewDetails;
+        this.onViewGroupCallback = onViewGroup || null;
 
         // Build GeoJSON features
         const features = [];
@@ -330,6 +357,18 @@ const PropertyMap = {
         }
     },
 
+    // Show the isochrone around a point (e.g. a clicked property) in the isochrone layer
+    async setIsochroneAround(lat, lng, minutes) {
+        try {
+            const geojson = await API.getIsochroneAround(lat, lng, minutes);
+            if (geojson) {
+                this.map.getSource(this.isochroneSourceId)?.setData(geojson);
+            }
+        } catch (err) {
+            console.warn('Failed to load isochrone:', err);
+        }
+    },
+
     // Get current map bounds as filter string
     getBoundsString() {
         const bounds = this.map.getBounds();
@@ -410,6 +449,41 @@ const PropertyMap = {
         }
     },
 
+    // ==================== Lot Search ====================
+
+    // Outline a searched lot (a GeoJSON Feature) and zoom to it
+    showLot(feature) {
+        this.onReady(() => {
+            const source = this.map.getSource(this.lotSearchSourceId);
+            if (source) {
+                source.setData(feature);
+            }
+
+            const bounds = new maplibregl.LngLatBounds();
+            const extend = (coords) => {
+                if (typeof coords[0] === 'number') {
+                    bounds.extend(coords);
+                } else {
+                    coords.forEach(extend);
+                }
+            };
+            extend(feature.geometry.coordinates);
+            if (!bounds.isEmpty()) {
+                this.map.fitBounds(bounds, { padding: 80, maxZoom: 16 });
+            }
+        });
+    },
+
+    // Remove the searched lot's outline
+    clearLot() {
+        this.onReady(() => {
+            const source = this.map.getSource(this.lotSearchSourceId);
+            if (source) {
+                source.setData({ type: 'FeatureCollection', features: [] });
+            }
+        });
+    },
+
     // ==================== Route Display ====================
 
     // Show route from property to a destination